@@ -26,6 +26,8 @@ type Cloud interface {
 	AttachDisk(vmCID, diskCID string) error
 	DetachDisk(vmCID, diskCID string) error
 	DeleteDisk(diskCID string) error
+	ResizeDisk(diskCID string, newSize int) error
+	Info() (CPIInfo, error)
 	fmt.Stringer
 }
 
@@ -40,6 +42,12 @@ type VMMetadata map[string]string
 
 type DiskMetadata map[string]string
 
+// CPIInfo describes stemcell formats and API version supported by the CPI
+type CPIInfo struct {
+	StemcellFormats []string
+	APIVersion      int
+}
+
 func NewCloud(
 	cpiCmdRunner CPICmdRunner,
 	directorID string,
@@ -281,6 +289,56 @@ func (c cloud) DeleteDisk(diskCID string) error {
 	return nil
 }
 
+func (c cloud) ResizeDisk(diskCID string, newSize int) error {
+	c.logger.Debug(c.logTag, "Resizing disk '%s' to %d", diskCID, newSize)
+	method := "resize_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, diskCID, newSize)
+	if err != nil {
+		return bosherr.WrapError(err, "Calling CPI 'resize_disk' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return nil
+}
+
+func (c cloud) Info() (CPIInfo, error) {
+	c.logger.Debug(c.logTag, "Getting CPI info")
+	method := "info"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method)
+	if err != nil {
+		return CPIInfo{}, bosherr.WrapError(err, "Calling CPI 'info' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return CPIInfo{}, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	result, ok := cmdOutput.Result.(map[string]interface{})
+	if !ok {
+		return CPIInfo{}, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+
+	info := CPIInfo{APIVersion: 1}
+
+	if formats, ok := result["stemcell_formats"].([]interface{}); ok {
+		for _, format := range formats {
+			if formatStr, ok := format.(string); ok {
+				info.StemcellFormats = append(info.StemcellFormats, formatStr)
+			}
+		}
+	}
+
+	// api_version is only returned by CPIs supporting API version 2 or newer
+	if apiVersion, ok := result["api_version"].(float64); ok {
+		info.APIVersion = int(apiVersion)
+	}
+
+	return info, nil
+}
+
 func (c cloud) String() string {
 	return fmt.Sprintf("Cloud{Context=%s}", c.context)
 }
@@ -590,4 +590,96 @@ var _ = Describe("Cloud", func() {
 			return cloud.DeleteDisk("fake-disk-cid")
 		})
 	})
+
+	Describe("ResizeDisk", func() {
+		It("executes the cpi job script with the correct arguments", func() {
+			err := cloud.ResizeDisk("fake-disk-cid", 2048)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.RunInputs).To(HaveLen(1))
+			Expect(fakeCPICmdRunner.RunInputs[0]).To(Equal(fakebicloud.RunInput{
+				Context: context,
+				Method:  "resize_disk",
+				Arguments: []interface{}{
+					"fake-disk-cid",
+					2048,
+				},
+			}))
+		})
+
+		Context("when the cpi command execution fails", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunErr = errors.New("fake-run-error")
+			})
+
+			It("returns an error", func() {
+				err := cloud.ResizeDisk("fake-disk-cid", 2048)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
+			})
+		})
+
+		itHandlesCPIErrors("resize_disk", func() error {
+			return cloud.ResizeDisk("fake-disk-cid", 2048)
+		})
+	})
+
+	Describe("Info", func() {
+		It("returns stemcell formats and api version supported by the cpi", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{
+					"stemcell_formats": []interface{}{"fake-format-raw", "fake-format-light"},
+					"api_version":      float64(2),
+				},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(CPIInfo{
+				StemcellFormats: []string{"fake-format-raw", "fake-format-light"},
+				APIVersion:      2,
+			}))
+
+			Expect(fakeCPICmdRunner.RunInputs).To(HaveLen(1))
+			Expect(fakeCPICmdRunner.RunInputs[0].Method).To(Equal("info"))
+		})
+
+		It("defaults api version to 1 when cpi does not return it", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{
+					"stemcell_formats": []interface{}{"fake-format-raw"},
+				},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.APIVersion).To(Equal(1))
+		})
+
+		It("returns an error when result is not a hash", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: "fake-result",
+			}
+
+			_, err := cloud.Info()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result"))
+		})
+
+		Context("when the cpi command execution fails", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunErr = errors.New("fake-run-error")
+			})
+
+			It("returns an error", func() {
+				_, err := cloud.Info()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
+			})
+		})
+
+		itHandlesCPIErrors("info", func() error {
+			_, err := cloud.Info()
+			return err
+		})
+	})
 })
@@ -11,25 +11,45 @@ type Factory interface {
 	NewCloud(installation biinstall.Installation, directorID string) (Cloud, error)
 }
 
+// LocalCPIFactory provides CPI built into the CLI for installations
+// configured with cloud_provider.local
+type LocalCPIFactory interface {
+	NewCPICmdRunner(stateDir, mbusURL string) (CPICmdRunner, error)
+}
+
 type factory struct {
-	fs        boshsys.FileSystem
-	cmdRunner boshsys.CmdRunner
-	logger    boshlog.Logger
+	fs              boshsys.FileSystem
+	cmdRunner       boshsys.CmdRunner
+	localCPIFactory LocalCPIFactory
+	logger          boshlog.Logger
 }
 
 func NewFactory(
 	fs boshsys.FileSystem,
 	cmdRunner boshsys.CmdRunner,
+	localCPIFactory LocalCPIFactory,
 	logger boshlog.Logger,
 ) Factory {
 	return &factory{
-		fs:        fs,
-		cmdRunner: cmdRunner,
-		logger:    logger,
+		fs:              fs,
+		cmdRunner:       cmdRunner,
+		localCPIFactory: localCPIFactory,
+		logger:          logger,
 	}
 }
 
 func (f *factory) NewCloud(installation biinstall.Installation, directorID string) (Cloud, error) {
+	installationManifest := installation.Manifest()
+
+	if installationManifest.UsesLocalCPI() {
+		cpiCmdRunner, err := f.localCPIFactory.NewCPICmdRunner(installationManifest.Local.StateDir, installationManifest.Mbus)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Starting local CPI")
+		}
+
+		return NewCloud(cpiCmdRunner, directorID, f.logger), nil
+	}
+
 	cpiJob := installation.Job()
 	target := installation.Target()
 	cpi := CPI{
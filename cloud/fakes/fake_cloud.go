@@ -34,6 +34,13 @@ type FakeCloud struct {
 	DeleteDiskInputs []DeleteDiskInput
 	DeleteDiskErr    error
 
+	ResizeDiskInputs []ResizeDiskInput
+	ResizeDiskErr    error
+
+	InfoCalled bool
+	InfoInfo   cloud.CPIInfo
+	InfoErr    error
+
 	DeleteStemcellInputs []DeleteStemcellInput
 	DeleteStemcellErr    error
 
@@ -87,6 +94,11 @@ type DeleteDiskInput struct {
 	DiskCID string
 }
 
+type ResizeDiskInput struct {
+	DiskCID string
+	NewSize int
+}
+
 type DeleteStemcellInput struct {
 	StemcellCID string
 }
@@ -95,6 +107,7 @@ func NewFakeCloud() *FakeCloud {
 	return &FakeCloud{
 		CreateStemcellInputs: []CreateStemcellInput{},
 		DeleteDiskInputs:     []DeleteDiskInput{},
+		ResizeDiskInputs:     []ResizeDiskInput{},
 	}
 }
 
@@ -195,6 +208,19 @@ func (c *FakeCloud) DeleteDisk(diskCID string) error {
 	return c.DeleteDiskErr
 }
 
+func (c *FakeCloud) ResizeDisk(diskCID string, newSize int) error {
+	c.ResizeDiskInputs = append(c.ResizeDiskInputs, ResizeDiskInput{
+		DiskCID: diskCID,
+		NewSize: newSize,
+	})
+	return c.ResizeDiskErr
+}
+
+func (c *FakeCloud) Info() (cloud.CPIInfo, error) {
+	c.InfoCalled = true
+	return c.InfoInfo, c.InfoErr
+}
+
 func (c *FakeCloud) String() string {
 	return "FakeCloud{}"
 }
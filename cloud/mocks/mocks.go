@@ -125,6 +125,27 @@ func (_mr *_MockCloudRecorder) HasVM(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "HasVM", arg0)
 }
 
+func (_m *MockCloud) Info() (cloud.CPIInfo, error) {
+	ret := _m.ctrl.Call(_m, "Info")
+	ret0, _ := ret[0].(cloud.CPIInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockCloudRecorder) Info() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Info")
+}
+
+func (_m *MockCloud) ResizeDisk(_param0 string, _param1 int) error {
+	ret := _m.ctrl.Call(_m, "ResizeDisk", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCloudRecorder) ResizeDisk(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ResizeDisk", arg0, arg1)
+}
+
 func (_m *MockCloud) SetDiskMetadata(_param0 string, _param1 cloud.DiskMetadata) error {
 	ret := _m.ctrl.Call(_m, "SetDiskMetadata", _param0, _param1)
 	ret0, _ := ret[0].(error)
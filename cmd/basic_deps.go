@@ -9,6 +9,7 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+	bitemplateerb "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
@@ -22,6 +23,7 @@ type BasicDeps struct {
 	Compressor               boshcmd.Compressor
 	DigestCalculator         bicrypto.DigestCalculator
 	DigestCreationAlgorithms []boshcrypto.Algorithm
+	ERBRenderer              bitemplateerb.ERBRenderer
 
 	Time clock.Clock
 }
@@ -46,7 +48,8 @@ func NewBasicDepsWithFS(ui *boshui.ConfUI, fs boshsys.FileSystem, logger boshlog
 		Compressor:               boshcmd.NewTarballCompressor(cmdRunner, fs),
 		DigestCalculator:         digestCalculator,
 		DigestCreationAlgorithms: digestCreationAlgorithms,
-		Time: clock.NewClock(),
+		ERBRenderer:              bitemplateerb.NewERBRenderer(fs, cmdRunner, logger),
+		Time:                     clock.NewClock(),
 	}
 }
 
@@ -57,3 +60,8 @@ func (b BasicDeps) WithSha2CheckSumming() BasicDeps {
 	b.DigestCalculator = bicrypto.NewDigestCalculator(b.FS, b.DigestCreationAlgorithms)
 	return b
 }
+
+func (b BasicDeps) WithNativeERBRendering() BasicDeps {
+	b.ERBRenderer = bitemplateerb.NewNativeERBRenderer(b.FS, b.Logger)
+	return b
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type BlobsPruneRemoteCmd struct {
+	blobsAuditor boshreldir.BlobsAuditor
+	ui           boshui.UI
+}
+
+func NewBlobsPruneRemoteCmd(blobsAuditor boshreldir.BlobsAuditor, ui boshui.UI) BlobsPruneRemoteCmd {
+	return BlobsPruneRemoteCmd{blobsAuditor: blobsAuditor, ui: ui}
+}
+
+func (c BlobsPruneRemoteCmd) Run(opts BlobsPruneRemoteOpts) error {
+	// blobs referenced only by other branches or previous commits look unreferenced
+	if opts.Delete && !opts.IgnoreOtherBranches {
+		return bosherr.Error("Expected '--ignore-other-branches' to be specified with '--delete' " +
+			"since blobs referenced only by other branches or previous commits would be deleted")
+	}
+
+	blobIDs, referencedCount, err := c.blobsAuditor.UnreferencedBlobs()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "unreferenced blobs",
+
+		Header: []boshtbl.Header{boshtbl.NewHeader("Blobstore ID")},
+	}
+
+	for _, blobID := range blobIDs {
+		table.Rows = append(table.Rows, []boshtbl.Value{boshtbl.NewValueString(blobID)})
+	}
+
+	c.ui.PrintTable(table)
+
+	c.ui.PrintLinef("Referenced blobs: %d", referencedCount)
+	c.ui.PrintLinef("Unreferenced blobs: %d", len(blobIDs))
+
+	if !opts.Delete || len(blobIDs) == 0 {
+		return nil
+	}
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	for _, blobID := range blobIDs {
+		err := c.blobsAuditor.DeleteBlob(blobID)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Pruning blobstore")
+		}
+	}
+
+	c.ui.PrintLinef("Deleted %d unreferenced blob(s)", len(blobIDs))
+
+	return nil
+}
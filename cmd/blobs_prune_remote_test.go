@@ -0,0 +1,127 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakereldir "github.com/cloudfoundry/bosh-cli/releasedir/releasedirfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("BlobsPruneRemoteCmd", func() {
+	var (
+		blobsAuditor *fakereldir.FakeBlobsAuditor
+		ui           *fakeui.FakeUI
+		command      BlobsPruneRemoteCmd
+	)
+
+	BeforeEach(func() {
+		blobsAuditor = &fakereldir.FakeBlobsAuditor{}
+		ui = &fakeui.FakeUI{}
+		command = NewBlobsPruneRemoteCmd(blobsAuditor, ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts BlobsPruneRemoteOpts
+		)
+
+		BeforeEach(func() {
+			opts = BlobsPruneRemoteOpts{}
+			blobsAuditor.UnreferencedBlobsReturns([]string{"blob-id1", "blob-id2"}, 3, nil)
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("shows unreferenced blobs without deleting them", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "unreferenced blobs",
+
+				Header: []boshtbl.Header{boshtbl.NewHeader("Blobstore ID")},
+
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("blob-id1")},
+					{boshtbl.NewValueString("blob-id2")},
+				},
+			}))
+
+			Expect(ui.Said).To(Equal([]string{"Referenced blobs: 3", "Unreferenced blobs: 2"}))
+
+			Expect(ui.AskedConfirmationCalled).To(BeFalse())
+			Expect(blobsAuditor.DeleteBlobCallCount()).To(Equal(0))
+		})
+
+		Context("when deleting", func() {
+			BeforeEach(func() {
+				opts.Delete = true
+				opts.IgnoreOtherBranches = true
+			})
+
+			It("requires acknowledging that blobs of other branches may be deleted", func() {
+				opts.IgnoreOtherBranches = false
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected '--ignore-other-branches' to be specified with '--delete'"))
+
+				Expect(blobsAuditor.UnreferencedBlobsCallCount()).To(Equal(0))
+				Expect(blobsAuditor.DeleteBlobCallCount()).To(Equal(0))
+			})
+
+			It("deletes unreferenced blobs after confirmation", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.AskedConfirmationCalled).To(BeTrue())
+				Expect(blobsAuditor.DeleteBlobCallCount()).To(Equal(2))
+				Expect(blobsAuditor.DeleteBlobArgsForCall(0)).To(Equal("blob-id1"))
+				Expect(blobsAuditor.DeleteBlobArgsForCall(1)).To(Equal("blob-id2"))
+				Expect(ui.Said).To(Equal([]string{
+					"Referenced blobs: 3",
+					"Unreferenced blobs: 2",
+					"Deleted 2 unreferenced blob(s)",
+				}))
+			})
+
+			It("does not delete blobs if confirmation is rejected", func() {
+				ui.AskedConfirmationErr = errors.New("stop")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("stop"))
+				Expect(blobsAuditor.DeleteBlobCallCount()).To(Equal(0))
+			})
+
+			It("does not ask for confirmation if there is nothing to delete", func() {
+				blobsAuditor.UnreferencedBlobsReturns(nil, 3, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+			})
+
+			It("returns error if deleting fails", func() {
+				blobsAuditor.DeleteBlobReturns(errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+		})
+
+		It("returns error if finding unreferenced blobs fails", func() {
+			blobsAuditor.UnreferencedBlobsReturns(nil, 0, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
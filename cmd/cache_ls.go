@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CacheLsCmd struct {
+	cache bitarball.Cache
+	ui    boshui.UI
+}
+
+func NewCacheLsCmd(cache bitarball.Cache, ui boshui.UI) CacheLsCmd {
+	return CacheLsCmd{cache: cache, ui: ui}
+}
+
+func (c CacheLsCmd) Run(_ CacheLsOpts) error {
+	entries, err := c.cache.List()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing tarball cache")
+	}
+
+	c.ui.PrintTable(CacheTable{Entries: entries}.AsTable())
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CachePruneCmd struct {
+	cache       bitarball.Cache
+	timeService clock.Clock
+	ui          boshui.UI
+}
+
+func NewCachePruneCmd(cache bitarball.Cache, timeService clock.Clock, ui boshui.UI) CachePruneCmd {
+	return CachePruneCmd{cache: cache, timeService: timeService, ui: ui}
+}
+
+func (c CachePruneCmd) Run(opts CachePruneOpts) error {
+	entries, err := c.cache.List()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing tarball cache")
+	}
+
+	pruned, err := pruneEntries(cachePrunableEntries{c.cache, entries}, opts.OlderThan, opts.All, c.timeService)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Pruning tarball cache")
+	}
+
+	var prunedEntries []bitarball.CacheEntry
+
+	for _, i := range pruned {
+		prunedEntries = append(prunedEntries, entries[i])
+	}
+
+	c.ui.PrintTable(CacheTable{Entries: prunedEntries}.AsTable())
+
+	return nil
+}
+
+type cachePrunableEntries struct {
+	cache   bitarball.Cache
+	entries []bitarball.CacheEntry
+}
+
+func (e cachePrunableEntries) Len() int             { return len(e.entries) }
+func (e cachePrunableEntries) Time(i int) time.Time { return e.entries[i].LastUsedAt }
+func (e cachePrunableEntries) Delete(i int) error   { return e.cache.Delete(e.entries[i]) }
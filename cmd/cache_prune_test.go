@@ -0,0 +1,92 @@
+package cmd_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("CachePruneCmd", func() {
+	var (
+		ui          *fakeui.FakeUI
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		cache       bitarball.Cache
+		command     CachePruneCmd
+
+		oldSource bideplmanifest.StemcellRef
+		newSource bideplmanifest.StemcellRef
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+		cache = bitarball.NewCache("/cache", fs, timeService, boshlog.NewLogger(boshlog.LevelNone))
+		command = NewCachePruneCmd(cache, timeService, ui)
+
+		oldSource = bideplmanifest.StemcellRef{URL: "https://example.com/old.tgz", SHA1: "oldsha1"}
+		newSource = bideplmanifest.StemcellRef{URL: "https://example.com/new.tgz", SHA1: "newsha1"}
+
+		fs.WriteFileString("/old.tgz", "old")
+		Expect(cache.Save("/old.tgz", oldSource)).To(Succeed())
+
+		timeService.Increment(48 * time.Hour)
+
+		fs.WriteFileString("/new.tgz", "new")
+		Expect(cache.Save("/new.tgz", newSource)).To(Succeed())
+	})
+
+	Describe("Run", func() {
+		var (
+			opts CachePruneOpts
+		)
+
+		BeforeEach(func() {
+			opts = CachePruneOpts{}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("requires either --older-than or --all", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected '--older-than' or '--all' to be specified"))
+
+			Expect(cache.List()).To(HaveLen(2))
+		})
+
+		It("removes all tarballs when --all is specified", func() {
+			opts.All = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cache.List()).To(BeEmpty())
+			Expect(ui.Table.Rows).To(HaveLen(2))
+		})
+
+		It("removes only tarballs not used within given duration", func() {
+			opts.OlderThan = 24 * time.Hour
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(cache.Path(oldSource))).To(BeFalse())
+			Expect(fs.FileExists(cache.Path(newSource))).To(BeTrue())
+
+			Expect(ui.Table.Rows).To(HaveLen(1))
+			Expect(ui.Table.Rows[0][2]).To(Equal(boshtbl.NewValueString("https://example.com/old.tgz")))
+		})
+	})
+})
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type CacheTable struct {
+	Entries []bitarball.CacheEntry
+}
+
+func (t CacheTable) AsTable() boshtbl.Table {
+	table := boshtbl.Table{
+		Content: "tarballs",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("File"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("URL"),
+			boshtbl.NewHeader("Last used"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{{Column: 3, Asc: true}},
+	}
+
+	for _, entry := range t.Entries {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(filepath.Base(entry.Path)),
+			boshtbl.NewValueBytes(uint64(entry.Size)),
+			boshtbl.NewValueString(entry.URL),
+			boshtbl.NewValueTime(entry.LastUsedAt),
+		})
+	}
+
+	return table
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CacheWarmCmd struct {
+	ui               boshui.UI
+	tarballProvider  bitarball.Provider
+	releaseSetParser birelsetmanifest.Parser
+	templateFactory  bidepltpl.DeploymentTemplateFactory
+	deploymentParser bideplmanifest.Parser
+}
+
+func NewCacheWarmCmd(
+	ui boshui.UI,
+	tarballProvider bitarball.Provider,
+	releaseSetParser birelsetmanifest.Parser,
+	templateFactory bidepltpl.DeploymentTemplateFactory,
+	deploymentParser bideplmanifest.Parser,
+) CacheWarmCmd {
+	return CacheWarmCmd{
+		ui:               ui,
+		tarballProvider:  tarballProvider,
+		releaseSetParser: releaseSetParser,
+		templateFactory:  templateFactory,
+		deploymentParser: deploymentParser,
+	}
+}
+
+func (c CacheWarmCmd) Run(stage boshui.Stage, opts CacheWarmOpts) error {
+	path := opts.Args.Manifest.Path
+	vars := opts.VarFlags.AsReadOnlyVariables()
+	op := opts.OpsFlags.AsOp()
+
+	c.ui.BeginLinef("Deployment manifest: '%s'\n", path)
+
+	releaseSetManifest, err := c.releaseSetParser.Parse(path, vars, op)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing release set manifest '%s'", path)
+	}
+
+	var sources []bitarball.Source
+
+	for _, releaseRef := range releaseSetManifest.Releases {
+		sources = append(sources, releaseRef)
+	}
+
+	template, err := c.templateFactory.NewDeploymentTemplateFromPath(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	interpolatedTemplate, err := template.Evaluate(vars, op)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest '%s'", path)
+	}
+
+	deploymentManifest, err := c.deploymentParser.Parse(interpolatedTemplate, path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing deployment manifest '%s'", path)
+	}
+
+	stemcellRef, err := deploymentManifest.Stemcell(deploymentManifest.JobName())
+	if err != nil {
+		return err
+	}
+
+	sources = append(sources, stemcellRef)
+
+	// file:// sources are not downloaded and are left as is by the provider
+	for _, source := range sources {
+		_, err = c.tarballProvider.Get(source, stage)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
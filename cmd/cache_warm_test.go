@@ -0,0 +1,139 @@
+package cmd_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	mock_tarball "github.com/cloudfoundry/bosh-cli/installation/tarball/mocks"
+	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("CacheWarmCmd", func() {
+	var (
+		mockCtrl            *gomock.Controller
+		ui                  *fakeui.FakeUI
+		fs                  *fakesys.FakeFileSystem
+		stage               *fakeui.FakeStage
+		mockTarballProvider *mock_tarball.MockProvider
+		command             CacheWarmCmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		stage = fakeui.NewFakeStage()
+		mockTarballProvider = mock_tarball.NewMockProvider(mockCtrl)
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		command = NewCacheWarmCmd(
+			ui,
+			mockTarballProvider,
+			birelsetmanifest.NewParser(fs, logger, birelsetmanifest.NewValidator(logger)),
+			bidepltpl.NewDeploymentTemplateFactory(fs),
+			bideplmanifest.NewParser(fs, logger),
+		)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			opts CacheWarmOpts
+		)
+
+		BeforeEach(func() {
+			opts = CacheWarmOpts{
+				Args: CacheWarmArgs{
+					Manifest: FileBytesWithPathArg{Path: "/manifest.yml"},
+				},
+				VarFlags: VarFlags{
+					VarKVs: []boshtpl.VarKV{{Name: "release_sha1", Value: "fake-release-sha1"}},
+				},
+			}
+
+			fs.WriteFileString("/manifest.yml", `---
+name: test-deployment
+
+releases:
+- name: fake-release
+  url: https://example.com/fake-release.tgz
+  sha1: ((release_sha1))
+- name: fake-local-release
+  url: file:///fake-local-release.tgz
+
+resource_pools:
+- name: resource-pool-1
+  network: network-1
+  stemcell:
+    url: https://example.com/fake-stemcell.tgz
+    sha1: fake-stemcell-sha1
+
+networks:
+- name: network-1
+  type: dynamic
+
+jobs:
+- name: fake-job
+  instances: 1
+  resource_pool: resource-pool-1
+  networks:
+  - name: network-1
+`)
+		})
+
+		act := func() error { return command.Run(stage, opts) }
+
+		It("downloads releases and stemcell referenced by the manifest", func() {
+			gomock.InOrder(
+				mockTarballProvider.EXPECT().Get(birelmanifest.ReleaseRef{
+					Name: "fake-release",
+					URL:  "https://example.com/fake-release.tgz",
+					SHA1: "fake-release-sha1",
+				}, stage).Return("/fake-release-path", nil),
+				mockTarballProvider.EXPECT().Get(birelmanifest.ReleaseRef{
+					Name: "fake-local-release",
+					URL:  "file:///fake-local-release.tgz",
+				}, stage).Return("/fake-local-release.tgz", nil),
+				mockTarballProvider.EXPECT().Get(bideplmanifest.StemcellRef{
+					URL:  "https://example.com/fake-stemcell.tgz",
+					SHA1: "fake-stemcell-sha1",
+				}, stage).Return("/fake-stemcell-path", nil),
+			)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if download fails", func() {
+			mockTarballProvider.EXPECT().Get(gomock.Any(), stage).Return("", errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+
+		It("returns error if manifest cannot be parsed", func() {
+			fs.WriteFileString("/manifest.yml", "-")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing release set manifest '/manifest.yml'"))
+		})
+	})
+})
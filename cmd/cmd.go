@@ -7,18 +7,27 @@ import (
 	"github.com/cppforlife/go-patch/patch"
 
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	"github.com/cloudfoundry/bosh-cli/crypto"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	boshrellint "github.com/cloudfoundry/bosh-cli/release/lint"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
-	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
 
 	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 )
 
@@ -56,6 +65,10 @@ func (c Cmd) Execute() (cmdErr error) {
 		c.deps = c.deps.WithSha2CheckSumming()
 	}
 
+	if c.BoshOpts.NativeERB {
+		c.deps = c.deps.WithNativeERBRendering()
+	}
+
 	deps := c.deps
 
 	switch opts := c.Opts.(type) {
@@ -67,7 +80,7 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *CreateEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op).Preparer()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.Parallel, opts.CompiledPackageCache).Preparer()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
@@ -75,12 +88,56 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *DeleteEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentDeleter {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op).Deleter()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, 1, "").Deleter()
 		}
 
 		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
 		return NewDeleteCmd(deps.UI, envProvider).Run(stage, *opts)
 
+	case *CompiledCacheExportOpts:
+		return NewCompiledCacheExportCmd(c.compiledPackageCache(opts.CompiledCacheFlags), deps.UI).Run(*opts)
+
+	case *CompiledCacheImportOpts:
+		return NewCompiledCacheImportCmd(c.compiledPackageCache(opts.CompiledCacheFlags), deps.UI).Run(*opts)
+
+	case *CompiledCachePruneOpts:
+		return NewCompiledCachePruneCmd(c.compiledPackageCache(opts.CompiledCacheFlags), deps.Time, deps.UI).Run(*opts)
+
+	case *CacheLsOpts:
+		return NewCacheLsCmd(newTarballCache(deps), deps.UI).Run(*opts)
+
+	case *CachePruneOpts:
+		return NewCachePruneCmd(newTarballCache(deps), deps.Time, deps.UI).Run(*opts)
+
+	case *CacheWarmOpts:
+		releaseSetParser := birelsetmanifest.NewParser(deps.FS, deps.Logger, birelsetmanifest.NewValidator(deps.Logger))
+
+		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
+		return NewCacheWarmCmd(
+			deps.UI,
+			newTarballProvider(deps, newTarballCache(deps)),
+			releaseSetParser,
+			bidepltpl.NewDeploymentTemplateFactory(deps.FS),
+			bideplmanifest.NewParser(deps.FS, deps.Logger),
+		).Run(stage, *opts)
+
+	case *StateShowOpts:
+		return NewStateShowCmd(c.deploymentStateService(opts.Args.Path), deps.UI).Run(*opts)
+
+	case *StateSetVMCIDOpts:
+		return NewStateSetVMCIDCmd(c.deploymentStateEditor()).Run(*opts)
+
+	case *StateForgetDiskOpts:
+		return NewStateForgetDiskCmd(c.deploymentStateEditor()).Run(*opts)
+
+	case *StateForgetStemcellOpts:
+		return NewStateForgetStemcellCmd(c.deploymentStateEditor()).Run(*opts)
+
+	case *StateMigrateOpts:
+		stateService := c.deploymentStateService(opts.Args.Path)
+		migrator := biconfig.NewLegacyDeploymentStateMigrator(stateService, deps.FS, deps.UUIDGen, deps.Logger)
+		return NewStateMigrateCmd(stateService, migrator, deps.FS, deps.Time, deps.UI).Run(*opts)
+
 	case *AliasEnvOpts:
 		sessionFactory := func(config cmdconf.Config) Session {
 			return NewSessionFromOpts(c.BoshOpts, config, deps.UI, true, false, deps.FS, deps.Logger)
@@ -112,8 +169,8 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewLogOutCmd(sess.Environment(), config, deps.UI).Run()
 
 	case *TaskOpts:
-		eventsTaskReporter := boshuit.NewReporter(deps.UI, true)
-		plainTaskReporter := boshuit.NewReporter(deps.UI, false)
+		eventsTaskReporter := NewTaskReporter(c.BoshOpts, deps.UI, true)
+		plainTaskReporter := NewTaskReporter(c.BoshOpts, deps.UI, false)
 		return NewTaskCmd(eventsTaskReporter, plainTaskReporter, c.director()).Run(*opts)
 
 	case *TasksOpts:
@@ -221,6 +278,15 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *InterpolateOpts:
 		return NewInterpolateCmd(deps.UI).Run(*opts)
 
+	case *VarsStoreEncryptOpts:
+		return NewVarsStoreEncryptCmd(deps.FS).Run(*opts)
+
+	case *VarsStoreDecryptOpts:
+		return NewVarsStoreDecryptCmd(deps.FS).Run(*opts)
+
+	case *VarsStoreRekeyOpts:
+		return NewVarsStoreRekeyCmd(deps.FS).Run(*opts)
+
 	case *CloudConfigOpts:
 		return NewCloudConfigCmd(deps.UI, c.director()).Run()
 
@@ -242,9 +308,44 @@ func (c Cmd) Execute() (cmdErr error) {
 		releaseManager := c.releaseManager(director, parallelUploads)
 		return NewUpdateRuntimeConfigCmd(deps.UI, director, releaseManager).Run(*opts)
 
+	case *DiffConfigOpts:
+		directorFunc := func() (boshdir.Director, error) { return c.session().Director() }
+		return NewDiffConfigCmd(deps.UI, deps.FS, boshdirman.NewDiffer(), directorFunc).Run(*opts)
+
 	case *ManifestOpts:
 		return NewManifestCmd(deps.UI, c.deployment()).Run()
 
+	case *DiffManifestOpts:
+		deploymentFunc := func(name string) (boshdir.Deployment, error) {
+			if len(name) == 0 {
+				return c.session().Deployment()
+			}
+
+			director, err := c.session().Director()
+			if err != nil {
+				return nil, err
+			}
+
+			return director.FindDeployment(name)
+		}
+
+		directorFunc := func() (boshdir.Director, error) { return c.session().Director() }
+
+		return NewDiffManifestCmd(deps.UI, deps.FS, boshdirman.NewDiffer(), deploymentFunc, directorFunc).Run(*opts)
+
+	case *LintManifestOpts:
+		return NewLintManifestCmd(deps.UI, boshdirman.NewLinter()).Run(*opts)
+
+	case *ValidatePropertiesOpts:
+		return NewValidatePropertiesCmd(
+			c.localReleaseReader(), boshdirman.NewPropertiesValidator(), deps.UI).Run(*opts)
+
+	case *RenderTemplatesOpts:
+		jobRenderer := bitemplate.NewJobRenderer(deps.ERBRenderer, deps.FS, deps.UUIDGen, deps.Logger)
+
+		return NewRenderTemplatesCmd(
+			c.localReleaseReader(), jobRenderer, deps.UUIDGen, deps.FS, deps.UI, deps.Logger).Run(*opts)
+
 	case *EventsOpts:
 		return NewEventsCmd(deps.UI, c.director()).Run(*opts)
 
@@ -254,6 +355,20 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *InspectReleaseOpts:
 		return NewInspectReleaseCmd(deps.UI, c.director()).Run(*opts)
 
+	case *DiffReleaseOpts:
+		relProv, relDirProv := c.releaseProviders()
+
+		releaseDirFactory := func(dir DirOrCWDArg) boshreldir.ReleaseDir {
+			return relDirProv.NewFSReleaseDir(dir.Path)
+		}
+
+		jobReader := boshjob.NewArchiveReaderImpl(true, deps.Compressor, deps.FS)
+
+		directorFunc := func() (boshdir.Director, error) { return c.session().Director() }
+
+		return NewDiffReleaseCmd(
+			relProv.NewExtractingArchiveReader(), releaseDirFactory, jobReader, directorFunc, deps.FS, deps.UI).Run(*opts)
+
 	case *VMsOpts:
 		return NewVMsCmd(deps.UI, c.director()).Run(*opts)
 
@@ -332,6 +447,13 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *VendorPackageOpts:
 		return NewVendorPackageCmd(c.releaseDir, deps.UI).Run(*opts)
 
+	case *LintReleaseOpts:
+		relProv, _ := c.releaseProviders()
+		dirPath := opts.Directory.Path
+		linter := boshrellint.NewDirLinter(
+			dirPath, relProv.NewJobDirReader(dirPath), relProv.NewPackageDirReader(dirPath), deps.FS)
+		return NewLintReleaseCmd(linter, deps.UI).Run()
+
 	case *FinalizeReleaseOpts:
 		_, relDirProv := c.releaseProviders()
 		releaseReader := relDirProv.NewReleaseReader(opts.Directory.Path)
@@ -375,8 +497,14 @@ func (c Cmd) Execute() (cmdErr error) {
 		).Run(opts.Args)
 
 	case *BlobsOpts:
+		if opts.Verify {
+			return NewVerifyBlobsCmd(c.blobsAuditor(opts.Directory), deps.UI).Run()
+		}
 		return NewBlobsCmd(c.blobsDir(opts.Directory), deps.UI).Run()
 
+	case *BlobsPruneRemoteOpts:
+		return NewBlobsPruneRemoteCmd(c.blobsAuditor(opts.Directory), deps.UI).Run(*opts)
+
 	case *AddBlobOpts:
 		return NewAddBlobCmd(c.blobsDir(opts.Directory), deps.FS, deps.UI).Run(*opts)
 
@@ -411,6 +539,10 @@ func (c Cmd) configureUI() {
 		c.deps.UI.EnableJSON()
 	}
 
+	if c.BoshOpts.JSONStreamOpt {
+		c.deps.UI.EnableJSONStream()
+	}
+
 	if c.BoshOpts.NonInteractiveOpt {
 		c.deps.UI.EnableNonInteractive()
 	}
@@ -508,16 +640,47 @@ func (c Cmd) releaseManager(director boshdir.Director, parallelUploads int) Rele
 	return NewReleaseManager(createReleaseCmd, uploadReleaseCmd, parallelUploads)
 }
 
+func (c Cmd) localReleaseReader() LocalReleaseReader {
+	relProv, relDirProv := c.releaseProviders()
+
+	releaseDirFactory := func(path string) (boshrel.Reader, boshreldir.ReleaseDir) {
+		return relProv.NewDirReader(path), relDirProv.NewFSReleaseDir(path)
+	}
+
+	return NewLocalReleaseReader(relProv.NewExtractingArchiveReader(), releaseDirFactory, c.deps.FS)
+}
+
 func (c Cmd) blobsDir(dir DirOrCWDArg) boshreldir.BlobsDir {
 	_, relDirProv := c.releaseProviders()
 	return relDirProv.NewFSBlobsDir(dir.Path)
 }
 
+func (c Cmd) blobsAuditor(dir DirOrCWDArg) boshreldir.BlobsAuditor {
+	_, relDirProv := c.releaseProviders()
+	return relDirProv.NewFSBlobsAuditor(dir.Path)
+}
+
 func (c Cmd) releaseDir(dir DirOrCWDArg) boshreldir.ReleaseDir {
 	_, relDirProv := c.releaseProviders()
 	return relDirProv.NewFSReleaseDir(dir.Path)
 }
 
+func (c Cmd) compiledPackageCache(flags CompiledCacheFlags) bistatepkg.CompiledPackageCache {
+	if len(flags.Cache) == 0 {
+		c.panicIfErr(bosherr.Error("Expected compiled package cache to be specified via '--cache' or BOSH_COMPILED_PACKAGE_CACHE"))
+	}
+
+	return bistatepkg.NewCompiledPackageCache(flags.Cache, c.deps.FS, c.deps.Compressor, c.deps.Time)
+}
+
+func (c Cmd) deploymentStateService(path string) biconfig.DeploymentStateService {
+	return biconfig.NewDeploymentStateService(c.deps.FS, c.deps.UUIDGen, c.deps.Logger, path)
+}
+
+func (c Cmd) deploymentStateEditor() DeploymentStateEditor {
+	return NewDeploymentStateEditor(c.deploymentStateService, c.deps.Time, c.deps.UI, c.deps.Logger)
+}
+
 func (c Cmd) panicIfErr(err error) {
 	if err != nil {
 		panic(cmdConveniencePanic{err})
@@ -2,6 +2,7 @@ package cmdfakes
 
 import (
 	biinstallation "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -17,6 +18,10 @@ func (f *FakeInstallation) Job() biinstallation.InstalledJob {
 	return biinstallation.InstalledJob{}
 }
 
+func (f *FakeInstallation) Manifest() biinstallmanifest.Manifest {
+	return biinstallmanifest.Manifest{}
+}
+
 func (f *FakeInstallation) WithRunningRegistry(logger boshlog.Logger, stage biui.Stage, fn func() error) error {
 	return fn()
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CompiledCacheExportCmd struct {
+	cache bistatepkg.CompiledPackageCache
+	ui    boshui.UI
+}
+
+func NewCompiledCacheExportCmd(cache bistatepkg.CompiledPackageCache, ui boshui.UI) CompiledCacheExportCmd {
+	return CompiledCacheExportCmd{cache: cache, ui: ui}
+}
+
+func (c CompiledCacheExportCmd) Run(opts CompiledCacheExportOpts) error {
+	entries, err := c.cache.Export(opts.Args.Path.ExpandedPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Exporting compiled package cache")
+	}
+
+	c.ui.PrintTable(CompiledCacheTable{Entries: entries}.AsTable())
+
+	c.ui.PrintLinef("Exported compiled packages to '%s'", opts.Args.Path.ExpandedPath)
+
+	return nil
+}
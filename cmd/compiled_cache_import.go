@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CompiledCacheImportCmd struct {
+	cache bistatepkg.CompiledPackageCache
+	ui    boshui.UI
+}
+
+func NewCompiledCacheImportCmd(cache bistatepkg.CompiledPackageCache, ui boshui.UI) CompiledCacheImportCmd {
+	return CompiledCacheImportCmd{cache: cache, ui: ui}
+}
+
+func (c CompiledCacheImportCmd) Run(opts CompiledCacheImportOpts) error {
+	entries, err := c.cache.Import(opts.Args.Path.ExpandedPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Importing compiled package cache")
+	}
+
+	c.ui.PrintTable(CompiledCacheTable{Entries: entries}.AsTable())
+
+	return nil
+}
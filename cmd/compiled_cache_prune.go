@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CompiledCachePruneCmd struct {
+	cache       bistatepkg.CompiledPackageCache
+	timeService clock.Clock
+	ui          boshui.UI
+}
+
+func NewCompiledCachePruneCmd(cache bistatepkg.CompiledPackageCache, timeService clock.Clock, ui boshui.UI) CompiledCachePruneCmd {
+	return CompiledCachePruneCmd{cache: cache, timeService: timeService, ui: ui}
+}
+
+func (c CompiledCachePruneCmd) Run(opts CompiledCachePruneOpts) error {
+	entries, err := c.cache.List()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing compiled package cache")
+	}
+
+	pruned, err := pruneEntries(compiledCachePrunableEntries{c.cache, entries}, opts.OlderThan, opts.All, c.timeService)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Pruning compiled package cache")
+	}
+
+	var prunedEntries []bistatepkg.CompiledPackageCacheEntry
+
+	for _, i := range pruned {
+		prunedEntries = append(prunedEntries, entries[i])
+	}
+
+	c.ui.PrintTable(CompiledCacheTable{Entries: prunedEntries}.AsTable())
+
+	return nil
+}
+
+type compiledCachePrunableEntries struct {
+	cache   bistatepkg.CompiledPackageCache
+	entries []bistatepkg.CompiledPackageCacheEntry
+}
+
+func (e compiledCachePrunableEntries) Len() int             { return len(e.entries) }
+func (e compiledCachePrunableEntries) Time(i int) time.Time { return e.entries[i].CreatedAt }
+func (e compiledCachePrunableEntries) Delete(i int) error   { return e.cache.Delete(e.entries[i]) }
@@ -0,0 +1,118 @@
+package cmd_test
+
+import (
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshrelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("CompiledCachePruneCmd", func() {
+	var (
+		ui          *fakeui.FakeUI
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		cache       bistatepkg.CompiledPackageCache
+		command     CompiledCachePruneCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+		cache = bistatepkg.NewCompiledPackageCache("/cache", fs, fakecmd.NewFakeCompressor(), timeService)
+		command = NewCompiledCachePruneCmd(cache, timeService, ui)
+
+		fs.WriteFileString("/compiled.tgz", "compiled-content")
+
+		oldPkg := boshrelpkg.NewPackage(NewResource("old-pkg", "old-fp", nil), nil)
+		Expect(cache.Save(oldPkg, "stemcell/1", "/compiled.tgz", "sha1")).To(Succeed())
+
+		timeService.Increment(48 * time.Hour)
+
+		newPkg := boshrelpkg.NewPackage(NewResource("new-pkg", "new-fp", nil), nil)
+		Expect(cache.Save(newPkg, "stemcell/1", "/compiled.tgz", "sha1")).To(Succeed())
+	})
+
+	Describe("Run", func() {
+		var (
+			opts CompiledCachePruneOpts
+		)
+
+		BeforeEach(func() {
+			opts = CompiledCachePruneOpts{}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("requires either --older-than or --all", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected '--older-than' or '--all' to be specified"))
+
+			Expect(cache.List()).To(HaveLen(2))
+		})
+
+		It("does not allow both --older-than and --all", func() {
+			opts.OlderThan = 24 * time.Hour
+			opts.All = true
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected only one of '--older-than' and '--all' to be specified"))
+
+			Expect(cache.List()).To(HaveLen(2))
+		})
+
+		It("removes all packages when --all is specified", func() {
+			opts.All = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cache.List()).To(BeEmpty())
+			Expect(ui.Table.Rows).To(HaveLen(2))
+		})
+
+		It("removes only packages cached more than given duration ago", func() {
+			opts.OlderThan = 24 * time.Hour
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name).To(Equal("new-pkg"))
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("old-pkg"),
+					boshtbl.NewValueString("old-fp"),
+					boshtbl.NewValueString("stemcell/1"),
+					boshtbl.NewValueTime(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)),
+				},
+			}))
+		})
+
+		It("returns error if cache entry cannot be read", func() {
+			opts.All = true
+			fs.WriteFileString("/cache/"+strings.Repeat("0", 64)+".json", "-")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Listing compiled package cache"))
+		})
+	})
+})
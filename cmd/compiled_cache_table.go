@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type CompiledCacheTable struct {
+	Entries []bistatepkg.CompiledPackageCacheEntry
+}
+
+func (t CompiledCacheTable) AsTable() boshtbl.Table {
+	table := boshtbl.Table{
+		Content: "compiled packages",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Fingerprint"),
+			boshtbl.NewHeader("Stemcell"),
+			boshtbl.NewHeader("Created"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0, Asc: true},
+			{Column: 2, Asc: true},
+		},
+	}
+
+	for _, entry := range t.Entries {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(entry.Name),
+			boshtbl.NewValueString(entry.Fingerprint),
+			boshtbl.NewValueString(entry.Stemcell),
+			boshtbl.NewValueTime(entry.CreatedAt),
+		})
+	}
+
+	return table
+}
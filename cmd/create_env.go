@@ -22,5 +22,13 @@ func (c *CreateEnvCmd) Run(stage boshui.Stage, opts CreateEnvOpts) error {
 	depPreparer := c.envProvider(
 		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
 
-	return depPreparer.PrepareDeployment(stage, opts.Recreate)
+	if opts.Plan {
+		return depPreparer.PlanDeployment(stage, opts.Recreate)
+	}
+
+	if opts.PreflightOnly {
+		return depPreparer.PreflightDeployment(stage)
+	}
+
+	return depPreparer.PrepareDeployment(stage, opts.Recreate, opts.DeleteOrphans)
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"code.cloudfoundry.org/clock"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -34,6 +35,7 @@ import (
 	fakebideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest/manifestfakes"
 	fakebideplval "github.com/cloudfoundry/bosh-cli/deployment/manifest/manifestfakes"
 	mock_deployment "github.com/cloudfoundry/bosh-cli/deployment/mocks"
+	fakebipreflight "github.com/cloudfoundry/bosh-cli/deployment/preflight/fakes"
 	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	fakebidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template/templatefakes"
 	fakebivm "github.com/cloudfoundry/bosh-cli/deployment/vm/fakes"
@@ -82,6 +84,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			manifestSHA      string
 
 			mockDeployer              *mock_deployment.MockDeployer
+			mockLocalCPIDeployer      *mock_deployment.MockDeployer
 			mockInstaller             *mock_install.MockInstaller
 			mockInstallerFactory      *mock_install.MockInstallerFactory
 			releaseReader             *fakerel.FakeReader
@@ -118,6 +121,8 @@ var _ = Describe("CreateEnvCmd", func() {
 
 			fakeStage *fakebiui.FakeStage
 
+			fakePreflightChecker *fakebipreflight.FakeChecker
+
 			deploymentManifestPath string
 			deploymentStatePath    string
 			cpiReleaseTarballPath  string
@@ -133,6 +138,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			boshDeploymentManifest bideplmanifest.Manifest
 			installationManifest   biinstallmanifest.Manifest
 			cloud                  bicloud.Cloud
+			fakeCPICmdRunner       *fakebicloud.FakeCPICmdRunner
 
 			cloudStemcell bistemcell.CloudStemcell
 
@@ -161,6 +167,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			fs.WriteFileString(deploymentManifestPath, "")
 
 			mockDeployer = mock_deployment.NewMockDeployer(mockCtrl)
+			mockLocalCPIDeployer = mock_deployment.NewMockDeployer(mockCtrl)
 			mockInstaller = mock_install.NewMockInstaller(mockCtrl)
 			mockInstallerFactory = mock_install.NewMockInstallerFactory(mockCtrl)
 
@@ -203,6 +210,8 @@ var _ = Describe("CreateEnvCmd", func() {
 
 			fakeStage = fakebiui.NewFakeStage()
 
+			fakePreflightChecker = fakebipreflight.NewFakeChecker()
+
 			algos := []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1}
 			digestCalculator = crypto.NewDigestCalculator(fs, algos)
 			fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
@@ -303,7 +312,8 @@ var _ = Describe("CreateEnvCmd", func() {
 				return cpiRelease, nil
 			}
 
-			cloud = bicloud.NewCloud(fakebicloud.NewFakeCPICmdRunner(), "fake-director-id", logger)
+			fakeCPICmdRunner = fakebicloud.NewFakeCPICmdRunner()
+			cloud = bicloud.NewCloud(fakeCPICmdRunner, "fake-director-id", logger)
 			cloudStemcell = fakebistemcell.NewFakeCloudStemcell(
 				"fake-stemcell-cid", "fake-stemcell-name", "fake-stemcell-version")
 
@@ -322,7 +332,7 @@ var _ = Describe("CreateEnvCmd", func() {
 				stemcellRepo := biconfig.NewStemcellRepo(deploymentStateService, fakeUUIDGenerator)
 				deploymentRecord := deployment.NewRecord(deploymentRepo, releaseRepo, stemcellRepo)
 
-				tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
+				tarballCache := bitarball.NewCache("fake-base-path", fs, clock.NewClock(), logger)
 				tarballProvider := bitarball.NewProvider(tarballCache, fs, nil, 1, 0, logger)
 
 				cpiInstaller := bicpirel.CpiInstaller{
@@ -364,12 +374,14 @@ var _ = Describe("CreateEnvCmd", func() {
 					mockLegacyDeploymentStateMigrator,
 					releaseManager,
 					deploymentRecord,
+					biconfig.NewCheckpointRepo(deploymentStateService),
 					mockCloudFactory,
 					fakeStemcellManagerFactory,
 					mockAgentClientFactory,
 					mockVMManagerFactory,
 					mockBlobstoreFactory,
 					mockDeployer,
+					mockLocalCPIDeployer,
 					deploymentManifestPath,
 					deploymentVars,
 					deploymentOp,
@@ -380,6 +392,7 @@ var _ = Describe("CreateEnvCmd", func() {
 					deploymentManifestParser,
 					tempRootConfigurator,
 					targetProvider,
+					fakePreflightChecker,
 				)
 			}
 
@@ -605,6 +618,29 @@ var _ = Describe("CreateEnvCmd", func() {
 			})
 		})
 
+		Context("when the installation uses local CPI", func() {
+			BeforeEach(func() {
+				installationManifest.Local = &biinstallmanifest.LocalCPI{StateDir: "/fake-state-dir"}
+			})
+
+			It("deploys with the deployer that does not use compiled package cache", func() {
+				expectDeploy.Times(0)
+
+				mockLocalCPIDeployer.EXPECT().Deploy(
+					cloud,
+					boshDeploymentManifest,
+					cloudStemcell,
+					installationManifest.Registry,
+					fakeVMManager,
+					mockBlobstore,
+					gomock.Any(),
+				).Return(mock_deployment.NewMockDeployment(mockCtrl), nil)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
 		It("deletes the extracted CPI release", func() {
 			err := command.Run(fakeStage, defaultCreateEnvOpts)
 			Expect(err).NotTo(HaveOccurred())
@@ -626,11 +662,41 @@ var _ = Describe("CreateEnvCmd", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
-		It("adds a new 'deploying' event logger stage", func() {
+		It("runs pre-flight checks before uploading the stemcell", func() {
 			err := command.Run(fakeStage, defaultCreateEnvOpts)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeStage.PerformCalls[3]).To(Equal(&fakebiui.PerformCall{
+				Name:  "running pre-flight checks",
+				Stage: &fakebiui.FakeStage{}, // fake checker doesn't add sub-stages
+			}))
+
+			Expect(fakePreflightChecker.CheckInputs).To(HaveLen(1))
+			Expect(fakePreflightChecker.CheckInputs[0].Cloud).To(Equal(cloud))
+			Expect(fakePreflightChecker.CheckInputs[0].InstallationManifest).To(Equal(installationManifest))
+			Expect(fakePreflightChecker.CheckInputs[0].Stemcell).To(Equal(extractedStemcell))
+		})
+
+		Context("when pre-flight checks fail", func() {
+			BeforeEach(func() {
+				fakePreflightChecker.CheckErr = bosherr.Error("fake-preflight-error")
+			})
+
+			It("returns an error without uploading the stemcell or deploying", func() {
+				expectStemcellUpload.Times(0)
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-preflight-error"))
+			})
+		})
+
+		It("adds a new 'deploying' event logger stage", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeStage.PerformCalls[4]).To(Equal(&fakebiui.PerformCall{
 				Name:  "deploying",
 				Stage: &fakebiui.FakeStage{}, // mock deployer doesn't add sub-stages
 			}))
@@ -667,6 +733,91 @@ var _ = Describe("CreateEnvCmd", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("removes deploy checkpoint after successful deploy", func() {
+			err := command.Run(fakeStage, defaultCreateEnvOpts)
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentState, err := setupDeploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Checkpoint).To(BeNil())
+		})
+
+		Context("when previous attempt of the same deploy failed", func() {
+			BeforeEach(func() {
+				err := setupDeploymentStateService.Save(biconfig.DeploymentState{
+					Checkpoint: &biconfig.CheckpointRecord{
+						ManifestSHA:    manifestSHA,
+						StemcellCID:    "fake-stemcell-cid",
+						CompletedSteps: []string{biconfig.CheckpointVMCreated},
+						VMCID:          "fake-vm-cid",
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("resumes previous deploy", func() {
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdOut).To(gbytes.Say("Resuming previously failed deploy \\(completed: vm_created\\)"))
+			})
+
+			It("discards progress of previous deploy if recreate flag is specified", func() {
+				defaultCreateEnvOpts.Recreate = true
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdOut).ToNot(gbytes.Say("Resuming previously failed deploy"))
+			})
+		})
+
+		Context("when previous deploys left orphaned VMs and disks", func() {
+			BeforeEach(func() {
+				err := setupDeploymentStateService.Save(biconfig.DeploymentState{
+					Checkpoint: &biconfig.CheckpointRecord{
+						CreatedVMCIDs:   []string{"fake-orphan-vm-cid"},
+						CreatedDiskCIDs: []string{"fake-orphan-disk-cid"},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("reports orphaned VMs and disks without deleting them", func() {
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdOut).To(gbytes.Say("Found orphaned VM 'fake-orphan-vm-cid' created by previous deploy"))
+				Expect(stdOut).To(gbytes.Say("Found orphaned disk 'fake-orphan-disk-cid' created by previous deploy"))
+				Expect(fakeCPICmdRunner.RunInputs).To(BeEmpty())
+
+				deploymentState, err := setupDeploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.Checkpoint.CreatedVMCIDs).To(Equal([]string{"fake-orphan-vm-cid"}))
+				Expect(deploymentState.Checkpoint.CreatedDiskCIDs).To(Equal([]string{"fake-orphan-disk-cid"}))
+			})
+
+			It("deletes orphaned VMs and disks if delete-orphans flag is specified", func() {
+				defaultCreateEnvOpts.DeleteOrphans = true
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeCPICmdRunner.RunInputs).To(HaveLen(2))
+				Expect(fakeCPICmdRunner.RunInputs[0].Method).To(Equal("delete_vm"))
+				Expect(fakeCPICmdRunner.RunInputs[0].Arguments).To(Equal([]interface{}{"fake-orphan-vm-cid"}))
+				Expect(fakeCPICmdRunner.RunInputs[1].Method).To(Equal("delete_disk"))
+				Expect(fakeCPICmdRunner.RunInputs[1].Arguments).To(Equal([]interface{}{"fake-orphan-disk-cid"}))
+
+				Expect(fakeStage.PerformCalls).To(ContainElement(&fakebiui.PerformCall{Name: "Deleting orphaned VM 'fake-orphan-vm-cid'"}))
+				Expect(fakeStage.PerformCalls).To(ContainElement(&fakebiui.PerformCall{Name: "Deleting orphaned disk 'fake-orphan-disk-cid'"}))
+
+				deploymentState, err := setupDeploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.Checkpoint).To(BeNil())
+			})
+		})
+
 		Context("when deployment has not changed", func() {
 			JustBeforeEach(func() {
 				previousDeploymentState := biconfig.DeploymentState{
@@ -708,6 +859,76 @@ var _ = Describe("CreateEnvCmd", func() {
 			})
 		})
 
+		Context("when plan flag is specified", func() {
+			BeforeEach(func() {
+				defaultCreateEnvOpts.Plan = true
+			})
+
+			It("prints plan without installing CPI, uploading stemcell or deploying", func() {
+				expectInstall.Times(0)
+				expectNewCloud.Times(0)
+				expectStemcellUpload.Times(0)
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdOut).To(gbytes.Say("Plan"))
+				Expect(stdOut).To(gbytes.Say("VM"))
+				Expect(stdOut).To(gbytes.Say("Stemcell"))
+				Expect(stdOut).To(gbytes.Say("upload 'fake-stemcell-name/fake-stemcell-version'"))
+				Expect(stdOut).To(gbytes.Say(cpiRelease.Name()))
+			})
+
+			It("does not create deployment state", func() {
+				fs.RemoveAll(deploymentStatePath)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fs.FileExists(deploymentStatePath)).To(BeFalse())
+			})
+		})
+
+		Context("when preflight-only flag is specified", func() {
+			BeforeEach(func() {
+				defaultCreateEnvOpts.PreflightOnly = true
+			})
+
+			It("installs CPI and runs pre-flight checks without uploading stemcell or deploying", func() {
+				expectInstall.Times(1)
+				expectNewCloud.Times(1)
+				expectStemcellUpload.Times(0)
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakePreflightChecker.CheckInputs).To(HaveLen(1))
+				Expect(stdOut).To(gbytes.Say("Pre-flight checks succeeded. Skipping deploy."))
+			})
+
+			It("creates deployment state so that the installed CPI is reused by deploy", func() {
+				fs.RemoveAll(deploymentStatePath)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				deploymentState, err := setupDeploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.InstallationID).To(Equal("fake-installation-id"))
+				Expect(deploymentState.CurrentManifestSHA).To(BeEmpty())
+			})
+
+			It("returns an error when pre-flight checks fail", func() {
+				fakePreflightChecker.CheckErr = bosherr.Error("fake-preflight-error")
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-preflight-error"))
+			})
+		})
+
 		Context("when parsing the cpi deployment manifest fails", func() {
 			JustBeforeEach(func() {
 				manifest := bideplmanifest.Manifest{}
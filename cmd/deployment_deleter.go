@@ -95,6 +95,18 @@ func (c *deploymentDeleter) DeleteDeployment(stage biui.Stage) (err error) {
 		return nil
 	}
 
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
 	deploymentState, err := c.deploymentStateService.Load()
 	if err != nil {
 		return bosherr.WrapError(err, "Loading deployment state")
@@ -126,15 +138,17 @@ func (c *deploymentDeleter) DeleteDeployment(stage biui.Stage) (err error) {
 			return err
 		}
 
-		cpiReleaseName := installationManifest.Template.Release
-		cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
-		if !found {
-			return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
-		}
+		if !installationManifest.UsesLocalCPI() {
+			cpiReleaseName := installationManifest.Template.Release
+			cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
+			if !found {
+				return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
+			}
 
-		err = c.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
-		if err != nil {
-			return err
+			err = c.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
+			if err != nil {
+				return err
+			}
 		}
 
 		err = c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
@@ -13,6 +16,7 @@ import (
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bipreflight "github.com/cloudfoundry/bosh-cli/deployment/preflight"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	biinstall "github.com/cloudfoundry/bosh-cli/installation"
@@ -31,12 +35,14 @@ func NewDeploymentPreparer(
 	legacyDeploymentStateMigrator biconfig.LegacyDeploymentStateMigrator,
 	releaseManager boshinst.ReleaseManager,
 	deploymentRecord bidepl.Record,
+	checkpointRepo biconfig.CheckpointRepo,
 	cloudFactory bicloud.Factory,
 	stemcellManagerFactory bistemcell.ManagerFactory,
 	agentClientFactory bihttpagent.AgentClientFactory,
 	vmManagerFactory bivm.ManagerFactory,
 	blobstoreFactory biblobstore.Factory,
 	deployer bidepl.Deployer,
+	localCPIDeployer bidepl.Deployer,
 	deploymentManifestPath string,
 	deploymentVars boshtpl.Variables,
 	deploymentOp patch.Op,
@@ -47,6 +53,7 @@ func NewDeploymentPreparer(
 	deploymentManifestParser DeploymentManifestParser,
 	tempRootConfigurator TempRootConfigurator,
 	targetProvider biinstall.TargetProvider,
+	preflightChecker bipreflight.Checker,
 ) DeploymentPreparer {
 	return DeploymentPreparer{
 		ui:                                      ui,
@@ -56,12 +63,14 @@ func NewDeploymentPreparer(
 		legacyDeploymentStateMigrator:           legacyDeploymentStateMigrator,
 		releaseManager:                          releaseManager,
 		deploymentRecord:                        deploymentRecord,
+		checkpointRepo:                          checkpointRepo,
 		cloudFactory:                            cloudFactory,
 		stemcellManagerFactory:                  stemcellManagerFactory,
 		agentClientFactory:                      agentClientFactory,
 		vmManagerFactory:                        vmManagerFactory,
 		blobstoreFactory:                        blobstoreFactory,
 		deployer:                                deployer,
+		localCPIDeployer:                        localCPIDeployer,
 		deploymentManifestPath:                  deploymentManifestPath,
 		deploymentVars:                          deploymentVars,
 		deploymentOp:                            deploymentOp,
@@ -72,6 +81,7 @@ func NewDeploymentPreparer(
 		deploymentManifestParser:                deploymentManifestParser,
 		tempRootConfigurator:                    tempRootConfigurator,
 		targetProvider:                          targetProvider,
+		preflightChecker:                        preflightChecker,
 	}
 }
 
@@ -83,12 +93,14 @@ type DeploymentPreparer struct {
 	legacyDeploymentStateMigrator           biconfig.LegacyDeploymentStateMigrator
 	releaseManager                          boshinst.ReleaseManager
 	deploymentRecord                        bidepl.Record
+	checkpointRepo                          biconfig.CheckpointRepo
 	cloudFactory                            bicloud.Factory
 	stemcellManagerFactory                  bistemcell.ManagerFactory
 	agentClientFactory                      bihttpagent.AgentClientFactory
 	vmManagerFactory                        bivm.ManagerFactory
 	blobstoreFactory                        biblobstore.Factory
 	deployer                                bidepl.Deployer
+	localCPIDeployer                        bidepl.Deployer
 	deploymentManifestPath                  string
 	deploymentVars                          boshtpl.Variables
 	deploymentOp                            patch.Op
@@ -99,24 +111,27 @@ type DeploymentPreparer struct {
 	deploymentManifestParser                DeploymentManifestParser
 	tempRootConfigurator                    TempRootConfigurator
 	targetProvider                          biinstall.TargetProvider
+	preflightChecker                        bipreflight.Checker
 }
 
-func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool) (err error) {
+func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate, deleteOrphans bool) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
-	if !c.deploymentStateService.Exists() {
-		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
-		if err != nil {
-			return bosherr.WrapError(err, "Migrating legacy deployment state file")
-		}
-		if migrated {
-			c.ui.BeginLinef("Migrated legacy deployments file: '%s'\n", biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
-		}
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
 	}
 
-	deploymentState, err := c.deploymentStateService.Load()
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
+	deploymentState, err := c.loadDeploymentState()
 	if err != nil {
-		return bosherr.WrapError(err, "Loading deployment state")
+		return err
 	}
 
 	target, err := c.targetProvider.NewTarget()
@@ -136,39 +151,7 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool)
 		}
 	}()
 
-	var (
-		extractedStemcell    bistemcell.ExtractedStemcell
-		deploymentManifest   bideplmanifest.Manifest
-		installationManifest biinstallmanifest.Manifest
-		manifestSHA          string
-	)
-	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
-		var releaseSetManifest birelsetmanifest.Manifest
-		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
-		if err != nil {
-			return err
-		}
-
-		for _, releaseRef := range releaseSetManifest.Releases {
-			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
-			if err != nil {
-				return err
-			}
-		}
-
-		err := c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
-		if err != nil {
-			return err
-		}
-
-		deploymentManifest, manifestSHA, err = c.deploymentManifestParser.GetDeploymentManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp, releaseSetManifest, stage)
-		if err != nil {
-			return err
-		}
-
-		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
-		return err
-	})
+	installationManifest, deploymentManifest, manifestSHA, extractedStemcell, err := c.validate(stage)
 	if err != nil {
 		return err
 	}
@@ -198,6 +181,8 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool)
 				installationManifest,
 				deploymentManifest,
 				manifestSHA,
+				recreate,
+				deleteOrphans,
 				stage)
 		})
 	})
@@ -206,6 +191,179 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool)
 
 }
 
+// PlanDeployment prints changes PrepareDeployment would make without
+// installing the CPI or modifying deployment state
+func (c *DeploymentPreparer) PlanDeployment(stage biui.Stage, recreate bool) error {
+	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+
+	var deploymentState biconfig.DeploymentState
+
+	// Load initializes and saves missing state hence it's skipped
+	if c.deploymentStateService.Exists() {
+		var err error
+
+		deploymentState, err = c.deploymentStateService.Load()
+		if err != nil {
+			return bosherr.WrapError(err, "Loading deployment state")
+		}
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	_, deploymentManifest, manifestSHA, extractedStemcell, err := c.validate(stage)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		deleteErr := extractedStemcell.Cleanup()
+		if deleteErr != nil {
+			c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+		}
+	}()
+
+	plan, err := bidepl.NewPlanner().Plan(
+		deploymentState, deploymentManifest, manifestSHA, c.releaseManager.List(), extractedStemcell, recreate)
+	if err != nil {
+		return bosherr.WrapError(err, "Planning deployment")
+	}
+
+	NewPlanPrinter(c.ui).Print(plan)
+
+	return nil
+}
+
+// PreflightDeployment installs the CPI and runs pre-flight checks
+// without uploading stemcell or deploying. Deployment state is initialized
+// so that the installed CPI is reused by the following deploy.
+func (c *DeploymentPreparer) PreflightDeployment(stage biui.Stage) (err error) {
+	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+
+	err = c.deploymentStateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer func() {
+		unlockErr := c.deploymentStateService.Unlock()
+		if unlockErr != nil {
+			c.logger.Warn(c.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
+	deploymentState, err := c.loadDeploymentState()
+	if err != nil {
+		return err
+	}
+
+	target, err := c.targetProvider.NewTarget()
+	if err != nil {
+		return bosherr.WrapError(err, "Determining installation target")
+	}
+
+	err = c.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), c.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	installationManifest, deploymentManifest, _, extractedStemcell, err := c.validate(stage)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		deleteErr := extractedStemcell.Cleanup()
+		if deleteErr != nil {
+			c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+		}
+	}()
+
+	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(installation biinstall.Installation) error {
+		cloud, err := c.cloudFactory.NewCloud(installation, deploymentState.DirectorID)
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		return c.preflight(cloud, installationManifest, deploymentManifest, extractedStemcell, stage)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.ui.BeginLinef("Pre-flight checks succeeded. Skipping deploy.\n")
+
+	return nil
+}
+
+func (c *DeploymentPreparer) loadDeploymentState() (biconfig.DeploymentState, error) {
+	if !c.deploymentStateService.Exists() {
+		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+		if err != nil {
+			return biconfig.DeploymentState{}, bosherr.WrapError(err, "Migrating legacy deployment state file")
+		}
+		if migrated {
+			c.ui.BeginLinef("Migrated legacy deployments file: '%s'\n", biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+		}
+	}
+
+	deploymentState, err := c.deploymentStateService.Load()
+	if err != nil {
+		return biconfig.DeploymentState{}, bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	return deploymentState, nil
+}
+
+func (c *DeploymentPreparer) validate(stage biui.Stage) (
+	installationManifest biinstallmanifest.Manifest,
+	deploymentManifest bideplmanifest.Manifest,
+	manifestSHA string,
+	extractedStemcell bistemcell.ExtractedStemcell,
+	err error,
+) {
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		for _, releaseRef := range releaseSetManifest.Releases {
+			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		deploymentManifest, manifestSHA, err = c.deploymentManifestParser.GetDeploymentManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp, releaseSetManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
+		return err
+	})
+
+	return
+}
+
 func (c *DeploymentPreparer) deploy(
 	installation biinstall.Installation,
 	deploymentState biconfig.DeploymentState,
@@ -213,6 +371,8 @@ func (c *DeploymentPreparer) deploy(
 	installationManifest biinstallmanifest.Manifest,
 	deploymentManifest bideplmanifest.Manifest,
 	manifestSHA string,
+	recreate bool,
+	deleteOrphans bool,
 	stage biui.Stage,
 ) (err error) {
 	cloud, err := c.cloudFactory.NewCloud(installation, deploymentState.DirectorID)
@@ -220,6 +380,11 @@ func (c *DeploymentPreparer) deploy(
 		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
 	}
 
+	err = c.preflight(cloud, installationManifest, deploymentManifest, extractedStemcell, stage)
+	if err != nil {
+		return err
+	}
+
 	stemcellManager := c.stemcellManagerFactory.NewManager(cloud)
 
 	cloudStemcell, err := stemcellManager.Upload(extractedStemcell, stage)
@@ -227,6 +392,16 @@ func (c *DeploymentPreparer) deploy(
 		return err
 	}
 
+	err = c.startCheckpoint(manifestSHA, cloudStemcell.CID(), recreate)
+	if err != nil {
+		return err
+	}
+
+	err = c.handleOrphans(cloud, deleteOrphans, stage)
+	if err != nil {
+		return err
+	}
+
 	agentClient, err := c.agentClientFactory.NewAgentClient(deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
 	if err != nil {
 		return err
@@ -244,7 +419,13 @@ func (c *DeploymentPreparer) deploy(
 			return bosherr.WrapError(err, "Clearing deployment record")
 		}
 
-		_, err = c.deployer.Deploy(
+		deployer := c.deployer
+		if installationManifest.UsesLocalCPI() {
+			// Local CPI agent does not compile packages so its results must not end up in compiled package cache
+			deployer = c.localCPIDeployer
+		}
+
+		_, err = deployer.Deploy(
 			cloud,
 			deploymentManifest,
 			cloudStemcell,
@@ -262,6 +443,11 @@ func (c *DeploymentPreparer) deploy(
 			return bosherr.WrapError(err, "Updating deployment record")
 		}
 
+		err = c.checkpointRepo.Finish()
+		if err != nil {
+			return bosherr.WrapError(err, "Finishing deploy checkpoint")
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -277,3 +463,99 @@ func (c *DeploymentPreparer) deploy(
 
 	return nil
 }
+
+func (c *DeploymentPreparer) preflight(
+	cloud bicloud.Cloud,
+	installationManifest biinstallmanifest.Manifest,
+	deploymentManifest bideplmanifest.Manifest,
+	extractedStemcell bistemcell.ExtractedStemcell,
+	stage biui.Stage,
+) error {
+	return stage.PerformComplex("running pre-flight checks", func(stage biui.Stage) error {
+		return c.preflightChecker.Check(cloud, installationManifest, deploymentManifest, extractedStemcell, stage)
+	})
+}
+
+// startCheckpoint keeps progress of a previously failed deploy of the same manifest and stemcell
+// unless VM recreation was requested
+func (c *DeploymentPreparer) startCheckpoint(manifestSHA, stemcellCID string, recreate bool) error {
+	if recreate {
+		err := c.checkpointRepo.Discard()
+		if err != nil {
+			return bosherr.WrapError(err, "Discarding deploy checkpoint")
+		}
+	}
+
+	checkpoint, resumed, err := c.checkpointRepo.Start(manifestSHA, stemcellCID)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting deploy checkpoint")
+	}
+
+	if resumed {
+		c.ui.BeginLinef("Resuming previously failed deploy (completed: %s)\n", strings.Join(checkpoint.CompletedSteps, ", "))
+	}
+
+	return nil
+}
+
+// handleOrphans reports VMs and disks left behind by failed deploys and optionally deletes them
+func (c *DeploymentPreparer) handleOrphans(cloud bicloud.Cloud, deleteOrphans bool, stage biui.Stage) error {
+	vmCIDs, diskCIDs, err := c.checkpointRepo.FindOrphans()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding orphaned VMs and disks")
+	}
+
+	if len(vmCIDs) == 0 && len(diskCIDs) == 0 {
+		return nil
+	}
+
+	if !deleteOrphans {
+		for _, cid := range vmCIDs {
+			c.ui.BeginLinef("Found orphaned VM '%s' created by previous deploy\n", cid)
+		}
+
+		for _, cid := range diskCIDs {
+			c.ui.BeginLinef("Found orphaned disk '%s' created by previous deploy\n", cid)
+		}
+
+		c.ui.BeginLinef("Use '--delete-orphans' to delete orphaned VMs and disks\n")
+
+		return nil
+	}
+
+	for _, cid := range vmCIDs {
+		err = stage.Perform(fmt.Sprintf("Deleting orphaned VM '%s'", cid), func() error {
+			err := cloud.DeleteVM(cid)
+			if err != nil {
+				cloudErr, ok := err.(bicloud.Error)
+				if !ok || cloudErr.Type() != bicloud.VMNotFoundError {
+					return err
+				}
+			}
+
+			return c.checkpointRepo.ForgetVM(cid)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, cid := range diskCIDs {
+		err = stage.Perform(fmt.Sprintf("Deleting orphaned disk '%s'", cid), func() error {
+			err := cloud.DeleteDisk(cid)
+			if err != nil {
+				cloudErr, ok := err.(bicloud.Error)
+				if !ok || cloudErr.Type() != bicloud.DiskNotFoundError {
+					return err
+				}
+			}
+
+			return c.checkpointRepo.ForgetDisk(cid)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const diffConfigDirectorPrefix = "director:"
+
+type DiffConfigCmd struct {
+	ui           boshui.UI
+	fs           boshsys.FileSystem
+	differ       boshdirman.Differ
+	directorFunc func() (boshdir.Director, error)
+}
+
+func NewDiffConfigCmd(
+	ui boshui.UI,
+	fs boshsys.FileSystem,
+	differ boshdirman.Differ,
+	directorFunc func() (boshdir.Director, error),
+) DiffConfigCmd {
+	return DiffConfigCmd{ui: ui, fs: fs, differ: differ, directorFunc: directorFunc}
+}
+
+func (c DiffConfigCmd) Run(opts DiffConfigOpts) error {
+	before, err := c.config(opts.Args.From, opts)
+	if err != nil {
+		return err
+	}
+
+	after, err := c.config(opts.Args.To, opts)
+	if err != nil {
+		return err
+	}
+
+	lines, err := c.differ.Diff(before, after, !opts.NoRedact)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Diffing %s configs", opts.Type)
+	}
+
+	if len(lines) == 0 {
+		c.ui.PrintLinef("No differences")
+		return nil
+	}
+
+	NewDiff(lines).Print(c.ui)
+
+	return nil
+}
+
+// config returns latest config from the Director for 'director:[NAME]' sources;
+// otherwise source is a local file with variables and ops files applied
+func (c DiffConfigCmd) config(source string, opts DiffConfigOpts) ([]byte, error) {
+	if strings.HasPrefix(source, diffConfigDirectorPrefix) {
+		name := strings.TrimPrefix(source, diffConfigDirectorPrefix)
+
+		director, err := c.directorFunc()
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := LatestConfig(director, opts.Type, name)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(config), nil
+	}
+
+	bytes, err := c.fs.ReadFile(source)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading %s config '%s'", opts.Type, source)
+	}
+
+	tpl := boshtpl.NewTemplate(bytes)
+
+	bytes, err = tpl.Evaluate(opts.VarFlags.AsReadOnlyVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Evaluating %s config '%s'", opts.Type, source)
+	}
+
+	return bytes, nil
+}
+
+// LatestConfig returns latest config of a given type (cloud, runtime or cpi)
+func LatestConfig(director boshdir.Director, configType, name string) (string, error) {
+	switch configType {
+	case "cloud":
+		config, err := director.LatestCloudConfig()
+		return config.Properties, err
+
+	case "runtime":
+		config, err := director.LatestRuntimeConfig(name)
+		return config.Properties, err
+
+	case "cpi":
+		config, err := director.LatestCPIConfig()
+		return config.Properties, err
+
+	default:
+		return "", bosherr.Errorf("Unknown config type '%s'", configType)
+	}
+}
+
+// ConfigDiffLines returns Director provided diff lines; for directors without
+// diff endpoints it diffs given config against the latest config locally
+func ConfigDiffLines(
+	ui boshui.UI,
+	configDiff boshdir.ConfigDiff,
+	latestFunc func() (string, error),
+	config []byte,
+	redact bool,
+) [][]interface{} {
+	if !configDiff.Unsupported {
+		return configDiff.Diff
+	}
+
+	latest, err := latestFunc()
+	if err != nil {
+		ui.ErrorLinef("Unable to show config diff: %s", err)
+		return nil
+	}
+
+	lines, err := boshdirman.NewDiffer().Diff([]byte(latest), config, redact)
+	if err != nil {
+		ui.ErrorLinef("Unable to show config diff: %s", err)
+		return nil
+	}
+
+	return lines
+}
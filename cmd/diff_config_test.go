@@ -0,0 +1,162 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("DiffConfigCmd", func() {
+	var (
+		ui          *fakeui.FakeUI
+		fs          *fakesys.FakeFileSystem
+		director    *fakedir.FakeDirector
+		directorErr error
+		command     DiffConfigCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		director = &fakedir.FakeDirector{}
+		directorErr = nil
+
+		directorFunc := func() (boshdir.Director, error) { return director, directorErr }
+
+		command = NewDiffConfigCmd(ui, fs, boshdirman.NewDiffer(), directorFunc)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts DiffConfigOpts
+		)
+
+		BeforeEach(func() {
+			opts = DiffConfigOpts{
+				Args: DiffConfigArgs{From: "/before.yml", To: "/after.yml"},
+				Type: "cloud",
+			}
+
+			fs.WriteFileString("/before.yml", "compilation: {workers: 1}\nvm_types: [{name: small, cloud_properties: {type: t1}}]\n")
+			fs.WriteFileString("/after.yml", "compilation: {workers: ((workers))}\nvm_types: [{name: small, cloud_properties: {type: t2}}]\n")
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("diffs two local files with variables applied without contacting the director", func() {
+			opts.VarFlags = VarFlags{
+				VarKVs: []boshtpl.VarKV{{Name: "workers", Value: 5}},
+			}
+			opts.NoRedact = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"  compilation:\n",
+				"-   workers: 1\n",
+				"+   workers: 5\n",
+				"  vm_types:\n",
+				"  - name: small\n",
+				"    cloud_properties:\n",
+				"-     type: t1\n",
+				"+     type: t2\n",
+			}))
+			Expect(director.Invocations()).To(BeEmpty())
+		})
+
+		It("prints no differences for equal configs", func() {
+			opts.Args.To = "/before.yml"
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(Equal([]string{"No differences"}))
+		})
+
+		It("diffs latest cloud config on the director against local file", func() {
+			opts.Args.From = "director:"
+			opts.VarFlags = VarFlags{
+				VarKVs: []boshtpl.VarKV{{Name: "workers", Value: 1}},
+			}
+			director.LatestCloudConfigReturns(boshdir.CloudConfig{Properties: "compilation: {workers: 1}\n"}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.LatestCloudConfigCallCount()).To(Equal(1))
+			Expect(ui.Said).To(Equal([]string{
+				"+ vm_types:\n",
+				"+ - cloud_properties:\n",
+				"+     type: t2\n",
+				"+   name: small\n",
+			}))
+		})
+
+		It("fetches named runtime config", func() {
+			opts.Type = "runtime"
+			opts.Args.From = "director:dns"
+			director.LatestRuntimeConfigReturns(boshdir.RuntimeConfig{Properties: "addons: []\n"}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.LatestRuntimeConfigCallCount()).To(Equal(1))
+			Expect(director.LatestRuntimeConfigArgsForCall(0)).To(Equal("dns"))
+		})
+
+		It("fetches cpi config", func() {
+			opts.Type = "cpi"
+			opts.Args.To = "director:"
+			director.LatestCPIConfigReturns(boshdir.CPIConfig{Properties: "cpis: []\n"}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.LatestCPIConfigCallCount()).To(Equal(1))
+		})
+
+		It("returns error if director cannot be obtained", func() {
+			opts.Args.From = "director:"
+			directorErr = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if latest config cannot be fetched", func() {
+			opts.Args.To = "director:"
+			director.LatestCloudConfigReturns(boshdir.CloudConfig{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if local file cannot be read", func() {
+			opts.Args.To = "/missing.yml"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading cloud config '/missing.yml'"))
+		})
+
+		It("returns error if configs cannot be diffed", func() {
+			opts.Args.To = "director:"
+			director.LatestCloudConfigReturns(boshdir.CloudConfig{Properties: "key: ["}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Diffing cloud configs"))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const (
+	diffManifestDeploymentPrefix = "deployment:"
+	diffManifestTaskPrefix       = "task:"
+	diffManifestEventPrefix      = "event:"
+)
+
+type DiffManifestCmd struct {
+	ui             boshui.UI
+	fs             boshsys.FileSystem
+	differ         boshdirman.Differ
+	deploymentFunc func(string) (boshdir.Deployment, error)
+	directorFunc   func() (boshdir.Director, error)
+}
+
+func NewDiffManifestCmd(
+	ui boshui.UI,
+	fs boshsys.FileSystem,
+	differ boshdirman.Differ,
+	deploymentFunc func(string) (boshdir.Deployment, error),
+	directorFunc func() (boshdir.Director, error),
+) DiffManifestCmd {
+	return DiffManifestCmd{
+		ui:             ui,
+		fs:             fs,
+		differ:         differ,
+		deploymentFunc: deploymentFunc,
+		directorFunc:   directorFunc,
+	}
+}
+
+func (c DiffManifestCmd) Run(opts DiffManifestOpts) error {
+	before, err := c.manifest(opts.Args.From, opts)
+	if err != nil {
+		return err
+	}
+
+	after, err := c.manifest(opts.Args.To, opts)
+	if err != nil {
+		return err
+	}
+
+	lines, err := c.differ.Diff(before, after, !opts.NoRedact)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Diffing manifests")
+	}
+
+	if len(lines) == 0 {
+		c.ui.PrintLinef("No differences")
+		return nil
+	}
+
+	NewDiff(lines).Print(c.ui)
+
+	return nil
+}
+
+// manifest returns deployed manifest for 'deployment:[NAME]' sources,
+// manifest used by a past deploy for 'task:ID' and 'event:ID' sources;
+// otherwise source is a local file with variables and ops files applied
+func (c DiffManifestCmd) manifest(source string, opts DiffManifestOpts) ([]byte, error) {
+	if strings.HasPrefix(source, diffManifestTaskPrefix) {
+		return c.taskManifest(strings.TrimPrefix(source, diffManifestTaskPrefix))
+	}
+
+	if strings.HasPrefix(source, diffManifestEventPrefix) {
+		return c.eventManifest(strings.TrimPrefix(source, diffManifestEventPrefix))
+	}
+
+	if strings.HasPrefix(source, diffManifestDeploymentPrefix) {
+		name := strings.TrimPrefix(source, diffManifestDeploymentPrefix)
+
+		deployment, err := c.deploymentFunc(name)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := deployment.Manifest()
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(manifest), nil
+	}
+
+	bytes, err := c.fs.ReadFile(source)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading manifest '%s'", source)
+	}
+
+	tpl := boshtpl.NewTemplate(bytes)
+
+	bytes, err = tpl.Evaluate(opts.VarFlags.AsReadOnlyVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Evaluating manifest '%s'", source)
+	}
+
+	return bytes, nil
+}
+
+func (c DiffManifestCmd) eventManifest(id string) ([]byte, error) {
+	director, err := c.directorFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := director.Event(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(event.TaskID()) == 0 {
+		return nil, bosherr.Errorf("Expected event '%s' to be associated with a task", id)
+	}
+
+	return c.taskManifest(event.TaskID())
+}
+
+// taskManifest finds manifest in task's debug output since
+// director does not keep manifests used by past deploys otherwise
+func (c DiffManifestCmd) taskManifest(idStr string) ([]byte, error) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing task ID '%s'", idStr)
+	}
+
+	director, err := c.directorFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := director.FindTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &diffManifestTaskOutput{}
+
+	err = task.DebugOutput(output)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Fetching task '%d' debug output", id)
+	}
+
+	manifest, err := boshdirman.ManifestFromTaskDebugOutput(output.String())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding manifest used by task '%d'", id)
+	}
+
+	return []byte(manifest), nil
+}
+
+type diffManifestTaskOutput struct {
+	bytes.Buffer
+}
+
+func (o *diffManifestTaskOutput) TaskStarted(int)                     {}
+func (o *diffManifestTaskOutput) TaskFinished(int, string)            {}
+func (o *diffManifestTaskOutput) TaskOutputChunk(_ int, chunk []byte) { o.Write(chunk) }
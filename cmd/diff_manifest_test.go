@@ -0,0 +1,249 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("DiffManifestCmd", func() {
+	var (
+		ui              *fakeui.FakeUI
+		fs              *fakesys.FakeFileSystem
+		deployment      *fakedir.FakeDeployment
+		deploymentNames []string
+		deploymentErr   error
+		director        *fakedir.FakeDirector
+		command         DiffManifestCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		deployment = &fakedir.FakeDeployment{}
+		deploymentNames = nil
+		deploymentErr = nil
+
+		deploymentFunc := func(name string) (boshdir.Deployment, error) {
+			deploymentNames = append(deploymentNames, name)
+			return deployment, deploymentErr
+		}
+
+		director = &fakedir.FakeDirector{}
+		directorFunc := func() (boshdir.Director, error) { return director, nil }
+
+		command = NewDiffManifestCmd(ui, fs, boshdirman.NewDiffer(), deploymentFunc, directorFunc)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts DiffManifestOpts
+		)
+
+		BeforeEach(func() {
+			opts = DiffManifestOpts{
+				Args: DiffManifestArgs{From: "/before.yml", To: "/after.yml"},
+			}
+
+			fs.WriteFileString("/before.yml", "name: dep\ninstances: 1\nproperties: {secret: abc}\n")
+			fs.WriteFileString("/after.yml", "name: dep\ninstances: ((instances))\nproperties: {secret: xyz}\n")
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("diffs two local files with variables and ops applied to both", func() {
+			opts.VarFlags = VarFlags{
+				VarKVs: []boshtpl.VarKV{{Name: "instances", Value: 2}},
+			}
+
+			opts.OpsFlags = OpsFlags{
+				OpsFiles: []OpsFileArg{
+					{Ops: patch.Ops{
+						patch.ReplaceOp{Path: patch.MustNewPointerFromString("/properties/secret"), Value: "same"},
+					}},
+				},
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"- instances: 1\n",
+				"+ instances: 2\n",
+			}))
+			Expect(deploymentNames).To(BeEmpty())
+		})
+
+		It("does not redact values when --no-redact is given", func() {
+			opts.VarFlags = VarFlags{
+				VarKVs: []boshtpl.VarKV{{Name: "instances", Value: 1}},
+			}
+			opts.NoRedact = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"  properties:\n",
+				"-   secret: abc\n",
+				"+   secret: xyz\n",
+			}))
+		})
+
+		It("diffs against deployed manifests without interpolating them", func() {
+			opts.Args.From = "deployment:"
+			opts.Args.To = "deployment:other-dep"
+			deployment.ManifestReturns("name: dep\ninstances: ((instances))\n", nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deploymentNames).To(Equal([]string{"", "other-dep"}))
+			Expect(ui.Said).To(Equal([]string{"No differences"}))
+		})
+
+		It("diffs deployed manifest against local file", func() {
+			opts.Args.From = "deployment:dep"
+			deployment.ManifestReturns("name: dep\ninstances: 1\nproperties: {secret: xyz}\n", nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"- instances: 1\n",
+				"+ instances: ((instances))\n",
+			}))
+		})
+
+		Context("when diffing against manifests used by past deploys", func() {
+			var (
+				task *fakedir.FakeTask
+			)
+
+			BeforeEach(func() {
+				task = &fakedir.FakeTask{}
+				task.DebugOutputStub = func(reporter boshdir.TaskReporter) error {
+					reporter.TaskStarted(5)
+					reporter.TaskOutputChunk(5, []byte("D, [2017-08-01T22:09:07.563584 #1] [task:5] DEBUG -- DirectorJobRunner: Manifest:\n"))
+					reporter.TaskOutputChunk(5, []byte("name: dep\ninstances: 1\nproperties: {secret: xyz}\n"))
+					reporter.TaskOutputChunk(5, []byte("D, [2017-08-01T22:09:08.563584 #1] [task:5] DEBUG -- DirectorJobRunner: Done\n"))
+					reporter.TaskFinished(5, "done")
+					return nil
+				}
+				director.FindTaskReturns(task, nil)
+			})
+
+			It("diffs manifest logged by a task against local file", func() {
+				opts.Args.From = "task:5"
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.FindTaskArgsForCall(0)).To(Equal(5))
+				Expect(ui.Said).To(Equal([]string{
+					"- instances: 1\n",
+					"+ instances: ((instances))\n",
+				}))
+			})
+
+			It("diffs manifest used by event's task", func() {
+				opts.Args.From = "event:7"
+
+				event := &fakedir.FakeEvent{}
+				event.TaskIDReturns("5")
+				director.EventReturns(event, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.EventArgsForCall(0)).To(Equal("7"))
+				Expect(director.FindTaskArgsForCall(0)).To(Equal(5))
+				Expect(ui.Said).To(Equal([]string{
+					"- instances: 1\n",
+					"+ instances: ((instances))\n",
+				}))
+			})
+
+			It("returns error if task ID is not a number", func() {
+				opts.Args.From = "task:abc"
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Parsing task ID 'abc'"))
+			})
+
+			It("returns error if event is not associated with a task", func() {
+				opts.Args.From = "event:7"
+				director.EventReturns(&fakedir.FakeEvent{}, nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected event '7' to be associated with a task"))
+			})
+
+			It("returns error if task did not log manifest", func() {
+				opts.Args.From = "task:5"
+				task.DebugOutputStub = nil
+				task.DebugOutputReturns(nil)
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Finding manifest used by task '5'"))
+			})
+
+			It("returns error if task cannot be found", func() {
+				opts.Args.From = "task:5"
+				director.FindTaskReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+		})
+
+		It("returns error if deployment cannot be found", func() {
+			opts.Args.From = "deployment:dep"
+			deploymentErr = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if deployed manifest cannot be fetched", func() {
+			opts.Args.To = "deployment:dep"
+			deployment.ManifestReturns("", errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if local file cannot be read", func() {
+			opts.Args.To = "/missing.yml"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading manifest '/missing.yml'"))
+		})
+
+		It("returns error if manifest cannot be diffed", func() {
+			opts.Args.To = "deployment:dep"
+			deployment.ManifestReturns("key: [", nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Diffing manifests"))
+		})
+	})
+})
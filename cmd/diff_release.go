@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	semver "github.com/cppforlife/go-semi-semantic/version"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshreldiff "github.com/cloudfoundry/bosh-cli/release/diff"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	boshman "github.com/cloudfoundry/bosh-cli/release/manifest"
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+const diffReleaseDirectorPrefix = "director:"
+
+type DiffReleaseCmd struct {
+	releaseReader     boshrel.Reader
+	releaseDirFactory func(DirOrCWDArg) boshreldir.ReleaseDir
+	jobReader         boshjob.ArchiveReader
+	directorFunc      func() (boshdir.Director, error)
+	fs                boshsys.FileSystem
+	ui                boshui.UI
+}
+
+func NewDiffReleaseCmd(
+	releaseReader boshrel.Reader,
+	releaseDirFactory func(DirOrCWDArg) boshreldir.ReleaseDir,
+	jobReader boshjob.ArchiveReader,
+	directorFunc func() (boshdir.Director, error),
+	fs boshsys.FileSystem,
+	ui boshui.UI,
+) DiffReleaseCmd {
+	return DiffReleaseCmd{
+		releaseReader:     releaseReader,
+		releaseDirFactory: releaseDirFactory,
+		jobReader:         jobReader,
+		directorFunc:      directorFunc,
+		fs:                fs,
+		ui:                ui,
+	}
+}
+
+func (c DiffReleaseCmd) Run(opts DiffReleaseOpts) error {
+	var cleanUps []func() error
+
+	defer func() {
+		for _, cleanUp := range cleanUps {
+			_ = cleanUp()
+		}
+	}()
+
+	from, err := c.release(opts.Args.From, opts.Directory, &cleanUps)
+	if err != nil {
+		return err
+	}
+
+	to, err := c.release(opts.Args.To, opts.Directory, &cleanUps)
+	if err != nil {
+		return err
+	}
+
+	result := boshreldiff.Diff(from, to)
+
+	if result.Empty() {
+		c.ui.PrintLinef("No differences")
+		return nil
+	}
+
+	c.printJobs(result.Jobs)
+	c.printPackages(result.Packages)
+
+	return nil
+}
+
+// release returns release from the Director for 'director:NAME/VERSION' sources,
+// release tarball if source is an existing file;
+// otherwise source is '[NAME/]VERSION' of a release in the release directory
+func (c DiffReleaseCmd) release(source string, dir DirOrCWDArg, cleanUps *[]func() error) (boshreldiff.Release, error) {
+	if strings.HasPrefix(source, diffReleaseDirectorPrefix) {
+		return c.directorRelease(strings.TrimPrefix(source, diffReleaseDirectorPrefix))
+	}
+
+	if c.fs.FileExists(source) {
+		release, err := c.releaseReader.Read(source)
+		if err != nil {
+			return boshreldiff.Release{}, bosherr.WrapErrorf(err, "Reading release tarball '%s'", source)
+		}
+
+		*cleanUps = append(*cleanUps, release.CleanUp)
+
+		return boshreldiff.NewRelease(release), nil
+	}
+
+	return c.releaseDirRelease(source, dir, cleanUps)
+}
+
+func (c DiffReleaseCmd) directorRelease(source string) (boshreldiff.Release, error) {
+	var slug boshdir.ReleaseSlug
+
+	err := (&slug).UnmarshalFlag(source)
+	if err != nil {
+		return boshreldiff.Release{}, err
+	}
+
+	director, err := c.directorFunc()
+	if err != nil {
+		return boshreldiff.Release{}, err
+	}
+
+	release, err := director.FindRelease(slug)
+	if err != nil {
+		return boshreldiff.Release{}, err
+	}
+
+	jobs, err := release.Jobs()
+	if err != nil {
+		return boshreldiff.Release{}, err
+	}
+
+	pkgs, err := release.Packages()
+	if err != nil {
+		return boshreldiff.Release{}, err
+	}
+
+	// Director does not return job properties and package dependencies
+	result := boshreldiff.Release{Name: slug.Name(), Version: slug.Version()}
+
+	for _, job := range jobs {
+		result.Jobs = append(result.Jobs, boshreldiff.Job{
+			Name:        job.Name,
+			Fingerprint: job.Fingerprint,
+			Provides:    c.directorLinks(job.LinksProvided),
+			Consumes:    c.directorLinks(job.LinksConsumed),
+		})
+	}
+
+	for _, pkg := range pkgs {
+		result.Packages = append(result.Packages, boshreldiff.Package{
+			Name:        pkg.Name,
+			Fingerprint: pkg.Fingerprint,
+		})
+	}
+
+	return result, nil
+}
+
+func (c DiffReleaseCmd) directorLinks(links []boshdir.Link) []boshjob.LinkDefinition {
+	var result []boshjob.LinkDefinition
+
+	for _, link := range links {
+		result = append(result, boshjob.LinkDefinition{Name: link.Name, Type: link.Type, Optional: link.Optional})
+	}
+
+	return result
+}
+
+func (c DiffReleaseCmd) releaseDirRelease(source string, dir DirOrCWDArg, cleanUps *[]func() error) (boshreldiff.Release, error) {
+	var name, versionStr string
+
+	pieces := strings.Split(source, "/")
+
+	switch len(pieces) {
+	case 1:
+		versionStr = pieces[0]
+	case 2:
+		name, versionStr = pieces[0], pieces[1]
+	default:
+		return boshreldiff.Release{}, bosherr.Errorf(
+			"Expected release '%s' to be a release tarball, 'director:NAME/VERSION' or '[NAME/]VERSION'", source)
+	}
+
+	version, err := semver.NewVersionFromString(versionStr)
+	if err != nil {
+		return boshreldiff.Release{}, bosherr.WrapErrorf(err, "Parsing release version '%s'", versionStr)
+	}
+
+	release, err := c.releaseDirFactory(dir).FindRelease(name, version)
+	if err != nil {
+		return boshreldiff.Release{}, bosherr.WrapErrorf(err, "Finding release '%s'", source)
+	}
+
+	result := boshreldiff.NewRelease(release)
+
+	// Release manifests do not include job specs hence they are read from job archives
+	for i, job := range release.Jobs() {
+		jobRef := boshman.JobRef{Name: job.Name(), Fingerprint: job.Fingerprint(), SHA1: job.ArchiveDigest()}
+
+		extractedJob, err := c.jobReader.Read(jobRef, job.ArchivePath())
+		if err != nil {
+			return boshreldiff.Release{}, bosherr.WrapErrorf(err, "Reading job '%s'", job.Name())
+		}
+
+		*cleanUps = append(*cleanUps, extractedJob.CleanUp)
+
+		result.Jobs[i] = boshreldiff.NewJob(extractedJob)
+	}
+
+	return result, nil
+}
+
+func (c DiffReleaseCmd) printJobs(jobDiffs []boshreldiff.JobDiff) {
+	table := boshtbl.Table{
+		Content: "jobs",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Job"),
+			boshtbl.NewHeader("Change"),
+			boshtbl.NewHeader("From"),
+			boshtbl.NewHeader("To"),
+			boshtbl.NewHeader("Details"),
+		},
+	}
+
+	for _, jobDiff := range jobDiffs {
+		var details []string
+
+		for _, propDiff := range jobDiff.Properties {
+			details = append(details, c.propertyDetails(propDiff))
+		}
+
+		for _, linkDiff := range jobDiff.Provides {
+			details = append(details, c.linkDetails("provided", linkDiff))
+		}
+
+		for _, linkDiff := range jobDiff.Consumes {
+			details = append(details, c.linkDetails("consumed", linkDiff))
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(jobDiff.Name),
+			boshtbl.NewValueString(string(jobDiff.Change)),
+			boshtbl.NewValueString(jobDiff.OldFingerprint),
+			boshtbl.NewValueString(jobDiff.NewFingerprint),
+			boshtbl.NewValueStrings(details),
+		})
+	}
+
+	c.ui.PrintTable(table)
+}
+
+func (c DiffReleaseCmd) printPackages(pkgDiffs []boshreldiff.PackageDiff) {
+	table := boshtbl.Table{
+		Content: "packages",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Package"),
+			boshtbl.NewHeader("Change"),
+			boshtbl.NewHeader("From"),
+			boshtbl.NewHeader("To"),
+			boshtbl.NewHeader("Details"),
+		},
+	}
+
+	for _, pkgDiff := range pkgDiffs {
+		var details []string
+
+		for _, dep := range pkgDiff.AddedDependencies {
+			details = append(details, fmt.Sprintf("added dependency '%s'", dep))
+		}
+
+		for _, dep := range pkgDiff.RemovedDependencies {
+			details = append(details, fmt.Sprintf("removed dependency '%s'", dep))
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(pkgDiff.Name),
+			boshtbl.NewValueString(string(pkgDiff.Change)),
+			boshtbl.NewValueString(pkgDiff.OldFingerprint),
+			boshtbl.NewValueString(pkgDiff.NewFingerprint),
+			boshtbl.NewValueStrings(details),
+		})
+	}
+
+	c.ui.PrintTable(table)
+}
+
+func (c DiffReleaseCmd) propertyDetails(propDiff boshreldiff.PropertyDiff) string {
+	switch propDiff.Change {
+	case boshreldiff.Added:
+		if propDiff.NewDefault == nil {
+			return fmt.Sprintf("added property '%s'", propDiff.Name)
+		}
+		return fmt.Sprintf("added property '%s' with default %s", propDiff.Name, c.value(propDiff.NewDefault))
+
+	case boshreldiff.Removed:
+		return fmt.Sprintf("removed property '%s'", propDiff.Name)
+
+	default:
+		return fmt.Sprintf("changed property '%s' default from %s to %s",
+			propDiff.Name, c.value(propDiff.OldDefault), c.value(propDiff.NewDefault))
+	}
+}
+
+func (c DiffReleaseCmd) linkDetails(kind string, linkDiff boshreldiff.LinkDiff) string {
+	switch linkDiff.Change {
+	case boshreldiff.Added:
+		return fmt.Sprintf("added %s link '%s' (%s)", kind, linkDiff.Name, c.link(linkDiff.New))
+
+	case boshreldiff.Removed:
+		return fmt.Sprintf("removed %s link '%s' (%s)", kind, linkDiff.Name, c.link(linkDiff.Old))
+
+	default:
+		return fmt.Sprintf("changed %s link '%s' from (%s) to (%s)",
+			kind, linkDiff.Name, c.link(linkDiff.Old), c.link(linkDiff.New))
+	}
+}
+
+func (c DiffReleaseCmd) link(link boshjob.LinkDefinition) string {
+	if link.Optional {
+		return fmt.Sprintf("type '%s', optional", link.Type)
+	}
+	return fmt.Sprintf("type '%s'", link.Type)
+}
+
+func (c DiffReleaseCmd) value(val interface{}) string {
+	bytes, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%#v", val)
+	}
+
+	return string(bytes)
+}
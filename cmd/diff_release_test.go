@@ -0,0 +1,279 @@
+package cmd_test
+
+import (
+	"errors"
+
+	semver "github.com/cppforlife/go-semi-semantic/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakejob "github.com/cloudfoundry/bosh-cli/release/job/jobfakes"
+	boshman "github.com/cloudfoundry/bosh-cli/release/manifest"
+	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/releasedir/releasedirfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("DiffReleaseCmd", func() {
+	var (
+		releaseReader *fakerel.FakeReader
+		releaseDir    *fakereldir.FakeReleaseDir
+		jobReader     *fakejob.FakeArchiveReader
+		director      *fakedir.FakeDirector
+		directorErr   error
+		fs            *fakesys.FakeFileSystem
+		ui            *fakeui.FakeUI
+		command       DiffReleaseCmd
+	)
+
+	BeforeEach(func() {
+		releaseReader = &fakerel.FakeReader{}
+		releaseDir = &fakereldir.FakeReleaseDir{}
+		jobReader = &fakejob.FakeArchiveReader{}
+		director = &fakedir.FakeDirector{}
+		directorErr = nil
+		fs = fakesys.NewFakeFileSystem()
+		ui = &fakeui.FakeUI{}
+
+		releaseDirFactory := func(dir DirOrCWDArg) boshreldir.ReleaseDir {
+			Expect(dir).To(Equal(DirOrCWDArg{Path: "/dir"}))
+			return releaseDir
+		}
+
+		directorFunc := func() (boshdir.Director, error) { return director, directorErr }
+
+		command = NewDiffReleaseCmd(releaseReader, releaseDirFactory, jobReader, directorFunc, fs, ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts DiffReleaseOpts
+		)
+
+		BeforeEach(func() {
+			opts = DiffReleaseOpts{Directory: DirOrCWDArg{Path: "/dir"}}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		newJob := func(name, fp string) *boshjob.Job {
+			return boshjob.NewJob(NewExistingResource(name, fp, name+"-sha1"))
+		}
+
+		newPkg := func(name, fp string) *boshpkg.Package {
+			return boshpkg.NewPackage(NewExistingResource(name, fp, name+"-sha1"), nil)
+		}
+
+		Context("when comparing release tarball with release on the Director", func() {
+			var (
+				tarballRelease  *fakerel.FakeRelease
+				directorRelease *fakedir.FakeRelease
+			)
+
+			BeforeEach(func() {
+				opts.Args = DiffReleaseArgs{From: "/release.tgz", To: "director:rel/2"}
+
+				fs.WriteFileString("/release.tgz", "")
+
+				job := newJob("changed-job", "old-job-fp")
+				job.Properties = map[string]boshjob.PropertyDefinition{"prop": {}}
+				job.Provides = []boshjob.LinkDefinition{{Name: "db", Type: "postgres"}}
+
+				pkg1 := newPkg("same-pkg", "pkg1-fp")
+				pkg2 := boshpkg.NewPackage(NewExistingResource("removed-pkg", "pkg2-fp", "pkg2-sha1"), []string{"same-pkg"})
+				Expect(pkg2.AttachDependencies([]*boshpkg.Package{pkg1})).To(Succeed())
+
+				tarballRelease = &fakerel.FakeRelease{}
+				tarballRelease.JobsReturns([]*boshjob.Job{job})
+				tarballRelease.PackagesReturns([]*boshpkg.Package{pkg1, pkg2})
+				releaseReader.ReadReturns(tarballRelease, nil)
+
+				directorRelease = &fakedir.FakeRelease{}
+				directorRelease.JobsReturns([]boshdir.Job{
+					{
+						Name:          "changed-job",
+						Fingerprint:   "new-job-fp",
+						LinksProvided: []boshdir.Link{{Name: "db", Type: "mysql"}},
+					},
+					{Name: "added-job", Fingerprint: "added-job-fp"},
+				}, nil)
+				directorRelease.PackagesReturns([]boshdir.Package{
+					{Name: "same-pkg", Fingerprint: "pkg1-fp"},
+				}, nil)
+				director.FindReleaseReturns(directorRelease, nil)
+			})
+
+			It("shows changed jobs and packages", func() {
+				Expect(act()).ToNot(HaveOccurred())
+
+				Expect(releaseReader.ReadArgsForCall(0)).To(Equal("/release.tgz"))
+				Expect(director.FindReleaseArgsForCall(0)).To(Equal(boshdir.NewReleaseSlug("rel", "2")))
+
+				Expect(ui.Tables).To(Equal([]boshtbl.Table{
+					{
+						Content: "jobs",
+						Header: []boshtbl.Header{
+							boshtbl.NewHeader("Job"),
+							boshtbl.NewHeader("Change"),
+							boshtbl.NewHeader("From"),
+							boshtbl.NewHeader("To"),
+							boshtbl.NewHeader("Details"),
+						},
+						Rows: [][]boshtbl.Value{
+							{
+								boshtbl.NewValueString("added-job"),
+								boshtbl.NewValueString("added"),
+								boshtbl.NewValueString(""),
+								boshtbl.NewValueString("added-job-fp"),
+								boshtbl.NewValueStrings(nil),
+							},
+							{
+								boshtbl.NewValueString("changed-job"),
+								boshtbl.NewValueString("changed"),
+								boshtbl.NewValueString("old-job-fp"),
+								boshtbl.NewValueString("new-job-fp"),
+								boshtbl.NewValueStrings([]string{
+									"changed provided link 'db' from (type 'postgres') to (type 'mysql')",
+								}),
+							},
+						},
+					},
+					{
+						Content: "packages",
+						Header: []boshtbl.Header{
+							boshtbl.NewHeader("Package"),
+							boshtbl.NewHeader("Change"),
+							boshtbl.NewHeader("From"),
+							boshtbl.NewHeader("To"),
+							boshtbl.NewHeader("Details"),
+						},
+						Rows: [][]boshtbl.Value{
+							{
+								boshtbl.NewValueString("removed-pkg"),
+								boshtbl.NewValueString("removed"),
+								boshtbl.NewValueString("pkg2-fp"),
+								boshtbl.NewValueString(""),
+								boshtbl.NewValueStrings(nil),
+							},
+						},
+					},
+				}))
+			})
+
+			It("cleans up extracted release tarball", func() {
+				Expect(act()).ToNot(HaveOccurred())
+				Expect(tarballRelease.CleanUpCallCount()).To(Equal(1))
+			})
+
+			It("returns error if reading release tarball fails", func() {
+				releaseReader.ReadReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+
+			It("returns error if director cannot be reached", func() {
+				directorErr = errors.New("fake-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+				Expect(tarballRelease.CleanUpCallCount()).To(Equal(1))
+			})
+
+			It("returns error if director release slug is invalid", func() {
+				opts.Args.To = "director:rel"
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected release 'rel' to be in format 'name/version'"))
+			})
+		})
+
+		Context("when comparing release versions in release directory", func() {
+			BeforeEach(func() {
+				opts.Args = DiffReleaseArgs{From: "1", To: "rel/2"}
+
+				releaseDir.FindReleaseStub = func(name string, version semver.Version) (boshrel.Release, error) {
+					release := &fakerel.FakeRelease{}
+					job := boshjob.NewJob(NewResourceWithBuiltArchive("job", "job-fp-"+version.AsString(), "/job.tgz", "job-sha1"))
+					release.JobsReturns([]*boshjob.Job{job})
+					release.PackagesReturns([]*boshpkg.Package{newPkg("pkg", "pkg-fp")})
+					return release, nil
+				}
+
+				jobReader.ReadStub = func(ref boshman.JobRef, path string) (*boshjob.Job, error) {
+					job := newJob(ref.Name, ref.Fingerprint)
+					job.Properties = map[string]boshjob.PropertyDefinition{
+						"prop": {Default: ref.Fingerprint},
+					}
+					return job, nil
+				}
+			})
+
+			It("shows property changes read from job archives", func() {
+				Expect(act()).ToNot(HaveOccurred())
+
+				name, version := releaseDir.FindReleaseArgsForCall(0)
+				Expect(name).To(BeEmpty())
+				Expect(version.AsString()).To(Equal("1"))
+
+				name, version = releaseDir.FindReleaseArgsForCall(1)
+				Expect(name).To(Equal("rel"))
+				Expect(version.AsString()).To(Equal("2"))
+
+				ref, path := jobReader.ReadArgsForCall(0)
+				Expect(ref).To(Equal(boshman.JobRef{Name: "job", Fingerprint: "job-fp-1", SHA1: "job-sha1"}))
+				Expect(path).To(Equal("/job.tgz"))
+
+				Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("job"),
+						boshtbl.NewValueString("changed"),
+						boshtbl.NewValueString("job-fp-1"),
+						boshtbl.NewValueString("job-fp-2"),
+						boshtbl.NewValueStrings([]string{
+							`changed property 'prop' default from "job-fp-1" to "job-fp-2"`,
+						}),
+					},
+				}))
+				Expect(ui.Tables[1].Rows).To(BeEmpty())
+			})
+
+			It("shows that there are no differences for the same versions", func() {
+				opts.Args.To = "1"
+
+				Expect(act()).ToNot(HaveOccurred())
+				Expect(ui.Said).To(Equal([]string{"No differences"}))
+				Expect(ui.Tables).To(BeEmpty())
+			})
+
+			It("returns error if version is invalid", func() {
+				opts.Args.To = "rel/2/3"
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected release 'rel/2/3' to be a release tarball"))
+			})
+
+			It("returns error if reading job archive fails", func() {
+				jobReader.ReadReturns(nil, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Reading job 'job': fake-err"))
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"net"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,12 +12,14 @@ import (
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpilocal "github.com/cloudfoundry/bosh-cli/cpi/local"
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	biinstancestate "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bipreflight "github.com/cloudfoundry/bosh-cli/deployment/preflight"
 	bideplrel "github.com/cloudfoundry/bosh-cli/deployment/release"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
@@ -32,7 +35,6 @@ import (
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
-	bitemplateerb "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
 )
 
@@ -43,6 +45,7 @@ type envFactory struct {
 	manifestOp   patch.Op
 
 	deploymentStateService     biconfig.DeploymentStateService
+	checkpointRepo             biconfig.CheckpointRepo
 	installationManifestParser ReleaseSetAndInstallationManifestParser
 
 	releaseManager  boshinst.ReleaseManager
@@ -57,8 +60,9 @@ type envFactory struct {
 	vmManagerFactory       bivm.ManagerFactory
 	stemcellManagerFactory bistemcell.ManagerFactory
 
-	instanceManagerFactory   biinstance.ManagerFactory
-	deploymentManagerFactory bidepl.ManagerFactory
+	instanceManagerFactory         biinstance.ManagerFactory
+	localCPIInstanceManagerFactory biinstance.ManagerFactory
+	deploymentManagerFactory       bidepl.ManagerFactory
 
 	agentClientFactory bihttpagent.AgentClientFactory
 	blobstoreFactory   biblobstore.Factory
@@ -66,7 +70,7 @@ type envFactory struct {
 	deploymentRecord   bidepl.Record
 }
 
-func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manifestVars boshtpl.Variables, manifestOp patch.Op) *envFactory {
+func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manifestVars boshtpl.Variables, manifestOp patch.Op, parallel int, compiledPackageCachePath string) *envFactory {
 	f := envFactory{
 		deps:         deps,
 		manifestPath: manifestPath,
@@ -80,12 +84,15 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	// todo expand path?
 	workspaceRootPath := filepath.Join(os.Getenv("HOME"), ".bosh")
 
+	var compiledPackageCache bistatepkg.CompiledPackageCache
+
+	if len(compiledPackageCachePath) > 0 {
+		compiledPackageCache = bistatepkg.NewCompiledPackageCache(
+			compiledPackageCachePath, deps.FS, deps.Compressor, deps.Time)
+	}
+
 	{
-		tarballCacheBasePath := filepath.Join(workspaceRootPath, "downloads")
-		tarballCache := bitarball.NewCache(tarballCacheBasePath, deps.FS, deps.Logger)
-		httpClient := httpclient.NewHTTPClient(bitarball.HTTPClient, deps.Logger)
-		tarballProvider := bitarball.NewProvider(
-			tarballCache, deps.FS, httpClient, 3, 500*time.Millisecond, deps.Logger)
+		tarballProvider := newTarballProvider(deps, newTarballCache(deps))
 
 		releaseProvider := boshrel.NewProvider(
 			deps.CmdRunner, deps.Compressor, deps.DigestCalculator, deps.FS, deps.Logger)
@@ -105,14 +112,14 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 		}
 	}
 
-	f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
+	f.deploymentStateService = biconfig.NewDeploymentStateService(
 		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
 
 	{
 		registryServer := biregistry.NewServerManager(deps.Logger)
 		installerFactory := boshinst.NewInstallerFactory(
 			deps.UI, deps.CmdRunner, deps.Compressor, releaseJobResolver,
-			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms)
+			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms, deps.ERBRenderer, parallel, compiledPackageCache)
 
 		f.cpiInstaller = bicpirel.CpiInstaller{
 			ReleaseManager:   f.releaseManager,
@@ -124,17 +131,19 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	f.targetProvider = boshinst.NewTargetProvider(
 		f.deploymentStateService, deps.UUIDGen, filepath.Join(workspaceRootPath, "installations"))
 
+	stemcellRepo := biconfig.NewStemcellRepo(f.deploymentStateService, deps.UUIDGen)
+
 	{
 		diskRepo := biconfig.NewDiskRepo(f.deploymentStateService, deps.UUIDGen)
-		stemcellRepo := biconfig.NewStemcellRepo(f.deploymentStateService, deps.UUIDGen)
 		vmRepo := biconfig.NewVMRepo(f.deploymentStateService)
+		f.checkpointRepo = biconfig.NewCheckpointRepo(f.deploymentStateService)
 
 		f.diskManagerFactory = bidisk.NewManagerFactory(diskRepo, deps.Logger)
-		diskDeployer := bivm.NewDiskDeployer(f.diskManagerFactory, diskRepo, deps.Logger)
+		diskDeployer := bivm.NewDiskDeployer(f.diskManagerFactory, diskRepo, f.checkpointRepo, deps.Logger)
 
 		f.stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
 		f.vmManagerFactory = bivm.NewManagerFactory(
-			vmRepo, stemcellRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.Logger)
+			vmRepo, stemcellRepo, f.checkpointRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.Logger)
 
 		deploymentRepo := biconfig.NewDeploymentRepo(f.deploymentStateService)
 		releaseRepo := biconfig.NewReleaseRepo(f.deploymentStateService, deps.UUIDGen)
@@ -145,26 +154,37 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger)
 		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond)
 		f.agentClientFactory = bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger)
-		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
+		f.cloudFactory = bicloud.NewFactory(
+			deps.FS, deps.CmdRunner, bicpilocal.NewFactory(deps.FS, deps.UUIDGen, deps.Logger), deps.Logger)
 	}
 
 	{
-		erbRenderer := bitemplateerb.NewERBRenderer(deps.FS, deps.CmdRunner, deps.Logger)
-		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, deps.FS, deps.UUIDGen, deps.Logger)
-
-		builderFactory := biinstancestate.NewBuilderFactory(
-			bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex()),
-			releaseJobResolver,
-			bitemplate.NewJobListRenderer(jobRenderer, deps.Logger),
-			bitemplate.NewRenderedJobListCompressor(deps.FS, deps.Compressor, deps.DigestCalculator, deps.Logger),
-			deps.Logger,
-		)
+		jobRenderer := bitemplate.NewJobRenderer(deps.ERBRenderer, deps.FS, deps.UUIDGen, deps.Logger)
 
 		sshTunnelFactory := bisshtunnel.NewFactory(deps.Logger)
-		instanceFactory := biinstance.NewFactory(builderFactory)
 
-		f.instanceManagerFactory = biinstance.NewManagerFactory(
-			sshTunnelFactory, instanceFactory, deps.Logger)
+		newInstanceManagerFactory := func(cache bistatepkg.CompiledPackageCache) biinstance.ManagerFactory {
+			builderFactory := biinstancestate.NewBuilderFactory(
+				bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex()),
+				cache,
+				stemcellRepo,
+				releaseJobResolver,
+				bitemplate.NewJobListRenderer(jobRenderer, deps.Logger),
+				bitemplate.NewRenderedJobListCompressor(deps.FS, deps.Compressor, deps.DigestCalculator, deps.Logger),
+				parallel,
+				deps.FS,
+				deps.Logger,
+			)
+
+			instanceFactory := biinstance.NewFactory(builderFactory)
+
+			return biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, deps.Logger)
+		}
+
+		f.instanceManagerFactory = newInstanceManagerFactory(compiledPackageCache)
+
+		// Local CPI agent only pretends to compile packages hence they are never cached
+		f.localCPIInstanceManagerFactory = newInstanceManagerFactory(nil)
 	}
 
 	{
@@ -183,6 +203,18 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	return &f
 }
 
+// newTarballCache returns cache of release and stemcell tarballs downloaded by create-env
+func newTarballCache(deps BasicDeps) bitarball.Cache {
+	// todo expand path?
+	basePath := filepath.Join(os.Getenv("HOME"), ".bosh", "downloads")
+	return bitarball.NewCache(basePath, deps.FS, deps.Time, deps.Logger)
+}
+
+func newTarballProvider(deps BasicDeps, tarballCache bitarball.Cache) bitarball.Provider {
+	httpClient := httpclient.NewHTTPClient(bitarball.HTTPClient, deps.Logger)
+	return bitarball.NewProvider(tarballCache, deps.FS, httpClient, 3, 500*time.Millisecond, deps.Logger)
+}
+
 func (f *envFactory) Preparer() DeploymentPreparer {
 	return NewDeploymentPreparer(
 		f.deps.UI,
@@ -197,6 +229,7 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 		),
 		f.releaseManager,
 		f.deploymentRecord,
+		f.checkpointRepo,
 		f.cloudFactory,
 		f.stemcellManagerFactory,
 		f.agentClientFactory,
@@ -206,6 +239,14 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 			f.vmManagerFactory,
 			f.instanceManagerFactory,
 			f.deploymentFactory,
+			f.checkpointRepo,
+			f.deps.Logger,
+		),
+		bidepl.NewDeployer(
+			f.vmManagerFactory,
+			f.localCPIInstanceManagerFactory,
+			f.deploymentFactory,
+			f.checkpointRepo,
 			f.deps.Logger,
 		),
 		f.manifestPath,
@@ -223,6 +264,7 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 		),
 		NewTempRootConfigurator(f.deps.FS),
 		f.targetProvider,
+		bipreflight.NewChecker(net.LookupHost, f.deps.Logger),
 	)
 }
 
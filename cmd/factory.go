@@ -100,6 +100,11 @@ func (f Factory) New(args []string) (Cmd, error) {
 			if field.IsValid() {
 				field.Set(reflect.ValueOf(f.deps.FS))
 			}
+
+			field = stype.FieldByName("CmdRunner")
+			if field.IsValid() {
+				field.Set(reflect.ValueOf(f.deps.CmdRunner))
+			}
 		}
 	}
 
@@ -112,6 +117,19 @@ func (f Factory) New(args []string) (Cmd, error) {
 		return Cmd{}, errors.New("BOSH_USER is deprecated use BOSH_CLIENT instead")
 	}
 
+	switch boshOpts.OutputOpt {
+	case "":
+	case "ndjson":
+		boshOpts.JSONStreamOpt = true
+	default:
+		// Unknown values should not break every command; keep the default UI
+		f.deps.UI.ErrorLinef("Warning: Ignoring BOSH_OUTPUT value '%s', expected 'ndjson'", boshOpts.OutputOpt)
+	}
+
+	if boshOpts.JSONOpt && boshOpts.JSONStreamOpt {
+		return Cmd{}, errors.New("Expected only one of --json or --json-stream to be specified")
+	}
+
 	// --help and --version result in errors; turn them into successful output cmds
 	if typedErr, ok := err.(*goflags.Error); ok {
 		if typedErr.Type == goflags.ErrHelp {
@@ -13,6 +13,7 @@ import (
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
 
 var _ = Describe("Factory", func() {
@@ -359,6 +360,8 @@ var _ = Describe("Factory", func() {
 			boshOpts.CACertOpt.FS = nil // fs is populated by factory.New
 			boshOpts.UploadRelease = UploadReleaseOpts{}
 			boshOpts.ExportRelease = ExportReleaseOpts{}
+			boshOpts.DiffRelease = DiffReleaseOpts{}
+			boshOpts.LintRelease = LintReleaseOpts{}
 			boshOpts.RunErrand = RunErrandOpts{}
 			boshOpts.Logs = LogsOpts{}
 			boshOpts.Interpolate = InterpolateOpts{}
@@ -378,6 +381,8 @@ var _ = Describe("Factory", func() {
 			boshOpts.SCP = SCPOpts{}
 			boshOpts.Deploy = DeployOpts{}
 			boshOpts.UpdateRuntimeConfig = UpdateRuntimeConfigOpts{}
+			boshOpts.RenderTemplates = RenderTemplatesOpts{}
+			boshOpts.CreateEnv = CreateEnvOpts{}
 			return boshOpts
 		}
 
@@ -436,8 +441,46 @@ var _ = Describe("Factory", func() {
 
 		It("errors when BOSH_USER is set", func() {
 			os.Setenv("BOSH_USER", "bar")
+			defer os.Unsetenv("BOSH_USER")
+
 			_, err := factory.New([]string{})
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("can set --json-stream", func() {
+			cmd, err := factory.New([]string{"--json-stream", "locks"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmd.BoshOpts.JSONStreamOpt).To(BeTrue())
+		})
+
+		It("enables json stream when BOSH_OUTPUT is ndjson", func() {
+			os.Setenv("BOSH_OUTPUT", "ndjson")
+			defer os.Unsetenv("BOSH_OUTPUT")
+
+			cmd, err := factory.New([]string{"locks"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmd.BoshOpts.JSONStreamOpt).To(BeTrue())
+		})
+
+		It("warns and keeps default output when BOSH_OUTPUT is set to an unknown value", func() {
+			os.Setenv("BOSH_OUTPUT", "xml")
+			defer os.Unsetenv("BOSH_OUTPUT")
+
+			ui := &fakeui.FakeUI{}
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			deps := NewBasicDeps(boshui.NewWrappingConfUI(ui, logger), logger)
+			deps.FS = fs
+
+			cmd, err := NewFactory(deps).New([]string{"locks"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmd.BoshOpts.JSONStreamOpt).To(BeFalse())
+			Expect(ui.Errors).To(Equal([]string{"Warning: Ignoring BOSH_OUTPUT value 'xml', expected 'ndjson'"}))
+		})
+
+		It("errors when both --json and --json-stream are set", func() {
+			_, err := factory.New([]string{"--json", "--json-stream", "locks"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected only one of --json or --json-stream to be specified"))
+		})
 	})
 })
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type LintManifestCmd struct {
+	ui     boshui.UI
+	linter boshdirman.Linter
+}
+
+func NewLintManifestCmd(ui boshui.UI, linter boshdirman.Linter) LintManifestCmd {
+	return LintManifestCmd{ui: ui, linter: linter}
+}
+
+func (c LintManifestCmd) Run(opts LintManifestOpts) error {
+	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+
+	// Keep variable references to find out which variables are used
+	rawBytes, err := tpl.Evaluate(boshtpl.StaticVariables{}, opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsReadOnlyVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	input := boshdirman.LintInput{
+		Manifest:    bytes,
+		RawManifest: rawBytes,
+		CloudConfig: opts.CloudConfig.Bytes,
+	}
+
+	for _, rc := range opts.RuntimeConfigs {
+		input.RuntimeConfigs = append(input.RuntimeConfigs, rc.Bytes)
+	}
+
+	problems, err := c.linter.Lint(input)
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "problems",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Source"),
+			boshtbl.NewHeader("Path"),
+			boshtbl.NewHeader("Problem"),
+		},
+	}
+
+	for _, p := range problems {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(p.Source),
+			boshtbl.NewValueString(p.Path),
+			boshtbl.NewValueString(p.Message),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if len(problems) > 0 {
+		return bosherr.Errorf("Found %d problem(s) in manifest", len(problems))
+	}
+
+	return nil
+}
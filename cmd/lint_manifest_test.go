@@ -0,0 +1,129 @@
+package cmd_test
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("LintManifestCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		command LintManifestCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		command = NewLintManifestCmd(ui, boshdirman.NewLinter())
+	})
+
+	Describe("Run", func() {
+		var (
+			opts LintManifestOpts
+		)
+
+		BeforeEach(func() {
+			opts = LintManifestOpts{
+				Args: LintManifestArgs{
+					Manifest: FileBytesArg{Bytes: []byte(`
+name: dep
+update: {canaries: ((canaries))}
+instance_groups:
+- name: web
+  instances: 1
+  vm_type: small
+  networks: [{name: default}]
+variables:
+- {name: canaries, type: password}
+`)},
+				},
+				CloudConfig: FileBytesArg{Bytes: []byte("vm_types: [{name: small}]\nnetworks: [{name: default}]")},
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("prints empty problems table if manifest has no problems", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Content).To(Equal("problems"))
+			Expect(ui.Table.Rows).To(BeEmpty())
+		})
+
+		It("applies ops and variables before linting and reports problems", func() {
+			opts.VarFlags = VarFlags{
+				VarKVs: []boshtpl.VarKV{{Name: "canaries", Value: -1}},
+			}
+
+			opts.OpsFlags = OpsFlags{
+				OpsFiles: []OpsFileArg{
+					{Ops: patch.Ops{
+						patch.ReplaceOp{Path: patch.MustNewPointerFromString("/instance_groups/0/vm_type"), Value: "large"},
+					}},
+				},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Found 2 problem(s) in manifest"))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "problems",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Source"),
+					boshtbl.NewHeader("Path"),
+					boshtbl.NewHeader("Problem"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("manifest"),
+						boshtbl.NewValueString("update.canaries"),
+						boshtbl.NewValueString("must be >= 0"),
+					},
+					{
+						boshtbl.NewValueString("manifest"),
+						boshtbl.NewValueString("instance_groups[0].vm_type"),
+						boshtbl.NewValueString("must refer to a VM type ('large' is not defined)"),
+					},
+				},
+			}))
+		})
+
+		It("lints runtime configs", func() {
+			opts.RuntimeConfigs = []FileBytesArg{{Bytes: []byte("addons: [{name: addon, jobs: [{name: job}]}]")}}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("runtime config"),
+					boshtbl.NewValueString("addons[0].jobs[0].release"),
+					boshtbl.NewValueString("must be provided"),
+				},
+			}))
+		})
+
+		It("returns error if manifest cannot be evaluated", func() {
+			opts.Args.Manifest = FileBytesArg{Bytes: []byte("-")}
+			opts.OpsFlags = OpsFlags{
+				OpsFiles: []OpsFileArg{
+					{Ops: patch.Ops{patch.RemoveOp{Path: patch.MustNewPointerFromString("/a")}}},
+				},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Evaluating manifest"))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshrellint "github.com/cloudfoundry/bosh-cli/release/lint"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type LintReleaseCmd struct {
+	linter boshrellint.Linter
+	ui     boshui.UI
+}
+
+func NewLintReleaseCmd(linter boshrellint.Linter, ui boshui.UI) LintReleaseCmd {
+	return LintReleaseCmd{linter: linter, ui: ui}
+}
+
+func (c LintReleaseCmd) Run() error {
+	problems, err := c.linter.Lint()
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		c.ui.PrintLinef("No problems found")
+		return nil
+	}
+
+	table := boshtbl.Table{
+		Content: "problems",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Problem"),
+		},
+	}
+
+	for _, problem := range problems {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(problem.Type),
+			boshtbl.NewValueString(problem.Name),
+			boshtbl.NewValueString(problem.Message),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return bosherr.Errorf("Found %d release problem(s)", len(problems))
+}
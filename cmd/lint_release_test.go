@@ -0,0 +1,80 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshrellint "github.com/cloudfoundry/bosh-cli/release/lint"
+	fakerellint "github.com/cloudfoundry/bosh-cli/release/lint/lintfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("LintReleaseCmd", func() {
+	var (
+		linter  *fakerellint.FakeLinter
+		ui      *fakeui.FakeUI
+		command LintReleaseCmd
+	)
+
+	BeforeEach(func() {
+		linter = &fakerellint.FakeLinter{}
+		ui = &fakeui.FakeUI{}
+		command = NewLintReleaseCmd(linter, ui)
+	})
+
+	Describe("Run", func() {
+		act := func() error { return command.Run() }
+
+		It("succeeds if there are no problems", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(Equal([]string{"No problems found"}))
+		})
+
+		It("shows problems and returns error", func() {
+			linter.LintReturns([]boshrellint.Problem{
+				{Type: "package", Name: "pkg", Message: "Package is not used by any job"},
+				{Type: "job", Name: "job", Message: "Template 'a.erb' does not exist in templates/"},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Found 2 release problem(s)"))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "problems",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Type"),
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Problem"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("package"),
+						boshtbl.NewValueString("pkg"),
+						boshtbl.NewValueString("Package is not used by any job"),
+					},
+					{
+						boshtbl.NewValueString("job"),
+						boshtbl.NewValueString("job"),
+						boshtbl.NewValueString("Template 'a.erb' does not exist in templates/"),
+					},
+				},
+			}))
+		})
+
+		It("returns error if linting fails", func() {
+			linter.LintReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+)
+
+// LocalReleaseReader reads jobs and packages from either a release tarball
+// or a release directory without building a release out of the directory.
+type LocalReleaseReader struct {
+	archiveReader     boshrel.Reader
+	releaseDirFactory func(string) (boshrel.Reader, boshreldir.ReleaseDir)
+	fs                boshsys.FileSystem
+}
+
+func NewLocalReleaseReader(
+	archiveReader boshrel.Reader,
+	releaseDirFactory func(string) (boshrel.Reader, boshreldir.ReleaseDir),
+	fs boshsys.FileSystem,
+) LocalReleaseReader {
+	return LocalReleaseReader{
+		archiveReader:     archiveReader,
+		releaseDirFactory: releaseDirFactory,
+		fs:                fs,
+	}
+}
+
+func (r LocalReleaseReader) Read(path string) (boshrel.Release, error) {
+	expandedPath, err := r.fs.ExpandPath(path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Expanding release path '%s'", path)
+	}
+
+	if !r.fs.FileExists(expandedPath) {
+		return nil, bosherr.Errorf("Expected release '%s' to exist", expandedPath)
+	}
+
+	stat, err := r.fs.Stat(expandedPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Checking release path '%s'", expandedPath)
+	}
+
+	if !stat.IsDir() {
+		release, err := r.archiveReader.Read(expandedPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading release tarball '%s'", expandedPath)
+		}
+
+		return release, nil
+	}
+
+	releaseReader, releaseDir := r.releaseDirFactory(expandedPath)
+
+	name, err := releaseDir.DefaultName()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Determining release name for '%s'", expandedPath)
+	}
+
+	release, err := releaseReader.Read(expandedPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading release directory '%s'", expandedPath)
+	}
+
+	release.SetName(name)
+
+	return release, nil
+}
@@ -0,0 +1,100 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/releasedir/releasedirfakes"
+)
+
+var _ = Describe("LocalReleaseReader", func() {
+	var (
+		archiveReader *fakerel.FakeReader
+		dirReader     *fakerel.FakeReader
+		releaseDir    *fakereldir.FakeReleaseDir
+		fs            *fakesys.FakeFileSystem
+		reader        LocalReleaseReader
+	)
+
+	BeforeEach(func() {
+		archiveReader = &fakerel.FakeReader{}
+		dirReader = &fakerel.FakeReader{}
+		releaseDir = &fakereldir.FakeReleaseDir{}
+
+		releaseDirFactory := func(path string) (boshrel.Reader, boshreldir.ReleaseDir) {
+			Expect(path).To(Equal("/rel-dir"))
+			return dirReader, releaseDir
+		}
+
+		fs = fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/rel.tgz", "")
+		fs.MkdirAll("/rel-dir", 0755)
+
+		reader = NewLocalReleaseReader(archiveReader, releaseDirFactory, fs)
+	})
+
+	Describe("Read", func() {
+		It("reads release tarball", func() {
+			release := &fakerel.FakeRelease{}
+			archiveReader.ReadReturns(release, nil)
+
+			result, err := reader.Read("/rel.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(release))
+
+			Expect(archiveReader.ReadArgsForCall(0)).To(Equal("/rel.tgz"))
+			Expect(dirReader.ReadCallCount()).To(Equal(0))
+		})
+
+		It("reads release directory and names release based on its config", func() {
+			release := &fakerel.FakeRelease{}
+			dirReader.ReadReturns(release, nil)
+			releaseDir.DefaultNameReturns("dir-rel", nil)
+
+			result, err := reader.Read("/rel-dir")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(release))
+
+			Expect(dirReader.ReadArgsForCall(0)).To(Equal("/rel-dir"))
+			Expect(release.SetNameArgsForCall(0)).To(Equal("dir-rel"))
+			Expect(archiveReader.ReadCallCount()).To(Equal(0))
+		})
+
+		It("returns error if reading release tarball fails", func() {
+			archiveReader.ReadReturns(nil, errors.New("fake-err"))
+
+			_, err := reader.Read("/rel.tgz")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if release directory name cannot be determined", func() {
+			releaseDir.DefaultNameReturns("", errors.New("fake-err"))
+
+			_, err := reader.Read("/rel-dir")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if reading release directory fails", func() {
+			dirReader.ReadReturns(nil, errors.New("fake-err"))
+
+			_, err := reader.Read("/rel-dir")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if release path does not exist", func() {
+			_, err := reader.Read("/missing")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected release '/missing' to exist"))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/go-patch/patch"
 
@@ -18,6 +20,7 @@ type BoshOpts struct {
 	EnvironmentOpt string    `long:"environment" short:"e" description:"Director environment name or URL" env:"BOSH_ENVIRONMENT"`
 	CACertOpt      CACertArg `long:"ca-cert"               description:"Director CA certificate path or value" env:"BOSH_CA_CERT"`
 	Sha2           bool      `long:"sha2"                  description:"Use SHA256 checksums"`
+	NativeERB      bool      `long:"native-erb"            description:"Render job templates with experimental built-in renderer instead of ruby" env:"BOSH_NATIVE_ERB"`
 
 	// Hidden
 	UsernameOpt string `long:"user" hidden:"true" env:"BOSH_USER"`
@@ -31,6 +34,8 @@ type BoshOpts struct {
 	// Output formatting
 	ColumnOpt         []ColumnOpt `long:"column"                    description:"Filter to show only given column(s)"`
 	JSONOpt           bool        `long:"json"                      description:"Output as JSON"`
+	JSONStreamOpt     bool        `long:"json-stream"               description:"Stream task events as newline-delimited JSON"`
+	OutputOpt         string      `long:"output" hidden:"true" env:"BOSH_OUTPUT"`
 	TTYOpt            bool        `long:"tty"                       description:"Force TTY-like output"`
 	NoColorOpt        bool        `long:"no-color"                  description:"Toggle colorized output"`
 	NonInteractiveOpt bool        `long:"non-interactive" short:"n" description:"Don't ask for user input" env:"BOSH_NON_INTERACTIVE"`
@@ -40,11 +45,14 @@ type BoshOpts struct {
 	// -----> Director management
 
 	// Environments
-	Environment  EnvironmentOpts  `command:"environment"  alias:"env"  description:"Show environment"`
-	Environments EnvironmentsOpts `command:"environments" alias:"envs" description:"List environments"`
-	CreateEnv    CreateEnvOpts    `command:"create-env"                description:"Create or update BOSH environment"`
-	DeleteEnv    DeleteEnvOpts    `command:"delete-env"                description:"Delete BOSH environment"`
-	AliasEnv     AliasEnvOpts     `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
+	Environment   EnvironmentOpts   `command:"environment"  alias:"env"  description:"Show environment"`
+	Environments  EnvironmentsOpts  `command:"environments" alias:"envs" description:"List environments"`
+	CreateEnv     CreateEnvOpts     `command:"create-env"                description:"Create or update BOSH environment"`
+	DeleteEnv     DeleteEnvOpts     `command:"delete-env"                description:"Delete BOSH environment"`
+	CompiledCache CompiledCacheOpts `command:"compiled-cache"            description:"Export, import or prune compiled package cache used by create-env"`
+	Cache         CacheOpts         `command:"cache"                     description:"List, prune or pre-download release and stemcell tarballs cached by create-env"`
+	State         StateOpts         `command:"state"                     description:"Show or edit deployment state used by create-env"`
+	AliasEnv      AliasEnvOpts      `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
 
 	// Authentication
 	LogIn  LogInOpts  `command:"log-in"  alias:"l" alias:"login"  description:"Log in"`
@@ -71,6 +79,8 @@ type BoshOpts struct {
 	RuntimeConfig       RuntimeConfigOpts       `command:"runtime-config"        alias:"rc"  description:"Show current runtime config"`
 	UpdateRuntimeConfig UpdateRuntimeConfigOpts `command:"update-runtime-config" alias:"urc" description:"Update current runtime config"`
 
+	DiffConfig DiffConfigOpts `command:"diff-config" description:"Show differences between two configs (local files or latest on the Director)"`
+
 	// Deployments
 	Deployment       DeploymentOpts       `command:"deployment"        alias:"dep"             description:"Show deployment information"`
 	Deployments      DeploymentsOpts      `command:"deployments"       alias:"ds" alias:"deps" description:"List deployments"`
@@ -79,8 +89,17 @@ type BoshOpts struct {
 	Deploy   DeployOpts   `command:"deploy"   alias:"d"   description:"Update deployment"`
 	Manifest ManifestOpts `command:"manifest" alias:"man" description:"Show deployment manifest"`
 
+	DiffManifest DiffManifestOpts `command:"diff-manifest" description:"Show differences between two manifests (local files or deployed)"`
+	LintManifest LintManifestOpts `command:"lint-manifest" description:"Check manifest for problems without contacting the Director"`
+
+	ValidateProperties ValidatePropertiesOpts `command:"validate-properties" description:"Check manifest properties against release job specs"`
+
+	RenderTemplates RenderTemplatesOpts `command:"render-templates" description:"Render job templates locally for each instance"`
+
 	Interpolate InterpolateOpts `command:"interpolate" alias:"int" description:"Interpolates variables into a manifest"`
 
+	VarsStore VarsStoreOpts `command:"vars-store" description:"Encrypt, decrypt or rekey variables store file"`
+
 	// Events
 	Events EventsOpts `command:"events" description:"List events"`
 	Event  EventOpts  `command:"event" description:"Show event details"`
@@ -96,6 +115,7 @@ type BoshOpts struct {
 	UploadRelease  UploadReleaseOpts  `command:"upload-release"  alias:"ur"   description:"Upload release"`
 	ExportRelease  ExportReleaseOpts  `command:"export-release"               description:"Export the compiled release to a tarball"`
 	InspectRelease InspectReleaseOpts `command:"inspect-release"              description:"List release contents such as jobs"`
+	DiffRelease    DiffReleaseOpts    `command:"diff-release"                 description:"Show differences between jobs and packages of two releases"`
 	DeleteRelease  DeleteReleaseOpts  `command:"delete-release"  alias:"delr" description:"Delete release"`
 
 	// Errands
@@ -143,6 +163,7 @@ type BoshOpts struct {
 	GeneratePackage GeneratePackageOpts `command:"generate-package"            description:"Generate package"`
 	CreateRelease   CreateReleaseOpts   `command:"create-release"   alias:"cr" description:"Create release"`
 	VendorPackage   VendorPackageOpts   `command:"vendor-package"              description:"Vendor package"`
+	LintRelease     LintReleaseOpts     `command:"lint-release"                description:"Check jobs, packages and spec files in release directory"`
 
 	// Hidden
 	Sha1ifyRelease  Sha1ifyReleaseOpts  `command:"sha1ify-release"  hidden:"true" description:"Convert release tarball to use SHA1"`
@@ -150,7 +171,7 @@ type BoshOpts struct {
 	FinalizeRelease FinalizeReleaseOpts `command:"finalize-release"               description:"Create final release from dev release tarball"`
 
 	// Blob management
-	Blobs       BlobsOpts       `command:"blobs"        description:"List blobs"`
+	Blobs       BlobsOpts       `command:"blobs"        description:"List blobs" subcommands-optional:"true"`
 	AddBlob     AddBlobOpts     `command:"add-blob"     description:"Add blob"`
 	RemoveBlob  RemoveBlobOpts  `command:"remove-blob"  description:"Remove blob"`
 	SyncBlobs   SyncBlobsOpts   `command:"sync-blobs"   description:"Sync blobs"`
@@ -168,8 +189,13 @@ type CreateEnvOpts struct {
 	Args CreateEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
-	Recreate  bool   `long:"recreate" description:"Recreate VM in deployment"`
+	StatePath            string `long:"state" value-name:"PATH" description:"State file path or URL (s3://, gcs://, file://)"`
+	Recreate             bool   `long:"recreate" description:"Recreate VM in deployment"`
+	Plan                 bool   `long:"plan" description:"Show what would change without deploying"`
+	PreflightOnly        bool   `long:"preflight-only" description:"Install CPI and run pre-flight checks without deploying"`
+	Parallel             int    `long:"parallel" description:"Compile packages in parallel with given number of workers (default: 1)" default:"1"`
+	CompiledPackageCache string `long:"compiled-package-cache" value-name:"PATH" description:"Compiled package cache directory or URL (s3://, gcs://, file://)" env:"BOSH_COMPILED_PACKAGE_CACHE"`
+	DeleteOrphans        bool   `long:"delete-orphans" description:"Delete VMs and disks orphaned by previously failed deploys"`
 	cmd
 }
 
@@ -181,7 +207,7 @@ type DeleteEnvOpts struct {
 	Args DeleteEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath string `long:"state" value-name:"PATH" description:"State file path or URL (s3://, gcs://, file://)"`
 	cmd
 }
 
@@ -189,6 +215,113 @@ type DeleteEnvArgs struct {
 	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type CompiledCacheOpts struct {
+	Export CompiledCacheExportOpts `command:"export" description:"Export compiled packages into a tarball"`
+	Import CompiledCacheImportOpts `command:"import" description:"Import compiled packages from a tarball"`
+	Prune  CompiledCachePruneOpts  `command:"prune" description:"Remove compiled packages from the cache"`
+}
+
+type CompiledCacheFlags struct {
+	Cache string `long:"cache" value-name:"PATH" description:"Compiled package cache directory or URL (s3://, gcs://, file://)" env:"BOSH_COMPILED_PACKAGE_CACHE"`
+}
+
+type CompiledCacheExportOpts struct {
+	Args CompiledCacheTarballArgs `positional-args:"true" required:"true"`
+	CompiledCacheFlags
+	cmd
+}
+
+type CompiledCacheImportOpts struct {
+	Args CompiledCacheTarballArgs `positional-args:"true" required:"true"`
+	CompiledCacheFlags
+	cmd
+}
+
+type CompiledCacheTarballArgs struct {
+	Path FileArg `positional-arg-name:"PATH" description:"Path to a compiled package cache tarball"`
+}
+
+type CompiledCachePruneOpts struct {
+	CompiledCacheFlags
+	OlderThan time.Duration `long:"older-than" value-name:"DURATION" description:"Only remove packages cached more than given duration ago (e.g. 720h)"`
+	All       bool          `long:"all" description:"Remove all packages"`
+	cmd
+}
+
+type CacheOpts struct {
+	Ls    CacheLsOpts    `command:"ls"    alias:"list" description:"List cached release and stemcell tarballs"`
+	Prune CachePruneOpts `command:"prune" description:"Remove cached release and stemcell tarballs"`
+	Warm  CacheWarmOpts  `command:"warm"  description:"Download release and stemcell tarballs referenced by a manifest"`
+}
+
+type CacheLsOpts struct {
+	cmd
+}
+
+type CachePruneOpts struct {
+	OlderThan time.Duration `long:"older-than" value-name:"DURATION" description:"Only remove tarballs not used within given duration (e.g. 720h)"`
+	All       bool          `long:"all" description:"Remove all tarballs"`
+	cmd
+}
+
+type CacheWarmOpts struct {
+	Args CacheWarmArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	cmd
+}
+
+type CacheWarmArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type StateOpts struct {
+	Show           StateShowOpts           `command:"show"            description:"Show VM, disks, stemcells and releases recorded in deployment state"`
+	SetVMCID       StateSetVMCIDOpts       `command:"set-vm-cid"      description:"Set current VM CID in deployment state"`
+	ForgetDisk     StateForgetDiskOpts     `command:"forget-disk"     description:"Remove disk from deployment state without deleting it"`
+	ForgetStemcell StateForgetStemcellOpts `command:"forget-stemcell" description:"Remove stemcell from deployment state without deleting it"`
+	Migrate        StateMigrateOpts        `command:"migrate"         description:"Migrate legacy bosh-deployments.yml to deployment state"`
+}
+
+type StateShowOpts struct {
+	Args StateArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type StateArgs struct {
+	Path string `positional-arg-name:"STATE-PATH" description:"Deployment state file path or URL"`
+}
+
+type StateSetVMCIDOpts struct {
+	Args StateCIDArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type StateForgetDiskOpts struct {
+	Args StateCIDArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type StateForgetStemcellOpts struct {
+	Args StateCIDArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type StateCIDArgs struct {
+	Path string `positional-arg-name:"STATE-PATH" description:"Deployment state file path or URL"`
+	CID  string `positional-arg-name:"CID"        description:"Cloud ID"`
+}
+
+type StateMigrateOpts struct {
+	Args StateMigrateArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type StateMigrateArgs struct {
+	LegacyPath string `positional-arg-name:"LEGACY-PATH" description:"Path to legacy bosh-deployments.yml"`
+	Path       string `positional-arg-name:"STATE-PATH"  description:"Deployment state file path or URL"`
+}
+
 // Environment
 type EnvironmentOpts struct {
 	cmd
@@ -231,6 +364,8 @@ type TaskOpts struct {
 	All        bool `long:"all" short:"a" description:"Include all task types (ssh, logs, vms, etc)"`
 	Deployment string
 
+	Summary bool `long:"summary" description:"Show instance timing summary after task event log"`
+
 	cmd
 }
 
@@ -290,6 +425,38 @@ type InterpolateArgs struct {
 	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a template that will be interpolated"`
 }
 
+type VarsStoreOpts struct {
+	Encrypt VarsStoreEncryptOpts `command:"encrypt" description:"Encrypt variables store file"`
+	Decrypt VarsStoreDecryptOpts `command:"decrypt" description:"Decrypt variables store file"`
+	Rekey   VarsStoreRekeyOpts   `command:"rekey"   description:"Re-encrypt variables store file with a new key"`
+}
+
+type VarsStoreEncryptOpts struct {
+	Args VarsStoreArgs `positional-args:"true" required:"true"`
+	VarsStoreKeyFlags
+	cmd
+}
+
+type VarsStoreDecryptOpts struct {
+	Args VarsStoreArgs `positional-args:"true" required:"true"`
+	VarsStoreKeyFlags
+	cmd
+}
+
+type VarsStoreRekeyOpts struct {
+	Args VarsStoreArgs `positional-args:"true" required:"true"`
+	VarsStoreKeyFlags
+
+	NewKey        FileBytesArg `long:"new-key"        value-name:"PATH"       description:"New key file"`
+	NewPassphrase string       `long:"new-passphrase" value-name:"PASSPHRASE" description:"New passphrase" env:"BOSH_VARS_STORE_NEW_PASSPHRASE"`
+
+	cmd
+}
+
+type VarsStoreArgs struct {
+	Path FileArg `positional-arg-name:"PATH" description:"Path to a variables store file"`
+}
+
 // Cloud config
 type CloudConfigOpts struct {
 	cmd
@@ -346,6 +513,23 @@ type UpdateRuntimeConfigArgs struct {
 	RuntimeConfig FileBytesArg `positional-arg-name:"PATH" description:"Path to a runtime config file"`
 }
 
+type DiffConfigOpts struct {
+	Args DiffConfigArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	Type     string `long:"type" description:"Config type" choice:"cloud" choice:"runtime" choice:"cpi" required:"true"`
+	NoRedact bool   `long:"no-redact" description:"Show non-redacted config diff"`
+
+	cmd
+}
+
+type DiffConfigArgs struct {
+	From string `positional-arg-name:"FROM" description:"Path to a config file or 'director:[NAME]' for latest config on the Director"`
+	To   string `positional-arg-name:"TO"   description:"Path to a config file or 'director:[NAME]' for latest config on the Director"`
+}
+
 // Deployments
 type DeploymentOpts struct {
 	cmd
@@ -373,6 +557,8 @@ type DeployOpts struct {
 
 	DryRun bool `long:"dry-run" description:"Renders job templates without altering deployment"`
 
+	Summary bool `long:"summary" description:"Show instance timing summary after deploy task finishes"`
+
 	cmd
 }
 
@@ -384,6 +570,72 @@ type ManifestOpts struct {
 	cmd
 }
 
+type DiffManifestOpts struct {
+	Args DiffManifestArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	NoRedact bool `long:"no-redact" description:"Show non-redacted manifest diff"`
+
+	cmd
+}
+
+type DiffManifestArgs struct {
+	From string `positional-arg-name:"FROM" description:"Path to a manifest file, 'deployment:[NAME]' for deployed manifest, or 'task:ID'/'event:ID' for manifest used by a past deploy"`
+	To   string `positional-arg-name:"TO"   description:"Path to a manifest file, 'deployment:[NAME]' for deployed manifest, or 'task:ID'/'event:ID' for manifest used by a past deploy"`
+}
+
+type LintManifestOpts struct {
+	Args LintManifestArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	CloudConfig    FileBytesArg   `long:"cloud-config"   value-name:"PATH" description:"Path to a cloud config file"`
+	RuntimeConfigs []FileBytesArg `long:"runtime-config" value-name:"PATH" description:"Path to a runtime config file (can be specified multiple times)"`
+
+	cmd
+}
+
+type LintManifestArgs struct {
+	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type ValidatePropertiesOpts struct {
+	Args ValidatePropertiesArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	Releases []string `long:"release" value-name:"PATH" description:"Path to a release tarball or release directory (can be specified multiple times)"`
+
+	cmd
+}
+
+type ValidatePropertiesArgs struct {
+	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type RenderTemplatesOpts struct {
+	Args RenderTemplatesArgs `positional-args:"true" required:"true"`
+
+	VarFlags
+	OpsFlags
+
+	Releases      []string     `long:"release"        value-name:"PATH" description:"Path to a release tarball or release directory (can be specified multiple times)"`
+	InstanceGroup string       `long:"instance-group" value-name:"NAME" description:"Only render templates for given instance group"`
+	SpecStub      FileBytesArg `long:"spec-stub"      value-name:"PATH" description:"Path to a YAML file with values merged into spec of each instance (e.g. networks, address)"`
+	LinksStub     FileBytesArg `long:"links-stub"     value-name:"PATH" description:"Path to a YAML file with links by name (each with properties, instances and address)"`
+	Directory     DirOrCWDArg  `long:"dir"                              description:"Destination directory" default:"."`
+
+	cmd
+}
+
+type RenderTemplatesArgs struct {
+	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
 type DeleteDeploymentOpts struct {
 	Force bool `long:"force" description:"Ignore errors"`
 	cmd
@@ -530,6 +782,19 @@ type InspectReleaseArgs struct {
 	Slug boshdir.ReleaseSlug `positional-arg-name:"NAME/VERSION"`
 }
 
+type DiffReleaseOpts struct {
+	Args DiffReleaseArgs `positional-args:"true" required:"true"`
+
+	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+
+	cmd
+}
+
+type DiffReleaseArgs struct {
+	From string `positional-arg-name:"FROM" description:"Path to a release tarball, '[NAME/]VERSION' in release directory or 'director:NAME/VERSION'"`
+	To   string `positional-arg-name:"TO"   description:"Path to a release tarball, '[NAME/]VERSION' in release directory or 'director:NAME/VERSION'"`
+}
+
 // Errands
 type ErrandsOpts struct {
 	cmd
@@ -829,6 +1094,11 @@ type VendorPackageArgs struct {
 	URL         DirOrCWDArg `positional-arg-name:"SRC-DIR" default:"."`
 }
 
+type LintReleaseOpts struct {
+	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+	cmd
+}
+
 type Sha1ifyReleaseOpts struct {
 	Args RedigestReleaseArgs `positional-args:"true"`
 
@@ -886,6 +1156,17 @@ type FinalizeReleaseArgs struct {
 // Blobs
 type BlobsOpts struct {
 	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+	Verify    bool        `long:"verify" description:"Verify local blob digests and presence of blobs and final release blobs in blobstore"`
+
+	PruneRemote BlobsPruneRemoteOpts `command:"prune-remote" description:"Show or delete blobstore objects not referenced by blobs or final releases"`
+	cmd
+}
+
+type BlobsPruneRemoteOpts struct {
+	Directory DirOrCWDArg `long:"dir" description:"Release directory path if not current working directory" default:"."`
+	Delete    bool        `long:"delete" description:"Delete unreferenced blobstore objects"`
+
+	IgnoreOtherBranches bool `long:"ignore-other-branches" description:"Acknowledge that blobs referenced only by other branches or previous commits are deleted"`
 	cmd
 }
 
@@ -123,6 +123,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("NativeERB", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("NativeERB", opts)).To(Equal(
+					`long:"native-erb" description:"Render job templates with experimental built-in renderer instead of ruby" env:"BOSH_NATIVE_ERB"`,
+				))
+			})
+		})
+
 		Describe("CACertOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("CACertOpt", opts)).To(Equal(
@@ -171,6 +179,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("JSONStreamOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("JSONStreamOpt", opts)).To(Equal(
+					`long:"json-stream" description:"Stream task events as newline-delimited JSON"`,
+				))
+			})
+		})
+
+		Describe("OutputOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("OutputOpt", opts)).To(Equal(
+					`long:"output" hidden:"true" env:"BOSH_OUTPUT"`,
+				))
+			})
+		})
+
 		Describe("TTYOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("TTYOpt", opts)).To(Equal(
@@ -379,6 +403,70 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("CompiledCache", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CompiledCache", opts)).To(Equal(
+					`command:"compiled-cache" description:"Export, import or prune compiled package cache used by create-env"`,
+				))
+			})
+		})
+
+		Describe("Cache", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Cache", opts)).To(Equal(
+					`command:"cache" description:"List, prune or pre-download release and stemcell tarballs cached by create-env"`,
+				))
+			})
+		})
+
+		Describe("State", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("State", opts)).To(Equal(
+					`command:"state" description:"Show or edit deployment state used by create-env"`,
+				))
+			})
+		})
+
+		Describe("DiffConfig", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DiffConfig", opts)).To(Equal(
+					`command:"diff-config" description:"Show differences between two configs (local files or latest on the Director)"`,
+				))
+			})
+		})
+
+		Describe("DiffManifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DiffManifest", opts)).To(Equal(
+					`command:"diff-manifest" description:"Show differences between two manifests (local files or deployed)"`,
+				))
+			})
+		})
+
+		Describe("LintManifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("LintManifest", opts)).To(Equal(
+					`command:"lint-manifest" description:"Check manifest for problems without contacting the Director"`,
+				))
+			})
+		})
+
+		Describe("ValidateProperties", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ValidateProperties", opts)).To(Equal(
+					`command:"validate-properties" description:"Check manifest properties against release job specs"`,
+				))
+			})
+		})
+
+		Describe("RenderTemplates", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RenderTemplates", opts)).To(Equal(
+					`command:"render-templates" description:"Render job templates locally for each instance"`,
+				))
+			})
+		})
+
 		Describe("Stemcells", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Stemcells", opts)).To(Equal(
@@ -443,6 +531,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("DiffRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DiffRelease", opts)).To(Equal(
+					`command:"diff-release" description:"Show differences between jobs and packages of two releases"`,
+				))
+			})
+		})
+
 		Describe("DeleteRelease", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("DeleteRelease", opts)).To(Equal(
@@ -667,6 +763,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("LintRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("LintRelease", opts)).To(Equal(
+					`command:"lint-release" description:"Check jobs, packages and spec files in release directory"`,
+				))
+			})
+		})
+
 		Describe("Sha2ifyRelease", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Sha2ifyRelease", opts)).To(Equal(
@@ -686,7 +790,7 @@ var _ = Describe("Opts", func() {
 		Describe("Blobs", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Blobs", opts)).To(Equal(
-					`command:"blobs" description:"List blobs"`,
+					`command:"blobs" description:"List blobs" subcommands-optional:"true"`,
 				))
 			})
 		})
@@ -747,7 +851,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path or URL (s3://, gcs://, file://)"`,
 			))
 		})
 
@@ -756,6 +860,282 @@ var _ = Describe("Opts", func() {
 				`long:"recreate" description:"Recreate VM in deployment"`,
 			))
 		})
+
+		It("has --plan", func() {
+			Expect(getStructTagForName("Plan", opts)).To(Equal(
+				`long:"plan" description:"Show what would change without deploying"`,
+			))
+		})
+
+		It("has --parallel", func() {
+			Expect(getStructTagForName("Parallel", opts)).To(Equal(
+				`long:"parallel" description:"Compile packages in parallel with given number of workers (default: 1)" default:"1"`,
+			))
+		})
+
+		It("has --compiled-package-cache", func() {
+			Expect(getStructTagForName("CompiledPackageCache", opts)).To(Equal(
+				`long:"compiled-package-cache" value-name:"PATH" description:"Compiled package cache directory or URL (s3://, gcs://, file://)" env:"BOSH_COMPILED_PACKAGE_CACHE"`,
+			))
+		})
+
+		It("has --delete-orphans", func() {
+			Expect(getStructTagForName("DeleteOrphans", opts)).To(Equal(
+				`long:"delete-orphans" description:"Delete VMs and disks orphaned by previously failed deploys"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheOpts", func() {
+		var opts *CompiledCacheOpts
+
+		BeforeEach(func() {
+			opts = &CompiledCacheOpts{}
+		})
+
+		It("has export, import and prune commands", func() {
+			Expect(getStructTagForName("Export", opts)).To(Equal(`command:"export" description:"Export compiled packages into a tarball"`))
+			Expect(getStructTagForName("Import", opts)).To(Equal(`command:"import" description:"Import compiled packages from a tarball"`))
+			Expect(getStructTagForName("Prune", opts)).To(Equal(`command:"prune" description:"Remove compiled packages from the cache"`))
+		})
+	})
+
+	Describe("CacheOpts", func() {
+		var opts *CacheOpts
+
+		BeforeEach(func() {
+			opts = &CacheOpts{}
+		})
+
+		It("has ls, prune and warm commands", func() {
+			Expect(getStructTagForName("Ls", opts)).To(Equal(`command:"ls" alias:"list" description:"List cached release and stemcell tarballs"`))
+			Expect(getStructTagForName("Prune", opts)).To(Equal(`command:"prune" description:"Remove cached release and stemcell tarballs"`))
+			Expect(getStructTagForName("Warm", opts)).To(Equal(`command:"warm" description:"Download release and stemcell tarballs referenced by a manifest"`))
+		})
+	})
+
+	Describe("CachePruneOpts", func() {
+		var opts *CachePruneOpts
+
+		BeforeEach(func() {
+			opts = &CachePruneOpts{}
+		})
+
+		It("has --older-than", func() {
+			Expect(getStructTagForName("OlderThan", opts)).To(Equal(
+				`long:"older-than" value-name:"DURATION" description:"Only remove tarballs not used within given duration (e.g. 720h)"`,
+			))
+		})
+
+		It("has --all", func() {
+			Expect(getStructTagForName("All", opts)).To(Equal(
+				`long:"all" description:"Remove all tarballs"`,
+			))
+		})
+	})
+
+	Describe("CacheWarmOpts", func() {
+		var opts *CacheWarmOpts
+
+		BeforeEach(func() {
+			opts = &CacheWarmOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+	})
+
+	Describe("CacheWarmArgs", func() {
+		var opts *CacheWarmArgs
+
+		BeforeEach(func() {
+			opts = &CacheWarmArgs{}
+		})
+
+		It("has Manifest", func() {
+			Expect(getStructTagForName("Manifest", opts)).To(Equal(
+				`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+			))
+		})
+	})
+
+	Describe("StateOpts", func() {
+		var opts *StateOpts
+
+		BeforeEach(func() {
+			opts = &StateOpts{}
+		})
+
+		It("has show, set-vm-cid, forget-disk, forget-stemcell and migrate commands", func() {
+			Expect(getStructTagForName("Show", opts)).To(Equal(`command:"show" description:"Show VM, disks, stemcells and releases recorded in deployment state"`))
+			Expect(getStructTagForName("SetVMCID", opts)).To(Equal(`command:"set-vm-cid" description:"Set current VM CID in deployment state"`))
+			Expect(getStructTagForName("ForgetDisk", opts)).To(Equal(`command:"forget-disk" description:"Remove disk from deployment state without deleting it"`))
+			Expect(getStructTagForName("ForgetStemcell", opts)).To(Equal(`command:"forget-stemcell" description:"Remove stemcell from deployment state without deleting it"`))
+			Expect(getStructTagForName("Migrate", opts)).To(Equal(`command:"migrate" description:"Migrate legacy bosh-deployments.yml to deployment state"`))
+		})
+	})
+
+	Describe("StateShowOpts", func() {
+		var opts *StateShowOpts
+
+		BeforeEach(func() {
+			opts = &StateShowOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+	})
+
+	Describe("StateArgs", func() {
+		var opts *StateArgs
+
+		BeforeEach(func() {
+			opts = &StateArgs{}
+		})
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", opts)).To(Equal(
+				`positional-arg-name:"STATE-PATH" description:"Deployment state file path or URL"`,
+			))
+		})
+	})
+
+	Describe("StateSetVMCIDOpts", func() {
+		var opts *StateSetVMCIDOpts
+
+		BeforeEach(func() {
+			opts = &StateSetVMCIDOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+	})
+
+	Describe("StateForgetDiskOpts", func() {
+		var opts *StateForgetDiskOpts
+
+		BeforeEach(func() {
+			opts = &StateForgetDiskOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+	})
+
+	Describe("StateForgetStemcellOpts", func() {
+		var opts *StateForgetStemcellOpts
+
+		BeforeEach(func() {
+			opts = &StateForgetStemcellOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+	})
+
+	Describe("StateCIDArgs", func() {
+		var opts *StateCIDArgs
+
+		BeforeEach(func() {
+			opts = &StateCIDArgs{}
+		})
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", opts)).To(Equal(
+				`positional-arg-name:"STATE-PATH" description:"Deployment state file path or URL"`,
+			))
+		})
+
+		It("has CID", func() {
+			Expect(getStructTagForName("CID", opts)).To(Equal(
+				`positional-arg-name:"CID" description:"Cloud ID"`,
+			))
+		})
+	})
+
+	Describe("StateMigrateOpts", func() {
+		var opts *StateMigrateOpts
+
+		BeforeEach(func() {
+			opts = &StateMigrateOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+	})
+
+	Describe("StateMigrateArgs", func() {
+		var opts *StateMigrateArgs
+
+		BeforeEach(func() {
+			opts = &StateMigrateArgs{}
+		})
+
+		It("has LegacyPath", func() {
+			Expect(getStructTagForName("LegacyPath", opts)).To(Equal(
+				`positional-arg-name:"LEGACY-PATH" description:"Path to legacy bosh-deployments.yml"`,
+			))
+		})
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", opts)).To(Equal(
+				`positional-arg-name:"STATE-PATH" description:"Deployment state file path or URL"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheFlags", func() {
+		var opts *CompiledCacheFlags
+
+		BeforeEach(func() {
+			opts = &CompiledCacheFlags{}
+		})
+
+		It("has --cache", func() {
+			Expect(getStructTagForName("Cache", opts)).To(Equal(
+				`long:"cache" value-name:"PATH" description:"Compiled package cache directory or URL (s3://, gcs://, file://)" env:"BOSH_COMPILED_PACKAGE_CACHE"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheTarballArgs", func() {
+		var opts *CompiledCacheTarballArgs
+
+		BeforeEach(func() {
+			opts = &CompiledCacheTarballArgs{}
+		})
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", opts)).To(Equal(
+				`positional-arg-name:"PATH" description:"Path to a compiled package cache tarball"`,
+			))
+		})
+	})
+
+	Describe("CompiledCachePruneOpts", func() {
+		var opts *CompiledCachePruneOpts
+
+		BeforeEach(func() {
+			opts = &CompiledCachePruneOpts{}
+		})
+
+		It("has --older-than", func() {
+			Expect(getStructTagForName("OlderThan", opts)).To(Equal(
+				`long:"older-than" value-name:"DURATION" description:"Only remove packages cached more than given duration ago (e.g. 720h)"`,
+			))
+		})
+
+		It("has --all", func() {
+			Expect(getStructTagForName("All", opts)).To(Equal(
+				`long:"all" description:"Remove all packages"`,
+			))
+		})
 	})
 
 	Describe("CreateEnvArgs", func() {
@@ -789,7 +1169,7 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path or URL (s3://, gcs://, file://)"`,
 			))
 		})
 	})
@@ -892,6 +1272,14 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("Summary", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Summary", opts)).To(Equal(
+					`long:"summary" description:"Show instance timing summary after task event log"`,
+				))
+			})
+		})
 	})
 
 	Describe("TaskArgs", func() {
@@ -1035,6 +1423,286 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("DiffConfigOpts", func() {
+		var opts *DiffConfigOpts
+
+		BeforeEach(func() {
+			opts = &DiffConfigOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --type", func() {
+			Expect(getStructTagForName("Type", opts)).To(Equal(
+				`long:"type" description:"Config type" choice:"cloud" choice:"runtime" choice:"cpi" required:"true"`,
+			))
+		})
+
+		It("has --no-redact", func() {
+			Expect(getStructTagForName("NoRedact", opts)).To(Equal(
+				`long:"no-redact" description:"Show non-redacted config diff"`,
+			))
+		})
+	})
+
+	Describe("DiffConfigArgs", func() {
+		var opts *DiffConfigArgs
+
+		BeforeEach(func() {
+			opts = &DiffConfigArgs{}
+		})
+
+		It("has From", func() {
+			Expect(getStructTagForName("From", opts)).To(Equal(
+				`positional-arg-name:"FROM" description:"Path to a config file or 'director:[NAME]' for latest config on the Director"`,
+			))
+		})
+
+		It("has To", func() {
+			Expect(getStructTagForName("To", opts)).To(Equal(
+				`positional-arg-name:"TO" description:"Path to a config file or 'director:[NAME]' for latest config on the Director"`,
+			))
+		})
+	})
+
+	Describe("DiffManifestOpts", func() {
+		var opts *DiffManifestOpts
+
+		BeforeEach(func() {
+			opts = &DiffManifestOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --no-redact", func() {
+			Expect(getStructTagForName("NoRedact", opts)).To(Equal(
+				`long:"no-redact" description:"Show non-redacted manifest diff"`,
+			))
+		})
+	})
+
+	Describe("DiffManifestArgs", func() {
+		var opts *DiffManifestArgs
+
+		BeforeEach(func() {
+			opts = &DiffManifestArgs{}
+		})
+
+		It("has From", func() {
+			Expect(getStructTagForName("From", opts)).To(Equal(
+				`positional-arg-name:"FROM" description:"Path to a manifest file, 'deployment:[NAME]' for deployed manifest, or 'task:ID'/'event:ID' for manifest used by a past deploy"`,
+			))
+		})
+
+		It("has To", func() {
+			Expect(getStructTagForName("To", opts)).To(Equal(
+				`positional-arg-name:"TO" description:"Path to a manifest file, 'deployment:[NAME]' for deployed manifest, or 'task:ID'/'event:ID' for manifest used by a past deploy"`,
+			))
+		})
+	})
+
+	Describe("LintManifestOpts", func() {
+		var opts *LintManifestOpts
+
+		BeforeEach(func() {
+			opts = &LintManifestOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --cloud-config", func() {
+			Expect(getStructTagForName("CloudConfig", opts)).To(Equal(
+				`long:"cloud-config" value-name:"PATH" description:"Path to a cloud config file"`,
+			))
+		})
+
+		It("has --runtime-config", func() {
+			Expect(getStructTagForName("RuntimeConfigs", opts)).To(Equal(
+				`long:"runtime-config" value-name:"PATH" description:"Path to a runtime config file (can be specified multiple times)"`,
+			))
+		})
+	})
+
+	Describe("LintManifestArgs", func() {
+		var opts *LintManifestArgs
+
+		BeforeEach(func() {
+			opts = &LintManifestArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", opts)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+				))
+			})
+		})
+	})
+
+	Describe("ValidatePropertiesOpts", func() {
+		var opts *ValidatePropertiesOpts
+
+		BeforeEach(func() {
+			opts = &ValidatePropertiesOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --release", func() {
+			Expect(getStructTagForName("Releases", opts)).To(Equal(
+				`long:"release" value-name:"PATH" description:"Path to a release tarball or release directory (can be specified multiple times)"`,
+			))
+		})
+	})
+
+	Describe("ValidatePropertiesArgs", func() {
+		var opts *ValidatePropertiesArgs
+
+		BeforeEach(func() {
+			opts = &ValidatePropertiesArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", opts)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+				))
+			})
+		})
+	})
+
+	Describe("RenderTemplatesOpts", func() {
+		var opts *RenderTemplatesOpts
+
+		BeforeEach(func() {
+			opts = &RenderTemplatesOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --release", func() {
+			Expect(getStructTagForName("Releases", opts)).To(Equal(
+				`long:"release" value-name:"PATH" description:"Path to a release tarball or release directory (can be specified multiple times)"`,
+			))
+		})
+
+		It("has --instance-group", func() {
+			Expect(getStructTagForName("InstanceGroup", opts)).To(Equal(
+				`long:"instance-group" value-name:"NAME" description:"Only render templates for given instance group"`,
+			))
+		})
+
+		It("has --spec-stub", func() {
+			Expect(getStructTagForName("SpecStub", opts)).To(Equal(
+				`long:"spec-stub" value-name:"PATH" description:"Path to a YAML file with values merged into spec of each instance (e.g. networks, address)"`,
+			))
+		})
+
+		It("has --links-stub", func() {
+			Expect(getStructTagForName("LinksStub", opts)).To(Equal(
+				`long:"links-stub" value-name:"PATH" description:"Path to a YAML file with links by name (each with properties, instances and address)"`,
+			))
+		})
+
+		It("has --dir", func() {
+			Expect(getStructTagForName("Directory", opts)).To(Equal(
+				`long:"dir" description:"Destination directory" default:"."`,
+			))
+		})
+	})
+
+	Describe("RenderTemplatesArgs", func() {
+		var opts *RenderTemplatesArgs
+
+		BeforeEach(func() {
+			opts = &RenderTemplatesArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", opts)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+				))
+			})
+		})
+	})
+
+	Describe("VarsStoreOpts", func() {
+		var opts *VarsStoreOpts
+
+		BeforeEach(func() {
+			opts = &VarsStoreOpts{}
+		})
+
+		It("has encrypt, decrypt and rekey commands", func() {
+			Expect(getStructTagForName("Encrypt", opts)).To(Equal(
+				`command:"encrypt" description:"Encrypt variables store file"`,
+			))
+			Expect(getStructTagForName("Decrypt", opts)).To(Equal(
+				`command:"decrypt" description:"Decrypt variables store file"`,
+			))
+			Expect(getStructTagForName("Rekey", opts)).To(Equal(
+				`command:"rekey" description:"Re-encrypt variables store file with a new key"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreRekeyOpts", func() {
+		var opts *VarsStoreRekeyOpts
+
+		BeforeEach(func() {
+			opts = &VarsStoreRekeyOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --new-key", func() {
+			Expect(getStructTagForName("NewKey", opts)).To(Equal(
+				`long:"new-key" value-name:"PATH" description:"New key file"`,
+			))
+		})
+
+		It("has --new-passphrase", func() {
+			Expect(getStructTagForName("NewPassphrase", opts)).To(Equal(
+				`long:"new-passphrase" value-name:"PASSPHRASE" description:"New passphrase" env:"BOSH_VARS_STORE_NEW_PASSPHRASE"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreKeyFlags", func() {
+		var opts *VarsStoreKeyFlags
+
+		BeforeEach(func() {
+			opts = &VarsStoreKeyFlags{}
+		})
+
+		It("has --vars-store-key", func() {
+			Expect(getStructTagForName("Key", opts)).To(Equal(
+				`long:"vars-store-key" value-name:"PATH" description:"Encrypt/decrypt variables store with key file"`,
+			))
+		})
+
+		It("has --vars-store-passphrase", func() {
+			Expect(getStructTagForName("Passphrase", opts)).To(Equal(
+				`long:"vars-store-passphrase" value-name:"PASSPHRASE" description:"Encrypt/decrypt variables store with passphrase" env:"BOSH_VARS_STORE_PASSPHRASE"`,
+			))
+		})
+	})
+
 	Describe("UpdateCloudConfigOpts", func() {
 		var opts *UpdateCloudConfigOpts
 
@@ -1193,6 +1861,14 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("Summary", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Summary", opts)).To(Equal(
+					`long:"summary" description:"Show instance timing summary after deploy task finishes"`,
+				))
+			})
+		})
 	})
 
 	Describe("DeployArgs", func() {
@@ -1608,6 +2284,44 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("DiffReleaseOpts", func() {
+		var opts *DiffReleaseOpts
+
+		BeforeEach(func() {
+			opts = &DiffReleaseOpts{}
+		})
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --dir", func() {
+			Expect(getStructTagForName("Directory", opts)).To(Equal(
+				`long:"dir" description:"Release directory path if not current working directory" default:"."`,
+			))
+		})
+	})
+
+	Describe("DiffReleaseArgs", func() {
+		var opts *DiffReleaseArgs
+
+		BeforeEach(func() {
+			opts = &DiffReleaseArgs{}
+		})
+
+		It("has From", func() {
+			Expect(getStructTagForName("From", opts)).To(Equal(
+				`positional-arg-name:"FROM" description:"Path to a release tarball, '[NAME/]VERSION' in release directory or 'director:NAME/VERSION'"`,
+			))
+		})
+
+		It("has To", func() {
+			Expect(getStructTagForName("To", opts)).To(Equal(
+				`positional-arg-name:"TO" description:"Path to a release tarball, '[NAME/]VERSION' in release directory or 'director:NAME/VERSION'"`,
+			))
+		})
+	})
+
 	Describe("InstanceGroupOrInstanceSlugFlags", func() {
 		var opts *InstanceGroupOrInstanceSlugFlags
 
@@ -2580,6 +3294,22 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("LintReleaseOpts", func() {
+		var opts *LintReleaseOpts
+
+		BeforeEach(func() {
+			opts = &LintReleaseOpts{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"dir" description:"Release directory path if not current working directory" default:"."`,
+				))
+			})
+		})
+	})
+
 	Describe("CreateReleaseOpts", func() {
 		var opts *CreateReleaseOpts
 
@@ -2779,6 +3509,54 @@ var _ = Describe("Opts", func() {
 				))
 			})
 		})
+
+		Describe("Verify", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Verify", opts)).To(Equal(
+					`long:"verify" description:"Verify local blob digests and presence of blobs and final release blobs in blobstore"`,
+				))
+			})
+		})
+
+		Describe("PruneRemote", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("PruneRemote", opts)).To(Equal(
+					`command:"prune-remote" description:"Show or delete blobstore objects not referenced by blobs or final releases"`,
+				))
+			})
+		})
+	})
+
+	Describe("BlobsPruneRemoteOpts", func() {
+		var opts *BlobsPruneRemoteOpts
+
+		BeforeEach(func() {
+			opts = &BlobsPruneRemoteOpts{}
+		})
+
+		Describe("Directory", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"dir" description:"Release directory path if not current working directory" default:"."`,
+				))
+			})
+		})
+
+		Describe("Delete", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Delete", opts)).To(Equal(
+					`long:"delete" description:"Delete unreferenced blobstore objects"`,
+				))
+			})
+		})
+
+		Describe("IgnoreOtherBranches", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("IgnoreOtherBranches", opts)).To(Equal(
+					`long:"ignore-other-branches" description:"Acknowledge that blobs referenced only by other branches or previous commits are deleted"`,
+				))
+			})
+		})
 	})
 
 	Describe("AddBlobArgs", func() {
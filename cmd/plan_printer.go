@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type PlanPrinter struct {
+	ui boshui.UI
+}
+
+func NewPlanPrinter(ui boshui.UI) PlanPrinter {
+	return PlanPrinter{ui: ui}
+}
+
+func (p PlanPrinter) Print(plan bidepl.Plan) {
+	if !plan.Deploy {
+		p.ui.PrintLinef("No deployment, stemcell or release changes. Deploy would be skipped.")
+		return
+	}
+
+	summary := boshtbl.Table{
+		Title: "Plan",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("VM"),
+			boshtbl.NewHeader("Persistent disk"),
+			boshtbl.NewHeader("Stemcell"),
+		},
+
+		Rows: [][]boshtbl.Value{
+			{
+				boshtbl.NewValueString(p.vm(plan.VM)),
+				boshtbl.NewValueString(p.disks(plan.Disks)),
+				boshtbl.NewValueString(p.stemcell(plan.Stemcell)),
+			},
+		},
+
+		Transpose: true,
+	}
+
+	p.ui.PrintTable(summary)
+
+	releases := boshtbl.Table{
+		Content: "releases",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+			boshtbl.NewHeader("Change"),
+			boshtbl.NewHeader("Packages to compile"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, rel := range plan.Releases {
+		version := rel.Version
+
+		switch rel.Change {
+		case bidepl.PlanReleaseUpdated:
+			version = fmt.Sprintf("%s -> %s", rel.PreviousVersion, rel.Version)
+		case bidepl.PlanReleaseRemoved:
+			version = rel.PreviousVersion
+		}
+
+		releases.Rows = append(releases.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(rel.Name),
+			boshtbl.NewValueString(version),
+			boshtbl.NewValueString(rel.Change),
+			boshtbl.NewValueStrings(rel.Packages),
+		})
+	}
+
+	p.ui.PrintTable(releases)
+}
+
+func (p PlanPrinter) vm(vm bidepl.PlanVM) string {
+	switch vm.Action {
+	case bidepl.PlanActionCreate:
+		return "create"
+	case bidepl.PlanActionRecreate:
+		return fmt.Sprintf("recreate '%s' (%s)", vm.CID, strings.Join(vm.Reasons, ", "))
+	default:
+		return fmt.Sprintf("keep '%s'", vm.CID)
+	}
+}
+
+func (p PlanPrinter) disks(disks []bidepl.PlanDisk) string {
+	if len(disks) == 0 {
+		return "none"
+	}
+
+	var descs []string
+
+	for _, disk := range disks {
+		desc := p.disk(disk)
+		if disk.Name != "" {
+			desc = fmt.Sprintf("%s: %s", disk.Name, desc)
+		}
+		descs = append(descs, desc)
+	}
+
+	return strings.Join(descs, "\n")
+}
+
+func (p PlanPrinter) disk(disk bidepl.PlanDisk) string {
+	switch disk.Action {
+	case bidepl.PlanActionCreate:
+		return fmt.Sprintf("create %d MB", disk.NewSize)
+
+	case bidepl.PlanActionMigrate:
+		desc := fmt.Sprintf("migrate '%s' from %d MB to %d MB", disk.CID, disk.OldSize, disk.NewSize)
+		if disk.CloudPropertiesChanged {
+			desc += " (cloud properties changed)"
+		}
+		return desc
+
+	case bidepl.PlanActionUnsupported:
+		desc := fmt.Sprintf("cannot change '%s' from %d MB to %d MB since named disks can only grow", disk.CID, disk.OldSize, disk.NewSize)
+		if disk.CloudPropertiesChanged {
+			desc += " (cloud properties changed)"
+		}
+		return desc
+
+	case bidepl.PlanActionResize:
+		return fmt.Sprintf("resize '%s' from %d MB to %d MB", disk.CID, disk.OldSize, disk.NewSize)
+
+	case bidepl.PlanActionKeep:
+		return fmt.Sprintf("keep '%s' (%d MB)", disk.CID, disk.OldSize)
+
+	default:
+		return "none"
+	}
+}
+
+func (p PlanPrinter) stemcell(stemcell bidepl.PlanStemcell) string {
+	if stemcell.Upload {
+		return fmt.Sprintf("upload '%s/%s'", stemcell.Name, stemcell.Version)
+	}
+
+	return fmt.Sprintf("use uploaded '%s/%s'", stemcell.Name, stemcell.Version)
+}
@@ -0,0 +1,148 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("PlanPrinter", func() {
+	var (
+		ui      *fakeui.FakeUI
+		printer PlanPrinter
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		printer = NewPlanPrinter(ui)
+	})
+
+	It("prints that deploy would be skipped", func() {
+		printer.Print(bidepl.Plan{Deploy: false})
+
+		Expect(ui.Said).To(Equal([]string{"No deployment, stemcell or release changes. Deploy would be skipped."}))
+		Expect(ui.Tables).To(BeEmpty())
+	})
+
+	It("prints VM, disk, stemcell and release changes", func() {
+		printer.Print(bidepl.Plan{
+			Deploy: true,
+			VM: bidepl.PlanVM{
+				Action:  bidepl.PlanActionRecreate,
+				CID:     "vm-cid",
+				Reasons: []string{"manifest changed", "stemcell changed"},
+			},
+			Disks: []bidepl.PlanDisk{{
+				Action:                 bidepl.PlanActionMigrate,
+				CID:                    "disk-cid",
+				OldSize:                1024,
+				NewSize:                2048,
+				CloudPropertiesChanged: true,
+			}},
+			Stemcell: bidepl.PlanStemcell{Name: "stemcell", Version: "2", Upload: true},
+			Releases: []bidepl.PlanRelease{
+				{Name: "rel", Version: "2", PreviousVersion: "1", Change: bidepl.PlanReleaseUpdated, Packages: []string{"pkg"}},
+				{Name: "old-rel", PreviousVersion: "3", Change: bidepl.PlanReleaseRemoved},
+			},
+		})
+
+		Expect(ui.Tables).To(Equal([]boshtbl.Table{
+			{
+				Title: "Plan",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("VM"),
+					boshtbl.NewHeader("Persistent disk"),
+					boshtbl.NewHeader("Stemcell"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("recreate 'vm-cid' (manifest changed, stemcell changed)"),
+						boshtbl.NewValueString("migrate 'disk-cid' from 1024 MB to 2048 MB (cloud properties changed)"),
+						boshtbl.NewValueString("upload 'stemcell/2'"),
+					},
+				},
+
+				Transpose: true,
+			},
+			{
+				Content: "releases",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Version"),
+					boshtbl.NewHeader("Change"),
+					boshtbl.NewHeader("Packages to compile"),
+				},
+
+				SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("rel"),
+						boshtbl.NewValueString("1 -> 2"),
+						boshtbl.NewValueString("updated"),
+						boshtbl.NewValueStrings([]string{"pkg"}),
+					},
+					{
+						boshtbl.NewValueString("old-rel"),
+						boshtbl.NewValueString("3"),
+						boshtbl.NewValueString("removed"),
+						boshtbl.NewValueStrings(nil),
+					},
+				},
+			},
+		}))
+	})
+
+	It("describes new VM, disk and uploaded stemcell", func() {
+		printer.Print(bidepl.Plan{
+			Deploy:   true,
+			VM:       bidepl.PlanVM{Action: bidepl.PlanActionCreate},
+			Disks:    []bidepl.PlanDisk{{Action: bidepl.PlanActionCreate, NewSize: 1024}},
+			Stemcell: bidepl.PlanStemcell{Name: "stemcell", Version: "1"},
+		})
+
+		Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("create"),
+				boshtbl.NewValueString("create 1024 MB"),
+				boshtbl.NewValueString("use uploaded 'stemcell/1'"),
+			},
+		}))
+	})
+
+	It("describes each named disk", func() {
+		printer.Print(bidepl.Plan{
+			Deploy: true,
+			VM:     bidepl.PlanVM{Action: bidepl.PlanActionCreate},
+			Disks: []bidepl.PlanDisk{
+				{Name: "db", Action: bidepl.PlanActionResize, CID: "db-cid", OldSize: 1024, NewSize: 2048},
+				{Name: "blobstore", Action: bidepl.PlanActionCreate, NewSize: 4096},
+			},
+			Stemcell: bidepl.PlanStemcell{Name: "stemcell", Version: "1"},
+		})
+
+		Expect(ui.Tables[0].Rows[0][1]).To(Equal(boshtbl.NewValueString(
+			"db: resize 'db-cid' from 1024 MB to 2048 MB\nblobstore: create 4096 MB")))
+	})
+
+	It("describes named disk changes that cannot be made", func() {
+		printer.Print(bidepl.Plan{
+			Deploy: true,
+			VM:     bidepl.PlanVM{Action: bidepl.PlanActionCreate},
+			Disks: []bidepl.PlanDisk{
+				{Name: "db", Action: bidepl.PlanActionUnsupported, CID: "db-cid", OldSize: 2048, NewSize: 1024},
+			},
+			Stemcell: bidepl.PlanStemcell{Name: "stemcell", Version: "1"},
+		})
+
+		Expect(ui.Tables[0].Rows[0][1]).To(Equal(boshtbl.NewValueString(
+			"db: cannot change 'db-cid' from 2048 MB to 1024 MB since named disks can only grow")))
+	})
+})
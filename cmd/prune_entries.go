@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// prunableEntries adapts cache listings to pruneEntries
+type prunableEntries interface {
+	Len() int
+	Time(i int) time.Time
+	Delete(i int) error
+}
+
+// pruneEntries removes entries older than given duration, or all entries
+// only when explicitly requested; indices of removed entries are returned
+func pruneEntries(entries prunableEntries, olderThan time.Duration, all bool, timeService clock.Clock) ([]int, error) {
+	if olderThan > 0 && all {
+		return nil, bosherr.Error("Expected only one of '--older-than' and '--all' to be specified")
+	} else if olderThan <= 0 && !all {
+		return nil, bosherr.Error("Expected '--older-than' or '--all' to be specified")
+	}
+
+	var pruned []int
+
+	for i := 0; i < entries.Len(); i++ {
+		if !all && timeService.Since(entries.Time(i)) < olderThan {
+			continue
+		}
+
+		err := entries.Delete(i)
+		if err != nil {
+			return pruned, err
+		}
+
+		pruned = append(pruned, i)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"gopkg.in/yaml.v2"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type RenderTemplatesCmd struct {
+	releaseReader boshrel.Reader
+	jobRenderer   bitemplate.JobRenderer
+	uuidGen       boshuuid.Generator
+	fs            boshsys.FileSystem
+	ui            boshui.UI
+	logger        boshlog.Logger
+}
+
+type renderTemplatesManifest struct {
+	Name           string                         `yaml:"name"`
+	Properties     map[interface{}]interface{}    `yaml:"properties"`
+	InstanceGroups []renderTemplatesInstanceGroup `yaml:"instance_groups"`
+}
+
+type renderTemplatesInstanceGroup struct {
+	Name       string                      `yaml:"name"`
+	Instances  int                         `yaml:"instances"`
+	AZs        []string                    `yaml:"azs"`
+	Properties map[interface{}]interface{} `yaml:"properties"`
+	Jobs       []renderTemplatesJob        `yaml:"jobs"`
+}
+
+type renderTemplatesJob struct {
+	Name       string                       `yaml:"name"`
+	Release    string                       `yaml:"release"`
+	Properties *map[interface{}]interface{} `yaml:"properties"`
+}
+
+func NewRenderTemplatesCmd(
+	releaseReader boshrel.Reader,
+	jobRenderer bitemplate.JobRenderer,
+	uuidGen boshuuid.Generator,
+	fs boshsys.FileSystem,
+	ui boshui.UI,
+	logger boshlog.Logger,
+) RenderTemplatesCmd {
+	return RenderTemplatesCmd{
+		releaseReader: releaseReader,
+		jobRenderer:   jobRenderer,
+		uuidGen:       uuidGen,
+		fs:            fs,
+		ui:            ui,
+		logger:        logger,
+	}
+}
+
+func (c RenderTemplatesCmd) Run(opts RenderTemplatesOpts) error {
+	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsReadOnlyVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	var manifest renderTemplatesManifest
+
+	err = yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling manifest")
+	}
+
+	var specStub map[string]interface{}
+
+	err = yaml.Unmarshal(opts.SpecStub.Bytes, &specStub)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling spec stub")
+	}
+
+	var linksStub map[string]interface{}
+
+	err = yaml.Unmarshal(opts.LinksStub.Bytes, &linksStub)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling links stub")
+	}
+
+	releases := map[string]boshrel.Release{}
+
+	for _, path := range opts.Releases {
+		release, err := c.releaseReader.Read(path)
+		if err != nil {
+			return err
+		}
+
+		defer release.CleanUp()
+
+		releases[release.Name()] = release
+	}
+
+	globalProps, err := biproperty.BuildMap(manifest.Properties)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing global properties")
+	}
+
+	table := boshtbl.Table{
+		Content: "rendered templates",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("Job"),
+			boshtbl.NewHeader("Path"),
+		},
+	}
+
+	var foundIG bool
+
+	for _, ig := range manifest.InstanceGroups {
+		if len(opts.InstanceGroup) > 0 && opts.InstanceGroup != ig.Name {
+			continue
+		}
+
+		foundIG = true
+
+		igProps, err := biproperty.BuildMap(ig.Properties)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing instance group '%s' properties", ig.Name)
+		}
+
+		for _, job := range ig.Jobs {
+			release, found := releases[job.Release]
+			if !found {
+				return bosherr.Errorf("Expected release '%s' for job '%s' in instance group '%s' to be provided via --release",
+					job.Release, job.Name, ig.Name)
+			}
+
+			relJob, found := release.FindJobByName(job.Name)
+			if !found {
+				return bosherr.Errorf("Expected to find job '%s' in release '%s'", job.Name, job.Release)
+			}
+
+			var jobProps *biproperty.Map
+
+			if job.Properties != nil {
+				props, err := biproperty.BuildMap(*job.Properties)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Parsing job '%s' properties", job.Name)
+				}
+
+				jobProps = &props
+			}
+
+			for index := 0; index < ig.Instances; index++ {
+				instance := bitemplate.InstanceSpec{
+					Name:      ig.Name,
+					Index:     index,
+					Bootstrap: index == 0,
+					Links:     linksStub,
+					Spec:      specStub,
+				}
+
+				if len(ig.AZs) > 0 {
+					instance.AZ = ig.AZs[index%len(ig.AZs)]
+				}
+
+				context := bitemplate.NewInstanceEvaluationContext(
+					bitemplate.NewJobEvaluationContext(
+						relJob, jobProps, igProps, globalProps, manifest.Name, "", c.uuidGen, c.logger),
+					instance,
+				)
+
+				dstPath := filepath.Join(opts.Directory.Path, ig.Name, strconv.Itoa(index), job.Name)
+
+				err := c.render(relJob, context, dstPath)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Rendering job '%s' for instance '%s/%d'", job.Name, ig.Name, index)
+				}
+
+				table.Rows = append(table.Rows, []boshtbl.Value{
+					boshtbl.NewValueString(ig.Name + "/" + strconv.Itoa(index)),
+					boshtbl.NewValueString(job.Name),
+					boshtbl.NewValueString(dstPath),
+				})
+			}
+		}
+	}
+
+	if len(opts.InstanceGroup) > 0 && !foundIG {
+		return bosherr.Errorf("Expected to find instance group '%s'", opts.InstanceGroup)
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+func (c RenderTemplatesCmd) render(job boshjob.Job, context bierbrenderer.TemplateEvaluationContext, dstPath string) error {
+	renderedJob, err := c.jobRenderer.RenderWithContext(job, context)
+	if err != nil {
+		return err
+	}
+
+	defer renderedJob.DeleteSilently()
+
+	err = c.fs.RemoveAll(dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing previously rendered templates in '%s'", dstPath)
+	}
+
+	err = c.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory '%s'", filepath.Dir(dstPath))
+	}
+
+	err = c.fs.CopyDir(renderedJob.Path(), dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying rendered templates to '%s'", dstPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	mock_template "github.com/cloudfoundry/bosh-cli/templatescompiler/mocks"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("RenderTemplatesCmd", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		releaseReader   *fakerel.FakeReader
+		mockJobRenderer *mock_template.MockJobRenderer
+		fs              *fakesys.FakeFileSystem
+		ui              *fakeui.FakeUI
+		logger          boshlog.Logger
+		command         RenderTemplatesCmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		releaseReader = &fakerel.FakeReader{}
+		mockJobRenderer = mock_template.NewMockJobRenderer(mockCtrl)
+		fs = fakesys.NewFakeFileSystem()
+		ui = &fakeui.FakeUI{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		uuidGen := fakeuuid.NewFakeGenerator()
+		uuidGen.GeneratedUUID = "fake-uuid"
+
+		command = NewRenderTemplatesCmd(releaseReader, mockJobRenderer, uuidGen, fs, ui, logger)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			opts     RenderTemplatesOpts
+			release  *fakerel.FakeRelease
+			contexts []map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			opts = RenderTemplatesOpts{
+				Args: RenderTemplatesArgs{
+					Manifest: FileBytesArg{Bytes: []byte(`
+name: dep
+properties: {global: value}
+instance_groups:
+- name: web
+  instances: 2
+  azs: [z1, z2]
+  properties: {ig: value}
+  jobs:
+  - name: web-job
+    release: rel
+    properties: {port: 80}
+- name: worker
+  instances: 1
+  jobs:
+  - name: worker-job
+    release: rel
+`)},
+				},
+				Releases:  []string{"/rel.tgz"},
+				SpecStub:  FileBytesArg{Bytes: []byte("networks: {default: {ip: 10.0.0.5}}")},
+				LinksStub: FileBytesArg{Bytes: []byte("db: {properties: {port: 5432}}")},
+				Directory: DirOrCWDArg{Path: "/out"},
+			}
+
+			release = &fakerel.FakeRelease{}
+			release.NameReturns("rel")
+			release.FindJobByNameStub = func(name string) (boshjob.Job, bool) {
+				switch name {
+				case "web-job", "worker-job":
+					return *boshjob.NewJob(NewResourceWithBuiltArchive(name, "fp", "path", "sha1")), true
+				}
+				return boshjob.Job{}, false
+			}
+			releaseReader.ReadReturns(release, nil)
+
+			contexts = nil
+
+			renderedJobPath := filepath.Join("/", "tmp", "rendered")
+
+			mockJobRenderer.EXPECT().RenderWithContext(gomock.Any(), gomock.Any()).Do(
+				func(job boshjob.Job, context bierbrenderer.TemplateEvaluationContext) {
+					contextBytes, err := context.MarshalJSON()
+					Expect(err).ToNot(HaveOccurred())
+
+					var contextHash map[string]interface{}
+					Expect(json.Unmarshal(contextBytes, &contextHash)).ToNot(HaveOccurred())
+
+					contexts = append(contexts, contextHash)
+
+					fs.WriteFileString(filepath.Join(renderedJobPath, "monit"), "monit-"+job.Name())
+				},
+			).Return(bitemplate.NewRenderedJob(boshjob.Job{}, renderedJobPath, fs, logger), nil).AnyTimes()
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("renders templates for each instance into destination directory", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(releaseReader.ReadArgsForCall(0)).To(Equal("/rel.tgz"))
+			Expect(release.CleanUpCallCount()).To(Equal(1))
+
+			for _, path := range []string{"/out/web/0/web-job/monit", "/out/web/1/web-job/monit", "/out/worker/0/worker-job/monit"} {
+				Expect(fs.FileExists(path)).To(BeTrue(), path)
+			}
+
+			contents, err := fs.ReadFileString("/out/worker/0/worker-job/monit")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("monit-worker-job"))
+
+			Expect(fs.FileExists("/tmp/rendered")).To(BeFalse())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "rendered templates",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Instance"),
+					boshtbl.NewHeader("Job"),
+					boshtbl.NewHeader("Path"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("web/0"), boshtbl.NewValueString("web-job"), boshtbl.NewValueString("/out/web/0/web-job")},
+					{boshtbl.NewValueString("web/1"), boshtbl.NewValueString("web-job"), boshtbl.NewValueString("/out/web/1/web-job")},
+					{boshtbl.NewValueString("worker/0"), boshtbl.NewValueString("worker-job"), boshtbl.NewValueString("/out/worker/0/worker-job")},
+				},
+			}))
+		})
+
+		It("provides instance specific values, properties and stubs to templates", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(contexts).To(HaveLen(3))
+
+			Expect(contexts[1]["name"]).To(Equal("web"))
+			Expect(contexts[1]["index"]).To(Equal(float64(1)))
+			Expect(contexts[1]["az"]).To(Equal("z2"))
+			Expect(contexts[1]["bootstrap"]).To(Equal(false))
+			Expect(contexts[1]["deployment"]).To(Equal("dep"))
+			Expect(contexts[1]["job_properties"]).To(Equal(map[string]interface{}{"port": float64(80)}))
+			Expect(contexts[1]["cluster_properties"]).To(Equal(map[string]interface{}{"ig": "value"}))
+			Expect(contexts[1]["global_properties"]).To(Equal(map[string]interface{}{"global": "value"}))
+			Expect(contexts[1]["networks"]).To(HaveKeyWithValue("default", HaveKeyWithValue("ip", "10.0.0.5")))
+			Expect(contexts[1]["links"]).To(Equal(map[string]interface{}{
+				"db": map[string]interface{}{"properties": map[string]interface{}{"port": float64(5432)}},
+			}))
+
+			Expect(contexts[2]["name"]).To(Equal("worker"))
+			Expect(contexts[2]["bootstrap"]).To(Equal(true))
+			Expect(contexts[2]["job_properties"]).To(BeNil())
+		})
+
+		It("only renders given instance group", func() {
+			opts.InstanceGroup = "worker"
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(contexts).To(HaveLen(1))
+			Expect(fs.FileExists("/out/web")).To(BeFalse())
+		})
+
+		It("returns error if instance group is not found", func() {
+			opts.InstanceGroup = "missing"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected to find instance group 'missing'"))
+		})
+
+		It("returns error if release is not provided", func() {
+			release.NameReturns("other-rel")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected release 'rel' for job 'web-job' in instance group 'web' to be provided"))
+		})
+
+		It("returns error if job is not found in release", func() {
+			release.FindJobByNameStub = nil
+			release.FindJobByNameReturns(boshjob.Job{}, false)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected to find job 'web-job' in release 'rel'"))
+		})
+
+		It("returns error if reading release fails", func() {
+			releaseReader.ReadReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+
+		It("returns error if links stub is not valid", func() {
+			opts.LinksStub = FileBytesArg{Bytes: []byte("-")}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling links stub"))
+		})
+	})
+})
@@ -15,6 +15,7 @@ type SessionImpl struct {
 	context SessionContext
 
 	ui               boshui.UI
+	taskReporter     boshuit.Reporter
 	printEnvironment bool
 	printDeployment  bool
 
@@ -29,6 +30,7 @@ type SessionImpl struct {
 func NewSessionImpl(
 	context SessionContext,
 	ui boshui.UI,
+	taskReporter boshuit.Reporter,
 	printEnvironment bool,
 	printDeployment bool,
 	logger boshlog.Logger,
@@ -37,6 +39,7 @@ func NewSessionImpl(
 		context: context,
 
 		ui:               ui,
+		taskReporter:     taskReporter,
 		printEnvironment: printEnvironment,
 		printDeployment:  printDeployment,
 
@@ -118,10 +121,9 @@ func (c *SessionImpl) Director() (boshdir.Director, error) {
 		c.ui.PrintLinef("Using environment '%s' as %s", c.Environment(), creds.Description())
 	}
 
-	taskReporter := boshuit.NewReporter(c.ui, true)
 	fileReporter := boshui.NewFileReporter(c.ui)
 
-	director, err := boshdir.NewFactory(c.logger).New(dirConfig, taskReporter, fileReporter)
+	director, err := boshdir.NewFactory(c.logger).New(dirConfig, c.taskReporter, fileReporter)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
 )
 
 func NewSessionFromOpts(
@@ -19,5 +20,24 @@ func NewSessionFromOpts(
 ) Session {
 	context := NewSessionContextImpl(opts, config, fs)
 
-	return NewSessionImpl(context, ui, printEnvironment, printDeployment, logger)
+	taskReporter := NewTaskReporter(opts, ui, true)
+
+	return NewSessionImpl(context, ui, taskReporter, printEnvironment, printDeployment, logger)
+}
+
+func NewTaskReporter(opts BoshOpts, ui boshui.UI, isForEvents bool) boshuit.Reporter {
+	var reporter boshuit.Reporter
+
+	if opts.JSONStreamOpt {
+		reporter = boshuit.NewStreamReporter(ui, isForEvents)
+	} else {
+		reporter = boshuit.NewReporter(ui, isForEvents)
+	}
+
+	// Only deploy and task commands set summary option
+	if isForEvents && (opts.Deploy.Summary || opts.Task.Summary) {
+		reporter = boshuit.NewSummaryReporter(reporter, ui)
+	}
+
+	return reporter
 }
@@ -13,12 +13,14 @@ import (
 	fakecmd "github.com/cloudfoundry/bosh-cli/cmd/cmdfakes"
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	fakeuit "github.com/cloudfoundry/bosh-cli/ui/task/taskfakes"
 )
 
 var _ = Describe("SessionImpl", func() {
 	var (
 		context          *fakecmd.FakeSessionContext
 		ui               *fakeui.FakeUI
+		taskReporter     *fakeuit.FakeReporter
 		printEnvironment bool
 		printDeployment  bool
 		logger           boshlog.Logger
@@ -28,10 +30,11 @@ var _ = Describe("SessionImpl", func() {
 	BeforeEach(func() {
 		context = &fakecmd.FakeSessionContext{}
 		ui = &fakeui.FakeUI{}
+		taskReporter = &fakeuit.FakeReporter{}
 		printEnvironment = false
 		printDeployment = false
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		sess = NewSessionImpl(context, ui, printEnvironment, printDeployment, logger)
+		sess = NewSessionImpl(context, ui, taskReporter, printEnvironment, printDeployment, logger)
 	})
 
 	Describe("UAA", func() {
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const stateBackupTimeFormat = "20060102T150405Z"
+
+// DeploymentStateEditor applies targeted edits to deployment state
+// after confirming them and saving a copy of the original state
+type DeploymentStateEditor struct {
+	newStateService func(path string) biconfig.DeploymentStateService
+	timeService     clock.Clock
+	ui              boshui.UI
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewDeploymentStateEditor(
+	newStateService func(path string) biconfig.DeploymentStateService,
+	timeService clock.Clock,
+	ui boshui.UI,
+	logger boshlog.Logger,
+) DeploymentStateEditor {
+	return DeploymentStateEditor{
+		newStateService: newStateService,
+		timeService:     timeService,
+		ui:              ui,
+
+		logTag: "deploymentStateEditor",
+		logger: logger,
+	}
+}
+
+// Edit validates and applies editFunc; editFunc returns description of the change
+func (e DeploymentStateEditor) Edit(path string, editFunc func(*biconfig.DeploymentState) (string, error)) error {
+	stateService := e.newStateService(path)
+
+	// Load initializes and saves missing state hence existence is checked first
+	if !stateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist", stateService.Path())
+	}
+
+	err := stateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer func() {
+		unlockErr := stateService.Unlock()
+		if unlockErr != nil {
+			e.logger.Warn(e.logTag, "Unlocking deployment state: %s", unlockErr.Error())
+		}
+	}()
+
+	originalState, err := stateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	// editFunc replaces slices instead of modifying them to keep original state intact
+	state := originalState
+
+	desc, err := editFunc(&state)
+	if err != nil {
+		return err
+	}
+
+	e.ui.PrintLinef("%s", desc)
+
+	err = e.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	// backup path is printed via state service since URL query may contain credentials
+	backupStateService := e.newStateService(e.backupPath(path))
+
+	err = backupStateService.Save(originalState)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Backing up deployment state to '%s'", backupStateService.Path())
+	}
+
+	e.ui.PrintLinef("Saved backup of deployment state to '%s'", backupStateService.Path())
+
+	err = stateService.Save(state)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving deployment state")
+	}
+
+	return nil
+}
+
+// backupPath appends timestamp to the file name; for state URLs (e.g. s3://bucket/key?region=x)
+// only the key is changed so that backup is stored next to the state with the same options
+func (e DeploymentStateEditor) backupPath(path string) string {
+	suffix := fmt.Sprintf(".%s.bak", e.timeService.Now().UTC().Format(stateBackupTimeFormat))
+
+	pathURL, err := url.Parse(path)
+	if err == nil {
+		switch pathURL.Scheme {
+		case "s3", "gcs", "file":
+			pathURL.Path += suffix
+			return pathURL.String()
+		}
+	}
+
+	return path + suffix
+}
+
+type StateSetVMCIDCmd struct {
+	editor DeploymentStateEditor
+}
+
+func NewStateSetVMCIDCmd(editor DeploymentStateEditor) StateSetVMCIDCmd {
+	return StateSetVMCIDCmd{editor: editor}
+}
+
+func (c StateSetVMCIDCmd) Run(opts StateSetVMCIDOpts) error {
+	return c.editor.Edit(opts.Args.Path, func(state *biconfig.DeploymentState) (string, error) {
+		cid := opts.Args.CID
+
+		if len(strings.TrimSpace(cid)) == 0 {
+			return "", bosherr.Error("Expected non-empty VM CID")
+		}
+
+		if strings.TrimSpace(cid) != cid {
+			return "", bosherr.Errorf("Expected VM CID '%s' to not have leading or trailing whitespace", cid)
+		}
+
+		if cid == state.CurrentVMCID {
+			return "", bosherr.Errorf("VM CID is already '%s'", cid)
+		}
+
+		// Catches disk or stemcell CIDs passed by mistake
+		for _, disk := range state.Disks {
+			if disk.CID == cid {
+				return "", bosherr.Errorf("CID '%s' is recorded as disk CID in deployment state", cid)
+			}
+		}
+
+		for _, stemcell := range state.Stemcells {
+			if stemcell.CID == cid {
+				return "", bosherr.Errorf("CID '%s' is recorded as stemcell CID in deployment state", cid)
+			}
+		}
+
+		desc := fmt.Sprintf("Changing current VM CID from '%s' to '%s'", state.CurrentVMCID, opts.Args.CID)
+
+		state.CurrentVMCID = opts.Args.CID
+
+		return desc, nil
+	})
+}
+
+type StateForgetDiskCmd struct {
+	editor DeploymentStateEditor
+}
+
+func NewStateForgetDiskCmd(editor DeploymentStateEditor) StateForgetDiskCmd {
+	return StateForgetDiskCmd{editor: editor}
+}
+
+func (c StateForgetDiskCmd) Run(opts StateForgetDiskOpts) error {
+	return c.editor.Edit(opts.Args.Path, func(state *biconfig.DeploymentState) (string, error) {
+		var disks []biconfig.DiskRecord
+		var forgottenDisk *biconfig.DiskRecord
+
+		for i, disk := range state.Disks {
+			if disk.CID == opts.Args.CID {
+				forgottenDisk = &state.Disks[i]
+			} else {
+				disks = append(disks, disk)
+			}
+		}
+
+		if forgottenDisk == nil {
+			return "", bosherr.Errorf("Disk '%s' is not recorded in deployment state", opts.Args.CID)
+		}
+
+		desc := fmt.Sprintf("Forgetting disk '%s'", forgottenDisk.CID)
+
+		if state.IsCurrentDisk(forgottenDisk.ID) {
+			if forgottenDisk.ID == state.CurrentDiskID {
+				state.CurrentDiskID = ""
+			}
+			delete(state.CurrentDiskIDs, forgottenDisk.Name)
+			desc += "; it is the current disk so a new disk will be created by next deploy"
+		}
+
+		if disks == nil {
+			disks = []biconfig.DiskRecord{}
+		}
+
+		state.Disks = disks
+
+		return desc, nil
+	})
+}
+
+type StateForgetStemcellCmd struct {
+	editor DeploymentStateEditor
+}
+
+func NewStateForgetStemcellCmd(editor DeploymentStateEditor) StateForgetStemcellCmd {
+	return StateForgetStemcellCmd{editor: editor}
+}
+
+func (c StateForgetStemcellCmd) Run(opts StateForgetStemcellOpts) error {
+	return c.editor.Edit(opts.Args.Path, func(state *biconfig.DeploymentState) (string, error) {
+		var stemcells []biconfig.StemcellRecord
+		var forgottenStemcell *biconfig.StemcellRecord
+
+		for i, stemcell := range state.Stemcells {
+			if stemcell.CID == opts.Args.CID {
+				forgottenStemcell = &state.Stemcells[i]
+			} else {
+				stemcells = append(stemcells, stemcell)
+			}
+		}
+
+		if forgottenStemcell == nil {
+			return "", bosherr.Errorf("Stemcell '%s' is not recorded in deployment state", opts.Args.CID)
+		}
+
+		desc := fmt.Sprintf("Forgetting stemcell '%s/%s' (%s)",
+			forgottenStemcell.Name, forgottenStemcell.Version, forgottenStemcell.CID)
+
+		if forgottenStemcell.ID == state.CurrentStemcellID {
+			state.CurrentStemcellID = ""
+			desc += "; it is the current stemcell so it will be uploaded again by next deploy"
+		}
+
+		if stemcells == nil {
+			stemcells = []biconfig.StemcellRecord{}
+		}
+
+		state.Stemcells = stemcells
+
+		return desc, nil
+	})
+}
@@ -0,0 +1,263 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("DeploymentStateEditor", func() {
+	var (
+		ui            *fakeui.FakeUI
+		fs            *fakesys.FakeFileSystem
+		newStateSvc   func(string) biconfig.DeploymentStateService
+		originalState biconfig.DeploymentState
+		editor        DeploymentStateEditor
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		newStateSvc = func(path string) biconfig.DeploymentStateService {
+			return biconfig.NewFileSystemDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, path)
+		}
+
+		timeService := fakeclock.NewFakeClock(time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC))
+		editor = NewDeploymentStateEditor(newStateSvc, timeService, ui, logger)
+
+		originalState = biconfig.DeploymentState{
+			DirectorID:    "fake-director-id",
+			CurrentVMCID:  "fake-vm-cid",
+			CurrentDiskID: "fake-disk-id",
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid", Size: 1024},
+				{ID: "fake-old-disk-id", CID: "fake-old-disk-cid", Size: 512},
+			},
+			CurrentStemcellID: "fake-stemcell-id",
+			Stemcells: []biconfig.StemcellRecord{
+				{ID: "fake-stemcell-id", Name: "fake-stemcell", Version: "2", CID: "fake-stemcell-cid"},
+				{ID: "fake-old-stemcell-id", Name: "fake-stemcell", Version: "1", CID: "fake-old-stemcell-cid"},
+			},
+			Releases: []biconfig.ReleaseRecord{},
+		}
+
+		Expect(newStateSvc("/state.json").Save(originalState)).To(Succeed())
+	})
+
+	loadState := func(path string) biconfig.DeploymentState {
+		state, err := newStateSvc(path).Load()
+		Expect(err).ToNot(HaveOccurred())
+		return state
+	}
+
+	Describe("StateSetVMCIDCmd", func() {
+		act := func(cid string) error {
+			return NewStateSetVMCIDCmd(editor).Run(StateSetVMCIDOpts{
+				Args: StateCIDArgs{Path: "/state.json", CID: cid},
+			})
+		}
+
+		It("sets current VM CID after confirmation", func() {
+			err := act("fake-new-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.AskedConfirmationCalled).To(BeTrue())
+			Expect(ui.Said).To(ContainElement("Changing current VM CID from 'fake-vm-cid' to 'fake-new-vm-cid'"))
+
+			Expect(loadState("/state.json").CurrentVMCID).To(Equal("fake-new-vm-cid"))
+		})
+
+		It("saves backup of original state", func() {
+			err := act("fake-new-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			backupPath := "/state.json.20170102T030405Z.bak"
+			Expect(ui.Said).To(ContainElement("Saved backup of deployment state to '" + backupPath + "'"))
+			Expect(loadState(backupPath)).To(Equal(originalState))
+		})
+
+		It("saves backup next to the state referenced by URL keeping its query", func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+
+			newURLStateSvc := func(path string) biconfig.DeploymentStateService {
+				return biconfig.NewDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, path)
+			}
+
+			timeService := fakeclock.NewFakeClock(time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC))
+			urlEditor := NewDeploymentStateEditor(newURLStateSvc, timeService, ui, logger)
+
+			err := NewStateSetVMCIDCmd(urlEditor).Run(StateSetVMCIDOpts{
+				Args: StateCIDArgs{Path: "file:///state.json?region=us-east-1", CID: "fake-new-vm-cid"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			backupPath := "file:///state.json.20170102T030405Z.bak"
+			Expect(ui.Said).To(ContainElement("Saved backup of deployment state to '" + backupPath + "'"))
+			Expect(loadState("/state.json.20170102T030405Z.bak")).To(Equal(originalState))
+			Expect(loadState("/state.json").CurrentVMCID).To(Equal("fake-new-vm-cid"))
+		})
+
+		It("does not change state if confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("fake-confirmation-err")
+
+			err := act("fake-new-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-confirmation-err"))
+
+			Expect(loadState("/state.json")).To(Equal(originalState))
+			Expect(fs.FileExists("/state.json.20170102T030405Z.bak")).To(BeFalse())
+		})
+
+		It("returns error if CID is empty", func() {
+			err := act("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected non-empty VM CID"))
+			Expect(ui.AskedConfirmationCalled).To(BeFalse())
+
+			err = act("  ")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected non-empty VM CID"))
+		})
+
+		It("returns error if CID has surrounding whitespace", func() {
+			err := act("fake-new-vm-cid\n")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected VM CID 'fake-new-vm-cid\n' to not have leading or trailing whitespace"))
+			Expect(ui.AskedConfirmationCalled).To(BeFalse())
+		})
+
+		It("returns error if CID is already current VM CID", func() {
+			err := act("fake-vm-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("VM CID is already 'fake-vm-cid'"))
+			Expect(fs.FileExists("/state.json.20170102T030405Z.bak")).To(BeFalse())
+		})
+
+		It("returns error if CID belongs to disk or stemcell", func() {
+			err := act("fake-old-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("CID 'fake-old-disk-cid' is recorded as disk CID in deployment state"))
+
+			err = act("fake-stemcell-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("CID 'fake-stemcell-cid' is recorded as stemcell CID in deployment state"))
+
+			Expect(loadState("/state.json")).To(Equal(originalState))
+			Expect(ui.AskedConfirmationCalled).To(BeFalse())
+		})
+
+		It("returns error without creating state if it does not exist", func() {
+			err := NewStateSetVMCIDCmd(editor).Run(StateSetVMCIDOpts{
+				Args: StateCIDArgs{Path: "/missing.json", CID: "fake-new-vm-cid"},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/missing.json' does not exist"))
+			Expect(fs.FileExists("/missing.json")).To(BeFalse())
+		})
+	})
+
+	Describe("StateForgetDiskCmd", func() {
+		act := func(cid string) error {
+			return NewStateForgetDiskCmd(editor).Run(StateForgetDiskOpts{
+				Args: StateCIDArgs{Path: "/state.json", CID: cid},
+			})
+		}
+
+		It("removes disk from state", func() {
+			err := act("fake-old-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			state := loadState("/state.json")
+			Expect(state.CurrentDiskID).To(Equal("fake-disk-id"))
+			Expect(state.Disks).To(Equal([]biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid", Size: 1024},
+			}))
+			Expect(loadState("/state.json.20170102T030405Z.bak")).To(Equal(originalState))
+		})
+
+		It("clears current disk if current disk is forgotten", func() {
+			err := act("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement(
+				"Forgetting disk 'fake-disk-cid'; it is the current disk so a new disk will be created by next deploy"))
+
+			state := loadState("/state.json")
+			Expect(state.CurrentDiskID).To(BeEmpty())
+			Expect(state.Disks).To(Equal([]biconfig.DiskRecord{
+				{ID: "fake-old-disk-id", CID: "fake-old-disk-cid", Size: 512},
+			}))
+		})
+
+		It("clears current named disk if it is forgotten", func() {
+			originalState.CurrentDiskIDs = map[string]string{"fake-db-disk": "fake-db-disk-id"}
+			originalState.Disks = append(originalState.Disks,
+				biconfig.DiskRecord{ID: "fake-db-disk-id", Name: "fake-db-disk", CID: "fake-db-disk-cid", Size: 2048})
+			Expect(newStateSvc("/state.json").Save(originalState)).To(Succeed())
+
+			err := act("fake-db-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			state := loadState("/state.json")
+			Expect(state.CurrentDiskID).To(Equal("fake-disk-id"))
+			Expect(state.CurrentDiskIDs).To(BeEmpty())
+			Expect(state.Disks).To(HaveLen(2))
+		})
+
+		It("returns error if disk is not recorded", func() {
+			err := act("fake-unknown-disk-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Disk 'fake-unknown-disk-cid' is not recorded in deployment state"))
+
+			Expect(loadState("/state.json")).To(Equal(originalState))
+		})
+	})
+
+	Describe("StateForgetStemcellCmd", func() {
+		act := func(cid string) error {
+			return NewStateForgetStemcellCmd(editor).Run(StateForgetStemcellOpts{
+				Args: StateCIDArgs{Path: "/state.json", CID: cid},
+			})
+		}
+
+		It("removes stemcell from state", func() {
+			err := act("fake-old-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement("Forgetting stemcell 'fake-stemcell/1' (fake-old-stemcell-cid)"))
+
+			state := loadState("/state.json")
+			Expect(state.CurrentStemcellID).To(Equal("fake-stemcell-id"))
+			Expect(state.Stemcells).To(Equal([]biconfig.StemcellRecord{
+				{ID: "fake-stemcell-id", Name: "fake-stemcell", Version: "2", CID: "fake-stemcell-cid"},
+			}))
+		})
+
+		It("clears current stemcell if current stemcell is forgotten", func() {
+			err := act("fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			state := loadState("/state.json")
+			Expect(state.CurrentStemcellID).To(BeEmpty())
+			Expect(state.Stemcells).To(HaveLen(1))
+		})
+
+		It("returns error if stemcell is not recorded", func() {
+			err := act("fake-unknown-stemcell-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Stemcell 'fake-unknown-stemcell-cid' is not recorded in deployment state"))
+		})
+	})
+})
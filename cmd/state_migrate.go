@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type StateMigrateCmd struct {
+	stateService biconfig.DeploymentStateService
+	migrator     biconfig.LegacyDeploymentStateMigrator
+	fs           boshsys.FileSystem
+	timeService  clock.Clock
+	ui           boshui.UI
+}
+
+func NewStateMigrateCmd(
+	stateService biconfig.DeploymentStateService,
+	migrator biconfig.LegacyDeploymentStateMigrator,
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+	ui boshui.UI,
+) StateMigrateCmd {
+	return StateMigrateCmd{
+		stateService: stateService,
+		migrator:     migrator,
+		fs:           fs,
+		timeService:  timeService,
+		ui:           ui,
+	}
+}
+
+func (c StateMigrateCmd) Run(opts StateMigrateOpts) error {
+	legacyPath := opts.Args.LegacyPath
+
+	if !c.fs.FileExists(legacyPath) {
+		return bosherr.Errorf("Legacy deployment state '%s' does not exist", legacyPath)
+	}
+
+	if c.stateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' already exists", c.stateService.Path())
+	}
+
+	err := c.stateService.Lock()
+	if err != nil {
+		return bosherr.WrapError(err, "Locking deployment state")
+	}
+
+	defer c.stateService.Unlock()
+
+	// migrator deletes legacy state once it is migrated
+	backupPath := fmt.Sprintf("%s.%s.bak", legacyPath, c.timeService.Now().UTC().Format(stateBackupTimeFormat))
+
+	err = c.fs.CopyFile(legacyPath, backupPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Backing up legacy deployment state to '%s'", backupPath)
+	}
+
+	c.ui.PrintLinef("Saved backup of legacy deployment state to '%s'", backupPath)
+
+	_, err = c.migrator.MigrateIfExists(legacyPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Migrating legacy deployment state")
+	}
+
+	c.ui.PrintLinef("Migrated legacy deployment state '%s' to '%s'", legacyPath, c.stateService.Path())
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("StateMigrateCmd", func() {
+	var (
+		ui           *fakeui.FakeUI
+		fs           *fakesys.FakeFileSystem
+		stateService biconfig.DeploymentStateService
+		command      StateMigrateCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		uuidGenerator := &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+
+		stateService = biconfig.NewFileSystemDeploymentStateService(fs, uuidGenerator, logger, "/state.json")
+		migrator := biconfig.NewLegacyDeploymentStateMigrator(stateService, fs, uuidGenerator, logger)
+		timeService := fakeclock.NewFakeClock(time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC))
+
+		command = NewStateMigrateCmd(stateService, migrator, fs, timeService, ui)
+	})
+
+	Describe("Run", func() {
+		act := func() error {
+			return command.Run(StateMigrateOpts{
+				Args: StateMigrateArgs{LegacyPath: "/bosh-deployments.yml", Path: "/state.json"},
+			})
+		}
+
+		legacyContent := `---
+instances:
+- :id: 1
+  :name: micro-robinson
+  :uuid: fake-director-id
+  :stemcell_cid: fake-stemcell-cid
+  :stemcell_name: fake-stemcell-name
+  :vm_cid: fake-vm-cid
+  :disk_cid: fake-disk-cid
+`
+
+		It("migrates legacy state and keeps a backup of it", func() {
+			fs.WriteFileString("/bosh-deployments.yml", legacyContent)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			state, err := stateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.DirectorID).To(Equal("fake-director-id"))
+			Expect(state.CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(state.Disks[0].CID).To(Equal("fake-disk-cid"))
+			Expect(state.Stemcells[0].CID).To(Equal("fake-stemcell-cid"))
+
+			Expect(fs.FileExists("/bosh-deployments.yml")).To(BeFalse())
+			Expect(fs.ReadFileString("/bosh-deployments.yml.20170102T030405Z.bak")).To(Equal(legacyContent))
+
+			Expect(ui.Said).To(ContainElement("Migrated legacy deployment state '/bosh-deployments.yml' to '/state.json'"))
+		})
+
+		It("returns error if legacy state does not exist", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Legacy deployment state '/bosh-deployments.yml' does not exist"))
+		})
+
+		It("returns error if deployment state already exists", func() {
+			fs.WriteFileString("/bosh-deployments.yml", legacyContent)
+			fs.WriteFileString("/state.json", "{}")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/state.json' already exists"))
+
+			Expect(fs.FileExists("/bosh-deployments.yml")).To(BeTrue())
+			Expect(fs.ReadFileString("/state.json")).To(Equal("{}"))
+		})
+	})
+})
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type StateShowCmd struct {
+	stateService biconfig.DeploymentStateService
+	ui           boshui.UI
+}
+
+func NewStateShowCmd(stateService biconfig.DeploymentStateService, ui boshui.UI) StateShowCmd {
+	return StateShowCmd{stateService: stateService, ui: ui}
+}
+
+func (c StateShowCmd) Run(opts StateShowOpts) error {
+	// Load initializes and saves missing state hence existence is checked first
+	if !c.stateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist", c.stateService.Path())
+	}
+
+	state, err := c.stateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	StateTables{State: state}.Print(c.ui)
+
+	return nil
+}
+
+type StateTables struct {
+	State biconfig.DeploymentState
+}
+
+func (t StateTables) Print(ui boshui.UI) {
+	ui.PrintTable(boshtbl.Table{
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Director ID"),
+			boshtbl.NewHeader("Installation ID"),
+			boshtbl.NewHeader("VM CID"),
+			boshtbl.NewHeader("Manifest SHA"),
+		},
+
+		Rows: [][]boshtbl.Value{
+			{
+				boshtbl.NewValueString(t.State.DirectorID),
+				boshtbl.NewValueString(t.State.InstallationID),
+				boshtbl.NewValueString(t.State.CurrentVMCID),
+				boshtbl.NewValueString(t.State.CurrentManifestSHA),
+			},
+		},
+
+		Transpose: true,
+	})
+
+	disks := boshtbl.Table{
+		Content: "disks",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("CID"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Current"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, disk := range t.State.Disks {
+		disks.Rows = append(disks.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(disk.CID),
+			boshtbl.NewValueString(disk.Name),
+			boshtbl.NewValueMegaBytes(uint64(disk.Size)),
+			boshtbl.NewValueBool(t.State.IsCurrentDisk(disk.ID)),
+		})
+	}
+
+	ui.PrintTable(disks)
+
+	stemcells := boshtbl.Table{
+		Content: "stemcells",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+			boshtbl.NewHeader("CID"),
+			boshtbl.NewHeader("Current"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, stemcell := range t.State.Stemcells {
+		stemcells.Rows = append(stemcells.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(stemcell.Name),
+			boshtbl.NewValueString(stemcell.Version),
+			boshtbl.NewValueString(stemcell.CID),
+			boshtbl.NewValueBool(stemcell.ID == t.State.CurrentStemcellID),
+		})
+	}
+
+	ui.PrintTable(stemcells)
+
+	releases := boshtbl.Table{
+		Content: "releases",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+			boshtbl.NewHeader("Current"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, release := range t.State.Releases {
+		releases.Rows = append(releases.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(release.Name),
+			boshtbl.NewValueString(release.Version),
+			boshtbl.NewValueBool(t.isCurrentRelease(release)),
+		})
+	}
+
+	ui.PrintTable(releases)
+}
+
+func (t StateTables) isCurrentRelease(release biconfig.ReleaseRecord) bool {
+	for _, id := range t.State.CurrentReleaseIDs {
+		if id == release.ID {
+			return true
+		}
+	}
+
+	return false
+}
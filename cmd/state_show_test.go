@@ -0,0 +1,128 @@
+package cmd_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("StateShowCmd", func() {
+	var (
+		ui           *fakeui.FakeUI
+		fs           *fakesys.FakeFileSystem
+		stateService biconfig.DeploymentStateService
+		command      StateShowCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		stateService = biconfig.NewFileSystemDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, "/state.json")
+		command = NewStateShowCmd(stateService, ui)
+	})
+
+	Describe("Run", func() {
+		act := func() error {
+			return command.Run(StateShowOpts{Args: StateArgs{Path: "/state.json"}})
+		}
+
+		It("shows VM, disks, stemcells and releases", func() {
+			err := stateService.Save(biconfig.DeploymentState{
+				DirectorID:         "fake-director-id",
+				InstallationID:     "fake-installation-id",
+				CurrentVMCID:       "fake-vm-cid",
+				CurrentManifestSHA: "fake-manifest-sha",
+				CurrentDiskID:      "fake-disk-id",
+				CurrentDiskIDs:     map[string]string{"fake-db-disk": "fake-db-disk-id"},
+				Disks: []biconfig.DiskRecord{
+					{ID: "fake-disk-id", CID: "fake-disk-cid", Size: 1024},
+					{ID: "fake-old-disk-id", CID: "fake-old-disk-cid", Size: 512},
+					{ID: "fake-db-disk-id", Name: "fake-db-disk", CID: "fake-db-disk-cid", Size: 2048},
+				},
+				CurrentStemcellID: "fake-stemcell-id",
+				Stemcells: []biconfig.StemcellRecord{
+					{ID: "fake-stemcell-id", Name: "fake-stemcell", Version: "1", CID: "fake-stemcell-cid"},
+				},
+				CurrentReleaseIDs: []string{"fake-release-id"},
+				Releases: []biconfig.ReleaseRecord{
+					{ID: "fake-release-id", Name: "fake-release", Version: "2"},
+					{ID: "fake-old-release-id", Name: "fake-old-release", Version: "1"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Tables).To(HaveLen(4))
+
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-director-id"),
+					boshtbl.NewValueString("fake-installation-id"),
+					boshtbl.NewValueString("fake-vm-cid"),
+					boshtbl.NewValueString("fake-manifest-sha"),
+				},
+			}))
+
+			Expect(ui.Tables[1].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-disk-cid"),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueMegaBytes(1024),
+					boshtbl.NewValueBool(true),
+				},
+				{
+					boshtbl.NewValueString("fake-old-disk-cid"),
+					boshtbl.NewValueString(""),
+					boshtbl.NewValueMegaBytes(512),
+					boshtbl.NewValueBool(false),
+				},
+				{
+					boshtbl.NewValueString("fake-db-disk-cid"),
+					boshtbl.NewValueString("fake-db-disk"),
+					boshtbl.NewValueMegaBytes(2048),
+					boshtbl.NewValueBool(true),
+				},
+			}))
+
+			Expect(ui.Tables[2].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-stemcell"),
+					boshtbl.NewValueString("1"),
+					boshtbl.NewValueString("fake-stemcell-cid"),
+					boshtbl.NewValueBool(true),
+				},
+			}))
+
+			Expect(ui.Tables[3].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("fake-release"),
+					boshtbl.NewValueString("2"),
+					boshtbl.NewValueBool(true),
+				},
+				{
+					boshtbl.NewValueString("fake-old-release"),
+					boshtbl.NewValueString("1"),
+					boshtbl.NewValueBool(false),
+				},
+			}))
+		})
+
+		It("returns error without creating state if it does not exist", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/state.json' does not exist"))
+
+			Expect(fs.FileExists("/state.json")).To(BeFalse())
+		})
+	})
+})
@@ -30,7 +30,9 @@ func (c UpdateCloudConfigCmd) Run(opts UpdateCloudConfigOpts) error {
 		return err
 	}
 
-	diff := NewDiff(cloudConfigDiff.Diff)
+	latestFunc := func() (string, error) { return LatestConfig(c.director, "cloud", "") }
+
+	diff := NewDiff(ConfigDiffLines(c.ui, cloudConfigDiff, latestFunc, bytes, true))
 	diff.Print(c.ui)
 
 	err = c.ui.AskForConfirmation()
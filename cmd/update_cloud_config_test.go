@@ -107,6 +107,35 @@ var _ = Describe("UpdateCloudConfigCmd", func() {
 			Expect(ui.Said).To(ContainElement("- some line that was removed\n"))
 		})
 
+		Context("when director does not support diffing cloud configs", func() {
+			BeforeEach(func() {
+				opts.Args.CloudConfig = FileBytesArg{Bytes: []byte("vm_types: [{name: large}]\nnetworks: []")}
+				director.DiffCloudConfigReturns(boshdir.ConfigDiff{Unsupported: true}, nil)
+			})
+
+			It("diffs against latest cloud config locally", func() {
+				director.LatestCloudConfigReturns(boshdir.CloudConfig{Properties: "vm_types: [{name: small}]\nnetworks: []"}, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(ContainElement("  vm_types:\n"))
+				Expect(ui.Said).To(ContainElement("+ - name: large\n"))
+				Expect(ui.Said).To(ContainElement("- - name: small\n"))
+				Expect(director.UpdateCloudConfigCallCount()).To(Equal(1))
+			})
+
+			It("warns and continues updating if latest cloud config cannot be fetched", func() {
+				director.LatestCloudConfigReturns(boshdir.CloudConfig{}, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Errors).To(ContainElement("Unable to show config diff: fake-err"))
+				Expect(director.UpdateCloudConfigCallCount()).To(Equal(1))
+			})
+		})
+
 		It("does not stop if confirmation is rejected", func() {
 			ui.AskedConfirmationErr = errors.New("stop")
 
@@ -30,7 +30,9 @@ func (c UpdateCPIConfigCmd) Run(opts UpdateCPIConfigOpts) error {
 		return err
 	}
 
-	diff := NewDiff(configDiff.Diff)
+	latestFunc := func() (string, error) { return LatestConfig(c.director, "cpi", "") }
+
+	diff := NewDiff(ConfigDiffLines(c.ui, configDiff, latestFunc, bytes, !opts.NoRedact))
 	diff.Print(c.ui)
 
 	err = c.ui.AskForConfirmation()
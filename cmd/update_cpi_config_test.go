@@ -125,6 +125,38 @@ var _ = Describe("UpdateCPIConfigCmd", func() {
 			Expect(ui.Said).To(ContainElement("- some line that was removed\n"))
 		})
 
+		Context("when director does not support diffing cpi configs", func() {
+			BeforeEach(func() {
+				opts.Args.CPIConfig = FileBytesArg{Bytes: []byte("cpis:\n- name: cpi\n  properties: {key: new}")}
+				director.DiffCPIConfigReturns(boshdir.ConfigDiff{Unsupported: true}, nil)
+				director.LatestCPIConfigReturns(boshdir.CPIConfig{Properties: "cpis:\n- name: cpi\n  properties: {key: old}"}, nil)
+			})
+
+			It("diffs against latest cpi config locally with redaction", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(Equal([]string{
+					"  cpis:\n",
+					"  - name: cpi\n",
+					"    properties:\n",
+					"-     key: <redacted>\n",
+					"+     key: <redacted>\n",
+				}))
+				Expect(director.UpdateCPIConfigCallCount()).To(Equal(1))
+			})
+
+			It("does not redact when NoRedact option is passed", func() {
+				opts.NoRedact = true
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Said).To(ContainElement("-     key: old\n"))
+				Expect(ui.Said).To(ContainElement("+     key: new\n"))
+			})
+		})
+
 		Context("when NoRedact option is passed", func() {
 			BeforeEach(func() {
 				opts = UpdateCPIConfigOpts{
@@ -31,7 +31,9 @@ func (c UpdateRuntimeConfigCmd) Run(opts UpdateRuntimeConfigOpts) error {
 		return err
 	}
 
-	diff := NewDiff(configDiff.Diff)
+	latestFunc := func() (string, error) { return LatestConfig(c.director, "runtime", opts.Name) }
+
+	diff := NewDiff(ConfigDiffLines(c.ui, configDiff, latestFunc, bytes, !opts.NoRedact))
 	diff.Print(c.ui)
 
 	bytes, err = c.releaseUploader.UploadReleases(bytes)
@@ -177,6 +177,35 @@ releases:
 			Expect(ui.Said).To(ContainElement("- some line that was removed\n"))
 		})
 
+		Context("when director does not support diffing runtime configs", func() {
+			BeforeEach(func() {
+				opts.Args.RuntimeConfig = FileBytesArg{Bytes: []byte("addons: [{name: new}]")}
+				director.DiffRuntimeConfigReturns(boshdir.ConfigDiff{Unsupported: true}, nil)
+			})
+
+			It("diffs against latest runtime config with the same name locally", func() {
+				director.LatestRuntimeConfigReturns(boshdir.RuntimeConfig{Properties: "addons: [{name: old}]"}, nil)
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(director.LatestRuntimeConfigArgsForCall(0)).To(Equal("angry-smurf"))
+				Expect(ui.Said).To(ContainElement("+ - name: new\n"))
+				Expect(ui.Said).To(ContainElement("- - name: old\n"))
+				Expect(director.UpdateRuntimeConfigCallCount()).To(Equal(1))
+			})
+
+			It("warns and continues updating if latest runtime config cannot be fetched", func() {
+				director.LatestRuntimeConfigReturns(boshdir.RuntimeConfig{}, errors.New("fake-err"))
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Errors).To(ContainElement("Unable to show config diff: fake-err"))
+				Expect(director.UpdateRuntimeConfigCallCount()).To(Equal(1))
+			})
+		})
+
 		Context("when NoRedact option is passed", func() {
 			BeforeEach(func() {
 				opts = UpdateRuntimeConfigOpts{
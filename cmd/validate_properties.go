@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type ValidatePropertiesCmd struct {
+	releaseReader boshrel.Reader
+	validator     boshdirman.PropertiesValidator
+	ui            boshui.UI
+}
+
+func NewValidatePropertiesCmd(
+	releaseReader boshrel.Reader,
+	validator boshdirman.PropertiesValidator,
+	ui boshui.UI,
+) ValidatePropertiesCmd {
+	return ValidatePropertiesCmd{
+		releaseReader: releaseReader,
+		validator:     validator,
+		ui:            ui,
+	}
+}
+
+func (c ValidatePropertiesCmd) Run(opts ValidatePropertiesOpts) error {
+	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsReadOnlyVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	var specs []boshdirman.ReleaseJobSpec
+
+	for _, path := range opts.Releases {
+		relSpecs, err := c.readJobSpecs(path)
+		if err != nil {
+			return err
+		}
+
+		specs = append(specs, relSpecs...)
+	}
+
+	problems, err := c.validator.Validate(bytes, specs)
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "problems",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Instance Group"),
+			boshtbl.NewHeader("Job"),
+			boshtbl.NewHeader("Release"),
+			boshtbl.NewHeader("Property"),
+			boshtbl.NewHeader("Problem"),
+		},
+	}
+
+	for _, p := range problems {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(p.InstanceGroup),
+			boshtbl.NewValueString(p.Job),
+			boshtbl.NewValueString(p.Release),
+			boshtbl.NewValueString(p.Property),
+			boshtbl.NewValueString(p.Message),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if len(problems) > 0 {
+		return bosherr.Errorf("Found %d property problem(s) in manifest", len(problems))
+	}
+
+	return nil
+}
+
+func (c ValidatePropertiesCmd) readJobSpecs(path string) ([]boshdirman.ReleaseJobSpec, error) {
+	release, err := c.releaseReader.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release.CleanUp()
+
+	var specs []boshdirman.ReleaseJobSpec
+
+	for _, job := range release.Jobs() {
+		specs = append(specs, boshdirman.ReleaseJobSpec{
+			Release:    release.Name(),
+			Name:       job.Name(),
+			Properties: job.Properties,
+		})
+	}
+
+	return specs, nil
+}
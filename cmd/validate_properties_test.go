@@ -0,0 +1,137 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdirman "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("ValidatePropertiesCmd", func() {
+	var (
+		releaseReader *fakerel.FakeReader
+		ui            *fakeui.FakeUI
+		command       ValidatePropertiesCmd
+	)
+
+	BeforeEach(func() {
+		releaseReader = &fakerel.FakeReader{}
+		ui = &fakeui.FakeUI{}
+		command = NewValidatePropertiesCmd(releaseReader, boshdirman.NewPropertiesValidator(), ui)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts     ValidatePropertiesOpts
+			release1 *fakerel.FakeRelease
+			release2 *fakerel.FakeRelease
+		)
+
+		newJobWithProps := func(name string, props map[string]boshjob.PropertyDefinition) *boshjob.Job {
+			job := boshjob.NewJob(NewResourceWithBuiltArchive(name, "fp", "path", "sha1"))
+			job.Properties = props
+			return job
+		}
+
+		BeforeEach(func() {
+			opts = ValidatePropertiesOpts{
+				Args: ValidatePropertiesArgs{
+					Manifest: FileBytesArg{Bytes: []byte(`
+instance_groups:
+- name: ig
+  jobs:
+  - name: web
+    release: rel1
+    properties: {port: 80}
+  - name: worker
+    release: rel2
+    properties: {thread: 1}
+`)},
+				},
+				Releases: []string{"/rel1.tgz", "/rel2-dir"},
+			}
+
+			release1 = &fakerel.FakeRelease{}
+			release1.NameReturns("rel1")
+			release1.JobsReturns([]*boshjob.Job{
+				newJobWithProps("web", map[string]boshjob.PropertyDefinition{"port": {Default: 8080}}),
+			})
+
+			release2 = &fakerel.FakeRelease{}
+			release2.NameReturns("rel2")
+			release2.JobsReturns([]*boshjob.Job{
+				newJobWithProps("worker", map[string]boshjob.PropertyDefinition{"threads": {Default: 1}}),
+			})
+
+			releaseReader.ReadStub = func(path string) (boshrel.Release, error) {
+				switch path {
+				case "/rel1.tgz":
+					return release1, nil
+				case "/rel2-dir":
+					return release2, nil
+				}
+				panic("Unexpected release path")
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("reads job specs from releases and reports problems", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Found 1 property problem(s) in manifest"))
+
+			Expect(release1.CleanUpCallCount()).To(Equal(1))
+			Expect(release2.CleanUpCallCount()).To(Equal(1))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "problems",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Instance Group"),
+					boshtbl.NewHeader("Job"),
+					boshtbl.NewHeader("Release"),
+					boshtbl.NewHeader("Property"),
+					boshtbl.NewHeader("Problem"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("ig"),
+						boshtbl.NewValueString("worker"),
+						boshtbl.NewValueString("rel2"),
+						boshtbl.NewValueString("thread"),
+						boshtbl.NewValueString("Property is not defined in job spec (did you mean 'threads'?)"),
+					},
+				},
+			}))
+		})
+
+		It("prints empty table if there are no problems", func() {
+			opts.Releases = []string{"/rel1.tgz"}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table.Rows).To(BeEmpty())
+		})
+
+		It("returns error if reading release fails", func() {
+			releaseReader.ReadStub = nil
+			releaseReader.ReadReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+	})
+})
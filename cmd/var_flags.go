@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"bytes"
+
 	cfgtypes "github.com/cloudfoundry/config-server/types"
 
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 )
 
@@ -13,6 +16,27 @@ type VarFlags struct {
 	VarsFiles   []boshtpl.VarsFileArg `long:"vars-file"  short:"l" value-name:"PATH"      description:"Load variables from a YAML file"`
 	VarsEnvs    []boshtpl.VarsEnvArg  `long:"vars-env"             value-name:"PREFIX"    description:"Load variables from environment variables (e.g.: 'MY' to load MY_var=value)"`
 	VarsFSStore VarsFSStore           `long:"vars-store"           value-name:"PATH"      description:"Load/save variables from/to a YAML file"`
+	VarsSource  VarsSource            `long:"vars-source"          value-name:"SOURCE"    description:"Load/generate variables via external source (e.g.: 'exec:PATH', 'vault:URL')"`
+
+	VarsStoreKeyFlags
+}
+
+type VarsStoreKeyFlags struct {
+	Key        FileBytesArg `long:"vars-store-key"        value-name:"PATH"       description:"Encrypt/decrypt variables store with key file"`
+	Passphrase string       `long:"vars-store-passphrase" value-name:"PASSPHRASE" description:"Encrypt/decrypt variables store with passphrase" env:"BOSH_VARS_STORE_PASSPHRASE"`
+}
+
+// AsEncryptor returns nil if neither key file nor passphrase were provided
+func (f VarsStoreKeyFlags) AsEncryptor() bicrypto.Encryptor {
+	if key := bytes.TrimSpace(f.Key.Bytes); len(key) > 0 {
+		return bicrypto.NewPassphraseEncryptor(key)
+	}
+
+	if len(f.Passphrase) > 0 {
+		return bicrypto.NewPassphraseEncryptor([]byte(f.Passphrase))
+	}
+
+	return nil
 }
 
 func (f VarFlags) AsVariables() boshtpl.Variables {
@@ -44,6 +68,12 @@ func (f VarFlags) AsVariables() boshtpl.Variables {
 
 	firstToUse = append(firstToUse, staticVars)
 
+	source := &f.VarsSource
+
+	if f.VarsSource.IsSet() {
+		firstToUse = append(firstToUse, source)
+	}
+
 	store := &f.VarsFSStore
 
 	if f.VarsFSStore.IsSet() {
@@ -52,9 +82,34 @@ func (f VarFlags) AsVariables() boshtpl.Variables {
 
 	vars := boshtpl.NewMultiVars(firstToUse)
 
+	if f.VarsSource.IsSet() {
+		source.ValueGeneratorFactory = cfgtypes.NewValueGeneratorConcrete(NewVarsCertLoader(vars))
+	}
+
 	if f.VarsFSStore.IsSet() {
 		store.ValueGeneratorFactory = cfgtypes.NewValueGeneratorConcrete(NewVarsCertLoader(vars))
+		store.Encryptor = f.VarsStoreKeyFlags.AsEncryptor()
 	}
 
 	return vars
 }
+
+// AsReadOnlyVariables is meant for commands that only inspect manifests
+// (e.g. lint or diff): variables missing from vars store or vars source
+// are reported as not found instead of being generated and saved
+func (f VarFlags) AsReadOnlyVariables() boshtpl.Variables {
+	return readOnlyVariables{f.AsVariables()}
+}
+
+type readOnlyVariables struct {
+	vars boshtpl.Variables
+}
+
+func (v readOnlyVariables) Get(varDef boshtpl.VariableDefinition) (interface{}, bool, error) {
+	// Stores and sources only generate values for typed variables
+	return v.vars.Get(boshtpl.VariableDefinition{Name: varDef.Name})
+}
+
+func (v readOnlyVariables) List() ([]boshtpl.VariableDefinition, error) {
+	return v.vars.List()
+}
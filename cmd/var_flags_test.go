@@ -140,6 +140,50 @@ env: store
 			}
 		})
 
+		It("consults vars source before vars store if both are configured", func() {
+			varsStore := &VarsFSStore{FS: fakesys.NewFakeFileSystem()}
+
+			err := varsStore.UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = varsStore.FS.WriteFileString("/file", "source: store\nstore: store\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			cmdRunner := fakesys.NewFakeCmdRunner()
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Stdout: "found: true\nvalue: source",
+			})
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Stdout: "found: false",
+			})
+
+			varsSource := &VarsSource{FS: fakesys.NewFakeFileSystem(), CmdRunner: cmdRunner}
+
+			err = varsSource.UnmarshalFlag("exec:/source")
+			Expect(err).ToNot(HaveOccurred())
+
+			flags := VarFlags{
+				VarKVs:      []VarKV{{Name: "kv", Value: "kv"}},
+				VarsFSStore: *varsStore,
+				VarsSource:  *varsSource,
+			}
+
+			vars := flags.AsVariables()
+
+			expectedVals := map[string]string{
+				"kv":     "kv",
+				"source": "source",
+				"store":  "store",
+			}
+
+			for _, key := range []string{"kv", "source", "store"} {
+				val, found, err := vars.Get(VariableDefinition{Name: key})
+				Expect(val).To(Equal(expectedVals[key]), fmt.Sprintf("Expecting key '%s' value to match", key))
+				Expect(found).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
 		It("configures vars store to have ability to look up all variables for value generation", func() {
 			varsStore := &VarsFSStore{FS: fakesys.NewFakeFileSystem()}
 			varsStore.UnmarshalFlag("/file")
@@ -178,4 +222,33 @@ env: store
 			Expect(valRaw["ca"].(string)).To(Equal(caCert))
 		})
 	})
+
+	Describe("AsReadOnlyVariables", func() {
+		It("does not generate and save missing variables into vars store", func() {
+			fs := fakesys.NewFakeFileSystem()
+			varsStore := &VarsFSStore{FS: fs}
+
+			err := varsStore.UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/file", "store: store\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			flags := VarFlags{VarsFSStore: *varsStore}
+
+			vars := flags.AsReadOnlyVariables()
+
+			val, found, err := vars.Get(VariableDefinition{Name: "store", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal("store"))
+
+			val, found, err = vars.Get(VariableDefinition{Name: "missing", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(val).To(BeNil())
+
+			Expect(fs.ReadFileString("/file")).To(Equal("store: store\n"))
+		})
+	})
 })
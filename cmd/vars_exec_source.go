@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+// VarsExecSource delegates variable lookup and generation to an executable.
+//
+// Variables are retrieved by running 'PATH get' with a YAML request
+// (name, type, options) on stdin. Executable is expected to print a YAML
+// response with 'found' and 'value' keys. If variable is not found
+// and type is provided, executable may generate and persist the value.
+//
+// Variable names are listed by running 'PATH list' which is expected
+// to print a YAML array of names.
+type VarsExecSource struct {
+	path      string
+	cmdRunner boshsys.CmdRunner
+}
+
+type varsExecSourceReq struct {
+	Name    string      `yaml:"name"`
+	Type    string      `yaml:"type,omitempty"`
+	Options interface{} `yaml:"options,omitempty"`
+}
+
+type varsExecSourceResp struct {
+	Found bool        `yaml:"found"`
+	Value interface{} `yaml:"value"`
+}
+
+var _ boshtpl.Variables = VarsExecSource{}
+
+func NewVarsExecSource(path string, cmdRunner boshsys.CmdRunner) VarsExecSource {
+	return VarsExecSource{path: path, cmdRunner: cmdRunner}
+}
+
+func (s VarsExecSource) Get(varDef boshtpl.VariableDefinition) (interface{}, bool, error) {
+	reqBytes, err := yaml.Marshal(varsExecSourceReq{
+		Name:    varDef.Name,
+		Type:    varDef.Type,
+		Options: varDef.Options,
+	})
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Serializing variable '%s' request", varDef.Name)
+	}
+
+	stdout, err := s.run("get", reqBytes)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Getting variable '%s' from source", varDef.Name)
+	}
+
+	var resp varsExecSourceResp
+
+	err = yaml.Unmarshal([]byte(stdout), &resp)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Deserializing variable '%s' from source", varDef.Name)
+	}
+
+	if !resp.Found {
+		return nil, false, nil
+	}
+
+	return resp.Value, true, nil
+}
+
+func (s VarsExecSource) List() ([]boshtpl.VariableDefinition, error) {
+	stdout, err := s.run("list", nil)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing variables from source")
+	}
+
+	var names []string
+
+	err = yaml.Unmarshal([]byte(stdout), &names)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Deserializing variables list from source")
+	}
+
+	var defs []boshtpl.VariableDefinition
+
+	for _, name := range names {
+		defs = append(defs, boshtpl.VariableDefinition{Name: name})
+	}
+
+	return defs, nil
+}
+
+func (s VarsExecSource) run(action string, stdin []byte) (string, error) {
+	cmd := boshsys.Command{
+		Name:  s.path,
+		Args:  []string{action},
+		Stdin: bytes.NewReader(stdin),
+		Quiet: true,
+	}
+
+	stdout, _, _, err := s.cmdRunner.RunComplexCommand(cmd)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Running '%s %s'", s.path, action)
+	}
+
+	return stdout, nil
+}
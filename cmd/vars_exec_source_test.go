@@ -0,0 +1,122 @@
+package cmd_test
+
+import (
+	"errors"
+	"io/ioutil"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("VarsExecSource", func() {
+	var (
+		cmdRunner *fakesys.FakeCmdRunner
+		source    VarsExecSource
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		source = NewVarsExecSource("/source", cmdRunner)
+	})
+
+	Describe("Get", func() {
+		It("returns value and found if source finds variable", func() {
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Stdout: "found: true\nvalue: {key: val}",
+			})
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(val).To(Equal(map[interface{}]interface{}{"key": "val"}))
+			Expect(found).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("passes variable definition to the source on stdin", func() {
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Stdout: "found: true\nvalue: generated",
+			})
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{
+				Name:    "name",
+				Type:    "password",
+				Options: map[interface{}]interface{}{"length": 10},
+			})
+			Expect(val).To(Equal("generated"))
+			Expect(found).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+
+			stdin, err := ioutil.ReadAll(cmdRunner.RunComplexCommands[0].Stdin)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stdin)).To(Equal("name: name\ntype: password\noptions:\n  length: 10\n"))
+		})
+
+		It("returns nil and not found if source does not find variable", func() {
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Stdout: "found: false",
+			})
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(val).To(BeNil())
+			Expect(found).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if running source fails", func() {
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Error: errors.New("fake-err"),
+			})
+
+			_, _, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Getting variable 'name' from source: Running '/source get': fake-err"))
+		})
+
+		It("returns error if source output cannot be parsed", func() {
+			cmdRunner.AddCmdResult("/source get", fakesys.FakeCmdResult{
+				Stdout: "-",
+			})
+
+			_, _, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deserializing variable 'name' from source"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns list of variable names from source", func() {
+			cmdRunner.AddCmdResult("/source list", fakesys.FakeCmdResult{
+				Stdout: "- name1\n- name2",
+			})
+
+			defs, err := source.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(defs).To(Equal([]boshtpl.VariableDefinition{{Name: "name1"}, {Name: "name2"}}))
+		})
+
+		It("returns error if running source fails", func() {
+			cmdRunner.AddCmdResult("/source list", fakesys.FakeCmdResult{
+				Error: errors.New("fake-err"),
+			})
+
+			_, err := source.List()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if source output cannot be parsed", func() {
+			cmdRunner.AddCmdResult("/source list", fakesys.FakeCmdResult{
+				Stdout: "key: val",
+			})
+
+			_, err := source.List()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deserializing variables list from source"))
+		})
+	})
+})
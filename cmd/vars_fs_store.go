@@ -6,6 +6,7 @@ import (
 	cfgtypes "github.com/cloudfoundry/config-server/types"
 	"gopkg.in/yaml.v2"
 
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 )
 
@@ -14,6 +15,10 @@ type VarsFSStore struct {
 
 	ValueGeneratorFactory cfgtypes.ValueGeneratorFactory
 
+	// Encryptor is used when store file is encrypted;
+	// new store files are encrypted when it's configured
+	Encryptor bicrypto.Encryptor
+
 	path string
 }
 
@@ -92,6 +97,17 @@ func (s VarsFSStore) load() (boshtpl.StaticVariables, error) {
 			return vars, err
 		}
 
+		if bicrypto.IsEncrypted(bytes) {
+			if s.Encryptor == nil {
+				return vars, bosherr.Errorf("Expected key or passphrase to be provided for encrypted variables file store '%s'", s.path)
+			}
+
+			bytes, err = s.Encryptor.Decrypt(bytes)
+			if err != nil {
+				return vars, bosherr.WrapErrorf(err, "Decrypting variables file store '%s'", s.path)
+			}
+		}
+
 		err = yaml.Unmarshal(bytes, &vars)
 		if err != nil {
 			return vars, bosherr.WrapErrorf(err, "Deserializing variables file store '%s'", s.path)
@@ -107,6 +123,18 @@ func (s VarsFSStore) save(vars boshtpl.StaticVariables) error {
 		return bosherr.WrapErrorf(err, "Serializing variables")
 	}
 
+	encrypt, err := s.shouldEncrypt()
+	if err != nil {
+		return err
+	}
+
+	if encrypt {
+		bytes, err = s.Encryptor.Encrypt(bytes)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Encrypting variables")
+		}
+	}
+
 	err = s.FS.WriteFile(s.path, bytes)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Writing variables to file store '%s'", s.path)
@@ -115,6 +143,20 @@ func (s VarsFSStore) save(vars boshtpl.StaticVariables) error {
 	return nil
 }
 
+// shouldEncrypt preserves format of an existing store file
+func (s VarsFSStore) shouldEncrypt() (bool, error) {
+	if !s.FS.FileExists(s.path) {
+		return s.Encryptor != nil, nil
+	}
+
+	bytes, err := s.FS.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+
+	return bicrypto.IsEncrypted(bytes), nil
+}
+
 func (s *VarsFSStore) UnmarshalFlag(data string) error {
 	if len(data) == 0 {
 		return bosherr.Errorf("Expected file path to be non-empty")
@@ -10,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 )
 
@@ -120,6 +121,78 @@ var _ = Describe("VarsFSStore", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Deserializing variables file store '/file'"))
 		})
+		Context("when store file is encrypted", func() {
+			var (
+				encryptor bicrypto.Encryptor
+			)
+
+			BeforeEach(func() {
+				encryptor = bicrypto.NewPassphraseEncryptor([]byte("passphrase"))
+
+				encBytes, err := encryptor.Encrypt([]byte("key: val\n"))
+				Expect(err).ToNot(HaveOccurred())
+
+				fs.WriteFile("/file", encBytes)
+			})
+
+			It("returns decrypted value and keeps file encrypted when generating values", func() {
+				store.Encryptor = encryptor
+
+				val, found, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+				Expect(val).To(Equal("val"))
+				Expect(found).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+
+				val, found, err = store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "password"})
+				Expect(found).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+
+				encBytes, err := fs.ReadFile("/file")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bicrypto.IsEncrypted(encBytes)).To(BeTrue())
+
+				decBytes, err := encryptor.Decrypt(encBytes)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(decBytes)).To(Equal(fmt.Sprintf("key: val\nkey2: %s\n", val.(string))))
+			})
+
+			It("returns error if key or passphrase is not configured", func() {
+				_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Expected key or passphrase to be provided for encrypted variables file store '/file'"))
+			})
+
+			It("returns error if key does not match", func() {
+				store.Encryptor = bicrypto.NewPassphraseEncryptor([]byte("other"))
+
+				_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Decrypting variables file store '/file'"))
+			})
+		})
+
+		Context("when store file is not encrypted", func() {
+			It("keeps existing file unencrypted even if key is configured", func() {
+				fs.WriteFileString("/file", "key: val")
+				store.Encryptor = bicrypto.NewPassphraseEncryptor([]byte("passphrase"))
+
+				_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "password"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.ReadFileString("/file")).To(HavePrefix("key: val\n"))
+			})
+
+			It("encrypts new file if key is configured", func() {
+				store.Encryptor = bicrypto.NewPassphraseEncryptor([]byte("passphrase"))
+
+				_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "password"})
+				Expect(err).ToNot(HaveOccurred())
+
+				encBytes, err := fs.ReadFile("/file")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bicrypto.IsEncrypted(encBytes)).To(BeTrue())
+			})
+		})
 	})
 
 	Describe("List", func() {
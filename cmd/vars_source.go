@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	cfgtypes "github.com/cloudfoundry/config-server/types"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+const (
+	varsSourceExecScheme  = "exec"
+	varsSourceVaultScheme = "vault"
+
+	varsSourceVaultTokenEnv = "VAULT_TOKEN"
+)
+
+// VarsSource resolves variables from an external source such as
+// an executable (exec:PATH) or a Vault compatible KV store (vault:URL).
+// Generation of missing variables is delegated to the source.
+type VarsSource struct {
+	FS        boshsys.FileSystem
+	CmdRunner boshsys.CmdRunner
+
+	ValueGeneratorFactory cfgtypes.ValueGeneratorFactory
+
+	GetenvFunc func(string) string
+
+	scheme   string
+	location string
+	token    string
+
+	// Built once so that connections are reused across variables
+	client httpclient.Client
+}
+
+var _ boshtpl.Variables = VarsSource{}
+
+func (s VarsSource) IsSet() bool { return len(s.scheme) > 0 }
+
+func (s VarsSource) Get(varDef boshtpl.VariableDefinition) (interface{}, bool, error) {
+	source, err := s.source()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return source.Get(varDef)
+}
+
+func (s VarsSource) List() ([]boshtpl.VariableDefinition, error) {
+	source, err := s.source()
+	if err != nil {
+		return nil, err
+	}
+
+	return source.List()
+}
+
+func (s VarsSource) source() (boshtpl.Variables, error) {
+	switch s.scheme {
+	case varsSourceExecScheme:
+		return NewVarsExecSource(s.location, s.CmdRunner), nil
+
+	case varsSourceVaultScheme:
+		return NewVarsVaultSource(s.location, s.token, s.client, s.ValueGeneratorFactory), nil
+
+	default:
+		return nil, bosherr.Errorf("Expected variables source type '%s' to be one of: exec, vault", s.scheme)
+	}
+}
+
+func (s *VarsSource) UnmarshalFlag(data string) error {
+	if len(data) == 0 {
+		return bosherr.Errorf("Expected variables source to be non-empty")
+	}
+
+	pieces := strings.SplitN(data, ":", 2)
+	if len(pieces) != 2 || len(pieces[1]) == 0 {
+		return bosherr.Errorf("Expected variables source '%s' to be in format TYPE:LOCATION (e.g.: exec:PATH, vault:URL)", data)
+	}
+
+	switch pieces[0] {
+	case varsSourceExecScheme:
+		absPath, err := s.FS.ExpandPath(pieces[1])
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Getting absolute path '%s'", pieces[1])
+		}
+
+		(*s).location = absPath
+
+	case varsSourceVaultScheme:
+		if s.GetenvFunc == nil {
+			s.GetenvFunc = os.Getenv
+		}
+
+		(*s).location = strings.TrimSuffix(pieces[1], "/")
+		(*s).token = s.GetenvFunc(varsSourceVaultTokenEnv)
+		(*s).client = httpclient.CreateDefaultClient(nil)
+
+	default:
+		return bosherr.Errorf("Expected variables source type '%s' to be one of: exec, vault", pieces[0])
+	}
+
+	(*s).scheme = pieces[0]
+	(*s).ValueGeneratorFactory = cfgtypes.NewValueGeneratorConcrete(nil)
+
+	return nil
+}
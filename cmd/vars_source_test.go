@@ -0,0 +1,95 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("VarsSource", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		source    VarsSource
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		source = VarsSource{FS: fs, CmdRunner: cmdRunner}
+	})
+
+	Describe("UnmarshalFlag", func() {
+		It("configures exec source with expanded path", func() {
+			fs.ExpandPathExpanded = "/expanded-source"
+
+			err := (&source).UnmarshalFlag("exec:./source")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ExpandPathPath).To(Equal("./source"))
+			Expect(source.IsSet()).To(BeTrue())
+
+			cmdRunner.AddCmdResult("/expanded-source get", fakesys.FakeCmdResult{
+				Stdout: "found: true\nvalue: val",
+			})
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(val).To(Equal("val"))
+			Expect(found).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("configures vault source with token from environment", func() {
+			source.GetenvFunc = func(name string) string {
+				Expect(name).To(Equal("VAULT_TOKEN"))
+				return "token"
+			}
+
+			err := (&source).UnmarshalFlag("vault:https://vault/v1/secret/")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(source.IsSet()).To(BeTrue())
+		})
+
+		It("returns error if source is empty", func() {
+			err := (&source).UnmarshalFlag("")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected variables source to be non-empty"))
+		})
+
+		It("returns error if source does not specify location", func() {
+			err := (&source).UnmarshalFlag("exec:")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("to be in format TYPE:LOCATION"))
+		})
+
+		It("returns error if source type is unknown", func() {
+			err := (&source).UnmarshalFlag("unknown:path")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected variables source type 'unknown' to be one of: exec, vault"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns error if source was not configured", func() {
+			_, _, err := source.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected variables source type '' to be one of"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns error if source was not configured", func() {
+			_, err := source.List()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected variables source type '' to be one of"))
+		})
+	})
+
+	Describe("IsSet", func() {
+		It("returns false if flag was not provided", func() {
+			Expect(source.IsSet()).To(BeFalse())
+		})
+	})
+})
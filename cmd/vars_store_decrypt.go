@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+type VarsStoreDecryptCmd struct {
+	fs boshsys.FileSystem
+}
+
+func NewVarsStoreDecryptCmd(fs boshsys.FileSystem) VarsStoreDecryptCmd {
+	return VarsStoreDecryptCmd{fs: fs}
+}
+
+func (c VarsStoreDecryptCmd) Run(opts VarsStoreDecryptOpts) error {
+	encryptor := opts.VarsStoreKeyFlags.AsEncryptor()
+	if encryptor == nil {
+		return bosherr.Error("Expected key or passphrase to be provided")
+	}
+
+	path := opts.Args.Path.ExpandedPath
+
+	bytes, err := c.fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading variables store '%s'", path)
+	}
+
+	if !bicrypto.IsEncrypted(bytes) {
+		return bosherr.Errorf("Expected variables store '%s' to be encrypted", path)
+	}
+
+	decBytes, err := encryptor.Decrypt(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Decrypting variables store '%s'", path)
+	}
+
+	err = c.fs.WriteFile(path, decBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing variables store '%s'", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("VarsStoreDecryptCmd", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		command VarsStoreDecryptCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		command = NewVarsStoreDecryptCmd(fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts VarsStoreDecryptOpts
+		)
+
+		BeforeEach(func() {
+			opts = VarsStoreDecryptOpts{
+				Args:              VarsStoreArgs{Path: FileArg{ExpandedPath: "/creds.yml"}},
+				VarsStoreKeyFlags: VarsStoreKeyFlags{Passphrase: "passphrase"},
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("decrypts variables store in place", func() {
+			encBytes, err := bicrypto.NewPassphraseEncryptor([]byte("passphrase")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/creds.yml", encBytes)
+
+			err = act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString("/creds.yml")).To(Equal("key: val\n"))
+		})
+
+		It("returns error if passphrase does not match", func() {
+			encBytes, err := bicrypto.NewPassphraseEncryptor([]byte("other")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/creds.yml", encBytes)
+
+			err = act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("incorrect key or corrupted contents"))
+
+			Expect(fs.ReadFile("/creds.yml")).To(Equal(encBytes))
+		})
+
+		It("returns error if key or passphrase is not provided", func() {
+			opts.VarsStoreKeyFlags = VarsStoreKeyFlags{}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected key or passphrase to be provided"))
+		})
+
+		It("returns error if variables store is not encrypted", func() {
+			fs.WriteFileString("/creds.yml", "key: val\n")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected variables store '/creds.yml' to be encrypted"))
+		})
+	})
+})
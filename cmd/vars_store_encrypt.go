@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+type VarsStoreEncryptCmd struct {
+	fs boshsys.FileSystem
+}
+
+func NewVarsStoreEncryptCmd(fs boshsys.FileSystem) VarsStoreEncryptCmd {
+	return VarsStoreEncryptCmd{fs: fs}
+}
+
+func (c VarsStoreEncryptCmd) Run(opts VarsStoreEncryptOpts) error {
+	encryptor := opts.VarsStoreKeyFlags.AsEncryptor()
+	if encryptor == nil {
+		return bosherr.Error("Expected key or passphrase to be provided")
+	}
+
+	path := opts.Args.Path.ExpandedPath
+
+	bytes, err := c.fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading variables store '%s'", path)
+	}
+
+	if bicrypto.IsEncrypted(bytes) {
+		return bosherr.Errorf("Expected variables store '%s' to not be already encrypted", path)
+	}
+
+	encBytes, err := encryptor.Encrypt(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Encrypting variables store '%s'", path)
+	}
+
+	err = c.fs.WriteFile(path, encBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing variables store '%s'", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("VarsStoreEncryptCmd", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		command VarsStoreEncryptCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		command = NewVarsStoreEncryptCmd(fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts VarsStoreEncryptOpts
+		)
+
+		BeforeEach(func() {
+			opts = VarsStoreEncryptOpts{
+				Args:              VarsStoreArgs{Path: FileArg{ExpandedPath: "/creds.yml"}},
+				VarsStoreKeyFlags: VarsStoreKeyFlags{Passphrase: "passphrase"},
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("encrypts variables store in place", func() {
+			fs.WriteFileString("/creds.yml", "key: val\n")
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			encBytes, err := fs.ReadFile("/creds.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bicrypto.IsEncrypted(encBytes)).To(BeTrue())
+
+			decBytes, err := bicrypto.NewPassphraseEncryptor([]byte("passphrase")).Decrypt(encBytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(decBytes)).To(Equal("key: val\n"))
+		})
+
+		It("uses key file contents if provided", func() {
+			fs.WriteFileString("/creds.yml", "key: val\n")
+
+			opts.VarsStoreKeyFlags = VarsStoreKeyFlags{Key: FileBytesArg{Bytes: []byte("key\n")}}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			encBytes, err := fs.ReadFile("/creds.yml")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = bicrypto.NewPassphraseEncryptor([]byte("key")).Decrypt(encBytes)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if key or passphrase is not provided", func() {
+			opts.VarsStoreKeyFlags = VarsStoreKeyFlags{}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected key or passphrase to be provided"))
+		})
+
+		It("returns error if variables store is already encrypted", func() {
+			encBytes, err := bicrypto.NewPassphraseEncryptor([]byte("passphrase")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/creds.yml", encBytes)
+
+			err = act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected variables store '/creds.yml' to not be already encrypted"))
+		})
+
+		It("returns error if reading variables store fails", func() {
+			fs.WriteFileString("/creds.yml", "key: val\n")
+			fs.ReadFileError = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if writing variables store fails", func() {
+			fs.WriteFileString("/creds.yml", "key: val\n")
+			fs.WriteFileError = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
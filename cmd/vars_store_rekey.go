@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+type VarsStoreRekeyCmd struct {
+	fs boshsys.FileSystem
+}
+
+func NewVarsStoreRekeyCmd(fs boshsys.FileSystem) VarsStoreRekeyCmd {
+	return VarsStoreRekeyCmd{fs: fs}
+}
+
+func (c VarsStoreRekeyCmd) Run(opts VarsStoreRekeyOpts) error {
+	oldEncryptor := opts.VarsStoreKeyFlags.AsEncryptor()
+	if oldEncryptor == nil {
+		return bosherr.Error("Expected current key or passphrase to be provided")
+	}
+
+	newEncryptor := VarsStoreKeyFlags{Key: opts.NewKey, Passphrase: opts.NewPassphrase}.AsEncryptor()
+	if newEncryptor == nil {
+		return bosherr.Error("Expected new key or passphrase to be provided")
+	}
+
+	path := opts.Args.Path.ExpandedPath
+
+	bytes, err := c.fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading variables store '%s'", path)
+	}
+
+	if !bicrypto.IsEncrypted(bytes) {
+		return bosherr.Errorf("Expected variables store '%s' to be encrypted", path)
+	}
+
+	decBytes, err := oldEncryptor.Decrypt(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Decrypting variables store '%s'", path)
+	}
+
+	encBytes, err := newEncryptor.Encrypt(decBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Encrypting variables store '%s'", path)
+	}
+
+	err = c.fs.WriteFile(path, encBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing variables store '%s'", path)
+	}
+
+	return nil
+}
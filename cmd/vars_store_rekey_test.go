@@ -0,0 +1,96 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("VarsStoreRekeyCmd", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		command VarsStoreRekeyCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		command = NewVarsStoreRekeyCmd(fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts VarsStoreRekeyOpts
+		)
+
+		BeforeEach(func() {
+			opts = VarsStoreRekeyOpts{
+				Args:              VarsStoreArgs{Path: FileArg{ExpandedPath: "/creds.yml"}},
+				VarsStoreKeyFlags: VarsStoreKeyFlags{Passphrase: "old"},
+				NewPassphrase:     "new",
+			}
+
+			encBytes, err := bicrypto.NewPassphraseEncryptor([]byte("old")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/creds.yml", encBytes)
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("re-encrypts variables store with new passphrase", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			encBytes, err := fs.ReadFile("/creds.yml")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = bicrypto.NewPassphraseEncryptor([]byte("old")).Decrypt(encBytes)
+			Expect(err).To(HaveOccurred())
+
+			decBytes, err := bicrypto.NewPassphraseEncryptor([]byte("new")).Decrypt(encBytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(decBytes)).To(Equal("key: val\n"))
+		})
+
+		It("re-encrypts variables store with new key file", func() {
+			opts.NewPassphrase = ""
+			opts.NewKey = FileBytesArg{Bytes: []byte("new-key")}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			encBytes, err := fs.ReadFile("/creds.yml")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = bicrypto.NewPassphraseEncryptor([]byte("new-key")).Decrypt(encBytes)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if current passphrase does not match", func() {
+			opts.VarsStoreKeyFlags = VarsStoreKeyFlags{Passphrase: "wrong"}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("incorrect key or corrupted contents"))
+		})
+
+		It("returns error if current key or passphrase is not provided", func() {
+			opts.VarsStoreKeyFlags = VarsStoreKeyFlags{}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected current key or passphrase to be provided"))
+		})
+
+		It("returns error if new key or passphrase is not provided", func() {
+			opts.NewPassphrase = ""
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected new key or passphrase to be provided"))
+		})
+	})
+})
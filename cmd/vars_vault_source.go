@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	cfgtypes "github.com/cloudfoundry/config-server/types"
+	"gopkg.in/yaml.v2"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+// VarsVaultSource retrieves variables from a Vault compatible KV store.
+// Each variable is kept at URL/NAME under the 'value' key. Missing
+// variables are generated locally and written back to the store.
+type VarsVaultSource struct {
+	url   string
+	token string
+
+	client                httpclient.Client
+	valueGeneratorFactory cfgtypes.ValueGeneratorFactory
+}
+
+type varsVaultSourceResp struct {
+	Data struct {
+		Value interface{} `yaml:"value"`
+		Keys  []string    `yaml:"keys"`
+	} `yaml:"data"`
+}
+
+var _ boshtpl.Variables = VarsVaultSource{}
+
+func NewVarsVaultSource(
+	url string,
+	token string,
+	client httpclient.Client,
+	valueGeneratorFactory cfgtypes.ValueGeneratorFactory,
+) VarsVaultSource {
+	return VarsVaultSource{
+		url:   url,
+		token: token,
+
+		client:                client,
+		valueGeneratorFactory: valueGeneratorFactory,
+	}
+}
+
+func (s VarsVaultSource) Get(varDef boshtpl.VariableDefinition) (interface{}, bool, error) {
+	resp, found, err := s.request("GET", "/"+varDef.Name, nil)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Getting variable '%s' from source", varDef.Name)
+	}
+
+	if found {
+		return resp.Data.Value, true, nil
+	}
+
+	if len(varDef.Type) == 0 {
+		return nil, false, nil
+	}
+
+	val, err := s.generateAndSet(varDef)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Generating variable '%s'", varDef.Name)
+	}
+
+	return val, true, nil
+}
+
+func (s VarsVaultSource) List() ([]boshtpl.VariableDefinition, error) {
+	resp, found, err := s.request("LIST", "", nil)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing variables from source")
+	}
+
+	var defs []boshtpl.VariableDefinition
+
+	if found {
+		for _, name := range resp.Data.Keys {
+			defs = append(defs, boshtpl.VariableDefinition{Name: name})
+		}
+	}
+
+	return defs, nil
+}
+
+func (s VarsVaultSource) generateAndSet(varDef boshtpl.VariableDefinition) (interface{}, error) {
+	generator, err := s.valueGeneratorFactory.GetGenerator(varDef.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := generator.Generate(varDef.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBytes, err := json.Marshal(map[string]interface{}{"value": val})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Serializing variable")
+	}
+
+	_, _, err = s.request("PUT", "/"+varDef.Name, reqBytes)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Saving variable to source")
+	}
+
+	// Return value in the same form as it would be retrieved later
+	valBytes, err := yaml.Marshal(val)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Serializing variable")
+	}
+
+	var normalizedVal interface{}
+
+	err = yaml.Unmarshal(valBytes, &normalizedVal)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Deserializing variable")
+	}
+
+	return normalizedVal, nil
+}
+
+func (s VarsVaultSource) request(method, path string, payload []byte) (varsVaultSourceResp, bool, error) {
+	var resp varsVaultSourceResp
+
+	req, err := http.NewRequest(method, s.url+path, bytes.NewReader(payload))
+	if err != nil {
+		return resp, false, bosherr.WrapErrorf(err, "Creating %s request", method)
+	}
+
+	if len(s.token) > 0 {
+		req.Header.Set("X-Vault-Token", s.token)
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return resp, false, bosherr.WrapErrorf(err, "Performing %s request", method)
+	}
+
+	defer httpResp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, false, bosherr.WrapError(err, "Reading response body")
+	}
+
+	if httpResp.StatusCode == http.StatusNotFound {
+		return resp, false, nil
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return resp, false, bosherr.Errorf(
+			"Source responded with non-successful status code '%d' response '%s'", httpResp.StatusCode, respBytes)
+	}
+
+	if len(respBytes) > 0 {
+		// JSON is a subset of YAML; YAML decoding keeps values compatible with template evaluation
+		err = yaml.Unmarshal(respBytes, &resp)
+		if err != nil {
+			return resp, false, bosherr.WrapError(err, "Deserializing response")
+		}
+	}
+
+	return resp, true, nil
+}
@@ -0,0 +1,131 @@
+package cmd_test
+
+import (
+	"errors"
+	"net/http"
+
+	cfgtypes "github.com/cloudfoundry/config-server/types"
+	fakecfgtypes "github.com/cloudfoundry/config-server/types/typesfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("VarsVaultSource", func() {
+	var (
+		server *ghttp.Server
+		source VarsVaultSource
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		source = NewVarsVaultSource(
+			server.URL()+"/v1/secret/bosh", "token", http.DefaultClient, cfgtypes.NewValueGeneratorConcrete(nil))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Get", func() {
+		It("returns value and found if source finds variable", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/secret/bosh/name"),
+					ghttp.VerifyHeader(http.Header{"X-Vault-Token": []string{"token"}}),
+					ghttp.RespondWith(http.StatusOK, `{"data":{"value":{"key":"val"}}}`),
+				),
+			)
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(val).To(Equal(map[interface{}]interface{}{"key": "val"}))
+			Expect(found).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns nil and not found if variable is missing and type is not available", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/secret/bosh/name"),
+					ghttp.RespondWith(http.StatusNotFound, `{"errors":[]}`),
+				),
+			)
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(val).To(BeNil())
+			Expect(found).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("generates value and saves it if variable is missing and type is available", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v1/secret/bosh/name"),
+					ghttp.RespondWith(http.StatusNotFound, ``),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/v1/secret/bosh/name"),
+					ghttp.VerifyHeader(http.Header{"X-Vault-Token": []string{"token"}}),
+					ghttp.RespondWith(http.StatusNoContent, ``),
+				),
+			)
+
+			val, found, err := source.Get(boshtpl.VariableDefinition{Name: "name", Type: "password"})
+			Expect(len(val.(string))).To(BeNumerically(">", 10))
+			Expect(found).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("returns error if generating variable fails", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ``))
+
+			generator := &fakecfgtypes.FakeValueGenerator{}
+			generator.GenerateReturns(nil, errors.New("fake-err"))
+
+			factory := &fakecfgtypes.FakeValueGeneratorFactory{}
+			factory.GetGeneratorReturns(generator, nil)
+
+			source = NewVarsVaultSource(server.URL(), "token", http.DefaultClient, factory)
+
+			_, _, err := source.Get(boshtpl.VariableDefinition{Name: "name", Type: "type"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Generating variable 'name': fake-err"))
+		})
+
+		It("returns error if source responds with non-successful status", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, `denied`))
+
+			_, _, err := source.Get(boshtpl.VariableDefinition{Name: "name"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("non-successful status code '403' response 'denied'"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns list of variable names from source", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("LIST", "/v1/secret/bosh"),
+					ghttp.RespondWith(http.StatusOK, `{"data":{"keys":["name1","name2"]}}`),
+				),
+			)
+
+			defs, err := source.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(defs).To(Equal([]boshtpl.VariableDefinition{{Name: "name1"}, {Name: "name2"}}))
+		})
+
+		It("returns no variables if source path does not exist", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ``))
+
+			defs, err := source.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(defs).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type VerifyBlobsCmd struct {
+	blobsAuditor boshreldir.BlobsAuditor
+	ui           boshui.UI
+}
+
+func NewVerifyBlobsCmd(blobsAuditor boshreldir.BlobsAuditor, ui boshui.UI) VerifyBlobsCmd {
+	return VerifyBlobsCmd{blobsAuditor: blobsAuditor, ui: ui}
+}
+
+func (c VerifyBlobsCmd) Run() error {
+	problems, err := c.blobsAuditor.VerifyBlobs()
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		c.ui.PrintLinef("Verified local blobs and blobs in blobstore")
+		return nil
+	}
+
+	table := boshtbl.Table{
+		Content: "blob problems",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Source"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Blobstore ID"),
+			boshtbl.NewHeader("Problem"),
+		},
+	}
+
+	for _, problem := range problems {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(problem.Source),
+			boshtbl.NewValueString(problem.Name),
+			boshtbl.NewValueString(problem.BlobstoreID),
+			boshtbl.NewValueString(problem.Problem),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return bosherr.Errorf("Found %d blob problem(s)", len(problems))
+}
@@ -0,0 +1,82 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+	fakereldir "github.com/cloudfoundry/bosh-cli/releasedir/releasedirfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("VerifyBlobsCmd", func() {
+	var (
+		blobsAuditor *fakereldir.FakeBlobsAuditor
+		ui           *fakeui.FakeUI
+		command      VerifyBlobsCmd
+	)
+
+	BeforeEach(func() {
+		blobsAuditor = &fakereldir.FakeBlobsAuditor{}
+		ui = &fakeui.FakeUI{}
+		command = NewVerifyBlobsCmd(blobsAuditor, ui)
+	})
+
+	Describe("Run", func() {
+		act := func() error { return command.Run() }
+
+		It("succeeds if there are no problems", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Said).To(Equal([]string{"Verified local blobs and blobs in blobstore"}))
+			Expect(ui.Tables).To(BeEmpty())
+		})
+
+		It("shows problems and returns error", func() {
+			blobsAuditor.VerifyBlobsReturns([]boshreldir.BlobProblem{
+				{
+					Source:      "config/blobs.yml",
+					Name:        "dir/file",
+					BlobstoreID: "blob-id",
+					Problem:     "Missing from blobstore",
+				},
+			}, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Found 1 blob problem(s)"))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "blob problems",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Source"),
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Blobstore ID"),
+					boshtbl.NewHeader("Problem"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("config/blobs.yml"),
+						boshtbl.NewValueString("dir/file"),
+						boshtbl.NewValueString("blob-id"),
+						boshtbl.NewValueString("Missing from blobstore"),
+					},
+				},
+			}))
+		})
+
+		It("returns error if verifying fails", func() {
+			blobsAuditor.VerifyBlobsReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
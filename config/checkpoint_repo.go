@@ -0,0 +1,270 @@
+package config
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	CheckpointVMCreated    = "vm_created"
+	CheckpointDiskAttached = "disk_attached"
+)
+
+// CheckpointRecord tracks progress of a deploy so that it can be resumed after a failure
+type CheckpointRecord struct {
+	ManifestSHA    string   `json:"manifest_sha"`
+	StemcellCID    string   `json:"stemcell_cid"`
+	CompletedSteps []string `json:"completed_steps"`
+
+	// VMCID and PendingDiskCID are created by the deploy but not yet fully deployed
+	VMCID          string `json:"vm_cid,omitempty"`
+	PendingDiskCID string `json:"pending_disk_cid,omitempty"`
+
+	// Created CIDs are kept until VMs and disks are deleted to find orphaned ones
+	CreatedVMCIDs   []string `json:"created_vm_cids,omitempty"`
+	CreatedDiskCIDs []string `json:"created_disk_cids,omitempty"`
+}
+
+func (r CheckpointRecord) IsCompleted(step string) bool {
+	for _, completedStep := range r.CompletedSteps {
+		if completedStep == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanResumeVM returns true if current VM was created by the deploy being resumed;
+// VMs with attached disks are recreated since disk attachments cannot be safely repeated
+func (r CheckpointRecord) CanResumeVM(currentVMCID string) bool {
+	if r.IsCompleted(CheckpointDiskAttached) {
+		return false
+	}
+
+	return r.IsCompleted(CheckpointVMCreated) && len(r.VMCID) > 0 && r.VMCID == currentVMCID
+}
+
+type CheckpointRepo interface {
+	Find() (CheckpointRecord, bool, error)
+
+	// Start keeps checkpoint of a previous deploy of the same manifest and stemcell
+	Start(manifestSHA, stemcellCID string) (CheckpointRecord, bool, error)
+	Finish() error
+
+	// Discard removes progress of a previous deploy so that it's not resumed
+	Discard() error
+
+	CompleteStep(step string) error
+
+	RecordVM(cid string) error
+	RecordPendingDisk(cid string) error
+	ClearPendingDisk() error
+
+	FindOrphans() (vmCIDs []string, diskCIDs []string, err error)
+	ForgetVM(cid string) error
+	ForgetDisk(cid string) error
+}
+
+type checkpointRepo struct {
+	deploymentStateService DeploymentStateService
+}
+
+func NewCheckpointRepo(deploymentStateService DeploymentStateService) CheckpointRepo {
+	return checkpointRepo{deploymentStateService: deploymentStateService}
+}
+
+func (r checkpointRepo) Find() (CheckpointRecord, bool, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return CheckpointRecord{}, false, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Checkpoint == nil || len(deploymentState.Checkpoint.ManifestSHA) == 0 {
+		return CheckpointRecord{}, false, nil
+	}
+
+	return *deploymentState.Checkpoint, true, nil
+}
+
+func (r checkpointRepo) Start(manifestSHA, stemcellCID string) (CheckpointRecord, bool, error) {
+	var record CheckpointRecord
+	var resumed bool
+
+	err := r.update(func(checkpoint *CheckpointRecord) {
+		if checkpoint.ManifestSHA == manifestSHA && checkpoint.StemcellCID == stemcellCID {
+			resumed = len(checkpoint.CompletedSteps) > 0
+		} else {
+			*checkpoint = CheckpointRecord{
+				ManifestSHA: manifestSHA,
+				StemcellCID: stemcellCID,
+
+				CreatedVMCIDs:   checkpoint.CreatedVMCIDs,
+				CreatedDiskCIDs: checkpoint.CreatedDiskCIDs,
+			}
+		}
+
+		record = *checkpoint
+	})
+
+	return record, resumed, err
+}
+
+// Finish removes progress of a successful deploy and keeps only orphaned CIDs
+func (r checkpointRepo) Finish() error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	vmCIDs, diskCIDs := r.orphans(deploymentState)
+
+	if len(vmCIDs) == 0 && len(diskCIDs) == 0 {
+		deploymentState.Checkpoint = nil
+	} else {
+		deploymentState.Checkpoint = &CheckpointRecord{
+			CreatedVMCIDs:   vmCIDs,
+			CreatedDiskCIDs: diskCIDs,
+		}
+	}
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+func (r checkpointRepo) Discard() error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		*checkpoint = CheckpointRecord{
+			CreatedVMCIDs:   checkpoint.CreatedVMCIDs,
+			CreatedDiskCIDs: checkpoint.CreatedDiskCIDs,
+		}
+	})
+}
+
+func (r checkpointRepo) CompleteStep(step string) error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		if !checkpoint.IsCompleted(step) {
+			checkpoint.CompletedSteps = append(checkpoint.CompletedSteps, step)
+		}
+	})
+}
+
+func (r checkpointRepo) RecordVM(cid string) error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		checkpoint.VMCID = cid
+		checkpoint.CreatedVMCIDs = appendCID(checkpoint.CreatedVMCIDs, cid)
+	})
+}
+
+func (r checkpointRepo) RecordPendingDisk(cid string) error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		checkpoint.PendingDiskCID = cid
+		checkpoint.CreatedDiskCIDs = appendCID(checkpoint.CreatedDiskCIDs, cid)
+	})
+}
+
+func (r checkpointRepo) ClearPendingDisk() error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		checkpoint.PendingDiskCID = ""
+	})
+}
+
+// FindOrphans returns VMs and disks created by deploys that are no longer referenced by deployment state
+func (r checkpointRepo) FindOrphans() ([]string, []string, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	vmCIDs, diskCIDs := r.orphans(deploymentState)
+
+	return vmCIDs, diskCIDs, nil
+}
+
+func (r checkpointRepo) ForgetVM(cid string) error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		checkpoint.CreatedVMCIDs = removeCID(checkpoint.CreatedVMCIDs, cid)
+	})
+}
+
+func (r checkpointRepo) ForgetDisk(cid string) error {
+	return r.update(func(checkpoint *CheckpointRecord) {
+		checkpoint.CreatedDiskCIDs = removeCID(checkpoint.CreatedDiskCIDs, cid)
+	})
+}
+
+func (r checkpointRepo) orphans(deploymentState DeploymentState) ([]string, []string) {
+	var vmCIDs, diskCIDs []string
+
+	if deploymentState.Checkpoint == nil {
+		return vmCIDs, diskCIDs
+	}
+
+	for _, cid := range deploymentState.Checkpoint.CreatedVMCIDs {
+		if cid != deploymentState.CurrentVMCID {
+			vmCIDs = append(vmCIDs, cid)
+		}
+	}
+
+	for _, cid := range deploymentState.Checkpoint.CreatedDiskCIDs {
+		found := false
+
+		for _, diskRecord := range deploymentState.Disks {
+			if diskRecord.CID == cid {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			diskCIDs = append(diskCIDs, cid)
+		}
+	}
+
+	return vmCIDs, diskCIDs
+}
+
+func (r checkpointRepo) update(updateFunc func(*CheckpointRecord)) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.Checkpoint == nil {
+		deploymentState.Checkpoint = &CheckpointRecord{}
+	}
+
+	updateFunc(deploymentState.Checkpoint)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+func appendCID(cids []string, cid string) []string {
+	for _, existingCID := range cids {
+		if existingCID == cid {
+			return cids
+		}
+	}
+
+	return append(cids, cid)
+}
+
+func removeCID(cids []string, cid string) []string {
+	var keptCIDs []string
+
+	for _, existingCID := range cids {
+		if existingCID != cid {
+			keptCIDs = append(keptCIDs, existingCID)
+		}
+	}
+
+	return keptCIDs
+}
@@ -0,0 +1,209 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckpointRepo", func() {
+	var (
+		repo                   CheckpointRepo
+		vmRepo                 VMRepo
+		diskRepo               DiskRepo
+		deploymentStateService DeploymentStateService
+		fs                     *fakesys.FakeFileSystem
+		fakeUUIDGenerator      *fakeuuid.FakeGenerator
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
+		repo = NewCheckpointRepo(deploymentStateService)
+		vmRepo = NewVMRepo(deploymentStateService)
+		diskRepo = NewDiskRepo(deploymentStateService, fakeUUIDGenerator)
+	})
+
+	Describe("Find", func() {
+		It("returns false when deploy was not started", func() {
+			_, found, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns checkpoint of started deploy", func() {
+			_, _, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(repo.CompleteStep(CheckpointVMCreated)).To(Succeed())
+
+			record, found, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(record.ManifestSHA).To(Equal("fake-manifest-sha"))
+			Expect(record.StemcellCID).To(Equal("fake-stemcell-cid"))
+			Expect(record.IsCompleted(CheckpointVMCreated)).To(BeTrue())
+		})
+	})
+
+	Describe("Start", func() {
+		BeforeEach(func() {
+			_, _, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(repo.RecordVM("fake-vm-cid")).To(Succeed())
+			Expect(repo.CompleteStep(CheckpointVMCreated)).To(Succeed())
+			Expect(repo.RecordPendingDisk("fake-disk-cid")).To(Succeed())
+		})
+
+		It("resumes checkpoint of the same manifest and stemcell", func() {
+			record, resumed, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed).To(BeTrue())
+			Expect(record.CanResumeVM("fake-vm-cid")).To(BeTrue())
+			Expect(record.PendingDiskCID).To(Equal("fake-disk-cid"))
+		})
+
+		It("resets progress but keeps created CIDs when manifest changed", func() {
+			record, resumed, err := repo.Start("fake-other-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed).To(BeFalse())
+			Expect(record).To(Equal(CheckpointRecord{
+				ManifestSHA:     "fake-other-manifest-sha",
+				StemcellCID:     "fake-stemcell-cid",
+				CreatedVMCIDs:   []string{"fake-vm-cid"},
+				CreatedDiskCIDs: []string{"fake-disk-cid"},
+			}))
+		})
+
+		It("does not resume vm once disks were attached to it", func() {
+			Expect(repo.CompleteStep(CheckpointDiskAttached)).To(Succeed())
+
+			record, resumed, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed).To(BeTrue())
+			Expect(record.CanResumeVM("fake-vm-cid")).To(BeFalse())
+			Expect(record.PendingDiskCID).To(Equal("fake-disk-cid"))
+		})
+
+		It("resets progress when stemcell changed", func() {
+			record, resumed, err := repo.Start("fake-manifest-sha", "fake-other-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed).To(BeFalse())
+			Expect(record.CanResumeVM("fake-vm-cid")).To(BeFalse())
+		})
+	})
+
+	Describe("Discard", func() {
+		BeforeEach(func() {
+			_, _, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(repo.RecordVM("fake-vm-cid")).To(Succeed())
+			Expect(repo.CompleteStep(CheckpointVMCreated)).To(Succeed())
+			Expect(repo.RecordPendingDisk("fake-disk-cid")).To(Succeed())
+		})
+
+		It("removes progress so that the same deploy is not resumed but keeps created CIDs", func() {
+			Expect(repo.Discard()).To(Succeed())
+
+			_, found, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			record, resumed, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed).To(BeFalse())
+			Expect(record).To(Equal(CheckpointRecord{
+				ManifestSHA:     "fake-manifest-sha",
+				StemcellCID:     "fake-stemcell-cid",
+				CreatedVMCIDs:   []string{"fake-vm-cid"},
+				CreatedDiskCIDs: []string{"fake-disk-cid"},
+			}))
+		})
+	})
+
+	Describe("FindOrphans", func() {
+		BeforeEach(func() {
+			_, _, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(repo.RecordVM("fake-orphan-vm-cid")).To(Succeed())
+			Expect(repo.RecordVM("fake-vm-cid")).To(Succeed())
+			Expect(vmRepo.UpdateCurrent("fake-vm-cid")).To(Succeed())
+
+			Expect(repo.RecordPendingDisk("fake-orphan-disk-cid")).To(Succeed())
+			Expect(repo.RecordPendingDisk("fake-disk-cid")).To(Succeed())
+			_, err = diskRepo.Save("", "fake-disk-cid", 1024, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns created VMs and disks that are not referenced by deployment state", func() {
+			vmCIDs, diskCIDs, err := repo.FindOrphans()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmCIDs).To(Equal([]string{"fake-orphan-vm-cid"}))
+			Expect(diskCIDs).To(Equal([]string{"fake-orphan-disk-cid"}))
+		})
+
+		It("does not return VMs and disks that were deleted", func() {
+			Expect(vmRepo.ClearCurrent()).To(Succeed())
+
+			diskRecord, found, err := diskRepo.Find("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(diskRepo.Delete(diskRecord)).To(Succeed())
+
+			vmCIDs, diskCIDs, err := repo.FindOrphans()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmCIDs).To(Equal([]string{"fake-orphan-vm-cid"}))
+			Expect(diskCIDs).To(Equal([]string{"fake-orphan-disk-cid"}))
+		})
+
+		It("does not return forgotten VMs and disks", func() {
+			Expect(repo.ForgetVM("fake-orphan-vm-cid")).To(Succeed())
+			Expect(repo.ForgetDisk("fake-orphan-disk-cid")).To(Succeed())
+
+			vmCIDs, diskCIDs, err := repo.FindOrphans()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmCIDs).To(BeEmpty())
+			Expect(diskCIDs).To(BeEmpty())
+		})
+	})
+
+	Describe("Finish", func() {
+		It("removes checkpoint when there are no orphans", func() {
+			_, _, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.RecordVM("fake-vm-cid")).To(Succeed())
+			Expect(vmRepo.UpdateCurrent("fake-vm-cid")).To(Succeed())
+
+			Expect(repo.Finish()).To(Succeed())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Checkpoint).To(BeNil())
+		})
+
+		It("keeps only orphaned CIDs", func() {
+			_, _, err := repo.Start("fake-manifest-sha", "fake-stemcell-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.RecordVM("fake-orphan-vm-cid")).To(Succeed())
+			Expect(repo.CompleteStep(CheckpointVMCreated)).To(Succeed())
+
+			Expect(repo.Finish()).To(Succeed())
+
+			_, found, err := repo.Find()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			vmCIDs, _, err := repo.FindOrphans()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmCIDs).To(Equal([]string{"fake-orphan-vm-cid"}))
+		})
+	})
+})
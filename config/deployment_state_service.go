@@ -15,6 +15,12 @@ type DeploymentState struct {
 	Disks              []DiskRecord     `json:"disks"`
 	Stemcells          []StemcellRecord `json:"stemcells"`
 	Releases           []ReleaseRecord  `json:"releases"`
+
+	// CurrentDiskIDs maps names of named persistent disks to their current disk IDs
+	CurrentDiskIDs map[string]string `json:"current_disk_ids,omitempty"`
+
+	// Checkpoint is only present while deploy is in progress or orphans were found
+	Checkpoint *CheckpointRecord `json:"checkpoint,omitempty"`
 }
 
 type StemcellRecord struct {
@@ -26,11 +32,31 @@ type StemcellRecord struct {
 
 type DiskRecord struct {
 	ID              string         `json:"id"`
+	Name            string         `json:"name,omitempty"`
 	CID             string         `json:"cid"`
 	Size            int            `json:"size"`
 	CloudProperties biproperty.Map `json:"cloud_properties"`
 }
 
+// IsCurrentDisk returns true if disk is current unnamed or named persistent disk
+func (s DeploymentState) IsCurrentDisk(diskID string) bool {
+	if diskID == "" {
+		return false
+	}
+
+	if s.CurrentDiskID == diskID {
+		return true
+	}
+
+	for _, currentDiskID := range s.CurrentDiskIDs {
+		if currentDiskID == diskID {
+			return true
+		}
+	}
+
+	return false
+}
+
 type ReleaseRecord struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
@@ -43,4 +69,8 @@ type DeploymentStateService interface {
 	Load() (DeploymentState, error)
 	Save(DeploymentState) error
 	Cleanup() error
+
+	// Lock prevents concurrent modifications of deployment state by other processes
+	Lock() error
+	Unlock() error
 }
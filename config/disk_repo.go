@@ -7,10 +7,15 @@ import (
 )
 
 type DiskRepo interface {
+	// UpdateCurrent makes disk current for its name; unnamed disk becomes the current disk
 	UpdateCurrent(diskID string) error
+	// FindCurrent returns current unnamed disk
 	FindCurrent() (DiskRecord, bool, error)
+	// FindAllCurrent returns current unnamed disk and all current named disks
+	FindAllCurrent() ([]DiskRecord, error)
 	ClearCurrent() error
-	Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
+	Save(name, cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error)
+	UpdateSize(diskID string, size int) error
 	Find(cid string) (DiskRecord, bool, error)
 	All() ([]DiskRecord, error)
 	Delete(DiskRecord) error
@@ -28,7 +33,7 @@ func NewDiskRepo(deploymentStateService DeploymentStateService, uuidGenerator bo
 	}
 }
 
-func (r diskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error) {
+func (r diskRepo) Save(name, cid string, size int, cloudProperties biproperty.Map) (DiskRecord, error) {
 	config, records, err := r.load()
 	if err != nil {
 		return DiskRecord{}, err
@@ -40,6 +45,7 @@ func (r diskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (Di
 	}
 
 	newRecord := DiskRecord{
+		Name:            name,
 		CID:             cid,
 		Size:            size,
 		CloudProperties: cloudProperties,
@@ -79,15 +85,59 @@ func (r diskRepo) FindCurrent() (DiskRecord, bool, error) {
 	return DiskRecord{}, false, nil
 }
 
+func (r diskRepo) FindAllCurrent() ([]DiskRecord, error) {
+	deploymentState, records, err := r.load()
+	if err != nil {
+		return []DiskRecord{}, err
+	}
+
+	currentRecords := []DiskRecord{}
+	for _, record := range records {
+		if deploymentState.IsCurrentDisk(record.ID) {
+			currentRecords = append(currentRecords, record)
+		}
+	}
+
+	return currentRecords, nil
+}
+
 func (r diskRepo) UpdateCurrent(diskID string) error {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
 		return bosherr.WrapError(err, "Loading existing config")
 	}
 
+	record, found := r.findByID(deploymentState.Disks, diskID)
+	if !found {
+		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
+	}
+
+	if record.Name == "" {
+		deploymentState.CurrentDiskID = diskID
+	} else {
+		if deploymentState.CurrentDiskIDs == nil {
+			deploymentState.CurrentDiskIDs = map[string]string{}
+		}
+		deploymentState.CurrentDiskIDs[record.Name] = diskID
+	}
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
+
+func (r diskRepo) UpdateSize(diskID string, size int) error {
+	deploymentState, records, err := r.load()
+	if err != nil {
+		return err
+	}
+
 	found := false
-	for _, oldRecord := range deploymentState.Disks {
-		if oldRecord.ID == diskID {
+	for i, record := range records {
+		if record.ID == diskID {
+			records[i].Size = size
 			found = true
 		}
 	}
@@ -95,7 +145,7 @@ func (r diskRepo) UpdateCurrent(diskID string) error {
 		return bosherr.Errorf("Verifying disk record exists with id '%s'", diskID)
 	}
 
-	deploymentState.CurrentDiskID = diskID
+	deploymentState.Disks = records
 
 	err = r.deploymentStateService.Save(deploymentState)
 	if err != nil {
@@ -142,6 +192,15 @@ func (r diskRepo) Delete(diskRecord DiskRecord) error {
 		config.CurrentDiskID = ""
 	}
 
+	if config.CurrentDiskIDs[diskRecord.Name] == diskRecord.ID {
+		delete(config.CurrentDiskIDs, diskRecord.Name)
+	}
+
+	// deleted disk is no longer an orphan candidate
+	if config.Checkpoint != nil {
+		config.Checkpoint.CreatedDiskCIDs = removeCID(config.Checkpoint.CreatedDiskCIDs, diskRecord.CID)
+	}
+
 	err = r.deploymentStateService.Save(config)
 	if err != nil {
 		return bosherr.WrapError(err, "Saving new config")
@@ -157,6 +216,7 @@ func (r diskRepo) ClearCurrent() error {
 	}
 
 	deploymentState.CurrentDiskID = ""
+	deploymentState.CurrentDiskIDs = nil
 
 	err = r.deploymentStateService.Save(deploymentState)
 	if err != nil {
@@ -187,3 +247,12 @@ func (r diskRepo) find(records []DiskRecord, cid string) (DiskRecord, bool) {
 	}
 	return DiskRecord{}, false
 }
+
+func (r diskRepo) findByID(records []DiskRecord, id string) (DiskRecord, bool) {
+	for _, existingRecord := range records {
+		if existingRecord.ID == id {
+			return existingRecord, true
+		}
+	}
+	return DiskRecord{}, false
+}
@@ -33,7 +33,7 @@ var _ = Describe("DiskRepo", func() {
 
 	Describe("Save", func() {
 		It("saves the disk record using the config service", func() {
-			record, err := repo.Save("fake-cid", 1024, cloudProperties)
+			record, err := repo.Save("", "fake-cid", 1024, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(record).To(Equal(DiskRecord{
 				ID:              "fake-uuid-1",
@@ -62,7 +62,7 @@ var _ = Describe("DiskRepo", func() {
 
 	Describe("Find", func() {
 		It("finds existing disk records", func() {
-			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties)
+			savedRecord, err := repo.Save("", "fake-cid", 1024, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 
 			foundRecord, found, err := repo.Find("fake-cid")
@@ -72,7 +72,7 @@ var _ = Describe("DiskRepo", func() {
 		})
 
 		It("when the disk is not in the records, returns not found", func() {
-			_, err := repo.Save("other-cid", 1024, cloudProperties)
+			_, err := repo.Save("", "other-cid", 1024, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 
 			_, found, err := repo.Find("fake-cid")
@@ -88,7 +88,7 @@ var _ = Describe("DiskRepo", func() {
 			)
 
 			BeforeEach(func() {
-				record, err := repo.Save("fake-cid", 1024, cloudProperties)
+				record, err := repo.Save("", "fake-cid", 1024, cloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 				recordID = record.ID
 			})
@@ -104,9 +104,25 @@ var _ = Describe("DiskRepo", func() {
 			})
 		})
 
+		Context("when the disk record is a named disk", func() {
+			It("saves the disk record as current disk for its name", func() {
+				record, err := repo.Save("fake-disk-name", "fake-cid", 1024, cloudProperties)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = repo.UpdateCurrent(record.ID)
+				Expect(err).ToNot(HaveOccurred())
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deploymentState.CurrentDiskID).To(BeEmpty())
+				Expect(deploymentState.CurrentDiskIDs).To(Equal(map[string]string{"fake-disk-name": record.ID}))
+			})
+		})
+
 		Context("when a disk record does not exists with the same ID", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-cid", 1024, cloudProperties)
+				_, err := repo.Save("", "fake-cid", 1024, cloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -124,10 +140,10 @@ var _ = Describe("DiskRepo", func() {
 				diskID2 string
 			)
 			BeforeEach(func() {
-				_, err := repo.Save("fake-cid-1", 1024, cloudProperties)
+				_, err := repo.Save("", "fake-cid-1", 1024, cloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 
-				record, err := repo.Save("fake-cid-2", 1024, cloudProperties)
+				record, err := repo.Save("", "fake-cid-2", 1024, cloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 				diskID2 = record.ID
 
@@ -149,7 +165,7 @@ var _ = Describe("DiskRepo", func() {
 
 		Context("when current disk does not exist", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-cid", 1024, cloudProperties)
+				_, err := repo.Save("", "fake-cid", 1024, cloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -169,6 +185,46 @@ var _ = Describe("DiskRepo", func() {
 		})
 	})
 
+	Describe("FindAllCurrent", func() {
+		It("returns current unnamed and named disks", func() {
+			unnamedRecord, err := repo.Save("", "fake-cid-1", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.UpdateCurrent(unnamedRecord.ID)).To(Succeed())
+
+			_, err = repo.Save("fake-disk-name", "fake-cid-2", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			namedRecord, err := repo.Save("fake-disk-name", "fake-cid-3", 2048, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repo.UpdateCurrent(namedRecord.ID)).To(Succeed())
+
+			records, err := repo.FindAllCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]DiskRecord{unnamedRecord, namedRecord}))
+		})
+	})
+
+	Describe("UpdateSize", func() {
+		It("updates size of the disk record", func() {
+			record, err := repo.Save("", "fake-cid", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateSize(record.ID, 2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedRecord, found, err := repo.Find("fake-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(updatedRecord.Size).To(Equal(2048))
+		})
+
+		It("returns an error when disk record does not exist", func() {
+			err := repo.UpdateSize("fake-unknown-id", 2048)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying disk record exists with id 'fake-unknown-id'"))
+		})
+	})
+
 	Describe("All", func() {
 		var (
 			firstDisk  DiskRecord
@@ -177,10 +233,10 @@ var _ = Describe("DiskRepo", func() {
 
 		BeforeEach(func() {
 			var err error
-			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties)
+			firstDisk, err = repo.Save("", "fake-cid-1", 1024, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 
-			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties)
+			secondDisk, err = repo.Save("", "fake-cid-2", 2048, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -203,10 +259,10 @@ var _ = Describe("DiskRepo", func() {
 		BeforeEach(func() {
 			var err error
 
-			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties)
+			firstDisk, err = repo.Save("", "fake-cid-1", 1024, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 
-			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties)
+			secondDisk, err = repo.Save("", "fake-cid-2", 2048, cloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -242,6 +298,21 @@ var _ = Describe("DiskRepo", func() {
 				Expect(found).To(BeFalse())
 			})
 		})
+
+		Context("when the disk to be deleted is a current named disk", func() {
+			It("clears the current named disk", func() {
+				namedDisk, err := repo.Save("fake-disk-name", "fake-cid-3", 1024, cloudProperties)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(repo.UpdateCurrent(namedDisk.ID)).To(Succeed())
+
+				err = repo.Delete(namedDisk)
+				Expect(err).ToNot(HaveOccurred())
+
+				records, err := repo.FindAllCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+		})
 	})
 
 	Describe("ClearCurrent", func() {
@@ -0,0 +1,100 @@
+package fakes
+
+import (
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+type FakeCheckpointRepo struct {
+	FindRecord biconfig.CheckpointRecord
+	FindFound  bool
+	FindErr    error
+
+	StartManifestSHA string
+	StartStemcellCID string
+	StartResumed     bool
+	StartErr         error
+
+	FinishCalled bool
+	FinishErr    error
+
+	DiscardCalled bool
+	DiscardErr    error
+
+	CompletedSteps  []string
+	CompleteStepErr error
+
+	RecordVMCID string
+	RecordVMErr error
+
+	PendingDiskCID        string
+	RecordPendingDiskErr  error
+	ClearPendingDiskCalls int
+	ClearPendingDiskErr   error
+
+	OrphanVMCIDs   []string
+	OrphanDiskCIDs []string
+	FindOrphansErr error
+
+	ForgottenVMCIDs   []string
+	ForgottenDiskCIDs []string
+	ForgetErr         error
+}
+
+func NewFakeCheckpointRepo() *FakeCheckpointRepo {
+	return &FakeCheckpointRepo{}
+}
+
+func (r *FakeCheckpointRepo) Find() (biconfig.CheckpointRecord, bool, error) {
+	return r.FindRecord, r.FindFound, r.FindErr
+}
+
+func (r *FakeCheckpointRepo) Start(manifestSHA, stemcellCID string) (biconfig.CheckpointRecord, bool, error) {
+	r.StartManifestSHA = manifestSHA
+	r.StartStemcellCID = stemcellCID
+	return r.FindRecord, r.StartResumed, r.StartErr
+}
+
+func (r *FakeCheckpointRepo) Finish() error {
+	r.FinishCalled = true
+	return r.FinishErr
+}
+
+func (r *FakeCheckpointRepo) Discard() error {
+	r.DiscardCalled = true
+	return r.DiscardErr
+}
+
+func (r *FakeCheckpointRepo) CompleteStep(step string) error {
+	r.CompletedSteps = append(r.CompletedSteps, step)
+	return r.CompleteStepErr
+}
+
+func (r *FakeCheckpointRepo) RecordVM(cid string) error {
+	r.RecordVMCID = cid
+	return r.RecordVMErr
+}
+
+func (r *FakeCheckpointRepo) RecordPendingDisk(cid string) error {
+	r.PendingDiskCID = cid
+	return r.RecordPendingDiskErr
+}
+
+func (r *FakeCheckpointRepo) ClearPendingDisk() error {
+	r.ClearPendingDiskCalls++
+	r.PendingDiskCID = ""
+	return r.ClearPendingDiskErr
+}
+
+func (r *FakeCheckpointRepo) FindOrphans() ([]string, []string, error) {
+	return r.OrphanVMCIDs, r.OrphanDiskCIDs, r.FindOrphansErr
+}
+
+func (r *FakeCheckpointRepo) ForgetVM(cid string) error {
+	r.ForgottenVMCIDs = append(r.ForgottenVMCIDs, cid)
+	return r.ForgetErr
+}
+
+func (r *FakeCheckpointRepo) ForgetDisk(cid string) error {
+	r.ForgottenDiskCIDs = append(r.ForgottenDiskCIDs, cid)
+	return r.ForgetErr
+}
@@ -11,6 +11,11 @@ type FakeDiskRepo struct {
 
 	findCurrentOutput diskRepoFindCurrentOutput
 
+	findAllCurrentOutput diskRepoAllOutput
+
+	UpdateSizeInputs []DiskRepoUpdateSizeInput
+	UpdateSizeErr    error
+
 	SaveInputs []DiskRepoSaveInput
 	saveOutput diskRepoSaveOutput
 
@@ -32,7 +37,13 @@ type diskRepoFindCurrentOutput struct {
 	err        error
 }
 
+type DiskRepoUpdateSizeInput struct {
+	DiskID string
+	Size   int
+}
+
 type DiskRepoSaveInput struct {
+	Name            string
 	CID             string
 	Size            int
 	CloudProperties biproperty.Map
@@ -61,6 +72,7 @@ type diskRepoAllOutput struct {
 func NewFakeDiskRepo() *FakeDiskRepo {
 	return &FakeDiskRepo{
 		UpdateCurrentInputs: []DiskRepoUpdateCurrentInput{},
+		UpdateSizeInputs:    []DiskRepoUpdateSizeInput{},
 		SaveInputs:          []DiskRepoSaveInput{},
 		DeleteInputs:        []DiskRepoDeleteInput{},
 		findOutput:          map[string]diskRepoFindOutput{},
@@ -78,12 +90,17 @@ func (r *FakeDiskRepo) FindCurrent() (biconfig.DiskRecord, bool, error) {
 	return r.findCurrentOutput.diskRecord, r.findCurrentOutput.found, r.findCurrentOutput.err
 }
 
+func (r *FakeDiskRepo) FindAllCurrent() ([]biconfig.DiskRecord, error) {
+	return r.findAllCurrentOutput.diskRecords, r.findAllCurrentOutput.err
+}
+
 func (r *FakeDiskRepo) ClearCurrent() error {
 	return nil
 }
 
-func (r *FakeDiskRepo) Save(cid string, size int, cloudProperties biproperty.Map) (biconfig.DiskRecord, error) {
+func (r *FakeDiskRepo) Save(name, cid string, size int, cloudProperties biproperty.Map) (biconfig.DiskRecord, error) {
 	r.SaveInputs = append(r.SaveInputs, DiskRepoSaveInput{
+		Name:            name,
 		CID:             cid,
 		Size:            size,
 		CloudProperties: cloudProperties,
@@ -92,6 +109,14 @@ func (r *FakeDiskRepo) Save(cid string, size int, cloudProperties biproperty.Map
 	return r.saveOutput.diskRecord, r.saveOutput.err
 }
 
+func (r *FakeDiskRepo) UpdateSize(diskID string, size int) error {
+	r.UpdateSizeInputs = append(r.UpdateSizeInputs, DiskRepoUpdateSizeInput{
+		DiskID: diskID,
+		Size:   size,
+	})
+	return r.UpdateSizeErr
+}
+
 func (r *FakeDiskRepo) Find(cid string) (biconfig.DiskRecord, bool, error) {
 	return r.findOutput[cid].diskRecord, r.findOutput[cid].found, r.findOutput[cid].err
 }
@@ -120,6 +145,13 @@ func (r *FakeDiskRepo) SetFindCurrentBehavior(diskRecord biconfig.DiskRecord, fo
 	}
 }
 
+func (r *FakeDiskRepo) SetFindAllCurrentBehavior(diskRecords []biconfig.DiskRecord, err error) {
+	r.findAllCurrentOutput = diskRepoAllOutput{
+		diskRecords: diskRecords,
+		err:         err,
+	}
+}
+
 func (r *FakeDiskRepo) SetSaveBehavior(diskRecord biconfig.DiskRecord, found bool, err error) {
 	r.saveOutput = diskRepoSaveOutput{
 		diskRecord: diskRecord,
@@ -121,3 +121,8 @@ func (s *fileSystemDeploymentStateService) Cleanup() error {
 	}
 	return nil
 }
+
+// Lock is a no-op since local state files are not shared between operators
+func (s *fileSystemDeploymentStateService) Lock() error { return nil }
+
+func (s *fileSystemDeploymentStateService) Unlock() error { return nil }
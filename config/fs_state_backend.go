@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type fsStateBackend struct {
+	fs boshsys.FileSystem
+}
+
+func NewFSStateBackend(fs boshsys.FileSystem) StateBackend {
+	return fsStateBackend{fs: fs}
+}
+
+func (b fsStateBackend) Exists(key string) (bool, error) {
+	return b.fs.FileExists(key), nil
+}
+
+func (b fsStateBackend) Get(key string) ([]byte, error) {
+	contents, err := b.fs.ReadFile(key)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading file '%s'", key)
+	}
+
+	return contents, nil
+}
+
+func (b fsStateBackend) Put(key string, contents []byte) error {
+	err := b.fs.WriteFile(key, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing file '%s'", key)
+	}
+
+	return nil
+}
+
+func (b fsStateBackend) Create(key string, contents []byte) (bool, error) {
+	file, err := b.fs.OpenFile(key, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, bosherr.WrapErrorf(err, "Creating file '%s'", key)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(contents)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Writing file '%s'", key)
+	}
+
+	return true, nil
+}
+
+func (b fsStateBackend) Delete(key string) error {
+	err := b.fs.RemoveAll(key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting file '%s'", key)
+	}
+
+	return nil
+}
+
+func (b fsStateBackend) List(prefix string) ([]string, error) {
+	var keys []string
+
+	dir := filepath.Clean(prefix)
+
+	err := b.fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !info.IsDir() && filepath.Dir(path) == dir {
+			keys = append(keys, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing directory '%s'", prefix)
+	}
+
+	return keys, nil
+}
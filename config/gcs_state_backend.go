@@ -0,0 +1,151 @@
+package config
+
+import (
+	gobytes "bytes"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+)
+
+type gcsStateBackend struct {
+	options map[string]interface{}
+}
+
+// NewGCSStateBackend configures gcscli client from URL query
+// (e.g. gcs://bucket/key?storage_class=REGIONAL).
+// Application Default Credentials are used unless specified.
+func NewGCSStateBackend(bucketName string, query url.Values) StateBackend {
+	options := map[string]interface{}{"bucket_name": bucketName}
+
+	for key := range query {
+		options[key] = query.Get(key)
+	}
+
+	return gcsStateBackend{options: options}
+}
+
+func (b gcsStateBackend) Exists(key string) (bool, error) {
+	client, err := boshreldir.NewGCSClient(b.options)
+	if err != nil {
+		return false, err
+	}
+
+	return client.Exists(key)
+}
+
+func (b gcsStateBackend) Get(key string) ([]byte, error) {
+	client, err := boshreldir.NewGCSClient(b.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf gobytes.Buffer
+
+	err = client.Get(key, &buf)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Downloading '%s' from GCS", key)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b gcsStateBackend) Put(key string, contents []byte) error {
+	client, err := boshreldir.NewGCSClient(b.options)
+	if err != nil {
+		return err
+	}
+
+	err = client.Put(gobytes.NewReader(contents), key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Uploading '%s' to GCS", key)
+	}
+
+	return nil
+}
+
+// Create relies on 'ifGenerationMatch=0' precondition
+func (b gcsStateBackend) Create(key string, contents []byte) (bool, error) {
+	ctx, gcsSDK, conf, err := boshreldir.NewGCSSDK(b.options)
+	if err != nil {
+		return false, err
+	}
+
+	obj := gcsSDK.Bucket(conf.BucketName).Object(key)
+
+	writer := obj.If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+
+	if len(conf.StorageClass) > 0 {
+		writer.StorageClass = conf.StorageClass
+	}
+
+	_, err = writer.Write(contents)
+	if err == nil {
+		err = writer.Close()
+	} else {
+		_ = writer.Close()
+	}
+
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusPreconditionFailed {
+			return false, nil
+		}
+
+		return false, bosherr.WrapErrorf(err, "Conditionally uploading '%s' to GCS", key)
+	}
+
+	return true, nil
+}
+
+func (b gcsStateBackend) Delete(key string) error {
+	client, err := boshreldir.NewGCSClient(b.options)
+	if err != nil {
+		return err
+	}
+
+	err = client.Delete(key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting '%s' from GCS", key)
+	}
+
+	return nil
+}
+
+func (b gcsStateBackend) List(prefix string) ([]string, error) {
+	ctx, gcsSDK, conf, err := boshreldir.NewGCSSDK(b.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+
+	query := &storage.Query{Delimiter: "/"}
+
+	if len(prefix) > 0 {
+		query.Prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+
+	objects := gcsSDK.Bucket(conf.BucketName).Objects(ctx, query)
+
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listing '%s' in GCS", prefix)
+		}
+
+		// Synthetic directory entries only have a prefix
+		if len(attrs.Name) > 0 {
+			keys = append(keys, attrs.Name)
+		}
+	}
+
+	return keys, nil
+}
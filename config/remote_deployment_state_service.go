@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+type remoteDeploymentStateService struct {
+	backend       StateBackend
+	uuidGenerator boshuuid.Generator
+	logger        boshlog.Logger
+	logTag        string
+
+	path string
+	key  string
+
+	lockID string
+}
+
+type deploymentStateLock struct {
+	ID        string    `json:"id"`
+	Hostname  string    `json:"hostname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewRemoteDeploymentStateService(
+	backend StateBackend,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+	path string,
+	key string,
+) DeploymentStateService {
+	return &remoteDeploymentStateService{
+		backend:       backend,
+		uuidGenerator: uuidGenerator,
+		logger:        logger,
+		logTag:        "config",
+
+		path: path,
+		key:  key,
+	}
+}
+
+func (s *remoteDeploymentStateService) Path() string {
+	return s.path
+}
+
+func (s *remoteDeploymentStateService) Exists() bool {
+	exists, err := s.backend.Exists(s.key)
+	if err != nil {
+		// Assume state exists so that Load reports an error instead of
+		// callers starting from scratch and forking deployment state
+		s.logger.Error(s.logTag, "Checking if deployment state '%s' exists: %s", s.path, err)
+		return true
+	}
+
+	return exists
+}
+
+func (s *remoteDeploymentStateService) Load() (DeploymentState, error) {
+	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.path)
+
+	deploymentState := &DeploymentState{}
+
+	exists, err := s.backend.Exists(s.key)
+	if err != nil {
+		return DeploymentState{}, bosherr.WrapErrorf(err, "Checking deployment state '%s'", s.path)
+	}
+
+	if exists {
+		contents, err := s.backend.Get(s.key)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapErrorf(err, "Reading deployment state '%s'", s.path)
+		}
+
+		err = json.Unmarshal(contents, deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state '%s'", s.path)
+		}
+	}
+
+	if deploymentState.DirectorID == "" {
+		uuid, err := s.uuidGenerator.Generate()
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Generating DirectorID")
+		}
+
+		deploymentState.DirectorID = uuid
+
+		err = s.Save(*deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Saving deployment state")
+		}
+	}
+
+	return *deploymentState, nil
+}
+
+func (s *remoteDeploymentStateService) Save(deploymentState DeploymentState) error {
+	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
+
+	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
+	}
+
+	err = s.backend.Put(s.key, jsonContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state '%s'", s.path)
+	}
+
+	return nil
+}
+
+func (s *remoteDeploymentStateService) Cleanup() error {
+	err := s.backend.Delete(s.key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Could not delete deployment state %s", s.path)
+	}
+
+	return nil
+}
+
+// Lock creates a lock object next to the state using backend's
+// conditional create so that only one of concurrent callers succeeds
+func (s *remoteDeploymentStateService) Lock() error {
+	lockKey := s.lockKey()
+
+	id, err := s.uuidGenerator.Generate()
+	if err != nil {
+		return bosherr.WrapError(err, "Generating lock ID")
+	}
+
+	hostname, _ := os.Hostname()
+
+	lock := deploymentStateLock{ID: id, Hostname: hostname, CreatedAt: time.Now().UTC()}
+
+	lockBytes, err := json.Marshal(lock)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state lock")
+	}
+
+	s.logger.Debug(s.logTag, "Acquiring deployment state lock '%s' with ID '%s'", lockKey, id)
+
+	created, err := s.backend.Create(lockKey, lockBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating deployment state lock '%s'", lockKey)
+	}
+
+	if !created {
+		existingLock, found, err := s.readLock()
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			// Lock was released between create and read
+			return bosherr.Errorf("Deployment state '%s' was concurrently locked and unlocked; retry", s.path)
+		}
+
+		return s.lockedErr(existingLock)
+	}
+
+	s.lockID = id
+
+	return nil
+}
+
+func (s *remoteDeploymentStateService) Unlock() error {
+	if len(s.lockID) == 0 {
+		return nil
+	}
+
+	lockKey := s.lockKey()
+
+	existingLock, found, err := s.readLock()
+	if err != nil {
+		return err
+	}
+
+	if found && existingLock.ID != s.lockID {
+		return bosherr.Errorf("Expected deployment state lock '%s' to have ID '%s' but was '%s'", lockKey, s.lockID, existingLock.ID)
+	}
+
+	s.logger.Debug(s.logTag, "Releasing deployment state lock '%s' with ID '%s'", lockKey, s.lockID)
+
+	err = s.backend.Delete(lockKey)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting deployment state lock '%s'", lockKey)
+	}
+
+	s.lockID = ""
+
+	return nil
+}
+
+func (s *remoteDeploymentStateService) readLock() (deploymentStateLock, bool, error) {
+	var lock deploymentStateLock
+
+	lockKey := s.lockKey()
+
+	exists, err := s.backend.Exists(lockKey)
+	if err != nil {
+		return lock, false, bosherr.WrapErrorf(err, "Checking deployment state lock '%s'", lockKey)
+	}
+
+	if !exists {
+		return lock, false, nil
+	}
+
+	lockBytes, err := s.backend.Get(lockKey)
+	if err != nil {
+		return lock, false, bosherr.WrapErrorf(err, "Reading deployment state lock '%s'", lockKey)
+	}
+
+	err = json.Unmarshal(lockBytes, &lock)
+	if err != nil {
+		return lock, false, bosherr.WrapErrorf(err, "Unmarshalling deployment state lock '%s'", lockKey)
+	}
+
+	return lock, true, nil
+}
+
+func (s *remoteDeploymentStateService) lockedErr(lock deploymentStateLock) error {
+	return bosherr.Errorf(
+		"Deployment state '%s' is locked by '%s' since %s (lock ID '%s'). "+
+			"If no other operation is in progress, remove lock '%s' and retry",
+		s.path, lock.Hostname, lock.CreatedAt.Format(time.RFC3339), lock.ID, s.lockKey())
+}
+
+func (s *remoteDeploymentStateService) lockKey() string {
+	return s.key + ".lock"
+}
@@ -0,0 +1,262 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"errors"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+type errStateBackend struct {
+	StateBackend
+	existsErr error
+}
+
+func (b errStateBackend) Exists(key string) (bool, error) {
+	if b.existsErr != nil {
+		return false, b.existsErr
+	}
+	return b.StateBackend.Exists(key)
+}
+
+var _ = Describe("remoteDeploymentStateService", func() {
+	var (
+		service           DeploymentStateService
+		fakeFs            *fakesys.FakeFileSystem
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		logger            boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fakeFs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		service = NewRemoteDeploymentStateService(
+			NewFSStateBackend(fakeFs), fakeUUIDGenerator, logger, "file:///state.json", "/state.json")
+	})
+
+	Describe("NewDeploymentStateService", func() {
+		It("returns remote service for file://, s3:// and gcs:// URLs", func() {
+			service := NewDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "file:///some/state.json")
+			Expect(service.Path()).To(Equal("file:///some/state.json"))
+
+			fakeFs.WriteFileString("/some/state.json", `{"director_id":"id"}`)
+			Expect(service.Exists()).To(BeTrue())
+
+			service = NewDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "s3://bucket/state.json?region=us-west-1")
+			Expect(service.Path()).To(Equal("s3://bucket/state.json"))
+
+			service = NewDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "gcs://bucket/state.json")
+			Expect(service.Path()).To(Equal("gcs://bucket/state.json"))
+		})
+
+		It("does not include URL query with credentials in path and errors", func() {
+			service := NewDeploymentStateService(fakeFs, fakeUUIDGenerator, logger,
+				"file:///some/state.json?access_key_id=fake-key-id&secret_access_key=fake-secret")
+			Expect(service.Path()).To(Equal("file:///some/state.json"))
+
+			fakeFs.WriteFileString("/some/state.json", "invalid-json")
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling deployment state 'file:///some/state.json'"))
+			Expect(err.Error()).ToNot(ContainSubstring("fake-secret"))
+		})
+
+		It("returns file system service for local paths", func() {
+			service := NewDeploymentStateService(fakeFs, fakeUUIDGenerator, logger, "/some/state.json")
+			Expect(service.Path()).To(Equal("/some/state.json"))
+
+			Expect(service.Lock()).ToNot(HaveOccurred())
+			Expect(fakeFs.FileExists("/some/state.json.lock")).To(BeFalse())
+		})
+	})
+
+	Describe("Exists", func() {
+		It("returns whether state exists in the backend", func() {
+			Expect(service.Exists()).To(BeFalse())
+
+			fakeFs.WriteFileString("/state.json", "{}")
+			Expect(service.Exists()).To(BeTrue())
+		})
+
+		It("returns true if backend cannot be checked so that loading reports an error", func() {
+			backend := errStateBackend{StateBackend: NewFSStateBackend(fakeFs), existsErr: errors.New("fake-err")}
+			service = NewRemoteDeploymentStateService(backend, fakeUUIDGenerator, logger, "s3://bucket/state.json", "state.json")
+
+			Expect(service.Exists()).To(BeTrue())
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("Load and Save", func() {
+		It("loads saved state", func() {
+			state := DeploymentState{DirectorID: "fake-director-id", CurrentVMCID: "fake-vm-cid"}
+
+			err := service.Save(state)
+			Expect(err).ToNot(HaveOccurred())
+
+			loadedState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loadedState).To(Equal(state))
+		})
+
+		It("generates and saves director ID when state does not exist", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-uuid"
+
+			state, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.DirectorID).To(Equal("fake-uuid"))
+
+			var savedState DeploymentState
+			contents, err := fakeFs.ReadFile("/state.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(json.Unmarshal(contents, &savedState)).To(Succeed())
+			Expect(savedState.DirectorID).To(Equal("fake-uuid"))
+		})
+
+		It("returns error if state cannot be unmarshalled", func() {
+			fakeFs.WriteFileString("/state.json", "-")
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling deployment state 'file:///state.json'"))
+		})
+
+		It("returns error if state cannot be written", func() {
+			fakeFs.WriteFileError = errors.New("fake-err")
+
+			err := service.Save(DeploymentState{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("Cleanup", func() {
+		It("deletes state", func() {
+			fakeFs.WriteFileString("/state.json", "{}")
+
+			err := service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeFs.FileExists("/state.json")).To(BeFalse())
+		})
+	})
+
+	Describe("Lock and Unlock", func() {
+		var (
+			fs     boshsys.FileSystem
+			tmpDir string
+		)
+
+		// Real file system is used since locking relies on O_EXCL
+		BeforeEach(func() {
+			fs = boshsys.NewOsFileSystem(logger)
+
+			var err error
+			tmpDir, err = fs.TempDir("remote-deployment-state-service-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			statePath := filepath.Join(tmpDir, "state.json")
+
+			service = NewRemoteDeploymentStateService(
+				NewFSStateBackend(fs), fakeUUIDGenerator, logger, "file://"+statePath, statePath)
+		})
+
+		AfterEach(func() {
+			Expect(fs.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		lockPath := func() string { return filepath.Join(tmpDir, "state.json.lock") }
+
+		It("creates lock object and removes it on unlock", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-lock-id"
+
+			err := service.Lock()
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := fs.ReadFileString(lockPath())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(ContainSubstring(`"id":"fake-lock-id"`))
+
+			err = service.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(lockPath())).To(BeFalse())
+		})
+
+		It("returns error if state is locked by someone else", func() {
+			fs.WriteFileString(lockPath(), `{"id":"other-id","hostname":"other-host","created_at":"2017-01-01T00:00:00Z"}`)
+
+			err := service.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is locked by 'other-host' since 2017-01-01T00:00:00Z (lock ID 'other-id')"))
+			Expect(err.Error()).To(ContainSubstring("remove lock '" + lockPath() + "'"))
+
+			err = service.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(lockPath())).To(BeTrue())
+		})
+
+		It("prevents second service from acquiring lock", func() {
+			statePath := filepath.Join(tmpDir, "state.json")
+			otherService := NewRemoteDeploymentStateService(
+				NewFSStateBackend(fs), fakeUUIDGenerator, logger, "file://"+statePath, statePath)
+
+			Expect(service.Lock()).ToNot(HaveOccurred())
+			Expect(otherService.Lock()).To(HaveOccurred())
+
+			Expect(service.Unlock()).ToNot(HaveOccurred())
+			Expect(otherService.Lock()).ToNot(HaveOccurred())
+		})
+
+		It("allows only one of concurrent callers to acquire lock", func() {
+			statePath := filepath.Join(tmpDir, "state.json")
+
+			results := make(chan error, 10)
+
+			for i := 0; i < 10; i++ {
+				go func() {
+					defer GinkgoRecover()
+
+					otherService := NewRemoteDeploymentStateService(
+						NewFSStateBackend(fs), fakeuuid.NewFakeGenerator(), logger, "file://"+statePath, statePath)
+
+					results <- otherService.Lock()
+				}()
+			}
+
+			var acquired int
+
+			for i := 0; i < 10; i++ {
+				if err := <-results; err == nil {
+					acquired++
+				}
+			}
+
+			Expect(acquired).To(Equal(1))
+		})
+
+		It("does not remove lock taken over by someone else", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-lock-id"
+
+			Expect(service.Lock()).ToNot(HaveOccurred())
+
+			fs.WriteFileString(lockPath(), `{"id":"other-id"}`)
+
+			err := service.Unlock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected deployment state lock '" + lockPath() + "' to have ID 'fake-lock-id' but was 'other-id'"))
+			Expect(fs.FileExists(lockPath())).To(BeTrue())
+		})
+	})
+})
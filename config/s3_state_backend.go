@@ -0,0 +1,205 @@
+package config
+
+import (
+	gobytes "bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
+)
+
+type s3StateBackend struct {
+	options map[string]interface{}
+}
+
+// NewS3StateBackend configures s3cli client from URL query
+// (e.g. s3://bucket/key?region=us-west-1&credentials_source=static&access_key_id=...).
+// Credentials are taken from the environment or AWS profile unless specified.
+func NewS3StateBackend(bucketName string, query url.Values) StateBackend {
+	options := map[string]interface{}{"bucket_name": bucketName}
+
+	for key := range query {
+		value := query.Get(key)
+
+		switch key {
+		case "port":
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				options[key] = value
+			} else {
+				options[key] = port
+			}
+
+		case "use_ssl", "ssl_verify_peer":
+			options[key] = value == "true"
+
+		default:
+			options[key] = value
+		}
+	}
+
+	_, hasAccessKey := options["access_key_id"]
+	_, hasCredsSource := options["credentials_source"]
+
+	if !hasAccessKey && !hasCredsSource {
+		options["credentials_source"] = "env_or_profile"
+	}
+
+	return s3StateBackend{options: options}
+}
+
+func (b s3StateBackend) Exists(key string) (bool, error) {
+	client, err := boshreldir.NewS3Client(b.options)
+	if err != nil {
+		return false, err
+	}
+
+	return client.Exists(key)
+}
+
+func (b s3StateBackend) Get(key string) ([]byte, error) {
+	client, err := boshreldir.NewS3Client(b.options)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := aws.NewWriteAtBuffer([]byte{})
+
+	err = client.Get(key, buf)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Downloading '%s' from S3", key)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b s3StateBackend) Put(key string, contents []byte) error {
+	client, err := boshreldir.NewS3Client(b.options)
+	if err != nil {
+		return err
+	}
+
+	err = client.Put(gobytes.NewReader(contents), key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Uploading '%s' to S3", key)
+	}
+
+	return nil
+}
+
+// Create relies on 'If-None-Match: *' conditional writes. Since some S3 compatible
+// endpoints ignore the condition, it's verified by repeating the write which must fail.
+func (b s3StateBackend) Create(key string, contents []byte) (bool, error) {
+	created, err := b.putIfNoneMatch(key, contents)
+	if err != nil || !created {
+		return false, err
+	}
+
+	repeated, err := b.putIfNoneMatch(key, contents)
+	if err != nil {
+		return false, err
+	}
+
+	if repeated {
+		return false, bosherr.Errorf(
+			"Expected S3 endpoint to reject conditional write of existing '%s'; "+
+				"endpoint does not support conditional writes required for locking", key)
+	}
+
+	return true, nil
+}
+
+func (b s3StateBackend) putIfNoneMatch(key string, contents []byte) (bool, error) {
+	s3SDK, conf, err := boshreldir.NewS3SDK(b.options)
+	if err != nil {
+		return false, err
+	}
+
+	input := &s3.PutObjectInput{
+		Body:   gobytes.NewReader(contents),
+		Bucket: aws.String(conf.BucketName),
+		Key:    aws.String(key),
+	}
+
+	if conf.ServerSideEncryption != "" {
+		input.ServerSideEncryption = aws.String(conf.ServerSideEncryption)
+	}
+
+	if conf.SSEKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(conf.SSEKMSKeyID)
+	}
+
+	req, _ := s3SDK.PutObjectRequest(input)
+
+	// Vendored SDK does not have PutObjectInput.IfNoneMatch
+	req.HTTPRequest.Header.Set("If-None-Match", "*")
+
+	err = req.Send()
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok {
+			switch reqErr.StatusCode() {
+			case http.StatusPreconditionFailed, http.StatusConflict:
+				return false, nil
+
+			case http.StatusNotImplemented:
+				return false, bosherr.WrapErrorf(err,
+					"S3 endpoint does not support conditional writes required for locking '%s'", key)
+			}
+		}
+
+		return false, bosherr.WrapErrorf(err, "Conditionally uploading '%s' to S3", key)
+	}
+
+	return true, nil
+}
+
+func (b s3StateBackend) Delete(key string) error {
+	client, err := boshreldir.NewS3Client(b.options)
+	if err != nil {
+		return err
+	}
+
+	err = client.Delete(key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting '%s' from S3", key)
+	}
+
+	return nil
+}
+
+func (b s3StateBackend) List(prefix string) ([]string, error) {
+	s3SDK, conf, err := boshreldir.NewS3SDK(b.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+
+	input := &s3.ListObjectsInput{
+		Bucket:    aws.String(conf.BucketName),
+		Delimiter: aws.String("/"),
+	}
+
+	if len(prefix) > 0 {
+		input.Prefix = aws.String(strings.TrimSuffix(prefix, "/") + "/")
+	}
+
+	err = s3SDK.ListObjectsPages(input, func(output *s3.ListObjectsOutput, _ bool) bool {
+		for _, object := range output.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing '%s' in S3", prefix)
+	}
+
+	return keys, nil
+}
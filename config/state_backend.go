@@ -0,0 +1,76 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// StateBackend stores deployment state (and its lock) outside of local file system
+type StateBackend interface {
+	Exists(key string) (bool, error)
+	Get(key string) ([]byte, error)
+	Put(key string, contents []byte) error
+	Delete(key string) error
+
+	// List returns keys of objects located directly under prefix (as if it was a directory)
+	List(prefix string) ([]string, error)
+
+	// Create atomically writes key only if it does not exist yet;
+	// false is returned if key already exists
+	Create(key string, contents []byte) (bool, error)
+}
+
+// NewStateBackendFromURL returns backend and key within it for s3://bucket/key,
+// gcs://bucket/key and file:///path URLs; false is returned for other locations
+func NewStateBackendFromURL(fs boshsys.FileSystem, location string) (StateBackend, string, bool) {
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return nil, "", false
+	}
+
+	key := strings.TrimPrefix(locationURL.Path, "/")
+
+	switch locationURL.Scheme {
+	case "s3":
+		return NewS3StateBackend(locationURL.Host, locationURL.Query()), key, true
+
+	case "gcs":
+		return NewGCSStateBackend(locationURL.Host, locationURL.Query()), key, true
+
+	case "file":
+		return NewFSStateBackend(fs), locationURL.Path, true
+
+	default:
+		return nil, "", false
+	}
+}
+
+// NewDeploymentStateService returns deployment state service appropriate for the given path.
+// Paths such as s3://bucket/key, gcs://bucket/key and file:///path/to/state.json
+// are stored via remote backends with locking; other paths are treated as local files.
+func NewDeploymentStateService(fs boshsys.FileSystem, uuidGenerator boshuuid.Generator, logger boshlog.Logger, deploymentStatePath string) DeploymentStateService {
+	backend, key, found := NewStateBackendFromURL(fs, deploymentStatePath)
+	if !found {
+		return NewFileSystemDeploymentStateService(fs, uuidGenerator, logger, deploymentStatePath)
+	}
+
+	return NewRemoteDeploymentStateService(backend, uuidGenerator, logger, redactedStateURL(deploymentStatePath), key)
+}
+
+// redactedStateURL drops URL query since it may contain credentials (e.g. secret_access_key)
+// and state path is shown to the user and included in errors
+func redactedStateURL(location string) string {
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	locationURL.RawQuery = ""
+	locationURL.User = nil
+
+	return locationURL.String()
+}
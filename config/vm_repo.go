@@ -55,6 +55,11 @@ func (r vMRepo) ClearCurrent() error {
 		return bosherr.WrapError(err, "Loading existing config")
 	}
 
+	// VM is cleared once deleted hence it's no longer an orphan candidate
+	if deploymentState.Checkpoint != nil {
+		deploymentState.Checkpoint.CreatedVMCIDs = removeCID(deploymentState.Checkpoint.CreatedVMCIDs, deploymentState.CurrentVMCID)
+	}
+
 	deploymentState.CurrentVMCID = ""
 
 	err = r.deploymentStateService.Save(deploymentState)
@@ -0,0 +1,539 @@
+package local
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// Agent answers agent requests and serves blobstore on mbus
+// on behalf of the VM most recently created by local CPI
+type Agent struct {
+	store         Store
+	blobsDir      string
+	mbusURL       string
+	fs            boshsys.FileSystem
+	uuidGenerator boshuuid.Generator
+
+	listener net.Listener
+
+	tasks     map[string]agentResponse
+	tasksLock sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+type agentRequest struct {
+	Method    string        `json:"method"`
+	Arguments []interface{} `json:"arguments"`
+}
+
+type agentResponse struct {
+	Value     interface{}     `json:"value,omitempty"`
+	Exception *agentException `json:"exception,omitempty"`
+}
+
+type agentException struct {
+	Message string `json:"message"`
+}
+
+func NewAgent(
+	store Store,
+	blobsDir string,
+	mbusURL string,
+	fs boshsys.FileSystem,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+) *Agent {
+	return &Agent{
+		store:         store,
+		blobsDir:      blobsDir,
+		mbusURL:       mbusURL,
+		fs:            fs,
+		uuidGenerator: uuidGenerator,
+		tasks:         map[string]agentResponse{},
+		logger:        logger,
+		logTag:        "localAgent",
+	}
+}
+
+// Start listens on mbus unless agent is already started
+func (a *Agent) Start() error {
+	if a.listener != nil {
+		return nil
+	}
+
+	mbusURL, err := url.Parse(a.mbusURL)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing mbus URL")
+	}
+
+	if mbusURL.User == nil {
+		return bosherr.Error("Expected mbus URL to include credentials")
+	}
+
+	listener, err := net.Listen("tcp", mbusURL.Host)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on '%s'", mbusURL.Host)
+	}
+
+	if mbusURL.Scheme == "https" {
+		cert, err := a.generateCertificate(mbusURL.Hostname())
+		if err != nil {
+			_ = listener.Close()
+			return err
+		}
+
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}})
+	}
+
+	a.listener = listener
+
+	a.logger.Debug(a.logTag, "Starting agent at '%s'", mbusURL.Host)
+
+	go func() {
+		err := http.Serve(listener, a)
+		a.logger.Debug(a.logTag, "Agent stopped serving: %s", err)
+	}()
+
+	return nil
+}
+
+func (a *Agent) Stop() error {
+	if a.listener == nil {
+		return nil
+	}
+
+	err := a.listener.Close()
+	a.listener = nil
+
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping agent")
+	}
+
+	return nil
+}
+
+func (a *Agent) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	a.logger.Debug(a.logTag, "Received %s %s", req.Method, req.URL.Path)
+
+	if !a.isAuthorized(req) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case req.URL.Path == "/agent" && req.Method == "POST":
+		a.handleAgentRequest(w, req)
+	case path.Dir(path.Dir(path.Clean(req.URL.Path))) == "/blobs":
+		blobID := path.Base(path.Clean(req.URL.Path))
+		if !isValidBlobID(blobID) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		a.handleBlobRequest(blobID, w, req)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (a *Agent) isAuthorized(req *http.Request) bool {
+	mbusURL, err := url.Parse(a.mbusURL)
+	if err != nil || mbusURL.User == nil {
+		return false
+	}
+
+	password, _ := mbusURL.User.Password()
+
+	reqUsername, reqPassword, found := req.BasicAuth()
+	if !found {
+		return false
+	}
+
+	usernameMatches := subtle.ConstantTimeCompare([]byte(reqUsername), []byte(mbusURL.User.Username())) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(reqPassword), []byte(password)) == 1
+
+	return usernameMatches && passwordMatches
+}
+
+// isValidBlobID makes sure blob ID names a file directly inside blobs directory
+func isValidBlobID(blobID string) bool {
+	if blobID == "" || blobID == "." || blobID == ".." {
+		return false
+	}
+
+	return !strings.ContainsAny(blobID, `/\`)
+}
+
+func (a *Agent) handleAgentRequest(w http.ResponseWriter, req *http.Request) {
+	var request agentRequest
+
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	st, err := a.store.load()
+	if err != nil || st.AgentVMCID == "" {
+		// Agent on a missing VM is as good as unreachable
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	response := a.dispatch(st.AgentVMCID, request)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		a.logger.Warn(a.logTag, "Writing agent response: %s", err)
+	}
+}
+
+func (a *Agent) dispatch(vmCID string, request agentRequest) agentResponse {
+	args := arguments{method: request.Method, args: request.Arguments}
+
+	switch request.Method {
+	case "ping":
+		return agentResponse{Value: "pong"}
+
+	case "get_state":
+		return a.respond(a.getState(vmCID))
+
+	case "list_disk":
+		return a.respond(a.listDisk(vmCID))
+
+	case "start":
+		return a.respond("started", a.setJobState(vmCID, "running"))
+
+	case "sync_dns":
+		return agentResponse{Value: "synced"}
+
+	case "delete_arp_entries":
+		return agentResponse{Value: map[string]interface{}{}}
+
+	case "get_task":
+		return a.getTask(args.String(0), args.Err())
+
+	case "stop":
+		return a.startTask("stopped", a.setJobState(vmCID, "stopped"))
+
+	case "apply":
+		return a.startTask("applied", a.apply(vmCID, request.Arguments))
+
+	case "mount_disk":
+		return a.startTask(map[string]interface{}{}, a.mountDisk(vmCID, args.String(0), args.Err()))
+
+	case "unmount_disk":
+		return a.startTask(map[string]interface{}{}, a.unmountDisk(vmCID, args.String(0), args.Err()))
+
+	case "migrate_disk", "run_script":
+		return a.startTask(map[string]interface{}{}, nil)
+
+	case "compile_package":
+		return a.startTask(a.compilePackage(args.String(0), args.Err()))
+
+	default:
+		return agentResponse{Exception: &agentException{Message: fmt.Sprintf("unknown message %s", request.Method)}}
+	}
+}
+
+func (a *Agent) respond(value interface{}, err error) agentResponse {
+	if err != nil {
+		return agentResponse{Exception: &agentException{Message: err.Error()}}
+	}
+
+	return agentResponse{Value: value}
+}
+
+// startTask completes the task right away and keeps its result
+// for subsequent get_task requests
+func (a *Agent) startTask(value interface{}, err error) agentResponse {
+	if err != nil {
+		return agentResponse{Exception: &agentException{Message: err.Error()}}
+	}
+
+	taskID, err := a.uuidGenerator.Generate()
+	if err != nil {
+		return agentResponse{Exception: &agentException{Message: err.Error()}}
+	}
+
+	a.tasksLock.Lock()
+	a.tasks[taskID] = agentResponse{Value: value}
+	a.tasksLock.Unlock()
+
+	return agentResponse{Value: map[string]interface{}{"agent_task_id": taskID, "state": "running"}}
+}
+
+func (a *Agent) getTask(taskID string, err error) agentResponse {
+	if err != nil {
+		return agentResponse{Exception: &agentException{Message: err.Error()}}
+	}
+
+	a.tasksLock.Lock()
+	defer a.tasksLock.Unlock()
+
+	response, found := a.tasks[taskID]
+	if !found {
+		return agentResponse{Exception: &agentException{Message: fmt.Sprintf("Task with id %s could not be found", taskID)}}
+	}
+
+	return response
+}
+
+func (a *Agent) getState(vmCID string) (interface{}, error) {
+	st, err := a.store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	vm := st.VMs[vmCID]
+
+	mbusURL, err := url.Parse(a.mbusURL)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := map[string]interface{}{}
+
+	for name, network := range vm.Networks {
+		networkMap, _ := network.(map[string]interface{})
+
+		// dynamic networks get the address agent is reachable at
+		ip, _ := networkMap["ip"].(string)
+		if ip == "" {
+			ip = mbusURL.Hostname()
+		}
+
+		networks[name] = map[string]interface{}{"ip": ip}
+	}
+
+	return map[string]interface{}{
+		"agent_id":  vm.AgentID,
+		"vm":        map[string]interface{}{"name": vm.CID},
+		"job_state": vm.Agent.JobState,
+		"networks":  networks,
+	}, nil
+}
+
+func (a *Agent) listDisk(vmCID string) (interface{}, error) {
+	st, err := a.store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return st.VMs[vmCID].Agent.MountedDiskCIDs, nil
+}
+
+func (a *Agent) setJobState(vmCID, jobState string) error {
+	return a.updateVM(vmCID, func(vm *vmRecord) error {
+		vm.Agent.JobState = jobState
+		return nil
+	})
+}
+
+func (a *Agent) apply(vmCID string, args []interface{}) error {
+	if len(args) != 1 {
+		return bosherr.Error("Expected apply spec argument")
+	}
+
+	return a.updateVM(vmCID, func(vm *vmRecord) error {
+		vm.Agent.Spec = args[0]
+		return nil
+	})
+}
+
+func (a *Agent) mountDisk(vmCID, diskCID string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return a.store.update(func(st *state) error {
+		disk, found := st.Disks[diskCID]
+		if !found || disk.VMCID != vmCID {
+			return bosherr.Errorf("Persistent disk with volume id '%s' could not be found", diskCID)
+		}
+
+		vm := st.VMs[vmCID]
+		vm.Agent.MountedDiskCIDs = append(removeString(vm.Agent.MountedDiskCIDs, diskCID), diskCID)
+		st.VMs[vmCID] = vm
+
+		return nil
+	})
+}
+
+func (a *Agent) unmountDisk(vmCID, diskCID string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return a.updateVM(vmCID, func(vm *vmRecord) error {
+		vm.Agent.MountedDiskCIDs = removeString(vm.Agent.MountedDiskCIDs, diskCID)
+		return nil
+	})
+}
+
+// compilePackage stores source package as compiled package
+// since nothing is going to run it
+func (a *Agent) compilePackage(blobID string, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if !isValidBlobID(blobID) {
+		return nil, bosherr.Errorf("Invalid source package blob ID '%s'", blobID)
+	}
+
+	compiledBlobID, err := a.uuidGenerator.Generate()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating compiled package blob ID")
+	}
+
+	compiledBlobPath := filepath.Join(a.blobsDir, compiledBlobID)
+
+	err = a.fs.CopyFile(filepath.Join(a.blobsDir, blobID), compiledBlobPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Copying source package blob '%s'", blobID)
+	}
+
+	contents, err := a.fs.ReadFile(compiledBlobPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading compiled package blob '%s'", compiledBlobID)
+	}
+
+	return map[string]interface{}{
+		"result": map[string]interface{}{
+			"blobstore_id": compiledBlobID,
+			"sha1":         fmt.Sprintf("%x", sha1.Sum(contents)),
+		},
+	}, nil
+}
+
+func (a *Agent) updateVM(vmCID string, updateFunc func(*vmRecord) error) error {
+	return a.store.update(func(st *state) error {
+		vm := st.VMs[vmCID]
+
+		err := updateFunc(&vm)
+		if err != nil {
+			return err
+		}
+
+		st.VMs[vmCID] = vm
+
+		return nil
+	})
+}
+
+func (a *Agent) handleBlobRequest(blobID string, w http.ResponseWriter, req *http.Request) {
+	blobPath := filepath.Join(a.blobsDir, blobID)
+
+	switch req.Method {
+	case "GET":
+		file, err := a.fs.OpenFile(blobPath, os.O_RDONLY, 0)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		defer file.Close()
+
+		_, err = io.Copy(w, file)
+		if err != nil {
+			a.logger.Warn(a.logTag, "Writing blob '%s': %s", blobID, err)
+		}
+
+	case "HEAD":
+		if !a.fs.FileExists(blobPath) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	case "PUT":
+		err := a.fs.MkdirAll(a.blobsDir, os.ModePerm)
+		if err == nil {
+			var file boshsys.File
+
+			file, err = a.fs.OpenFile(blobPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+			if err == nil {
+				_, err = io.Copy(file, req.Body)
+				_ = file.Close()
+			}
+		}
+
+		if err != nil {
+			a.logger.Error(a.logTag, "Saving blob '%s': %s", blobID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+	case "DELETE":
+		err := a.fs.RemoveAll(blobPath)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// generateCertificate creates self-signed certificate since
+// agent client does not verify mbus certificate unless CA is configured
+func (a *Agent) generateCertificate(host string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, bosherr.WrapError(err, "Generating agent key")
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, bosherr.WrapError(err, "Generating agent certificate")
+	}
+
+	return tls.Certificate{Certificate: [][]byte{certDER}, PrivateKey: key}, nil
+}
@@ -0,0 +1,526 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+const cloudErrorType = "Bosh::Clouds::CloudError"
+
+type cpiError struct {
+	errType string
+	message string
+}
+
+func (e cpiError) Error() string {
+	return e.message
+}
+
+func newCPIError(errType string, msg string, args ...interface{}) cpiError {
+	return cpiError{errType: errType, message: fmt.Sprintf(msg, args...)}
+}
+
+type cpiCmdRunner struct {
+	store         Store
+	agent         *Agent
+	uuidGenerator boshuuid.Generator
+	logger        boshlog.Logger
+	logTag        string
+}
+
+// NewCPICmdRunner returns CPI that keeps simulated IaaS in store
+// and starts agent whenever a VM is created
+func NewCPICmdRunner(
+	store Store,
+	agent *Agent,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+) bicloud.CPICmdRunner {
+	return &cpiCmdRunner{
+		store:         store,
+		agent:         agent,
+		uuidGenerator: uuidGenerator,
+		logger:        logger,
+		logTag:        "localCPICmdRunner",
+	}
+}
+
+func (r *cpiCmdRunner) Run(context bicloud.CmdContext, method string, args ...interface{}) (bicloud.CmdOutput, error) {
+	// Arguments are passed through JSON so that they have the same types as external CPI receives
+	inputBytes, err := json.Marshal(bicloud.CmdInput{Method: method, Arguments: args, Context: context})
+	if err != nil {
+		return bicloud.CmdOutput{}, bosherr.WrapErrorf(err, "Marshalling local CPI command input for '%s'", method)
+	}
+
+	var input bicloud.CmdInput
+
+	err = json.Unmarshal(inputBytes, &input)
+	if err != nil {
+		return bicloud.CmdOutput{}, bosherr.WrapErrorf(err, "Unmarshalling local CPI command input for '%s'", method)
+	}
+
+	r.logger.Debug(r.logTag, "Running local CPI method '%s' with arguments: %s", method, string(inputBytes))
+
+	result, err := r.call(input.Method, input.Arguments)
+	if err != nil {
+		typedErr, ok := err.(cpiError)
+		if !ok {
+			typedErr = cpiError{errType: cloudErrorType, message: err.Error()}
+		}
+
+		return bicloud.CmdOutput{
+			Error: &bicloud.CmdError{Type: typedErr.errType, Message: typedErr.message},
+		}, nil
+	}
+
+	return bicloud.CmdOutput{Result: result}, nil
+}
+
+func (r *cpiCmdRunner) call(method string, args []interface{}) (interface{}, error) {
+	a := arguments{method: method, args: args}
+
+	switch method {
+	case "info":
+		return map[string]interface{}{"stemcell_formats": []string{}}, nil
+
+	case "create_stemcell":
+		return r.createStemcell(a.String(0), a.Map(1), a.Err())
+	case "delete_stemcell":
+		return nil, r.deleteStemcell(a.String(0), a.Err())
+
+	case "create_vm":
+		return r.createVM(a.String(0), a.String(1), a.Map(2), a.Map(3), a.Map(5), a.Err())
+	case "delete_vm":
+		return nil, r.deleteVM(a.String(0), a.Err())
+	case "has_vm":
+		return r.hasVM(a.String(0), a.Err())
+	case "set_vm_metadata":
+		return nil, r.setVMMetadata(a.String(0), a.Map(1), a.Err())
+
+	case "create_disk":
+		return r.createDisk(a.Int(0), a.Map(1), a.Err())
+	case "delete_disk":
+		return nil, r.deleteDisk(a.String(0), a.Err())
+	case "has_disk":
+		return r.hasDisk(a.String(0), a.Err())
+	case "resize_disk":
+		return nil, r.resizeDisk(a.String(0), a.Int(1), a.Err())
+	case "attach_disk":
+		return nil, r.attachDisk(a.String(0), a.String(1), a.Err())
+	case "detach_disk":
+		return nil, r.detachDisk(a.String(0), a.String(1), a.Err())
+	case "get_disks":
+		return r.getDisks(a.String(0), a.Err())
+	case "set_disk_metadata":
+		return nil, r.setDiskMetadata(a.String(0), a.Map(1), a.Err())
+
+	default:
+		// CLI treats this message as not implemented method
+		return nil, newCPIError(cloudErrorType, "Invalid Method: %s", method)
+	}
+}
+
+func (r *cpiCmdRunner) createStemcell(imagePath string, cloudProps map[string]interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	cid, err := r.newCID("stemcell")
+	if err != nil {
+		return "", err
+	}
+
+	return cid, r.store.update(func(st *state) error {
+		st.Stemcells[cid] = stemcellRecord{CID: cid, ImagePath: imagePath, CloudProperties: cloudProps}
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) deleteStemcell(cid string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		if _, found := st.Stemcells[cid]; !found {
+			return newCPIError(bicloud.StemcellNotFoundError, "Stemcell '%s' not found", cid)
+		}
+
+		delete(st.Stemcells, cid)
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) createVM(
+	agentID string,
+	stemcellCID string,
+	cloudProps map[string]interface{},
+	networks map[string]interface{},
+	env map[string]interface{},
+	err error,
+) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	cid, err := r.newCID("vm")
+	if err != nil {
+		return "", err
+	}
+
+	err = r.store.update(func(st *state) error {
+		if _, found := st.Stemcells[stemcellCID]; !found {
+			return bosherr.Errorf("Stemcell '%s' not found", stemcellCID)
+		}
+
+		st.VMs[cid] = vmRecord{
+			CID:             cid,
+			AgentID:         agentID,
+			StemcellCID:     stemcellCID,
+			CloudProperties: cloudProps,
+			Networks:        networks,
+			Env:             env,
+			Agent:           agentRecord{JobState: "stopped", MountedDiskCIDs: []string{}},
+		}
+
+		st.AgentVMCID = cid
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = r.agent.Start()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Starting agent for VM '%s'", cid)
+	}
+
+	return cid, nil
+}
+
+func (r *cpiCmdRunner) deleteVM(cid string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		if _, found := st.VMs[cid]; !found {
+			return newCPIError(bicloud.VMNotFoundError, "VM '%s' not found", cid)
+		}
+
+		for diskCID, disk := range st.Disks {
+			if disk.VMCID == cid {
+				disk.VMCID = ""
+				st.Disks[diskCID] = disk
+			}
+		}
+
+		delete(st.VMs, cid)
+
+		if st.AgentVMCID == cid {
+			st.AgentVMCID = ""
+		}
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) hasVM(cid string, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	st, err := r.store.load()
+	if err != nil {
+		return false, err
+	}
+
+	_, found := st.VMs[cid]
+
+	return found, nil
+}
+
+func (r *cpiCmdRunner) setVMMetadata(cid string, metadata map[string]interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		vm, found := st.VMs[cid]
+		if !found {
+			return newCPIError(bicloud.VMNotFoundError, "VM '%s' not found", cid)
+		}
+
+		vm.Metadata = metadata
+		st.VMs[cid] = vm
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) createDisk(size int, cloudProps map[string]interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	cid, err := r.newCID("disk")
+	if err != nil {
+		return "", err
+	}
+
+	return cid, r.store.update(func(st *state) error {
+		st.Disks[cid] = diskRecord{CID: cid, Size: size, CloudProperties: cloudProps}
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) deleteDisk(cid string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		disk, found := st.Disks[cid]
+		if !found {
+			return newCPIError(bicloud.DiskNotFoundError, "Disk '%s' not found", cid)
+		}
+
+		if disk.VMCID != "" {
+			return bosherr.Errorf("Disk '%s' is attached to VM '%s'", cid, disk.VMCID)
+		}
+
+		delete(st.Disks, cid)
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) resizeDisk(cid string, newSize int, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		disk, found := st.Disks[cid]
+		if !found {
+			return newCPIError(bicloud.DiskNotFoundError, "Disk '%s' not found", cid)
+		}
+
+		if disk.VMCID != "" {
+			return bosherr.Errorf("Disk '%s' must be detached from VM '%s' before resizing", cid, disk.VMCID)
+		}
+
+		if newSize < disk.Size {
+			return bosherr.Errorf("Disk '%s' cannot be shrunk from %d to %d", cid, disk.Size, newSize)
+		}
+
+		disk.Size = newSize
+		st.Disks[cid] = disk
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) hasDisk(cid string, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	st, err := r.store.load()
+	if err != nil {
+		return false, err
+	}
+
+	_, found := st.Disks[cid]
+
+	return found, nil
+}
+
+func (r *cpiCmdRunner) attachDisk(vmCID, diskCID string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		if _, found := st.VMs[vmCID]; !found {
+			return newCPIError(bicloud.VMNotFoundError, "VM '%s' not found", vmCID)
+		}
+
+		disk, found := st.Disks[diskCID]
+		if !found {
+			return newCPIError(bicloud.DiskNotFoundError, "Disk '%s' not found", diskCID)
+		}
+
+		if disk.VMCID != "" && disk.VMCID != vmCID {
+			return bosherr.Errorf("Disk '%s' is already attached to VM '%s'", diskCID, disk.VMCID)
+		}
+
+		disk.VMCID = vmCID
+		st.Disks[diskCID] = disk
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) detachDisk(vmCID, diskCID string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		vm, found := st.VMs[vmCID]
+		if !found {
+			return newCPIError(bicloud.VMNotFoundError, "VM '%s' not found", vmCID)
+		}
+
+		disk, found := st.Disks[diskCID]
+		if !found {
+			return newCPIError(bicloud.DiskNotFoundError, "Disk '%s' not found", diskCID)
+		}
+
+		if disk.VMCID != vmCID {
+			return bosherr.Errorf("Disk '%s' is not attached to VM '%s'", diskCID, vmCID)
+		}
+
+		disk.VMCID = ""
+		st.Disks[diskCID] = disk
+
+		vm.Agent.MountedDiskCIDs = removeString(vm.Agent.MountedDiskCIDs, diskCID)
+		st.VMs[vmCID] = vm
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) getDisks(vmCID string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := r.store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := st.VMs[vmCID]; !found {
+		return nil, newCPIError(bicloud.VMNotFoundError, "VM '%s' not found", vmCID)
+	}
+
+	diskCIDs := []string{}
+
+	for _, disk := range st.Disks {
+		if disk.VMCID == vmCID {
+			diskCIDs = append(diskCIDs, disk.CID)
+		}
+	}
+
+	return diskCIDs, nil
+}
+
+func (r *cpiCmdRunner) setDiskMetadata(cid string, metadata map[string]interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return r.store.update(func(st *state) error {
+		disk, found := st.Disks[cid]
+		if !found {
+			return newCPIError(bicloud.DiskNotFoundError, "Disk '%s' not found", cid)
+		}
+
+		disk.Metadata = metadata
+		st.Disks[cid] = disk
+
+		return nil
+	})
+}
+
+func (r *cpiCmdRunner) newCID(prefix string) (string, error) {
+	id, err := r.uuidGenerator.Generate()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Generating %s CID", prefix)
+	}
+
+	return fmt.Sprintf("%s-%s", prefix, id), nil
+}
+
+func removeString(strs []string, str string) []string {
+	result := []string{}
+
+	for _, s := range strs {
+		if s != str {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+// arguments remembers first conversion error so that each method
+// can read all of its arguments before checking for errors
+type arguments struct {
+	method string
+	args   []interface{}
+	err    error
+}
+
+func (a *arguments) String(i int) string {
+	str, _ := a.arg(i, "string").(string)
+	return str
+}
+
+func (a *arguments) Int(i int) int {
+	num, _ := a.arg(i, "number").(float64)
+	return int(num)
+}
+
+func (a *arguments) Map(i int) map[string]interface{} {
+	// null is a valid value for hashes
+	if i < len(a.args) && a.args[i] == nil {
+		return map[string]interface{}{}
+	}
+
+	m, _ := a.arg(i, "hash").(map[string]interface{})
+	return m
+}
+
+func (a *arguments) Err() error {
+	return a.err
+}
+
+func (a *arguments) arg(i int, kind string) interface{} {
+	if a.err != nil {
+		return nil
+	}
+
+	var value interface{}
+	var ok bool
+
+	if i < len(a.args) {
+		value = a.args[i]
+
+		switch kind {
+		case "string":
+			_, ok = value.(string)
+		case "number":
+			_, ok = value.(float64)
+		case "hash":
+			_, ok = value.(map[string]interface{})
+		}
+	}
+
+	if !ok {
+		a.err = newCPIError("InvalidCall", "Expected argument %d of '%s' to be a %s", i, a.method, kind)
+		return nil
+	}
+
+	return value
+}
@@ -0,0 +1,75 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+type factory struct {
+	fs            boshsys.FileSystem
+	uuidGenerator boshuuid.Generator
+	logger        boshlog.Logger
+
+	// runners are reused since agent keeps listening on mbus
+	// for as long as the CLI runs
+	runners map[runnerKey]bicloud.CPICmdRunner
+}
+
+type runnerKey struct {
+	stateDir string
+	mbusURL  string
+}
+
+func NewFactory(
+	fs boshsys.FileSystem,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+) bicloud.LocalCPIFactory {
+	return &factory{
+		fs:            fs,
+		uuidGenerator: uuidGenerator,
+		logger:        logger,
+		runners:       map[runnerKey]bicloud.CPICmdRunner{},
+	}
+}
+
+func (f *factory) NewCPICmdRunner(stateDir, mbusURL string) (bicloud.CPICmdRunner, error) {
+	key := runnerKey{stateDir: stateDir, mbusURL: mbusURL}
+
+	if runner, found := f.runners[key]; found {
+		return runner, nil
+	}
+
+	err := f.fs.MkdirAll(stateDir, os.ModePerm)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating local CPI state directory '%s'", stateDir)
+	}
+
+	store := NewStore(f.fs, filepath.Join(stateDir, "state.json"))
+	agent := NewAgent(store, filepath.Join(stateDir, "blobs"), mbusURL, f.fs, f.uuidGenerator, f.logger)
+
+	st, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// VM created by previous run is expected to be reachable
+	if st.AgentVMCID != "" {
+		err = agent.Start()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Starting agent for VM '%s'", st.AgentVMCID)
+		}
+	}
+
+	runner := NewCPICmdRunner(store, agent, f.uuidGenerator, f.logger)
+	f.runners[key] = runner
+
+	return runner, nil
+}
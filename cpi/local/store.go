@@ -0,0 +1,127 @@
+package local
+
+import (
+	"encoding/json"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// state is everything local CPI and its stub agent know about simulated IaaS
+type state struct {
+	Stemcells map[string]stemcellRecord `json:"stemcells"`
+	VMs       map[string]vmRecord       `json:"vms"`
+	Disks     map[string]diskRecord     `json:"disks"`
+
+	// AgentVMCID is the VM whose agent answers on mbus
+	AgentVMCID string `json:"agent_vm_cid,omitempty"`
+}
+
+type stemcellRecord struct {
+	CID             string                 `json:"cid"`
+	ImagePath       string                 `json:"image_path"`
+	CloudProperties map[string]interface{} `json:"cloud_properties"`
+}
+
+type vmRecord struct {
+	CID             string                 `json:"cid"`
+	AgentID         string                 `json:"agent_id"`
+	StemcellCID     string                 `json:"stemcell_cid"`
+	CloudProperties map[string]interface{} `json:"cloud_properties"`
+	Networks        map[string]interface{} `json:"networks"`
+	Env             map[string]interface{} `json:"env"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	Agent           agentRecord            `json:"agent"`
+}
+
+type agentRecord struct {
+	JobState        string      `json:"job_state"`
+	Spec            interface{} `json:"spec,omitempty"`
+	MountedDiskCIDs []string    `json:"mounted_disk_cids"`
+}
+
+type diskRecord struct {
+	CID             string                 `json:"cid"`
+	Size            int                    `json:"size"`
+	CloudProperties map[string]interface{} `json:"cloud_properties"`
+	VMCID           string                 `json:"vm_cid,omitempty"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Store persists state in a single JSON file;
+// it is shared by CPI calls and concurrently handled agent requests
+type Store struct {
+	fs   boshsys.FileSystem
+	path string
+	lock *sync.Mutex
+}
+
+func NewStore(fs boshsys.FileSystem, path string) Store {
+	return Store{fs: fs, path: path, lock: &sync.Mutex{}}
+}
+
+func (s Store) load() (state, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.read()
+}
+
+// update saves state only if updateFunc succeeds
+func (s Store) update(updateFunc func(*state) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	err = updateFunc(&st)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling local CPI state")
+	}
+
+	err = s.fs.WriteFile(s.path, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing local CPI state '%s'", s.path)
+	}
+
+	return nil
+}
+
+func (s Store) read() (state, error) {
+	st := state{}
+
+	if s.fs.FileExists(s.path) {
+		bytes, err := s.fs.ReadFile(s.path)
+		if err != nil {
+			return st, bosherr.WrapErrorf(err, "Reading local CPI state '%s'", s.path)
+		}
+
+		err = json.Unmarshal(bytes, &st)
+		if err != nil {
+			return st, bosherr.WrapErrorf(err, "Unmarshalling local CPI state '%s'", s.path)
+		}
+	}
+
+	if st.Stemcells == nil {
+		st.Stemcells = map[string]stemcellRecord{}
+	}
+
+	if st.VMs == nil {
+		st.VMs = map[string]vmRecord{}
+	}
+
+	if st.Disks == nil {
+		st.Disks = map[string]diskRecord{}
+	}
+
+	return st, nil
+}
@@ -15,6 +15,10 @@ type CpiInstaller struct {
 }
 
 func (i CpiInstaller) ValidateCpiRelease(installationManifest biinstallmanifest.Manifest, stage biui.Stage) error {
+	if installationManifest.UsesLocalCPI() {
+		return nil
+	}
+
 	return stage.Perform("Validating cpi release", func() error {
 		cpiReleaseName := installationManifest.Template.Release
 		cpiRelease, found := i.ReleaseManager.Find(cpiReleaseName)
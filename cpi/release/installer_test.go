@@ -15,6 +15,19 @@ import (
 )
 
 var _ = Describe("Installer", func() {
+	Describe("ValidateCpiRelease", func() {
+		It("does not require CPI release when using local CPI", func() {
+			installationManifest := biinstallationmanifest.Manifest{
+				Local: &biinstallationmanifest.LocalCPI{StateDir: "/fake-state-dir"},
+			}
+			stage := fakeui.NewFakeStage()
+
+			err := release.CpiInstaller{}.ValidateCpiRelease(installationManifest, stage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stage.PerformCalls).To(BeEmpty())
+		})
+	})
+
 	Describe("WithInstalledCpiRelease", func() {
 		var (
 			mockCtrl             *gomock.Controller
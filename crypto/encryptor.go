@@ -0,0 +1,168 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	encryptedHeader = "BOSH-ENCRYPTED v1 aes-256-gcm pbkdf2-sha256"
+
+	encryptorSaltLen    = 16
+	encryptorKeyLen     = 32
+	encryptorIterations = 100000
+	encryptorLineLen    = 64
+)
+
+type Encryptor interface {
+	Encrypt([]byte) ([]byte, error)
+	Decrypt([]byte) ([]byte, error)
+}
+
+// IsEncrypted returns true if contents were produced by an Encryptor.
+func IsEncrypted(contents []byte) bool {
+	return bytes.HasPrefix(contents, []byte(encryptedHeader+"\n"))
+}
+
+type passphraseEncryptor struct {
+	passphrase []byte
+	randReader io.Reader
+}
+
+// NewPassphraseEncryptor returns an Encryptor that uses AES-256-GCM
+// with a key derived from the passphrase via PBKDF2-SHA256 and random salt.
+// Output is an armored text block suitable for committing to a repository.
+func NewPassphraseEncryptor(passphrase []byte) Encryptor {
+	return passphraseEncryptor{passphrase: passphrase, randReader: rand.Reader}
+}
+
+func (e passphraseEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, encryptorSaltLen)
+
+	_, err := io.ReadFull(e.randReader, salt)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating salt")
+	}
+
+	aead, err := e.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err = io.ReadFull(e.randReader, nonce)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating nonce")
+	}
+
+	payload := append(append(salt, nonce...), aead.Seal(nil, nonce, plaintext, []byte(encryptedHeader))...)
+	encoded := base64.StdEncoding.EncodeToString(payload)
+
+	lines := []string{encryptedHeader}
+
+	for len(encoded) > encryptorLineLen {
+		lines = append(lines, encoded[:encryptorLineLen])
+		encoded = encoded[encryptorLineLen:]
+	}
+
+	lines = append(lines, encoded)
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func (e passphraseEncryptor) Decrypt(contents []byte) ([]byte, error) {
+	if !IsEncrypted(contents) {
+		return nil, bosherr.Error("Expected contents to be encrypted")
+	}
+
+	encoded := strings.Join(strings.Fields(string(contents[len(encryptedHeader):])), "")
+
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Decoding encrypted contents")
+	}
+
+	if len(payload) < encryptorSaltLen {
+		return nil, bosherr.Error("Expected encrypted contents to include salt")
+	}
+
+	aead, err := e.aead(payload[:encryptorSaltLen])
+	if err != nil {
+		return nil, err
+	}
+
+	payload = payload[encryptorSaltLen:]
+
+	if len(payload) < aead.NonceSize() {
+		return nil, bosherr.Error("Expected encrypted contents to include nonce")
+	}
+
+	plaintext, err := aead.Open(nil, payload[:aead.NonceSize()], payload[aead.NonceSize():], []byte(encryptedHeader))
+	if err != nil {
+		return nil, bosherr.Error("Decrypting contents: incorrect key or corrupted contents")
+	}
+
+	return plaintext, nil
+}
+
+func (e passphraseEncryptor) aead(salt []byte) (cipher.AEAD, error) {
+	if len(e.passphrase) == 0 {
+		return nil, bosherr.Error("Expected encryption passphrase to be non-empty")
+	}
+
+	block, err := aes.NewCipher(pbkdf2SHA256(e.passphrase, salt, encryptorIterations, encryptorKeyLen))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building GCM cipher")
+	}
+
+	return aead, nil
+}
+
+// pbkdf2SHA256 implements RFC 2898 key derivation (golang.org/x/crypto/pbkdf2 is not vendored)
+func pbkdf2SHA256(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for x := range u {
+				t[x] ^= u[x]
+			}
+		}
+	}
+
+	return dk[:keyLen]
+}
@@ -0,0 +1,88 @@
+package crypto_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("PassphraseEncryptor", func() {
+	var (
+		encryptor Encryptor
+	)
+
+	BeforeEach(func() {
+		encryptor = NewPassphraseEncryptor([]byte("passphrase"))
+	})
+
+	Describe("Encrypt", func() {
+		It("returns armored contents that can be decrypted", func() {
+			encBytes, err := encryptor.Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(IsEncrypted(encBytes)).To(BeTrue())
+			Expect(string(encBytes)).ToNot(ContainSubstring("key: val"))
+
+			for _, line := range strings.Split(string(encBytes), "\n") {
+				Expect(len(line)).To(BeNumerically("<=", 64))
+			}
+
+			decBytes, err := encryptor.Decrypt(encBytes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(decBytes)).To(Equal("key: val\n"))
+		})
+
+		It("returns different contents every time because of random salt and nonce", func() {
+			encBytes1, err := encryptor.Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			encBytes2, err := encryptor.Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(encBytes1).ToNot(Equal(encBytes2))
+		})
+
+		It("returns error if passphrase is empty", func() {
+			_, err := NewPassphraseEncryptor(nil).Encrypt([]byte("key: val\n"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected encryption passphrase to be non-empty"))
+		})
+	})
+
+	Describe("Decrypt", func() {
+		It("returns error if contents are not encrypted", func() {
+			_, err := encryptor.Decrypt([]byte("key: val\n"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected contents to be encrypted"))
+		})
+
+		It("returns error if passphrase does not match", func() {
+			encBytes, err := encryptor.Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = NewPassphraseEncryptor([]byte("other")).Decrypt(encBytes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Decrypting contents: incorrect key or corrupted contents"))
+		})
+
+		It("returns error if contents were tampered with", func() {
+			encBytes, err := encryptor.Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			lines := strings.Split(string(encBytes), "\n")
+			lines[1] = strings.Repeat("A", len(lines[1]))
+
+			_, err = encryptor.Decrypt([]byte(strings.Join(lines, "\n")))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("IsEncrypted", func() {
+		It("returns false for plain contents", func() {
+			Expect(IsEncrypted([]byte("key: val\n"))).To(BeFalse())
+		})
+	})
+})
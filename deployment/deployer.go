@@ -5,6 +5,7 @@ import (
 
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -32,6 +33,7 @@ type deployer struct {
 	vmManagerFactory       bivm.ManagerFactory
 	instanceManagerFactory biinstance.ManagerFactory
 	deploymentFactory      Factory
+	checkpointRepo         biconfig.CheckpointRepo
 	logger                 boshlog.Logger
 	logTag                 string
 }
@@ -40,12 +42,14 @@ func NewDeployer(
 	vmManagerFactory bivm.ManagerFactory,
 	instanceManagerFactory biinstance.ManagerFactory,
 	deploymentFactory Factory,
+	checkpointRepo biconfig.CheckpointRepo,
 	logger boshlog.Logger,
 ) Deployer {
 	return &deployer{
 		vmManagerFactory:       vmManagerFactory,
 		instanceManagerFactory: instanceManagerFactory,
 		deploymentFactory:      deploymentFactory,
+		checkpointRepo:         checkpointRepo,
 		logger:                 logger,
 		logTag:                 "deployer",
 	}
@@ -64,10 +68,19 @@ func (d *deployer) Deploy(
 
 	pingTimeout := 10 * time.Second
 	pingDelay := 500 * time.Millisecond
-	if err := instanceManager.DeleteAll(pingTimeout, pingDelay, deployStage); err != nil {
+
+	resumingVM, err := d.isResumingVM(vmManager, pingTimeout, pingDelay)
+	if err != nil {
 		return nil, err
 	}
 
+	// VM created by a failed attempt of the same deploy is kept and reused
+	if !resumingVM {
+		if err := instanceManager.DeleteAll(pingTimeout, pingDelay, deployStage); err != nil {
+			return nil, err
+		}
+	}
+
 	instances, disks, err := d.createAllInstances(deploymentManifest, instanceManager, cloudStemcell, registryConfig, deployStage)
 	if err != nil {
 		return nil, err
@@ -77,6 +90,35 @@ func (d *deployer) Deploy(
 	return d.deploymentFactory.NewDeployment(instances, disks, stemcells), nil
 }
 
+func (d *deployer) isResumingVM(vmManager bivm.Manager, pingTimeout, pingDelay time.Duration) (bool, error) {
+	checkpoint, found, err := d.checkpointRepo.Find()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Finding deploy checkpoint")
+	} else if !found {
+		return false, nil
+	}
+
+	vm, found, err := vmManager.FindCurrent()
+	if err != nil {
+		return false, bosherr.WrapError(err, "Finding current VM")
+	} else if !found {
+		return false, nil
+	}
+
+	if !checkpoint.CanResumeVM(vm.CID()) {
+		return false, nil
+	}
+
+	// Previous attempt may have failed because agent never became reachable
+	err = vm.WaitUntilReady(pingTimeout, pingDelay)
+	if err != nil {
+		d.logger.Warn(d.logTag, "Recreating VM '%s' created by previous deploy since its agent is not responding: %s", vm.CID(), err.Error())
+		return false, nil
+	}
+
+	return true, nil
+}
+
 func (d *deployer) createAllInstances(
 	deploymentManifest bideplmanifest.Manifest,
 	instanceManager biinstance.Manager,
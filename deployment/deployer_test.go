@@ -59,6 +59,7 @@ var _ = Describe("Deployer", func() {
 		registryConfig         biinstallmanifest.Registry
 		fakeStage              *fakebiui.FakeStage
 		fakeVM                 *fakebivm.FakeVM
+		fakeCheckpointRepo     *fakebiconfig.FakeCheckpointRepo
 
 		cloudStemcell bistemcell.CloudStemcell
 
@@ -155,10 +156,13 @@ var _ = Describe("Deployer", func() {
 		pingDelay := 500 * time.Millisecond
 		deploymentFactory := NewFactory(pingTimeout, pingDelay)
 
+		fakeCheckpointRepo = fakebiconfig.NewFakeCheckpointRepo()
+
 		deployer = NewDeployer(
 			mockVMManagerFactory,
 			instanceManagerFactory,
 			deploymentFactory,
+			fakeCheckpointRepo,
 			logger,
 		)
 	})
@@ -202,6 +206,50 @@ var _ = Describe("Deployer", func() {
 				{Name: "Deleting VM 'existing-vm-cid'"},
 			}))
 		})
+
+		Context("when existing vm was created by previous attempt of the same deploy", func() {
+			BeforeEach(func() {
+				fakeCheckpointRepo.FindFound = true
+				fakeCheckpointRepo.FindRecord = biconfig.CheckpointRecord{
+					ManifestSHA:    "fake-manifest-sha",
+					CompletedSteps: []string{biconfig.CheckpointVMCreated},
+					VMCID:          "existing-vm-cid",
+				}
+			})
+
+			It("does not delete existing vm", func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeExistingVM.DeleteCalled).To(Equal(0))
+			})
+
+			It("checks that agent on existing vm is responding", func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeExistingVM.WaitUntilReadyInputs).To(Equal([]fakebivm.WaitUntilReadyInput{
+					{Timeout: 10 * time.Second, Delay: 500 * time.Millisecond},
+				}))
+			})
+
+			Context("when agent on existing vm is not responding", func() {
+				BeforeEach(func() {
+					fakeExistingVM.WaitUntilReadyErr = errors.New("fake-wait-error")
+				})
+
+				It("deletes existing vm so that it is recreated", func() {
+					_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, fakeStage)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
+					Expect(fakeVMManager.CreateInput).To(Equal(fakebivm.CreateInput{
+						Stemcell: cloudStemcell,
+						Manifest: deploymentManifest,
+					}))
+				})
+			})
+		})
 	})
 
 	It("creates a vm", func() {
@@ -134,9 +134,10 @@ var _ = Describe("Deployment", func() {
 		JustBeforeEach(func() {
 			// all these local factories & managers are just used to construct a Deployment based on the deployment state
 			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, logger)
-			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger)
+			checkpointRepo := biconfig.NewCheckpointRepo(deploymentStateService)
+			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, checkpointRepo, logger)
 
-			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, checkpointRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
 			sshTunnelFactory := bisshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance_state.NewMockBuilderFactory(mockCtrl)
@@ -346,7 +347,7 @@ var _ = Describe("Deployment", func() {
 		Context("when a current disk exists", func() {
 			BeforeEach(func() {
 				deploymentStateService.Save(biconfig.DeploymentState{})
-				diskRecord, err := diskRepo.Save("fake-disk-cid", 100, nil)
+				diskRecord, err := diskRepo.Save("", "fake-disk-cid", 100, nil)
 				Expect(err).ToNot(HaveOccurred())
 				diskRepo.UpdateCurrent(diskRecord.ID)
 			})
@@ -11,12 +11,18 @@ import (
 
 type Disk interface {
 	CID() string
+	// Name is empty for the disk configured by persistent_disk or persistent_disk_pool
+	Name() string
 	NeedsMigration(newSize int, newCloudProperties biproperty.Map) bool
+	// CanResize returns true if the change only grows the disk so that it may be resized in place
+	CanResize(newSize int, newCloudProperties biproperty.Map) bool
+	Resize(newSize int) error
 	Delete() error
 }
 
 type disk struct {
 	cid             string
+	name            string
 	size            int
 	cloudProperties biproperty.Map
 
@@ -31,6 +37,7 @@ func NewDisk(
 ) Disk {
 	return &disk{
 		cid:             diskRecord.CID,
+		name:            diskRecord.Name,
 		size:            diskRecord.Size,
 		cloudProperties: diskRecord.CloudProperties,
 		cloud:           cloud,
@@ -42,10 +49,44 @@ func (d *disk) CID() string {
 	return d.cid
 }
 
+func (d *disk) Name() string {
+	return d.name
+}
+
 func (d *disk) NeedsMigration(newSize int, newCloudProperties biproperty.Map) bool {
 	return d.size != newSize || !reflect.DeepEqual(d.cloudProperties, newCloudProperties)
 }
 
+func (d *disk) CanResize(newSize int, newCloudProperties biproperty.Map) bool {
+	return newSize > d.size && reflect.DeepEqual(d.cloudProperties, newCloudProperties)
+}
+
+func (d *disk) Resize(newSize int) error {
+	err := d.cloud.ResizeDisk(d.cid, newSize)
+	if err != nil {
+		// returns bicloud.Error so that callers can tell if CPI does not implement resize_disk
+		return err
+	}
+
+	diskRecord, found, err := d.repo.Find(d.cid)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Finding disk record (cid=%s)", d.cid)
+	}
+
+	if !found {
+		return bosherr.Errorf("Failed to find disk record (cid=%s)", d.cid)
+	}
+
+	err = d.repo.UpdateSize(diskRecord.ID, newSize)
+	if err != nil {
+		return bosherr.WrapError(err, "Updating disk record size")
+	}
+
+	d.size = newSize
+
+	return nil
+}
+
 func (d *disk) Delete() error {
 	deleteErr := d.cloud.DeleteDisk(d.cid)
 	if deleteErr != nil {
@@ -82,6 +82,65 @@ var _ = Describe("Disk", func() {
 		})
 	})
 
+	Describe("CanResize", func() {
+		It("returns true when only size grows", func() {
+			Expect(disk.CanResize(2048, diskCloudProperties)).To(BeTrue())
+		})
+
+		It("returns false when size shrinks", func() {
+			Expect(disk.CanResize(512, diskCloudProperties)).To(BeFalse())
+		})
+
+		It("returns false when cloud properties are different", func() {
+			Expect(disk.CanResize(2048, biproperty.Map{})).To(BeFalse())
+		})
+	})
+
+	Describe("Resize", func() {
+		BeforeEach(func() {
+			_, err := diskRepo.Save("", "fake-disk-cid", 1024, diskCloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("resizes disk in the cloud and updates disk record", func() {
+			err := disk.Resize(2048)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeCloud.ResizeDiskInputs).To(Equal([]fakebicloud.ResizeDiskInput{
+				{
+					DiskCID: "fake-disk-cid",
+					NewSize: 2048,
+				},
+			}))
+
+			diskRecord, found, err := diskRepo.Find("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(diskRecord.Size).To(Equal(2048))
+
+			Expect(disk.NeedsMigration(2048, diskCloudProperties)).To(BeFalse())
+		})
+
+		Context("when resizing disk in the cloud fails", func() {
+			var resizeErr = bicloud.NewCPIError("resize_disk", bicloud.CmdError{
+				Type:    bicloud.NotImplementedError,
+				Message: "fake-not-implemented-message",
+			})
+
+			BeforeEach(func() {
+				fakeCloud.ResizeDiskErr = resizeErr
+			})
+
+			It("returns the cloud error and keeps disk record size", func() {
+				err := disk.Resize(2048)
+				Expect(err).To(Equal(resizeErr))
+
+				diskRecord, _, err := diskRepo.Find("fake-disk-cid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diskRecord.Size).To(Equal(1024))
+			})
+		})
+	})
+
 	Describe("Delete", func() {
 		It("deletes disk from cloud", func() {
 			err := disk.Delete()
@@ -94,7 +153,7 @@ var _ = Describe("Disk", func() {
 		})
 
 		It("deletes disk from repo", func() {
-			_, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+			_, err := diskRepo.Save("", "fake-disk-cid", 1024, diskCloudProperties)
 			Expect(err).ToNot(HaveOccurred())
 
 			err = disk.Delete()
@@ -105,7 +164,7 @@ var _ = Describe("Disk", func() {
 
 		Context("when deleted disk is the current disk", func() {
 			BeforeEach(func() {
-				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+				diskRecord, err := diskRepo.Save("", "fake-disk-cid", 1024, diskCloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = diskRepo.UpdateCurrent(diskRecord.ID)
@@ -141,7 +200,7 @@ var _ = Describe("Disk", func() {
 			})
 
 			BeforeEach(func() {
-				diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+				diskRecord, err := diskRepo.Save("", "fake-disk-cid", 1024, diskCloudProperties)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = diskRepo.UpdateCurrent(diskRecord.ID)
@@ -5,11 +5,17 @@ import (
 )
 
 type FakeDisk struct {
-	cid string
+	cid  string
+	name string
 
 	NeedsMigrationInputs []NeedsMigrationInput
 	needsMigrationOutput needsMigrationOutput
 
+	canResize bool
+
+	ResizeInputs []int
+	ResizeErr    error
+
 	DeleteCalledTimes int
 	deleteErr         error
 }
@@ -43,6 +49,19 @@ func (d *FakeDisk) NeedsMigration(size int, cloudProperties biproperty.Map) bool
 	return d.needsMigrationOutput.needsMigration
 }
 
+func (d *FakeDisk) Name() string {
+	return d.name
+}
+
+func (d *FakeDisk) CanResize(size int, cloudProperties biproperty.Map) bool {
+	return d.canResize
+}
+
+func (d *FakeDisk) Resize(size int) error {
+	d.ResizeInputs = append(d.ResizeInputs, size)
+	return d.ResizeErr
+}
+
 func (d *FakeDisk) Delete() error {
 	d.DeleteCalledTimes++
 	return d.deleteErr
@@ -54,6 +73,14 @@ func (d *FakeDisk) SetNeedsMigrationBehavior(needsMigration bool) {
 	}
 }
 
+func (d *FakeDisk) SetName(name string) {
+	d.name = name
+}
+
+func (d *FakeDisk) SetCanResizeBehavior(canResize bool) {
+	d.canResize = canResize
+}
+
 func (d *FakeDisk) SetDeleteBehavior(err error) {
 	d.deleteErr = err
 }
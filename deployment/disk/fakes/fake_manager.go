@@ -20,8 +20,8 @@ type FakeManager struct {
 }
 
 type CreateInput struct {
-	DiskPool   bideplmanifest.DiskPool
-	InstanceID string
+	PersistentDisk bideplmanifest.PersistentDisk
+	InstanceID     string
 }
 
 type findCurrentOutput struct {
@@ -38,10 +38,10 @@ func NewFakeManager() *FakeManager {
 	return &FakeManager{}
 }
 
-func (m *FakeManager) Create(diskPool bideplmanifest.DiskPool, instanceID string) (bidisk.Disk, error) {
+func (m *FakeManager) Create(persistentDisk bideplmanifest.PersistentDisk, instanceID string) (bidisk.Disk, error) {
 	input := CreateInput{
-		DiskPool:   diskPool,
-		InstanceID: instanceID,
+		PersistentDisk: persistentDisk,
+		InstanceID:     instanceID,
 	}
 	m.CreateInputs = append(m.CreateInputs, input)
 
@@ -13,7 +13,7 @@ import (
 
 type Manager interface {
 	FindCurrent() ([]Disk, error)
-	Create(bideplmanifest.DiskPool, string) (Disk, error)
+	Create(bideplmanifest.PersistentDisk, string) (Disk, error)
 	FindUnused() ([]Disk, error)
 	DeleteUnused(biui.Stage) error
 }
@@ -41,33 +41,32 @@ type manager struct {
 func (m *manager) FindCurrent() ([]Disk, error) {
 	disks := []Disk{}
 
-	diskRecord, found, err := m.diskRepo.FindCurrent()
+	diskRecords, err := m.diskRepo.FindAllCurrent()
 	if err != nil {
-		return disks, bosherr.WrapError(err, "Reading disk record")
+		return disks, bosherr.WrapError(err, "Reading disk records")
 	}
 
-	if found {
-		disk := NewDisk(diskRecord, m.cloud, m.diskRepo)
-		disks = append(disks, disk)
+	for _, diskRecord := range diskRecords {
+		disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo))
 	}
 
 	return disks, nil
 }
 
-func (m *manager) Create(diskPool bideplmanifest.DiskPool, vmCID string) (Disk, error) {
-	diskCloudProperties := diskPool.CloudProperties
+func (m *manager) Create(persistentDisk bideplmanifest.PersistentDisk, vmCID string) (Disk, error) {
+	diskCloudProperties := persistentDisk.CloudProperties
 
 	m.logger.Debug(m.logTag, "Creating disk")
-	cid, err := m.cloud.CreateDisk(diskPool.DiskSize, diskCloudProperties, vmCID)
+	cid, err := m.cloud.CreateDisk(persistentDisk.DiskSize, diskCloudProperties, vmCID)
 	if err != nil {
 		return nil,
 			bosherr.WrapErrorf(err,
 				"Creating disk with size %d, cloudProperties %#v, instanceID %s",
-				diskPool.DiskSize, diskCloudProperties, vmCID,
+				persistentDisk.DiskSize, diskCloudProperties, vmCID,
 			)
 	}
 
-	diskRecord, err := m.diskRepo.Save(cid, diskPool.DiskSize, diskCloudProperties)
+	diskRecord, err := m.diskRepo.Save(persistentDisk.Name, cid, persistentDisk.DiskSize, diskCloudProperties)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Saving deployment disk record")
 	}
@@ -85,13 +84,18 @@ func (m *manager) FindUnused() ([]Disk, error) {
 		return disks, bosherr.WrapError(err, "Getting all disk records")
 	}
 
-	currentDiskRecord, found, err := m.diskRepo.FindCurrent()
+	currentDiskRecords, err := m.diskRepo.FindAllCurrent()
 	if err != nil {
-		return disks, bosherr.WrapError(err, "Finding current disk record")
+		return disks, bosherr.WrapError(err, "Finding current disk records")
+	}
+
+	currentDiskIDs := map[string]struct{}{}
+	for _, currentDiskRecord := range currentDiskRecords {
+		currentDiskIDs[currentDiskRecord.ID] = struct{}{}
 	}
 
 	for _, diskRecord := range diskRecords {
-		if !found || diskRecord.ID != currentDiskRecord.ID {
+		if _, found := currentDiskIDs[diskRecord.ID]; !found {
 			disks = append(disks, NewDisk(diskRecord, m.cloud, m.diskRepo))
 		}
 	}
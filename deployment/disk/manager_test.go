@@ -40,13 +40,13 @@ var _ = Describe("Manager", func() {
 
 	Describe("Create", func() {
 		var (
-			diskPool bideplmanifest.DiskPool
+			persistentDisk bideplmanifest.PersistentDisk
 		)
 
 		BeforeEach(func() {
 
-			diskPool = bideplmanifest.DiskPool{
-				Name:     "fake-disk-pool-name",
+			persistentDisk = bideplmanifest.PersistentDisk{
+				Name:     "fake-disk-name",
 				DiskSize: 1024,
 				CloudProperties: biproperty.Map{
 					"fake-cloud-property-key": "fake-cloud-property-value",
@@ -60,13 +60,13 @@ var _ = Describe("Manager", func() {
 			})
 
 			It("returns a disk", func() {
-				disk, err := manager.Create(diskPool, "fake-vm-cid")
+				disk, err := manager.Create(persistentDisk, "fake-vm-cid")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(disk.CID()).To(Equal("fake-disk-cid"))
 			})
 
 			It("saves the disk record", func() {
-				_, err := manager.Create(diskPool, "fake-vm-cid")
+				_, err := manager.Create(persistentDisk, "fake-vm-cid")
 				Expect(err).ToNot(HaveOccurred())
 
 				diskRecord, found, err := diskRepo.Find("fake-disk-cid")
@@ -75,6 +75,7 @@ var _ = Describe("Manager", func() {
 
 				Expect(diskRecord).To(Equal(biconfig.DiskRecord{
 					ID:   "fake-uuid",
+					Name: "fake-disk-name",
 					CID:  "fake-disk-cid",
 					Size: 1024,
 					CloudProperties: biproperty.Map{
@@ -90,7 +91,7 @@ var _ = Describe("Manager", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := manager.Create(diskPool, "fake-vm-cid")
+				_, err := manager.Create(persistentDisk, "fake-vm-cid")
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-error"))
 			})
@@ -102,7 +103,7 @@ var _ = Describe("Manager", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := manager.Create(diskPool, "fake-vm-cid")
+				_, err := manager.Create(persistentDisk, "fake-vm-cid")
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
 			})
@@ -112,7 +113,7 @@ var _ = Describe("Manager", func() {
 	Describe("FindCurrent", func() {
 		Context("when disk already exists in disk repo", func() {
 			BeforeEach(func() {
-				diskRecord, err := diskRepo.Save("fake-existing-disk-cid", 1024, biproperty.Map{})
+				diskRecord, err := diskRepo.Save("", "fake-existing-disk-cid", 1024, biproperty.Map{})
 				Expect(err).ToNot(HaveOccurred())
 
 				err = diskRepo.UpdateCurrent(diskRecord.ID)
@@ -127,6 +128,27 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("when named disks exist in disk repo", func() {
+			BeforeEach(func() {
+				for _, name := range []string{"fake-db-disk", "fake-blobstore-disk"} {
+					fakeUUIDGenerator.GeneratedUUID = name + "-id"
+					diskRecord, err := diskRepo.Save(name, name+"-cid", 1024, biproperty.Map{})
+					Expect(err).ToNot(HaveOccurred())
+
+					err = diskRepo.UpdateCurrent(diskRecord.ID)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			})
+
+			It("returns all current disks with their names", func() {
+				disks, err := manager.FindCurrent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disks).To(HaveLen(2))
+				Expect(disks[0].Name()).To(Equal("fake-db-disk"))
+				Expect(disks[1].CID()).To(Equal("fake-blobstore-disk-cid"))
+			})
+		})
+
 		Context("when disk does not exists in disk repo", func() {
 			It("returns an empty array", func() {
 				disks, err := manager.FindCurrent()
@@ -157,18 +179,18 @@ var _ = Describe("Manager", func() {
 
 		BeforeEach(func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
-			firstDiskRecord, err := diskRepo.Save("fake-disk-cid-1", 1024, biproperty.Map{})
+			firstDiskRecord, err := diskRepo.Save("", "fake-disk-cid-1", 1024, biproperty.Map{})
 			Expect(err).ToNot(HaveOccurred())
 			firstDisk = NewDisk(firstDiskRecord, fakeCloud, diskRepo)
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-2"
-			_, err = diskRepo.Save("fake-disk-cid-2", 1024, biproperty.Map{})
+			_, err = diskRepo.Save("", "fake-disk-cid-2", 1024, biproperty.Map{})
 			Expect(err).ToNot(HaveOccurred())
 			err = diskRepo.UpdateCurrent("fake-guid-2")
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-guid-3"
-			thirdDiskRecord, err := diskRepo.Save("fake-disk-cid-3", 1024, biproperty.Map{})
+			thirdDiskRecord, err := diskRepo.Save("", "fake-disk-cid-3", 1024, biproperty.Map{})
 			Expect(err).ToNot(HaveOccurred())
 			thirdDisk = NewDisk(thirdDiskRecord, fakeCloud, diskRepo)
 		})
@@ -193,17 +215,17 @@ var _ = Describe("Manager", func() {
 			fakeStage = fakebiui.NewFakeStage()
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-disk-id-1"
-			_, err := diskRepo.Save("fake-disk-cid-1", 100, nil)
+			_, err := diskRepo.Save("", "fake-disk-cid-1", 100, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-disk-id-2"
-			secondDiskRecord, err = diskRepo.Save("fake-disk-cid-2", 100, nil)
+			secondDiskRecord, err = diskRepo.Save("", "fake-disk-cid-2", 100, nil)
 			Expect(err).ToNot(HaveOccurred())
 			err = diskRepo.UpdateCurrent(secondDiskRecord.ID)
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-disk-id-3"
-			_, err = diskRepo.Save("fake-disk-cid-3", 100, nil)
+			_, err = diskRepo.Save("", "fake-disk-cid-3", 100, nil)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -42,6 +42,16 @@ func (_mr *_MockDiskRecorder) CID() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "CID")
 }
 
+func (_m *MockDisk) CanResize(_param0 int, _param1 property.Map) bool {
+	ret := _m.ctrl.Call(_m, "CanResize", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+func (_mr *_MockDiskRecorder) CanResize(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CanResize", arg0, arg1)
+}
+
 func (_m *MockDisk) Delete() error {
 	ret := _m.ctrl.Call(_m, "Delete")
 	ret0, _ := ret[0].(error)
@@ -52,6 +62,16 @@ func (_mr *_MockDiskRecorder) Delete() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete")
 }
 
+func (_m *MockDisk) Name() string {
+	ret := _m.ctrl.Call(_m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockDiskRecorder) Name() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Name")
+}
+
 func (_m *MockDisk) NeedsMigration(_param0 int, _param1 property.Map) bool {
 	ret := _m.ctrl.Call(_m, "NeedsMigration", _param0, _param1)
 	ret0, _ := ret[0].(bool)
@@ -62,6 +82,16 @@ func (_mr *_MockDiskRecorder) NeedsMigration(arg0, arg1 interface{}) *gomock.Cal
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "NeedsMigration", arg0, arg1)
 }
 
+func (_m *MockDisk) Resize(_param0 int) error {
+	ret := _m.ctrl.Call(_m, "Resize", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockDiskRecorder) Resize(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Resize", arg0)
+}
+
 // Mock of Manager interface
 type MockManager struct {
 	ctrl     *gomock.Controller
@@ -83,7 +113,7 @@ func (_m *MockManager) EXPECT() *_MockManagerRecorder {
 	return _m.recorder
 }
 
-func (_m *MockManager) Create(_param0 manifest.DiskPool, _param1 string) (disk.Disk, error) {
+func (_m *MockManager) Create(_param0 manifest.PersistentDisk, _param1 string) (disk.Disk, error) {
 	ret := _m.ctrl.Call(_m, "Create", _param0, _param1)
 	ret0, _ := ret[0].(disk.Disk)
 	ret1, _ := ret[1].(error)
@@ -113,12 +113,12 @@ func (i *instance) WaitUntilReady(
 }
 
 func (i *instance) UpdateDisks(deploymentManifest bideplmanifest.Manifest, stage biui.Stage) ([]bidisk.Disk, error) {
-	diskPool, err := deploymentManifest.DiskPool(i.jobName)
+	persistentDisks, err := deploymentManifest.PersistentDisks(i.jobName)
 	if err != nil {
-		return []bidisk.Disk{}, bosherr.WrapError(err, "Getting disk pool")
+		return []bidisk.Disk{}, bosherr.WrapError(err, "Getting persistent disks")
 	}
 
-	disks, err := i.vm.UpdateDisks(diskPool, stage)
+	disks, err := i.vm.UpdateDisks(persistentDisks, stage)
 	if err != nil {
 		return disks, bosherr.WrapError(err, "Updating disks")
 	}
@@ -296,8 +296,13 @@ var _ = Describe("Manager", func() {
 
 			Expect(fakeVM.UpdateDisksInputs).To(Equal([]fakebivm.UpdateDisksInput{
 				{
-					DiskPool: diskPool,
-					Stage:    fakeStage,
+					PersistentDisks: []bideplmanifest.PersistentDisk{
+						{
+							DiskSize:        diskPool.DiskSize,
+							CloudProperties: diskPool.CloudProperties,
+						},
+					},
+					Stage: fakeStage,
 				},
 			}))
 		})
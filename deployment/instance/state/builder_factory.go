@@ -3,11 +3,13 @@ package state
 import (
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bideplrel "github.com/cloudfoundry/bosh-cli/deployment/release"
 	bistatejob "github.com/cloudfoundry/bosh-cli/state/job"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type BuilderFactory interface {
@@ -16,31 +18,43 @@ type BuilderFactory interface {
 
 type builderFactory struct {
 	packageRepo               bistatepkg.CompiledPackageRepo
+	packageCache              bistatepkg.CompiledPackageCache
+	stemcellRepo              biconfig.StemcellRepo
 	releaseJobResolver        bideplrel.JobResolver
 	jobRenderer               bitemplate.JobListRenderer
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor
+	parallel                  int
+	fs                        boshsys.FileSystem
 	logger                    boshlog.Logger
 }
 
 func NewBuilderFactory(
 	packageRepo bistatepkg.CompiledPackageRepo,
+	packageCache bistatepkg.CompiledPackageCache,
+	stemcellRepo biconfig.StemcellRepo,
 	releaseJobResolver bideplrel.JobResolver,
 	jobRenderer bitemplate.JobListRenderer,
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor,
+	parallel int,
+	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) BuilderFactory {
 	return &builderFactory{
 		packageRepo:               packageRepo,
+		packageCache:              packageCache,
+		stemcellRepo:              stemcellRepo,
 		releaseJobResolver:        releaseJobResolver,
 		jobRenderer:               jobRenderer,
 		renderedJobListCompressor: renderedJobListCompressor,
-		logger: logger,
+		parallel:                  parallel,
+		fs:                        fs,
+		logger:                    logger,
 	}
 }
 
 func (f *builderFactory) NewBuilder(blobstore biblobstore.Blobstore, agentClient biagentclient.AgentClient) Builder {
-	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, f.packageRepo)
-	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, f.logger)
+	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, f.packageRepo, f.packageCache, f.stemcellRepo, f.fs, f.logger)
+	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, f.parallel, f.logger)
 
 	return NewBuilder(
 		f.releaseJobResolver,
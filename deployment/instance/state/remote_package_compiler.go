@@ -1,34 +1,66 @@
 package state
 
 import (
+	"fmt"
+
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type remotePackageCompiler struct {
-	blobstore   biblobstore.Blobstore
-	agentClient biagentclient.AgentClient
-	packageRepo bistatepkg.CompiledPackageRepo
+	blobstore    biblobstore.Blobstore
+	agentClient  biagentclient.AgentClient
+	packageRepo  bistatepkg.CompiledPackageRepo
+	packageCache bistatepkg.CompiledPackageCache
+	stemcellRepo biconfig.StemcellRepo
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
+	logTag       string
 }
 
+// NewRemotePackageCompiler compiles packages with the agent; packageCache is optional and
+// is keyed by the current stemcell since packages are compiled on the deployed VM
 func NewRemotePackageCompiler(
 	blobstore biblobstore.Blobstore,
 	agentClient biagentclient.AgentClient,
 	packageRepo bistatepkg.CompiledPackageRepo,
+	packageCache bistatepkg.CompiledPackageCache,
+	stemcellRepo biconfig.StemcellRepo,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
 ) bistatepkg.Compiler {
 	return &remotePackageCompiler{
-		blobstore:   blobstore,
-		agentClient: agentClient,
-		packageRepo: packageRepo,
+		blobstore:    blobstore,
+		agentClient:  agentClient,
+		packageRepo:  packageRepo,
+		packageCache: packageCache,
+		stemcellRepo: stemcellRepo,
+		fs:           fs,
+		logger:       logger,
+		logTag:       "remotePackageCompiler",
 	}
 }
 
 func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
 	var record bistatepkg.CompiledPackageRecord
 
+	stemcell := c.cacheStemcell()
+
+	if !pkg.IsCompiled() && len(stemcell) > 0 {
+		cachedRecord, found, err := c.findInCache(pkg, stemcell)
+		if err != nil {
+			return record, false, err
+		} else if found {
+			return cachedRecord, true, nil
+		}
+	}
+
 	blobID, err := c.blobstore.Add(pkg.ArchivePath())
 	if err != nil {
 		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Adding release package archive '%s' to blobstore", pkg.ArchivePath())
@@ -86,6 +118,10 @@ func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.Com
 			BlobID:   compiledPackageRef.BlobstoreID,
 			BlobSHA1: compiledPackageRef.SHA1,
 		}
+
+		if len(stemcell) > 0 {
+			c.saveToCache(pkg, stemcell, record)
+		}
 	} else {
 		isAlreadyCompiled = true
 
@@ -102,3 +138,92 @@ func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.Com
 
 	return record, isAlreadyCompiled, nil
 }
+
+// cacheStemcell returns stemcell used to key compiled packages or empty string if cache is not used
+func (c *remotePackageCompiler) cacheStemcell() string {
+	if c.packageCache == nil {
+		return ""
+	}
+
+	stemcellRecord, found, err := c.stemcellRepo.FindCurrent()
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to find current stemcell for compiled package cache: %s", err.Error())
+		return ""
+	} else if !found {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s", stemcellRecord.Name, stemcellRecord.Version)
+}
+
+func (c *remotePackageCompiler) findInCache(pkg birelpkg.Compilable, stemcell string) (bistatepkg.CompiledPackageRecord, bool, error) {
+	var record bistatepkg.CompiledPackageRecord
+
+	entry, found, err := c.packageCache.Find(pkg, stemcell)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to find package '%s' in compiled package cache: %s", pkg.Name(), err.Error())
+		return record, false, nil
+	} else if !found {
+		return record, false, nil
+	}
+
+	c.logger.Debug(c.logTag, "Uploading cached compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	tarball, err := c.fs.TempFile("bosh-compiled-package")
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Creating temporary file for cached compiled package")
+	}
+
+	tarballPath := tarball.Name()
+
+	defer func() {
+		if err = c.fs.RemoveAll(tarballPath); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove cached compiled package tarball: %s", err.Error())
+		}
+	}()
+
+	err = tarball.Close()
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Closing temporary file for cached compiled package")
+	}
+
+	err = c.packageCache.Fetch(entry, tarballPath)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to fetch package '%s' from compiled package cache: %s", pkg.Name(), err.Error())
+		return record, false, nil
+	}
+
+	blobID, err := c.blobstore.Add(tarballPath)
+	if err != nil {
+		return record, false, bosherr.WrapErrorf(err, "Adding cached compiled package '%s' to blobstore", pkg.Name())
+	}
+
+	record = bistatepkg.CompiledPackageRecord{
+		BlobID:   blobID,
+		BlobSHA1: entry.SHA1,
+	}
+
+	err = c.packageRepo.Save(pkg, record)
+	if err != nil {
+		return record, false, bosherr.WrapErrorf(err, "Saving compiled package record '%#v' of package '%#v'", record, pkg)
+	}
+
+	return record, true, nil
+}
+
+// saveToCache downloads compiled package from the agent's blobstore;
+// failures are only logged since the package is already compiled
+func (c *remotePackageCompiler) saveToCache(pkg birelpkg.Compilable, stemcell string, record bistatepkg.CompiledPackageRecord) {
+	localBlob, err := c.blobstore.Get(record.BlobID)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to download compiled package '%s' for compiled package cache: %s", pkg.Name(), err.Error())
+		return
+	}
+
+	defer localBlob.DeleteSilently()
+
+	err = c.packageCache.Save(pkg, stemcell, localBlob.Path(), record.BlobSHA1)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to save package '%s' to compiled package cache: %s", pkg.Name(), err.Error())
+	}
+}
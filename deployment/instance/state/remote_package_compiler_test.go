@@ -1,13 +1,24 @@
 package state_test
 
 import (
+	"crypto/sha1"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	mock_agentclient "github.com/cloudfoundry/bosh-cli/agentclient/mocks"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	. "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -28,6 +39,8 @@ var _ = Describe("RemotePackageCompiler", func() {
 
 	var (
 		packageRepo bistatepkg.CompiledPackageRepo
+		fs          *fakesys.FakeFileSystem
+		logger      boshlog.Logger
 
 		mockBlobstore   *mock_blobstore.MockBlobstore
 		mockAgentClient *mock_agentclient.MockAgentClient
@@ -46,7 +59,9 @@ var _ = Describe("RemotePackageCompiler", func() {
 
 		index := biindex.NewInMemoryIndex()
 		packageRepo = bistatepkg.NewCompiledPackageRepo(index)
-		remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo)
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo, nil, nil, fs, logger)
 	})
 
 	Describe("Compile", func() {
@@ -132,6 +147,61 @@ var _ = Describe("RemotePackageCompiler", func() {
 				Expect(record).To(Equal(compiledPackageRecord))
 			})
 
+			Context("when compiled package cache is used", func() {
+				var (
+					packageCache bistatepkg.CompiledPackageCache
+				)
+
+				BeforeEach(func() {
+					packageCache = bistatepkg.NewCompiledPackageCache("/cache", fs, fakecmd.NewFakeCompressor(), fakeclock.NewFakeClock(time.Now()))
+
+					stemcellRepo := fakebiconfig.NewFakeStemcellRepo()
+					stemcellRepo.SetFindCurrentBehavior(biconfig.StemcellRecord{Name: "fake-stemcell", Version: "1"}, true, nil)
+
+					remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo, packageCache, stemcellRepo, fs, logger)
+				})
+
+				It("saves package compiled by the agent to the cache", func() {
+					fs.WriteFileString("/compiled-package", "compiled-content")
+					mockBlobstore.EXPECT().Get("fake-compiled-package-blob-id").Return(biblobstore.NewLocalBlob("/compiled-package", fs, logger), nil)
+
+					_, _, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+
+					entry, found, err := packageCache.Find(pkg, "fake-stemcell/1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(entry.SHA1).To(Equal("fake-compiled-package-sha1"))
+
+					Expect(fs.FileExists("/compiled-package")).To(BeFalse())
+				})
+
+				It("uploads cached package instead of compiling it with the agent", func() {
+					fs.WriteFileString("/cached-package", "compiled-content")
+					cachedSHA1 := fmt.Sprintf("%x", sha1.Sum([]byte("compiled-content")))
+
+					err := packageCache.Save(pkg, "fake-stemcell/1", "/cached-package", cachedSHA1)
+					Expect(err).ToNot(HaveOccurred())
+
+					expectBlobstoreAdd.Times(0)
+					expectAgentCompile.Times(0)
+					mockBlobstore.EXPECT().Add(gomock.Not(archivePath)).Return("fake-cached-blob-id", nil)
+
+					compiledPackageRecord, isAlreadyCompiled, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(isAlreadyCompiled).To(BeTrue())
+					Expect(compiledPackageRecord).To(Equal(bistatepkg.CompiledPackageRecord{
+						BlobID:   "fake-cached-blob-id",
+						BlobSHA1: cachedSHA1,
+					}))
+
+					record, found, err := packageRepo.Find(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(record).To(Equal(compiledPackageRecord))
+				})
+			})
+
 			Context("when the dependencies are not in the repo", func() {
 				BeforeEach(func() {
 					compiledPackages = map[bistatepkg.CompiledPackageRecord]*boshpkg.Package{}
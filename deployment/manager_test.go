@@ -185,9 +185,10 @@ var _ = Describe("Manager", func() {
 
 		JustBeforeEach(func() {
 			diskManagerFactory := bidisk.NewManagerFactory(diskRepo, logger)
-			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger)
+			checkpointRepo := biconfig.NewCheckpointRepo(deploymentStateService)
+			diskDeployer := bivm.NewDiskDeployer(diskManagerFactory, diskRepo, checkpointRepo, logger)
 
-			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
+			vmManagerFactory := bivm.NewManagerFactory(vmRepo, stemcellRepo, checkpointRepo, diskDeployer, fakeUUIDGenerator, fs, logger)
 			sshTunnelFactory := bisshtunnel.NewFactory(logger)
 
 			mockStateBuilderFactory = mock_instance_state.NewMockBuilderFactory(mockCtrl)
@@ -210,7 +211,7 @@ var _ = Describe("Manager", func() {
 
 			BeforeEach(func() {
 				var err error
-				currentDiskRecord, err = diskRepo.Save("fake-disk-cid", 100, nil)
+				currentDiskRecord, err = diskRepo.Save("", "fake-disk-cid", 100, nil)
 				Expect(err).ToNot(HaveOccurred())
 				err = diskRepo.UpdateCurrent(currentDiskRecord.ID)
 				Expect(err).ToNot(HaveOccurred())
@@ -246,7 +247,7 @@ var _ = Describe("Manager", func() {
 
 		Context("orphan disk records exist", func() {
 			BeforeEach(func() {
-				_, err := diskRepo.Save("orphan-disk-cid", 100, nil)
+				_, err := diskRepo.Save("", "orphan-disk-cid", 100, nil)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -9,3 +9,11 @@ type DiskPool struct {
 	DiskSize        int
 	CloudProperties biproperty.Map
 }
+
+// PersistentDisk is a persistent disk of a job with its disk pool resolved.
+// Name is empty for the disk configured by persistent_disk or persistent_disk_pool.
+type PersistentDisk struct {
+	Name            string
+	DiskSize        int
+	CloudProperties biproperty.Map
+}
@@ -12,6 +12,7 @@ type Job struct {
 	Networks           []JobNetwork
 	PersistentDisk     int
 	PersistentDiskPool string
+	PersistentDisks    []JobPersistentDisk
 	ResourcePool       string
 	Properties         biproperty.Map
 }
@@ -23,6 +24,14 @@ const (
 	JobLifecycleErrand  JobLifecycle = "errand"
 )
 
+// JobPersistentDisk is a named persistent disk sized either by
+// DiskSize or by the disk pool referenced by DiskPool
+type JobPersistentDisk struct {
+	Name     string
+	DiskSize int
+	DiskPool string
+}
+
 type ReleaseJobRef struct {
 	Name       string
 	Release    string
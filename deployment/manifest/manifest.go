@@ -102,6 +102,60 @@ func (d Manifest) DiskPool(jobName string) (DiskPool, error) {
 	return DiskPool{}, nil
 }
 
+// PersistentDisks returns all persistent disks of a job. The disk configured
+// by persistent_disk or persistent_disk_pool is returned without a name.
+func (d Manifest) PersistentDisks(jobName string) ([]PersistentDisk, error) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return []PersistentDisk{}, bosherr.Errorf("Could not find job with name: %s", jobName)
+	}
+
+	disks := []PersistentDisk{}
+
+	diskPool, err := d.DiskPool(jobName)
+	if err != nil {
+		return disks, err
+	}
+
+	if diskPool.DiskSize > 0 {
+		disks = append(disks, PersistentDisk{
+			DiskSize:        diskPool.DiskSize,
+			CloudProperties: diskPool.CloudProperties,
+		})
+	}
+
+	for _, jobDisk := range job.PersistentDisks {
+		disk := PersistentDisk{
+			Name:            jobDisk.Name,
+			DiskSize:        jobDisk.DiskSize,
+			CloudProperties: biproperty.Map{},
+		}
+
+		if jobDisk.DiskPool != "" {
+			diskPool, found := d.findDiskPool(jobDisk.DiskPool)
+			if !found {
+				return disks, bosherr.Errorf("Could not find disk pool '%s' for persistent disk '%s' of job '%s'", jobDisk.DiskPool, jobDisk.Name, jobName)
+			}
+
+			disk.DiskSize = diskPool.DiskSize
+			disk.CloudProperties = diskPool.CloudProperties
+		}
+
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+func (d Manifest) findDiskPool(name string) (DiskPool, bool) {
+	for _, diskPool := range d.DiskPools {
+		if diskPool.Name == name {
+			return diskPool, true
+		}
+	}
+	return DiskPool{}, false
+}
+
 func (d Manifest) networkMap() map[string]Network {
 	result := map[string]Network{}
 	for _, network := range d.Networks {
@@ -369,6 +369,80 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("PersistentDisks", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				DiskPools: []DiskPool{
+					{
+						Name:     "fake-disk-pool-name",
+						DiskSize: 2048,
+						CloudProperties: biproperty.Map{
+							"fake-disk-prop-key": "fake-disk-prop-value",
+						},
+					},
+				},
+				Jobs: []Job{
+					{
+						Name: "fake-job-name",
+						PersistentDisks: []JobPersistentDisk{
+							{Name: "fake-db-disk", DiskSize: 1024},
+							{Name: "fake-blobstore-disk", DiskPool: "fake-disk-pool-name"},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns named disks with their disk pools resolved", func() {
+			disks, err := deploymentManifest.PersistentDisks("fake-job-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]PersistentDisk{
+				{
+					Name:            "fake-db-disk",
+					DiskSize:        1024,
+					CloudProperties: biproperty.Map{},
+				},
+				{
+					Name:     "fake-blobstore-disk",
+					DiskSize: 2048,
+					CloudProperties: biproperty.Map{
+						"fake-disk-prop-key": "fake-disk-prop-value",
+					},
+				},
+			}))
+		})
+
+		It("returns unnamed disk for job with persistent_disk", func() {
+			deploymentManifest.Jobs[0].PersistentDisks = nil
+			deploymentManifest.Jobs[0].PersistentDisk = 1024
+
+			disks, err := deploymentManifest.PersistentDisks("fake-job-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]PersistentDisk{
+				{
+					DiskSize:        1024,
+					CloudProperties: biproperty.Map{},
+				},
+			}))
+		})
+
+		It("returns no disks for job without persistent disks", func() {
+			deploymentManifest.Jobs[0].PersistentDisks = nil
+
+			disks, err := deploymentManifest.PersistentDisks("fake-job-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(BeEmpty())
+		})
+
+		It("returns an error when disk pool of a named disk is missing", func() {
+			deploymentManifest.DiskPools = nil
+
+			_, err := deploymentManifest.PersistentDisks("fake-job-name")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Could not find disk pool 'fake-disk-pool-name' for persistent disk 'fake-blobstore-disk' of job 'fake-job-name'"))
+		})
+	})
+
 	Describe("Tags", func() {
 		It("can be referenced", func() {
 			deploymentManifest = Manifest{
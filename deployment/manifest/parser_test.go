@@ -187,6 +187,32 @@ properties:
 			}))
 		})
 
+		Context("when job has named persistent disks", func() {
+			BeforeEach(func() {
+				contents := `
+---
+name: fake-deployment-manifest
+jobs:
+- name: bosh
+  persistent_disks:
+  - name: fake-db-disk
+    disk_size: 1024
+  - name: fake-blobstore-disk
+    disk_pool: fake-disk-pool-name
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses persistent disks", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentManifest.Jobs[0].PersistentDisks).To(Equal([]JobPersistentDisk{
+					{Name: "fake-db-disk", DiskSize: 1024},
+					{Name: "fake-blobstore-disk", DiskPool: "fake-disk-pool-name"},
+				}))
+			})
+		})
+
 		Context("when stemcell url begins with 'http'", func() {
 			BeforeEach(func() {
 				contents := `
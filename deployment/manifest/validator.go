@@ -86,6 +86,7 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk_pool must be the name of a disk pool", idx))
 			}
 		}
+		errs = append(errs, v.validateJobPersistentDisks(job, deploymentManifest, idx)...)
 		if job.Instances < 0 {
 			errs = append(errs, bosherr.Errorf("jobs[%d].instances must be >= 0", idx))
 		}
@@ -186,6 +187,37 @@ func (v *validator) resourcePoolNames(deploymentManifest Manifest) map[string]st
 	return names
 }
 
+func (v *validator) validateJobPersistentDisks(job Job, deploymentManifest Manifest, jobIdx int) []error {
+	errs := []error{}
+
+	if len(job.PersistentDisks) > 0 && (job.PersistentDisk > 0 || job.PersistentDiskPool != "") {
+		errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks must not be used with persistent_disk or persistent_disk_pool", jobIdx))
+	}
+
+	diskNames := map[string]struct{}{}
+	for diskIdx, disk := range job.PersistentDisks {
+		if v.isBlank(disk.Name) {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].name must be provided", jobIdx, diskIdx))
+		} else if _, found := diskNames[disk.Name]; found {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].name '%s' must be unique", jobIdx, diskIdx, disk.Name))
+		}
+		diskNames[disk.Name] = struct{}{}
+
+		if disk.DiskPool != "" {
+			if disk.DiskSize != 0 {
+				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d] must specify only one of disk_size or disk_pool", jobIdx, diskIdx))
+			}
+			if _, ok := v.diskPoolNames(deploymentManifest)[disk.DiskPool]; !ok {
+				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].disk_pool must be the name of a disk pool", jobIdx, diskIdx))
+			}
+		} else if disk.DiskSize <= 0 {
+			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disks[%d].disk_size must be > 0", jobIdx, diskIdx))
+		}
+	}
+
+	return errs
+}
+
 func (v *validator) isValidIP(ip string) bool {
 	parsedIP := net.ParseIP(ip)
 	return parsedIP != nil
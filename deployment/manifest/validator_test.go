@@ -647,6 +647,36 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disk_pool must be the name of a disk pool"))
 		})
 
+		It("validates job persistent_disks", func() {
+			deploymentManifest := Manifest{
+				Jobs: []Job{
+					{
+						PersistentDisk: 1024,
+						PersistentDisks: []JobPersistentDisk{
+							{Name: "", DiskSize: 1024},
+							{Name: "fake-disk", DiskSize: 0},
+							{Name: "fake-disk", DiskPool: "non-existent-disk-pool"},
+							{Name: "fake-other-disk", DiskSize: 1024, DiskPool: "fake-disk-pool"},
+						},
+					},
+				},
+				DiskPools: []DiskPool{
+					{
+						Name: "fake-disk-pool",
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks must not be used with persistent_disk or persistent_disk_pool"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[0].name must be provided"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[1].disk_size must be > 0"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[2].name 'fake-disk' must be unique"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[2].disk_pool must be the name of a disk pool"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disks[3] must specify only one of disk_size or disk_pool"))
+		})
+
 		It("validates job resource pool is provided", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{{}},
@@ -0,0 +1,393 @@
+package deployment
+
+import (
+	"reflect"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	birel "github.com/cloudfoundry/bosh-cli/release"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+)
+
+const (
+	PlanActionNone     = "none"
+	PlanActionKeep     = "keep"
+	PlanActionCreate   = "create"
+	PlanActionRecreate = "recreate"
+	PlanActionMigrate  = "migrate"
+
+	// PlanActionResize falls back to migration when CPI does not implement resize_disk
+	PlanActionResize = "resize"
+
+	// PlanActionUnsupported is planned for named disk changes that would require migration
+	PlanActionUnsupported = "unsupported"
+
+	PlanReleaseAdded     = "added"
+	PlanReleaseUpdated   = "updated"
+	PlanReleaseRemoved   = "removed"
+	PlanReleaseUnchanged = "unchanged"
+)
+
+// Plan describes what create-env would change without talking to the CPI
+type Plan struct {
+	// Deploy is false when nothing changed and deploy will be skipped
+	Deploy bool
+
+	VM       PlanVM
+	Disks    []PlanDisk
+	Stemcell PlanStemcell
+	Releases []PlanRelease
+}
+
+type PlanVM struct {
+	Action  string
+	CID     string
+	Reasons []string
+}
+
+type PlanDisk struct {
+	// Name is empty for the disk configured by persistent_disk or persistent_disk_pool
+	Name   string
+	Action string
+	CID    string
+
+	OldSize int
+	NewSize int
+
+	CloudPropertiesChanged bool
+}
+
+type PlanStemcell struct {
+	Name    string
+	Version string
+	Upload  bool
+}
+
+type PlanRelease struct {
+	Name            string
+	Version         string
+	PreviousVersion string
+	Change          string
+
+	// Packages lists packages that will be compiled on the VM
+	Packages []string
+}
+
+type Planner struct{}
+
+func NewPlanner() Planner {
+	return Planner{}
+}
+
+func (p Planner) Plan(
+	state biconfig.DeploymentState,
+	manifest bideplmanifest.Manifest,
+	manifestSHA string,
+	releases []birel.Release,
+	stemcell bistemcell.ExtractedStemcell,
+	recreate bool,
+) (Plan, error) {
+	var plan Plan
+
+	plan.Stemcell = p.stemcell(state, stemcell)
+	plan.VM = p.vm(state, manifestSHA, releases, stemcell, recreate)
+	plan.Deploy = plan.VM.Action != PlanActionKeep
+
+	disks, err := p.disks(state, manifest, plan.Deploy)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	plan.Disks = disks
+
+	plan.Releases, err = p.releases(state, manifest, releases, plan.Deploy)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	if !plan.Deploy {
+		plan.Stemcell.Upload = false
+	}
+
+	return plan, nil
+}
+
+func (p Planner) stemcell(state biconfig.DeploymentState, stemcell bistemcell.ExtractedStemcell) PlanStemcell {
+	manifest := stemcell.Manifest()
+
+	result := PlanStemcell{Name: manifest.Name, Version: manifest.Version, Upload: true}
+
+	for _, rec := range state.Stemcells {
+		if rec.Name == manifest.Name && rec.Version == manifest.Version {
+			result.Upload = false
+		}
+	}
+
+	return result
+}
+
+// vm mirrors Record.IsDeployed to decide whether VM will be (re)created
+func (p Planner) vm(
+	state biconfig.DeploymentState,
+	manifestSHA string,
+	releases []birel.Release,
+	stemcell bistemcell.ExtractedStemcell,
+	recreate bool,
+) PlanVM {
+	result := PlanVM{CID: state.CurrentVMCID}
+
+	if len(state.CurrentManifestSHA) == 0 || state.CurrentManifestSHA != manifestSHA {
+		result.Reasons = append(result.Reasons, "manifest changed")
+	}
+
+	currentStemcell, found := p.findStemcell(state, state.CurrentStemcellID)
+	if !found || currentStemcell.Name != stemcell.Manifest().Name || currentStemcell.Version != stemcell.Manifest().Version {
+		result.Reasons = append(result.Reasons, "stemcell changed")
+	}
+
+	if p.releasesChanged(state, releases) {
+		result.Reasons = append(result.Reasons, "releases changed")
+	}
+
+	if recreate {
+		result.Reasons = append(result.Reasons, "recreate requested")
+	}
+
+	switch {
+	case len(result.Reasons) == 0:
+		result.Action = PlanActionKeep
+	case len(state.CurrentVMCID) == 0:
+		result.Action = PlanActionCreate
+	default:
+		result.Action = PlanActionRecreate
+	}
+
+	return result
+}
+
+// disks mirrors vm.DiskDeployer decisions
+func (p Planner) disks(state biconfig.DeploymentState, manifest bideplmanifest.Manifest, deploy bool) ([]PlanDisk, error) {
+	persistentDisks, err := manifest.PersistentDisks(manifest.JobName())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding persistent disks")
+	}
+
+	var result []PlanDisk
+
+	desiredNames := map[string]struct{}{}
+
+	for _, persistentDisk := range persistentDisks {
+		desiredNames[persistentDisk.Name] = struct{}{}
+		result = append(result, p.disk(state, persistentDisk, deploy))
+	}
+
+	// current disks that are no longer in the manifest are left in place
+	for _, rec := range state.Disks {
+		if _, found := desiredNames[rec.Name]; !found && state.IsCurrentDisk(rec.ID) {
+			result = append(result, PlanDisk{Name: rec.Name, Action: PlanActionKeep, CID: rec.CID, OldSize: rec.Size})
+		}
+	}
+
+	return result, nil
+}
+
+func (p Planner) disk(state biconfig.DeploymentState, persistentDisk bideplmanifest.PersistentDisk, deploy bool) PlanDisk {
+	result := PlanDisk{Name: persistentDisk.Name, Action: PlanActionNone, NewSize: persistentDisk.DiskSize}
+
+	currentDisk, found := p.findCurrentDisk(state, persistentDisk.Name)
+	if found {
+		result.CID = currentDisk.CID
+		result.OldSize = currentDisk.Size
+		result.Action = PlanActionKeep
+	}
+
+	if !deploy {
+		return result
+	}
+
+	if !found {
+		result.Action = PlanActionCreate
+		return result
+	}
+
+	cloudPropsChanged := !reflect.DeepEqual(currentDisk.CloudProperties, persistentDisk.CloudProperties)
+
+	switch {
+	case currentDisk.Size < persistentDisk.DiskSize && !cloudPropsChanged:
+		result.Action = PlanActionResize
+	case currentDisk.Size != persistentDisk.DiskSize || cloudPropsChanged:
+		result.Action = PlanActionMigrate
+		result.CloudPropertiesChanged = cloudPropsChanged
+
+		// named disks are never migrated since agent does not manage their content
+		if persistentDisk.Name != "" {
+			result.Action = PlanActionUnsupported
+		}
+	}
+
+	return result
+}
+
+func (p Planner) releases(
+	state biconfig.DeploymentState,
+	manifest bideplmanifest.Manifest,
+	releases []birel.Release,
+	deploy bool,
+) ([]PlanRelease, error) {
+	var result []PlanRelease
+
+	job, _ := manifest.FindJobByName(manifest.JobName())
+
+	for _, release := range releases {
+		planRel := PlanRelease{
+			Name:    release.Name(),
+			Version: release.Version(),
+			Change:  PlanReleaseAdded,
+		}
+
+		for _, rec := range state.Releases {
+			if rec.Name == release.Name() {
+				planRel.PreviousVersion = rec.Version
+
+				if rec.Version == release.Version() {
+					planRel.Change = PlanReleaseUnchanged
+				} else {
+					planRel.Change = PlanReleaseUpdated
+				}
+			}
+		}
+
+		if deploy {
+			pkgs, err := p.packagesToCompile(job, release)
+			if err != nil {
+				return nil, err
+			}
+
+			planRel.Packages = pkgs
+		}
+
+		result = append(result, planRel)
+	}
+
+	for _, rec := range state.Releases {
+		var found bool
+
+		for _, release := range releases {
+			if rec.Name == release.Name() {
+				found = true
+			}
+		}
+
+		if !found {
+			result = append(result, PlanRelease{
+				Name:            rec.Name,
+				PreviousVersion: rec.Version,
+				Change:          PlanReleaseRemoved,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// packagesToCompile includes all dependencies of release jobs used by the manifest
+// since compiled packages are not kept between create-env runs
+func (p Planner) packagesToCompile(job bideplmanifest.Job, release birel.Release) ([]string, error) {
+	names := map[string]struct{}{}
+
+	var collect func([]birelpkg.Compilable)
+
+	collect = func(pkgs []birelpkg.Compilable) {
+		for _, pkg := range pkgs {
+			if pkg.IsCompiled() {
+				continue
+			}
+
+			if _, found := names[pkg.Name()]; found {
+				continue
+			}
+
+			names[pkg.Name()] = struct{}{}
+
+			collect(pkg.Deps())
+		}
+	}
+
+	for _, jobRef := range job.Templates {
+		if jobRef.Release != release.Name() {
+			continue
+		}
+
+		relJob, found := release.FindJobByName(jobRef.Name)
+		if !found {
+			return nil, bosherr.Errorf("Finding job '%s' in release '%s'", jobRef.Name, release.Name())
+		}
+
+		collect(relJob.Packages)
+	}
+
+	var result []string
+
+	for name := range names {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+func (p Planner) releasesChanged(state biconfig.DeploymentState, releases []birel.Release) bool {
+	if len(state.Releases) == 0 || len(state.Releases) != len(releases) {
+		return true
+	}
+
+	for _, release := range releases {
+		var found bool
+
+		for _, rec := range state.Releases {
+			if rec.Name == release.Name() && rec.Version == release.Version() {
+				found = true
+			}
+		}
+
+		if !found {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Planner) findStemcell(state biconfig.DeploymentState, id string) (biconfig.StemcellRecord, bool) {
+	for _, rec := range state.Stemcells {
+		if rec.ID == id {
+			return rec, true
+		}
+	}
+
+	return biconfig.StemcellRecord{}, false
+}
+
+func (p Planner) findCurrentDisk(state biconfig.DeploymentState, name string) (biconfig.DiskRecord, bool) {
+	id := state.CurrentDiskID
+	if name != "" {
+		id = state.CurrentDiskIDs[name]
+	}
+
+	if id == "" {
+		return biconfig.DiskRecord{}, false
+	}
+
+	for _, rec := range state.Disks {
+		if rec.ID == id {
+			return rec, true
+		}
+	}
+
+	return biconfig.DiskRecord{}, false
+}
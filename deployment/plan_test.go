@@ -0,0 +1,201 @@
+package deployment_test
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/cloudfoundry/bosh-cli/deployment"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	bireljob "github.com/cloudfoundry/bosh-cli/release/job"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	fakerelpkg "github.com/cloudfoundry/bosh-cli/release/pkg/pkgfakes"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+)
+
+var _ = Describe("Planner", func() {
+	var (
+		planner  Planner
+		state    biconfig.DeploymentState
+		manifest bideplmanifest.Manifest
+		release  *fakerel.FakeRelease
+		stemcell bistemcell.ExtractedStemcell
+	)
+
+	BeforeEach(func() {
+		planner = NewPlanner()
+
+		state = biconfig.DeploymentState{
+			CurrentVMCID:       "vm-cid",
+			CurrentManifestSHA: "sha",
+			CurrentStemcellID:  "stemcell-id",
+			CurrentDiskID:      "disk-id",
+			Stemcells:          []biconfig.StemcellRecord{{ID: "stemcell-id", Name: "stemcell", Version: "1"}},
+			Disks:              []biconfig.DiskRecord{{ID: "disk-id", CID: "disk-cid", Size: 1024, CloudProperties: biproperty.Map{}}},
+			Releases:           []biconfig.ReleaseRecord{{ID: "rel-id", Name: "rel", Version: "1"}},
+		}
+
+		manifest = bideplmanifest.Manifest{
+			Jobs: []bideplmanifest.Job{{
+				Name:           "bosh",
+				PersistentDisk: 1024,
+				Templates:      []bideplmanifest.ReleaseJobRef{{Name: "job", Release: "rel"}},
+			}},
+		}
+
+		depPkg := &fakerelpkg.FakeCompilable{}
+		depPkg.NameReturns("dep-pkg")
+
+		pkg := &fakerelpkg.FakeCompilable{}
+		pkg.NameReturns("pkg")
+		pkg.DepsReturns([]birelpkg.Compilable{depPkg})
+
+		release = &fakerel.FakeRelease{}
+		release.NameReturns("rel")
+		release.VersionReturns("1")
+		release.FindJobByNameReturns(bireljob.Job{Packages: []birelpkg.Compilable{pkg}}, true)
+
+		stemcell = bistemcell.NewExtractedStemcell(
+			bistemcell.Manifest{Name: "stemcell", Version: "1"}, "/path", nil, fakesys.NewFakeFileSystem())
+	})
+
+	act := func(manifestSHA string, recreate bool) Plan {
+		plan, err := planner.Plan(state, manifest, manifestSHA, []boshrel.Release{release}, stemcell, recreate)
+		Expect(err).ToNot(HaveOccurred())
+		return plan
+	}
+
+	It("skips deploy when nothing changed", func() {
+		plan := act("sha", false)
+
+		Expect(plan.Deploy).To(BeFalse())
+		Expect(plan.VM).To(Equal(PlanVM{Action: PlanActionKeep, CID: "vm-cid"}))
+		Expect(plan.Disks).To(Equal([]PlanDisk{{Action: PlanActionKeep, CID: "disk-cid", OldSize: 1024, NewSize: 1024}}))
+		Expect(plan.Stemcell.Upload).To(BeFalse())
+		Expect(plan.Releases).To(Equal([]PlanRelease{
+			{Name: "rel", Version: "1", PreviousVersion: "1", Change: PlanReleaseUnchanged},
+		}))
+	})
+
+	It("recreates VM and compiles all packages when recreate is requested", func() {
+		plan := act("sha", true)
+
+		Expect(plan.Deploy).To(BeTrue())
+		Expect(plan.VM.Action).To(Equal(PlanActionRecreate))
+		Expect(plan.VM.Reasons).To(Equal([]string{"recreate requested"}))
+		Expect(plan.Releases[0].Packages).To(Equal([]string{"dep-pkg", "pkg"}))
+	})
+
+	It("creates VM, disk and uploads stemcell for empty state", func() {
+		plan, err := planner.Plan(biconfig.DeploymentState{}, manifest, "sha", []boshrel.Release{release}, stemcell, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(plan.VM.Action).To(Equal(PlanActionCreate))
+		Expect(plan.Disks).To(Equal([]PlanDisk{{Action: PlanActionCreate, NewSize: 1024}}))
+		Expect(plan.Stemcell).To(Equal(PlanStemcell{Name: "stemcell", Version: "1", Upload: true}))
+		Expect(plan.Releases[0].Change).To(Equal(PlanReleaseAdded))
+	})
+
+	It("reports manifest, stemcell and release changes", func() {
+		stemcell = bistemcell.NewExtractedStemcell(
+			bistemcell.Manifest{Name: "stemcell", Version: "2"}, "/path", nil, fakesys.NewFakeFileSystem())
+		release.VersionReturns("2")
+		state.Releases = append(state.Releases, biconfig.ReleaseRecord{Name: "old-rel", Version: "3"})
+
+		plan := act("new-sha", false)
+
+		Expect(plan.VM.Action).To(Equal(PlanActionRecreate))
+		Expect(plan.VM.Reasons).To(Equal([]string{"manifest changed", "stemcell changed", "releases changed"}))
+		Expect(plan.Stemcell.Upload).To(BeTrue())
+		Expect(plan.Releases).To(Equal([]PlanRelease{
+			{Name: "rel", Version: "2", PreviousVersion: "1", Change: PlanReleaseUpdated, Packages: []string{"dep-pkg", "pkg"}},
+			{Name: "old-rel", PreviousVersion: "3", Change: PlanReleaseRemoved},
+		}))
+	})
+
+	It("reports disk migration when size or cloud properties change", func() {
+		manifest.Jobs[0].PersistentDisk = 0
+		manifest.Jobs[0].PersistentDiskPool = "disks"
+		manifest.DiskPools = []bideplmanifest.DiskPool{{
+			Name:            "disks",
+			DiskSize:        2048,
+			CloudProperties: biproperty.Map{"type": "ssd"},
+		}}
+
+		plan := act("new-sha", false)
+
+		Expect(plan.Disks).To(Equal([]PlanDisk{{
+			Action:                 PlanActionMigrate,
+			CID:                    "disk-cid",
+			OldSize:                1024,
+			NewSize:                2048,
+			CloudPropertiesChanged: true,
+		}}))
+	})
+
+	It("reports disk resize when only size grows", func() {
+		manifest.Jobs[0].PersistentDisk = 2048
+
+		plan := act("new-sha", false)
+
+		Expect(plan.Disks).To(Equal([]PlanDisk{
+			{Action: PlanActionResize, CID: "disk-cid", OldSize: 1024, NewSize: 2048},
+		}))
+	})
+
+	It("plans named disks and keeps current disks that are no longer in the manifest", func() {
+		state.CurrentDiskIDs = map[string]string{"db": "db-disk-id"}
+		state.Disks = append(state.Disks,
+			biconfig.DiskRecord{ID: "db-disk-id", Name: "db", CID: "db-disk-cid", Size: 1024, CloudProperties: biproperty.Map{}})
+
+		manifest.Jobs[0].PersistentDisk = 0
+		manifest.Jobs[0].PersistentDisks = []bideplmanifest.JobPersistentDisk{
+			{Name: "db", DiskSize: 1024},
+			{Name: "blobstore", DiskSize: 4096},
+		}
+
+		plan := act("new-sha", false)
+
+		Expect(plan.Disks).To(Equal([]PlanDisk{
+			{Name: "db", Action: PlanActionKeep, CID: "db-disk-cid", OldSize: 1024, NewSize: 1024},
+			{Name: "blobstore", Action: PlanActionCreate, NewSize: 4096},
+			{Action: PlanActionKeep, CID: "disk-cid", OldSize: 1024},
+		}))
+	})
+
+	It("reports named disk changes other than growing in size as unsupported", func() {
+		state.CurrentDiskIDs = map[string]string{"db": "db-disk-id"}
+		state.Disks = append(state.Disks,
+			biconfig.DiskRecord{ID: "db-disk-id", Name: "db", CID: "db-disk-cid", Size: 2048, CloudProperties: biproperty.Map{}})
+
+		manifest.Jobs[0].PersistentDisk = 0
+		manifest.Jobs[0].PersistentDisks = []bideplmanifest.JobPersistentDisk{{Name: "db", DiskSize: 1024}}
+
+		plan := act("new-sha", false)
+
+		Expect(plan.Disks[0]).To(Equal(
+			PlanDisk{Name: "db", Action: PlanActionUnsupported, CID: "db-disk-cid", OldSize: 2048, NewSize: 1024}))
+	})
+
+	It("skips compiled packages", func() {
+		compiledPkg := &fakerelpkg.FakeCompilable{}
+		compiledPkg.NameReturns("compiled-pkg")
+		compiledPkg.IsCompiledReturns(true)
+		release.FindJobByNameReturns(bireljob.Job{Packages: []birelpkg.Compilable{compiledPkg}}, true)
+
+		plan := act("new-sha", false)
+		Expect(plan.Releases[0].Packages).To(BeEmpty())
+	})
+
+	It("returns error if job cannot be found in release", func() {
+		release.FindJobByNameReturns(bireljob.Job{}, false)
+
+		_, err := planner.Plan(state, manifest, "new-sha", []boshrel.Release{release}, stemcell, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Finding job 'job' in release 'rel'"))
+	})
+})
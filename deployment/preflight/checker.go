@@ -0,0 +1,211 @@
+package preflight
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// Checker verifies that the installed CPI, the stemcell and the manifests
+// fit together before create-env uploads stemcells, creates VMs or compiles packages.
+// Static IPs and tarball sources are already checked while validating manifests.
+type Checker interface {
+	Check(
+		cloud bicloud.Cloud,
+		installationManifest biinstallmanifest.Manifest,
+		deploymentManifest bideplmanifest.Manifest,
+		extractedStemcell bistemcell.ExtractedStemcell,
+		stage biui.Stage,
+	) error
+}
+
+// HostLookup resolves host name to its addresses (e.g. net.LookupHost)
+type HostLookup func(host string) ([]string, error)
+
+type checker struct {
+	lookupHost HostLookup
+	logger     boshlog.Logger
+	logTag     string
+}
+
+func NewChecker(lookupHost HostLookup, logger boshlog.Logger) Checker {
+	return checker{
+		lookupHost: lookupHost,
+		logger:     logger,
+		logTag:     "preflightChecker",
+	}
+}
+
+// Check runs all checks even if some of them fail so that all problems are reported at once
+func (c checker) Check(
+	cloud bicloud.Cloud,
+	installationManifest biinstallmanifest.Manifest,
+	deploymentManifest bideplmanifest.Manifest,
+	extractedStemcell bistemcell.ExtractedStemcell,
+	stage biui.Stage,
+) error {
+	var errs []error
+
+	var cpiInfo *bicloud.CPIInfo
+
+	err := stage.Perform("Checking CPI info", func() error {
+		info, err := cloud.Info()
+		if err != nil {
+			cloudErr, ok := err.(bicloud.Error)
+			if ok && cloudErr.Type() == bicloud.NotImplementedError {
+				return biui.NewSkipStageError(cloudErr, "CPI does not support 'info'")
+			}
+
+			return bosherr.WrapError(err, "Getting CPI info")
+		}
+
+		cpiInfo = &info
+
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// stemcell compatibility is unknown without CPI info
+	if cpiInfo != nil {
+		stemcellManifest := extractedStemcell.Manifest()
+
+		stageName := fmt.Sprintf("Checking stemcell '%s/%s' is supported by CPI", stemcellManifest.Name, stemcellManifest.Version)
+		err = stage.Perform(stageName, func() error {
+			return c.checkStemcell(stemcellManifest, *cpiInfo)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	staticIPs := c.staticIPs(deploymentManifest)
+
+	mbusURL, err := url.Parse(installationManifest.Mbus)
+	if err != nil {
+		errs = append(errs, bosherr.WrapErrorf(err, "Parsing mbus URL '%s'", installationManifest.Mbus))
+	} else {
+		err = stage.Perform(fmt.Sprintf("Checking mbus host '%s' is reachable", mbusURL.Hostname()), func() error {
+			return c.checkHost(mbusURL.Hostname(), staticIPs)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// agent connects back to registry through SSH tunnel to the VM
+	sshTunnel := installationManifest.Registry.SSHTunnel
+	if sshTunnel.Host != "" {
+		err = stage.Perform(fmt.Sprintf("Checking SSH tunnel host '%s' is reachable", sshTunnel.Host), func() error {
+			return c.checkHost(sshTunnel.Host, staticIPs)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapError(bosherr.NewMultiError(errs...), "Pre-flight checks failed")
+	}
+
+	return nil
+}
+
+// checkStemcell does not compare API versions since the lower of stemcell and CPI API versions is used
+func (c checker) checkStemcell(stemcellManifest bistemcell.Manifest, cpiInfo bicloud.CPIInfo) error {
+	// CPIs that do not report stemcell formats accept any stemcell
+	if len(cpiInfo.StemcellFormats) == 0 {
+		return nil
+	}
+
+	if len(stemcellManifest.StemcellFormats) > 0 {
+		for _, format := range stemcellManifest.StemcellFormats {
+			for _, cpiFormat := range cpiInfo.StemcellFormats {
+				if format == cpiFormat {
+					return nil
+				}
+			}
+		}
+
+		return bosherr.Errorf("Stemcell formats '%s' are not supported by CPI (supported formats: '%s')",
+			strings.Join(stemcellManifest.StemcellFormats, "', '"), strings.Join(cpiInfo.StemcellFormats, "', '"))
+	}
+
+	// older stemcells only specify infrastructure
+	infrastructure, _ := stemcellManifest.CloudProperties["infrastructure"].(string)
+	if infrastructure == "" {
+		return nil
+	}
+
+	for _, cpiFormat := range cpiInfo.StemcellFormats {
+		if cpiFormat == infrastructure || strings.HasPrefix(cpiFormat, infrastructure+"-") {
+			return nil
+		}
+	}
+
+	return bosherr.Errorf("Stemcell infrastructure '%s' is not supported by CPI (supported formats: '%s')",
+		infrastructure, strings.Join(cpiInfo.StemcellFormats, "', '"))
+}
+
+// checkHost makes sure that host will be reachable once the VM is created:
+// host names have to resolve and IPs are expected to be assigned to the VM.
+// IaaS may assign other IPs (e.g. elastic IPs or NAT) so those only result in a warning
+func (c checker) checkHost(host string, staticIPs []string) error {
+	if host == "" {
+		return bosherr.Error("Host must be specified")
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		addrs, err := c.lookupHost(host)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Resolving host '%s'", host)
+		}
+
+		c.logger.Debug(c.logTag, "Resolved host '%s' to '%s'", host, strings.Join(addrs, "', '"))
+
+		return nil
+	}
+
+	// local CPI runs agent on this machine
+	if ip.IsLoopback() {
+		return nil
+	}
+
+	for _, staticIP := range staticIPs {
+		if ip.Equal(net.ParseIP(staticIP)) {
+			return nil
+		}
+	}
+
+	return biui.NewSkipStageError(
+		bosherr.Errorf("IP '%s' is not one of static IPs '%s' assigned to the VM", host, strings.Join(staticIPs, "', '")),
+		fmt.Sprintf("Warning: IP '%s' is not one of static IPs assigned to the VM", host),
+	)
+}
+
+// staticIPs returns IPs that will be assigned to the VM of the only job
+func (c checker) staticIPs(deploymentManifest bideplmanifest.Manifest) []string {
+	var staticIPs []string
+
+	if len(deploymentManifest.Jobs) == 0 {
+		return staticIPs
+	}
+
+	for _, jobNetwork := range deploymentManifest.Jobs[0].Networks {
+		staticIPs = append(staticIPs, jobNetwork.StaticIPs...)
+	}
+
+	return staticIPs
+}
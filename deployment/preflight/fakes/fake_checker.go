@@ -0,0 +1,46 @@
+package fakes
+
+import (
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type FakeChecker struct {
+	CheckInputs []CheckInput
+	CheckErr    error
+}
+
+type CheckInput struct {
+	Cloud                bicloud.Cloud
+	InstallationManifest biinstallmanifest.Manifest
+	DeploymentManifest   bideplmanifest.Manifest
+	Stemcell             bistemcell.ExtractedStemcell
+	Stage                biui.Stage
+}
+
+func NewFakeChecker() *FakeChecker {
+	return &FakeChecker{
+		CheckInputs: []CheckInput{},
+	}
+}
+
+func (c *FakeChecker) Check(
+	cloud bicloud.Cloud,
+	installationManifest biinstallmanifest.Manifest,
+	deploymentManifest bideplmanifest.Manifest,
+	extractedStemcell bistemcell.ExtractedStemcell,
+	stage biui.Stage,
+) error {
+	c.CheckInputs = append(c.CheckInputs, CheckInput{
+		Cloud:                cloud,
+		InstallationManifest: installationManifest,
+		DeploymentManifest:   deploymentManifest,
+		Stemcell:             extractedStemcell,
+		Stage:                stage,
+	})
+
+	return c.CheckErr
+}
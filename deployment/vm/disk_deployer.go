@@ -14,51 +14,67 @@ import (
 
 // DiskDeployer is in the vm package to avoid a [disk -> vm -> disk] dependency cycle
 type DiskDeployer interface {
-	Deploy(diskPool bideplmanifest.DiskPool, cloud bicloud.Cloud, vm VM, eventLoggerStage biui.Stage) ([]bidisk.Disk, error)
+	Deploy(persistentDisks []bideplmanifest.PersistentDisk, cloud bicloud.Cloud, vm VM, eventLoggerStage biui.Stage) ([]bidisk.Disk, error)
 }
 
 type diskDeployer struct {
 	diskRepo           biconfig.DiskRepo
+	checkpointRepo     biconfig.CheckpointRepo
 	diskManagerFactory bidisk.ManagerFactory
 	diskManager        bidisk.Manager
 	logger             boshlog.Logger
 	logTag             string
 }
 
-func NewDiskDeployer(diskManagerFactory bidisk.ManagerFactory, diskRepo biconfig.DiskRepo, logger boshlog.Logger) DiskDeployer {
+func NewDiskDeployer(
+	diskManagerFactory bidisk.ManagerFactory,
+	diskRepo biconfig.DiskRepo,
+	checkpointRepo biconfig.CheckpointRepo,
+	logger boshlog.Logger,
+) DiskDeployer {
 	return &diskDeployer{
 		diskManagerFactory: diskManagerFactory,
 		diskRepo:           diskRepo,
+		checkpointRepo:     checkpointRepo,
 		logger:             logger,
 		logTag:             "diskDeployer",
 	}
 }
 
-func (d *diskDeployer) Deploy(diskPool bideplmanifest.DiskPool, cloud bicloud.Cloud, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
-	if diskPool.DiskSize == 0 {
+func (d *diskDeployer) Deploy(persistentDisks []bideplmanifest.PersistentDisk, cloud bicloud.Cloud, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
+	desiredDisks := []bideplmanifest.PersistentDisk{}
+	for _, persistentDisk := range persistentDisks {
+		if persistentDisk.DiskSize > 0 {
+			desiredDisks = append(desiredDisks, persistentDisk)
+		}
+	}
+
+	if len(desiredDisks) == 0 {
 		return []bidisk.Disk{}, nil
 	}
 
 	d.diskManager = d.diskManagerFactory.NewManager(cloud)
-	disks, err := d.diskManager.FindCurrent()
+	currentDisks, err := d.diskManager.FindCurrent()
 	if err != nil {
-		return disks, bosherr.WrapError(err, "Finding existing disk")
+		return []bidisk.Disk{}, bosherr.WrapError(err, "Finding existing disk")
 	}
 
-	if len(disks) > 1 {
-		return disks, bosherr.WrapError(err, "Multiple current disks not supported")
+	disks := []bidisk.Disk{}
+
+	for _, persistentDisk := range desiredDisks {
+		var disk bidisk.Disk
 
-	} else if len(disks) == 1 {
-		disks, err = d.deployExistingDisk(disks[0], diskPool, vm, stage)
-		if err != nil {
-			return disks, err
+		currentDisk, found := d.findDiskByName(currentDisks, persistentDisk.Name)
+		if found {
+			disk, err = d.deployExistingDisk(currentDisk, persistentDisk, vm, stage)
+		} else {
+			disk, err = d.deployNewDisk(persistentDisk, vm, stage)
 		}
-
-	} else {
-		disks, err = d.deployNewDisk(diskPool, vm, stage)
 		if err != nil {
 			return disks, err
 		}
+
+		disks = append(disks, disk)
 	}
 
 	err = d.diskManager.DeleteUnused(stage)
@@ -69,67 +85,166 @@ func (d *diskDeployer) Deploy(diskPool bideplmanifest.DiskPool, cloud bicloud.Cl
 	return disks, nil
 }
 
-func (d *diskDeployer) deployExistingDisk(disk bidisk.Disk, diskPool bideplmanifest.DiskPool, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
-	disks := []bidisk.Disk{}
+func (d *diskDeployer) findDiskByName(disks []bidisk.Disk, name string) (bidisk.Disk, bool) {
+	for _, disk := range disks {
+		if disk.Name() == name {
+			return disk, true
+		}
+	}
+	return nil, false
+}
 
+func (d *diskDeployer) deployExistingDisk(disk bidisk.Disk, persistentDisk bideplmanifest.PersistentDisk, vm VM, stage biui.Stage) (bidisk.Disk, error) {
 	// the disk is already part of the deployment, and should already be attached
-	disks = append(disks, disk)
-
 	// attach is idempotent
 	err := d.attachDisk(disk, vm, stage)
 	if err != nil {
-		return disks, err
+		return disk, err
 	}
 
-	if disk.NeedsMigration(diskPool.DiskSize, diskPool.CloudProperties) {
-		disk, err = d.migrateDisk(disk, diskPool, vm, stage)
-		if err != nil {
-			return disks, err
+	if !disk.NeedsMigration(persistentDisk.DiskSize, persistentDisk.CloudProperties) {
+		return disk, nil
+	}
+
+	if disk.Name() != "" {
+		return disk, d.resizeNamedDisk(disk, persistentDisk, vm, stage)
+	}
+
+	if disk.CanResize(persistentDisk.DiskSize, persistentDisk.CloudProperties) {
+		resized, err := d.resizeDisk(disk, persistentDisk, vm, stage)
+		if err != nil || resized {
+			return disk, err
 		}
+	}
 
-		// after migration, only the new disk is part of the deployment
-		disks[0] = disk
+	newDisk, err := d.migrateDisk(disk, persistentDisk, vm, stage)
+	if err != nil {
+		return disk, err
 	}
 
-	return disks, nil
+	// after migration, only the new disk is part of the deployment
+	return newDisk, nil
 }
 
-func (d *diskDeployer) deployNewDisk(diskPool bideplmanifest.DiskPool, vm VM, stage biui.Stage) ([]bidisk.Disk, error) {
-	disks := []bidisk.Disk{}
-
-	disk, err := d.createDisk(diskPool, vm, stage)
+func (d *diskDeployer) deployNewDisk(persistentDisk bideplmanifest.PersistentDisk, vm VM, stage biui.Stage) (bidisk.Disk, error) {
+	disk, err := d.createOrResumeDisk(persistentDisk, vm, stage)
 	if err != nil {
-		return disks, err
+		return disk, err
 	}
 
 	err = d.attachDisk(disk, vm, stage)
 	if err != nil {
-		return disks, err
+		return disk, err
 	}
 
 	// once attached, the disk is part of the deployment
-	disks = append(disks, disk)
-
 	err = d.updateCurrentDiskRecord(disk)
 	if err != nil {
-		return disks, err
+		return disk, err
 	}
 
-	return disks, nil
+	err = d.checkpointRepo.ClearPendingDisk()
+	if err != nil {
+		return disk, bosherr.WrapError(err, "Clearing pending disk checkpoint")
+	}
+
+	return disk, nil
+}
+
+// resizeNamedDisk only grows named disk in place since its content is managed by jobs
+// and agent cannot migrate it; any other change would have to replace the disk and lose data
+func (d *diskDeployer) resizeNamedDisk(
+	disk bidisk.Disk,
+	persistentDisk bideplmanifest.PersistentDisk,
+	vm VM,
+	stage biui.Stage,
+) error {
+	if !disk.CanResize(persistentDisk.DiskSize, persistentDisk.CloudProperties) {
+		return bosherr.Errorf(
+			"Named disk '%s' (cid=%s) can only grow in size since its content cannot be migrated to a new disk",
+			disk.Name(), disk.CID())
+	}
+
+	resized, err := d.resizeDisk(disk, persistentDisk, vm, stage)
+	if err != nil {
+		return err
+	}
+
+	if !resized {
+		return bosherr.Errorf(
+			"Named disk '%s' (cid=%s) cannot be resized since CPI does not support resizing disks and its content cannot be migrated to a new disk",
+			disk.Name(), disk.CID())
+	}
+
+	return nil
+}
+
+// resizeDisk grows the disk in place instead of migrating its content to a new disk.
+// It returns false if the CPI does not implement resize_disk so that the disk can be migrated.
+func (d *diskDeployer) resizeDisk(
+	disk bidisk.Disk,
+	persistentDisk bideplmanifest.PersistentDisk,
+	vm VM,
+	stage biui.Stage,
+) (bool, error) {
+	d.logger.Debug(d.logTag, "Resizing disk '%s'", disk.CID())
+
+	stageName := fmt.Sprintf("Detaching disk '%s'", disk.CID())
+	err := stage.Perform(stageName, func() error {
+		// named disks are never mounted by agent
+		if disk.Name() == "" {
+			err := vm.UnmountDisk(disk)
+			if err != nil {
+				return err
+			}
+		}
+
+		return vm.DetachDisk(disk)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	resized := true
+
+	stageName = fmt.Sprintf("Resizing disk '%s' to %d MB", disk.CID(), persistentDisk.DiskSize)
+	err = stage.Perform(stageName, func() error {
+		err := disk.Resize(persistentDisk.DiskSize)
+
+		cloudErr, ok := err.(bicloud.Error)
+		if ok && cloudErr.Type() == bicloud.NotImplementedError {
+			resized = false
+			return biui.NewSkipStageError(cloudErr, "CPI does not support resizing disks")
+		}
+
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// disk is attached again whether it was resized or will be migrated
+	stageName = fmt.Sprintf("Attaching disk '%s' to VM '%s'", disk.CID(), vm.CID())
+	err = stage.Perform(stageName, func() error {
+		return d.attachToVM(disk, vm)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return resized, nil
 }
 
 func (d *diskDeployer) migrateDisk(
 	originalDisk bidisk.Disk,
-	diskPool bideplmanifest.DiskPool,
+	persistentDisk bideplmanifest.PersistentDisk,
 	vm VM,
 	stage biui.Stage,
 ) (newDisk bidisk.Disk, err error) {
 	d.logger.Debug(d.logTag, "Migrating disk '%s'", originalDisk.CID())
 
-	err = stage.Perform("Creating disk", func() error {
-		newDisk, err = d.diskManager.Create(diskPool, vm.CID())
-		return err
-	})
+	// migration is restarted with the disk created by a failed deploy
+	newDisk, err = d.createOrResumeDisk(persistentDisk, vm, stage)
 	if err != nil {
 		return newDisk, err
 	}
@@ -155,6 +270,11 @@ func (d *diskDeployer) migrateDisk(
 		return newDisk, err
 	}
 
+	err = d.checkpointRepo.ClearPendingDisk()
+	if err != nil {
+		return newDisk, bosherr.WrapError(err, "Clearing pending disk checkpoint")
+	}
+
 	stageName = fmt.Sprintf("Detaching disk '%s'", originalDisk.CID())
 	err = stage.Perform(stageName, func() error {
 		return vm.DetachDisk(originalDisk)
@@ -192,20 +312,90 @@ func (d *diskDeployer) updateCurrentDiskRecord(disk bidisk.Disk) error {
 	return nil
 }
 
-func (d *diskDeployer) createDisk(diskPool bideplmanifest.DiskPool, vm VM, stage biui.Stage) (disk bidisk.Disk, err error) {
+func (d *diskDeployer) createDisk(persistentDisk bideplmanifest.PersistentDisk, vm VM, stage biui.Stage) (disk bidisk.Disk, err error) {
 	err = stage.Perform("Creating disk", func() error {
-		disk, err = d.diskManager.Create(diskPool, vm.CID())
+		disk, err = d.diskManager.Create(persistentDisk, vm.CID())
 		return err
 	})
 
 	return disk, err
 }
 
+// createOrResumeDisk reuses disk created by a previous attempt of the same deploy
+// that did not become current so that failed deploys do not leave orphaned disks
+func (d *diskDeployer) createOrResumeDisk(persistentDisk bideplmanifest.PersistentDisk, vm VM, stage biui.Stage) (bidisk.Disk, error) {
+	disk, found, err := d.findPendingDisk(persistentDisk.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		err = stage.Perform("Creating disk", func() error {
+			return biui.NewSkipStageError(
+				bosherr.Errorf("Disk '%s' was created by previous deploy", disk.CID()),
+				fmt.Sprintf("Resuming with disk '%s'", disk.CID()),
+			)
+		})
+
+		return disk, err
+	}
+
+	disk, err = d.createDisk(persistentDisk, vm, stage)
+	if err != nil {
+		return disk, err
+	}
+
+	err = d.checkpointRepo.RecordPendingDisk(disk.CID())
+	if err != nil {
+		return disk, bosherr.WrapError(err, "Recording pending disk checkpoint")
+	}
+
+	return disk, nil
+}
+
+func (d *diskDeployer) findPendingDisk(name string) (bidisk.Disk, bool, error) {
+	checkpoint, found, err := d.checkpointRepo.Find()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Finding deploy checkpoint")
+	} else if !found || len(checkpoint.PendingDiskCID) == 0 {
+		return nil, false, nil
+	}
+
+	unusedDisks, err := d.diskManager.FindUnused()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Finding unused disks")
+	}
+
+	for _, disk := range unusedDisks {
+		if disk.CID() == checkpoint.PendingDiskCID && disk.Name() == name {
+			return disk, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
 func (d *diskDeployer) attachDisk(disk bidisk.Disk, vm VM, stage biui.Stage) error {
+	// VM with disks attached is not resumed since attaching is not repeatable
+	err := d.checkpointRepo.CompleteStep(biconfig.CheckpointDiskAttached)
+	if err != nil {
+		return bosherr.WrapError(err, "Recording disk attached checkpoint")
+	}
+
 	stageName := fmt.Sprintf("Attaching disk '%s' to VM '%s'", disk.CID(), vm.CID())
-	err := stage.Perform(stageName, func() error {
-		return vm.AttachDisk(disk)
+	err = stage.Perform(stageName, func() error {
+		return d.attachToVM(disk, vm)
 	})
 
 	return err
 }
+
+// attachToVM mounts only the unnamed disk since agent treats mounted disk
+// as its persistent disk; named disks are left for jobs to use
+func (d *diskDeployer) attachToVM(disk bidisk.Disk, vm VM) error {
+	if disk.Name() != "" {
+		return vm.AttachDiskWithoutMounting(disk)
+	}
+
+	return vm.AttachDisk(disk)
+}
@@ -3,6 +3,7 @@ package vm_test
 import (
 	. "github.com/cloudfoundry/bosh-cli/deployment/vm"
 
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -21,14 +22,15 @@ import (
 
 var _ = Describe("DiskDeployer", func() {
 	var (
-		diskDeployer    DiskDeployer
-		fakeDiskManager *fakebidisk.FakeManager
-		diskPool        bideplmanifest.DiskPool
-		cloud           *fakebicloud.FakeCloud
-		fakeStage       *fakebiui.FakeStage
-		fakeVM          *fakebivm.FakeVM
-		fakeDisk        *fakebidisk.FakeDisk
-		fakeDiskRepo    *fakebiconfig.FakeDiskRepo
+		diskDeployer       DiskDeployer
+		fakeDiskManager    *fakebidisk.FakeManager
+		persistentDisks    []bideplmanifest.PersistentDisk
+		cloud              *fakebicloud.FakeCloud
+		fakeStage          *fakebiui.FakeStage
+		fakeVM             *fakebivm.FakeVM
+		fakeDisk           *fakebidisk.FakeDisk
+		fakeDiskRepo       *fakebiconfig.FakeDiskRepo
+		fakeCheckpointRepo *fakebiconfig.FakeCheckpointRepo
 	)
 
 	BeforeEach(func() {
@@ -44,9 +46,11 @@ var _ = Describe("DiskDeployer", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeStage = fakebiui.NewFakeStage()
 		fakeDiskRepo = fakebiconfig.NewFakeDiskRepo()
+		fakeCheckpointRepo = fakebiconfig.NewFakeCheckpointRepo()
 		diskDeployer = NewDiskDeployer(
 			fakeDiskManagerFactory,
 			fakeDiskRepo,
+			fakeCheckpointRepo,
 			logger,
 		)
 
@@ -58,13 +62,14 @@ var _ = Describe("DiskDeployer", func() {
 		fakeDiskRepo.SetFindBehavior("fake-new-disk-cid", newDiskRecord, true, nil)
 	})
 
-	Context("when the disk pool size is > 0", func() {
+	Context("when the disk size is > 0", func() {
 		BeforeEach(func() {
-			diskPool = bideplmanifest.DiskPool{
-				Name:     "fake-persistent-disk-pool-name",
-				DiskSize: 1024,
-				CloudProperties: biproperty.Map{
-					"fake-disk-pool-cloud-property-key": "fake-disk-pool-cloud-property-value",
+			persistentDisks = []bideplmanifest.PersistentDisk{
+				{
+					DiskSize: 1024,
+					CloudProperties: biproperty.Map{
+						"fake-disk-pool-cloud-property-key": "fake-disk-pool-cloud-property-value",
+					},
 				},
 			}
 		})
@@ -83,7 +88,7 @@ var _ = Describe("DiskDeployer", func() {
 			})
 
 			It("does not create primary disk", func() {
-				disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
@@ -96,7 +101,7 @@ var _ = Describe("DiskDeployer", func() {
 				})
 
 				It("does not log the create disk event", func() {
-					disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(disks).To(Equal([]bidisk.Disk{existingDisk}))
 
@@ -122,14 +127,14 @@ var _ = Describe("DiskDeployer", func() {
 				})
 
 				It("creates secondary disk", func() {
-					disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(disks).To(Equal([]bidisk.Disk{secondaryDisk}))
 
 					Expect(fakeDiskManager.CreateInputs).To(Equal([]fakebidisk.CreateInput{
 						{
-							DiskPool:   diskPool,
-							InstanceID: "fake-vm-cid",
+							PersistentDisk: persistentDisks[0],
+							InstanceID:     "fake-vm-cid",
 						},
 					}))
 
@@ -139,7 +144,7 @@ var _ = Describe("DiskDeployer", func() {
 				})
 
 				It("attaches secondary disk", func() {
-					_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(fakeVM.AttachDiskInputs).To(Equal([]fakebivm.AttachDiskInput{
 						{Disk: existingDisk},
@@ -152,7 +157,7 @@ var _ = Describe("DiskDeployer", func() {
 				})
 
 				It("migrates from primary to secondary disk", func() {
-					_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(1))
 
@@ -162,7 +167,7 @@ var _ = Describe("DiskDeployer", func() {
 				})
 
 				It("detaches primary disk", func() {
-					_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{
 						{Disk: existingDisk},
@@ -174,7 +179,7 @@ var _ = Describe("DiskDeployer", func() {
 				})
 
 				It("promotes secondary disk as primary", func() {
-					_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 					Expect(err).NotTo(HaveOccurred())
 
 					// existing disk must be current until after migration
@@ -190,7 +195,7 @@ var _ = Describe("DiskDeployer", func() {
 					})
 
 					It("returns error and leaves the existing disk attached", func() {
-						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-create-disk-error"))
 						Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{}))
@@ -207,7 +212,7 @@ var _ = Describe("DiskDeployer", func() {
 					})
 
 					It("returns error and leaves the existing disk attached", func() {
-						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-attach-disk-error"))
 						Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{}))
@@ -233,7 +238,7 @@ var _ = Describe("DiskDeployer", func() {
 					})
 
 					It("returns error", func() {
-						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-detach-disk-error"))
 
@@ -260,7 +265,7 @@ var _ = Describe("DiskDeployer", func() {
 					})
 
 					It("returns error and leaves the existing disk attached", func() {
-						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-migrate-disk-error"))
 						Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{}))
@@ -279,22 +284,215 @@ var _ = Describe("DiskDeployer", func() {
 			})
 		})
 
+		Context("when primary disk can be resized in place", func() {
+			var existingDisk *fakebidisk.FakeDisk
+
+			BeforeEach(func() {
+				existingDisk = fakebidisk.NewFakeDisk("fake-existing-disk-cid")
+				existingDisk.SetNeedsMigrationBehavior(true)
+				existingDisk.SetCanResizeBehavior(true)
+				fakeDiskManager.SetFindCurrentBehavior([]bidisk.Disk{existingDisk}, nil)
+				fakeVM.SetAttachDiskBehavior(existingDisk, nil)
+			})
+
+			It("resizes the disk instead of migrating it", func() {
+				disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disks).To(Equal([]bidisk.Disk{existingDisk}))
+
+				Expect(existingDisk.ResizeInputs).To(Equal([]int{1024}))
+				Expect(fakeVM.UnmountDiskInputs).To(Equal([]fakebivm.UnmountDiskInput{{Disk: existingDisk}}))
+				Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{{Disk: existingDisk}}))
+				Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+				Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+
+				Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+					{Name: "Attaching disk 'fake-existing-disk-cid' to VM 'fake-vm-cid'"},
+					{Name: "Detaching disk 'fake-existing-disk-cid'"},
+					{Name: "Resizing disk 'fake-existing-disk-cid' to 1024 MB"},
+					{Name: "Attaching disk 'fake-existing-disk-cid' to VM 'fake-vm-cid'"},
+				}))
+			})
+
+			Context("when CPI does not implement resize_disk", func() {
+				var secondaryDisk *fakebidisk.FakeDisk
+
+				BeforeEach(func() {
+					existingDisk.ResizeErr = bicloud.NewCPIError("resize_disk", bicloud.CmdError{
+						Type:    bicloud.NotImplementedError,
+						Message: "fake-not-implemented-message",
+					})
+
+					secondaryDisk = fakebidisk.NewFakeDisk("fake-secondary-disk-cid")
+					fakeDiskManager.CreateDisk = secondaryDisk
+					fakeVM.SetAttachDiskBehavior(secondaryDisk, nil)
+					fakeDiskRepo.SetFindBehavior("fake-secondary-disk-cid", biconfig.DiskRecord{ID: "fake-secondary-disk-id"}, true, nil)
+				})
+
+				It("reattaches the disk and migrates it", func() {
+					disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{secondaryDisk}))
+
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(1))
+					Expect(fakeStage.PerformCalls[2].Name).To(Equal("Resizing disk 'fake-existing-disk-cid' to 1024 MB"))
+					Expect(fakeStage.PerformCalls[2].SkipError).To(HaveOccurred())
+					Expect(fakeStage.PerformCalls[3].Name).To(Equal("Attaching disk 'fake-existing-disk-cid' to VM 'fake-vm-cid'"))
+					Expect(fakeStage.PerformCalls[4].Name).To(Equal("Creating disk"))
+				})
+			})
+
+			Context("when resizing fails", func() {
+				BeforeEach(func() {
+					existingDisk.ResizeErr = bosherr.Error("fake-resize-error")
+				})
+
+				It("returns an error without migrating the disk", func() {
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-resize-error"))
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when there are multiple named disks", func() {
+			var (
+				existingDBDisk *fakebidisk.FakeDisk
+				otherDisk      *fakebidisk.FakeDisk
+			)
+
+			BeforeEach(func() {
+				persistentDisks = []bideplmanifest.PersistentDisk{
+					{Name: "fake-db-disk", DiskSize: 1024, CloudProperties: biproperty.Map{}},
+					{Name: "fake-blobstore-disk", DiskSize: 2048, CloudProperties: biproperty.Map{}},
+				}
+
+				existingDBDisk = fakebidisk.NewFakeDisk("fake-db-disk-cid")
+				existingDBDisk.SetName("fake-db-disk")
+				otherDisk = fakebidisk.NewFakeDisk("fake-other-disk-cid")
+				otherDisk.SetName("fake-other-disk")
+				fakeDisk.SetName("fake-blobstore-disk")
+				fakeDiskManager.SetFindCurrentBehavior([]bidisk.Disk{otherDisk, existingDBDisk}, nil)
+				fakeVM.SetAttachDiskBehavior(existingDBDisk, nil)
+			})
+
+			It("keeps existing disk with the same name and creates missing disks", func() {
+				disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disks).To(Equal([]bidisk.Disk{existingDBDisk, fakeDisk}))
+
+				Expect(fakeDiskManager.CreateInputs).To(Equal([]fakebidisk.CreateInput{
+					{
+						PersistentDisk: persistentDisks[1],
+						InstanceID:     "fake-vm-cid",
+					},
+				}))
+				Expect(fakeVM.AttachDiskWithoutMountingInputs).To(Equal([]fakebivm.AttachDiskInput{
+					{Disk: existingDBDisk},
+					{Disk: fakeDisk},
+				}))
+				Expect(fakeDiskRepo.UpdateCurrentInputs).To(Equal([]fakebiconfig.DiskRepoUpdateCurrentInput{
+					{DiskID: "fake-new-disk-id"},
+				}))
+			})
+
+			It("attaches named disks without mounting them", func() {
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeVM.AttachDiskInputs).To(BeEmpty())
+			})
+
+			Context("when named disk changes", func() {
+				BeforeEach(func() {
+					existingDBDisk.SetNeedsMigrationBehavior(true)
+				})
+
+				It("never migrates or deletes the disk", func() {
+					existingDBDisk.SetCanResizeBehavior(false)
+
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(
+						"Named disk 'fake-db-disk' (cid=fake-db-disk-cid) can only grow in size since its content cannot be migrated to a new disk"))
+
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+					Expect(fakeVM.DetachDiskInputs).To(BeEmpty())
+					Expect(existingDBDisk.DeleteCalledTimes).To(Equal(0))
+					Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+					Expect(fakeDiskRepo.UpdateCurrentInputs).To(BeEmpty())
+				})
+
+				It("resizes the disk in place without mounting it", func() {
+					existingDBDisk.SetCanResizeBehavior(true)
+
+					disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{existingDBDisk, fakeDisk}))
+
+					Expect(existingDBDisk.ResizeInputs).To(Equal([]int{1024}))
+					Expect(fakeVM.UnmountDiskInputs).To(BeEmpty())
+					Expect(fakeVM.DetachDiskInputs).To(Equal([]fakebivm.DetachDiskInput{{Disk: existingDBDisk}}))
+					Expect(fakeVM.AttachDiskInputs).To(BeEmpty())
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+					Expect(existingDBDisk.DeleteCalledTimes).To(Equal(0))
+				})
+
+				It("returns an error without migrating the disk when CPI does not implement resize_disk", func() {
+					existingDBDisk.SetCanResizeBehavior(true)
+					existingDBDisk.ResizeErr = bicloud.NewCPIError("resize_disk", bicloud.CmdError{
+						Type:    bicloud.NotImplementedError,
+						Message: "fake-not-implemented-message",
+					})
+
+					_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("CPI does not support resizing disks"))
+
+					Expect(fakeVM.AttachDiskWithoutMountingInputs).To(Equal([]fakebivm.AttachDiskInput{
+						{Disk: existingDBDisk},
+						{Disk: existingDBDisk},
+					}))
+					Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(0))
+					Expect(existingDBDisk.DeleteCalledTimes).To(Equal(0))
+					Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+				})
+			})
+
+			It("does not resume pending disk created for a disk with another name", func() {
+				pendingDisk := fakebidisk.NewFakeDisk("fake-pending-disk-cid")
+				pendingDisk.SetName("fake-other-disk")
+				fakeDiskManager.SetFindUnusedBehavior([]bidisk.Disk{pendingDisk}, nil)
+
+				fakeCheckpointRepo.FindFound = true
+				fakeCheckpointRepo.FindRecord = biconfig.CheckpointRecord{
+					PendingDiskCID: "fake-pending-disk-cid",
+				}
+
+				disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disks).To(Equal([]bidisk.Disk{existingDBDisk, fakeDisk}))
+				Expect(fakeDiskManager.CreateInputs).To(HaveLen(1))
+			})
+		})
+
 		Context("when disk does not exist", func() {
 			It("creates a persistent disk", func() {
-				disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(disks).To(Equal([]bidisk.Disk{fakeDisk}))
 
 				Expect(fakeDiskManager.CreateInputs).To(Equal([]fakebidisk.CreateInput{
 					{
-						DiskPool:   diskPool,
-						InstanceID: "fake-vm-cid",
+						PersistentDisk: persistentDisks[0],
+						InstanceID:     "fake-vm-cid",
 					},
 				}))
 			})
 
 			It("sets the new disk as current", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeDiskRepo.UpdateCurrentInputs).To(Equal([]fakebiconfig.DiskRepoUpdateCurrentInput{
@@ -303,17 +501,72 @@ var _ = Describe("DiskDeployer", func() {
 			})
 
 			It("logs the create disk event", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls[0]).To(Equal(&fakebiui.PerformCall{
 					Name: "Creating disk",
 				}))
 			})
+
+			It("records the new disk as pending until it becomes current", func() {
+				fakeDiskRepo.SetUpdateBehavior(bosherr.Error("fake-update-error"))
+
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeCheckpointRepo.PendingDiskCID).To(Equal("fake-new-disk-cid"))
+			})
+
+			It("clears pending disk once it becomes current", func() {
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeCheckpointRepo.PendingDiskCID).To(BeEmpty())
+				Expect(fakeCheckpointRepo.ClearPendingDiskCalls).To(Equal(1))
+			})
+
+			Context("when disk was created by previous attempt of the same deploy", func() {
+				var pendingDisk *fakebidisk.FakeDisk
+
+				BeforeEach(func() {
+					pendingDisk = fakebidisk.NewFakeDisk("fake-pending-disk-cid")
+					fakeDiskManager.SetFindUnusedBehavior([]bidisk.Disk{pendingDisk}, nil)
+					fakeVM.SetAttachDiskBehavior(pendingDisk, nil)
+					fakeDiskRepo.SetFindBehavior("fake-pending-disk-cid", biconfig.DiskRecord{ID: "fake-pending-disk-id"}, true, nil)
+
+					fakeCheckpointRepo.FindFound = true
+					fakeCheckpointRepo.FindRecord = biconfig.CheckpointRecord{
+						ManifestSHA:    "fake-manifest-sha",
+						PendingDiskCID: "fake-pending-disk-cid",
+					}
+				})
+
+				It("reuses pending disk instead of creating a new one", func() {
+					disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{pendingDisk}))
+
+					Expect(fakeDiskManager.CreateInputs).To(BeEmpty())
+					Expect(fakeDiskRepo.UpdateCurrentInputs).To(Equal([]fakebiconfig.DiskRepoUpdateCurrentInput{
+						{DiskID: "fake-pending-disk-id"},
+					}))
+
+					Expect(fakeStage.PerformCalls[0].Name).To(Equal("Creating disk"))
+					Expect(fakeStage.PerformCalls[0].SkipError).To(HaveOccurred())
+				})
+
+				It("creates a new disk when pending disk no longer exists", func() {
+					fakeDiskManager.SetFindUnusedBehavior([]bidisk.Disk{}, nil)
+
+					disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{fakeDisk}))
+					Expect(fakeDiskManager.CreateInputs).To(HaveLen(1))
+				})
+			})
 		})
 
 		It("attaches the primary disk", func() {
-			_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+			_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeVM.AttachDiskInputs).To(Equal([]fakebivm.AttachDiskInput{
 				{
@@ -323,7 +576,7 @@ var _ = Describe("DiskDeployer", func() {
 		})
 
 		It("logs attaching primary disk event", func() {
-			_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+			_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
@@ -333,7 +586,7 @@ var _ = Describe("DiskDeployer", func() {
 		})
 
 		It("removes unused disks", func() {
-			_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+			_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(fakeDiskManager.DeleteUnusedCalledTimes).To(Equal(1))
@@ -345,7 +598,7 @@ var _ = Describe("DiskDeployer", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-delete-error"))
 			})
@@ -361,13 +614,13 @@ var _ = Describe("DiskDeployer", func() {
 			})
 
 			It("return an error", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-disk-error"))
 			})
 
 			It("logs start and stop events to the eventLogger", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).To(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
@@ -389,13 +642,13 @@ var _ = Describe("DiskDeployer", func() {
 			})
 
 			It("return an error", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-attach-disk-error"))
 			})
 
 			It("logs start and failed events to the eventLogger", func() {
-				_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+				_, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 				Expect(err).To(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
@@ -409,13 +662,13 @@ var _ = Describe("DiskDeployer", func() {
 		})
 	})
 
-	Context("when the disk pool size is 0", func() {
+	Context("when the disk size is 0", func() {
 		BeforeEach(func() {
-			diskPool = bideplmanifest.DiskPool{}
+			persistentDisks = []bideplmanifest.PersistentDisk{{}}
 		})
 
 		It("does not create a persistent disk", func() {
-			disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+			disks, err := diskDeployer.Deploy(persistentDisks, cloud, fakeVM, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(disks).To(Equal([]bidisk.Disk{}))
 
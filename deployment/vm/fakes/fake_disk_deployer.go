@@ -14,7 +14,7 @@ type FakeDiskDeployer struct {
 }
 
 type DeployInput struct {
-	DiskPool         bideplmanifest.DiskPool
+	PersistentDisks  []bideplmanifest.PersistentDisk
 	Cloud            bicloud.Cloud
 	VM               bivm.VM
 	EventLoggerStage biui.Stage
@@ -32,13 +32,13 @@ func NewFakeDiskDeployer() *FakeDiskDeployer {
 }
 
 func (d *FakeDiskDeployer) Deploy(
-	diskPool bideplmanifest.DiskPool,
+	persistentDisks []bideplmanifest.PersistentDisk,
 	cloud bicloud.Cloud,
 	vm bivm.VM,
 	eventLoggerStage biui.Stage,
 ) ([]bidisk.Disk, error) {
 	d.DeployInputs = append(d.DeployInputs, DeployInput{
-		DiskPool:         diskPool,
+		PersistentDisks:  persistentDisks,
 		Cloud:            cloud,
 		VM:               vm,
 		EventLoggerStage: eventLoggerStage,
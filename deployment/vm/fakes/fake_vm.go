@@ -32,6 +32,8 @@ type FakeVM struct {
 	AttachDiskInputs   []AttachDiskInput
 	attachDiskBehavior map[string]error
 
+	AttachDiskWithoutMountingInputs []AttachDiskInput
+
 	DetachDiskInputs   []DetachDiskInput
 	detachDiskBehavior map[string]error
 
@@ -65,8 +67,8 @@ type FakeVM struct {
 }
 
 type UpdateDisksInput struct {
-	DiskPool bideplmanifest.DiskPool
-	Stage    biui.Stage
+	PersistentDisks []bideplmanifest.PersistentDisk
+	Stage           biui.Stage
 }
 
 type ApplyInput struct {
@@ -97,17 +99,18 @@ type UnmountDiskInput struct {
 
 func NewFakeVM(cid string) *FakeVM {
 	return &FakeVM{
-		ExistsFound:           true,
-		ApplyInputs:           []ApplyInput{},
-		WaitUntilReadyInputs:  []WaitUntilReadyInput{},
-		WaitToBeRunningInputs: []WaitInput{},
-		AttachDiskInputs:      []AttachDiskInput{},
-		DetachDiskInputs:      []DetachDiskInput{},
-		UnmountDiskInputs:     []UnmountDiskInput{},
-		attachDiskBehavior:    map[string]error{},
-		detachDiskBehavior:    map[string]error{},
-		cid:                   cid,
-		RunScriptErrors:       map[string]error{},
+		ExistsFound:                     true,
+		ApplyInputs:                     []ApplyInput{},
+		WaitUntilReadyInputs:            []WaitUntilReadyInput{},
+		WaitToBeRunningInputs:           []WaitInput{},
+		AttachDiskInputs:                []AttachDiskInput{},
+		AttachDiskWithoutMountingInputs: []AttachDiskInput{},
+		DetachDiskInputs:                []DetachDiskInput{},
+		UnmountDiskInputs:               []UnmountDiskInput{},
+		attachDiskBehavior:              map[string]error{},
+		detachDiskBehavior:              map[string]error{},
+		cid:                             cid,
+		RunScriptErrors:                 map[string]error{},
 	}
 }
 
@@ -132,10 +135,10 @@ func (vm *FakeVM) WaitUntilReady(timeout time.Duration, delay time.Duration) err
 	return vm.WaitUntilReadyErr
 }
 
-func (vm *FakeVM) UpdateDisks(diskPool bideplmanifest.DiskPool, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
+func (vm *FakeVM) UpdateDisks(persistentDisks []bideplmanifest.PersistentDisk, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
 	vm.UpdateDisksInputs = append(vm.UpdateDisksInputs, UpdateDisksInput{
-		DiskPool: diskPool,
-		Stage:    eventLoggerStage,
+		PersistentDisks: persistentDisks,
+		Stage:           eventLoggerStage,
 	})
 	return vm.UpdateDisksDisks, vm.UpdateDisksErr
 }
@@ -169,6 +172,14 @@ func (vm *FakeVM) AttachDisk(disk bidisk.Disk) error {
 	return vm.attachDiskBehavior[disk.CID()]
 }
 
+func (vm *FakeVM) AttachDiskWithoutMounting(disk bidisk.Disk) error {
+	vm.AttachDiskWithoutMountingInputs = append(vm.AttachDiskWithoutMountingInputs, AttachDiskInput{
+		Disk: disk,
+	})
+
+	return vm.attachDiskBehavior[disk.CID()]
+}
+
 func (vm *FakeVM) DetachDisk(disk bidisk.Disk) error {
 	vm.DetachDiskInputs = append(vm.DetachDiskInputs, DetachDiskInput{
 		Disk: disk,
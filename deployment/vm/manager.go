@@ -24,6 +24,7 @@ type Manager interface {
 type manager struct {
 	vmRepo             biconfig.VMRepo
 	stemcellRepo       biconfig.StemcellRepo
+	checkpointRepo     biconfig.CheckpointRepo
 	diskDeployer       DiskDeployer
 	agentClient        biagentclient.AgentClient
 	agentClientFactory bihttpagent.AgentClientFactory
@@ -38,6 +39,7 @@ type manager struct {
 func NewManager(
 	vmRepo biconfig.VMRepo,
 	stemcellRepo biconfig.StemcellRepo,
+	checkpointRepo biconfig.CheckpointRepo,
 	diskDeployer DiskDeployer,
 	agentClient biagentclient.AgentClient,
 	cloud bicloud.Cloud,
@@ -47,16 +49,17 @@ func NewManager(
 	timeService Clock,
 ) Manager {
 	return &manager{
-		cloud:         cloud,
-		agentClient:   agentClient,
-		vmRepo:        vmRepo,
-		stemcellRepo:  stemcellRepo,
-		diskDeployer:  diskDeployer,
-		uuidGenerator: uuidGenerator,
-		fs:            fs,
-		logger:        logger,
-		logTag:        "vmManager",
-		timeService:   timeService,
+		cloud:          cloud,
+		agentClient:    agentClient,
+		vmRepo:         vmRepo,
+		stemcellRepo:   stemcellRepo,
+		checkpointRepo: checkpointRepo,
+		diskDeployer:   diskDeployer,
+		uuidGenerator:  uuidGenerator,
+		fs:             fs,
+		logger:         logger,
+		logTag:         "vmManager",
+		timeService:    timeService,
 	}
 }
 
@@ -86,6 +89,14 @@ func (m *manager) FindCurrent() (VM, bool, error) {
 }
 
 func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (VM, error) {
+	vm, found, err := m.findResumableVM()
+	if err != nil {
+		return nil, err
+	} else if found {
+		m.logger.Info(m.logTag, "Resuming deploy with VM '%s' created by previous deploy", vm.CID())
+		return vm, nil
+	}
+
 	jobName := deploymentManifest.JobName()
 	networkInterfaces, err := deploymentManifest.NetworkInterfaces(jobName)
 	m.logger.Debug(m.logTag, "Creating VM with network interfaces: %#v", networkInterfaces)
@@ -131,7 +142,12 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 		}
 	}
 
-	vm := NewVMWithMetadata(
+	err = m.checkpointRepo.CompleteStep(biconfig.CheckpointVMCreated)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Recording VM creation checkpoint")
+	}
+
+	vm = NewVMWithMetadata(
 		cid,
 		m.vmRepo,
 		m.stemcellRepo,
@@ -154,6 +170,11 @@ func (m *manager) createAndRecordVM(agentID string, stemcell bistemcell.CloudSte
 	}
 
 	// Record vm info immediately so we don't leak it
+	err = m.checkpointRepo.RecordVM(cid)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Recording created vm")
+	}
+
 	err = m.vmRepo.UpdateCurrent(cid)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Updating current vm record")
@@ -161,3 +182,22 @@ func (m *manager) createAndRecordVM(agentID string, stemcell bistemcell.CloudSte
 
 	return cid, nil
 }
+
+// findResumableVM returns current VM if it was created by a previous attempt of the same deploy
+func (m *manager) findResumableVM() (VM, bool, error) {
+	checkpoint, found, err := m.checkpointRepo.Find()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Finding deploy checkpoint")
+	} else if !found {
+		return nil, false, nil
+	}
+
+	vmCID, found, err := m.vmRepo.FindCurrent()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Finding currently deployed vm")
+	} else if !found || !checkpoint.CanResumeVM(vmCID) {
+		return nil, false, nil
+	}
+
+	return m.FindCurrent()
+}
@@ -15,29 +15,32 @@ type ManagerFactory interface {
 }
 
 type managerFactory struct {
-	vmRepo        biconfig.VMRepo
-	stemcellRepo  biconfig.StemcellRepo
-	diskDeployer  DiskDeployer
-	uuidGenerator boshuuid.Generator
-	fs            boshsys.FileSystem
-	logger        boshlog.Logger
+	vmRepo         biconfig.VMRepo
+	stemcellRepo   biconfig.StemcellRepo
+	checkpointRepo biconfig.CheckpointRepo
+	diskDeployer   DiskDeployer
+	uuidGenerator  boshuuid.Generator
+	fs             boshsys.FileSystem
+	logger         boshlog.Logger
 }
 
 func NewManagerFactory(
 	vmRepo biconfig.VMRepo,
 	stemcellRepo biconfig.StemcellRepo,
+	checkpointRepo biconfig.CheckpointRepo,
 	diskDeployer DiskDeployer,
 	uuidGenerator boshuuid.Generator,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
-		vmRepo:        vmRepo,
-		stemcellRepo:  stemcellRepo,
-		diskDeployer:  diskDeployer,
-		uuidGenerator: uuidGenerator,
-		fs:            fs,
-		logger:        logger,
+		vmRepo:         vmRepo,
+		stemcellRepo:   stemcellRepo,
+		checkpointRepo: checkpointRepo,
+		diskDeployer:   diskDeployer,
+		uuidGenerator:  uuidGenerator,
+		fs:             fs,
+		logger:         logger,
 	}
 }
 
@@ -45,6 +48,7 @@ func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclie
 	return NewManager(
 		f.vmRepo,
 		f.stemcellRepo,
+		f.checkpointRepo,
 		f.diskDeployer,
 		agentClient,
 		cloud,
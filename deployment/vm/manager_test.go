@@ -33,6 +33,7 @@ var _ = Describe("Manager", func() {
 		expectedEnv               biproperty.Map
 		deploymentManifest        bideplmanifest.Manifest
 		fakeVMRepo                *fakebiconfig.FakeVMRepo
+		fakeCheckpointRepo        *fakebiconfig.FakeCheckpointRepo
 		stemcellRepo              biconfig.StemcellRepo
 		fakeDiskDeployer          *fakebivm.FakeDiskDeployer
 		fakeAgentClient           *fakebiagentclient.FakeAgentClient
@@ -56,9 +57,12 @@ var _ = Describe("Manager", func() {
 		fakeTime := time.Date(2016, time.November, 10, 23, 0, 0, 0, time.UTC)
 		fakeTimeService = &FakeClock{Times: []time.Time{fakeTime, time.Now().Add(10 * time.Minute)}}
 
+		fakeCheckpointRepo = fakebiconfig.NewFakeCheckpointRepo()
+
 		manager = NewManager(
 			fakeVMRepo,
 			stemcellRepo,
+			fakeCheckpointRepo,
 			fakeDiskDeployer,
 			fakeAgentClient,
 			fakeCloud,
@@ -226,6 +230,43 @@ var _ = Describe("Manager", func() {
 			Expect(fakeVMRepo.UpdateCurrentCID).To(Equal("fake-vm-cid"))
 		})
 
+		It("records the vm in deploy checkpoint", func() {
+			_, err := manager.Create(stemcell, deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCheckpointRepo.RecordVMCID).To(Equal("fake-vm-cid"))
+			Expect(fakeCheckpointRepo.CompletedSteps).To(Equal([]string{biconfig.CheckpointVMCreated}))
+		})
+
+		Context("when current vm was created by previous attempt of the same deploy", func() {
+			BeforeEach(func() {
+				fakeVMRepo.SetFindCurrentBehavior("fake-existing-vm-cid", true, nil)
+				fakeCheckpointRepo.FindFound = true
+				fakeCheckpointRepo.FindRecord = biconfig.CheckpointRecord{
+					ManifestSHA:    "fake-manifest-sha",
+					CompletedSteps: []string{biconfig.CheckpointVMCreated},
+					VMCID:          "fake-existing-vm-cid",
+				}
+			})
+
+			It("returns current vm without creating a new one", func() {
+				vm, err := manager.Create(stemcell, deploymentManifest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(vm.CID()).To(Equal("fake-existing-vm-cid"))
+
+				Expect(fakeCloud.CreateVMInput).To(Equal(fakebicloud.CreateVMInput{}))
+				Expect(fakeVMRepo.UpdateCurrentCID).To(BeEmpty())
+			})
+
+			It("creates a new vm when checkpoint belongs to another vm", func() {
+				fakeCheckpointRepo.FindRecord.VMCID = "fake-other-vm-cid"
+
+				vm, err := manager.Create(stemcell, deploymentManifest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(vm.CID()).To(Equal("fake-vm-cid"))
+			})
+		})
+
 		Context("when setting vm metadata fails", func() {
 			BeforeEach(func() {
 				fakeCloud.SetVMMetadataError = errors.New("fake-set-metadata-error")
@@ -29,9 +29,10 @@ type VM interface {
 	Start() error
 	Stop() error
 	Apply(bias.ApplySpec) error
-	UpdateDisks(bideplmanifest.DiskPool, biui.Stage) ([]bidisk.Disk, error)
+	UpdateDisks([]bideplmanifest.PersistentDisk, biui.Stage) ([]bidisk.Disk, error)
 	WaitToBeRunning(maxAttempts int, delay time.Duration) error
 	AttachDisk(bidisk.Disk) error
+	AttachDiskWithoutMounting(bidisk.Disk) error
 	DetachDisk(bidisk.Disk) error
 	Disks() ([]bidisk.Disk, error)
 	UnmountDisk(bidisk.Disk) error
@@ -159,8 +160,8 @@ func (vm *vm) Apply(newState bias.ApplySpec) error {
 	return nil
 }
 
-func (vm *vm) UpdateDisks(diskPool bideplmanifest.DiskPool, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
-	disks, err := vm.diskDeployer.Deploy(diskPool, vm.cloud, vm, eventLoggerStage)
+func (vm *vm) UpdateDisks(persistentDisks []bideplmanifest.PersistentDisk, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
+	disks, err := vm.diskDeployer.Deploy(persistentDisks, vm.cloud, vm, eventLoggerStage)
 	if err != nil {
 		return disks, bosherr.WrapError(err, "Deploying disk")
 	}
@@ -174,6 +175,22 @@ func (vm *vm) WaitToBeRunning(maxAttempts int, delay time.Duration) error {
 }
 
 func (vm *vm) AttachDisk(disk bidisk.Disk) error {
+	err := vm.AttachDiskWithoutMounting(disk)
+	if err != nil {
+		return err
+	}
+
+	err = vm.agentClient.MountDisk(disk.CID())
+	if err != nil {
+		return bosherr.WrapError(err, "Mounting disk")
+	}
+
+	return nil
+}
+
+// AttachDiskWithoutMounting leaves the disk for jobs to mount themselves
+// so that agent does not treat it as its persistent disk
+func (vm *vm) AttachDiskWithoutMounting(disk bidisk.Disk) error {
 	err := vm.cloud.AttachDisk(vm.cid, disk.CID())
 	if err != nil {
 		return bosherr.WrapError(err, "Attaching disk in the cloud")
@@ -194,11 +211,6 @@ func (vm *vm) AttachDisk(disk bidisk.Disk) error {
 		return bosherr.WrapError(err, "Waiting for agent to be accessible after attaching disk")
 	}
 
-	err = vm.agentClient.MountDisk(disk.CID())
-	if err != nil {
-		return bosherr.WrapError(err, "Mounting disk")
-	}
-
 	return nil
 }
 
@@ -35,7 +35,7 @@ var _ = Describe("VM", func() {
 		fakeAgentClient  *fakebiagentclient.FakeAgentClient
 		fakeCloud        *fakebicloud.FakeCloud
 		applySpec        bias.ApplySpec
-		diskPool         bideplmanifest.DiskPool
+		persistentDisks  []bideplmanifest.PersistentDisk
 		timeService      *FakeClock
 		fs               *fakesys.FakeFileSystem
 		logger           fakedir.Logger
@@ -51,11 +51,12 @@ var _ = Describe("VM", func() {
 			Deployment: "fake-deployment-name",
 		}
 
-		diskPool = bideplmanifest.DiskPool{
-			Name:     "fake-persistent-disk-pool-name",
-			DiskSize: 1024,
-			CloudProperties: biproperty.Map{
-				"fake-disk-pool-cloud-property-key": "fake-disk-pool-cloud-property-value",
+		persistentDisks = []bideplmanifest.PersistentDisk{
+			{
+				DiskSize: 1024,
+				CloudProperties: biproperty.Map{
+					"fake-disk-pool-cloud-property-key": "fake-disk-pool-cloud-property-value",
+				},
 			},
 		}
 
@@ -117,13 +118,13 @@ var _ = Describe("VM", func() {
 		It("delegates to DiskDeployer.Deploy", func() {
 			fakeStage := fakebiui.NewFakeStage()
 
-			disks, err := vm.UpdateDisks(diskPool, fakeStage)
+			disks, err := vm.UpdateDisks(persistentDisks, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(disks).To(Equal(expectedDisks))
 
 			Expect(fakeDiskDeployer.DeployInputs).To(Equal([]fakebivm.DeployInput{
 				{
-					DiskPool:         diskPool,
+					PersistentDisks:  persistentDisks,
 					Cloud:            fakeCloud,
 					VM:               vm,
 					EventLoggerStage: fakeStage,
@@ -363,6 +364,33 @@ var _ = Describe("VM", func() {
 		})
 	})
 
+	Describe("AttachDiskWithoutMounting", func() {
+		var disk *fakebidisk.FakeDisk
+
+		BeforeEach(func() {
+			disk = fakebidisk.NewFakeDisk("fake-disk-cid")
+		})
+
+		It("attaches disk to vm in the cloud and waits for the agent without mounting the disk", func() {
+			err := vm.AttachDiskWithoutMounting(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeCloud.AttachDiskInput).To(Equal(fakebicloud.AttachDiskInput{
+				VMCID:   "fake-vm-cid",
+				DiskCID: "fake-disk-cid",
+			}))
+			Expect(fakeAgentClient.PingCallCount()).To(Equal(1))
+			Expect(fakeAgentClient.MountDiskCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if attaching disk to cloud fails", func() {
+			fakeCloud.AttachDiskErr = errors.New("fake-attach-error")
+
+			err := vm.AttachDiskWithoutMounting(disk)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-attach-error"))
+		})
+	})
+
 	Describe("DetachDisk", func() {
 		var disk *fakebidisk.FakeDisk
 
@@ -59,7 +59,10 @@ func (d DirectorImpl) DiffCloudConfig(manifest []byte) (ConfigDiff, error) {
 		return ConfigDiff{}, err
 	}
 
-	return NewConfigDiff(resp.Diff), nil
+	diff := NewConfigDiff(resp.Diff)
+	diff.Unsupported = resp.Unsupported
+
+	return diff, nil
 }
 
 func (c Client) DiffCloudConfig(manifest []byte) (ConfigDiffResponse, error) {
@@ -158,7 +158,7 @@ var _ = Describe("Director", func() {
 
 			diff, err := director.DiffCloudConfig([]byte("config"))
 			Expect(err).ToNot(HaveOccurred())
-			Expect(diff).To(Equal(ConfigDiff{}))
+			Expect(diff).To(Equal(ConfigDiff{Unsupported: true}))
 		})
 	})
 })
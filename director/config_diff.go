@@ -9,10 +9,15 @@ import (
 
 type ConfigDiff struct {
 	Diff [][]interface{}
+
+	// Unsupported is set when director does not have diff endpoint
+	Unsupported bool
 }
 
 type ConfigDiffResponse struct {
 	Diff [][]interface{} `json:"diff"`
+
+	Unsupported bool `json:"-"`
 }
 
 func NewConfigDiff(diff [][]interface{}) ConfigDiff {
@@ -28,6 +33,7 @@ func (c Client) postConfigDiff(path string, manifest []byte, setHeaders func(*ht
 	if err != nil {
 		if response != nil && response.StatusCode == http.StatusNotFound {
 			// return empty diff, just for compatibility with directors which don't have the endpoint
+			resp.Unsupported = true
 			return resp, nil
 		} else {
 			return resp, bosherr.WrapErrorf(err, "Fetching diff result")
@@ -62,7 +62,10 @@ func (d DirectorImpl) DiffCPIConfig(manifest []byte, noRedact bool) (ConfigDiff,
 		return ConfigDiff{}, err
 	}
 
-	return NewConfigDiff(resp.Diff), nil
+	diff := NewConfigDiff(resp.Diff)
+	diff.Unsupported = resp.Unsupported
+
+	return diff, nil
 }
 
 func (c Client) DiffCPIConfig(manifest []byte, noRedact bool) (ConfigDiffResponse, error) {
@@ -0,0 +1,261 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	DiffLineAdded   = "added"
+	DiffLineRemoved = "removed"
+
+	diffRedactedValue = "<redacted>"
+)
+
+// diffRedactedKeys match keys that director redacts in deployment diffs
+var diffRedactedKeys = map[string]struct{}{
+	"properties": {},
+	"env":        {},
+}
+
+// Differ produces structural YAML diffs in the same format as
+// director's /deployments/:name/diff endpoint (lines of [text, state]).
+type Differ struct{}
+
+func NewDiffer() Differ {
+	return Differ{}
+}
+
+func (d Differ) Diff(before, after []byte, redact bool) ([][]interface{}, error) {
+	var beforeObj, afterObj yaml.MapSlice
+
+	err := yaml.Unmarshal(before, &beforeObj)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling original YAML")
+	}
+
+	err = yaml.Unmarshal(after, &afterObj)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling new YAML")
+	}
+
+	diff := differ{redact: redact}
+	diff.mapSlices(0, beforeObj, afterObj, false)
+
+	if diff.err != nil {
+		return nil, diff.err
+	}
+
+	return diff.lines, nil
+}
+
+type differ struct {
+	redact bool
+	lines  [][]interface{}
+
+	// err keeps first marshalling error; remaining lines are not useful after it
+	err error
+}
+
+func (d *differ) mapSlices(indent int, before, after yaml.MapSlice, redacted bool) {
+	for _, afterItem := range after {
+		beforeItem, found := d.findKey(before, afterItem.Key)
+		itemRedacted := redacted || d.isRedactedKey(afterItem.Key)
+
+		if !found {
+			d.addYAML(indent, yaml.MapSlice{afterItem}, DiffLineAdded, itemRedacted)
+			continue
+		}
+
+		d.values(indent, afterItem.Key, beforeItem.Value, afterItem.Value, itemRedacted)
+	}
+
+	for _, beforeItem := range before {
+		if _, found := d.findKey(after, beforeItem.Key); !found {
+			itemRedacted := redacted || d.isRedactedKey(beforeItem.Key)
+			d.addYAML(indent, yaml.MapSlice{beforeItem}, DiffLineRemoved, itemRedacted)
+		}
+	}
+}
+
+func (d *differ) values(indent int, key, before, after interface{}, redacted bool) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	header := fmt.Sprintf("%s:", d.valueStr(key))
+
+	beforeMap, beforeIsMap := before.(yaml.MapSlice)
+	afterMap, afterIsMap := after.(yaml.MapSlice)
+
+	if beforeIsMap && afterIsMap {
+		d.nested(indent, header, func(sub *differ) { sub.mapSlices(indent+1, beforeMap, afterMap, redacted) })
+		return
+	}
+
+	beforeArr, beforeIsArr := before.([]interface{})
+	afterArr, afterIsArr := after.([]interface{})
+
+	if beforeIsArr && afterIsArr && d.isNamedArray(beforeArr) && d.isNamedArray(afterArr) {
+		d.nested(indent, header, func(sub *differ) { sub.namedArrays(indent, beforeArr, afterArr, redacted) })
+		return
+	}
+
+	d.addYAML(indent, yaml.MapSlice{{Key: key, Value: before}}, DiffLineRemoved, redacted)
+	d.addYAML(indent, yaml.MapSlice{{Key: key, Value: after}}, DiffLineAdded, redacted)
+}
+
+// namedArrays matches items by name (e.g. instance groups, jobs)
+func (d *differ) namedArrays(indent int, before, after []interface{}, redacted bool) {
+	for _, afterItem := range after {
+		afterMap := afterItem.(yaml.MapSlice)
+		name, _ := d.findKey(afterMap, "name")
+
+		beforeItem, found := d.findNamed(before, name.Value)
+		if !found {
+			d.addYAML(indent, []interface{}{afterMap}, DiffLineAdded, redacted)
+			continue
+		}
+
+		if reflect.DeepEqual(beforeItem, afterMap) {
+			continue
+		}
+
+		header := fmt.Sprintf("- name: %s", d.valueStr(name.Value))
+		d.nested(indent, header, func(sub *differ) { sub.mapSlices(indent+1, beforeItem, afterMap, redacted) })
+	}
+
+	for _, beforeItem := range before {
+		beforeMap := beforeItem.(yaml.MapSlice)
+		name, _ := d.findKey(beforeMap, "name")
+
+		if _, found := d.findNamed(after, name.Value); !found {
+			d.addYAML(indent, []interface{}{beforeMap}, DiffLineRemoved, redacted)
+		}
+	}
+}
+
+// nested adds header line only if nested diff produced any lines
+func (d *differ) nested(indent int, header string, diffFunc func(*differ)) {
+	sub := &differ{redact: d.redact}
+	diffFunc(sub)
+
+	if sub.err != nil && d.err == nil {
+		d.err = sub.err
+	}
+
+	if len(sub.lines) > 0 {
+		d.lines = append(d.lines, []interface{}{d.indentStr(indent) + header, nil})
+		d.lines = append(d.lines, sub.lines...)
+	}
+}
+
+func (d *differ) addYAML(indent int, obj interface{}, state string, redacted bool) {
+	if d.redact {
+		obj = d.redactObj(obj, redacted)
+	}
+
+	if d.err != nil {
+		return
+	}
+
+	bytes, err := yaml.Marshal(obj)
+	if err != nil {
+		d.err = bosherr.WrapError(err, "Marshalling diff YAML")
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n") {
+		d.lines = append(d.lines, []interface{}{d.indentStr(indent) + line, state})
+	}
+}
+
+func (d *differ) redactObj(obj interface{}, redacted bool) interface{} {
+	switch typedObj := obj.(type) {
+	case yaml.MapSlice:
+		result := yaml.MapSlice{}
+		for _, item := range typedObj {
+			itemRedacted := redacted || d.isRedactedKey(item.Key)
+			result = append(result, yaml.MapItem{Key: item.Key, Value: d.redactObj(item.Value, itemRedacted)})
+		}
+		return result
+
+	case []interface{}:
+		var result []interface{}
+		for _, item := range typedObj {
+			result = append(result, d.redactObj(item, redacted))
+		}
+		return result
+
+	default:
+		if redacted {
+			return diffRedactedValue
+		}
+		return obj
+	}
+}
+
+func (d *differ) isRedactedKey(key interface{}) bool {
+	keyStr, ok := key.(string)
+	if !ok {
+		return false
+	}
+
+	_, found := diffRedactedKeys[keyStr]
+
+	return found
+}
+
+func (d *differ) isNamedArray(arr []interface{}) bool {
+	for _, item := range arr {
+		itemMap, ok := item.(yaml.MapSlice)
+		if !ok {
+			return false
+		}
+
+		if _, found := d.findKey(itemMap, "name"); !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *differ) findKey(obj yaml.MapSlice, key interface{}) (yaml.MapItem, bool) {
+	for _, item := range obj {
+		if reflect.DeepEqual(item.Key, key) {
+			return item, true
+		}
+	}
+
+	return yaml.MapItem{}, false
+}
+
+func (d *differ) findNamed(arr []interface{}, name interface{}) (yaml.MapSlice, bool) {
+	for _, item := range arr {
+		itemMap := item.(yaml.MapSlice)
+
+		if itemName, found := d.findKey(itemMap, "name"); found && reflect.DeepEqual(itemName.Value, name) {
+			return itemMap, true
+		}
+	}
+
+	return nil, false
+}
+
+func (d *differ) valueStr(val interface{}) string {
+	bytes, err := yaml.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+
+	return strings.TrimSuffix(string(bytes), "\n")
+}
+
+func (d *differ) indentStr(indent int) string {
+	return strings.Repeat("  ", indent)
+}
@@ -0,0 +1,152 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director/manifest"
+)
+
+var _ = Describe("Differ", func() {
+	var (
+		differ Differ
+	)
+
+	BeforeEach(func() {
+		differ = NewDiffer()
+	})
+
+	const before = `
+name: dep
+releases:
+- name: rel
+  version: 1
+instance_groups:
+- name: web
+  instances: 2
+  azs: [z1]
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      port: 80
+      tls: {cert: CERT}
+- name: worker
+  instances: 1
+properties:
+  secret: abc
+`
+
+	It("returns no lines for equal manifests", func() {
+		lines, err := differ.Diff([]byte(before), []byte(before), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(BeEmpty())
+	})
+
+	It("shows changed, added and removed values with parent context", func() {
+		after := `
+name: dep
+releases:
+- name: rel
+  version: 2
+instance_groups:
+- name: web
+  instances: 3
+  azs: [z1, z2]
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      port: 80
+      tls: {cert: CERT}
+- name: api
+  instances: 1
+properties:
+  secret: abc
+update:
+  canaries: 1
+`
+
+		lines, err := differ.Diff([]byte(before), []byte(after), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(Equal([][]interface{}{
+			{"releases:", nil},
+			{"- name: rel", nil},
+			{"  version: 1", "removed"},
+			{"  version: 2", "added"},
+			{"instance_groups:", nil},
+			{"- name: web", nil},
+			{"  instances: 2", "removed"},
+			{"  instances: 3", "added"},
+			{"  azs:", "removed"},
+			{"  - z1", "removed"},
+			{"  azs:", "added"},
+			{"  - z1", "added"},
+			{"  - z2", "added"},
+			{"- name: api", "added"},
+			{"  instances: 1", "added"},
+			{"- name: worker", "removed"},
+			{"  instances: 1", "removed"},
+			{"update:", "added"},
+			{"  canaries: 1", "added"},
+		}))
+	})
+
+	It("redacts values under properties and env", func() {
+		after := `
+name: dep
+releases:
+- name: rel
+  version: 1
+instance_groups:
+- name: web
+  instances: 2
+  azs: [z1]
+  env: {password: new}
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      port: 8080
+      tls: {cert: CERT}
+- name: worker
+  instances: 1
+properties:
+  secret: abc
+  other: [a, b]
+`
+
+		lines, err := differ.Diff([]byte(before), []byte(after), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(Equal([][]interface{}{
+			{"instance_groups:", nil},
+			{"- name: web", nil},
+			{"  env:", "added"},
+			{"    password: <redacted>", "added"},
+			{"  jobs:", nil},
+			{"  - name: web", nil},
+			{"    properties:", nil},
+			{"      port: <redacted>", "removed"},
+			{"      port: <redacted>", "added"},
+			{"properties:", nil},
+			{"  other:", "added"},
+			{"  - <redacted>", "added"},
+			{"  - <redacted>", "added"},
+		}))
+
+		lines, err = differ.Diff([]byte(before), []byte(after), false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines).To(ContainElement([]interface{}{"      port: 8080", "added"}))
+		Expect(lines).To(ContainElement([]interface{}{"    password: new", "added"}))
+	})
+
+	It("returns error if YAML cannot be parsed", func() {
+		_, err := differ.Diff([]byte("key: ["), []byte(before), true)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling original YAML"))
+
+		_, err = differ.Diff([]byte(before), []byte("key: ["), true)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling new YAML"))
+	})
+})
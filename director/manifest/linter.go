@@ -0,0 +1,707 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Problem describes a single issue found in a manifest or a config.
+// Path uses the same notation as create-env validation (e.g. instance_groups[0].vm_type).
+type Problem struct {
+	Source  string
+	Path    string
+	Message string
+}
+
+type LintInput struct {
+	// Manifest is the deployment manifest after ops files and variables are applied
+	Manifest []byte
+
+	// RawManifest is the deployment manifest with ops files applied but before
+	// variable interpolation; it is used to find variable references.
+	// Manifest is used if it's not provided.
+	RawManifest []byte
+
+	CloudConfig    []byte
+	RuntimeConfigs [][]byte
+}
+
+type Linter struct{}
+
+func NewLinter() Linter {
+	return Linter{}
+}
+
+var (
+	lintVarRefRegex      = regexp.MustCompile(`\(\((!?[-/\.\w\pL]+)\)\)`)
+	lintPlaceholderRegex = regexp.MustCompile(`\A\(\((!?[-/\.\w\pL]+)\)\)\z`)
+	lintWatchTimeRegex   = regexp.MustCompile(`\A\s*(\d+)\s*-\s*(\d+)\s*\z`)
+	lintPercentageRegex  = regexp.MustCompile(`\A\d+%\z`)
+
+	lintManifestKeys = []string{
+		"name", "director_uuid", "releases", "stemcells", "update", "instance_groups",
+		"jobs", "variables", "properties", "tags", "features", "addons",
+		// v1 manifests may still include cloud config sections
+		"networks", "resource_pools", "disk_pools", "compilation",
+	}
+
+	lintInstanceGroupKeys = []string{
+		"name", "azs", "instances", "jobs", "templates", "vm_type", "vm_extensions", "vm_resources",
+		"stemcell", "persistent_disk", "persistent_disk_type", "persistent_disk_pool", "persistent_disks", "networks",
+		"update", "migrated_from", "lifecycle", "properties", "env", "resource_pool",
+	}
+
+	lintJobKeys = []string{
+		"name", "release", "properties", "consumes", "provides", "custom_provider_definitions",
+	}
+
+	lintUpdateKeys = []string{
+		"canaries", "max_in_flight", "canary_watch_time", "update_watch_time", "serial",
+	}
+
+	lintCloudConfigKeys = []string{
+		"azs", "vm_types", "vm_extensions", "disk_types", "networks", "compilation",
+	}
+
+	lintRuntimeConfigKeys = []string{
+		"releases", "addons", "tags", "variables",
+	}
+
+	lintAddonKeys = []string{
+		"name", "jobs", "properties", "include", "exclude",
+	}
+)
+
+func (l Linter) Lint(input LintInput) ([]Problem, error) {
+	var problems []Problem
+
+	manifest, err := l.parseMap(input.Manifest, "manifest")
+	if err != nil {
+		return nil, err
+	}
+
+	cc := newLintCloudConfig()
+
+	if len(input.CloudConfig) > 0 {
+		ccMap, err := l.parseMap(input.CloudConfig, "cloud config")
+		if err != nil {
+			return nil, err
+		}
+
+		cc, problems = l.lintCloudConfig(ccMap, problems)
+	}
+
+	problems = l.lintManifest(manifest, cc, len(input.CloudConfig) > 0, problems)
+
+	rawManifest := input.RawManifest
+	if len(rawManifest) == 0 {
+		rawManifest = input.Manifest
+	}
+
+	problems, err = l.lintUnusedVariables(manifest, rawManifest, problems)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rcBytes := range input.RuntimeConfigs {
+		source := "runtime config"
+		if len(input.RuntimeConfigs) > 1 {
+			source = fmt.Sprintf("runtime config %d", i+1)
+		}
+
+		rcMap, err := l.parseMap(rcBytes, source)
+		if err != nil {
+			return nil, err
+		}
+
+		problems = l.lintRuntimeConfig(source, rcMap, problems)
+	}
+
+	return problems, nil
+}
+
+func (l Linter) parseMap(bytes []byte, desc string) (map[interface{}]interface{}, error) {
+	var obj interface{}
+
+	err := yaml.Unmarshal(bytes, &obj)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Unmarshalling %s", desc)
+	}
+
+	if obj == nil {
+		return map[interface{}]interface{}{}, nil
+	}
+
+	typedObj, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		return nil, bosherr.Errorf("Expected %s to be a hash", desc)
+	}
+
+	return typedObj, nil
+}
+
+type lintCloudConfig struct {
+	AZs          map[string]struct{}
+	VMTypes      map[string]struct{}
+	VMExtensions map[string]struct{}
+	DiskTypes    map[string]struct{}
+	Networks     map[string]struct{}
+}
+
+func newLintCloudConfig() lintCloudConfig {
+	return lintCloudConfig{
+		AZs:          map[string]struct{}{},
+		VMTypes:      map[string]struct{}{},
+		VMExtensions: map[string]struct{}{},
+		DiskTypes:    map[string]struct{}{},
+		Networks:     map[string]struct{}{},
+	}
+}
+
+func (l Linter) lintCloudConfig(cc map[interface{}]interface{}, problems []Problem) (lintCloudConfig, []Problem) {
+	const source = "cloud config"
+
+	result := newLintCloudConfig()
+	r := lintReporter{source: source, problems: problems}
+
+	r.unknownKeys("", cc, lintCloudConfigKeys)
+
+	sections := []struct {
+		Key   string
+		Names map[string]struct{}
+	}{
+		{"azs", result.AZs},
+		{"vm_types", result.VMTypes},
+		{"vm_extensions", result.VMExtensions},
+		{"disk_types", result.DiskTypes},
+		{"networks", result.Networks},
+	}
+
+	for _, section := range sections {
+		for idx, item := range r.list(section.Key, cc[section.Key]) {
+			r.uniqueName(fmt.Sprintf("%s[%d]", section.Key, idx), item, section.Names)
+		}
+	}
+
+	for idx, network := range r.list("networks", cc["networks"]) {
+		netPath := fmt.Sprintf("networks[%d]", idx)
+
+		for subnetIdx, subnet := range r.list(netPath+".subnets", network["subnets"]) {
+			subnetPath := fmt.Sprintf("%s.subnets[%d]", netPath, subnetIdx)
+
+			r.refs(subnetPath+".azs", subnet["azs"], result.AZs, "an AZ")
+
+			if az, ok := subnet["az"]; ok {
+				r.ref(subnetPath+".az", az, result.AZs, "an AZ")
+			}
+		}
+	}
+
+	if compilation, ok := r.hash("compilation", cc["compilation"]); ok {
+		if az, ok := compilation["az"]; ok {
+			r.ref("compilation.az", az, result.AZs, "an AZ")
+		}
+
+		if network, ok := compilation["network"]; ok {
+			r.ref("compilation.network", network, result.Networks, "a network")
+		} else {
+			r.add("compilation.network", "must be provided")
+		}
+
+		if vmType, ok := compilation["vm_type"]; ok {
+			r.ref("compilation.vm_type", vmType, result.VMTypes, "a VM type")
+		}
+	}
+
+	return result, r.problems
+}
+
+func (l Linter) lintManifest(manifest map[interface{}]interface{}, cc lintCloudConfig, checkCC bool, problems []Problem) []Problem {
+	r := lintReporter{source: "manifest", problems: problems}
+
+	r.unknownKeys("", manifest, lintManifestKeys)
+
+	if name, ok := manifest["name"]; !ok || r.isBlank(name) {
+		r.add("name", "must be provided")
+	}
+
+	releaseNames := map[string]struct{}{}
+
+	for idx, release := range r.list("releases", manifest["releases"]) {
+		path := fmt.Sprintf("releases[%d]", idx)
+		r.uniqueName(path, release, releaseNames)
+
+		if version, ok := release["version"]; !ok || r.isBlank(version) {
+			r.add(path+".version", "must be provided")
+		}
+	}
+
+	stemcellAliases := map[string]struct{}{}
+
+	for idx, stemcell := range r.list("stemcells", manifest["stemcells"]) {
+		path := fmt.Sprintf("stemcells[%d]", idx)
+
+		alias, ok := stemcell["alias"]
+		if !ok || r.isBlank(alias) {
+			r.add(path+".alias", "must be provided")
+		} else if aliasStr, ok := alias.(string); ok {
+			if _, found := stemcellAliases[aliasStr]; found {
+				r.add(path+".alias", fmt.Sprintf("'%s' must be unique", aliasStr))
+			}
+			stemcellAliases[aliasStr] = struct{}{}
+		}
+
+		_, hasOS := stemcell["os"]
+		_, hasName := stemcell["name"]
+		if !hasOS && !hasName {
+			r.add(path, "must specify either os or name")
+		}
+
+		if version, ok := stemcell["version"]; !ok || r.isBlank(version) {
+			r.add(path+".version", "must be provided")
+		}
+	}
+
+	_, hasTopLevelUpdate := manifest["update"]
+	if hasTopLevelUpdate {
+		r.update("update", manifest["update"])
+	}
+
+	igKey := "instance_groups"
+	if _, ok := manifest[igKey]; !ok {
+		if _, ok := manifest["jobs"]; ok {
+			igKey = "jobs"
+		}
+	}
+
+	igNames := map[string]struct{}{}
+
+	for idx, ig := range r.list(igKey, manifest[igKey]) {
+		path := fmt.Sprintf("%s[%d]", igKey, idx)
+
+		r.unknownKeys(path, ig, lintInstanceGroupKeys)
+		r.uniqueName(path, ig, igNames)
+
+		if instances, ok := ig["instances"]; !ok {
+			r.add(path+".instances", "must be provided")
+		} else {
+			r.nonNegativeInt(path+".instances", instances)
+		}
+
+		if lifecycle, ok := ig["lifecycle"]; ok && !r.isPlaceholder(lifecycle) {
+			if lifecycle != "service" && lifecycle != "errand" {
+				r.add(path+".lifecycle", fmt.Sprintf("must be 'service' or 'errand' ('%v' not supported)", lifecycle))
+			}
+		}
+
+		if update, ok := ig["update"]; ok {
+			r.update(path+".update", update)
+		} else if !hasTopLevelUpdate {
+			r.add(path+".update", "must be provided since there is no top level update section")
+		}
+
+		if stemcell, ok := ig["stemcell"]; ok && len(stemcellAliases) > 0 {
+			r.ref(path+".stemcell", stemcell, stemcellAliases, "a stemcell alias")
+		}
+
+		persistentDisks := r.list(path+".persistent_disks", ig["persistent_disks"])
+
+		if checkCC {
+			r.refs(path+".azs", ig["azs"], cc.AZs, "an AZ")
+			r.refs(path+".vm_extensions", ig["vm_extensions"], cc.VMExtensions, "a VM extension")
+
+			if vmType, ok := ig["vm_type"]; ok {
+				r.ref(path+".vm_type", vmType, cc.VMTypes, "a VM type")
+			}
+
+			if diskType, ok := ig["persistent_disk_type"]; ok {
+				r.ref(path+".persistent_disk_type", diskType, cc.DiskTypes, "a disk type")
+			}
+
+			for diskIdx, disk := range persistentDisks {
+				if diskType, ok := disk["type"]; ok {
+					r.ref(fmt.Sprintf("%s.persistent_disks[%d].type", path, diskIdx), diskType, cc.DiskTypes, "a disk type")
+				}
+			}
+		}
+
+		networks := r.list(path+".networks", ig["networks"])
+		if len(networks) == 0 {
+			r.add(path+".networks", "must be a non-empty array")
+		}
+
+		for netIdx, network := range networks {
+			netPath := fmt.Sprintf("%s.networks[%d]", path, netIdx)
+
+			name, ok := network["name"]
+			if !ok || r.isBlank(name) {
+				r.add(netPath+".name", "must be provided")
+			} else if checkCC {
+				r.ref(netPath+".name", name, cc.Networks, "a network")
+			}
+		}
+
+		jobsKey := "jobs"
+		if _, ok := ig[jobsKey]; !ok {
+			if _, ok := ig["templates"]; ok {
+				jobsKey = "templates"
+			}
+		}
+
+		jobNames := map[string]struct{}{}
+
+		for jobIdx, job := range r.list(path+"."+jobsKey, ig[jobsKey]) {
+			jobPath := fmt.Sprintf("%s.%s[%d]", path, jobsKey, jobIdx)
+
+			r.unknownKeys(jobPath, job, lintJobKeys)
+			r.uniqueName(jobPath, job, jobNames)
+
+			if release, ok := job["release"]; !ok || r.isBlank(release) {
+				r.add(jobPath+".release", "must be provided")
+			} else {
+				r.ref(jobPath+".release", release, releaseNames, "a release in releases")
+			}
+		}
+	}
+
+	varNames := map[string]struct{}{}
+
+	for idx, variable := range r.list("variables", manifest["variables"]) {
+		path := fmt.Sprintf("variables[%d]", idx)
+		r.uniqueName(path, variable, varNames)
+
+		if typ, ok := variable["type"]; !ok || r.isBlank(typ) {
+			r.add(path+".type", "must be provided")
+		}
+	}
+
+	return r.problems
+}
+
+func (l Linter) lintUnusedVariables(manifest map[interface{}]interface{}, rawManifest []byte, problems []Problem) ([]Problem, error) {
+	r := lintReporter{source: "manifest", problems: problems}
+
+	rawMap, err := l.parseMap(rawManifest, "manifest")
+	if err != nil {
+		return nil, err
+	}
+
+	variables := r.list("variables", rawMap["variables"])
+
+	// Variables section itself should not count as usage, except for CA references
+	withoutVars := map[interface{}]interface{}{}
+	for k, v := range rawMap {
+		if k != "variables" {
+			withoutVars[k] = v
+		}
+	}
+
+	bytes, err := yaml.Marshal(withoutVars)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling manifest")
+	}
+
+	used := map[string]struct{}{}
+
+	for _, match := range lintVarRefRegex.FindAllStringSubmatch(string(bytes), -1) {
+		name := strings.TrimPrefix(match[1], "!")
+		used[strings.SplitN(name, ".", 2)[0]] = struct{}{}
+	}
+
+	for _, variable := range variables {
+		if opts, ok := variable["options"].(map[interface{}]interface{}); ok {
+			if ca, ok := opts["ca"].(string); ok {
+				used[ca] = struct{}{}
+			}
+		}
+	}
+
+	for idx, variable := range variables {
+		name, ok := variable["name"].(string)
+		if !ok || len(name) == 0 {
+			continue
+		}
+
+		if _, found := used[name]; !found {
+			r.add(fmt.Sprintf("variables[%d]", idx), fmt.Sprintf("'%s' is not used", name))
+		}
+	}
+
+	return r.problems, nil
+}
+
+func (l Linter) lintRuntimeConfig(source string, rc map[interface{}]interface{}, problems []Problem) []Problem {
+	r := lintReporter{source: source, problems: problems}
+
+	r.unknownKeys("", rc, lintRuntimeConfigKeys)
+
+	releaseNames := map[string]struct{}{}
+
+	for idx, release := range r.list("releases", rc["releases"]) {
+		path := fmt.Sprintf("releases[%d]", idx)
+		r.uniqueName(path, release, releaseNames)
+
+		if version, ok := release["version"]; !ok || r.isBlank(version) {
+			r.add(path+".version", "must be provided")
+		}
+	}
+
+	addonNames := map[string]struct{}{}
+
+	for idx, addon := range r.list("addons", rc["addons"]) {
+		path := fmt.Sprintf("addons[%d]", idx)
+
+		r.unknownKeys(path, addon, lintAddonKeys)
+		r.uniqueName(path, addon, addonNames)
+
+		jobNames := map[string]struct{}{}
+
+		for jobIdx, job := range r.list(path+".jobs", addon["jobs"]) {
+			jobPath := fmt.Sprintf("%s.jobs[%d]", path, jobIdx)
+
+			r.unknownKeys(jobPath, job, lintJobKeys)
+			r.uniqueName(jobPath, job, jobNames)
+
+			if release, ok := job["release"]; !ok || r.isBlank(release) {
+				r.add(jobPath+".release", "must be provided")
+			} else {
+				r.ref(jobPath+".release", release, releaseNames, "a release in releases")
+			}
+		}
+	}
+
+	return r.problems
+}
+
+type lintReporter struct {
+	source   string
+	problems []Problem
+}
+
+func (r *lintReporter) add(path, msg string) {
+	r.problems = append(r.problems, Problem{Source: r.source, Path: path, Message: msg})
+}
+
+func (r *lintReporter) join(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+func (r *lintReporter) unknownKeys(path string, obj map[interface{}]interface{}, allowedKeys []string) {
+	var unknown []string
+
+	for key := range obj {
+		keyStr := fmt.Sprintf("%v", key)
+		if r.isPlaceholder(keyStr) {
+			continue
+		}
+
+		found := false
+		for _, allowedKey := range allowedKeys {
+			if keyStr == allowedKey {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			unknown = append(unknown, keyStr)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		r.add(r.join(path, key), "is not a known key")
+	}
+}
+
+func (r *lintReporter) list(path string, obj interface{}) []map[interface{}]interface{} {
+	if obj == nil || r.isPlaceholder(obj) {
+		return nil
+	}
+
+	typedObj, ok := obj.([]interface{})
+	if !ok {
+		r.add(path, "must be an array")
+		return nil
+	}
+
+	var items []map[interface{}]interface{}
+
+	for idx, item := range typedObj {
+		typedItem, ok := item.(map[interface{}]interface{})
+		if !ok {
+			r.add(fmt.Sprintf("%s[%d]", path, idx), "must be a hash")
+			continue
+		}
+		items = append(items, typedItem)
+	}
+
+	return items
+}
+
+func (r *lintReporter) hash(path string, obj interface{}) (map[interface{}]interface{}, bool) {
+	if obj == nil || r.isPlaceholder(obj) {
+		return nil, false
+	}
+
+	typedObj, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		r.add(path, "must be a hash")
+		return nil, false
+	}
+
+	return typedObj, true
+}
+
+func (r *lintReporter) uniqueName(path string, obj map[interface{}]interface{}, names map[string]struct{}) {
+	name, ok := obj["name"]
+	if !ok || r.isBlank(name) {
+		r.add(path+".name", "must be provided")
+		return
+	}
+
+	nameStr := fmt.Sprintf("%v", name)
+
+	if _, found := names[nameStr]; found {
+		r.add(path+".name", fmt.Sprintf("'%s' must be unique", nameStr))
+	}
+
+	names[nameStr] = struct{}{}
+}
+
+func (r *lintReporter) ref(path string, obj interface{}, names map[string]struct{}, desc string) {
+	if r.isPlaceholder(obj) {
+		return
+	}
+
+	name, ok := obj.(string)
+	if !ok {
+		r.add(path, "must be a string")
+		return
+	}
+
+	if _, found := names[name]; !found {
+		r.add(path, fmt.Sprintf("must refer to %s ('%s' is not defined)", desc, name))
+	}
+}
+
+func (r *lintReporter) refs(path string, obj interface{}, names map[string]struct{}, desc string) {
+	if obj == nil || r.isPlaceholder(obj) {
+		return
+	}
+
+	typedObj, ok := obj.([]interface{})
+	if !ok {
+		r.add(path, "must be an array")
+		return
+	}
+
+	for idx, item := range typedObj {
+		r.ref(fmt.Sprintf("%s[%d]", path, idx), item, names, desc)
+	}
+}
+
+func (r *lintReporter) update(path string, obj interface{}) {
+	update, ok := r.hash(path, obj)
+	if !ok {
+		return
+	}
+
+	r.unknownKeys(path, update, lintUpdateKeys)
+
+	if canaries, ok := update["canaries"]; ok {
+		r.nonNegativeInt(path+".canaries", canaries)
+	}
+
+	if maxInFlight, ok := update["max_in_flight"]; ok && !r.isPlaceholder(maxInFlight) {
+		switch typedVal := maxInFlight.(type) {
+		case int:
+			if typedVal < 1 {
+				r.add(path+".max_in_flight", "must be >= 1")
+			}
+		case string:
+			if !lintPercentageRegex.MatchString(typedVal) {
+				r.add(path+".max_in_flight", "must be an integer or a percentage (e.g. 25%)")
+			} else if pct, _ := strconv.Atoi(strings.TrimSuffix(typedVal, "%")); pct < 1 || pct > 100 {
+				r.add(path+".max_in_flight", "must be a percentage between 1% and 100%")
+			}
+		default:
+			r.add(path+".max_in_flight", "must be an integer or a percentage (e.g. 25%)")
+		}
+	}
+
+	for _, key := range []string{"canary_watch_time", "update_watch_time"} {
+		if watchTime, ok := update[key]; ok && !r.isPlaceholder(watchTime) {
+			r.watchTime(path+"."+key, watchTime)
+		}
+	}
+
+	if serial, ok := update["serial"]; ok && !r.isPlaceholder(serial) {
+		if _, ok := serial.(bool); !ok {
+			r.add(path+".serial", "must be a boolean")
+		}
+	}
+}
+
+func (r *lintReporter) watchTime(path string, obj interface{}) {
+	switch typedVal := obj.(type) {
+	case int:
+		if typedVal < 0 {
+			r.add(path, "must be >= 0")
+		}
+	case string:
+		matches := lintWatchTimeRegex.FindStringSubmatch(typedVal)
+		if matches == nil {
+			r.add(path, "must be an integer or a range (e.g. 1000-30000)")
+			return
+		}
+
+		start, _ := strconv.Atoi(matches[1])
+		end, _ := strconv.Atoi(matches[2])
+
+		if start > end {
+			r.add(path, fmt.Sprintf("range start must be <= range end ('%s')", typedVal))
+		}
+	default:
+		r.add(path, "must be an integer or a range (e.g. 1000-30000)")
+	}
+}
+
+func (r *lintReporter) nonNegativeInt(path string, obj interface{}) {
+	if r.isPlaceholder(obj) {
+		return
+	}
+
+	val, ok := obj.(int)
+	if !ok {
+		r.add(path, "must be an integer")
+	} else if val < 0 {
+		r.add(path, "must be >= 0")
+	}
+}
+
+func (r *lintReporter) isBlank(obj interface{}) bool {
+	if obj == nil {
+		return true
+	}
+
+	str, ok := obj.(string)
+
+	return ok && len(strings.TrimSpace(str)) == 0
+}
+
+func (r *lintReporter) isPlaceholder(obj interface{}) bool {
+	str, ok := obj.(string)
+
+	return ok && lintPlaceholderRegex.MatchString(str)
+}
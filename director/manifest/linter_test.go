@@ -0,0 +1,305 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director/manifest"
+)
+
+var _ = Describe("Linter", func() {
+	var (
+		linter Linter
+	)
+
+	const validManifest = `
+name: dep
+releases:
+- name: rel
+  version: 1
+stemcells:
+- alias: default
+  os: ubuntu-trusty
+  version: latest
+update:
+  canaries: 1
+  max_in_flight: 25%
+  canary_watch_time: 1000-30000
+  update_watch_time: 5000
+instance_groups:
+- name: web
+  instances: 2
+  azs: [z1]
+  vm_type: small
+  stemcell: default
+  persistent_disk_type: ((disk_type))
+  networks:
+  - name: default
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      password: ((password))
+variables:
+- name: password
+  type: password
+`
+
+	const validCloudConfig = `
+azs:
+- name: z1
+vm_types:
+- name: small
+disk_types:
+- name: ssd
+networks:
+- name: default
+  subnets:
+  - azs: [z1]
+compilation:
+  az: z1
+  network: default
+  vm_type: small
+`
+
+	BeforeEach(func() {
+		linter = NewLinter()
+	})
+
+	It("returns no problems for valid manifest and cloud config", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest:    []byte(validManifest),
+			CloudConfig: []byte(validCloudConfig),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("reports unknown keys", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(validManifest + `
+unknown: true
+`),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "manifest", Path: "unknown", Message: "is not a known key"},
+		}))
+	})
+
+	It("reports unknown instance group and job keys", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+releases: [{name: rel, version: 1}]
+update: {canaries: 1}
+instance_groups:
+- name: web
+  instances: 1
+  vm_tpye: small
+  persistent_disks: [{name: data, type: ssd}]
+  networks: [{name: default}]
+  jobs:
+  - name: web
+    release: rel
+    propertes: {}
+`),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "manifest", Path: "instance_groups[0].vm_tpye", Message: "is not a known key"},
+			{Source: "manifest", Path: "instance_groups[0].jobs[0].propertes", Message: "is not a known key"},
+		}))
+	})
+
+	It("reports references to undefined cloud config resources", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+releases: [{name: rel, version: 1}]
+update: {canaries: 1}
+instance_groups:
+- name: web
+  instances: 1
+  azs: [z1, z2]
+  vm_type: large
+  vm_extensions: [lb]
+  persistent_disk_type: hdd
+  persistent_disks: [{name: data, type: ssd}, {name: logs, type: hdd}]
+  networks: [{name: private}]
+  jobs: [{name: web, release: other-rel}]
+`),
+			CloudConfig: []byte(validCloudConfig),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "manifest", Path: "instance_groups[0].azs[1]", Message: "must refer to an AZ ('z2' is not defined)"},
+			{Source: "manifest", Path: "instance_groups[0].vm_extensions[0]", Message: "must refer to a VM extension ('lb' is not defined)"},
+			{Source: "manifest", Path: "instance_groups[0].vm_type", Message: "must refer to a VM type ('large' is not defined)"},
+			{Source: "manifest", Path: "instance_groups[0].persistent_disk_type", Message: "must refer to a disk type ('hdd' is not defined)"},
+			{Source: "manifest", Path: "instance_groups[0].persistent_disks[1].type", Message: "must refer to a disk type ('hdd' is not defined)"},
+			{Source: "manifest", Path: "instance_groups[0].networks[0].name", Message: "must refer to a network ('private' is not defined)"},
+			{Source: "manifest", Path: "instance_groups[0].jobs[0].release", Message: "must refer to a release in releases ('other-rel' is not defined)"},
+		}))
+	})
+
+	It("does not check cloud config references if cloud config is not provided", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(validManifest),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("reports duplicate names", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+releases: [{name: rel, version: 1}, {name: rel, version: 2}]
+stemcells: [{alias: default, os: ubuntu, version: 1}, {alias: default, os: ubuntu, version: 2}]
+update: {canaries: 1}
+instance_groups:
+- {name: web, instances: 1, networks: [{name: default}], jobs: [{name: web, release: rel}, {name: web, release: rel}]}
+- {name: web, instances: 1, networks: [{name: default}], jobs: []}
+`),
+			CloudConfig: []byte(`
+networks: [{name: default}, {name: default}]
+`),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "cloud config", Path: "networks[1].name", Message: "'default' must be unique"},
+			{Source: "manifest", Path: "releases[1].name", Message: "'rel' must be unique"},
+			{Source: "manifest", Path: "stemcells[1].alias", Message: "'default' must be unique"},
+			{Source: "manifest", Path: "instance_groups[0].jobs[1].name", Message: "'web' must be unique"},
+			{Source: "manifest", Path: "instance_groups[1].name", Message: "'web' must be unique"},
+		}))
+	})
+
+	It("reports bad update values", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+update:
+  canaries: -1
+  max_in_flight: 0
+  canary_watch_time: 30000-1000
+  update_watch_time: abc
+  serial: "yes"
+  max_inflight: 1
+instance_groups:
+- name: web
+  instances: 1
+  networks: [{name: default}]
+  update: {max_in_flight: 200%}
+`),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "manifest", Path: "update.max_inflight", Message: "is not a known key"},
+			{Source: "manifest", Path: "update.canaries", Message: "must be >= 0"},
+			{Source: "manifest", Path: "update.max_in_flight", Message: "must be >= 1"},
+			{Source: "manifest", Path: "update.canary_watch_time", Message: "range start must be <= range end ('30000-1000')"},
+			{Source: "manifest", Path: "update.update_watch_time", Message: "must be an integer or a range (e.g. 1000-30000)"},
+			{Source: "manifest", Path: "update.serial", Message: "must be a boolean"},
+			{Source: "manifest", Path: "instance_groups[0].update.max_in_flight", Message: "must be a percentage between 1% and 100%"},
+		}))
+	})
+
+	It("reports missing update section", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+instance_groups:
+- {name: web, instances: 1, networks: [{name: default}]}
+`),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "manifest", Path: "instance_groups[0].update", Message: "must be provided since there is no top level update section"},
+		}))
+	})
+
+	It("reports unused variables based on raw manifest", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+update: {canaries: 1}
+properties:
+  password: interpolated
+  cert: ((cert.certificate))
+variables:
+- {name: password, type: password}
+- {name: ca, type: certificate}
+- {name: cert, type: certificate, options: {ca: ca}}
+- {name: unused, type: password}
+`),
+			RawManifest: []byte(`
+name: dep
+properties:
+  password: ((password))
+  cert: ((cert.certificate))
+variables:
+- {name: password, type: password}
+- {name: ca, type: certificate}
+- {name: cert, type: certificate, options: {ca: ca}}
+- {name: unused, type: password}
+`),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "manifest", Path: "variables[3]", Message: "'unused' is not used"},
+		}))
+	})
+
+	It("tolerates unresolved variables in place of values", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(`
+name: dep
+releases: [{name: rel, version: ((rel_version))}]
+update: {canaries: ((canaries)), max_in_flight: ((max_in_flight))}
+instance_groups:
+- name: web
+  instances: ((instances))
+  azs: ((azs))
+  vm_type: ((vm_type))
+  networks: [{name: ((network))}]
+  jobs: [{name: web, release: rel}]
+`),
+			CloudConfig: []byte(validCloudConfig),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("reports runtime config problems", func() {
+		problems, err := linter.Lint(LintInput{
+			Manifest: []byte(validManifest),
+			RuntimeConfigs: [][]byte{[]byte(`
+releases: [{name: addon-rel, version: 1}]
+addons:
+- name: addon
+  jobs: [{name: job, release: other-rel}]
+- name: addon
+  jobs: []
+`)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Source: "runtime config", Path: "addons[0].jobs[0].release", Message: "must refer to a release in releases ('other-rel' is not defined)"},
+			{Source: "runtime config", Path: "addons[1].name", Message: "'addon' must be unique"},
+		}))
+	})
+
+	It("returns error if manifest is not a hash", func() {
+		_, err := linter.Lint(LintInput{Manifest: []byte(`- item`)})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected manifest to be a hash"))
+	})
+
+	It("returns error if manifest cannot be parsed", func() {
+		_, err := linter.Lint(LintInput{Manifest: []byte(validManifest), CloudConfig: []byte(`{`)})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling"))
+	})
+})
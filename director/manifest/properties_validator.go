@@ -0,0 +1,356 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+// ReleaseJobSpec holds property definitions from release job's spec file.
+type ReleaseJobSpec struct {
+	Release    string
+	Name       string
+	Properties map[string]boshjob.PropertyDefinition
+}
+
+type PropertyProblem struct {
+	InstanceGroup string
+	Job           string
+	Release       string
+	Property      string
+	Message       string
+}
+
+type PropertiesValidator struct {
+	linter Linter
+}
+
+func NewPropertiesValidator() PropertiesValidator {
+	return PropertiesValidator{linter: NewLinter()}
+}
+
+// Validate checks properties specified for each job in the manifest against
+// definitions found in release job specs. Jobs from releases without
+// provided specs are not checked. Properties are resolved the same way
+// templates see them: job level properties are used if specified,
+// otherwise instance group properties merged over global properties.
+// Instance group properties are checked against specs of all jobs using them.
+func (v PropertiesValidator) Validate(manifestBytes []byte, specs []ReleaseJobSpec) ([]PropertyProblem, error) {
+	manifest, err := v.linter.parseMap(manifestBytes, "manifest")
+	if err != nil {
+		return nil, err
+	}
+
+	knownReleases := map[string]struct{}{}
+	specsByJob := map[string]ReleaseJobSpec{}
+
+	for _, spec := range specs {
+		knownReleases[spec.Release] = struct{}{}
+		specsByJob[spec.Release+"/"+spec.Name] = spec
+	}
+
+	var problems []PropertyProblem
+
+	globalProps := v.hash(manifest["properties"])
+
+	igs, _ := manifest["instance_groups"].([]interface{})
+
+	for _, igObj := range igs {
+		ig := v.hash(igObj)
+		igName := v.str(ig["name"])
+		igProps := v.hash(ig["properties"])
+
+		// Property definitions of jobs using instance group properties;
+		// incomplete if some of those jobs do not have specs
+		igSpec := ReleaseJobSpec{Properties: map[string]boshjob.PropertyDefinition{}}
+		igSpecComplete := true
+		igPropsUsed := false
+
+		jobs, _ := ig["jobs"].([]interface{})
+
+		for _, jobObj := range jobs {
+			job := v.hash(jobObj)
+			jobName := v.str(job["name"])
+			relName := v.str(job["release"])
+
+			jobProps, hasJobProps := job["properties"]
+			hasJobProps = hasJobProps && jobProps != nil
+
+			if _, found := knownReleases[relName]; !found {
+				if !hasJobProps {
+					igSpecComplete = false
+				}
+				continue
+			}
+
+			report := func(prop, msg string) {
+				problems = append(problems, PropertyProblem{
+					InstanceGroup: igName,
+					Job:           jobName,
+					Release:       relName,
+					Property:      prop,
+					Message:       msg,
+				})
+			}
+
+			spec, found := specsByJob[relName+"/"+jobName]
+			if !found {
+				report("", fmt.Sprintf("Job '%s' is not found in release '%s'", jobName, relName))
+				if !hasJobProps {
+					igSpecComplete = false
+				}
+				continue
+			}
+
+			var effectiveProps map[interface{}]interface{}
+
+			if hasJobProps {
+				effectiveProps = v.hash(jobProps)
+				v.unknownProps("", effectiveProps, spec, report)
+			} else {
+				effectiveProps = v.merge(globalProps, igProps)
+				igPropsUsed = true
+
+				for name, def := range spec.Properties {
+					igSpec.Properties[name] = def
+				}
+			}
+
+			v.definedProps(effectiveProps, spec, report)
+		}
+
+		if igPropsUsed && igSpecComplete {
+			v.unknownProps("", igProps, igSpec, func(prop, msg string) {
+				problems = append(problems, PropertyProblem{InstanceGroup: igName, Property: prop, Message: msg})
+			})
+		}
+	}
+
+	return problems, nil
+}
+
+func (v PropertiesValidator) unknownProps(prefix string, props map[interface{}]interface{}, spec ReleaseJobSpec, report func(string, string)) {
+	var keys []string
+
+	for key := range props {
+		keys = append(keys, fmt.Sprintf("%v", key))
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := key
+		if len(prefix) > 0 {
+			name = prefix + "." + key
+		}
+
+		if _, found := spec.Properties[name]; found {
+			continue
+		}
+
+		if v.isPrefixOfDefinedProp(name, spec) {
+			if nested, ok := props[key].(map[interface{}]interface{}); ok {
+				v.unknownProps(name, nested, spec, report)
+			} else if !v.isPlaceholder(props[key]) {
+				report(name, "Expected property to be a hash since it contains nested properties")
+			}
+			continue
+		}
+
+		msg := "Property is not defined in job spec"
+
+		if suggestion, found := v.suggest(name, spec); found {
+			msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+		}
+
+		report(name, msg)
+	}
+}
+
+func (v PropertiesValidator) definedProps(props map[interface{}]interface{}, spec ReleaseJobSpec, report func(string, string)) {
+	var names []string
+
+	for name := range spec.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := spec.Properties[name]
+
+		val, found := v.lookup(props, name)
+		if !found || val == nil {
+			if def.Default == nil {
+				report(name, "Property is required but it's not provided and has no default")
+			}
+			continue
+		}
+
+		if def.Default == nil || v.isPlaceholder(val) {
+			continue
+		}
+
+		expected := v.kind(def.Default)
+
+		if !v.kindMatches(expected, val) {
+			report(name, fmt.Sprintf("Expected property to be %s (based on default) but was %s", expected, v.kind(val)))
+		}
+	}
+}
+
+func (v PropertiesValidator) lookup(props map[interface{}]interface{}, name string) (interface{}, bool) {
+	var current interface{} = props
+
+	for _, piece := range strings.Split(name, ".") {
+		hash, ok := current.(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = hash[piece]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func (v PropertiesValidator) isPrefixOfDefinedProp(name string, spec ReleaseJobSpec) bool {
+	for defName := range spec.Properties {
+		if strings.HasPrefix(defName, name+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v PropertiesValidator) suggest(name string, spec ReleaseJobSpec) (string, bool) {
+	var best string
+	bestDist := 3 // only suggest close matches
+
+	for defName := range spec.Properties {
+		dist := levenshteinDistance(name, defName)
+		if dist < bestDist || (dist == bestDist && len(best) > 0 && defName < best) {
+			best, bestDist = defName, dist
+		}
+	}
+
+	return best, len(best) > 0
+}
+
+func (v PropertiesValidator) kind(obj interface{}) string {
+	switch obj.(type) {
+	case bool:
+		return "a boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}, []biproperty.Property:
+		return "an array"
+	case map[interface{}]interface{}, biproperty.Map:
+		return "a hash"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", obj)
+	}
+}
+
+func (v PropertiesValidator) kindMatches(expected string, val interface{}) bool {
+	actual := v.kind(val)
+
+	if actual == expected {
+		return true
+	}
+
+	switch expected {
+	case "a string":
+		// Scalars are rendered as strings in templates
+		return actual == "a number" || actual == "a boolean"
+	case "a number":
+		if str, ok := val.(string); ok {
+			_, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+			return err == nil
+		}
+	}
+
+	return false
+}
+
+func (v PropertiesValidator) merge(base, override map[interface{}]interface{}) map[interface{}]interface{} {
+	result := map[interface{}]interface{}{}
+
+	for key, val := range base {
+		result[key] = val
+	}
+
+	for key, val := range override {
+		baseVal, baseIsHash := result[key].(map[interface{}]interface{})
+		overrideVal, overrideIsHash := val.(map[interface{}]interface{})
+
+		if baseIsHash && overrideIsHash {
+			result[key] = v.merge(baseVal, overrideVal)
+		} else {
+			result[key] = val
+		}
+	}
+
+	return result
+}
+
+func (v PropertiesValidator) hash(obj interface{}) map[interface{}]interface{} {
+	hash, _ := obj.(map[interface{}]interface{})
+	return hash
+}
+
+func (v PropertiesValidator) str(obj interface{}) string {
+	str, _ := obj.(string)
+	return str
+}
+
+func (v PropertiesValidator) isPlaceholder(obj interface{}) bool {
+	str, ok := obj.(string)
+	return ok && lintPlaceholderRegex.MatchString(str)
+}
+
+func levenshteinDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
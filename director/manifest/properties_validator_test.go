@@ -0,0 +1,200 @@
+package manifest_test
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director/manifest"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+var _ = Describe("PropertiesValidator", func() {
+	var (
+		validator PropertiesValidator
+		specs     []ReleaseJobSpec
+	)
+
+	BeforeEach(func() {
+		validator = NewPropertiesValidator()
+
+		specs = []ReleaseJobSpec{
+			{
+				Release: "rel",
+				Name:    "web",
+				Properties: map[string]boshjob.PropertyDefinition{
+					"port":           {Default: 8080},
+					"tls.enabled":    {Default: false},
+					"tls.cert":       {},
+					"name":           {Default: "web"},
+					"users":          {Default: []biproperty.Property{}},
+					"env":            {Default: biproperty.Map{}},
+					"nats.addresses": {Default: []biproperty.Property{"127.0.0.1"}},
+				},
+			},
+		}
+	})
+
+	It("returns no problems if properties match job spec", func() {
+		problems, err := validator.Validate([]byte(`
+instance_groups:
+- name: web
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      port: "8443"
+      name: 1
+      tls: {enabled: true, cert: ((cert))}
+      users: [admin]
+      env: {ANY: thing}
+  - name: other
+    release: other-rel
+    properties:
+      unknown: true
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("reports unknown properties with suggestions", func() {
+		problems, err := validator.Validate([]byte(`
+instance_groups:
+- name: web
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      prot: 8080
+      tls: {enabeld: true, cert: cert}
+      totally_unknown: 1
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]PropertyProblem{
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "prot", Message: "Property is not defined in job spec (did you mean 'port'?)"},
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "tls.enabeld", Message: "Property is not defined in job spec (did you mean 'tls.enabled'?)"},
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "totally_unknown", Message: "Property is not defined in job spec"},
+		}))
+	})
+
+	It("reports missing required properties", func() {
+		problems, err := validator.Validate([]byte(`
+instance_groups:
+- name: web
+  jobs:
+  - name: web
+    release: rel
+    properties: {}
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]PropertyProblem{
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "tls.cert", Message: "Property is required but it's not provided and has no default"},
+		}))
+	})
+
+	It("reports type mismatches based on defaults", func() {
+		problems, err := validator.Validate([]byte(`
+instance_groups:
+- name: web
+  jobs:
+  - name: web
+    release: rel
+    properties:
+      port: abc
+      tls: {enabled: "yes", cert: cert}
+      users: admin
+      env: [a]
+      nats: value
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]PropertyProblem{
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "nats", Message: "Expected property to be a hash since it contains nested properties"},
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "env", Message: "Expected property to be a hash (based on default) but was an array"},
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "port", Message: "Expected property to be a number (based on default) but was a string"},
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "tls.enabled", Message: "Expected property to be a boolean (based on default) but was a string"},
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "users", Message: "Expected property to be an array (based on default) but was a string"},
+		}))
+	})
+
+	It("uses instance group properties merged over global properties if job properties are not specified", func() {
+		problems, err := validator.Validate([]byte(`
+properties:
+  tls: {cert: cert}
+  unrelated: value
+instance_groups:
+- name: web
+  properties:
+    tls: {enabled: "no"}
+  jobs:
+  - name: web
+    release: rel
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]PropertyProblem{
+			{InstanceGroup: "web", Job: "web", Release: "rel", Property: "tls.enabled", Message: "Expected property to be a boolean (based on default) but was a string"},
+		}))
+	})
+
+	It("reports instance group properties not defined in specs of jobs using them", func() {
+		specs = append(specs, ReleaseJobSpec{
+			Release:    "rel",
+			Name:       "worker",
+			Properties: map[string]boshjob.PropertyDefinition{"queue": {Default: "jobs"}},
+		})
+
+		problems, err := validator.Validate([]byte(`
+instance_groups:
+- name: web
+  properties:
+    tls: {cert: cert, enabeld: true}
+    queue: fast
+    prot: 8080
+  jobs:
+  - name: web
+    release: rel
+  - name: worker
+    release: rel
+- name: custom
+  properties:
+    unused: value
+  jobs:
+  - name: web
+    release: rel
+    properties: {tls: {cert: cert}}
+- name: other
+  properties:
+    unknown: value
+  jobs:
+  - name: web
+    release: rel
+  - name: other
+    release: other-rel
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]PropertyProblem{
+			{InstanceGroup: "web", Property: "prot", Message: "Property is not defined in job spec (did you mean 'port'?)"},
+			{InstanceGroup: "web", Property: "tls.enabeld", Message: "Property is not defined in job spec (did you mean 'tls.enabled'?)"},
+			{InstanceGroup: "other", Job: "web", Release: "rel", Property: "tls.cert", Message: "Property is required but it's not provided and has no default"},
+		}))
+	})
+
+	It("reports jobs missing from provided release", func() {
+		problems, err := validator.Validate([]byte(`
+instance_groups:
+- name: web
+  jobs:
+  - name: missing
+    release: rel
+`), specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]PropertyProblem{
+			{InstanceGroup: "web", Job: "missing", Release: "rel", Message: "Job 'missing' is not found in release 'rel'"},
+		}))
+	})
+
+	It("returns error if manifest cannot be parsed", func() {
+		_, err := validator.Validate([]byte(`{`), specs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling manifest"))
+	})
+})
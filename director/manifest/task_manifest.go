@@ -0,0 +1,45 @@
+package manifest
+
+import (
+	"regexp"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// taskManifestHeader is logged by director's deploy task right before raw manifest text
+const taskManifestHeader = "Manifest:"
+
+// taskLogLineRegexp matches start of a Ruby logger line, e.g. 'D, [2017-01-01T00:00:00.000000 #1] ...'
+var taskLogLineRegexp = regexp.MustCompile(`^[DIWEFA], \[\d{4}-\d{2}-\d{2}T`)
+
+// ManifestFromTaskDebugOutput returns manifest text logged by a deploy task.
+// Manifest is logged as given to the director hence variables are not interpolated.
+// Last logged manifest is returned if task logged more than one.
+func ManifestFromTaskDebugOutput(output string) (string, error) {
+	var manifest []string
+	var inManifest, found bool
+
+	for _, line := range strings.Split(output, "\n") {
+		if taskLogLineRegexp.MatchString(line) {
+			inManifest = strings.HasSuffix(strings.TrimRight(line, " \r"), taskManifestHeader)
+
+			if inManifest {
+				manifest = nil
+				found = true
+			}
+
+			continue
+		}
+
+		if inManifest {
+			manifest = append(manifest, line)
+		}
+	}
+
+	if !found {
+		return "", bosherr.Error("Expected task debug output to include deployment manifest")
+	}
+
+	return strings.TrimRight(strings.Join(manifest, "\n"), "\n") + "\n", nil
+}
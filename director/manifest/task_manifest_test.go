@@ -0,0 +1,44 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/director/manifest"
+)
+
+var _ = Describe("ManifestFromTaskDebugOutput", func() {
+	It("returns manifest logged by deploy task", func() {
+		output := `I, [2017-08-01T22:09:07.561000 #11541] [task:5]  INFO -- DirectorJobRunner: Reading deployment manifest
+D, [2017-08-01T22:09:07.563584 #11541] [task:5] DEBUG -- DirectorJobRunner: Manifest:
+name: dep
+instance_groups:
+- name: web
+  instances: ((instances))
+
+D, [2017-08-01T22:09:07.570000 #11541] [task:5] DEBUG -- DirectorJobRunner: Creating deployment plan
+`
+
+		manifest, err := ManifestFromTaskDebugOutput(output)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest).To(Equal("name: dep\ninstance_groups:\n- name: web\n  instances: ((instances))\n"))
+	})
+
+	It("returns last logged manifest", func() {
+		output := `D, [2017-08-01T22:09:07.563584 #1] [task:5] DEBUG -- DirectorJobRunner: Manifest:
+name: first
+D, [2017-08-01T22:09:08.563584 #1] [task:5] DEBUG -- DirectorJobRunner: Manifest:
+name: second
+`
+
+		manifest, err := ManifestFromTaskDebugOutput(output)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest).To(Equal("name: second\n"))
+	})
+
+	It("returns error if task did not log manifest", func() {
+		_, err := ManifestFromTaskDebugOutput("I, [2017-08-01T22:09:07.561000 #1] [task:5]  INFO -- DirectorJobRunner: Deleting\n")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected task debug output to include deployment manifest"))
+	})
+})
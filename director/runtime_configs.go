@@ -71,7 +71,10 @@ func (d DirectorImpl) DiffRuntimeConfig(name string, manifest []byte, noRedact b
 		return ConfigDiff{}, err
 	}
 
-	return NewConfigDiff(resp.Diff), nil
+	diff := NewConfigDiff(resp.Diff)
+	diff.Unsupported = resp.Unsupported
+
+	return diff, nil
 }
 
 func (c Client) DiffRuntimeConfig(name string, manifest []byte, noRedact bool) (ConfigDiffResponse, error) {
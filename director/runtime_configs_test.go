@@ -175,7 +175,7 @@ var _ = Describe("Director", func() {
 
 			diff, err := director.DiffRuntimeConfig("rc1", []byte("config"), false)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(diff).To(Equal(ConfigDiff{}))
+			Expect(diff).To(Equal(ConfigDiff{Unsupported: true}))
 		})
 
 		Context("when 'noRedact' is true", func() {
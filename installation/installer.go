@@ -62,6 +62,12 @@ func (i *installer) Install(manifest biinstallmanifest.Manifest, stage biui.Stag
 	i.logger.Info(i.logTag, "Installing CPI deployment '%s'", manifest.Name)
 	i.logger.Debug(i.logTag, "Installing CPI deployment '%s' with manifest: %#v", manifest.Name, manifest)
 
+	if manifest.UsesLocalCPI() {
+		// local CPI is built into the CLI so there is no CPI job to install
+		i.logger.Info(i.logTag, "Using local CPI with state in '%s'", manifest.Local.StateDir)
+		return NewInstallation(i.target, InstalledJob{}, manifest, i.registryServerManager), nil
+	}
+
 	jobs, err := i.jobResolver.From(manifest)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Resolving jobs from manifest")
@@ -99,6 +105,10 @@ func (i *installer) Install(manifest biinstallmanifest.Manifest, stage biui.Stag
 }
 
 func (i *installer) Cleanup(installation Installation) error {
+	if installation.Manifest().UsesLocalCPI() {
+		return nil
+	}
+
 	job := installation.Job()
 	return i.blobExtractor.Cleanup(job.BlobstoreID, job.Path)
 }
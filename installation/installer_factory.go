@@ -34,6 +34,9 @@ type installerFactory struct {
 	logTag                 string
 	fs                     boshsys.FileSystem
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	erbRenderer            bierbrenderer.ERBRenderer
+	parallel               int
+	compiledPackageCache   bistatepkg.CompiledPackageCache
 }
 
 func NewInstallerFactory(
@@ -46,31 +49,40 @@ func NewInstallerFactory(
 	logger boshlog.Logger,
 	fs boshsys.FileSystem,
 	digestCreateAlgorithms []boshcrypto.Algorithm,
+	erbRenderer bierbrenderer.ERBRenderer,
+	parallel int,
+	compiledPackageCache bistatepkg.CompiledPackageCache,
 ) InstallerFactory {
 	return &installerFactory{
-		ui:                    ui,
-		runner:                runner,
-		extractor:             extractor,
-		releaseJobResolver:    releaseJobResolver,
-		uuidGenerator:         uuidGenerator,
-		registryServerManager: registryServerManager,
-		logger:                logger,
-		logTag:                "installer",
-		fs:                    fs,
+		ui:                     ui,
+		runner:                 runner,
+		extractor:              extractor,
+		releaseJobResolver:     releaseJobResolver,
+		uuidGenerator:          uuidGenerator,
+		registryServerManager:  registryServerManager,
+		logger:                 logger,
+		logTag:                 "installer",
+		fs:                     fs,
 		digestCreateAlgorithms: digestCreateAlgorithms,
+		erbRenderer:            erbRenderer,
+		parallel:               parallel,
+		compiledPackageCache:   compiledPackageCache,
 	}
 }
 
 func (f *installerFactory) NewInstaller(target Target) Installer {
 	context := &installerFactoryContext{
-		target:             target,
-		runner:             f.runner,
-		logger:             f.logger,
-		extractor:          f.extractor,
-		uuidGenerator:      f.uuidGenerator,
-		releaseJobResolver: f.releaseJobResolver,
-		fs:                 f.fs,
+		target:                 target,
+		runner:                 f.runner,
+		logger:                 f.logger,
+		extractor:              f.extractor,
+		uuidGenerator:          f.uuidGenerator,
+		releaseJobResolver:     f.releaseJobResolver,
+		fs:                     f.fs,
 		digestCreateAlgorithms: f.digestCreateAlgorithms,
+		erbRenderer:            f.erbRenderer,
+		parallel:               f.parallel,
+		compiledPackageCache:   f.compiledPackageCache,
 	}
 
 	return NewInstaller(
@@ -99,12 +111,14 @@ type installerFactoryContext struct {
 	blobExtractor          blobextract.Extractor
 	compiledPackageRepo    bistatepkg.CompiledPackageRepo
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	erbRenderer            bierbrenderer.ERBRenderer
+	parallel               int
+	compiledPackageCache   bistatepkg.CompiledPackageCache
 }
 
 func (c *installerFactoryContext) JobRenderer() JobRenderer {
 
-	erbRenderer := bierbrenderer.NewERBRenderer(c.fs, c.runner, c.logger)
-	jobRenderer := bitemplate.NewJobRenderer(erbRenderer, c.fs, c.uuidGenerator, c.logger)
+	jobRenderer := bitemplate.NewJobRenderer(c.erbRenderer, c.fs, c.uuidGenerator, c.logger)
 	jobListRenderer := bitemplate.NewJobListRenderer(jobRenderer, c.logger)
 
 	return NewJobRenderer(
@@ -132,6 +146,7 @@ func (c *installerFactoryContext) JobDependencyCompiler() bistatejob.DependencyC
 
 	c.jobDependencyCompiler = bistatejob.NewDependencyCompiler(
 		c.InstallationStatePackageCompiler(),
+		c.parallel,
 		c.logger,
 	)
 
@@ -150,6 +165,7 @@ func (c *installerFactoryContext) InstallationStatePackageCompiler() bistatepkg.
 		c.extractor,
 		c.Blobstore(),
 		c.CompiledPackageRepo(),
+		c.compiledPackageCache,
 		c.BlobExtractor(),
 		c.logger,
 	)
@@ -125,6 +125,21 @@ var _ = Describe("Installer", func() {
 			})
 		})
 
+		Context("when using local CPI", func() {
+			BeforeEach(func() {
+				installationManifest.Local = &biinstallmanifest.LocalCPI{StateDir: "/fake-state-dir"}
+			})
+
+			It("returns the installation without compiling or rendering jobs", func() {
+				installation, err := installer.Install(installationManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installation.Job()).To(Equal(InstalledJob{}))
+				Expect(installation.Manifest()).To(Equal(installationManifest))
+				Expect(fakeExtractor.ExtractCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when rendering jobs errors", func() {
 			JustBeforeEach(func() {
 				err := errors.New("OMG - no ruby found!!")
@@ -160,6 +175,16 @@ var _ = Describe("Installer", func() {
 			Expect(extractedBlobPath).To(Equal(installedJob.Path))
 		})
 
+		It("does not clean up anything for local CPI", func() {
+			installationManifest.Local = &biinstallmanifest.LocalCPI{StateDir: "/fake-state-dir"}
+			installation = NewInstallation(target, InstalledJob{}, installationManifest, mockRegistryServerManager)
+
+			err := installer.Cleanup(installation)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeExtractor.CleanupCallCount()).To(Equal(0))
+		})
+
 		It("returns errors when cleaning up installed jobs", func() {
 			fakeExtractor.CleanupReturns(errors.New("nope"))
 
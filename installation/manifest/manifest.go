@@ -11,6 +11,13 @@ type Manifest struct {
 	Mbus       string
 	Cert       Certificate
 	Registry   Registry
+	Local      *LocalCPI
+}
+
+// LocalCPI configures built-in CPI that simulates IaaS on local machine
+// and is used instead of CPI job from a release
+type LocalCPI struct {
+	StateDir string
 }
 
 type Certificate struct {
@@ -42,6 +49,10 @@ type SSHTunnel struct {
 	PrivateKey string `yaml:"private_key"`
 }
 
+func (m Manifest) UsesLocalCPI() bool {
+	return m.Local != nil
+}
+
 func (m *Manifest) PopulateRegistry(username string, password string, host string, port int, sshTunnel SSHTunnel) {
 	m.Properties["registry"] = biproperty.Map{
 		"host":     host,
@@ -40,6 +40,11 @@ type installation struct {
 	SSHTunnel  SSHTunnel `yaml:"ssh_tunnel"`
 	Mbus       string
 	Cert       Certificate
+	Local      *localCPI
+}
+
+type localCPI struct {
+	StateDir string `yaml:"state_dir"`
 }
 
 func (i installation) HasSSHTunnel() bool {
@@ -119,6 +124,17 @@ func (p *parser) Parse(path string, vars boshtpl.Variables, op patch.Op, release
 		Cert: comboManifest.CloudProvider.Cert,
 	}
 
+	if comboManifest.CloudProvider.Local != nil {
+		stateDir := comboManifest.CloudProvider.Local.StateDir
+		if stateDir != "" {
+			stateDir, err = biutil.AbsolutifyPath(path, stateDir, p.fs)
+			if err != nil {
+				return Manifest{}, bosherr.WrapErrorf(err, "Expanding local CPI state_dir path")
+			}
+		}
+		installationManifest.Local = &LocalCPI{StateDir: stateDir}
+	}
+
 	properties, err := biproperty.BuildMap(comboManifest.CloudProvider.Properties)
 	if err != nil {
 		return Manifest{}, bosherr.WrapErrorf(err, "Parsing cloud_provider manifest properties: %#v", comboManifest.CloudProvider.Properties)
@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"net/url"
 	"strings"
 
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
@@ -23,6 +24,10 @@ func NewValidator(logger boshlog.Logger) Validator {
 }
 
 func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanifest.Manifest) error {
+	if manifest.UsesLocalCPI() {
+		return v.validateLocalCPI(manifest)
+	}
+
 	errs := []error{}
 
 	cpiJobName := manifest.Template.Name
@@ -47,6 +52,47 @@ func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanif
 	return nil
 }
 
+func (v *validator) validateLocalCPI(manifest Manifest) error {
+	errs := []error{}
+
+	if v.isBlank(manifest.Local.StateDir) {
+		errs = append(errs, bosherr.Error("cloud_provider.local.state_dir must be provided"))
+	}
+
+	if manifest.Template != (ReleaseJobRef{}) {
+		errs = append(errs, bosherr.Error("cloud_provider.template must not be provided when using cloud_provider.local"))
+	}
+
+	if v.isBlank(manifest.Mbus) {
+		errs = append(errs, bosherr.Error("cloud_provider.mbus must be provided when using cloud_provider.local"))
+	} else if !v.hasCredentials(manifest.Mbus) {
+		// local agent serves blobstore and agent API on the host hence it must not be open to anyone
+		errs = append(errs, bosherr.Error("cloud_provider.mbus must include username and password when using cloud_provider.local"))
+	}
+
+	// local agent serves self-signed certificate hence it cannot be verified against CA
+	if manifest.Cert.CA != "" {
+		errs = append(errs, bosherr.Error("cloud_provider.cert.ca must not be provided when using cloud_provider.local"))
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+func (v *validator) hasCredentials(mbus string) bool {
+	mbusURL, err := url.Parse(mbus)
+	if err != nil || mbusURL.User == nil {
+		return false
+	}
+
+	password, _ := mbusURL.User.Password()
+
+	return mbusURL.User.Username() != "" && password != ""
+}
+
 func (v *validator) isBlank(str string) bool {
 	return str == "" || strings.TrimSpace(str) == ""
 }
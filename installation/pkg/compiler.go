@@ -3,6 +3,10 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -15,17 +19,32 @@ import (
 )
 
 type compiler struct {
-	runner              boshsys.CmdRunner
-	packagesDir         string
-	fileSystem          boshsys.FileSystem
-	compressor          boshcmd.Compressor
-	blobstore           boshblob.DigestBlobstore
-	compiledPackageRepo bistatepkg.CompiledPackageRepo
-	blobExtractor       blobextract.Extractor
-	logger              boshlog.Logger
-	logTag              string
+	runner               boshsys.CmdRunner
+	packagesDir          string
+	fileSystem           boshsys.FileSystem
+	compressor           boshcmd.Compressor
+	blobstore            boshblob.DigestBlobstore
+	compiledPackageRepo  bistatepkg.CompiledPackageRepo
+	compiledPackageCache bistatepkg.CompiledPackageCache
+	blobExtractor        blobextract.Extractor
+	logger               boshlog.Logger
+	logTag               string
+
+	// Packages compiled in parallel share packagesDir since packaging scripts
+	// may refer to dependencies by their runtime location; installedDeps counts
+	// compilations using each installed dependency so it is removed by the last one
+	installLock   sync.Mutex
+	installedDeps map[string]int
+	compiling     int
+
+	processesLock sync.Mutex
+	processes     map[boshsys.Process]struct{}
+	canceled      bool
 }
 
+// osReleasePath identifies Linux distribution (and therefore its libc) packages are compiled against
+const osReleasePath = "/etc/os-release"
+
 func NewPackageCompiler(
 	runner boshsys.CmdRunner,
 	packagesDir string,
@@ -33,19 +52,23 @@ func NewPackageCompiler(
 	compressor boshcmd.Compressor,
 	blobstore boshblob.DigestBlobstore,
 	compiledPackageRepo bistatepkg.CompiledPackageRepo,
+	compiledPackageCache bistatepkg.CompiledPackageCache,
 	blobExtractor blobextract.Extractor,
 	logger boshlog.Logger,
 ) bistatepkg.Compiler {
 	return &compiler{
-		runner:              runner,
-		packagesDir:         packagesDir,
-		fileSystem:          fileSystem,
-		compressor:          compressor,
-		blobstore:           blobstore,
-		compiledPackageRepo: compiledPackageRepo,
-		blobExtractor:       blobExtractor,
-		logger:              logger,
-		logTag:              "packageCompiler",
+		runner:               runner,
+		packagesDir:          packagesDir,
+		fileSystem:           fileSystem,
+		compressor:           compressor,
+		blobstore:            blobstore,
+		compiledPackageRepo:  compiledPackageRepo,
+		compiledPackageCache: compiledPackageCache,
+		blobExtractor:        blobExtractor,
+		logger:               logger,
+		logTag:               "packageCompiler",
+		installedDeps:        map[string]int{},
+		processes:            map[boshsys.Process]struct{}{},
 	}
 }
 
@@ -64,6 +87,17 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, nil
 	}
 
+	compilationTarget, cacheable := c.localCompilationTarget()
+
+	if cacheable {
+		record, found, err = c.findInCache(pkg, compilationTarget)
+		if err != nil {
+			return record, isCompiledPackage, err
+		} else if found {
+			return record, isCompiledPackage, nil
+		}
+	}
+
 	c.logger.Debug(c.logTag, "Installing dependencies of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	err = c.installPackages(pkg.Deps())
@@ -71,15 +105,11 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Installing dependencies of package '%s'", pkg.Name())
 	}
 
-	defer func() {
-		if err = c.fileSystem.RemoveAll(c.packagesDir); err != nil {
-			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
-		}
-	}()
+	installDir := filepath.Join(c.packagesDir, pkg.Name())
 
-	c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+	defer c.uninstallPackages(pkg.Deps(), installDir)
 
-	installDir := filepath.Join(c.packagesDir, pkg.Name())
+	c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	err = c.fileSystem.MkdirAll(installDir, os.ModePerm)
 	if err != nil {
@@ -106,7 +136,7 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		WorkingDir:     packageSrcDir,
 	}
 
-	_, _, _, err = c.runner.RunComplexCommand(cmd)
+	err = c.runPackagingScript(cmd)
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapError(err, "Compiling package")
 	}
@@ -137,26 +167,229 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, bosherr.WrapError(err, "Saving compiled package")
 	}
 
+	if cacheable {
+		err = c.compiledPackageCache.Save(pkg, compilationTarget, tarball, record.BlobSHA1)
+		if err != nil {
+			c.logger.Warn(c.logTag, "Failed to save package '%s' to compiled package cache: %s", pkg.Name(), err.Error())
+		}
+	}
+
 	return record, isCompiledPackage, nil
 }
 
-func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
-	for _, pkg := range packages {
-		c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+// localCompilationTarget identifies packages compiled on this machine in the compiled package cache.
+// Since Linux binaries are linked against distribution's libc, distribution ID and version are included;
+// packages are not cached if distribution cannot be determined. Compiled packages may also refer
+// to the location they were installed to so packages dir is included as well.
+func (c *compiler) localCompilationTarget() (string, bool) {
+	if c.compiledPackageCache == nil {
+		return "", false
+	}
+
+	target := "local/" + runtime.GOOS + "-" + runtime.GOARCH
+
+	if runtime.GOOS != "linux" {
+		return target + ":" + c.packagesDir, true
+	}
+
+	osRelease, err := c.fileSystem.ReadFileString(osReleasePath)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Skipping compiled package cache since distribution is unknown: %s", err.Error())
+		return "", false
+	}
+
+	fields := map[string]string{}
+
+	for _, line := range strings.Split(osRelease, "\n") {
+		pieces := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(pieces) == 2 {
+			fields[pieces[0]] = strings.Trim(pieces[1], `"'`)
+		}
+	}
+
+	if len(fields["ID"]) == 0 {
+		c.logger.Warn(c.logTag, "Skipping compiled package cache since '%s' does not specify distribution ID", osReleasePath)
+		return "", false
+	}
+
+	return target + "/" + fields["ID"] + "-" + fields["VERSION_ID"] + ":" + c.packagesDir, true
+}
+
+func (c *compiler) findInCache(pkg birelpkg.Compilable, compilationTarget string) (bistatepkg.CompiledPackageRecord, bool, error) {
+	var record bistatepkg.CompiledPackageRecord
+
+	entry, found, err := c.compiledPackageCache.Find(pkg, compilationTarget)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to find package '%s' in compiled package cache: %s", pkg.Name(), err.Error())
+		return record, false, nil
+	} else if !found {
+		return record, false, nil
+	}
+
+	c.logger.Debug(c.logTag, "Using cached compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	tarball, err := c.fileSystem.TempFile("bosh-compiled-package")
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Creating temporary file for cached compiled package")
+	}
 
-		record, found, err := c.compiledPackageRepo.Find(pkg)
+	tarballPath := tarball.Name()
+
+	defer func() {
+		if err = c.fileSystem.RemoveAll(tarballPath); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove cached compiled package tarball: %s", err.Error())
+		}
+	}()
+
+	err = tarball.Close()
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Closing temporary file for cached compiled package")
+	}
+
+	err = c.compiledPackageCache.Fetch(entry, tarballPath)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to fetch package '%s' from compiled package cache: %s", pkg.Name(), err.Error())
+		return record, false, nil
+	}
+
+	blobID, digest, err := c.blobstore.Create(tarballPath)
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Creating blob")
+	}
+
+	record = bistatepkg.CompiledPackageRecord{
+		BlobID:   blobID,
+		BlobSHA1: digest.String(),
+	}
+
+	err = c.compiledPackageRepo.Save(pkg, record)
+	if err != nil {
+		return record, false, bosherr.WrapError(err, "Saving compiled package")
+	}
+
+	return record, true, nil
+}
+
+// Cancel terminates running packaging scripts and fails subsequent compilations
+func (c *compiler) Cancel() {
+	c.processesLock.Lock()
+	defer c.processesLock.Unlock()
+
+	c.canceled = true
+
+	for process := range c.processes {
+		err := process.TerminateNicely(10 * time.Second)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
-		} else if !found {
-			return bosherr.Errorf("Finding compiled package '%s'", pkg.Name())
+			c.logger.Warn(c.logTag, "Failed to terminate packaging script: %s", err.Error())
 		}
+	}
+}
 
-		c.logger.Debug(c.logTag, "Installing package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+func (c *compiler) runPackagingScript(cmd boshsys.Command) error {
+	c.processesLock.Lock()
 
-		err = c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, filepath.Join(c.packagesDir, pkg.Name()))
+	if c.canceled {
+		c.processesLock.Unlock()
+		return bosherr.Error("Compilation was canceled")
+	}
+
+	process, err := c.runner.RunComplexCommandAsync(cmd)
+	if err != nil {
+		c.processesLock.Unlock()
+		return err
+	}
+
+	resultCh := process.Wait()
+
+	c.processes[process] = struct{}{}
+	c.processesLock.Unlock()
+
+	result := <-resultCh
+
+	c.processesLock.Lock()
+	delete(c.processes, process)
+	c.processesLock.Unlock()
+
+	return result.Error
+}
+
+func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
+	c.installLock.Lock()
+	defer c.installLock.Unlock()
+
+	c.compiling++
+
+	for i, pkg := range packages {
+		if c.installedDeps[pkg.Name()] > 0 {
+			c.installedDeps[pkg.Name()]++
+			continue
+		}
+
+		err := c.installPackage(pkg)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
+			c.releasePackages(packages[:i], "")
+			return err
 		}
+
+		c.installedDeps[pkg.Name()] = 1
+	}
+
+	return nil
+}
+
+func (c *compiler) uninstallPackages(packages []birelpkg.Compilable, installDir string) {
+	c.installLock.Lock()
+	defer c.installLock.Unlock()
+
+	c.releasePackages(packages, installDir)
+}
+
+// releasePackages removes dependencies no longer used by other compilations and
+// the whole packages dir once no compilation is running; installLock must be held
+func (c *compiler) releasePackages(packages []birelpkg.Compilable, installDir string) {
+	c.compiling--
+
+	dirs := []string{}
+
+	if len(installDir) > 0 {
+		dirs = append(dirs, installDir)
+	}
+
+	for _, pkg := range packages {
+		c.installedDeps[pkg.Name()]--
+
+		if c.installedDeps[pkg.Name()] == 0 {
+			delete(c.installedDeps, pkg.Name())
+			dirs = append(dirs, filepath.Join(c.packagesDir, pkg.Name()))
+		}
+	}
+
+	if c.compiling == 0 {
+		dirs = []string{c.packagesDir}
+	}
+
+	for _, dir := range dirs {
+		if err := c.fileSystem.RemoveAll(dir); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
+		}
+	}
+}
+
+func (c *compiler) installPackage(pkg birelpkg.Compilable) error {
+	c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	record, found, err := c.compiledPackageRepo.Find(pkg)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
+	} else if !found {
+		return bosherr.Errorf("Finding compiled package '%s'", pkg.Name())
+	}
+
+	c.logger.Debug(c.logTag, "Installing package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	err = c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, filepath.Join(c.packagesDir, pkg.Name()))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
 	}
 
 	return nil
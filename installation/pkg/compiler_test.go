@@ -1,9 +1,14 @@
 package pkg_test
 
 import (
+	"crypto/sha1"
 	"errors"
+	"fmt"
 	"path/filepath"
+	"runtime"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
@@ -78,6 +83,7 @@ var _ = Describe("PackageCompiler", func() {
 			compressor,
 			blobstore,
 			mockCompiledPackageRepo,
+			nil,
 			fakeExtractor,
 			logger,
 		)
@@ -87,6 +93,7 @@ var _ = Describe("PackageCompiler", func() {
 		var (
 			compiledPackageTarballPath string
 			installPath                string
+			process                    *fakesys.FakeProcess
 
 			dep1 bistatepkg.CompiledPackageRecord
 			dep2 bistatepkg.CompiledPackageRecord
@@ -98,6 +105,7 @@ var _ = Describe("PackageCompiler", func() {
 		BeforeEach(func() {
 			installPath = filepath.Join(packagesDir, "pkg1-name")
 			compiledPackageTarballPath = filepath.Join(packagesDir, "new-tarball.tgz")
+			process = &fakesys.FakeProcess{}
 		})
 
 		JustBeforeEach(func() {
@@ -115,6 +123,9 @@ var _ = Describe("PackageCompiler", func() {
 			}
 			mockCompiledPackageRepo.EXPECT().Find(dependency2).Return(dep2, true, nil).AnyTimes()
 
+			runner.AddProcess("bash -x packaging", process)
+			runner.AddProcess("bash -x packaging", &fakesys.FakeProcess{})
+
 			// packaging file created when source is extracted
 			fs.WriteFileString("/pkg-dir/packaging", "")
 
@@ -219,6 +230,151 @@ var _ = Describe("PackageCompiler", func() {
 			Expect(fs.FileExists(packagesDir)).To(BeFalse())
 		})
 
+		Context("when compiled package cache is used", func() {
+			var (
+				compiledPackageCache bistatepkg.CompiledPackageCache
+			)
+
+			BeforeEach(func() {
+				compiledPackageCache = bistatepkg.NewCompiledPackageCache("/cache", fs, fakecmd.NewFakeCompressor(), fakeclock.NewFakeClock(time.Now()))
+
+				fs.WriteFileString("/etc/os-release", "NAME=\"Ubuntu\"\nID=ubuntu\nVERSION_ID=\"22.04\"\n")
+
+				compiler = NewPackageCompiler(
+					runner,
+					packagesDir,
+					fs,
+					compressor,
+					blobstore,
+					mockCompiledPackageRepo,
+					compiledPackageCache,
+					fakeExtractor,
+					logger,
+				)
+			})
+
+			It("saves the compiled package to the cache", func() {
+				compressor.CompressFilesInDirCallBack = func() {
+					fs.WriteFileString(compiledPackageTarballPath, "compiled-content")
+				}
+
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+
+				entries, err := compiledPackageCache.List()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].Name).To(Equal("pkg1-name"))
+				Expect(entries[0].SHA1).To(Equal("fakefingerprint"))
+			})
+
+			It("uses the cached package instead of compiling it again", func() {
+				compressor.CompressFilesInDirCallBack = func() {
+					fs.WriteFileString(compiledPackageTarballPath, "compiled-content")
+				}
+
+				compiledDigest := boshcrypto.MustParseMultipleDigest(fmt.Sprintf("%x", sha1.Sum([]byte("compiled-content"))))
+				blobstore.CreateReturns("fake-blob-id", compiledDigest, nil)
+
+				mockCompiledPackageRepo.EXPECT().Save(pkg, bistatepkg.CompiledPackageRecord{
+					BlobID:   "fake-blob-id",
+					BlobSHA1: compiledDigest.String(),
+				}).Times(2)
+
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunComplexCommands).To(HaveLen(1))
+
+				runner.RunComplexCommands = nil
+
+				_, _, err = compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunComplexCommands).To(BeEmpty())
+				Expect(blobstore.CreateCallCount()).To(Equal(2))
+			})
+
+			It("keys cached packages by platform and Linux distribution", func() {
+				compressor.CompressFilesInDirCallBack = func() {
+					fs.WriteFileString(compiledPackageTarballPath, "compiled-content")
+				}
+
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+
+				entries, err := compiledPackageCache.List()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+
+				expectedTarget := "local/" + runtime.GOOS + "-" + runtime.GOARCH
+				if runtime.GOOS == "linux" {
+					expectedTarget += "/ubuntu-22.04"
+				}
+				expectedTarget += ":" + packagesDir
+
+				Expect(entries[0].Stemcell).To(Equal(expectedTarget))
+			})
+
+			It("does not use package cached for different packages dir", func() {
+				compressor.CompressFilesInDirCallBack = func() {
+					fs.WriteFileString(compiledPackageTarballPath, "compiled-content")
+				}
+
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunComplexCommands).To(HaveLen(1))
+
+				compiler = NewPackageCompiler(
+					runner,
+					"other-packages-dir",
+					fs,
+					compressor,
+					blobstore,
+					mockCompiledPackageRepo,
+					compiledPackageCache,
+					fakeExtractor,
+					logger,
+				)
+
+				_, _, err = compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunComplexCommands).To(HaveLen(2))
+				Expect(runner.RunComplexCommands[1].Env["BOSH_INSTALL_TARGET"]).To(Equal(filepath.Join("other-packages-dir", "pkg1-name")))
+			})
+
+			It("does not use cache on Linux if distribution cannot be determined", func() {
+				if runtime.GOOS != "linux" {
+					Skip("distribution is only checked on Linux")
+				}
+
+				fs.RemoveAll("/etc/os-release")
+
+				compressor.CompressFilesInDirCallBack = func() {
+					fs.WriteFileString(compiledPackageTarballPath, "compiled-content")
+				}
+
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(compiledPackageCache.List()).To(BeEmpty())
+			})
+
+			It("compiles package if cached package does not match its digest", func() {
+				compressor.CompressFilesInDirCallBack = func() {
+					fs.WriteFileString(compiledPackageTarballPath, "compiled-content")
+				}
+
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunComplexCommands).To(HaveLen(1))
+
+				runner.RunComplexCommands = nil
+
+				_, _, err = compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunComplexCommands).To(HaveLen(1))
+			})
+		})
+
 		Context("when dependency installation fails", func() {
 			JustBeforeEach(func() {
 				fakeExtractor.ExtractReturns(errors.New("fake-install-error"))
@@ -245,12 +401,11 @@ var _ = Describe("PackageCompiler", func() {
 		})
 
 		Context("when the packaging script fails", func() {
-			JustBeforeEach(func() {
-				fakeResult := fakesys.FakeCmdResult{
+			BeforeEach(func() {
+				process.WaitResult = boshsys.Result{
 					ExitStatus: 1,
 					Error:      errors.New("fake-error"),
 				}
-				runner.AddCmdResult("bash -x packaging", fakeResult)
 			})
 
 			It("returns error", func() {
@@ -261,6 +416,46 @@ var _ = Describe("PackageCompiler", func() {
 			})
 		})
 
+		Context("when compilation is canceled", func() {
+			var (
+				started chan struct{}
+			)
+
+			BeforeEach(func() {
+				started = make(chan struct{}, 1)
+				runner.SetCmdCallback("bash -x packaging", func() { started <- struct{}{} })
+
+				process.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143, Error: errors.New("fake-terminated-error")}
+				}
+			})
+
+			It("terminates running packaging script and fails subsequent compilations", func() {
+				errCh := make(chan error, 1)
+
+				go func() {
+					_, _, err := compiler.Compile(pkg)
+					errCh <- err
+				}()
+
+				Eventually(started).Should(Receive())
+
+				compiler.(bistatepkg.CancelableCompiler).Cancel()
+
+				var err error
+				Eventually(errCh).Should(Receive(&err))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-terminated-error"))
+				Expect(process.TerminatedNicely).To(BeTrue())
+				Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+
+				_, _, err = compiler.Compile(pkg)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Compilation was canceled"))
+				Expect(runner.RunComplexCommands).To(HaveLen(1))
+			})
+		})
+
 		Context("when compression fails", func() {
 			JustBeforeEach(func() {
 				compressor.CompressFilesInDirErr = errors.New("fake-compression-error")
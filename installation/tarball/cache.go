@@ -2,35 +2,68 @@ package tarball
 
 import (
 	"crypto/sha1"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+const cacheMetadataSuffix = ".meta"
+
 type Cache interface {
 	Get(source Source) (path string, found bool)
 	Path(source Source) (path string)
 	Save(sourcePath string, source Source) error
+
+	List() ([]CacheEntry, error)
+	Delete(entry CacheEntry) error
+}
+
+// CacheEntry describes downloaded tarball; URL and Digest are empty
+// for tarballs downloaded before cache metadata was recorded
+type CacheEntry struct {
+	Path   string
+	URL    string
+	Digest string
+	Size   int64
+
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+type cacheMetadata struct {
+	URL    string `json:"url"`
+	Digest string `json:"digest"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
 }
 
 type cache struct {
-	basePath string
-	fs       boshsys.FileSystem
-	logger   boshlog.Logger
-	logTag   string
+	basePath    string
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+	logger      boshlog.Logger
+	logTag      string
 }
 
-func NewCache(basePath string, fs boshsys.FileSystem, logger boshlog.Logger) Cache {
+func NewCache(basePath string, fs boshsys.FileSystem, timeService clock.Clock, logger boshlog.Logger) Cache {
 	return &cache{
-		basePath: basePath,
-		fs:       fs,
-		logger:   logger,
-		logTag:   "tarballCache",
+		basePath:    basePath,
+		fs:          fs,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "tarballCache",
 	}
 }
 
@@ -38,6 +71,16 @@ func (c *cache) Get(source Source) (string, bool) {
 	cachedPath := c.Path(source)
 	if c.fs.FileExists(cachedPath) {
 		c.logger.Debug(c.logTag, "Found cached tarball at: '%s'", cachedPath)
+
+		metadata, found := c.readMetadata(cachedPath)
+		if !found {
+			metadata = cacheMetadata{URL: source.GetURL(), Digest: source.GetSHA1()}
+		}
+
+		metadata.LastUsedAt = c.timeService.Now().UTC()
+
+		c.writeMetadata(cachedPath, metadata)
+
 		return cachedPath, true
 	}
 
@@ -55,12 +98,146 @@ func (c *cache) Save(sourcePath string, source Source) error {
 		return bosherr.WrapErrorf(err, "Failed to save tarball path '%s' in cache", sourcePath)
 	}
 
+	now := c.timeService.Now().UTC()
+
+	c.writeMetadata(c.Path(source), cacheMetadata{
+		URL:    source.GetURL(),
+		Digest: source.GetSHA1(),
+
+		CreatedAt:  now,
+		LastUsedAt: now,
+	})
+
 	c.logger.Debug(c.logTag, "Saving tarball in cache at: '%s'", c.Path(source))
 	return nil
 }
 
+// Path is keyed by source URL and the strongest of source digests;
+// SHA1 keyed paths are kept the same as before multiple digests were supported
 func (c *cache) Path(source Source) string {
 	urlSHA1 := sha1.Sum([]byte(source.GetURL()))
-	filename := fmt.Sprintf("%x-%s", string(urlSHA1[:]), source.GetSHA1())
+	filename := fmt.Sprintf("%x-%s", string(urlSHA1[:]), c.digestName(source.GetSHA1()))
 	return filepath.Join(c.basePath, filename)
 }
+
+func (c *cache) List() ([]CacheEntry, error) {
+	var entries []CacheEntry
+
+	if !c.fs.FileExists(c.basePath) {
+		return entries, nil
+	}
+
+	err := c.fs.Walk(c.basePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == c.basePath {
+			return nil
+		} else if info.IsDir() {
+			return filepath.SkipDir
+		} else if strings.HasSuffix(path, cacheMetadataSuffix) {
+			return nil
+		}
+
+		entry := CacheEntry{
+			Path: path,
+			Size: info.Size(),
+
+			CreatedAt:  info.ModTime(),
+			LastUsedAt: info.ModTime(),
+		}
+
+		if metadata, found := c.readMetadata(path); found {
+			entry.URL = metadata.URL
+			entry.Digest = metadata.Digest
+			entry.CreatedAt = metadata.CreatedAt
+			entry.LastUsedAt = metadata.LastUsedAt
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing cache directory '%s'", c.basePath)
+	}
+
+	sort.Sort(cacheEntriesByPath(entries))
+
+	return entries, nil
+}
+
+func (c *cache) Delete(entry CacheEntry) error {
+	err := c.fs.RemoveAll(entry.Path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting cached tarball '%s'", entry.Path)
+	}
+
+	err = c.fs.RemoveAll(entry.Path + cacheMetadataSuffix)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting cached tarball metadata '%s'", entry.Path)
+	}
+
+	return nil
+}
+
+func (c *cache) digestName(digest string) string {
+	multipleDigest, err := boshcrypto.ParseMultipleDigest(digest)
+	if err != nil {
+		return digest
+	}
+
+	strongestDigest, err := multipleDigest.DigestFor(multipleDigest.Algorithm())
+	if err != nil {
+		return digest
+	}
+
+	return strings.Replace(strongestDigest.String(), ":", "-", -1)
+}
+
+func (c *cache) readMetadata(path string) (cacheMetadata, bool) {
+	var metadata cacheMetadata
+
+	metadataPath := path + cacheMetadataSuffix
+
+	if !c.fs.FileExists(metadataPath) {
+		return metadata, false
+	}
+
+	bytes, err := c.fs.ReadFile(metadataPath)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to read cached tarball metadata '%s': %s", metadataPath, err.Error())
+		return metadata, false
+	}
+
+	err = json.Unmarshal(bytes, &metadata)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to unmarshal cached tarball metadata '%s': %s", metadataPath, err.Error())
+		return metadata, false
+	}
+
+	return metadata, true
+}
+
+// writeMetadata only logs failures since metadata is not required to use cached tarballs
+func (c *cache) writeMetadata(path string, metadata cacheMetadata) {
+	metadataPath := path + cacheMetadataSuffix
+
+	bytes, err := json.Marshal(metadata)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to marshal cached tarball metadata '%s': %s", metadataPath, err.Error())
+		return
+	}
+
+	err = c.fs.WriteFile(metadataPath, bytes)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to write cached tarball metadata '%s': %s", metadataPath, err.Error())
+	}
+}
+
+type cacheEntriesByPath []CacheEntry
+
+func (s cacheEntriesByPath) Len() int           { return len(s) }
+func (s cacheEntriesByPath) Less(i, j int) bool { return s[i].Path < s[j].Path }
+func (s cacheEntriesByPath) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
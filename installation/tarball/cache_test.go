@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -14,16 +16,19 @@ import (
 
 var _ = Describe("Cache", func() {
 	var (
-		cache Cache
-		fs    *fakesys.FakeFileSystem
+		cache       Cache
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
 	)
 
 	BeforeEach(func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
 		cache = NewCache(
 			"/fake-base-path",
 			fs,
+			timeService,
 			logger,
 		)
 	})
@@ -114,4 +119,106 @@ var _ = Describe("Cache", func() {
 		})
 		Expect(err).ToNot(HaveOccurred())
 	})
+
+	It("saves files named with the strongest digest when multiple digests are given", func() {
+		fs.WriteFileString("source-path", "")
+
+		source := &fakeSource{
+			sha1:        "sha1:fakesha1;sha256:fakesha256",
+			url:         "http://foo.bar.com",
+			description: "some tarball",
+		}
+
+		err := cache.Save("source-path", source)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cache.Path(source)).To(Equal(filepath.Join("/", "fake-base-path", "587cd74a86333e7f1ebca70474a1f4456e4b5d3e-sha256-fakesha256")))
+
+		_, found := cache.Get(&fakeSource{
+			sha1:        "sha256:fakesha256",
+			url:         "http://foo.bar.com",
+			description: "some tarball",
+		})
+		Expect(found).To(BeTrue())
+	})
+
+	Describe("List", func() {
+		It("returns no entries when nothing was downloaded", func() {
+			Expect(cache.List()).To(BeEmpty())
+		})
+
+		It("returns saved tarballs with their source URL, size and last used time", func() {
+			fs.WriteFileString("source-path", "content")
+
+			source := &fakeSource{
+				sha1:        "fake-sha1",
+				url:         "http://foo.bar.com",
+				description: "some tarball",
+			}
+
+			err := cache.Save("source-path", source)
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.Increment(time.Hour)
+
+			_, found := cache.Get(source)
+			Expect(found).To(BeTrue())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]CacheEntry{
+				{
+					Path:   cache.Path(source),
+					URL:    "http://foo.bar.com",
+					Digest: "fake-sha1",
+					Size:   7,
+
+					CreatedAt:  time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+					LastUsedAt: time.Date(2017, 1, 1, 1, 0, 0, 0, time.UTC),
+				},
+			}))
+		})
+
+		It("returns tarballs downloaded without metadata", func() {
+			modTime := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			fs.WriteFileString("/fake-base-path/legacy-tarball", "content")
+			fs.GetFileTestStat("/fake-base-path/legacy-tarball").ModTime = modTime
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]CacheEntry{
+				{
+					Path: "/fake-base-path/legacy-tarball",
+					Size: 7,
+
+					CreatedAt:  modTime,
+					LastUsedAt: modTime,
+				},
+			}))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes tarball and its metadata", func() {
+			fs.WriteFileString("source-path", "content")
+
+			err := cache.Save("source-path", &fakeSource{
+				sha1:        "fake-sha1",
+				url:         "http://foo.bar.com",
+				description: "some tarball",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = cache.Delete(entries[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(entries[0].Path)).To(BeFalse())
+			Expect(fs.FileExists(entries[0].Path + ".meta")).To(BeFalse())
+			Expect(cache.List()).To(BeEmpty())
+		})
+	})
 })
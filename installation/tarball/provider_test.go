@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"code.cloudfoundry.org/clock"
 	. "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
@@ -34,7 +35,7 @@ var _ = Describe("Provider", func() {
 		server = ghttp.NewServer()
 		fs = fakesys.NewFakeFileSystem()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		cache = NewCache(filepath.Join("/", "fake-base-path"), fs, logger)
+		cache = NewCache(filepath.Join("/", "fake-base-path"), fs, clock.NewClock(), logger)
 		httpClient := httpclient.NewHTTPClient(httpclient.DefaultClient, logger)
 		provider = NewProvider(cache, fs, httpClient, 3, 0, logger)
 		fakeStage = fakebiui.NewFakeStage()
@@ -7,6 +7,7 @@ import (
 	"text/template"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
@@ -34,6 +35,7 @@ import (
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	mock_instance_state "github.com/cloudfoundry/bosh-cli/deployment/instance/state/mocks"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bipreflight "github.com/cloudfoundry/bosh-cli/deployment/preflight"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
@@ -410,15 +412,17 @@ cloud_provider:
 				deploymentRecord := bidepl.NewRecord(deploymentRepo, releaseRepo, stemcellRepo)
 				stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
 				diskManagerFactory = bidisk.NewManagerFactory(diskRepo, logger)
-				diskDeployer = bivm.NewDiskDeployer(diskManagerFactory, diskRepo, logger)
-				vmManagerFactory = bivm.NewManagerFactory(vmRepo, stemcellRepo, diskDeployer, fakeAgentIDGenerator, fs, logger)
+				checkpointRepo := biconfig.NewCheckpointRepo(deploymentStateService)
+				diskDeployer = bivm.NewDiskDeployer(diskManagerFactory, diskRepo, checkpointRepo, logger)
+				vmManagerFactory = bivm.NewManagerFactory(vmRepo, stemcellRepo, checkpointRepo, diskDeployer, fakeAgentIDGenerator, fs, logger)
 				deployer := bidepl.NewDeployer(
 					vmManagerFactory,
 					instanceManagerFactory,
 					deploymentFactory,
+					checkpointRepo,
 					logger,
 				)
-				tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
+				tarballCache := bitarball.NewCache("fake-base-path", fs, clock.NewClock(), logger)
 				tarballProvider := bitarball.NewProvider(tarballCache, fs, nil, 1, 0, logger)
 
 				cpiInstaller := bicpirel.CpiInstaller{
@@ -461,12 +465,14 @@ cloud_provider:
 					legacyDeploymentStateMigrator,
 					releaseManager,
 					deploymentRecord,
+					checkpointRepo,
 					mockCloudFactory,
 					stemcellManagerFactory,
 					mockAgentClientFactory,
 					vmManagerFactory,
 					mockBlobstoreFactory,
 					deployer,
+					deployer,
 					deploymentManifestPath,
 					deploymentVars,
 					deploymentOp,
@@ -477,6 +483,7 @@ cloud_provider:
 					deploymentManifestParser,
 					tempRootConfigurator,
 					targetProvider,
+					bipreflight.NewChecker(func(string) ([]string, error) { return []string{"10.0.0.1"}, nil }, logger),
 				)
 			}
 
@@ -514,6 +521,11 @@ cloud_provider:
 			)
 		}
 
+		var resizeNotImplementedErr = bicloud.NewCPIError("resize_disk", bicloud.CmdError{
+			Type:    bicloud.NotImplementedError,
+			Message: "fake-resize-not-implemented",
+		})
+
 		var expectDeployWithDiskMigration = func() {
 			agentID := "fake-uuid-1"
 			oldVMCID := "fake-vm-cid-1"
@@ -544,6 +556,15 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				// attempt to resize old disk in place
+				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
+				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, 2048).Return(resizeNotImplementedErr),
+				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -566,6 +587,53 @@ cloud_provider:
 			)
 		}
 
+		var expectDeployWithDiskResize = func() {
+			agentID := "fake-uuid-1"
+			oldVMCID := "fake-vm-cid-1"
+			newVMCID := "fake-vm-cid-2"
+			diskCID := "fake-disk-cid-1"
+
+			gomock.InOrder(
+				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
+
+				// shutdown old vm
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{diskCID}, nil),
+				mockAgentClient.EXPECT().UnmountDisk(diskCID),
+				mockCloud.EXPECT().DeleteVM(oldVMCID),
+
+				// create new vm
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+
+				// attach disk, resize it in place and attach it again
+				mockCloud.EXPECT().AttachDisk(newVMCID, diskCID),
+				mockCloud.EXPECT().SetDiskMetadata(diskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(diskCID),
+				mockAgentClient.EXPECT().UnmountDisk(diskCID),
+				mockCloud.EXPECT().DetachDisk(newVMCID, diskCID),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().ResizeDisk(diskCID, 2048),
+				mockCloud.EXPECT().AttachDisk(newVMCID, diskCID),
+				mockCloud.EXPECT().SetDiskMetadata(diskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(diskCID),
+
+				// start jobs & wait for running
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().GetState(),
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().RunScript("pre-start", map[string]interface{}{}),
+				mockAgentClient.EXPECT().Start(),
+				mockAgentClient.EXPECT().GetState().Return(agentRunningState, nil),
+				mockAgentClient.EXPECT().RunScript("post-start", map[string]interface{}{}),
+			)
+		}
+
 		var expectDeployWithDiskMigrationMissingVM = func() {
 			agentID := "fake-uuid-1"
 			oldVMCID := "fake-vm-cid-1"
@@ -592,6 +660,15 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				// attempt to resize old disk in place
+				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
+				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, 2048).Return(resizeNotImplementedErr),
+				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -673,6 +750,15 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				// attempt to resize old disk in place
+				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
+				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, 2048).Return(resizeNotImplementedErr),
+				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
@@ -689,8 +775,7 @@ cloud_provider:
 			oldVMCID := "fake-vm-cid-2"
 			newVMCID := "fake-vm-cid-3"
 			oldDiskCID := "fake-disk-cid-1"
-			newDiskCID := "fake-disk-cid-3"
-			newDiskSize := 2048
+			pendingDiskCID := "fake-disk-cid-2"
 
 			gomock.InOrder(
 				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
@@ -698,8 +783,9 @@ cloud_provider:
 				// shutdown old vm
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().Stop(),
-				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID, pendingDiskCID}, nil),
 				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
+				mockAgentClient.EXPECT().UnmountDisk(pendingDiskCID),
 				mockCloud.EXPECT().DeleteVM(oldVMCID),
 
 				// create new vm
@@ -707,16 +793,24 @@ cloud_provider:
 				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
-				// attach both disks and migrate
+				// attach both disks and migrate to disk created by failed deploy
 				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
-				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
-				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
-				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
+				// attempt to resize old disk in place
+				mockAgentClient.EXPECT().UnmountDisk(oldDiskCID),
+				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
-				mockAgentClient.EXPECT().MountDisk(newDiskCID),
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, 2048).Return(resizeNotImplementedErr),
+				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
+				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockCloud.EXPECT().AttachDisk(newVMCID, pendingDiskCID),
+				mockCloud.EXPECT().SetDiskMetadata(pendingDiskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(pendingDiskCID),
 				mockAgentClient.EXPECT().MigrateDisk(),
 				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
@@ -758,6 +852,7 @@ cloud_provider:
 			fakeRepoUUIDGenerator = fakeuuid.NewFakeGenerator()
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+			mockCloud.EXPECT().Info().Return(bicloud.CPIInfo{}, nil).AnyTimes()
 
 			registryServerManager = biregistry.NewServerManager(logger)
 
@@ -904,6 +999,19 @@ cloud_provider:
 					Expect(err).ToNot(HaveOccurred())
 				})
 
+				It("resizes the disk in place when the CPI supports it", func() {
+					expectDeployWithDiskResize()
+
+					err := newCreateEnvCmd().Run(fakeStage, newDeployOpts(deploymentManifestPath, ""))
+					Expect(err).ToNot(HaveOccurred())
+
+					diskRecords, err := diskRepo.All()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(diskRecords).To(HaveLen(1))
+					Expect(diskRecords[0].CID).To(Equal("fake-disk-cid-1"))
+					Expect(diskRecords[0].Size).To(Equal(2048))
+				})
+
 				Context("when current VM has been deleted manually (outside of bosh)", func() {
 					It("migrates the disk content, but does not shutdown the old VM", func() {
 						expectDeployWithDiskMigrationMissingVM()
@@ -950,18 +1058,16 @@ cloud_provider:
 						Expect(diskRecords).To(HaveLen(2)) // current + unused
 					})
 
-					It("deletes unused disks", func() {
+					It("resumes migration to the disk created by failed deploy", func() {
 						expectDeployWithDiskMigrationRepair()
 
-						mockCloud.EXPECT().DeleteDisk("fake-disk-cid-2")
-
 						err := newCreateEnvCmd().Run(fakeStage, newDeployOpts(deploymentManifestPath, ""))
 						Expect(err).ToNot(HaveOccurred())
 
 						diskRecord, found, err := diskRepo.FindCurrent()
 						Expect(err).ToNot(HaveOccurred())
 						Expect(found).To(BeTrue())
-						Expect(diskRecord.CID).To(Equal("fake-disk-cid-3"))
+						Expect(diskRecord.CID).To(Equal("fake-disk-cid-2"))
 
 						diskRecords, err := diskRepo.All()
 						Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,257 @@
+package diff
+
+import (
+	"reflect"
+	"sort"
+
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+type Change string
+
+const (
+	Added   Change = "added"
+	Removed Change = "removed"
+	Changed Change = "changed"
+)
+
+type Result struct {
+	Jobs     []JobDiff
+	Packages []PackageDiff
+}
+
+func (r Result) Empty() bool { return len(r.Jobs) == 0 && len(r.Packages) == 0 }
+
+type JobDiff struct {
+	Name   string
+	Change Change
+
+	OldFingerprint string
+	NewFingerprint string
+
+	Properties []PropertyDiff
+	Provides   []LinkDiff
+	Consumes   []LinkDiff
+}
+
+type PropertyDiff struct {
+	Name   string
+	Change Change
+
+	OldDefault interface{}
+	NewDefault interface{}
+}
+
+type LinkDiff struct {
+	Name   string
+	Change Change
+
+	Old boshjob.LinkDefinition
+	New boshjob.LinkDefinition
+}
+
+type PackageDiff struct {
+	Name   string
+	Change Change
+
+	OldFingerprint string
+	NewFingerprint string
+
+	AddedDependencies   []string
+	RemovedDependencies []string
+}
+
+// Diff compares jobs and packages by fingerprint; only jobs and packages
+// with different fingerprints are compared in more detail
+func Diff(oldRel, newRel Release) Result {
+	var result Result
+
+	oldJobs := map[string]Job{}
+	newJobs := map[string]Job{}
+
+	for _, job := range oldRel.Jobs {
+		oldJobs[job.Name] = job
+	}
+
+	for _, job := range newRel.Jobs {
+		newJobs[job.Name] = job
+	}
+
+	for _, name := range unionNames(oldJobs, newJobs) {
+		oldJob, oldFound := oldJobs[name]
+		newJob, newFound := newJobs[name]
+
+		switch {
+		case !oldFound:
+			result.Jobs = append(result.Jobs, JobDiff{Name: name, Change: Added, NewFingerprint: newJob.Fingerprint})
+
+		case !newFound:
+			result.Jobs = append(result.Jobs, JobDiff{Name: name, Change: Removed, OldFingerprint: oldJob.Fingerprint})
+
+		case oldJob.Fingerprint != newJob.Fingerprint:
+			result.Jobs = append(result.Jobs, diffJob(oldJob, newJob))
+		}
+	}
+
+	oldPkgs := map[string]Package{}
+	newPkgs := map[string]Package{}
+
+	for _, pkg := range oldRel.Packages {
+		oldPkgs[pkg.Name] = pkg
+	}
+
+	for _, pkg := range newRel.Packages {
+		newPkgs[pkg.Name] = pkg
+	}
+
+	for _, name := range unionNames(oldPkgs, newPkgs) {
+		oldPkg, oldFound := oldPkgs[name]
+		newPkg, newFound := newPkgs[name]
+
+		switch {
+		case !oldFound:
+			result.Packages = append(result.Packages, PackageDiff{
+				Name: name, Change: Added, NewFingerprint: newPkg.Fingerprint})
+
+		case !newFound:
+			result.Packages = append(result.Packages, PackageDiff{
+				Name: name, Change: Removed, OldFingerprint: oldPkg.Fingerprint})
+
+		case oldPkg.Fingerprint != newPkg.Fingerprint:
+			result.Packages = append(result.Packages, diffPackage(oldPkg, newPkg))
+		}
+	}
+
+	return result
+}
+
+func diffJob(oldJob, newJob Job) JobDiff {
+	jobDiff := JobDiff{
+		Name:           oldJob.Name,
+		Change:         Changed,
+		OldFingerprint: oldJob.Fingerprint,
+		NewFingerprint: newJob.Fingerprint,
+		Provides:       diffLinks(oldJob.Provides, newJob.Provides),
+		Consumes:       diffLinks(oldJob.Consumes, newJob.Consumes),
+	}
+
+	if oldJob.Properties == nil || newJob.Properties == nil {
+		return jobDiff
+	}
+
+	for _, name := range unionNames(oldJob.Properties, newJob.Properties) {
+		oldProp, oldFound := oldJob.Properties[name]
+		newProp, newFound := newJob.Properties[name]
+
+		switch {
+		case !oldFound:
+			jobDiff.Properties = append(jobDiff.Properties, PropertyDiff{
+				Name: name, Change: Added, NewDefault: newProp.Default})
+
+		case !newFound:
+			jobDiff.Properties = append(jobDiff.Properties, PropertyDiff{
+				Name: name, Change: Removed, OldDefault: oldProp.Default})
+
+		case !reflect.DeepEqual(oldProp.Default, newProp.Default):
+			jobDiff.Properties = append(jobDiff.Properties, PropertyDiff{
+				Name: name, Change: Changed, OldDefault: oldProp.Default, NewDefault: newProp.Default})
+		}
+	}
+
+	return jobDiff
+}
+
+func diffLinks(oldLinks, newLinks []boshjob.LinkDefinition) []LinkDiff {
+	var diffs []LinkDiff
+
+	oldLinksByName := map[string]boshjob.LinkDefinition{}
+	newLinksByName := map[string]boshjob.LinkDefinition{}
+
+	for _, link := range oldLinks {
+		oldLinksByName[link.Name] = link
+	}
+
+	for _, link := range newLinks {
+		newLinksByName[link.Name] = link
+	}
+
+	for _, name := range unionNames(oldLinksByName, newLinksByName) {
+		oldLink, oldFound := oldLinksByName[name]
+		newLink, newFound := newLinksByName[name]
+
+		switch {
+		case !oldFound:
+			diffs = append(diffs, LinkDiff{Name: name, Change: Added, New: newLink})
+
+		case !newFound:
+			diffs = append(diffs, LinkDiff{Name: name, Change: Removed, Old: oldLink})
+
+		case oldLink != newLink:
+			diffs = append(diffs, LinkDiff{Name: name, Change: Changed, Old: oldLink, New: newLink})
+		}
+	}
+
+	return diffs
+}
+
+func diffPackage(oldPkg, newPkg Package) PackageDiff {
+	pkgDiff := PackageDiff{
+		Name:           oldPkg.Name,
+		Change:         Changed,
+		OldFingerprint: oldPkg.Fingerprint,
+		NewFingerprint: newPkg.Fingerprint,
+	}
+
+	if oldPkg.Dependencies == nil || newPkg.Dependencies == nil {
+		return pkgDiff
+	}
+
+	pkgDiff.AddedDependencies = subtractNames(newPkg.Dependencies, oldPkg.Dependencies)
+	pkgDiff.RemovedDependencies = subtractNames(oldPkg.Dependencies, newPkg.Dependencies)
+
+	return pkgDiff
+}
+
+// unionNames returns sorted keys of both maps
+func unionNames(oldMap, newMap interface{}) []string {
+	namesMap := map[string]struct{}{}
+
+	for _, m := range []interface{}{oldMap, newMap} {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			namesMap[key.String()] = struct{}{}
+		}
+	}
+
+	var names []string
+
+	for name := range namesMap {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func subtractNames(names, otherNames []string) []string {
+	var result []string
+
+	for _, name := range names {
+		var found bool
+
+		for _, otherName := range otherNames {
+			if name == otherName {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			result = append(result, name)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
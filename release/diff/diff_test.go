@@ -0,0 +1,157 @@
+package diff_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/release/diff"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+var _ = Describe("Diff", func() {
+	var (
+		oldRel, newRel Release
+	)
+
+	BeforeEach(func() {
+		oldRel = Release{
+			Jobs: []Job{
+				{Name: "same-job", Fingerprint: "same-job-fp"},
+				{Name: "removed-job", Fingerprint: "removed-job-fp"},
+			},
+			Packages: []Package{
+				{Name: "same-pkg", Fingerprint: "same-pkg-fp"},
+				{Name: "removed-pkg", Fingerprint: "removed-pkg-fp"},
+			},
+		}
+
+		newRel = Release{
+			Jobs: []Job{
+				{Name: "added-job", Fingerprint: "added-job-fp"},
+				{Name: "same-job", Fingerprint: "same-job-fp"},
+			},
+			Packages: []Package{
+				{Name: "added-pkg", Fingerprint: "added-pkg-fp"},
+				{Name: "same-pkg", Fingerprint: "same-pkg-fp"},
+			},
+		}
+	})
+
+	It("returns added and removed jobs and packages sorted by name", func() {
+		Expect(Diff(oldRel, newRel)).To(Equal(Result{
+			Jobs: []JobDiff{
+				{Name: "added-job", Change: Added, NewFingerprint: "added-job-fp"},
+				{Name: "removed-job", Change: Removed, OldFingerprint: "removed-job-fp"},
+			},
+			Packages: []PackageDiff{
+				{Name: "added-pkg", Change: Added, NewFingerprint: "added-pkg-fp"},
+				{Name: "removed-pkg", Change: Removed, OldFingerprint: "removed-pkg-fp"},
+			},
+		}))
+	})
+
+	It("returns empty result for same releases", func() {
+		Expect(Diff(oldRel, oldRel).Empty()).To(BeTrue())
+		Expect(Diff(oldRel, newRel).Empty()).To(BeFalse())
+	})
+
+	Describe("changed jobs", func() {
+		BeforeEach(func() {
+			oldRel = Release{Jobs: []Job{{
+				Name:        "job",
+				Fingerprint: "old-fp",
+				Properties: map[string]boshjob.PropertyDefinition{
+					"same":    {Default: "default"},
+					"removed": {Default: "removed-default"},
+					"changed": {Default: "old-default"},
+				},
+				Provides: []boshjob.LinkDefinition{{Name: "db", Type: "postgres"}},
+				Consumes: []boshjob.LinkDefinition{
+					{Name: "removed", Type: "removed-type"},
+					{Name: "changed", Type: "changed-type"},
+				},
+			}}}
+
+			newRel = Release{Jobs: []Job{{
+				Name:        "job",
+				Fingerprint: "new-fp",
+				Properties: map[string]boshjob.PropertyDefinition{
+					"same":    {Default: "default", Description: "new-desc"},
+					"added":   {Default: nil},
+					"changed": {Default: "new-default"},
+				},
+				Provides: []boshjob.LinkDefinition{{Name: "db", Type: "postgres"}},
+				Consumes: []boshjob.LinkDefinition{
+					{Name: "changed", Type: "changed-type", Optional: true},
+					{Name: "added", Type: "added-type"},
+				},
+			}}}
+		})
+
+		It("returns property and link changes", func() {
+			Expect(Diff(oldRel, newRel).Jobs).To(Equal([]JobDiff{{
+				Name:           "job",
+				Change:         Changed,
+				OldFingerprint: "old-fp",
+				NewFingerprint: "new-fp",
+				Properties: []PropertyDiff{
+					{Name: "added", Change: Added},
+					{Name: "changed", Change: Changed, OldDefault: "old-default", NewDefault: "new-default"},
+					{Name: "removed", Change: Removed, OldDefault: "removed-default"},
+				},
+				Consumes: []LinkDiff{
+					{Name: "added", Change: Added, New: boshjob.LinkDefinition{Name: "added", Type: "added-type"}},
+					{
+						Name:   "changed",
+						Change: Changed,
+						Old:    boshjob.LinkDefinition{Name: "changed", Type: "changed-type"},
+						New:    boshjob.LinkDefinition{Name: "changed", Type: "changed-type", Optional: true},
+					},
+					{Name: "removed", Change: Removed, Old: boshjob.LinkDefinition{Name: "removed", Type: "removed-type"}},
+				},
+			}}))
+		})
+
+		It("does not compare properties when either release does not include them", func() {
+			newRel.Jobs[0].Properties = nil
+
+			Expect(Diff(oldRel, newRel).Jobs[0].Properties).To(BeEmpty())
+		})
+
+		It("does not compare specs of jobs with the same fingerprint", func() {
+			newRel.Jobs[0].Fingerprint = "old-fp"
+
+			Expect(Diff(oldRel, newRel).Jobs).To(BeEmpty())
+		})
+	})
+
+	Describe("changed packages", func() {
+		BeforeEach(func() {
+			oldRel = Release{Packages: []Package{
+				{Name: "pkg", Fingerprint: "old-fp", Dependencies: []string{"same", "removed"}},
+			}}
+			newRel = Release{Packages: []Package{
+				{Name: "pkg", Fingerprint: "new-fp", Dependencies: []string{"same", "added2", "added1"}},
+			}}
+		})
+
+		It("returns dependency changes", func() {
+			Expect(Diff(oldRel, newRel).Packages).To(Equal([]PackageDiff{{
+				Name:                "pkg",
+				Change:              Changed,
+				OldFingerprint:      "old-fp",
+				NewFingerprint:      "new-fp",
+				AddedDependencies:   []string{"added1", "added2"},
+				RemovedDependencies: []string{"removed"},
+			}}))
+		})
+
+		It("does not compare dependencies when either release does not include them", func() {
+			oldRel.Packages[0].Dependencies = nil
+
+			pkgDiff := Diff(oldRel, newRel).Packages[0]
+			Expect(pkgDiff.AddedDependencies).To(BeEmpty())
+			Expect(pkgDiff.RemovedDependencies).To(BeEmpty())
+		})
+	})
+})
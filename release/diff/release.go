@@ -0,0 +1,84 @@
+package diff
+
+import (
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+// Release is a comparable view of a release. Releases on the Director
+// do not include job properties and package dependencies, hence those are
+// nil when unknown and are only compared when both releases include them.
+type Release struct {
+	Name    string
+	Version string
+
+	Jobs     []Job
+	Packages []Package
+}
+
+type Job struct {
+	Name        string
+	Fingerprint string
+
+	Properties map[string]boshjob.PropertyDefinition
+	Provides   []boshjob.LinkDefinition
+	Consumes   []boshjob.LinkDefinition
+}
+
+type Package struct {
+	Name        string
+	Fingerprint string
+
+	Dependencies []string
+}
+
+// NewRelease expects jobs to be read with their specs (e.g. extracted)
+// so that properties and links can be compared
+func NewRelease(release boshrel.Release) Release {
+	result := Release{Name: release.Name(), Version: release.Version()}
+
+	for _, job := range release.Jobs() {
+		result.Jobs = append(result.Jobs, NewJob(job))
+	}
+
+	for _, pkg := range release.Packages() {
+		deps := []string{}
+
+		for _, dep := range pkg.Dependencies {
+			deps = append(deps, dep.Name())
+		}
+
+		result.Packages = append(result.Packages, Package{
+			Name:         pkg.Name(),
+			Fingerprint:  pkg.Fingerprint(),
+			Dependencies: deps,
+		})
+	}
+
+	// compiled releases only include compiled packages which keep source fingerprints
+	for _, compiledPkg := range release.CompiledPackages() {
+		deps := []string{}
+
+		for _, dep := range compiledPkg.Dependencies {
+			deps = append(deps, dep.Name())
+		}
+
+		result.Packages = append(result.Packages, Package{
+			Name:         compiledPkg.Name(),
+			Fingerprint:  compiledPkg.Fingerprint(),
+			Dependencies: deps,
+		})
+	}
+
+	return result
+}
+
+func NewJob(job *boshjob.Job) Job {
+	return Job{
+		Name:        job.Name(),
+		Fingerprint: job.Fingerprint(),
+		Properties:  job.Properties,
+		Provides:    job.Provides,
+		Consumes:    job.Consumes,
+	}
+}
@@ -0,0 +1,45 @@
+package diff_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/release/diff"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+)
+
+var _ = Describe("NewRelease", func() {
+	It("includes job specs and package dependencies", func() {
+		job := boshjob.NewJob(NewExistingResource("job1", "job1-fp", "job1-sha1"))
+		job.Properties = map[string]boshjob.PropertyDefinition{"prop": {Default: "default"}}
+		job.Provides = []boshjob.LinkDefinition{{Name: "db", Type: "postgres"}}
+
+		pkg1 := boshpkg.NewPackage(NewExistingResource("pkg1", "pkg1-fp", "pkg1-sha1"), nil)
+		pkg2 := boshpkg.NewPackage(NewExistingResource("pkg2", "pkg2-fp", "pkg2-sha1"), []string{"pkg1"})
+		Expect(pkg2.AttachDependencies([]*boshpkg.Package{pkg1})).To(Succeed())
+
+		release := &fakerel.FakeRelease{}
+		release.NameReturns("rel")
+		release.VersionReturns("1")
+		release.JobsReturns([]*boshjob.Job{job})
+		release.PackagesReturns([]*boshpkg.Package{pkg1, pkg2})
+
+		Expect(NewRelease(release)).To(Equal(Release{
+			Name:    "rel",
+			Version: "1",
+			Jobs: []Job{{
+				Name:        "job1",
+				Fingerprint: "job1-fp",
+				Properties:  map[string]boshjob.PropertyDefinition{"prop": {Default: "default"}},
+				Provides:    []boshjob.LinkDefinition{{Name: "db", Type: "postgres"}},
+			}},
+			Packages: []Package{
+				{Name: "pkg1", Fingerprint: "pkg1-fp", Dependencies: []string{}},
+				{Name: "pkg2", Fingerprint: "pkg2-fp", Dependencies: []string{"pkg1"}},
+			},
+		}))
+	})
+})
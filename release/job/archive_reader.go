@@ -59,23 +59,47 @@ func (r ArchiveReaderImpl) Read(ref boshman.JobRef, path string) (*Job, error) {
 		job.Templates = manifest.Templates
 		job.PackageNames = manifest.Packages
 
-		properties := make(map[string]PropertyDefinition, len(manifest.Properties))
-
-		for propertyName, rawPropertyDef := range manifest.Properties {
-			defaultValue, err := biproperty.Build(rawPropertyDef.Default)
-			if err != nil {
-				errMsg := "Parsing job '%s' property '%s' default: %#v"
-				return nil, bosherr.WrapErrorf(err, errMsg, job.Name(), propertyName, rawPropertyDef.Default)
-			}
-
-			properties[propertyName] = PropertyDefinition{
-				Description: rawPropertyDef.Description,
-				Default:     defaultValue,
-			}
+		job.Properties, err = newPropertyDefinitions(job.Name(), manifest)
+		if err != nil {
+			return nil, err
 		}
 
-		job.Properties = properties
+		job.Provides = newLinkDefinitions(manifest.Provides)
+		job.Consumes = newLinkDefinitions(manifest.Consumes)
 	}
 
 	return job, nil
 }
+
+func newPropertyDefinitions(jobName string, manifest boshjobman.Manifest) (map[string]PropertyDefinition, error) {
+	properties := make(map[string]PropertyDefinition, len(manifest.Properties))
+
+	for propertyName, rawPropertyDef := range manifest.Properties {
+		defaultValue, err := biproperty.Build(rawPropertyDef.Default)
+		if err != nil {
+			errMsg := "Parsing job '%s' property '%s' default: %#v"
+			return nil, bosherr.WrapErrorf(err, errMsg, jobName, propertyName, rawPropertyDef.Default)
+		}
+
+		properties[propertyName] = PropertyDefinition{
+			Description: rawPropertyDef.Description,
+			Default:     defaultValue,
+		}
+	}
+
+	return properties, nil
+}
+
+func newLinkDefinitions(rawLinkDefs []boshjobman.LinkDefinition) []LinkDefinition {
+	var links []LinkDefinition
+
+	for _, rawLinkDef := range rawLinkDefs {
+		links = append(links, LinkDefinition{
+			Name:     rawLinkDef.Name,
+			Type:     rawLinkDef.Type,
+			Optional: rawLinkDef.Optional,
+		})
+	}
+
+	return links
+}
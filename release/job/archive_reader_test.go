@@ -75,6 +75,22 @@ properties:
 			Expect(compressor.DecompressFileToDirOptions).To(Equal([]boshcmd.CompressorOptions{{}}))
 		})
 
+		It("returns a job with links from the manifest", func() {
+			fs.WriteFileString("/extracted/job/job.MF", `---
+name: name
+provides:
+- {name: db, type: postgres}
+consumes:
+- {name: blobstore, type: blobstore, optional: true}
+`)
+
+			job, err := reader.Read(ref, "archive-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(job.Provides).To(Equal([]LinkDefinition{{Name: "db", Type: "postgres"}}))
+			Expect(job.Consumes).To(Equal([]LinkDefinition{{Name: "blobstore", Type: "blobstore", Optional: true}}))
+		})
+
 		It("returns an error when the job manifest is invalid", func() {
 			fs.WriteFileString("/extracted/job/job.MF", "-")
 
@@ -32,8 +32,20 @@ func (r DirReaderImpl) Read(path string) (*Job, error) {
 		return nil, err
 	}
 
-	job := NewJob(NewResource(manifest.Name, fp, archive))
+	// Job directory already has the same layout as an extracted job archive
+	// so templates can be rendered from it; no fs is given to avoid removing it on clean up
+	job := NewExtractedJob(NewResource(manifest.Name, fp, archive), path, nil)
+	job.Templates = manifest.Templates
 	job.PackageNames = manifest.Packages
+
+	job.Properties, err = newPropertyDefinitions(job.Name(), manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Provides = newLinkDefinitions(manifest.Provides)
+	job.Consumes = newLinkDefinitions(manifest.Consumes)
+
 	// Does not read all manifest values...
 
 	return job, nil
@@ -54,8 +54,12 @@ properties:
 
 			archive.FingerprintReturns("fp", nil)
 
-			expectedJob := NewJob(NewResource("name", "fp", archive))
-			expectedJob.PackageNames = []string{"pkg"} // only expect pkg names
+			expectedJob := NewExtractedJob(NewResource("name", "fp", archive), filepath.Join("/", "dir"), nil)
+			expectedJob.Templates = map[string]string{"src": "dst"}
+			expectedJob.PackageNames = []string{"pkg"}
+			expectedJob.Properties = map[string]PropertyDefinition{
+				"prop": PropertyDefinition{Description: "prop-desc", Default: "prop-default"},
+			}
 
 			job, err := reader.Read(filepath.Join("/", "dir"))
 			Expect(err).NotTo(HaveOccurred())
@@ -77,9 +81,12 @@ properties:
 
 			archive.FingerprintReturns("fp", nil)
 
+			expectedJob := NewExtractedJob(NewResource("name", "fp", archive), filepath.Join("/", "dir"), nil)
+			expectedJob.Properties = map[string]PropertyDefinition{}
+
 			job, err := reader.Read(filepath.Join("/", "dir"))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(job).To(Equal(NewJob(NewResource("name", "fp", archive))))
+			Expect(job).To(Equal(expectedJob))
 
 			Expect(collectedFiles).To(Equal([]File{
 				File{Path: filepath.Join("/", "dir", "spec"), DirPath: filepath.Join("/", "dir"), RelativePath: "job.MF"},
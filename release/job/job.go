@@ -24,6 +24,8 @@ type Job struct {
 	PackageNames []string
 	Packages     []boshpkg.Compilable
 	Properties   map[string]PropertyDefinition
+	Provides     []LinkDefinition
+	Consumes     []LinkDefinition
 
 	extractedPath string
 	fs            boshsys.FileSystem
@@ -34,6 +36,12 @@ type PropertyDefinition struct {
 	Default     biproperty.Property
 }
 
+type LinkDefinition struct {
+	Name     string
+	Type     string
+	Optional bool
+}
+
 func NewJob(resource Resource) *Job {
 	return &Job{resource: resource}
 }
@@ -80,6 +88,8 @@ func (j *Job) RehashWithCalculator(calculator crypto.DigestCalculator, archiveFi
 		PackageNames: j.PackageNames,
 		Packages:     j.Packages,
 		Properties:   j.Properties,
+		Provides:     j.Provides,
+		Consumes:     j.Consumes,
 
 		extractedPath: j.extractedPath,
 		fs:            j.fs,
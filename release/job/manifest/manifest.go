@@ -11,6 +11,8 @@ type Manifest struct {
 	Templates  map[string]string             `yaml:"templates"`
 	Packages   []string                      `yaml:"packages"`
 	Properties map[string]PropertyDefinition `yaml:"properties"`
+	Provides   []LinkDefinition              `yaml:"provides"`
+	Consumes   []LinkDefinition              `yaml:"consumes"`
 }
 
 type PropertyDefinition struct {
@@ -18,6 +20,12 @@ type PropertyDefinition struct {
 	Default     interface{} `yaml:"default"`
 }
 
+type LinkDefinition struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Optional bool   `yaml:"optional"`
+}
+
 func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
 	var manifest Manifest
 
@@ -37,6 +37,15 @@ properties:
   prop1.prop2:
     description: prop2-desc
     default: prop2-default
+
+provides:
+- name: db
+  type: postgres
+
+consumes:
+- name: blobstore
+  type: blobstore
+  optional: true
 `
 
 		fs.WriteFileString("/path", contents)
@@ -60,6 +69,9 @@ properties:
 					Default:     "prop2-default",
 				},
 			},
+
+			Provides: []LinkDefinition{{Name: "db", Type: "postgres"}},
+			Consumes: []LinkDefinition{{Name: "blobstore", Type: "blobstore", Optional: true}},
 		}))
 	})
 
@@ -0,0 +1,493 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	boshjobman "github.com/cloudfoundry/bosh-cli/release/job/manifest"
+	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	boshpkgman "github.com/cloudfoundry/bosh-cli/release/pkg/manifest"
+)
+
+const (
+	jobType     = "job"
+	packageType = "package"
+)
+
+// DirLinter checks release directory jobs and packages;
+// each problem is reported without stopping at the first one
+type DirLinter struct {
+	dirPath string
+
+	jobDirReader boshjob.DirReader
+	pkgDirReader boshpkg.DirReader
+	fs           boshsys.FileSystem
+}
+
+type lintedJob struct {
+	name     string
+	packages []string
+
+	// nil if job could not be read
+	readJob *boshjob.Job
+}
+
+func NewDirLinter(
+	dirPath string,
+	jobDirReader boshjob.DirReader,
+	pkgDirReader boshpkg.DirReader,
+	fs boshsys.FileSystem,
+) DirLinter {
+	return DirLinter{
+		dirPath: dirPath,
+
+		jobDirReader: jobDirReader,
+		pkgDirReader: pkgDirReader,
+		fs:           fs,
+	}
+}
+
+func (l DirLinter) Lint() ([]Problem, error) {
+	var problems []Problem
+
+	pkgPaths, err := l.dirs(filepath.Join(l.dirPath, "packages", "*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing packages in directory")
+	}
+
+	jobPaths, err := l.dirs(filepath.Join(l.dirPath, "jobs", "*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing jobs in directory")
+	}
+
+	blobPaths, err := l.blobPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	// Dependency names by package name
+	pkgDeps := map[string][]string{}
+
+	for _, pkgPath := range pkgPaths {
+		name, deps, pkgProblems := l.lintPackage(pkgPath, blobPaths)
+		problems = append(problems, pkgProblems...)
+
+		if len(name) > 0 {
+			pkgDeps[name] = deps
+		}
+	}
+
+	var jobs []lintedJob
+
+	for _, jobPath := range jobPaths {
+		job, jobProblems := l.lintJob(jobPath)
+		problems = append(problems, jobProblems...)
+
+		if len(job.name) > 0 {
+			jobs = append(jobs, job)
+		}
+	}
+
+	problems = append(problems, l.lintPackageReferences(jobs, pkgDeps)...)
+	problems = append(problems, l.lintDependencyCycles(pkgDeps)...)
+	problems = append(problems, l.lintMonitDependencies(jobs)...)
+	problems = append(problems, l.lintLinks(jobs)...)
+
+	return problems, nil
+}
+
+func (l DirLinter) lintPackage(path string, blobPaths []string) (string, []string, []Problem) {
+	// Locked packages do not include their files
+	if l.fs.FileExists(filepath.Join(path, "spec.lock")) {
+		return l.readPackage(path, filepath.Base(path))
+	}
+
+	manifest, err := boshpkgman.NewManifestFromPath(filepath.Join(path, "spec"), l.fs)
+	if err != nil {
+		return "", nil, []Problem{l.packageProblem(filepath.Base(path), err.Error())}
+	}
+
+	var problems []Problem
+	var missingBlobs bool
+
+	for _, glob := range manifest.Files {
+		found, err := l.globMatches(glob)
+		if err != nil {
+			problems = append(problems, l.packageProblem(manifest.Name, err.Error()))
+		} else if found {
+			continue
+		} else if l.blobPathsMatch(glob, blobPaths) {
+			missingBlobs = true
+		} else {
+			problems = append(problems, l.packageProblem(
+				manifest.Name, fmt.Sprintf("Files pattern '%s' does not match any files in src/, blobs/ or config/blobs.yml", glob)))
+		}
+	}
+
+	// Package dir reader only reports first pattern without matches and
+	// cannot read packages whose blobs have not been downloaded yet
+	if len(problems) > 0 || missingBlobs {
+		return manifest.Name, manifest.Dependencies, problems
+	}
+
+	return l.readPackage(path, manifest.Name)
+}
+
+func (l DirLinter) readPackage(path, name string) (string, []string, []Problem) {
+	pkg, err := l.pkgDirReader.Read(path)
+	if err != nil {
+		return "", nil, []Problem{l.packageProblem(name, err.Error())}
+	}
+
+	return pkg.Name(), pkg.DependencyNames(), nil
+}
+
+func (l DirLinter) globMatches(glob string) (bool, error) {
+	for _, dir := range []string{"src", "blobs"} {
+		matches, err := l.fs.RecursiveGlob(filepath.Join(l.dirPath, dir, glob))
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Listing files for pattern '%s'", glob)
+		}
+
+		for _, match := range matches {
+			info, err := l.fs.Lstat(match)
+			if err != nil {
+				return false, bosherr.WrapErrorf(err, "Checking file '%s'", match)
+			}
+
+			if !info.IsDir() {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// blobPaths returns paths of blobs listed in config/blobs.yml
+// since they are not in blobs/ until they are downloaded
+func (l DirLinter) blobPaths() ([]string, error) {
+	indexPath := filepath.Join(l.dirPath, "config", "blobs.yml")
+
+	if !l.fs.FileExists(indexPath) {
+		return nil, nil
+	}
+
+	bytes, err := l.fs.ReadFile(indexPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading blobs index '%s'", indexPath)
+	}
+
+	var index map[string]interface{}
+
+	err = yaml.Unmarshal(bytes, &index)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Unmarshalling blobs index '%s'", indexPath)
+	}
+
+	var paths []string
+
+	for path := range index {
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+func (l DirLinter) blobPathsMatch(glob string, blobPaths []string) bool {
+	for _, path := range blobPaths {
+		if matched, err := doublestar.PathMatch(glob, path); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l DirLinter) lintJob(path string) (lintedJob, []Problem) {
+	manifest, err := boshjobman.NewManifestFromPath(filepath.Join(path, "spec"), l.fs)
+	if err != nil {
+		return lintedJob{}, []Problem{l.jobProblem(filepath.Base(path), err.Error())}
+	}
+
+	result := lintedJob{name: manifest.Name, packages: manifest.Packages}
+
+	var problems []Problem
+
+	for _, src := range l.sortedKeys(manifest.Templates) {
+		if !l.fs.FileExists(filepath.Join(path, "templates", src)) {
+			problems = append(problems, l.jobProblem(
+				manifest.Name, fmt.Sprintf("Template '%s' does not exist in templates/", src)))
+		}
+	}
+
+	// Job dir reader fails to fingerprint missing templates
+	if len(problems) > 0 {
+		return result, problems
+	}
+
+	job, err := l.jobDirReader.Read(path)
+	if err != nil {
+		return result, []Problem{l.jobProblem(manifest.Name, err.Error())}
+	}
+
+	result.readJob = job
+
+	templatePaths := map[string]string{"monit": filepath.Join(path, "monit")}
+
+	for src := range job.Templates {
+		templatePaths[src] = filepath.Join(path, "templates", src)
+	}
+
+	for _, src := range l.sortedKeys(templatePaths) {
+		if !l.fs.FileExists(templatePaths[src]) {
+			continue
+		}
+
+		contents, err := l.fs.ReadFileString(templatePaths[src])
+		if err != nil {
+			problems = append(problems, l.jobProblem(job.Name(), err.Error()))
+			continue
+		}
+
+		for _, propName := range erbPropertyNames(contents) {
+			if !l.propertyDeclared(propName, job.Properties) {
+				problems = append(problems, l.jobProblem(job.Name(), fmt.Sprintf(
+					"Template '%s' references property '%s' which is not declared in spec", src, propName)))
+			}
+		}
+	}
+
+	return result, problems
+}
+
+// propertyDeclared allows referencing nested properties and parents of declared properties
+func (l DirLinter) propertyDeclared(name string, props map[string]boshjob.PropertyDefinition) bool {
+	for declaredName := range props {
+		if name == declaredName || strings.HasPrefix(declaredName, name+".") || strings.HasPrefix(name, declaredName+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l DirLinter) lintPackageReferences(jobs []lintedJob, pkgDeps map[string][]string) []Problem {
+	var problems []Problem
+
+	usedPkgs := map[string]struct{}{}
+
+	var markUsed func(name string)
+
+	markUsed = func(name string) {
+		if _, found := usedPkgs[name]; found {
+			return
+		}
+
+		usedPkgs[name] = struct{}{}
+
+		for _, dep := range pkgDeps[name] {
+			markUsed(dep)
+		}
+	}
+
+	for _, job := range jobs {
+		for _, pkgName := range job.packages {
+			if _, found := pkgDeps[pkgName]; !found {
+				problems = append(problems, l.jobProblem(
+					job.name, fmt.Sprintf("Package '%s' is not defined in release", pkgName)))
+			}
+
+			markUsed(pkgName)
+		}
+	}
+
+	for _, pkgName := range l.sortedKeys(pkgDeps) {
+		for _, dep := range pkgDeps[pkgName] {
+			if _, found := pkgDeps[dep]; !found {
+				problems = append(problems, l.packageProblem(
+					pkgName, fmt.Sprintf("Dependency '%s' is not defined in release", dep)))
+			}
+		}
+
+		if _, found := usedPkgs[pkgName]; !found {
+			problems = append(problems, l.packageProblem(pkgName, "Package is not used by any job"))
+		}
+	}
+
+	return problems
+}
+
+func (l DirLinter) lintDependencyCycles(pkgDeps map[string][]string) []Problem {
+	var problems []Problem
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	states := map[string]int{}
+
+	var visit func(name string, stack []string)
+
+	visit = func(name string, stack []string) {
+		switch states[name] {
+		case visited:
+			return
+
+		case visiting:
+			for i, stackName := range stack {
+				if stackName == name {
+					cycle := append(append([]string{}, stack[i:]...), name)
+					problems = append(problems, l.packageProblem(
+						name, fmt.Sprintf("Dependency cycle '%s'", strings.Join(cycle, " -> "))))
+					break
+				}
+			}
+			return
+		}
+
+		states[name] = visiting
+
+		for _, dep := range pkgDeps[name] {
+			if _, found := pkgDeps[dep]; found {
+				visit(dep, append(stack, name))
+			}
+		}
+
+		states[name] = visited
+	}
+
+	for _, pkgName := range l.sortedKeys(pkgDeps) {
+		visit(pkgName, nil)
+	}
+
+	return problems
+}
+
+func (l DirLinter) lintMonitDependencies(jobs []lintedJob) []Problem {
+	var problems []Problem
+
+	definedServices := map[string]struct{}{}
+	serviceDeps := map[string][]string{}
+
+	for _, job := range jobs {
+		if job.readJob == nil {
+			continue
+		}
+
+		monitPath := filepath.Join(job.readJob.ExtractedPath(), "monit")
+
+		if !l.fs.FileExists(monitPath) {
+			continue
+		}
+
+		contents, err := l.fs.ReadFileString(monitPath)
+		if err != nil {
+			problems = append(problems, l.jobProblem(job.name, err.Error()))
+			continue
+		}
+
+		defined, deps := monitServices(contents)
+
+		for _, name := range defined {
+			definedServices[name] = struct{}{}
+		}
+
+		serviceDeps[job.name] = deps
+	}
+
+	for _, job := range jobs {
+		for _, dep := range serviceDeps[job.name] {
+			if _, found := definedServices[dep]; !found {
+				problems = append(problems, l.jobProblem(
+					job.name, fmt.Sprintf("Monit file depends on process '%s' which is not defined by any job", dep)))
+			}
+		}
+	}
+
+	return problems
+}
+
+// lintLinks only reports required links since optional links may not be provided
+func (l DirLinter) lintLinks(jobs []lintedJob) []Problem {
+	var problems []Problem
+
+	providedTypes := map[string]struct{}{}
+
+	for _, job := range jobs {
+		if job.readJob != nil {
+			for _, link := range job.readJob.Provides {
+				providedTypes[link.Type] = struct{}{}
+			}
+		}
+	}
+
+	for _, job := range jobs {
+		if job.readJob == nil {
+			continue
+		}
+
+		for _, link := range job.readJob.Consumes {
+			if _, found := providedTypes[link.Type]; !found && !link.Optional {
+				problems = append(problems, l.jobProblem(job.name, fmt.Sprintf(
+					"Consumed link '%s' of type '%s' is not provided by any job", link.Name, link.Type)))
+			}
+		}
+	}
+
+	return problems
+}
+
+func (l DirLinter) dirs(pattern string) ([]string, error) {
+	var dirs []string
+
+	matches, err := l.fs.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range matches {
+		info, err := l.fs.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			dirs = append(dirs, match)
+		}
+	}
+
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
+
+func (l DirLinter) sortedKeys(m interface{}) []string {
+	var keys []string
+
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (l DirLinter) jobProblem(name, msg string) Problem {
+	return Problem{Type: jobType, Name: name, Message: msg}
+}
+
+func (l DirLinter) packageProblem(name, msg string) Problem {
+	return Problem{Type: packageType, Name: name, Message: msg}
+}
@@ -0,0 +1,305 @@
+package lint_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakejob "github.com/cloudfoundry/bosh-cli/release/job/jobfakes"
+	. "github.com/cloudfoundry/bosh-cli/release/lint"
+	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	fakepkg "github.com/cloudfoundry/bosh-cli/release/pkg/pkgfakes"
+	boshres "github.com/cloudfoundry/bosh-cli/release/resource"
+)
+
+var _ = Describe("DirLinter", func() {
+	var (
+		jobDirReader *fakejob.FakeDirReader
+		pkgDirReader *fakepkg.FakeDirReader
+		fs           *fakesys.FakeFileSystem
+		linter       DirLinter
+
+		jobs map[string]*boshjob.Job
+		pkgs map[string]*boshpkg.Package
+	)
+
+	relPath := func(pieces ...string) string {
+		return filepath.Join(append([]string{"/", "release"}, pieces...)...)
+	}
+
+	addPackage := func(name, spec string, deps []string) {
+		fs.MkdirAll(relPath("packages", name), 0755)
+		fs.WriteFileString(relPath("packages", name, "spec"), spec)
+
+		pkgs[relPath("packages", name)] = boshpkg.NewPackage(boshres.NewExistingResource(name, "fp", "sha1"), deps)
+	}
+
+	addJob := func(name, spec string, job *boshjob.Job) {
+		fs.MkdirAll(relPath("jobs", name), 0755)
+		fs.WriteFileString(relPath("jobs", name, "spec"), spec)
+
+		jobs[relPath("jobs", name)] = job
+	}
+
+	newJob := func(name string) *boshjob.Job {
+		return boshjob.NewExtractedJob(boshres.NewExistingResource(name, "fp", "sha1"), relPath("jobs", name), nil)
+	}
+
+	setGlobs := func() {
+		var pkgPaths, jobPaths []string
+
+		for path := range pkgs {
+			pkgPaths = append(pkgPaths, path)
+		}
+
+		for path := range jobs {
+			jobPaths = append(jobPaths, path)
+		}
+
+		fs.SetGlob(relPath("packages", "*"), pkgPaths)
+		fs.SetGlob(relPath("jobs", "*"), jobPaths)
+	}
+
+	BeforeEach(func() {
+		jobDirReader = &fakejob.FakeDirReader{}
+		pkgDirReader = &fakepkg.FakeDirReader{}
+		fs = fakesys.NewFakeFileSystem()
+
+		jobs = map[string]*boshjob.Job{}
+		pkgs = map[string]*boshpkg.Package{}
+
+		jobDirReader.ReadStub = func(path string) (*boshjob.Job, error) { return jobs[path], nil }
+		pkgDirReader.ReadStub = func(path string) (*boshpkg.Package, error) { return pkgs[path], nil }
+
+		linter = NewDirLinter(relPath(), jobDirReader, pkgDirReader, fs)
+	})
+
+	It("returns no problems for a valid release", func() {
+		addPackage("pkg1", "name: pkg1\nfiles: [pkg1/**/*]", []string{"pkg2"})
+		addPackage("pkg2", "name: pkg2", nil)
+
+		fs.WriteFileString(relPath("src", "pkg1", "file"), "")
+		fs.SetGlob(relPath("src", "pkg1/**/*"), []string{relPath("src", "pkg1", "file")})
+
+		job1 := newJob("job1")
+		job1.Templates = map[string]string{"config.erb": "config/config"}
+		job1.PackageNames = []string{"pkg1"}
+		job1.Properties = map[string]boshjob.PropertyDefinition{"a.b": {}, "c": {}}
+		job1.Provides = []boshjob.LinkDefinition{{Name: "db", Type: "database"}}
+		job1.Consumes = []boshjob.LinkDefinition{{Name: "db", Type: "database"}, {Name: "opt", Type: "other", Optional: true}}
+
+		addJob("job1", "name: job1\ntemplates: {config.erb: config/config}\npackages: [pkg1]", job1)
+
+		fs.WriteFileString(relPath("jobs", "job1", "templates", "config.erb"),
+			`<%= p("a.b") %> <%= p("a") %> <% if_p("c") do |c| %><% end %> <%= link("db").p("port") %>`)
+
+		fs.WriteFileString(relPath("jobs", "job1", "monit"),
+			"check process job1\n  depends on job1_helper\ncheck process job1_helper\n")
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("reports every package files pattern that matches nothing", func() {
+		addPackage("pkg", "name: pkg\nfiles: [missing1/*, missing2/*]", nil)
+
+		job := newJob("job")
+		job.PackageNames = []string{"pkg"}
+		addJob("job", "name: job\npackages: [pkg]", job)
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "package", Name: "pkg", Message: "Files pattern 'missing1/*' does not match any files in src/, blobs/ or config/blobs.yml"},
+			{Type: "package", Name: "pkg", Message: "Files pattern 'missing2/*' does not match any files in src/, blobs/ or config/blobs.yml"},
+		}))
+
+		Expect(pkgDirReader.ReadCallCount()).To(Equal(0))
+	})
+
+	It("accepts package files patterns matching blobs that are not downloaded yet", func() {
+		addPackage("pkg", "name: pkg\nfiles: [ruby/ruby-*.tgz, gems/**/*.gem]", nil)
+
+		fs.WriteFileString(relPath("config", "blobs.yml"), `
+ruby/ruby-2.4.1.tgz:
+  size: 100
+  object_id: fake-object-id
+  sha: fake-sha1
+gems/vendor/bundler.gem:
+  size: 10
+  sha: fake-sha1
+`)
+
+		job := newJob("job")
+		job.PackageNames = []string{"pkg"}
+		addJob("job", "name: job\npackages: [pkg]", job)
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+
+		Expect(pkgDirReader.ReadCallCount()).To(Equal(0))
+	})
+
+	It("returns error if blobs index cannot be parsed", func() {
+		fs.WriteFileString(relPath("config", "blobs.yml"), "-")
+
+		_, err := linter.Lint()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling blobs index"))
+	})
+
+	It("reports job templates that do not exist", func() {
+		addJob("job", "name: job\ntemplates: {b.erb: b, a.erb: a}", newJob("job"))
+		fs.WriteFileString(relPath("jobs", "job", "templates", "b.erb"), "")
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "job", Name: "job", Message: "Template 'a.erb' does not exist in templates/"},
+		}))
+
+		Expect(jobDirReader.ReadCallCount()).To(Equal(0))
+	})
+
+	It("reports packages not used by any job and undefined packages", func() {
+		addPackage("used", "name: used", []string{"dep"})
+		addPackage("dep", "name: dep", []string{"undefined-dep"})
+		addPackage("unused", "name: unused", nil)
+
+		job := newJob("job")
+		job.PackageNames = []string{"used", "undefined"}
+		addJob("job", "name: job\npackages: [used, undefined]", job)
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "job", Name: "job", Message: "Package 'undefined' is not defined in release"},
+			{Type: "package", Name: "dep", Message: "Dependency 'undefined-dep' is not defined in release"},
+			{Type: "package", Name: "unused", Message: "Package is not used by any job"},
+		}))
+	})
+
+	It("reports package dependency cycles", func() {
+		addPackage("pkg1", "name: pkg1", []string{"pkg2"})
+		addPackage("pkg2", "name: pkg2", []string{"pkg3"})
+		addPackage("pkg3", "name: pkg3", []string{"pkg1"})
+
+		job := newJob("job")
+		job.PackageNames = []string{"pkg1"}
+		addJob("job", "name: job\npackages: [pkg1]", job)
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "package", Name: "pkg1", Message: "Dependency cycle 'pkg1 -> pkg2 -> pkg3 -> pkg1'"},
+		}))
+	})
+
+	It("reports monit files depending on undefined processes", func() {
+		addJob("job", "name: job", newJob("job"))
+
+		fs.WriteFileString(relPath("jobs", "job", "monit"), `
+check process job
+  depends on other, <%= p("x") %>
+  depends on job
+`)
+
+		job := jobs[relPath("jobs", "job")]
+		job.Properties = map[string]boshjob.PropertyDefinition{"x": {}}
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "job", Name: "job", Message: "Monit file depends on process 'other' which is not defined by any job"},
+		}))
+	})
+
+	It("reports templates referencing undeclared properties", func() {
+		job := newJob("job")
+		job.Templates = map[string]string{"config.erb": "config"}
+		job.Properties = map[string]boshjob.PropertyDefinition{"declared": {}}
+		addJob("job", "name: job\ntemplates: {config.erb: config}", job)
+
+		fs.WriteFileString(relPath("jobs", "job", "templates", "config.erb"), `
+<%= p("declared") %>
+<%= p('undeclared1', "default") %>
+<%= p(["undeclared2", "declared"]) %>
+<% if_p("undeclared3") do |v| %><% end %>
+`)
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "job", Name: "job", Message: "Template 'config.erb' references property 'undeclared1' which is not declared in spec"},
+			{Type: "job", Name: "job", Message: "Template 'config.erb' references property 'undeclared2' which is not declared in spec"},
+			{Type: "job", Name: "job", Message: "Template 'config.erb' references property 'undeclared3' which is not declared in spec"},
+		}))
+	})
+
+	It("reports required links that are consumed but not provided", func() {
+		job := newJob("job")
+		job.Consumes = []boshjob.LinkDefinition{
+			{Name: "db", Type: "database"},
+			{Name: "cache", Type: "cache", Optional: true},
+		}
+		addJob("job", "name: job", job)
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "job", Name: "job", Message: "Consumed link 'db' of type 'database' is not provided by any job"},
+		}))
+	})
+
+	It("reports jobs and packages that cannot be read", func() {
+		addPackage("pkg", "name: pkg", nil)
+		addJob("job", "name: job", newJob("job"))
+
+		pkgDirReader.ReadStub = nil
+		pkgDirReader.ReadReturns(nil, errors.New("fake-pkg-err"))
+
+		jobDirReader.ReadStub = nil
+		jobDirReader.ReadReturns(nil, errors.New("fake-job-err"))
+
+		setGlobs()
+
+		problems, err := linter.Lint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problems).To(Equal([]Problem{
+			{Type: "package", Name: "pkg", Message: "fake-pkg-err"},
+			{Type: "job", Name: "job", Message: "fake-job-err"},
+		}))
+	})
+
+	It("returns error if packages cannot be listed", func() {
+		fs.GlobErrs = map[string]error{relPath("packages", "*"): errors.New("fake-err")}
+
+		_, err := linter.Lint()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+	})
+})
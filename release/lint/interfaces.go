@@ -0,0 +1,14 @@
+package lint
+
+//go:generate counterfeiter . Linter
+
+type Linter interface {
+	// Lint returns problems that would otherwise only show up at compile or deploy time
+	Lint() ([]Problem, error)
+}
+
+type Problem struct {
+	Type    string // job or package
+	Name    string
+	Message string
+}
@@ -0,0 +1,74 @@
+package lint
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// p("name"), p("name", default), p(["name1", "name2"]) but not link("name").p("name")
+	erbPropertyRe      = regexp.MustCompile(`(?:^|[^.\w])p\(\s*["']([^"']+)["']`)
+	erbPropertyArrayRe = regexp.MustCompile(`(?:^|[^.\w])p\(\s*\[([^\]]*)\]`)
+	erbIfPropertyRe    = regexp.MustCompile(`(?:^|[^.\w])if_p\(([^)]*)\)`)
+	quotedStringRe     = regexp.MustCompile(`["']([^"']+)["']`)
+
+	monitServiceRe   = regexp.MustCompile(`(?m)^\s*check\s+\w+\s+(\S+)`)
+	monitDependsOnRe = regexp.MustCompile(`(?m)^\s*depends\s+on\s+(.+)$`)
+)
+
+// erbPropertyNames returns sorted unique property names referenced by ERB template
+func erbPropertyNames(template string) []string {
+	var names []string
+
+	for _, match := range erbPropertyRe.FindAllStringSubmatch(template, -1) {
+		names = append(names, match[1])
+	}
+
+	for _, re := range []*regexp.Regexp{erbPropertyArrayRe, erbIfPropertyRe} {
+		for _, match := range re.FindAllStringSubmatch(template, -1) {
+			for _, quotedMatch := range quotedStringRe.FindAllStringSubmatch(match[1], -1) {
+				names = append(names, quotedMatch[1])
+			}
+		}
+	}
+
+	return uniqueNames(names)
+}
+
+// monitServices returns names of services (e.g. processes) defined by monit file
+// and sorted names of services they depend on; names generated by ERB are ignored
+func monitServices(monit string) ([]string, []string) {
+	var defined, dependencies []string
+
+	for _, match := range monitServiceRe.FindAllStringSubmatch(monit, -1) {
+		defined = append(defined, match[1])
+	}
+
+	for _, match := range monitDependsOnRe.FindAllStringSubmatch(monit, -1) {
+		for _, name := range strings.Split(match[1], ",") {
+			dependencies = append(dependencies, strings.TrimSpace(name))
+		}
+	}
+
+	return uniqueNames(defined), uniqueNames(dependencies)
+}
+
+func uniqueNames(names []string) []string {
+	var result []string
+
+	seen := map[string]struct{}{}
+
+	for _, name := range names {
+		if _, found := seen[name]; found || len(name) == 0 || strings.Contains(name, "<%") {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
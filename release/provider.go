@@ -17,11 +17,12 @@ import (
 type Provider struct {
 	fingerprinterFactory func(bool) Fingerprinter
 
-	cmdRunner        boshsys.CmdRunner
-	compressor       boshcmd.Compressor
-	digestCalculator bicrypto.DigestCalculator
-	fs               boshsys.FileSystem
-	logger           boshlog.Logger
+	cmdRunner         boshsys.CmdRunner
+	compressor        boshcmd.Compressor
+	archiveCompressor boshcmd.Compressor
+	digestCalculator  bicrypto.DigestCalculator
+	fs                boshsys.FileSystem
+	logger            boshlog.Logger
 }
 
 func NewProvider(
@@ -35,11 +36,13 @@ func NewProvider(
 		fingerprinterFactory: func(followSymlinks bool) Fingerprinter {
 			return NewFingerprinterImpl(digestCalculator, fs, followSymlinks)
 		},
-		cmdRunner:        cmdRunner,
-		compressor:       compressor,
-		digestCalculator: digestCalculator,
-		fs:               fs,
-		logger:           logger,
+		cmdRunner:  cmdRunner,
+		compressor: compressor,
+		// Archives are built reproducibly so that their digests only depend on their contents
+		archiveCompressor: NewReproducibleCompressor(compressor, fs),
+		digestCalculator:  digestCalculator,
+		fs:                fs,
+		logger:            logger,
 	}
 }
 
@@ -62,19 +65,26 @@ func (p Provider) archiveReader(extracting bool) ArchiveReader {
 }
 
 func (p Provider) NewDirReader(dirPath string) DirReader {
-	archiveFactory := func(args ArchiveFactoryArgs) Archive {
-		return NewArchiveImpl(
-			args, dirPath, p.fingerprinterFactory(args.FollowSymlinks), p.compressor, p.digestCalculator, p.cmdRunner, p.fs)
-	}
+	licDirReader := boshlic.NewDirReaderImpl(p.archiveFactory(dirPath), p.fs)
+	return NewDirReader(p.NewJobDirReader(dirPath), p.NewPackageDirReader(dirPath), licDirReader, p.fs, p.logger)
+}
+
+func (p Provider) NewJobDirReader(dirPath string) boshjob.DirReaderImpl {
+	return boshjob.NewDirReaderImpl(p.archiveFactory(dirPath), p.fs)
+}
 
+func (p Provider) NewPackageDirReader(dirPath string) boshpkg.DirReaderImpl {
 	srcDirPath := filepath.Join(dirPath, "src")
 	blobsDirPath := filepath.Join(dirPath, "blobs")
 
-	jobDirReader := boshjob.NewDirReaderImpl(archiveFactory, p.fs)
-	pkgDirReader := boshpkg.NewDirReaderImpl(archiveFactory, srcDirPath, blobsDirPath, p.fs)
-	licDirReader := boshlic.NewDirReaderImpl(archiveFactory, p.fs)
+	return boshpkg.NewDirReaderImpl(p.archiveFactory(dirPath), srcDirPath, blobsDirPath, p.fs)
+}
 
-	return NewDirReader(jobDirReader, pkgDirReader, licDirReader, p.fs, p.logger)
+func (p Provider) archiveFactory(dirPath string) ArchiveFunc {
+	return func(args ArchiveFactoryArgs) Archive {
+		return NewArchiveImpl(
+			args, dirPath, p.fingerprinterFactory(args.FollowSymlinks), p.archiveCompressor, p.digestCalculator, p.cmdRunner, p.fs)
+	}
 }
 
 func (p Provider) NewManifestReader() ManifestReader {
@@ -82,5 +92,5 @@ func (p Provider) NewManifestReader() ManifestReader {
 }
 
 func (p Provider) NewArchiveWriter() ArchiveWriter {
-	return NewArchiveWriter(p.compressor, p.fs, p.logger)
+	return NewArchiveWriter(p.archiveCompressor, p.fs, p.logger)
 }
@@ -0,0 +1,167 @@
+package resource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// ReproducibleMtime is recorded for all entries instead of their modification times
+var ReproducibleMtime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ReproducibleCompressor produces byte-identical tarballs for the same file contents:
+// entries are sorted, mtime/owner/mode are normalized and gzip header has no name or time.
+// Decompression and clean up are delegated to the wrapped compressor.
+type ReproducibleCompressor struct {
+	boshcmd.Compressor
+
+	fs boshsys.FileSystem
+}
+
+func NewReproducibleCompressor(compressor boshcmd.Compressor, fs boshsys.FileSystem) ReproducibleCompressor {
+	return ReproducibleCompressor{Compressor: compressor, fs: fs}
+}
+
+func (c ReproducibleCompressor) CompressFilesInDir(dir string) (string, error) {
+	return c.CompressSpecificFilesInDir(dir, []string{"."})
+}
+
+func (c ReproducibleCompressor) CompressSpecificFilesInDir(dir string, files []string) (string, error) {
+	tarball, err := c.fs.TempFile("bosh-release-ReproducibleCompressor-CompressSpecificFilesInDir")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for tarball")
+	}
+
+	defer tarball.Close()
+
+	tarballPath := tarball.Name()
+
+	err = c.writeTarball(tarball, dir, files)
+	if err != nil {
+		_ = c.fs.RemoveAll(tarballPath)
+		return "", bosherr.WrapErrorf(err, "Compressing files in '%s'", dir)
+	}
+
+	return tarballPath, nil
+}
+
+func (c ReproducibleCompressor) writeTarball(dst io.Writer, dir string, files []string) error {
+	// Header is left without name, comment and modification time
+	gzipWriter := gzip.NewWriter(dst)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	// Files are added in given order while their contents are walked in lexical order
+	for _, file := range files {
+		err := c.fs.Walk(filepath.Join(dir, file), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			return c.writeEntry(tarWriter, path, c.entryName(file, relPath), info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tarWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Closing tar writer")
+	}
+
+	return gzipWriter.Close()
+}
+
+// entryName matches names produced by 'tar -C dir file', e.g. './job.MF' for '.'
+func (c ReproducibleCompressor) entryName(file, relPath string) string {
+	name := filepath.ToSlash(relPath)
+
+	if strings.HasPrefix(file, ".") && name != "." && !strings.HasPrefix(name, "./") {
+		name = "./" + name
+	}
+
+	return name
+}
+
+func (c ReproducibleCompressor) writeEntry(tarWriter *tar.Writer, path, name string, info os.FileInfo) error {
+	var linkTarget string
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := c.fs.Readlink(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading symlink '%s'", path)
+		}
+
+		linkTarget = target
+	}
+
+	header, err := tar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Building tar header for '%s'", path)
+	}
+
+	header.Name = name
+	header.ModTime = ReproducibleMtime
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	header.Mode = c.entryMode(info)
+	header.Format = tar.FormatPAX
+
+	if info.IsDir() && !strings.HasSuffix(header.Name, "/") {
+		header.Name += "/"
+	}
+
+	err = tarWriter.WriteHeader(header)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing tar header for '%s'", path)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := c.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening '%s'", path)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(tarWriter, file)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing '%s' into tarball", path)
+	}
+
+	return nil
+}
+
+// entryMode only keeps whether file is executable, similarly to how files are staged
+func (c ReproducibleCompressor) entryMode(info os.FileInfo) int64 {
+	switch {
+	case info.IsDir():
+		return 0755
+	case info.Mode()&os.ModeSymlink != 0:
+		return 0777
+	case info.Mode()&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
+}
@@ -0,0 +1,175 @@
+package resource_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+)
+
+var _ = Describe("ReproducibleCompressor", func() {
+	var (
+		fs         boshsys.FileSystem
+		tmpDir     string
+		compressor ReproducibleCompressor
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+		tmpDir, err = fs.TempDir("reproducible-compressor-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		tarCompressor := boshcmd.NewTarballCompressor(boshsys.NewExecCmdRunner(logger), fs)
+		compressor = NewReproducibleCompressor(tarCompressor, fs)
+
+		Expect(fs.MkdirAll(filepath.Join(tmpDir, "src", "dir"), 0700)).To(Succeed())
+		Expect(fs.WriteFileString(filepath.Join(tmpDir, "src", "dir", "b"), "b-content")).To(Succeed())
+		Expect(fs.WriteFileString(filepath.Join(tmpDir, "src", "a"), "a-content")).To(Succeed())
+		Expect(fs.Chmod(filepath.Join(tmpDir, "src", "a"), 0700)).To(Succeed())
+		Expect(fs.Symlink("a", filepath.Join(tmpDir, "src", "link"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(fs.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	type entry struct {
+		Name     string
+		Mode     int64
+		Linkname string
+		ModTime  time.Time
+		Uid      int
+		Gid      int
+		Uname    string
+		Gname    string
+	}
+
+	readEntries := func(path string) []entry {
+		file, err := os.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		defer file.Close()
+
+		gzipReader, err := gzip.NewReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(gzipReader.Header.Name).To(BeEmpty())
+		Expect(gzipReader.Header.ModTime.IsZero()).To(BeTrue())
+
+		tarReader := tar.NewReader(gzipReader)
+
+		var entries []entry
+
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			entries = append(entries, entry{
+				Name:     header.Name,
+				Mode:     header.Mode,
+				Linkname: header.Linkname,
+				ModTime:  header.ModTime.UTC(),
+				Uid:      header.Uid,
+				Gid:      header.Gid,
+				Uname:    header.Uname,
+				Gname:    header.Gname,
+			})
+		}
+
+		return entries
+	}
+
+	Describe("CompressFilesInDir", func() {
+		It("writes sorted entries with normalized headers", func() {
+			path, err := compressor.CompressFilesInDir(filepath.Join(tmpDir, "src"))
+			Expect(err).ToNot(HaveOccurred())
+
+			defer fs.RemoveAll(path)
+
+			Expect(readEntries(path)).To(Equal([]entry{
+				{Name: "./", Mode: 0755, ModTime: ReproducibleMtime},
+				{Name: "./a", Mode: 0755, ModTime: ReproducibleMtime},
+				{Name: "./dir/", Mode: 0755, ModTime: ReproducibleMtime},
+				{Name: "./dir/b", Mode: 0644, ModTime: ReproducibleMtime},
+				{Name: "./link", Mode: 0777, Linkname: "a", ModTime: ReproducibleMtime},
+			}))
+		})
+
+		It("produces identical tarballs regardless of file modification times", func() {
+			path1, err := compressor.CompressFilesInDir(filepath.Join(tmpDir, "src"))
+			Expect(err).ToNot(HaveOccurred())
+
+			defer fs.RemoveAll(path1)
+
+			later := time.Now().Add(time.Hour)
+			Expect(os.Chtimes(filepath.Join(tmpDir, "src", "a"), later, later)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(tmpDir, "src", "dir"), later, later)).To(Succeed())
+
+			path2, err := compressor.CompressFilesInDir(filepath.Join(tmpDir, "src"))
+			Expect(err).ToNot(HaveOccurred())
+
+			defer fs.RemoveAll(path2)
+
+			contents1, err := fs.ReadFile(path1)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents2, err := fs.ReadFile(path2)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(contents1).To(Equal(contents2))
+		})
+
+		It("produces tarballs that can be decompressed", func() {
+			path, err := compressor.CompressFilesInDir(filepath.Join(tmpDir, "src"))
+			Expect(err).ToNot(HaveOccurred())
+
+			defer fs.RemoveAll(path)
+
+			dstDir := filepath.Join(tmpDir, "dst")
+			Expect(fs.MkdirAll(dstDir, 0700)).To(Succeed())
+
+			err = compressor.DecompressFileToDir(path, dstDir, boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString(filepath.Join(dstDir, "dir", "b"))).To(Equal("b-content"))
+			Expect(fs.Readlink(filepath.Join(dstDir, "link"))).To(Equal("a"))
+		})
+	})
+
+	Describe("CompressSpecificFilesInDir", func() {
+		It("includes given files in given order", func() {
+			path, err := compressor.CompressSpecificFilesInDir(filepath.Join(tmpDir, "src"), []string{"dir", "a"})
+			Expect(err).ToNot(HaveOccurred())
+
+			defer fs.RemoveAll(path)
+
+			Expect(readEntries(path)).To(Equal([]entry{
+				{Name: "dir/", Mode: 0755, ModTime: ReproducibleMtime},
+				{Name: "dir/b", Mode: 0644, ModTime: ReproducibleMtime},
+				{Name: "a", Mode: 0755, ModTime: ReproducibleMtime},
+			}))
+		})
+
+		It("returns error if file does not exist", func() {
+			_, err := compressor.CompressSpecificFilesInDir(filepath.Join(tmpDir, "src"), []string{"missing"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Compressing files in"))
+		})
+	})
+})
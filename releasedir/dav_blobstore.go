@@ -0,0 +1,252 @@
+package releasedir
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	davclient "github.com/cloudfoundry/bosh-davcli/client"
+	davconfig "github.com/cloudfoundry/bosh-davcli/config"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+/*
+# final.yml
+---
+blobstore:
+  provider: dav
+  options:
+    endpoint: https://blobstore.internal:8443/release-blobs
+
+# private.yml
+---
+blobstore:
+  options:
+    user: release-writer
+    password: ...
+    ca_cert: |
+      -----BEGIN CERTIFICATE-----
+      ...
+*/
+
+type DAVBlobstore struct {
+	fs      boshsys.FileSystem
+	uuidGen boshuuid.Generator
+	options map[string]interface{}
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type davBlobstoreOptions struct {
+	Endpoint string `json:"endpoint"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	CACert   string `json:"ca_cert"`
+}
+
+func NewDAVBlobstore(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	options map[string]interface{},
+	logger boshlog.Logger,
+) DAVBlobstore {
+	return DAVBlobstore{
+		fs:      fs,
+		uuidGen: uuidGen,
+		options: options,
+
+		logTag: "releasedir.DAVBlobstore",
+		logger: logger,
+	}
+}
+
+func (b DAVBlobstore) Get(blobID string) (string, error) {
+	client, err := b.client()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := client.Get(blobID)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Downloading dav blob '%s'", blobID)
+	}
+
+	defer content.Close()
+
+	file, err := b.fs.TempFile("bosh-dav-blob")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating destination file")
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		// Partially downloaded blob would be left behind otherwise
+		if removeErr := b.fs.RemoveAll(file.Name()); removeErr != nil {
+			b.logger.Warn(b.logTag, "Failed to remove partially downloaded blob: %s", removeErr.Error())
+		}
+
+		return "", bosherr.WrapErrorf(err, "Writing dav blob '%s'", blobID)
+	}
+
+	return file.Name(), nil
+}
+
+func (b DAVBlobstore) Create(path string) (string, error) {
+	client, err := b.client()
+	if err != nil {
+		return "", err
+	}
+
+	blobID, err := b.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating blobstore ID")
+	}
+
+	file, err := b.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening source file")
+	}
+
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Checking source file size")
+	}
+
+	err = client.Put(blobID, file, fileInfo.Size())
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Uploading dav blob '%s'", blobID)
+	}
+
+	return blobID, nil
+}
+
+func (b DAVBlobstore) CleanUp(path string) error {
+	return b.fs.RemoveAll(path)
+}
+
+func (b DAVBlobstore) Delete(blobID string) error {
+	client, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	err = client.Delete(blobID)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting dav blob '%s'", blobID)
+	}
+
+	return nil
+}
+
+// Exists makes its own HEAD request since davcli client
+// does not distinguish missing blobs from failed requests
+func (b DAVBlobstore) Exists(blobID string) (bool, error) {
+	opts, certPool, err := newDAVBlobstoreOptions(b.options)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest("HEAD", davBlobURL(opts.Endpoint, blobID), nil)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Building request for dav blob '%s'", blobID)
+	}
+
+	req.SetBasicAuth(opts.User, opts.Password)
+
+	resp, err := httpclient.CreateDefaultClient(certPool).Do(req)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Checking if dav blob '%s' exists", blobID)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, bosherr.Errorf(
+			"Checking if dav blob '%s' exists: Wrong response code: %d", blobID, resp.StatusCode)
+	}
+}
+
+func (b DAVBlobstore) List() ([]string, error) {
+	return nil, bosherr.Error("Listing blobs is not supported by 'dav' blobstore")
+}
+
+func (b DAVBlobstore) Validate() error {
+	_, err := b.client()
+	return err
+}
+
+func (b DAVBlobstore) client() (davclient.Client, error) {
+	return NewDAVClient(b.options, b.logger)
+}
+
+// NewDAVClient builds davcli client from blobstore options (as found in config/final.yml);
+// optional CA certificate is used to verify blobstore's TLS certificate
+func NewDAVClient(options map[string]interface{}, logger boshlog.Logger) (davclient.Client, error) {
+	opts, certPool, err := newDAVBlobstoreOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := davconfig.Config{
+		Endpoint: opts.Endpoint,
+		User:     opts.User,
+		Password: opts.Password,
+	}
+
+	return davclient.NewClient(conf, httpclient.CreateDefaultClient(certPool), logger), nil
+}
+
+func newDAVBlobstoreOptions(options map[string]interface{}) (davBlobstoreOptions, *x509.CertPool, error) {
+	var opts davBlobstoreOptions
+
+	bytes, err := json.Marshal(options)
+	if err != nil {
+		return opts, nil, bosherr.WrapError(err, "Marshaling config")
+	}
+
+	err = json.Unmarshal(bytes, &opts)
+	if err != nil {
+		return opts, nil, bosherr.WrapError(err, "Reading config")
+	}
+
+	if len(opts.Endpoint) == 0 {
+		return opts, nil, bosherr.Error("Expected non-empty 'endpoint' in dav blobstore options")
+	}
+
+	var certPool *x509.CertPool
+
+	if len(opts.CACert) > 0 {
+		certPool, err = boshcrypto.CertPoolFromPEM([]byte(opts.CACert))
+		if err != nil {
+			return opts, nil, bosherr.WrapError(err, "Parsing CA certificate")
+		}
+	}
+
+	return opts, certPool, nil
+}
+
+// davBlobURL matches blob layout used by davcli: <endpoint>/<first byte of sha1(blobID)>/<blobID>
+func davBlobURL(endpoint, blobID string) string {
+	digest := sha1.Sum([]byte(blobID))
+	return strings.TrimSuffix(endpoint, "/") + "/" + fmt.Sprintf("%02x", digest[0]) + "/" + blobID
+}
@@ -0,0 +1,256 @@
+package releasedir_test
+
+import (
+	"crypto/sha1"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/releasedir"
+)
+
+var _ = Describe("DAVBlobstore", func() {
+	var (
+		server    *ghttp.Server
+		fs        boshsys.FileSystem
+		uuidGen   *fakeuuid.FakeGenerator
+		options   map[string]interface{}
+		tmpDir    string
+		blobPath  string
+		blobstore DAVBlobstore
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUUID: "blob-id"}
+
+		var err error
+		tmpDir, err = fs.TempDir("dav-blobstore-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		options = map[string]interface{}{
+			"endpoint": server.URL() + "/blobs",
+			"user":     "user",
+			"password": "password",
+		}
+
+		// blobs are spread across directories named after first byte of SHA1 of blob ID
+		blobPath = fmt.Sprintf("/blobs/%02x/blob-id", sha1.Sum([]byte("blob-id"))[0])
+
+		blobstore = NewDAVBlobstore(fs, uuidGen, options, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(fs.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("Create", func() {
+		It("uploads file with basic auth", func() {
+			path := filepath.Join(tmpDir, "file")
+			Expect(fs.WriteFileString(path, "content")).To(Succeed())
+
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", blobPath),
+				ghttp.VerifyBasicAuth("user", "password"),
+				ghttp.VerifyBody([]byte("content")),
+				ghttp.RespondWith(http.StatusCreated, nil),
+			))
+
+			blobID, err := blobstore.Create(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("blob-id"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns error if upload fails", func() {
+			path := filepath.Join(tmpDir, "file")
+			Expect(fs.WriteFileString(path, "content")).To(Succeed())
+
+			// client retries failed requests
+			server.RouteToHandler("PUT", blobPath, ghttp.RespondWith(http.StatusForbidden, "forbidden"))
+
+			_, err := blobstore.Create(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Uploading dav blob 'blob-id'"))
+			Expect(err.Error()).To(ContainSubstring("StatusCode: 403"))
+		})
+
+		It("returns error if file cannot be opened", func() {
+			_, err := blobstore.Create(filepath.Join(tmpDir, "missing"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Opening source file"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("downloads blob to a temporary file", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", blobPath),
+				ghttp.VerifyBasicAuth("user", "password"),
+				ghttp.RespondWith(http.StatusOK, "content"),
+			))
+
+			path, err := blobstore.Get("blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(path)
+
+			Expect(fs.ReadFileString(path)).To(Equal("content"))
+		})
+
+		It("returns error if blob is not found", func() {
+			server.RouteToHandler("GET", blobPath, ghttp.RespondWith(http.StatusNotFound, nil))
+
+			_, err := blobstore.Get("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Downloading dav blob 'blob-id'"))
+			Expect(err.Error()).To(ContainSubstring("StatusCode: 404"))
+		})
+
+		It("removes partially downloaded blob if download is interrupted", func() {
+			Expect(fs.ChangeTempRoot(tmpDir)).To(Succeed())
+
+			server.RouteToHandler("GET", blobPath, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "100")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("partial"))
+			})
+
+			_, err := blobstore.Get("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Writing dav blob 'blob-id'"))
+
+			Expect(filepath.Glob(filepath.Join(tmpDir, "bosh-dav-blob*"))).To(BeEmpty())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes blob", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", blobPath),
+				ghttp.VerifyBasicAuth("user", "password"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+
+			Expect(blobstore.Delete("blob-id")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns error if deleting fails", func() {
+			server.RouteToHandler("DELETE", blobPath, ghttp.RespondWith(http.StatusForbidden, nil))
+
+			err := blobstore.Delete("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deleting dav blob 'blob-id'"))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("returns true if blob is found", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("HEAD", blobPath),
+				ghttp.VerifyBasicAuth("user", "password"),
+				ghttp.RespondWith(http.StatusOK, nil),
+			))
+
+			exists, err := blobstore.Exists("blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("returns false if blob is not found", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("HEAD", blobPath),
+				ghttp.RespondWith(http.StatusNotFound, nil),
+			))
+
+			exists, err := blobstore.Exists("blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("returns error for unexpected response", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("HEAD", blobPath),
+				ghttp.RespondWith(http.StatusForbidden, nil),
+			))
+
+			_, err := blobstore.Exists("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 403"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns error since dav does not support listing", func() {
+			_, err := blobstore.List()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not supported by 'dav' blobstore"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("returns error if endpoint is not configured", func() {
+			delete(options, "endpoint")
+
+			err := blobstore.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected non-empty 'endpoint' in dav blobstore options"))
+		})
+
+		It("returns error if CA certificate is invalid", func() {
+			options["ca_cert"] = "invalid"
+
+			err := blobstore.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing CA certificate"))
+		})
+	})
+
+	Context("when blobstore uses TLS", func() {
+		var (
+			tlsServer *ghttp.Server
+		)
+
+		BeforeEach(func() {
+			tlsServer = ghttp.NewTLSServer()
+			tlsServer.AllowUnhandledRequests = true
+			tlsServer.UnhandledRequestStatusCode = http.StatusOK
+
+			options["endpoint"] = tlsServer.URL() + "/blobs"
+		})
+
+		AfterEach(func() {
+			tlsServer.Close()
+		})
+
+		It("verifies server certificate with configured CA certificate", func() {
+			options["ca_cert"] = string(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: tlsServer.HTTPTestServer.Certificate().Raw,
+			}))
+
+			path, err := blobstore.Get("blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Remove(path)).To(Succeed())
+		})
+
+		It("returns error if server certificate is not trusted", func() {
+			_, err := blobstore.Get("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("certificate"))
+		})
+	})
+})
@@ -18,3 +18,6 @@ func (b ErrBlobstore) Create(path string) (string, crypto.MultipleDigest, error)
 func (b ErrBlobstore) CleanUp(path string) error  { return b.err }
 func (b ErrBlobstore) Delete(blobID string) error { return b.err }
 func (b ErrBlobstore) Validate() error            { return b.err }
+
+func (b ErrBlobstore) Exists(blobID string) (bool, error) { return false, b.err }
+func (b ErrBlobstore) List() ([]string, error)            { return nil, b.err }
@@ -30,6 +30,12 @@ var _ = Describe("ErrBlobstore", func() {
 
 			err = blob.Validate()
 			Expect(err).To(Equal(blobErr))
+
+			_, err = blob.Exists("")
+			Expect(err).To(Equal(blobErr))
+
+			_, err = blob.List()
+			Expect(err).To(Equal(blobErr))
 		})
 	})
 })
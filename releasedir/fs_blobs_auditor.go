@@ -0,0 +1,208 @@
+package releasedir
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshidx "github.com/cloudfoundry/bosh-cli/releasedir/index"
+)
+
+// blobIDRegexp matches blobstore IDs generated by the CLI
+var blobIDRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+type FSBlobsAuditor struct {
+	dirPath string
+
+	blobsDir      BlobsDir
+	finalBlobRefs func() ([]boshidx.BlobRef, error)
+	blobstore     ListableBlobstore
+	fs            boshsys.FileSystem
+}
+
+func NewFSBlobsAuditor(
+	dirPath string,
+	blobsDir BlobsDir,
+	finalBlobRefs func() ([]boshidx.BlobRef, error),
+	blobstore ListableBlobstore,
+	fs boshsys.FileSystem,
+) FSBlobsAuditor {
+	return FSBlobsAuditor{
+		dirPath: dirPath,
+
+		blobsDir:      blobsDir,
+		finalBlobRefs: finalBlobRefs,
+		blobstore:     blobstore,
+		fs:            fs,
+	}
+}
+
+func (a FSBlobsAuditor) VerifyBlobs() ([]BlobProblem, error) {
+	var problems []BlobProblem
+
+	blobs, err := a.blobsDir.Blobs()
+	if err != nil {
+		return nil, err
+	}
+
+	blobsIndexPath := filepath.Join("config", "blobs.yml")
+
+	for _, blob := range blobs {
+		problem := BlobProblem{Source: blobsIndexPath, Name: blob.Path, BlobstoreID: blob.BlobstoreID}
+
+		localPath := filepath.Join(a.dirPath, "blobs", blob.Path)
+
+		if a.fs.FileExists(localPath) {
+			digest, err := boshcrypto.ParseMultipleDigest(blob.SHA1)
+			if err != nil {
+				problem.Problem = fmt.Sprintf("Invalid digest '%s'", blob.SHA1)
+				problems = append(problems, problem)
+				continue
+			}
+
+			err = digest.VerifyFilePath(localPath, a.fs)
+			if err != nil {
+				problem.Problem = fmt.Sprintf("Local file does not match digest '%s'", blob.SHA1)
+				problems = append(problems, problem)
+			}
+		} else if len(blob.BlobstoreID) == 0 {
+			problem.Problem = "Local file is missing and blob is not uploaded"
+			problems = append(problems, problem)
+		}
+
+		if len(blob.BlobstoreID) > 0 {
+			missingProblem, err := a.checkExistence(problem)
+			if err != nil {
+				return nil, err
+			}
+
+			problems = append(problems, missingProblem...)
+		}
+	}
+
+	refs, err := a.finalBlobRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		problem := BlobProblem{
+			Source:      a.relativePath(ref.IndexPath),
+			Name:        ref.Name + "/" + ref.Version,
+			BlobstoreID: ref.BlobstoreID,
+		}
+
+		if len(ref.BlobstoreID) == 0 {
+			problem.Problem = "Missing blobstore ID"
+			problems = append(problems, problem)
+			continue
+		}
+
+		missingProblem, err := a.checkExistence(problem)
+		if err != nil {
+			return nil, err
+		}
+
+		problems = append(problems, missingProblem...)
+	}
+
+	return problems, nil
+}
+
+func (a FSBlobsAuditor) UnreferencedBlobs() ([]string, int, error) {
+	// without final builds index blobs of all final releases would look unreferenced
+	finalBuildsPath := filepath.Join(a.dirPath, ".final_builds")
+	if !a.fs.FileExists(finalBuildsPath) {
+		return nil, 0, bosherr.Errorf(
+			"Expected release directory '%s' to contain final builds index '%s'", a.dirPath, finalBuildsPath)
+	}
+
+	referencedIDs := map[string]struct{}{}
+
+	blobs, err := a.blobsDir.Blobs()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, blob := range blobs {
+		referencedIDs[blob.BlobstoreID] = struct{}{}
+	}
+
+	refs, err := a.finalBlobRefs()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, ref := range refs {
+		referencedIDs[ref.BlobstoreID] = struct{}{}
+	}
+
+	blobIDs, err := a.blobstore.List()
+	if err != nil {
+		return nil, 0, bosherr.WrapError(err, "Listing blobstore")
+	}
+
+	var foreignIDs, unreferencedIDs []string
+
+	for _, blobID := range blobIDs {
+		if !blobIDRegexp.MatchString(blobID) {
+			foreignIDs = append(foreignIDs, blobID)
+		}
+	}
+
+	// objects not created by the CLI mean that blobstore is shared with something else
+	if len(foreignIDs) > 0 {
+		sort.Strings(foreignIDs)
+
+		return nil, 0, bosherr.Errorf(
+			"Expected blobstore to only contain blobs created for releases, but found %d object(s) "+
+				"that do not look like blobstore IDs (e.g. '%s')", len(foreignIDs), foreignIDs[0])
+	}
+
+	for _, blobID := range blobIDs {
+		if _, found := referencedIDs[blobID]; !found {
+			unreferencedIDs = append(unreferencedIDs, blobID)
+		}
+	}
+
+	sort.Strings(unreferencedIDs)
+
+	return unreferencedIDs, len(blobIDs) - len(unreferencedIDs), nil
+}
+
+func (a FSBlobsAuditor) DeleteBlob(blobID string) error {
+	err := a.blobstore.Delete(blobID)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting blob '%s'", blobID)
+	}
+
+	return nil
+}
+
+func (a FSBlobsAuditor) checkExistence(problem BlobProblem) ([]BlobProblem, error) {
+	exists, err := a.blobstore.Exists(problem.BlobstoreID)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Checking blob '%s' for '%s'", problem.BlobstoreID, problem.Name)
+	}
+
+	if !exists {
+		problem.Problem = "Missing from blobstore"
+		return []BlobProblem{problem}, nil
+	}
+
+	return nil, nil
+}
+
+func (a FSBlobsAuditor) relativePath(path string) string {
+	relPath, err := filepath.Rel(a.dirPath, path)
+	if err != nil {
+		return path
+	}
+
+	return relPath
+}
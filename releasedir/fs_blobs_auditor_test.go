@@ -0,0 +1,248 @@
+package releasedir_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/releasedir"
+	boshidx "github.com/cloudfoundry/bosh-cli/releasedir/index"
+	fakereldir "github.com/cloudfoundry/bosh-cli/releasedir/releasedirfakes"
+)
+
+var _ = Describe("FSBlobsAuditor", func() {
+	var (
+		blobsDir      *fakereldir.FakeBlobsDir
+		finalBlobRefs []boshidx.BlobRef
+		finalRefsErr  error
+		blobstore     *fakereldir.FakeListableBlobstore
+		fs            *fakesys.FakeFileSystem
+		auditor       FSBlobsAuditor
+	)
+
+	BeforeEach(func() {
+		blobsDir = &fakereldir.FakeBlobsDir{}
+		finalBlobRefs = nil
+		finalRefsErr = nil
+		blobstore = &fakereldir.FakeListableBlobstore{}
+		fs = fakesys.NewFakeFileSystem()
+
+		finalBlobRefsFunc := func() ([]boshidx.BlobRef, error) { return finalBlobRefs, finalRefsErr }
+
+		auditor = NewFSBlobsAuditor(filepath.Join("/", "dir"), blobsDir, finalBlobRefsFunc, blobstore, fs)
+	})
+
+	Describe("VerifyBlobs", func() {
+		BeforeEach(func() {
+			blobstore.ExistsReturns(true, nil)
+		})
+
+		It("returns no problems if local blobs match digests and all blobs exist in blobstore", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "blobs", "dir", "file"), "content")
+
+			blobsDir.BlobsReturns([]Blob{
+				{Path: "dir/file", BlobstoreID: "blob-id", SHA1: "040f06fd774092478d450774f5ba30c5da78acc8"},
+			}, nil)
+
+			finalBlobRefs = []boshidx.BlobRef{{
+				IndexPath:   filepath.Join("/", "dir", ".final_builds", "jobs", "job", "index.yml"),
+				Name:        "job",
+				Version:     "fp",
+				BlobstoreID: "job-blob-id",
+			}}
+
+			problems, err := auditor.VerifyBlobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+
+			Expect(blobstore.ExistsCallCount()).To(Equal(2))
+			Expect(blobstore.ExistsArgsForCall(0)).To(Equal("blob-id"))
+			Expect(blobstore.ExistsArgsForCall(1)).To(Equal("job-blob-id"))
+		})
+
+		It("returns problem if local blob does not match its digest", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "blobs", "file"), "other-content")
+
+			blobsDir.BlobsReturns([]Blob{
+				{Path: "file", SHA1: "040f06fd774092478d450774f5ba30c5da78acc8"},
+			}, nil)
+
+			problems, err := auditor.VerifyBlobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]BlobProblem{{
+				Source:  filepath.Join("config", "blobs.yml"),
+				Name:    "file",
+				Problem: "Local file does not match digest '040f06fd774092478d450774f5ba30c5da78acc8'",
+			}}))
+
+			Expect(blobstore.ExistsCallCount()).To(Equal(0))
+		})
+
+		It("returns problem if local blob is missing and it was not uploaded", func() {
+			blobsDir.BlobsReturns([]Blob{{Path: "file", SHA1: "sha1"}}, nil)
+
+			problems, err := auditor.VerifyBlobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]BlobProblem{{
+				Source:  filepath.Join("config", "blobs.yml"),
+				Name:    "file",
+				Problem: "Local file is missing and blob is not uploaded",
+			}}))
+		})
+
+		It("does not require local blob to exist if it was uploaded", func() {
+			blobsDir.BlobsReturns([]Blob{{Path: "file", BlobstoreID: "blob-id", SHA1: "sha1"}}, nil)
+
+			problems, err := auditor.VerifyBlobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("returns problems for blobs missing from blobstore", func() {
+			blobsDir.BlobsReturns([]Blob{{Path: "file", BlobstoreID: "blob-id", SHA1: "sha1"}}, nil)
+
+			finalBlobRefs = []boshidx.BlobRef{
+				{
+					IndexPath:   filepath.Join("/", "dir", ".final_builds", "packages", "pkg", "index.yml"),
+					Name:        "pkg",
+					Version:     "fp",
+					BlobstoreID: "pkg-blob-id",
+				},
+				{
+					IndexPath: filepath.Join("/", "dir", ".final_builds", "license", "index.yml"),
+					Name:      "license",
+					Version:   "fp",
+				},
+			}
+
+			blobstore.ExistsReturns(false, nil)
+
+			problems, err := auditor.VerifyBlobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(problems).To(Equal([]BlobProblem{
+				{
+					Source:      filepath.Join("config", "blobs.yml"),
+					Name:        "file",
+					BlobstoreID: "blob-id",
+					Problem:     "Missing from blobstore",
+				},
+				{
+					Source:      filepath.Join(".final_builds", "packages", "pkg", "index.yml"),
+					Name:        "pkg/fp",
+					BlobstoreID: "pkg-blob-id",
+					Problem:     "Missing from blobstore",
+				},
+				{
+					Source:  filepath.Join(".final_builds", "license", "index.yml"),
+					Name:    "license/fp",
+					Problem: "Missing blobstore ID",
+				},
+			}))
+		})
+
+		It("returns error if checking blobstore fails", func() {
+			blobsDir.BlobsReturns([]Blob{{Path: "file", BlobstoreID: "blob-id", SHA1: "sha1"}}, nil)
+			blobstore.ExistsReturns(false, errors.New("fake-err"))
+
+			_, err := auditor.VerifyBlobs()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if blobs cannot be read", func() {
+			blobsDir.BlobsReturns(nil, errors.New("fake-err"))
+
+			_, err := auditor.VerifyBlobs()
+			Expect(err).To(Equal(errors.New("fake-err")))
+		})
+
+		It("returns error if final indicies cannot be read", func() {
+			finalRefsErr = errors.New("fake-err")
+
+			_, err := auditor.VerifyBlobs()
+			Expect(err).To(Equal(errors.New("fake-err")))
+		})
+	})
+
+	Describe("UnreferencedBlobs", func() {
+		const (
+			blobID         = "5a1d8c2e-0e5c-4a4d-9b7e-1b0c3f6e2a01"
+			jobBlobID      = "5a1d8c2e-0e5c-4a4d-9b7e-1b0c3f6e2a02"
+			unknownBlobID1 = "5a1d8c2e-0e5c-4a4d-9b7e-1b0c3f6e2a03"
+			unknownBlobID2 = "5a1d8c2e-0e5c-4a4d-9b7e-1b0c3f6e2a04"
+		)
+
+		BeforeEach(func() {
+			fs.MkdirAll(filepath.Join("/", "dir", ".final_builds"), 0755)
+		})
+
+		It("returns sorted blobstore IDs not referenced by blobs or final indicies and number of referenced blobs", func() {
+			blobsDir.BlobsReturns([]Blob{{Path: "file", BlobstoreID: blobID}}, nil)
+			finalBlobRefs = []boshidx.BlobRef{{Name: "job", BlobstoreID: jobBlobID}}
+
+			blobstore.ListReturns([]string{unknownBlobID2, jobBlobID, blobID, unknownBlobID1}, nil)
+
+			blobIDs, referencedCount, err := auditor.UnreferencedBlobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobIDs).To(Equal([]string{unknownBlobID1, unknownBlobID2}))
+			Expect(referencedCount).To(Equal(2))
+		})
+
+		It("returns error without listing blobstore if release directory does not have final builds", func() {
+			fs.RemoveAll(filepath.Join("/", "dir", ".final_builds"))
+
+			_, _, err := auditor.UnreferencedBlobs()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Expected release directory '" + filepath.Join("/", "dir") + "' to contain final builds index"))
+			Expect(blobstore.ListCallCount()).To(Equal(0))
+		})
+
+		It("returns error if blobstore contains objects that do not look like blobstore IDs", func() {
+			blobsDir.BlobsReturns(nil, nil)
+			blobstore.ListReturns([]string{unknownBlobID1, "other-release/blob", "backup.tgz"}, nil)
+
+			blobIDs, _, err := auditor.UnreferencedBlobs()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"found 2 object(s) that do not look like blobstore IDs (e.g. 'backup.tgz')"))
+			Expect(blobIDs).To(BeEmpty())
+		})
+
+		It("returns error if blobstore cannot be listed", func() {
+			blobsDir.BlobsReturns(nil, nil)
+			blobstore.ListReturns(nil, errors.New("fake-err"))
+
+			_, _, err := auditor.UnreferencedBlobs()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if final indicies cannot be read", func() {
+			finalRefsErr = errors.New("fake-err")
+
+			_, _, err := auditor.UnreferencedBlobs()
+			Expect(err).To(Equal(errors.New("fake-err")))
+			Expect(blobstore.ListCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("DeleteBlob", func() {
+		It("deletes blob from blobstore", func() {
+			err := auditor.DeleteBlob("blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobstore.DeleteArgsForCall(0)).To(Equal("blob-id"))
+		})
+
+		It("returns error if deleting fails", func() {
+			blobstore.DeleteReturns(errors.New("fake-err"))
+
+			err := auditor.DeleteBlob("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deleting blob 'blob-id'"))
+		})
+	})
+})
@@ -10,8 +10,10 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
+	"cloud.google.com/go/storage"
 	gcsclient "github.com/cloudfoundry/bosh-gcscli/client"
 	gcsconfig "github.com/cloudfoundry/bosh-gcscli/config"
+	"google.golang.org/api/iterator"
 )
 
 type GCSBlobstore struct {
@@ -80,7 +82,45 @@ func (b GCSBlobstore) CleanUp(path string) error {
 }
 
 func (b GCSBlobstore) Delete(blobID string) error {
-	panic("Not implemented")
+	client, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	return client.Delete(blobID)
+}
+
+func (b GCSBlobstore) Exists(blobID string) (bool, error) {
+	client, err := b.client()
+	if err != nil {
+		return false, err
+	}
+
+	return client.Exists(blobID)
+}
+
+func (b GCSBlobstore) List() ([]string, error) {
+	ctx, gcsSDK, conf, err := NewGCSSDK(b.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobIDs []string
+
+	objects := gcsSDK.Bucket(conf.BucketName).Objects(ctx, nil)
+
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listing bucket '%s'", conf.BucketName)
+		}
+
+		blobIDs = append(blobIDs, attrs.Name)
+	}
+
+	return blobIDs, nil
 }
 
 func (b GCSBlobstore) Validate() error {
@@ -89,25 +129,40 @@ func (b GCSBlobstore) Validate() error {
 }
 
 func (b GCSBlobstore) client() (*gcsclient.GCSBlobstore, error) {
-	bytes, err := json.Marshal(b.options)
+	return NewGCSClient(b.options)
+}
+
+// NewGCSClient builds gcscli client from blobstore options (as found in config/final.yml)
+func NewGCSClient(options map[string]interface{}) (*gcsclient.GCSBlobstore, error) {
+	_, gcsSDK, conf, err := NewGCSSDK(options)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Marshaling config")
+		return nil, err
 	}
 
-	conf, err := gcsconfig.NewFromReader(gobytes.NewBuffer(bytes))
+	client, err := gcsclient.New(context.Background(), gcsSDK, &conf)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Reading config")
+		return nil, bosherr.WrapError(err, "Validating config")
 	}
 
-	_, gcsSDK, err := gcsclient.NewSDK(conf)
+	return &client, nil
+}
+
+// NewGCSSDK builds GCS SDK client for operations not offered by gcscli client
+func NewGCSSDK(options map[string]interface{}) (context.Context, *storage.Client, gcsconfig.GCSCli, error) {
+	bytes, err := json.Marshal(options)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Building client SDK")
+		return nil, nil, gcsconfig.GCSCli{}, bosherr.WrapError(err, "Marshaling config")
 	}
 
-	client, err := gcsclient.New(context.Background(), gcsSDK, &conf)
+	conf, err := gcsconfig.NewFromReader(gobytes.NewBuffer(bytes))
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Validating config")
+		return nil, nil, gcsconfig.GCSCli{}, bosherr.WrapError(err, "Reading config")
 	}
 
-	return &client, nil
+	ctx, gcsSDK, err := gcsclient.NewSDK(conf)
+	if err != nil {
+		return nil, nil, gcsconfig.GCSCli{}, bosherr.WrapError(err, "Building client SDK")
+	}
+
+	return ctx, gcsSDK, conf, nil
 }
@@ -22,6 +22,16 @@ type FSIndex struct {
 	expectsBlobstoreIDs bool
 }
 
+// BlobRef is a blob recorded in the index for a version of a job, package or license
+type BlobRef struct {
+	IndexPath string
+	Name      string
+	Version   string
+
+	BlobstoreID string
+	SHA1        string
+}
+
 type indexEntry struct {
 	Key     string
 	Version string
@@ -126,6 +136,50 @@ func (i FSIndex) Find(name, fingerprint string) (string, string, error) {
 	return "", "", nil
 }
 
+// BlobRefs returns blobs recorded in the index for all names
+func (i FSIndex) BlobRefs() ([]BlobRef, error) {
+	names := []string{i.name}
+
+	if i.useSubdir {
+		indexPaths, err := i.fs.Glob(filepath.Join(i.dirPath, "*", "index.yml"))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listing indices in '%s'", i.dirPath)
+		}
+
+		names = nil
+
+		for _, indexPath := range indexPaths {
+			names = append(names, filepath.Base(filepath.Dir(indexPath)))
+		}
+	}
+
+	sort.Strings(names)
+
+	var refs []BlobRef
+
+	for _, name := range names {
+		entries, err := i.entries(name)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading index '%s'", i.indexPath(name))
+		}
+
+		sort.Sort(indexEntrySorting(entries))
+
+		for _, entry := range entries {
+			refs = append(refs, BlobRef{
+				IndexPath: i.indexPath(name),
+				Name:      name,
+				Version:   entry.Version,
+
+				BlobstoreID: entry.BlobstoreID,
+				SHA1:        entry.SHA1,
+			})
+		}
+	}
+
+	return refs, nil
+}
+
 func (i FSIndex) Add(name, fingerprint, path, sha1 string) (string, string, error) {
 	if len(name) == 0 {
 		return "", "", bosherr.Error("Expected non-empty name")
@@ -268,3 +322,9 @@ func (i FSIndex) indexPath(name string) string {
 	}
 	return filepath.Join(i.dirPath, "index.yml")
 }
+
+type indexEntrySorting []indexEntry
+
+func (s indexEntrySorting) Len() int           { return len(s) }
+func (s indexEntrySorting) Less(i, j int) bool { return s[i].Version < s[j].Version }
+func (s indexEntrySorting) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -188,6 +188,86 @@ format-version: "2"`)
 		})
 	})
 
+	Describe("BlobRefs", func() {
+		It("returns blobs of all names sorted by name and version", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "name1", "index.yml"), `---
+builds:
+  fp2: {version: fp2, sha1: fp2-sha1, blobstore_id: fp2-blob-id}
+  fp1: {version: fp1, sha1: fp1-sha1, blobstore_id: fp1-blob-id}
+format-version: "2"`)
+
+			fs.WriteFileString(filepath.Join("/", "dir", "name2", "index.yml"), `---
+builds:
+  fp3: {version: fp3, sha1: fp3-sha1, blobstore_id: fp3-blob-id}
+format-version: "2"`)
+
+			fs.SetGlob(filepath.Join("/", "dir", "*", "index.yml"), []string{
+				filepath.Join("/", "dir", "name2", "index.yml"),
+				filepath.Join("/", "dir", "name1", "index.yml"),
+			})
+
+			refs, err := index.BlobRefs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(refs).To(Equal([]boshidx.BlobRef{
+				{
+					IndexPath:   filepath.Join("/", "dir", "name1", "index.yml"),
+					Name:        "name1",
+					Version:     "fp1",
+					BlobstoreID: "fp1-blob-id",
+					SHA1:        "fp1-sha1",
+				},
+				{
+					IndexPath:   filepath.Join("/", "dir", "name1", "index.yml"),
+					Name:        "name1",
+					Version:     "fp2",
+					BlobstoreID: "fp2-blob-id",
+					SHA1:        "fp2-sha1",
+				},
+				{
+					IndexPath:   filepath.Join("/", "dir", "name2", "index.yml"),
+					Name:        "name2",
+					Version:     "fp3",
+					BlobstoreID: "fp3-blob-id",
+					SHA1:        "fp3-sha1",
+				},
+			}))
+		})
+
+		It("returns blobs from non-prefixed index file", func() {
+			index = boshidx.NewFSIndex("index-name", filepath.Join("/", "dir"), false, true, reporter, blobs, fs)
+
+			fs.WriteFileString(filepath.Join("/", "dir", "index.yml"), `---
+builds:
+  fp: {version: fp, sha1: fp-sha1, blobstore_id: fp-blob-id}
+format-version: "2"`)
+
+			refs, err := index.BlobRefs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(refs).To(Equal([]boshidx.BlobRef{{
+				IndexPath:   filepath.Join("/", "dir", "index.yml"),
+				Name:        "index-name",
+				Version:     "fp",
+				BlobstoreID: "fp-blob-id",
+				SHA1:        "fp-sha1",
+			}}))
+		})
+
+		It("returns nothing if index does not exist", func() {
+			refs, err := index.BlobRefs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(refs).To(BeEmpty())
+		})
+
+		It("returns error if index cannot be read", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "name", "index.yml"), "-")
+			fs.SetGlob(filepath.Join("/", "dir", "*", "index.yml"), []string{filepath.Join("/", "dir", "name", "index.yml")})
+
+			_, err := index.BlobRefs()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling index"))
+		})
+	})
+
 	Describe("Add", func() {
 		It("adds new entry when no index file exists", func() {
 			blobs.AddStub = func(name, path, sha1 string) (string, string, error) {
@@ -37,21 +37,47 @@ func (p Provider) DevAndFinalIndicies(dirPath string) (boshrel.ArchiveIndicies,
 	devPkgsPath := filepath.Join(dirPath, ".dev_builds", "packages")
 	devLicPath := filepath.Join(dirPath, ".dev_builds", "license")
 
-	finalJobsPath := filepath.Join(dirPath, ".final_builds", "jobs")
-	finalPkgsPath := filepath.Join(dirPath, ".final_builds", "packages")
-	finalLicPath := filepath.Join(dirPath, ".final_builds", "license")
-
 	devIndicies := boshrel.ArchiveIndicies{
 		Jobs:     NewFSIndex("job", devJobsPath, true, false, p.reporter, devBlobsCache, p.fs),
 		Packages: NewFSIndex("package", devPkgsPath, true, false, p.reporter, devBlobsCache, p.fs),
 		Licenses: NewFSIndex("license", devLicPath, false, false, p.reporter, devBlobsCache, p.fs),
 	}
 
+	jobsIndex, pkgsIndex, licIndex := p.finalIndicies(dirPath, finalBlobsCache)
+
 	finalIndicies := boshrel.ArchiveIndicies{
-		Jobs:     NewFSIndex("job", finalJobsPath, true, true, p.reporter, finalBlobsCache, p.fs),
-		Packages: NewFSIndex("package", finalPkgsPath, true, true, p.reporter, finalBlobsCache, p.fs),
-		Licenses: NewFSIndex("license", finalLicPath, false, true, p.reporter, finalBlobsCache, p.fs),
+		Jobs:     jobsIndex,
+		Packages: pkgsIndex,
+		Licenses: licIndex,
 	}
 
 	return devIndicies, finalIndicies
 }
+
+// FinalBlobRefs returns blobs recorded in final indicies of jobs, packages and license
+func (p Provider) FinalBlobRefs(dirPath string) ([]BlobRef, error) {
+	var refs []BlobRef
+
+	jobsIndex, pkgsIndex, licIndex := p.finalIndicies(dirPath, nil)
+
+	for _, index := range []FSIndex{jobsIndex, pkgsIndex, licIndex} {
+		indexRefs, err := index.BlobRefs()
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, indexRefs...)
+	}
+
+	return refs, nil
+}
+
+func (p Provider) finalIndicies(dirPath string, blobs IndexBlobs) (FSIndex, FSIndex, FSIndex) {
+	finalJobsPath := filepath.Join(dirPath, ".final_builds", "jobs")
+	finalPkgsPath := filepath.Join(dirPath, ".final_builds", "packages")
+	finalLicPath := filepath.Join(dirPath, ".final_builds", "license")
+
+	return NewFSIndex("job", finalJobsPath, true, true, p.reporter, blobs, p.fs),
+		NewFSIndex("package", finalPkgsPath, true, true, p.reporter, blobs, p.fs),
+		NewFSIndex("license", finalLicPath, false, true, p.reporter, blobs, p.fs)
+}
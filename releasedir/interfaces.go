@@ -95,6 +95,37 @@ type Blob struct {
 	SHA1        string
 }
 
+//go:generate counterfeiter . ListableBlobstore
+
+// ListableBlobstore allows to inspect and clean up release blobstore
+// independently of blobs referenced by the release directory
+type ListableBlobstore interface {
+	Exists(blobID string) (bool, error)
+	List() ([]string, error)
+	Delete(blobID string) error
+}
+
+//go:generate counterfeiter . BlobsAuditor
+
+type BlobsAuditor interface {
+	// VerifyBlobs checks local blobs against their digests and
+	// that blobs in blobs.yml and final indicies exist in the blobstore.
+	VerifyBlobs() ([]BlobProblem, error)
+
+	// UnreferencedBlobs returns blobstore IDs that are neither referenced
+	// by blobs.yml nor by final indicies, and the number of referenced blobs found in blobstore.
+	UnreferencedBlobs() (unreferencedIDs []string, referencedCount int, err error)
+	DeleteBlob(blobID string) error
+}
+
+type BlobProblem struct {
+	Source string // e.g. config/blobs.yml or .final_builds/jobs/name/index.yml
+	Name   string
+
+	BlobstoreID string
+	Problem     string
+}
+
 //go:generate counterfeiter . ReleaseIndex
 
 type ReleaseIndex interface {
@@ -0,0 +1,65 @@
+package releasedir
+
+import (
+	"path/filepath"
+
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// LocalBlobstore adds checking and listing of blobs to bosh-utils local blobstore
+type LocalBlobstore struct {
+	boshblob.Blobstore
+
+	fs      boshsys.FileSystem
+	options map[string]interface{}
+}
+
+func NewLocalBlobstore(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	options map[string]interface{},
+) LocalBlobstore {
+	return LocalBlobstore{
+		Blobstore: boshblob.NewLocalBlobstore(fs, uuidGen, options),
+
+		fs:      fs,
+		options: options,
+	}
+}
+
+func (b LocalBlobstore) Exists(blobID string) (bool, error) {
+	err := b.Validate()
+	if err != nil {
+		return false, err
+	}
+
+	return b.fs.FileExists(filepath.Join(b.path(), blobID)), nil
+}
+
+func (b LocalBlobstore) List() ([]string, error) {
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := b.fs.Glob(filepath.Join(b.path(), "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing blobstore path '%s'", b.path())
+	}
+
+	var blobIDs []string
+
+	for _, path := range paths {
+		blobIDs = append(blobIDs, filepath.Base(path))
+	}
+
+	return blobIDs, nil
+}
+
+func (b LocalBlobstore) path() string {
+	// Validate() makes sure that it's a string
+	return b.options["blobstore_path"].(string)
+}
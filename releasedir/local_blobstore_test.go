@@ -0,0 +1,60 @@
+package releasedir_test
+
+import (
+	"path/filepath"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/releasedir"
+)
+
+var _ = Describe("LocalBlobstore", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		blobstore LocalBlobstore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		options := map[string]interface{}{"blobstore_path": filepath.Join("/", "blobs")}
+		blobstore = NewLocalBlobstore(fs, &fakeuuid.FakeGenerator{}, options)
+	})
+
+	Describe("Exists", func() {
+		It("returns true only if blob is in blobstore path", func() {
+			fs.WriteFileString(filepath.Join("/", "blobs", "blob-id"), "content")
+
+			exists, err := blobstore.Exists("blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			exists, err = blobstore.Exists("other-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("returns error if blobstore path is not configured", func() {
+			blobstore = NewLocalBlobstore(fs, &fakeuuid.FakeGenerator{}, map[string]interface{}{})
+
+			_, err := blobstore.Exists("blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing blobstore_path"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns blob IDs in blobstore path", func() {
+			fs.SetGlob(filepath.Join("/", "blobs", "*"), []string{
+				filepath.Join("/", "blobs", "blob-id1"),
+				filepath.Join("/", "blobs", "blob-id2"),
+			})
+
+			blobIDs, err := blobstore.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobIDs).To(Equal([]string{"blob-id1", "blob-id2"}))
+		})
+	})
+})
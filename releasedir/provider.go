@@ -90,23 +90,18 @@ func (p Provider) NewReleaseReader(dirPath string) boshrel.BuiltReader {
 	return boshrel.NewBuiltReader(multiReader, devIndex, finalIndex)
 }
 
-func (p Provider) newBlobstore(dirPath string) boshblob.DigestBlobstore {
-	provider, options, err := p.newConfig(dirPath).Blobstore()
-	if err != nil {
-		return NewErrBlobstore(err)
+func (p Provider) NewFSBlobsAuditor(dirPath string) FSBlobsAuditor {
+	finalBlobRefs := func() ([]boshidx.BlobRef, error) {
+		return boshidx.NewProvider(p.indexReporter, nil, p.fs).FinalBlobRefs(dirPath)
 	}
 
-	var blobstore boshblob.Blobstore
+	return NewFSBlobsAuditor(dirPath, p.NewFSBlobsDir(dirPath), finalBlobRefs, p.newListableBlobstore(dirPath), p.fs)
+}
 
-	switch provider {
-	case "local":
-		blobstore = boshblob.NewLocalBlobstore(p.fs, p.uuidGen, options)
-	case "s3":
-		blobstore = NewS3Blobstore(p.fs, p.uuidGen, options)
-	case "gcs":
-		blobstore = NewGCSBlobstore(p.fs, p.uuidGen, options)
-	default:
-		return NewErrBlobstore(bosherr.Error("Expected release blobstore to be configured"))
+func (p Provider) newBlobstore(dirPath string) boshblob.DigestBlobstore {
+	blobstore, err := p.newProviderBlobstore(dirPath)
+	if err != nil {
+		return NewErrBlobstore(err)
 	}
 
 	digestBlobstore := boshblob.NewDigestVerifiableBlobstore(blobstore, p.fs, p.digestCreateAlgorithms)
@@ -120,6 +115,45 @@ func (p Provider) newBlobstore(dirPath string) boshblob.DigestBlobstore {
 	return digestBlobstore
 }
 
+func (p Provider) newListableBlobstore(dirPath string) ListableBlobstore {
+	blobstore, err := p.newProviderBlobstore(dirPath)
+	if err != nil {
+		return NewErrBlobstore(err)
+	}
+
+	err = blobstore.Validate()
+	if err != nil {
+		return NewErrBlobstore(err)
+	}
+
+	listableBlobstore, ok := blobstore.(ListableBlobstore)
+	if !ok {
+		return NewErrBlobstore(bosherr.Error("Expected release blobstore to support listing blobs"))
+	}
+
+	return listableBlobstore
+}
+
+func (p Provider) newProviderBlobstore(dirPath string) (boshblob.Blobstore, error) {
+	provider, options, err := p.newConfig(dirPath).Blobstore()
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider {
+	case "local":
+		return NewLocalBlobstore(p.fs, p.uuidGen, options), nil
+	case "s3":
+		return NewS3Blobstore(p.fs, p.uuidGen, options), nil
+	case "gcs":
+		return NewGCSBlobstore(p.fs, p.uuidGen, options), nil
+	case "dav":
+		return NewDAVBlobstore(p.fs, p.uuidGen, options, p.logger), nil
+	default:
+		return nil, bosherr.Error("Expected release blobstore to be configured")
+	}
+}
+
 func (p Provider) newConfig(dirPath string) FSConfig {
 	publicPath := filepath.Join(dirPath, "config", "final.yml")
 	privatePath := filepath.Join(dirPath, "config", "private.yml")
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"os"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -82,7 +84,44 @@ func (b S3Blobstore) CleanUp(path string) error {
 }
 
 func (b S3Blobstore) Delete(blobID string) error {
-	panic("Not implemented")
+	client, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	return client.Delete(blobID)
+}
+
+func (b S3Blobstore) Exists(blobID string) (bool, error) {
+	client, err := b.client()
+	if err != nil {
+		return false, err
+	}
+
+	return client.Exists(blobID)
+}
+
+func (b S3Blobstore) List() ([]string, error) {
+	s3ClientSDK, conf, err := NewS3SDK(b.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobIDs []string
+
+	input := &s3.ListObjectsInput{Bucket: aws.String(conf.BucketName)}
+
+	err = s3ClientSDK.ListObjectsPages(input, func(output *s3.ListObjectsOutput, _ bool) bool {
+		for _, object := range output.Contents {
+			blobIDs = append(blobIDs, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing bucket '%s'", conf.BucketName)
+	}
+
+	return blobIDs, nil
 }
 
 func (b S3Blobstore) Validate() error {
@@ -91,25 +130,40 @@ func (b S3Blobstore) Validate() error {
 }
 
 func (b S3Blobstore) client() (s3client.S3Blobstore, error) {
-	bytes, err := json.Marshal(b.options)
+	return NewS3Client(b.options)
+}
+
+// NewS3Client builds s3cli client from blobstore options (as found in config/final.yml)
+func NewS3Client(options map[string]interface{}) (s3client.S3Blobstore, error) {
+	s3ClientSDK, conf, err := NewS3SDK(options)
 	if err != nil {
-		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Marshaling config")
+		return s3client.S3Blobstore{}, err
 	}
 
-	conf, err := s3config.NewFromReader(gobytes.NewBuffer(bytes))
+	client, err := s3client.New(s3ClientSDK, &conf)
 	if err != nil {
-		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Reading config")
+		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Validating config")
 	}
 
-	s3ClientSDK, err := s3client.NewSDK(conf)
+	return client, nil
+}
+
+// NewS3SDK builds AWS SDK client for operations not offered by s3cli client
+func NewS3SDK(options map[string]interface{}) (*s3.S3, s3config.S3Cli, error) {
+	bytes, err := json.Marshal(options)
 	if err != nil {
-		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Building client SDK")
+		return nil, s3config.S3Cli{}, bosherr.WrapErrorf(err, "Marshaling config")
 	}
 
-	client, err := s3client.New(s3ClientSDK, &conf)
+	conf, err := s3config.NewFromReader(gobytes.NewBuffer(bytes))
 	if err != nil {
-		return s3client.S3Blobstore{}, bosherr.WrapErrorf(err, "Validating config")
+		return nil, s3config.S3Cli{}, bosherr.WrapErrorf(err, "Reading config")
 	}
 
-	return client, nil
+	s3ClientSDK, err := s3client.NewSDK(conf)
+	if err != nil {
+		return nil, s3config.S3Cli{}, bosherr.WrapErrorf(err, "Building client SDK")
+	}
+
+	return s3ClientSDK, conf, nil
 }
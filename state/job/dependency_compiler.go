@@ -26,14 +26,20 @@ type DependencyCompiler interface {
 
 type dependencyCompiler struct {
 	packageCompiler bistatepkg.Compiler
+	parallel        int
 
 	logTag string
 	logger boshlog.Logger
 }
 
-func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, logger boshlog.Logger) DependencyCompiler {
+func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, parallel int, logger boshlog.Logger) DependencyCompiler {
+	if parallel < 1 {
+		parallel = 1
+	}
+
 	return &dependencyCompiler{
 		packageCompiler: packageCompiler,
+		parallel:        parallel,
 
 		logTag: "dependencyCompiler",
 		logger: logger,
@@ -104,39 +110,123 @@ func (c *dependencyCompiler) resolvePackageDependencies(releasePackage birelpkg.
 	}
 }
 
-// compilePackages compiles the specified packages, in the order specified, uploads them to the Blobstore, and returns the blob references
+type compileResult struct {
+	pkg birelpkg.Compilable
+	ref CompiledPackageRef
+	err error
+}
+
+// compilePackages compiles the specified packages, uploads them to the Blobstore, and returns the blob references in the order specified.
+// Packages are compiled by up to c.parallel workers as soon as all of their dependencies are compiled.
+// No new packages are started after the first failure and, if package compiler supports it,
+// running compilations are canceled. Agent's compile_package cannot be canceled so remote
+// compilations are waited for and, if successful, stay in compiled package repo for the next deploy.
 func (c *dependencyCompiler) compilePackages(requiredPackages []birelpkg.Compilable, stage biui.Stage) ([]CompiledPackageRef, error) {
-	packageRefs := make([]CompiledPackageRef, 0, len(requiredPackages))
+	compiledRefs := map[string]CompiledPackageRef{}
+	startedPkgs := map[string]bool{}
+	results := make(chan compileResult)
+
+	var running int
+	var firstErr error
+
+	for {
+		if firstErr == nil {
+			for _, pkg := range requiredPackages {
+				if running >= c.parallel {
+					break
+				}
+
+				if startedPkgs[c.pkgKey(pkg)] || !c.depsCompiled(pkg, compiledRefs) {
+					continue
+				}
+
+				startedPkgs[c.pkgKey(pkg)] = true
+				running++
+
+				go func(pkg birelpkg.Compilable) {
+					ref, err := c.compilePackage(pkg, stage)
+					results <- compileResult{pkg: pkg, ref: ref, err: err}
+				}(pkg)
+			}
+		}
 
-	for _, pkg := range requiredPackages {
-		stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+		if running == 0 {
+			break
+		}
 
-		err := stage.Perform(stepName, func() error {
-			compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
-			if err != nil {
-				return err
-			}
+		result := <-results
+		running--
 
-			packageRef := CompiledPackageRef{
-				Name:        pkg.Name(),
-				Version:     pkg.Fingerprint(),
-				BlobstoreID: compiledPackageRecord.BlobID,
-				SHA1:        compiledPackageRecord.BlobSHA1,
-			}
-			packageRefs = append(packageRefs, packageRef)
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
 
-			if isAlreadyCompiled {
-				return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
+				if cancelable, ok := c.packageCompiler.(bistatepkg.CancelableCompiler); ok && running > 0 {
+					cancelable.Cancel()
+				}
+			} else {
+				c.logger.Error(c.logTag, "Compiling package '%s' failed: %s", result.pkg.Name(), result.err.Error())
 			}
+			continue
+		}
 
-			return nil
-		})
-		if err != nil {
-			return nil, err
+		compiledRefs[c.pkgKey(result.pkg)] = result.ref
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	packageRefs := make([]CompiledPackageRef, 0, len(requiredPackages))
+
+	for _, pkg := range requiredPackages {
+		ref, found := compiledRefs[c.pkgKey(pkg)]
+		if !found {
+			return nil, bosherr.Errorf("Dependencies of package '%s' could not be compiled", pkg.Name())
 		}
+
+		packageRefs = append(packageRefs, ref)
 	}
 
 	return packageRefs, nil
 }
 
+func (c *dependencyCompiler) depsCompiled(pkg birelpkg.Compilable, compiledRefs map[string]CompiledPackageRef) bool {
+	for _, dep := range pkg.Deps() {
+		if _, found := compiledRefs[c.pkgKey(dep)]; !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *dependencyCompiler) compilePackage(pkg birelpkg.Compilable, stage biui.Stage) (CompiledPackageRef, error) {
+	var packageRef CompiledPackageRef
+
+	stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	err := stage.Perform(stepName, func() error {
+		compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
+		if err != nil {
+			return err
+		}
+
+		packageRef = CompiledPackageRef{
+			Name:        pkg.Name(),
+			Version:     pkg.Fingerprint(),
+			BlobstoreID: compiledPackageRecord.BlobID,
+			SHA1:        compiledPackageRecord.BlobSHA1,
+		}
+
+		if isAlreadyCompiled {
+			return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
+		}
+
+		return nil
+	})
+
+	return packageRef, err
+}
+
 func (c *dependencyCompiler) pkgKey(pkg birelpkg.Compilable) string { return pkg.Name() }
@@ -1,6 +1,8 @@
 package job_test
 
 import (
+	"errors"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -15,6 +17,27 @@ import (
 	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
 
+type blockingCompiler struct {
+	started chan string
+	release chan error
+}
+
+func (c blockingCompiler) Compile(pkg boshrelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
+	c.started <- pkg.Name()
+	err := <-c.release
+	return bistatepkg.CompiledPackageRecord{BlobID: pkg.Name() + "-blob-id"}, false, err
+}
+
+type cancelableCompiler struct {
+	blockingCompiler
+	canceled chan struct{}
+}
+
+func (c cancelableCompiler) Cancel() {
+	close(c.canceled)
+	c.release <- errors.New("fake-canceled-err")
+}
+
 var _ = Describe("DependencyCompiler", func() {
 	var mockCtrl *gomock.Controller
 
@@ -47,7 +70,7 @@ var _ = Describe("DependencyCompiler", func() {
 		mockPackageCompiler = mock_state_package.NewMockCompiler(mockCtrl)
 
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, logger)
+		dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, 1, logger)
 
 		stage = fakeui.NewFakeStage()
 
@@ -215,4 +238,105 @@ var _ = Describe("DependencyCompiler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("when compiling packages in parallel", func() {
+		var (
+			compiler blockingCompiler
+		)
+
+		BeforeEach(func() {
+			compiler = blockingCompiler{started: make(chan string, 3), release: make(chan error, 3)}
+			dependencyCompiler = NewDependencyCompiler(compiler, 2, logger)
+
+			pkgA := newPkg("pkgA-name", "pkgA-fp", nil)
+			pkgB := newPkg("pkgB-name", "pkgB-fp", nil)
+			pkgC := newPkg("pkgC-name", "pkgC-fp", []string{"pkgA-name", "pkgB-name"})
+			pkgC.AttachDependencies([]*boshrelpkg.Package{pkgA, pkgB})
+
+			job = boshreljob.NewJob(NewResourceWithBuiltArchive("cpi", "job-fp", "path", "sha1"))
+			job.PackageNames = []string{"pkgC-name"}
+			job.AttachPackages([]*boshrelpkg.Package{pkgC})
+			jobs = []boshreljob.Job{*job}
+		})
+
+		compileAsync := func() (chan []CompiledPackageRef, chan error) {
+			refsCh := make(chan []CompiledPackageRef, 1)
+			errCh := make(chan error, 1)
+
+			go func() {
+				refs, err := dependencyCompiler.Compile(jobs, stage)
+				refsCh <- refs
+				errCh <- err
+			}()
+
+			return refsCh, errCh
+		}
+
+		expectIndependentPackagesStarted := func() {
+			var first, second string
+			Eventually(compiler.started).Should(Receive(&first))
+			Eventually(compiler.started).Should(Receive(&second))
+			Expect([]string{first, second}).To(ConsistOf("pkgA-name", "pkgB-name"))
+			Consistently(compiler.started).ShouldNot(Receive())
+		}
+
+		It("compiles independent packages concurrently and dependent packages after their dependencies", func() {
+			refsCh, errCh := compileAsync()
+
+			expectIndependentPackagesStarted()
+
+			compiler.release <- nil
+			compiler.release <- nil
+
+			Eventually(compiler.started).Should(Receive(Equal("pkgC-name")))
+
+			compiler.release <- nil
+
+			Eventually(errCh).Should(Receive(BeNil()))
+
+			var refs []CompiledPackageRef
+			Expect(refsCh).To(Receive(&refs))
+			Expect(refs).To(HaveLen(3))
+			Expect(refs[2]).To(Equal(CompiledPackageRef{Name: "pkgC-name", Version: "pkgC-fp", BlobstoreID: "pkgC-name-blob-id"}))
+
+			Expect(stage.PerformCalls).To(HaveLen(3))
+		})
+
+		It("stops scheduling packages after the first failure and waits for running compilations", func() {
+			_, errCh := compileAsync()
+
+			expectIndependentPackagesStarted()
+
+			compiler.release <- errors.New("fake-compile-err")
+			Consistently(errCh).ShouldNot(Receive())
+
+			compiler.release <- nil
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-compile-err"))
+
+			Expect(compiler.started).ToNot(Receive())
+		})
+
+		It("cancels running compilations after the first failure when package compiler supports it", func() {
+			canceled := make(chan struct{})
+			dependencyCompiler = NewDependencyCompiler(cancelableCompiler{blockingCompiler: compiler, canceled: canceled}, 2, logger)
+
+			_, errCh := compileAsync()
+
+			expectIndependentPackagesStarted()
+
+			compiler.release <- errors.New("fake-compile-err")
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-compile-err"))
+
+			Expect(canceled).To(BeClosed())
+			Expect(compiler.started).ToNot(Receive())
+		})
+	})
 })
@@ -0,0 +1,426 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+)
+
+// compiledPackageCacheIndexName is only used inside of exported tarballs;
+// cache itself keeps separate metadata object per entry so that
+// concurrent writers never overwrite each other's entries
+const compiledPackageCacheIndexName = "index.json"
+
+// compiledPackageCacheKeyRegexp matches keys generated by key()
+var compiledPackageCacheKeyRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type CompiledPackageCacheEntry struct {
+	// Key is a digest of package fingerprint, dependency fingerprints and stemcell
+	Key string `json:"key"`
+
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+	Stemcell    string `json:"stemcell"`
+	SHA1        string `json:"sha1"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CompiledPackageCache keeps compiled package tarballs between create-env runs
+// and can be shared by multiple machines via a directory or an object store
+type CompiledPackageCache interface {
+	Find(pkg birelpkg.Compilable, stemcell string) (CompiledPackageCacheEntry, bool, error)
+	Fetch(entry CompiledPackageCacheEntry, destinationPath string) error
+	Save(pkg birelpkg.Compilable, stemcell string, tarballPath string, sha1 string) error
+
+	List() ([]CompiledPackageCacheEntry, error)
+	Delete(entry CompiledPackageCacheEntry) error
+
+	Export(destinationPath string) ([]CompiledPackageCacheEntry, error)
+	Import(sourcePath string) ([]CompiledPackageCacheEntry, error)
+}
+
+// compiledPackageCache stores each entry as '<key>.tgz' and '<key>.json' objects.
+// Metadata object is written after the tarball hence its presence marks complete entry.
+type compiledPackageCache struct {
+	backend biconfig.StateBackend
+	prefix  string
+
+	fs          boshsys.FileSystem
+	compressor  boshcmd.Compressor
+	timeService clock.Clock
+}
+
+type compiledPackageCacheIndex struct {
+	Entries []CompiledPackageCacheEntry `json:"entries"`
+}
+
+// NewCompiledPackageCache returns cache stored in a local directory
+// or under s3://bucket/prefix, gcs://bucket/prefix and file:///path locations
+func NewCompiledPackageCache(
+	location string,
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	timeService clock.Clock,
+) CompiledPackageCache {
+	backend, prefix, found := biconfig.NewStateBackendFromURL(fs, location)
+	if !found {
+		backend = biconfig.NewFSStateBackend(fs)
+		prefix = location
+
+		if expandedPath, err := fs.ExpandPath(location); err == nil {
+			prefix = expandedPath
+		}
+	}
+
+	return &compiledPackageCache{
+		backend: backend,
+		prefix:  prefix,
+
+		fs:          fs,
+		compressor:  compressor,
+		timeService: timeService,
+	}
+}
+
+// Find does not modify the cache so that lookups can be done concurrently by multiple machines
+func (c *compiledPackageCache) Find(pkg birelpkg.Compilable, stemcell string) (CompiledPackageCacheEntry, bool, error) {
+	key := c.key(pkg, stemcell)
+
+	exists, err := c.backend.Exists(c.metadataKey(key))
+	if err != nil {
+		return CompiledPackageCacheEntry{}, false, bosherr.WrapErrorf(err, "Checking compiled package '%s/%s' in cache", pkg.Name(), pkg.Fingerprint())
+	}
+
+	if !exists {
+		return CompiledPackageCacheEntry{}, false, nil
+	}
+
+	entry, err := c.loadEntry(key)
+	if err != nil {
+		return CompiledPackageCacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+func (c *compiledPackageCache) Fetch(entry CompiledPackageCacheEntry, destinationPath string) error {
+	contents, err := c.backend.Get(c.tarballKey(entry.Key))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Fetching compiled package '%s/%s' from cache", entry.Name, entry.Fingerprint)
+	}
+
+	err = c.verify(entry, contents)
+	if err != nil {
+		return err
+	}
+
+	err = c.fs.WriteFile(destinationPath, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing compiled package '%s/%s'", entry.Name, entry.Fingerprint)
+	}
+
+	return nil
+}
+
+func (c *compiledPackageCache) Save(pkg birelpkg.Compilable, stemcell string, tarballPath string, sha1 string) error {
+	contents, err := c.fs.ReadFile(tarballPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+	}
+
+	entry := CompiledPackageCacheEntry{
+		Key: c.key(pkg, stemcell),
+
+		Name:        pkg.Name(),
+		Fingerprint: pkg.Fingerprint(),
+		Stemcell:    stemcell,
+		SHA1:        sha1,
+
+		CreatedAt: c.timeService.Now().UTC(),
+	}
+
+	return c.put(entry, contents)
+}
+
+func (c *compiledPackageCache) List() ([]CompiledPackageCacheEntry, error) {
+	objectKeys, err := c.backend.List(c.prefix)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing compiled package cache")
+	}
+
+	var entries []CompiledPackageCacheEntry
+
+	for _, objectKey := range objectKeys {
+		name := path.Base(objectKey)
+
+		if path.Ext(name) != ".json" {
+			continue
+		}
+
+		key := strings.TrimSuffix(name, ".json")
+
+		// Skip unrelated objects that may be stored next to the cache
+		if !compiledPackageCacheKeyRegexp.MatchString(key) {
+			continue
+		}
+
+		entry, err := c.loadEntry(key)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Sort(compiledPackageCacheEntrySorting(entries))
+
+	return entries, nil
+}
+
+// Delete removes metadata first so that entry is never found without its tarball
+func (c *compiledPackageCache) Delete(entry CompiledPackageCacheEntry) error {
+	err := c.backend.Delete(c.metadataKey(entry.Key))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting compiled package '%s/%s' from cache", entry.Name, entry.Fingerprint)
+	}
+
+	err = c.backend.Delete(c.tarballKey(entry.Key))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting compiled package '%s/%s' from cache", entry.Name, entry.Fingerprint)
+	}
+
+	return nil
+}
+
+// Export writes all cached packages into a single tarball
+func (c *compiledPackageCache) Export(destinationPath string) ([]CompiledPackageCacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	exportDir, err := c.fs.TempDir("bosh-compiled-cache-export")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating export dir")
+	}
+
+	defer c.fs.RemoveAll(exportDir)
+
+	for _, entry := range entries {
+		contents, err := c.backend.Get(c.tarballKey(entry.Key))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Fetching compiled package '%s/%s' from cache", entry.Name, entry.Fingerprint)
+		}
+
+		err = c.verify(entry, contents)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.fs.WriteFile(filepath.Join(exportDir, entry.Key+".tgz"), contents)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Writing compiled package '%s/%s'", entry.Name, entry.Fingerprint)
+		}
+	}
+
+	indexBytes, err := json.Marshal(compiledPackageCacheIndex{Entries: entries})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling compiled package cache index")
+	}
+
+	err = c.fs.WriteFile(filepath.Join(exportDir, compiledPackageCacheIndexName), indexBytes)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Writing compiled package cache index")
+	}
+
+	tarballPath, err := c.compressor.CompressFilesInDir(exportDir)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Compressing compiled package cache")
+	}
+
+	defer c.compressor.CleanUp(tarballPath)
+
+	err = c.fs.CopyFile(tarballPath, destinationPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Copying compiled package cache to '%s'", destinationPath)
+	}
+
+	return entries, nil
+}
+
+// Import adds packages from a tarball created by Export that are not already cached
+func (c *compiledPackageCache) Import(sourcePath string) ([]CompiledPackageCacheEntry, error) {
+	importDir, err := c.fs.TempDir("bosh-compiled-cache-import")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating import dir")
+	}
+
+	defer c.fs.RemoveAll(importDir)
+
+	err = c.compressor.DecompressFileToDir(sourcePath, importDir, boshcmd.CompressorOptions{})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Extracting compiled package cache '%s'", sourcePath)
+	}
+
+	indexBytes, err := c.fs.ReadFile(filepath.Join(importDir, compiledPackageCacheIndexName))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading compiled package cache index")
+	}
+
+	var index compiledPackageCacheIndex
+
+	err = json.Unmarshal(indexBytes, &index)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling compiled package cache index")
+	}
+
+	var importedEntries []CompiledPackageCacheEntry
+
+	for _, entry := range index.Entries {
+		// Key is used to build file paths and object keys
+		if !compiledPackageCacheKeyRegexp.MatchString(entry.Key) {
+			return nil, bosherr.Errorf("Expected compiled package '%s/%s' key '%s' to be a SHA256 hex digest", entry.Name, entry.Fingerprint, entry.Key)
+		}
+
+		exists, err := c.backend.Exists(c.metadataKey(entry.Key))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking compiled package '%s/%s' in cache", entry.Name, entry.Fingerprint)
+		}
+
+		if exists {
+			continue
+		}
+
+		contents, err := c.fs.ReadFile(filepath.Join(importDir, entry.Key+".tgz"))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading compiled package '%s/%s'", entry.Name, entry.Fingerprint)
+		}
+
+		err = c.verify(entry, contents)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.put(entry, contents)
+		if err != nil {
+			return nil, err
+		}
+
+		importedEntries = append(importedEntries, entry)
+	}
+
+	return importedEntries, nil
+}
+
+func (c *compiledPackageCache) put(entry CompiledPackageCacheEntry, contents []byte) error {
+	err := c.backend.Put(c.tarballKey(entry.Key), contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving compiled package '%s/%s' to cache", entry.Name, entry.Fingerprint)
+	}
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling compiled package '%s/%s' cache entry", entry.Name, entry.Fingerprint)
+	}
+
+	err = c.backend.Put(c.metadataKey(entry.Key), entryBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving compiled package '%s/%s' cache entry", entry.Name, entry.Fingerprint)
+	}
+
+	return nil
+}
+
+func (c *compiledPackageCache) loadEntry(key string) (CompiledPackageCacheEntry, error) {
+	var entry CompiledPackageCacheEntry
+
+	entryBytes, err := c.backend.Get(c.metadataKey(key))
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Reading compiled package cache entry '%s'", key)
+	}
+
+	err = json.Unmarshal(entryBytes, &entry)
+	if err != nil {
+		return entry, bosherr.WrapErrorf(err, "Unmarshalling compiled package cache entry '%s'", key)
+	}
+
+	if entry.Key != key {
+		return entry, bosherr.Errorf("Expected compiled package cache entry '%s' to have matching key but was '%s'", key, entry.Key)
+	}
+
+	return entry, nil
+}
+
+func (c *compiledPackageCache) verify(entry CompiledPackageCacheEntry, contents []byte) error {
+	digest, err := boshcrypto.ParseMultipleDigest(entry.SHA1)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing compiled package '%s/%s' digest '%s'", entry.Name, entry.Fingerprint, entry.SHA1)
+	}
+
+	err = digest.Verify(bytes.NewReader(contents))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Verifying compiled package '%s/%s' from cache", entry.Name, entry.Fingerprint)
+	}
+
+	return nil
+}
+
+func (c *compiledPackageCache) tarballKey(key string) string {
+	return c.objectKey(key + ".tgz")
+}
+
+func (c *compiledPackageCache) metadataKey(key string) string {
+	return c.objectKey(key + ".json")
+}
+
+func (c *compiledPackageCache) objectKey(name string) string {
+	if len(c.prefix) == 0 {
+		return name
+	}
+
+	return path.Join(c.prefix, name)
+}
+
+func (c *compiledPackageCache) key(pkg birelpkg.Compilable, stemcell string) string {
+	var deps []string
+
+	for _, dep := range ResolveDependencies(pkg) {
+		deps = append(deps, fmt.Sprintf("%s:%s", dep.Name(), dep.Fingerprint()))
+	}
+
+	sort.Strings(deps)
+
+	id := fmt.Sprintf("%s:%s;%s;%s", pkg.Name(), pkg.Fingerprint(), strings.Join(deps, ","), stemcell)
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(id)))
+}
+
+type compiledPackageCacheEntrySorting []CompiledPackageCacheEntry
+
+func (s compiledPackageCacheEntrySorting) Len() int      { return len(s) }
+func (s compiledPackageCacheEntrySorting) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s compiledPackageCacheEntrySorting) Less(i, j int) bool {
+	if s[i].Name != s[j].Name {
+		return s[i].Name < s[j].Name
+	}
+
+	return s[i].Key < s[j].Key
+}
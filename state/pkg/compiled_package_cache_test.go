@@ -0,0 +1,280 @@
+package pkg_test
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshrelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/state/pkg"
+)
+
+var _ = Describe("CompiledPackageCache", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		compressor  *fakecmd.FakeCompressor
+		timeService *fakeclock.FakeClock
+		cache       CompiledPackageCache
+
+		dep *boshrelpkg.Package
+		pkg *boshrelpkg.Package
+
+		compiledSHA1 string
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		compressor = fakecmd.NewFakeCompressor()
+		timeService = fakeclock.NewFakeClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+		cache = NewCompiledPackageCache("/cache", fs, compressor, timeService)
+
+		dep = newPkg("dep-name", "dep-fp", nil)
+		pkg = newPkg("pkg-name", "pkg-fp", []string{"dep-name"})
+		pkg.AttachDependencies([]*boshrelpkg.Package{dep})
+
+		fs.WriteFileString("/compiled.tgz", "compiled-content")
+		compiledSHA1 = fmt.Sprintf("%x", sha1.Sum([]byte("compiled-content")))
+	})
+
+	Describe("Save/Find/Fetch", func() {
+		It("finds saved package for the same stemcell", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entry, found, err := cache.Find(pkg, "stemcell/1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(entry.Name).To(Equal("pkg-name"))
+			Expect(entry.Fingerprint).To(Equal("pkg-fp"))
+			Expect(entry.Stemcell).To(Equal("stemcell/1"))
+			Expect(entry.SHA1).To(Equal(compiledSHA1))
+
+			err = cache.Fetch(entry, "/fetched.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString("/fetched.tgz")).To(Equal("compiled-content"))
+
+			Expect(fs.FileExists(filepath.Join("/cache", entry.Key+".tgz"))).To(BeTrue())
+			Expect(fs.FileExists(filepath.Join("/cache", entry.Key+".json"))).To(BeTrue())
+		})
+
+		It("does not find package compiled for different stemcell", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := cache.Find(pkg, "stemcell/2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not find package compiled against different dependency fingerprints", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			otherDep := newPkg("dep-name", "other-dep-fp", nil)
+			otherPkg := newPkg("pkg-name", "pkg-fp", []string{"dep-name"})
+			otherPkg.AttachDependencies([]*boshrelpkg.Package{otherDep})
+
+			_, found, err := cache.Find(otherPkg, "stemcell/1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not modify cache when package is found", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			metadataPath := filepath.Join("/cache", entries[0].Key+".json")
+
+			metadata, err := fs.ReadFileString(metadataPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			writes := fs.WriteFileCallCount
+			timeService.Increment(time.Hour)
+
+			_, found, err := cache.Find(pkg, "stemcell/1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(fs.WriteFileCallCount).To(Equal(writes))
+			Expect(fs.ReadFileString(metadataPath)).To(Equal(metadata))
+		})
+
+		It("keeps entries saved by different cache instances sharing same location", func() {
+			otherCache := NewCompiledPackageCache("/cache", fs, compressor, timeService)
+
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = otherCache.Save(pkg, "stemcell/2", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			_, found, err := otherCache.Find(pkg, "stemcell/1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+		})
+
+		It("returns error if fetched package does not match its digest", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entry, _, err := cache.Find(pkg, "stemcell/1")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString(filepath.Join("/cache", entry.Key+".tgz"), "tampered-content")
+
+			err = cache.Fetch(entry, "/fetched.tgz")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying compiled package 'pkg-name/pkg-fp' from cache"))
+			Expect(fs.FileExists("/fetched.tgz")).To(BeFalse())
+		})
+
+		It("returns error if entry cannot be read", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString(filepath.Join("/cache", entries[0].Key+".json"), "-")
+
+			_, _, err = cache.Find(pkg, "stemcell/1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling compiled package cache entry"))
+		})
+	})
+
+	Describe("List", func() {
+		It("ignores objects that are not cache entries", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString("/cache/other.json", "-")
+			fs.WriteFileString("/cache/nested/"+fmt.Sprintf("%064d", 0)+".json", "-")
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name).To(Equal("pkg-name"))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes package and its metadata", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = cache.Delete(entries[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(filepath.Join("/cache", entries[0].Key+".tgz"))).To(BeFalse())
+			Expect(fs.FileExists(filepath.Join("/cache", entries[0].Key+".json"))).To(BeFalse())
+			Expect(cache.List()).To(BeEmpty())
+		})
+	})
+
+	Describe("Export/Import", func() {
+		It("imports packages exported from another cache", func() {
+			err := cache.Save(pkg, "stemcell/1", "/compiled.tgz", compiledSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			exportedFiles := map[string]string{}
+
+			compressor.CompressFilesInDirTarballPath = "/export.tgz"
+			compressor.CompressFilesInDirCallBack = func() {
+				for _, name := range []string{"index.json", entries[0].Key + ".tgz"} {
+					exportedFiles[name], _ = fs.ReadFileString(filepath.Join(compressor.CompressFilesInDirDir, name))
+				}
+				fs.WriteFileString("/export.tgz", "tarball")
+			}
+
+			exported, err := cache.Export("/exported.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exported).To(HaveLen(1))
+			Expect(fs.ReadFileString("/exported.tgz")).To(Equal("tarball"))
+			Expect(exportedFiles).To(HaveLen(2))
+			Expect(compressor.CleanUpTarballPath).To(Equal("/export.tgz"))
+
+			otherCache := NewCompiledPackageCache("/other-cache", fs, compressor, timeService)
+
+			compressor.DecompressFileToDirCallBack = func() {
+				dir := compressor.DecompressFileToDirDirs[len(compressor.DecompressFileToDirDirs)-1]
+				for name, contents := range exportedFiles {
+					fs.WriteFileString(filepath.Join(dir, name), contents)
+				}
+			}
+
+			imported, err := otherCache.Import("/exported.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imported).To(Equal(exported))
+
+			entry, found, err := otherCache.Find(pkg, "stemcell/1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			err = otherCache.Fetch(entry, "/fetched.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString("/fetched.tgz")).To(Equal("compiled-content"))
+
+			imported, err = otherCache.Import("/exported.tgz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imported).To(BeEmpty())
+		})
+
+		Context("when tarball contains invalid entries", func() {
+			importIndex := func(index string, files map[string]string) ([]CompiledPackageCacheEntry, error) {
+				compressor.DecompressFileToDirCallBack = func() {
+					dir := compressor.DecompressFileToDirDirs[len(compressor.DecompressFileToDirDirs)-1]
+					fs.WriteFileString(filepath.Join(dir, "index.json"), index)
+					for name, contents := range files {
+						fs.WriteFileString(filepath.Join(dir, name), contents)
+					}
+				}
+
+				return cache.Import("/exported.tgz")
+			}
+
+			It("rejects keys that are not SHA256 hex digests", func() {
+				fs.WriteFileString("/outside.tgz", "compiled-content")
+
+				_, err := importIndex(fmt.Sprintf(`{"entries":[{"key":"../../outside","name":"pkg-name","sha1":"%s"}]}`, compiledSHA1), nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("key '../../outside' to be a SHA256 hex digest"))
+
+				Expect(cache.List()).To(BeEmpty())
+			})
+
+			It("rejects packages that do not match their digest", func() {
+				key := fmt.Sprintf("%064d", 1)
+
+				_, err := importIndex(
+					fmt.Sprintf(`{"entries":[{"key":"%s","name":"pkg-name","fingerprint":"pkg-fp","sha1":"%s"}]}`, key, compiledSHA1),
+					map[string]string{key + ".tgz": "tampered-content"},
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Verifying compiled package 'pkg-name/pkg-fp' from cache"))
+
+				Expect(cache.List()).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -22,13 +23,19 @@ type CompiledPackageRepo interface {
 
 type compiledPackageRepo struct {
 	index biindex.Index
+
+	// lock allows packages to be saved and found while compiling in parallel
+	lock *sync.Mutex
 }
 
 func NewCompiledPackageRepo(index biindex.Index) CompiledPackageRepo {
-	return &compiledPackageRepo{index: index}
+	return &compiledPackageRepo{index: index, lock: &sync.Mutex{}}
 }
 
 func (cpr *compiledPackageRepo) Save(pkg birelpkg.Compilable, record CompiledPackageRecord) error {
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+
 	err := cpr.index.Save(cpr.pkgKey(pkg), record)
 
 	if err != nil {
@@ -39,6 +46,9 @@ func (cpr *compiledPackageRepo) Save(pkg birelpkg.Compilable, record CompiledPac
 }
 
 func (cpr *compiledPackageRepo) Find(pkg birelpkg.Compilable) (CompiledPackageRecord, bool, error) {
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+
 	var record CompiledPackageRecord
 
 	err := cpr.index.Find(cpr.pkgKey(pkg), &record)
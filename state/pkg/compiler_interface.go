@@ -7,3 +7,9 @@ import (
 type Compiler interface {
 	Compile(birelpkg.Compilable) (CompiledPackageRecord, bool, error)
 }
+
+// CancelableCompiler is implemented by compilers that can terminate compilations in progress
+type CancelableCompiler interface {
+	Compiler
+	Cancel()
+}
@@ -16,6 +16,8 @@ type manifest struct {
 	OS              string `yaml:"operating_system"`
 	SHA1            string
 	BoshProtocol    string                      `yaml:"bosh_protocol"`
+	StemcellFormats []string                    `yaml:"stemcell_formats"`
+	APIVersion      int                         `yaml:"api_version"`
 	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
 }
 
@@ -54,11 +56,13 @@ func (s reader) Read(stemcellTarballPath string, extractedPath string) (Extracte
 	}
 
 	manifest := Manifest{
-		Name:         rawManifest.Name,
-		Version:      rawManifest.Version,
-		OS:           rawManifest.OS,
-		SHA1:         rawManifest.SHA1,
-		BoshProtocol: rawManifest.BoshProtocol,
+		Name:            rawManifest.Name,
+		Version:         rawManifest.Version,
+		OS:              rawManifest.OS,
+		SHA1:            rawManifest.SHA1,
+		BoshProtocol:    rawManifest.BoshProtocol,
+		StemcellFormats: rawManifest.StemcellFormats,
+		APIVersion:      rawManifest.APIVersion,
 	}
 
 	cloudProperties, err := biproperty.BuildMap(rawManifest.CloudProperties)
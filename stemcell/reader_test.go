@@ -30,6 +30,9 @@ version: '2690'
 operating_system: ubuntu-trusty
 sha1: sha
 bosh_protocol: 1
+stemcell_formats:
+- aws-raw
+api_version: 2
 cloud_properties:
   infrastructure: aws
   ami:
@@ -50,11 +53,13 @@ cloud_properties:
 		Expect(err).ToNot(HaveOccurred())
 		expectedStemcell := NewExtractedStemcell(
 			Manifest{
-				Name:         "fake-stemcell-name",
-				Version:      "2690",
-				OS:           "ubuntu-trusty",
-				SHA1:         "sha",
-				BoshProtocol: "1",
+				Name:            "fake-stemcell-name",
+				Version:         "2690",
+				OS:              "ubuntu-trusty",
+				SHA1:            "sha",
+				BoshProtocol:    "1",
+				StemcellFormats: []string{"aws-raw"},
+				APIVersion:      2,
 				CloudProperties: biproperty.Map{
 					"infrastructure": "aws",
 					"ami": biproperty.Map{
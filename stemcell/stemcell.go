@@ -125,5 +125,6 @@ type Manifest struct {
 	SHA1            string         `yaml:"sha1"`
 	BoshProtocol    string         `yaml:"bosh_protocol"`
 	StemcellFormats []string       `yaml:"stemcell_formats"`
+	APIVersion      int            `yaml:"api_version,omitempty"`
 	CloudProperties biproperty.Map `yaml:"cloud_properties"`
 }
@@ -0,0 +1,154 @@
+package erbrenderer
+
+// erbNode is a node of a parsed ERB template
+type erbNode interface{}
+
+type (
+	erbTextNode struct{ Text string }
+
+	erbOutputNode struct {
+		Expr erbNode
+		Line int
+	}
+
+	erbSeqNode struct{ Stmts []erbNode }
+
+	erbLitNode struct{ Val interface{} }
+
+	erbStrNode struct{ Parts []erbNode }
+
+	erbSymbolNode struct{ Parts []erbNode }
+
+	erbRegexpNode struct {
+		Parts []erbNode
+		Flags string
+	}
+
+	erbArrayNode struct{ Elems []erbNode }
+
+	erbHashNode struct{ Keys, Vals []erbNode }
+
+	erbRangeNode struct {
+		From, To  erbNode
+		Exclusive bool
+	}
+
+	erbSelfNode struct{}
+
+	// erbVarNode is either a local variable or a method call without arguments
+	erbVarNode struct {
+		Name string
+		Line int
+	}
+
+	erbIVarNode struct{ Name string }
+
+	erbGVarNode struct{ Name string }
+
+	erbConstNode struct {
+		Scope erbNode
+		Name  string
+	}
+
+	erbCallNode struct {
+		Recv     erbNode // nil for calls on self
+		Name     string
+		Args     []erbNode
+		BlockArg erbNode // &:sym
+		Block    *erbBlockNode
+		SafeNav  bool
+		Line     int
+	}
+
+	erbBlockParam struct {
+		Name   string
+		Nested []erbBlockParam // destructuring, e.g. |(k, v), i|
+		Splat  bool
+	}
+
+	erbBlockNode struct {
+		Params []erbBlockParam
+		Body   erbNode
+	}
+
+	erbSplatNode struct{ Expr erbNode }
+
+	erbAssignNode struct {
+		Target erbNode
+		Value  erbNode
+	}
+
+	erbMultiAssignNode struct {
+		Targets []erbNode
+		Value   erbNode
+	}
+
+	erbOpAssignNode struct {
+		Target erbNode
+		Op     string
+		Value  erbNode
+	}
+
+	erbAndNode struct{ Left, Right erbNode }
+
+	erbOrNode struct{ Left, Right erbNode }
+
+	erbNotNode struct{ Expr erbNode }
+
+	erbDefinedNode struct{ Expr erbNode }
+
+	erbIfNode struct {
+		Cond       erbNode
+		Then, Else erbNode
+	}
+
+	erbWhenClause struct {
+		Conds []erbNode
+		Body  erbNode
+	}
+
+	erbCaseNode struct {
+		Subject erbNode
+		Whens   []erbWhenClause
+		Else    erbNode
+	}
+
+	erbWhileNode struct {
+		Cond  erbNode
+		Body  erbNode
+		Until bool
+	}
+
+	erbForNode struct {
+		Params []erbBlockParam
+		Iter   erbNode
+		Body   erbNode
+	}
+
+	erbBreakNode struct{ Value erbNode }
+
+	erbNextNode struct{ Value erbNode }
+
+	erbReturnNode struct{ Value erbNode }
+
+	erbDefParam struct {
+		Name    string
+		Default erbNode // optional parameters, e.g. 'port = 80'
+		Splat   bool
+		Block   bool
+	}
+
+	// erbDefNode defines helper method on template context
+	erbDefNode struct {
+		Name   string
+		Params []erbDefParam
+		Body   erbNode
+	}
+
+	erbBeginNode struct {
+		Body      erbNode
+		RescueVar string
+		Rescue    erbNode
+		Ensure    erbNode
+	}
+)
@@ -0,0 +1,436 @@
+package erbrenderer
+
+import (
+	"strings"
+)
+
+// erbContextMethods lists methods templates may call without receiver
+var erbContextMethods = map[string]struct{}{
+	"name": {}, "index": {}, "properties": {}, "raw_properties": {}, "spec": {},
+	"p": {}, "if_p": {}, "link": {}, "if_link": {}, "get_binding": {},
+}
+
+// erbContext mirrors TemplateEvaluationContext from template_evaluation_context_rb.go
+type erbContext struct {
+	name          interface{}
+	index         interface{}
+	properties    interface{}
+	rawProperties *erbHash
+	spec          interface{}
+	links         interface{}
+}
+
+func newERBContext(spec interface{}) (*erbContext, error) {
+	specHash, ok := spec.(*erbHash)
+	if !ok {
+		return nil, newERBError("TypeError", 0, "expected context to be a Hash, got %s", erbClassName(spec))
+	}
+
+	ctx := &erbContext{index: specHash.val("index")}
+
+	if job, ok := specHash.val("job").(*erbHash); ok {
+		ctx.name = job.val("name")
+	}
+
+	var properties1 interface{}
+	if jobProperties := specHash.val("job_properties"); jobProperties != nil {
+		properties1 = jobProperties
+	} else {
+		globalProperties, ok := specHash.val("global_properties").(*erbHash)
+		if !ok {
+			return nil, newERBError("NoMethodError", 0, "%s", erbUndefinedMethodMsg("recursive_merge!", specHash.val("global_properties")))
+		}
+		properties1 = erbRecursiveMerge(globalProperties, specHash.val("cluster_properties"))
+	}
+
+	properties := newERBHash()
+
+	if defaults, ok := specHash.val("default_properties").(*erbHash); ok {
+		for _, name := range defaults.Keys() {
+			erbCopyProperty(properties, properties1, erbToS(name), defaults.val(name))
+		}
+	}
+
+	ctx.properties = erbToOpenStruct(properties)
+	ctx.rawProperties = properties
+	ctx.spec = erbToOpenStruct(specHash)
+	ctx.links = specHash.val("links")
+
+	return ctx, nil
+}
+
+func (c *erbContext) ivars() map[string]interface{} {
+	return map[string]interface{}{
+		"@name":           c.name,
+		"@index":          c.index,
+		"@properties":     c.properties,
+		"@raw_properties": c.rawProperties,
+		"@spec":           c.spec,
+		"@links":          c.links,
+	}
+}
+
+func (c *erbContext) ClassName() string { return "TemplateEvaluationContext" }
+
+func (c *erbContext) CallMethod(i *erbInterpreter, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	var result interface{}
+
+	switch name {
+	case "name":
+		result = c.name
+	case "index":
+		result = c.index
+	case "properties":
+		result = c.properties
+	case "raw_properties":
+		result = c.rawProperties
+	case "spec":
+		result = c.spec
+	case "get_binding":
+		result = c
+
+	case "p":
+		result, err := erbLookupP(i, c.rawProperties, args)
+		return result, true, err
+
+	case "if_p":
+		result, err := erbLookupIfP(i, c, c.rawProperties, args, blk)
+		return result, true, err
+
+	case "link":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		linkSpec, err := c.lookupLink(i, args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if linkSpec == nil {
+			return nil, true, newERBError("TemplateEvaluationContext::UnknownLink", 0, "Can't find link '%s'", erbToS(args[0]))
+		}
+		link, err := newERBLink(i, linkSpec)
+		return link, true, err
+
+	case "if_link":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		if c.links == nil {
+			return false, true, nil
+		}
+		linkSpec, err := c.lookupLink(i, args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if linkSpec == nil {
+			return &erbElseBlock{active: true, ctx: c}, true, nil
+		}
+		link, err := newERBLink(i, linkSpec)
+		if err != nil {
+			return nil, true, err
+		}
+		if _, err := i.callProc(blk, link); err != nil {
+			return nil, true, err
+		}
+		return &erbElseBlock{}, true, nil
+
+	default:
+		return nil, false, nil
+	}
+
+	if err := erbArgc(args, 0, 0); err != nil {
+		return nil, true, err
+	}
+
+	return result, true, nil
+}
+
+func (c *erbContext) lookupLink(i *erbInterpreter, name interface{}) (interface{}, error) {
+	if c.links == nil {
+		return nil, nil
+	}
+
+	return i.callMethod(c.links, "[]", []interface{}{name}, nil)
+}
+
+func erbRecursiveMerge(dst *erbHash, src interface{}) *erbHash {
+	srcHash, ok := src.(*erbHash)
+	if !ok {
+		return dst
+	}
+
+	for _, key := range srcHash.Keys() {
+		newVal := srcHash.val(key)
+
+		oldHash, oldIsHash := dst.val(key).(*erbHash)
+		newHash, newIsHash := newVal.(*erbHash)
+
+		if oldIsHash && newIsHash {
+			dst.Set(key, erbRecursiveMerge(oldHash, newHash))
+		} else {
+			dst.Set(key, newVal)
+		}
+	}
+
+	return dst
+}
+
+func erbCopyProperty(dst *erbHash, src interface{}, name string, defaultVal interface{}) {
+	keys := strings.Split(name, ".")
+
+	srcRef := src
+	for _, key := range keys {
+		srcHash, ok := srcRef.(*erbHash)
+		if !ok {
+			srcRef = nil
+			break
+		}
+		srcRef = srcHash.val(key)
+		if srcRef == nil {
+			break
+		}
+	}
+
+	dstRef := dst
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := dstRef.val(key).(*erbHash)
+		if !ok {
+			next = newERBHash()
+			dstRef.Set(key, next)
+		}
+		dstRef = next
+	}
+
+	if srcRef == nil {
+		dstRef.Set(keys[len(keys)-1], defaultVal)
+	} else {
+		dstRef.Set(keys[len(keys)-1], srcRef)
+	}
+}
+
+func erbToOpenStruct(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case *erbHash:
+		fields := newERBHash()
+		for _, key := range typedVal.Keys() {
+			fields.Set(erbToS(key), erbToOpenStruct(typedVal.val(key)))
+		}
+		return &erbOpenStruct{Fields: fields}
+	case *erbArray:
+		arr := newERBArray()
+		for _, item := range typedVal.Items {
+			arr.Items = append(arr.Items, erbToOpenStruct(item))
+		}
+		return arr
+	default:
+		return val
+	}
+}
+
+func erbLookupProperty(i *erbInterpreter, collection interface{}, name interface{}) (interface{}, error) {
+	keys, err := i.callMethod(name, "split", []interface{}{"."}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := collection
+
+	for _, key := range keys.(*erbArray).Items {
+		ref, err = i.callMethod(ref, "[]", []interface{}{key}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if ref == nil {
+			return nil, nil
+		}
+	}
+
+	return ref, nil
+}
+
+func erbLookupP(i *erbInterpreter, collection interface{}, args []interface{}) (interface{}, error) {
+	var firstArg interface{}
+	if len(args) > 0 {
+		firstArg = args[0]
+	}
+
+	names, err := i.toA(firstArg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		result, err := erbLookupProperty(i, collection, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if result != nil {
+			return result, nil
+		}
+	}
+
+	if len(args) == 2 {
+		return args[1], nil
+	}
+
+	var strNames []string
+	for _, name := range names {
+		strNames = append(strNames, erbToS(name))
+	}
+
+	return nil, newERBError("TemplateEvaluationContext::UnknownProperty", 0, "Can't find property '%s'", strings.Join(strNames, "', or '"))
+}
+
+func erbLookupIfP(i *erbInterpreter, ctx erbObject, collection interface{}, names []interface{}, blk *erbProc) (interface{}, error) {
+	var values []interface{}
+
+	for _, name := range names {
+		value, err := erbLookupProperty(i, collection, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			return &erbElseBlock{active: true, ctx: ctx}, nil
+		}
+
+		values = append(values, value)
+	}
+
+	if _, err := i.callProc(blk, values...); err != nil {
+		return nil, err
+	}
+
+	return &erbElseBlock{}, nil
+}
+
+// erbElseBlock mirrors ActiveElseBlock and InactiveElseBlock
+type erbElseBlock struct {
+	active bool
+	ctx    erbObject
+}
+
+func (b *erbElseBlock) ClassName() string {
+	if b.active {
+		return "TemplateEvaluationContext::ActiveElseBlock"
+	}
+	return "TemplateEvaluationContext::InactiveElseBlock"
+}
+
+func (b *erbElseBlock) CallMethod(i *erbInterpreter, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "else":
+		if !b.active {
+			return nil, true, nil
+		}
+		result, err := i.callProc(blk)
+		return result, true, err
+
+	case "else_if_p":
+		if !b.active {
+			return &erbElseBlock{}, true, nil
+		}
+		return b.ctx.CallMethod(i, "if_p", args, blk)
+	}
+
+	return nil, false, nil
+}
+
+// erbLink mirrors TemplateEvaluationContext::EvaluationLink
+type erbLink struct {
+	properties interface{}
+	address    interface{}
+	instances  *erbArray
+}
+
+func newERBLink(i *erbInterpreter, linkSpec interface{}) (*erbLink, error) {
+	get := func(key string) (interface{}, error) {
+		return i.callMethod(linkSpec, "[]", []interface{}{key}, nil)
+	}
+
+	properties, err := get("properties")
+	if err != nil {
+		return nil, err
+	}
+
+	if properties == nil {
+		properties = newERBHash()
+	}
+
+	address, err := get("address")
+	if err != nil {
+		return nil, err
+	}
+
+	instanceSpecs, err := get("instances")
+	if err != nil {
+		return nil, err
+	}
+
+	instances := newERBArray()
+
+	if instanceSpecs != nil {
+		items, err := i.toA(instanceSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instanceSpec := range items {
+			instance := &erbLinkInstance{attrs: map[string]interface{}{}}
+
+			for _, attr := range erbLinkInstanceAttrs {
+				val, err := i.callMethod(instanceSpec, "[]", []interface{}{attr}, nil)
+				if err != nil {
+					return nil, err
+				}
+				instance.attrs[attr] = val
+			}
+
+			instances.Items = append(instances.Items, instance)
+		}
+	}
+
+	return &erbLink{properties: properties, address: address, instances: instances}, nil
+}
+
+func (l *erbLink) ClassName() string { return "TemplateEvaluationContext::EvaluationLink" }
+
+func (l *erbLink) CallMethod(i *erbInterpreter, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "instances":
+		return l.instances, true, erbArgc(args, 0, 0)
+	case "properties":
+		return l.properties, true, erbArgc(args, 0, 0)
+	case "address":
+		return l.address, true, nil
+	case "p":
+		result, err := erbLookupP(i, l.properties, args)
+		return result, true, err
+	case "if_p":
+		result, err := erbLookupIfP(i, l, l.properties, args, blk)
+		return result, true, err
+	}
+
+	return nil, false, nil
+}
+
+var erbLinkInstanceAttrs = []string{"name", "index", "id", "az", "address", "bootstrap"}
+
+// erbLinkInstance mirrors TemplateEvaluationContext::EvaluationLinkInstance
+type erbLinkInstance struct {
+	attrs map[string]interface{}
+}
+
+func (l *erbLinkInstance) ClassName() string {
+	return "TemplateEvaluationContext::EvaluationLinkInstance"
+}
+
+func (l *erbLinkInstance) CallMethod(i *erbInterpreter, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	if val, found := l.attrs[name]; found {
+		return val, true, erbArgc(args, 0, 0)
+	}
+
+	return nil, false, nil
+}
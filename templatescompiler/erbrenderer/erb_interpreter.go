@@ -0,0 +1,1075 @@
+package erbrenderer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// erbError mirrors Ruby exceptions raised while evaluating templates
+type erbError struct {
+	Class string
+	Msg   string
+	Line  int
+}
+
+func newERBError(class string, line int, format string, args ...interface{}) *erbError {
+	return &erbError{Class: class, Msg: fmt.Sprintf(format, args...), Line: line}
+}
+
+func (e *erbError) Error() string {
+	return fmt.Sprintf("#<%s: %s>", e.Class, e.Msg)
+}
+
+// erbBreak and erbNext are used to unwind evaluation for 'break' and 'next'
+type erbBreak struct{ Val interface{} }
+
+func (erbBreak) Error() string { return "break from proc-closure" }
+
+type erbNext struct{ Val interface{} }
+
+func (erbNext) Error() string { return "next used outside of block" }
+
+type erbReturn struct{ Val interface{} }
+
+func (erbReturn) Error() string { return "unexpected return" }
+
+type erbEnv struct {
+	vars   map[string]interface{}
+	parent *erbEnv
+}
+
+func newERBEnv(parent *erbEnv) *erbEnv {
+	return &erbEnv{vars: map[string]interface{}{}, parent: parent}
+}
+
+func (e *erbEnv) Lookup(name string) (interface{}, bool) {
+	for env := e; env != nil; env = env.parent {
+		if val, found := env.vars[name]; found {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Set assigns existing variable in closest scope or defines new one
+func (e *erbEnv) Set(name string, val interface{}) {
+	for env := e; env != nil; env = env.parent {
+		if _, found := env.vars[name]; found {
+			env.vars[name] = val
+			return
+		}
+	}
+	e.vars[name] = val
+}
+
+func (e *erbEnv) Define(name string, val interface{}) {
+	e.vars[name] = val
+}
+
+type erbInterpreter struct {
+	self  erbObject
+	ivars map[string]interface{}
+	out   *bytes.Buffer
+	line  int
+
+	lastMatch *erbMatchData
+
+	methods map[string]erbDefNode
+	blocks  []*erbProc // blocks passed to methods defined in template
+}
+
+func newERBInterpreter(self erbObject, ivars map[string]interface{}) *erbInterpreter {
+	return &erbInterpreter{self: self, ivars: ivars, out: &bytes.Buffer{}, methods: map[string]erbDefNode{}}
+}
+
+func (i *erbInterpreter) Run(tpl erbTemplate) (string, error) {
+	_, err := i.eval(tpl.body, newERBEnv(nil))
+	if _, ok := err.(erbReturn); ok {
+		err = nil
+	}
+	if err != nil {
+		switch typedErr := err.(type) {
+		case erbBreak, erbNext:
+			return "", newERBError("SyntaxError", i.line, "%s", typedErr.Error())
+		}
+		return "", err
+	}
+
+	return i.out.String(), nil
+}
+
+// errorf creates an error at currently evaluated line
+func (i *erbInterpreter) errorf(class string, format string, args ...interface{}) error {
+	return newERBError(class, i.line, format, args...)
+}
+
+func (i *erbInterpreter) withLine(err error) error {
+	if typedErr, ok := err.(*erbError); ok && typedErr.Line == 0 {
+		typedErr.Line = i.line
+	}
+	return err
+}
+
+func (i *erbInterpreter) eval(node erbNode, env *erbEnv) (interface{}, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+
+	case *erbSeqNode:
+		var last interface{}
+		for _, stmt := range n.Stmts {
+			val, err := i.eval(stmt, env)
+			if err != nil {
+				return nil, err
+			}
+			last = val
+		}
+		return last, nil
+
+	case erbTextNode:
+		i.out.WriteString(n.Text)
+		return nil, nil
+
+	case erbOutputNode:
+		i.line = n.Line
+
+		val, err := i.eval(n.Expr, env)
+		if err != nil {
+			return nil, err
+		}
+
+		str, err := i.toS(val)
+		if err != nil {
+			return nil, err
+		}
+
+		i.out.WriteString(str)
+		return nil, nil
+
+	case erbLitNode:
+		// Each evaluation of string literal creates new String object
+		return erbStringObj(n.Val), nil
+
+	case erbStrNode:
+		str, err := i.evalStr(n.Parts, env)
+		if err != nil {
+			return nil, err
+		}
+		return erbStringObj(str), nil
+
+	case erbSymbolNode:
+		str, err := i.evalStr(n.Parts, env)
+		if err != nil {
+			return nil, err
+		}
+		return erbSymbol(str), nil
+
+	case erbRegexpNode:
+		source, err := i.evalStr(n.Parts, env)
+		if err != nil {
+			return nil, err
+		}
+
+		re, err := newERBRegexp(source, n.Flags)
+		return re, i.withLine(err)
+
+	case erbArrayNode:
+		items, err := i.evalArgs(n.Elems, env)
+		if err != nil {
+			return nil, err
+		}
+		return newERBArray(items...), nil
+
+	case erbHashNode:
+		return i.evalHash(n, env)
+
+	case *erbHashNode:
+		return i.evalHash(*n, env)
+
+	case erbRangeNode:
+		from, err := i.eval(n.From, env)
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := i.eval(n.To, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return &erbRange{From: erbUnwrap(from), To: erbUnwrap(to), Exclusive: n.Exclusive}, nil
+
+	case erbSelfNode:
+		return i.self, nil
+
+	case erbVarNode:
+		if val, found := env.Lookup(n.Name); found {
+			return val, nil
+		}
+
+		i.line = n.Line
+
+		val, err := i.callFunction(n.Name, nil, nil, env)
+		if typedErr, ok := err.(*erbError); ok && typedErr.Class == "NoMethodError" && typedErr.Msg == erbUndefinedMethodMsg(n.Name, i.self) {
+			return nil, i.errorf("NameError", "undefined local variable or method `%s' for %s", n.Name, erbUndefinedMethodRecv(i.self))
+		}
+
+		return val, err
+
+	case erbIVarNode:
+		return i.ivars[n.Name], nil
+
+	case erbGVarNode:
+		if i.lastMatch == nil {
+			return nil, nil
+		}
+		if n.Name == "$~" {
+			return i.lastMatch, nil
+		}
+		result, _, err := i.matchDataMethod(i.lastMatch, "[]", []interface{}{erbLenientInt(n.Name[1:], 10)})
+		return result, err
+
+	case erbConstNode:
+		return i.evalConst(n, env)
+
+	case *erbCallNode:
+		return i.evalCall(n, env)
+
+	case erbAssignNode:
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		val = erbStringObj(val)
+		return val, i.assign(n.Target, val, env)
+
+	case erbMultiAssignNode:
+		return i.evalMultiAssign(n, env)
+
+	case erbOpAssignNode:
+		return i.evalOpAssign(n, env)
+
+	case erbAndNode:
+		left, err := i.eval(n.Left, env)
+		if err != nil || !erbTruthy(left) {
+			return left, err
+		}
+		return i.eval(n.Right, env)
+
+	case erbOrNode:
+		left, err := i.eval(n.Left, env)
+		if err != nil || erbTruthy(left) {
+			return left, err
+		}
+		return i.eval(n.Right, env)
+
+	case erbNotNode:
+		val, err := i.eval(n.Expr, env)
+		if err != nil {
+			return nil, err
+		}
+		return !erbTruthy(val), nil
+
+	case erbDefinedNode:
+		return i.evalDefined(n, env)
+
+	case erbIfNode:
+		cond, err := i.eval(n.Cond, env)
+		if err != nil {
+			return nil, err
+		}
+		if erbTruthy(cond) {
+			return i.eval(n.Then, env)
+		}
+		return i.eval(n.Else, env)
+
+	case erbCaseNode:
+		return i.evalCase(n, env)
+
+	case erbWhileNode:
+		return i.evalWhile(n, env)
+
+	case erbForNode:
+		return i.evalFor(n, env)
+
+	case erbBreakNode:
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		return nil, erbBreak{Val: val}
+
+	case erbReturnNode:
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		return nil, erbReturn{Val: val}
+
+	case erbDefNode:
+		i.methods[n.Name] = n
+		return erbSymbol(n.Name), nil
+
+	case erbNextNode:
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		return nil, erbNext{Val: val}
+
+	case erbBeginNode:
+		return i.evalBegin(n, env)
+
+	case erbSplatNode:
+		return i.eval(n.Expr, env)
+	}
+
+	return nil, i.errorf("NotImplementedError", "unsupported expression %T", node)
+}
+
+func (i *erbInterpreter) toS(val interface{}) (string, error) {
+	// Objects may define their own to_s
+	if obj, ok := val.(erbObject); ok {
+		result, found, err := obj.CallMethod(i, "to_s", nil, nil)
+		if err != nil || found {
+			str, _ := result.(string)
+			return str, err
+		}
+	}
+	return erbToS(val), nil
+}
+
+func (i *erbInterpreter) evalStr(parts []erbNode, env *erbEnv) (string, error) {
+	var buf bytes.Buffer
+
+	for _, part := range parts {
+		val, err := i.eval(part, env)
+		if err != nil {
+			return "", err
+		}
+
+		str, err := i.toS(val)
+		if err != nil {
+			return "", err
+		}
+
+		buf.WriteString(str)
+	}
+
+	return buf.String(), nil
+}
+
+func (i *erbInterpreter) evalHash(n erbHashNode, env *erbEnv) (interface{}, error) {
+	hash := newERBHash()
+
+	for idx := range n.Keys {
+		key, err := i.eval(n.Keys[idx], env)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := i.eval(n.Vals[idx], env)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Set(key, val)
+	}
+
+	return hash, nil
+}
+
+// evalArgs evaluates arguments expanding splats
+func (i *erbInterpreter) evalArgs(nodes []erbNode, env *erbEnv) ([]interface{}, error) {
+	var args []interface{}
+
+	for _, node := range nodes {
+		val, err := i.eval(node, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, isSplat := node.(erbSplatNode); isSplat {
+			items, err := i.toA(val)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, items...)
+			continue
+		}
+
+		args = append(args, val)
+	}
+
+	return args, nil
+}
+
+func (i *erbInterpreter) toA(val interface{}) ([]interface{}, error) {
+	switch typedVal := val.(type) {
+	case nil:
+		return nil, nil
+	case *erbArray:
+		return typedVal.Items, nil
+	case *erbHash:
+		return typedVal.Pairs(), nil
+	case *erbRange:
+		items, err := typedVal.Items()
+		return items, i.withLine(err)
+	default:
+		return []interface{}{val}, nil
+	}
+}
+
+func (i *erbInterpreter) evalConst(n erbConstNode, env *erbEnv) (interface{}, error) {
+	name := n.Name
+
+	if n.Scope != nil {
+		scope, err := i.eval(n.Scope, env)
+		if err != nil {
+			return nil, err
+		}
+
+		class, ok := scope.(*erbClass)
+		if !ok {
+			return nil, i.errorf("TypeError", "%s is not a class/module", erbInspect(scope))
+		}
+
+		name = class.Name + "::" + n.Name
+	}
+
+	switch name {
+	case "Float::INFINITY":
+		return math.Inf(1), nil
+	case "Float::NAN":
+		return math.NaN(), nil
+	}
+
+	if erbKnownConsts[name] {
+		return &erbClass{Name: name}, nil
+	}
+
+	return nil, i.errorf("NameError", "uninitialized constant %s", name)
+}
+
+var erbKnownConsts = map[string]bool{
+	"Object": true, "BasicObject": true, "Kernel": true, "Comparable": true, "Enumerable": true,
+	"NilClass": true, "TrueClass": true, "FalseClass": true,
+	"Numeric": true, "Integer": true, "Fixnum": true, "Bignum": true, "Float": true,
+	"String": true, "Symbol": true, "Array": true, "Hash": true, "Range": true, "Regexp": true,
+	"MatchData": true, "Proc": true, "OpenStruct": true,
+	"Exception": true, "StandardError": true, "RuntimeError": true, "ArgumentError": true,
+	"TypeError": true, "KeyError": true, "IndexError": true, "NameError": true, "NoMethodError": true,
+	"JSON": true, "YAML": true, "Psych": true, "Base64": true,
+}
+
+func (i *erbInterpreter) evalCall(n *erbCallNode, env *erbEnv) (interface{}, error) {
+	var recv interface{}
+
+	if n.Recv != nil {
+		val, err := i.eval(n.Recv, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if val == nil && n.SafeNav {
+			return nil, nil
+		}
+
+		recv = val
+	}
+
+	args, err := i.evalArgs(n.Args, env)
+	if err != nil {
+		return nil, err
+	}
+
+	blk, err := i.blockFor(n, env)
+	if err != nil {
+		return nil, err
+	}
+
+	i.line = n.Line
+
+	var result interface{}
+
+	if n.Recv == nil {
+		result, err = i.callFunction(n.Name, args, blk, env)
+	} else {
+		result, err = i.callMutatingMethod(n, recv, args, blk, env)
+	}
+
+	if brk, ok := err.(erbBreak); ok && blk != nil && blk.Body != nil {
+		return brk.Val, nil
+	}
+
+	if n.Recv != nil {
+		switch recv.(type) {
+		case nil, bool, int, float64, string, *erbString, erbSymbol:
+			// Methods of scalars return new String objects unlike
+			// methods of collections which may return their items
+			if arr, ok := result.(*erbArray); ok {
+				for idx, item := range arr.Items {
+					arr.Items[idx] = erbStringObj(item)
+				}
+			}
+			result = erbStringObj(result)
+		}
+	}
+
+	return result, i.withLine(err)
+}
+
+func (i *erbInterpreter) blockFor(n *erbCallNode, env *erbEnv) (*erbProc, error) {
+	if n.Block != nil {
+		return &erbProc{Params: n.Block.Params, Body: n.Block.Body, Env: env}, nil
+	}
+
+	if n.BlockArg == nil {
+		return nil, nil
+	}
+
+	val, err := i.eval(n.BlockArg, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typedVal := val.(type) {
+	case nil:
+		return nil, nil
+	case erbSymbol:
+		return &erbProc{Sym: string(typedVal)}, nil
+	case *erbProc:
+		return typedVal, nil
+	}
+
+	return nil, i.errorf("TypeError", "wrong argument type %s (expected Proc)", erbClassName(val))
+}
+
+// Plain Go strings are immutable, hence methods such as '<<' and 'gsub!'
+// modify String objects in place (see strObjMethod) or assign result
+// back to where plain string receiver came from
+var erbStringMutators = map[string]string{
+	"<<": "+", "concat": "+", "replace": "replace",
+	"gsub!": "gsub", "sub!": "sub", "strip!": "strip", "lstrip!": "lstrip", "rstrip!": "rstrip",
+	"chomp!": "chomp", "chop!": "chop", "upcase!": "upcase", "downcase!": "downcase",
+	"capitalize!": "capitalize", "squeeze!": "squeeze", "tr!": "tr", "reverse!": "reverse",
+	"prepend": "prepend", "insert": "insert",
+}
+
+func (i *erbInterpreter) callMutatingMethod(n *erbCallNode, recv interface{}, args []interface{}, blk *erbProc, env *erbEnv) (interface{}, error) {
+	if str, ok := recv.(string); ok {
+		if method, found := erbStringMutators[n.Name]; found {
+			result, err := i.callMethod(str, method, args, blk)
+			if err != nil {
+				return nil, err
+			}
+
+			err = i.assignBack(n.Recv, result, env)
+			if err != nil {
+				return nil, err
+			}
+
+			if strings.HasSuffix(n.Name, "!") && result == str {
+				return nil, nil
+			}
+
+			return result, nil
+		}
+	}
+
+	return i.callMethod(recv, n.Name, args, blk)
+}
+
+// assignBack emulates in-place mutation of immutable Go strings
+// by storing result where the receiver came from
+func (i *erbInterpreter) assignBack(target erbNode, val interface{}, env *erbEnv) error {
+	switch t := target.(type) {
+	case erbVarNode, erbIVarNode:
+		return i.assign(target, val, env)
+	case *erbCallNode:
+		if _, found := erbStringMutators[t.Name]; found && t.Recv != nil {
+			// Chained calls such as 's << "a" << "b"'
+			return i.assignBack(t.Recv, val, env)
+		}
+		if t.Recv != nil && t.Name == "[]" {
+			return i.assign(target, val, env)
+		}
+		if t.Recv != nil && len(t.Args) == 0 && isIdentName(t.Name) {
+			// Only attributes of OpenStruct can be written back,
+			// results of other methods are not updated
+			recv, err := i.eval(t.Recv, env)
+			if err != nil {
+				return err
+			}
+			if os, ok := recv.(*erbOpenStruct); ok {
+				os.Fields.Set(t.Name, val)
+			}
+		}
+	}
+	return nil
+}
+
+// callFunction calls method on self, e.g. 'p("prop")'
+func (i *erbInterpreter) callFunction(name string, args []interface{}, blk *erbProc, env *erbEnv) (interface{}, error) {
+	if def, found := i.methods[name]; found {
+		return i.callDef(def, args, blk)
+	}
+
+	switch name {
+	case "yield":
+		if len(i.blocks) == 0 || i.blocks[len(i.blocks)-1] == nil {
+			return nil, i.errorf("LocalJumpError", "no block given (yield)")
+		}
+		return i.callProc(i.blocks[len(i.blocks)-1], args...)
+	case "block_given?":
+		return len(i.blocks) > 0 && i.blocks[len(i.blocks)-1] != nil, nil
+	}
+
+	args = erbUnwrapAll(args)
+
+	result, found, err := i.self.CallMethod(i, name, args, blk)
+	if found || err != nil {
+		return result, err
+	}
+
+	result, found, err = i.callKernel(name, args, blk, env)
+	if found || err != nil {
+		return result, err
+	}
+
+	return i.callMethod(i.self, name, args, blk)
+}
+
+func (i *erbInterpreter) callDef(def erbDefNode, args []interface{}, blk *erbProc) (interface{}, error) {
+	env := newERBEnv(nil)
+
+	required, optional, splat := 0, 0, false
+	for _, param := range def.Params {
+		switch {
+		case param.Splat:
+			splat = true
+		case param.Block:
+		case param.Default != nil:
+			optional++
+		default:
+			required++
+		}
+	}
+
+	if len(args) < required || (!splat && len(args) > required+optional) {
+		expected := strconv.Itoa(required)
+		if splat {
+			expected += "+"
+		} else if optional > 0 {
+			expected += ".." + strconv.Itoa(required+optional)
+		}
+		return nil, i.errorf("ArgumentError", "wrong number of arguments (given %d, expected %s)", len(args), expected)
+	}
+
+	optionalGiven := len(args) - required
+	idx := 0
+
+	for _, param := range def.Params {
+		switch {
+		case param.Block:
+			var blkVal interface{}
+			if blk != nil {
+				blkVal = blk
+			}
+			env.Define(param.Name, blkVal)
+
+		case param.Splat:
+			rest := newERBArray()
+			for ; idx < len(args)-(required-i.requiredBefore(def, param.Name)); idx++ {
+				rest.Items = append(rest.Items, args[idx])
+			}
+			env.Define(param.Name, rest)
+
+		case param.Default != nil:
+			if optionalGiven > 0 {
+				env.Define(param.Name, args[idx])
+				idx++
+				optionalGiven--
+				continue
+			}
+
+			val, err := i.eval(param.Default, env)
+			if err != nil {
+				return nil, err
+			}
+			env.Define(param.Name, val)
+
+		default:
+			env.Define(param.Name, args[idx])
+			idx++
+		}
+	}
+
+	i.blocks = append(i.blocks, blk)
+	defer func() { i.blocks = i.blocks[:len(i.blocks)-1] }()
+
+	result, err := i.eval(def.Body, env)
+	if ret, ok := err.(erbReturn); ok {
+		return ret.Val, nil
+	}
+
+	return result, err
+}
+
+// requiredBefore counts required parameters before given one
+func (i *erbInterpreter) requiredBefore(def erbDefNode, name string) int {
+	count := 0
+	for _, param := range def.Params {
+		if param.Name == name {
+			break
+		}
+		if !param.Splat && !param.Block && param.Default == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (i *erbInterpreter) callProc(proc *erbProc, args ...interface{}) (interface{}, error) {
+	if proc == nil {
+		return nil, i.errorf("LocalJumpError", "no block given (yield)")
+	}
+
+	if len(proc.Sym) > 0 {
+		if len(args) == 0 {
+			return nil, i.errorf("ArgumentError", "no receiver given")
+		}
+		return i.callMethod(args[0], proc.Sym, args[1:], nil)
+	}
+
+	env := newERBEnv(proc.Env)
+
+	// Blocks destructure single array argument, e.g. 'hash.each { |k, v| }'
+	if !proc.Lambda && len(args) == 1 && len(proc.Params) > 1 {
+		if arr, ok := args[0].(*erbArray); ok {
+			args = arr.Items
+		}
+	}
+
+	i.bindParams(env, proc.Params, args)
+
+	val, err := i.eval(proc.Body, env)
+	if next, ok := err.(erbNext); ok {
+		return next.Val, nil
+	}
+
+	return val, err
+}
+
+func (i *erbInterpreter) bindParams(env *erbEnv, params []erbBlockParam, args []interface{}) {
+	for idx, param := range params {
+		var arg interface{}
+		if idx < len(args) {
+			arg = args[idx]
+		}
+
+		switch {
+		case param.Splat:
+			rest := newERBArray()
+			if idx < len(args) {
+				rest.Items = append(rest.Items, args[idx:]...)
+			}
+			env.Define(param.Name, rest)
+
+		case len(param.Nested) > 0:
+			var nested []interface{}
+			if arr, ok := arg.(*erbArray); ok {
+				nested = arr.Items
+			} else {
+				nested = []interface{}{arg}
+			}
+			i.bindParams(env, param.Nested, nested)
+
+		default:
+			env.Define(param.Name, arg)
+		}
+	}
+}
+
+func (i *erbInterpreter) assign(target erbNode, val interface{}, env *erbEnv) error {
+	switch t := target.(type) {
+	case erbVarNode:
+		env.Set(t.Name, erbStringObj(val))
+		return nil
+
+	case erbIVarNode:
+		i.ivars[t.Name] = erbStringObj(val)
+		return nil
+
+	case *erbCallNode:
+		recv, err := i.eval(t.Recv, env)
+		if err != nil {
+			return err
+		}
+
+		if t.Name == "[]" {
+			args, err := i.evalArgs(t.Args, env)
+			if err != nil {
+				return err
+			}
+
+			_, err = i.callMethod(recv, "[]=", append(args, val), nil)
+			return i.withLine(err)
+		}
+
+		_, err = i.callMethod(recv, t.Name+"=", []interface{}{val}, nil)
+		return i.withLine(err)
+
+	case erbSplatNode:
+		return i.assign(t.Expr, val, env)
+	}
+
+	return i.errorf("SyntaxError", "cannot assign to %T", target)
+}
+
+func (i *erbInterpreter) evalMultiAssign(n erbMultiAssignNode, env *erbEnv) (interface{}, error) {
+	val, err := i.eval(n.Value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []interface{}
+	if arr, ok := val.(*erbArray); ok {
+		items = arr.Items
+	} else {
+		items = []interface{}{val}
+	}
+
+	for idx, target := range n.Targets {
+		if _, isSplat := target.(erbSplatNode); isSplat {
+			rest := newERBArray()
+			if idx < len(items) {
+				rest.Items = append(rest.Items, items[idx:]...)
+			}
+
+			err = i.assign(target, rest, env)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		var item interface{}
+		if idx < len(items) {
+			item = items[idx]
+		}
+
+		err = i.assign(target, item, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return val, nil
+}
+
+func (i *erbInterpreter) evalOpAssign(n erbOpAssignNode, env *erbEnv) (interface{}, error) {
+	var current interface{}
+
+	switch t := n.Target.(type) {
+	case erbVarNode:
+		current, _ = env.Lookup(t.Name)
+	default:
+		val, err := i.eval(n.Target, env)
+		if err != nil {
+			return nil, err
+		}
+		current = val
+	}
+
+	var result interface{}
+
+	switch n.Op {
+	case "||":
+		if erbTruthy(current) {
+			return current, nil
+		}
+
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		result = val
+
+	case "&&":
+		if !erbTruthy(current) {
+			return current, nil
+		}
+
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		result = val
+
+	default:
+		val, err := i.eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err = i.callMethod(current, n.Op, []interface{}{val}, nil)
+		if err != nil {
+			return nil, i.withLine(err)
+		}
+	}
+
+	return result, i.assign(n.Target, result, env)
+}
+
+func (i *erbInterpreter) evalDefined(n erbDefinedNode, env *erbEnv) (interface{}, error) {
+	switch t := n.Expr.(type) {
+	case erbVarNode:
+		if _, found := env.Lookup(t.Name); found {
+			return "local-variable", nil
+		}
+		if _, found := erbContextMethods[t.Name]; found {
+			return "method", nil
+		}
+	case erbIVarNode:
+		if _, found := i.ivars[t.Name]; found {
+			return "instance-variable", nil
+		}
+	case erbConstNode:
+		if _, err := i.evalConst(t, env); err == nil {
+			return "constant", nil
+		}
+	default:
+		if _, err := i.eval(n.Expr, env); err == nil {
+			return "expression", nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (i *erbInterpreter) evalCase(n erbCaseNode, env *erbEnv) (interface{}, error) {
+	var subject interface{}
+
+	if n.Subject != nil {
+		val, err := i.eval(n.Subject, env)
+		if err != nil {
+			return nil, err
+		}
+		subject = val
+	}
+
+	for _, when := range n.Whens {
+		conds, err := i.evalArgs(when.Conds, env)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cond := range conds {
+			matched := erbTruthy(cond)
+
+			if n.Subject != nil {
+				result, err := i.callMethod(cond, "===", []interface{}{subject}, nil)
+				if err != nil {
+					return nil, i.withLine(err)
+				}
+				matched = erbTruthy(result)
+			}
+
+			if matched {
+				return i.eval(when.Body, env)
+			}
+		}
+	}
+
+	return i.eval(n.Else, env)
+}
+
+func (i *erbInterpreter) evalWhile(n erbWhileNode, env *erbEnv) (interface{}, error) {
+	for {
+		cond, err := i.eval(n.Cond, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if erbTruthy(cond) == n.Until {
+			return nil, nil
+		}
+
+		_, err = i.eval(n.Body, env)
+		if err != nil {
+			switch typedErr := err.(type) {
+			case erbBreak:
+				return typedErr.Val, nil
+			case erbNext:
+				continue
+			}
+			return nil, err
+		}
+	}
+}
+
+func (i *erbInterpreter) evalFor(n erbForNode, env *erbEnv) (interface{}, error) {
+	iter, err := i.eval(n.Iter, env)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := i.toA(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		args := []interface{}{item}
+		if arr, ok := item.(*erbArray); ok && len(n.Params) > 1 {
+			args = arr.Items
+		}
+
+		for idx, param := range n.Params {
+			var arg interface{}
+			if idx < len(args) {
+				arg = args[idx]
+			}
+			env.Set(param.Name, arg)
+		}
+
+		_, err = i.eval(n.Body, env)
+		if err != nil {
+			switch typedErr := err.(type) {
+			case erbBreak:
+				return typedErr.Val, nil
+			case erbNext:
+				continue
+			}
+			return nil, err
+		}
+	}
+
+	return iter, nil
+}
+
+func (i *erbInterpreter) evalBegin(n erbBeginNode, env *erbEnv) (interface{}, error) {
+	val, err := i.eval(n.Body, env)
+
+	if err != nil && n.Rescue != nil {
+		if rubyErr, ok := err.(*erbError); ok {
+			if len(n.RescueVar) > 0 {
+				env.Set(n.RescueVar, rubyErr)
+			}
+			val, err = i.eval(n.Rescue, env)
+		}
+	}
+
+	if n.Ensure != nil {
+		_, ensureErr := i.eval(n.Ensure, env)
+		if ensureErr != nil {
+			return nil, ensureErr
+		}
+	}
+
+	return val, err
+}
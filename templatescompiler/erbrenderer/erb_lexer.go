@@ -0,0 +1,739 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type erbTokenKind int
+
+const (
+	erbTokEOF erbTokenKind = iota
+	erbTokNewline
+	erbTokText   // literal template text
+	erbTokOutput // start of <%= tag
+	erbTokInt
+	erbTokFloat
+	erbTokString
+	erbTokSymbol
+	erbTokRegexp
+	erbTokWords // %w[] and %i[] literals
+	erbTokIdent
+	erbTokConst
+	erbTokIVar
+	erbTokGVar  // $~ and $1..$9 set by regexp matches
+	erbTokLabel // 'key:' in hash literals and keyword args
+	erbTokKeyword
+	erbTokOp
+)
+
+var erbKeywords = map[string]bool{
+	"and": true, "begin": true, "break": true, "case": true, "do": true,
+	"else": true, "elsif": true, "end": true, "ensure": true, "false": true,
+	"for": true, "if": true, "in": true, "next": true, "nil": true,
+	"not": true, "or": true, "rescue": true, "self": true, "then": true,
+	"true": true, "unless": true, "until": true, "when": true, "while": true,
+	"defined?": true, "def": true, "return": true,
+}
+
+// erbStrPart is either a literal piece of a string or Ruby code to interpolate
+type erbStrPart struct {
+	Lit    string
+	Code   string
+	IsCode bool
+	Line   int
+}
+
+type erbToken struct {
+	Kind erbTokenKind
+	Val  string
+	Line int
+
+	SpaceBefore bool
+
+	Parts []erbStrPart // strings and regexps
+	Words []string     // %w[] and %i[]
+	Flags string       // regexp flags; 'i' for %i[]
+}
+
+func (t erbToken) Is(kind erbTokenKind, val string) bool {
+	return t.Kind == kind && t.Val == val
+}
+
+func (t erbToken) String() string {
+	switch t.Kind {
+	case erbTokEOF:
+		return "end of template"
+	case erbTokNewline:
+		return "end of line"
+	case erbTokText, erbTokOutput:
+		return "template text"
+	default:
+		return fmt.Sprintf("'%s'", t.Val)
+	}
+}
+
+type erbLexer struct {
+	src  []rune
+	pos  int
+	line int
+
+	// Lines of heredoc bodies already consumed from the current line
+	heredocLines int
+
+	tokens []erbToken
+}
+
+var erbOps = []string{
+	"**=", "<=>", "===", "...", "||=", "&&=", "<<=",
+	"**", "==", "!=", "=~", "!~", "<=", ">=", "<<", ">>", "&&", "||", "&.",
+	"..", "::", "=>", "+=", "-=", "*=", "/=", "->",
+	"+", "-", "*", "/", "%", "=", "<", ">", "!", "&", "|", "^", "~",
+	".", ",", "(", ")", "[", "]", "{", "}", "?", ":",
+}
+
+// lexRuby splits Ruby code into tokens; line is the line number where code starts
+func lexRuby(code string, line int) ([]erbToken, error) {
+	l := &erbLexer{src: []rune(code), line: line}
+
+	err := l.run()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.tokens, nil
+}
+
+func (l *erbLexer) run() error {
+	spaceBefore := false
+
+	for l.pos < len(l.src) {
+		ch := l.src[l.pos]
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			l.pos++
+			spaceBefore = true
+			continue
+
+		case ch == '\\' && l.peek(1) == '\n':
+			l.pos += 2
+			l.line++
+			spaceBefore = true
+			continue
+
+		case ch == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+
+		case ch == '\n' || ch == ';':
+			l.pos++
+			tokLine := l.line
+			if ch == '\n' {
+				l.line += 1 + l.heredocLines
+				l.heredocLines = 0
+			}
+			// Method chains may continue on the next line (e.g. '.map { ... }')
+			if !l.continuesOnNextLine() {
+				l.emit(erbToken{Kind: erbTokNewline, Val: string(ch), Line: tokLine})
+			}
+			spaceBefore = true
+			continue
+		}
+
+		tok, err := l.next(spaceBefore)
+		if err != nil {
+			return err
+		}
+
+		tok.SpaceBefore = spaceBefore
+		l.emit(tok)
+		spaceBefore = false
+	}
+
+	return nil
+}
+
+func (l *erbLexer) emit(tok erbToken) {
+	l.tokens = append(l.tokens, tok)
+}
+
+func (l *erbLexer) peek(offset int) rune {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *erbLexer) continuesOnNextLine() bool {
+	for i := l.pos; i < len(l.src); i++ {
+		switch l.src[i] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '.':
+			return i+1 < len(l.src) && l.src[i+1] != '.'
+		case '&':
+			return i+1 < len(l.src) && l.src[i+1] == '.'
+		}
+		return false
+	}
+	return false
+}
+
+// valueExpected reports whether next token starts an operand,
+// which disambiguates e.g. regexps from division
+func (l *erbLexer) valueExpected(spaceBefore bool) bool {
+	if len(l.tokens) == 0 {
+		return true
+	}
+
+	prev := l.tokens[len(l.tokens)-1]
+
+	switch prev.Kind {
+	case erbTokNewline, erbTokText, erbTokOutput, erbTokLabel:
+		return true
+	case erbTokOp:
+		return prev.Val != ")" && prev.Val != "]" && prev.Val != "}"
+	case erbTokKeyword:
+		switch prev.Val {
+		case "end", "self", "true", "false", "nil":
+			return false
+		}
+		return true
+	case erbTokIdent:
+		// Command call argument, e.g. 'split /,/'
+		next := l.peek(1)
+		return spaceBefore && next != ' ' && next != '='
+	}
+
+	return false
+}
+
+func (l *erbLexer) next(spaceBefore bool) (erbToken, error) {
+	ch := l.src[l.pos]
+	line := l.line
+
+	switch {
+	case unicode.IsDigit(ch):
+		return l.number(), nil
+
+	case ch == '"':
+		l.pos++
+		parts, err := l.stringBody('"', 0, erbStrDouble)
+		return erbToken{Kind: erbTokString, Val: "string", Parts: parts, Line: line}, err
+
+	case ch == '`':
+		l.pos++
+		parts, err := l.stringBody('`', 0, erbStrDouble)
+		return erbToken{Kind: erbTokString, Val: "xstring", Parts: parts, Line: line}, err
+
+	case ch == '\'':
+		l.pos++
+		parts, err := l.stringBody('\'', 0, erbStrSingle)
+		return erbToken{Kind: erbTokString, Val: "string", Parts: parts, Line: line}, err
+
+	case ch == '@':
+		start := l.pos
+		l.pos++
+		l.identChars()
+		return erbToken{Kind: erbTokIVar, Val: string(l.src[start:l.pos]), Line: line}, nil
+
+	case ch == '$' && (unicode.IsDigit(l.peek(1)) || l.peek(1) == '~'):
+		start := l.pos
+		l.pos += 2
+		for l.pos < len(l.src) && unicode.IsDigit(l.src[l.pos]) && unicode.IsDigit(l.src[start+1]) {
+			l.pos++
+		}
+		return erbToken{Kind: erbTokGVar, Val: string(l.src[start:l.pos]), Line: line}, nil
+
+	case ch == ':' && l.peek(1) == '"':
+		l.pos += 2
+		parts, err := l.stringBody('"', 0, erbStrDouble)
+		return erbToken{Kind: erbTokSymbol, Val: "symbol", Parts: parts, Line: line}, err
+
+	case ch == ':' && l.peek(1) != ':' && isIdentStart(l.peek(1)) && (l.valueExpected(spaceBefore) || spaceBefore):
+		l.pos++
+		start := l.pos
+		l.identChars()
+		if l.pos < len(l.src) && (l.src[l.pos] == '?' || l.src[l.pos] == '!' || l.src[l.pos] == '=') && l.peek(1) != '=' {
+			l.pos++
+		}
+		name := string(l.src[start:l.pos])
+		return erbToken{Kind: erbTokSymbol, Val: name, Parts: []erbStrPart{{Lit: name}}, Line: line}, nil
+
+	case ch == ':' && (l.valueExpected(spaceBefore) || spaceBefore) && l.operatorSymbol() != "":
+		name := l.operatorSymbol()
+		l.pos += 1 + len(name)
+		return erbToken{Kind: erbTokSymbol, Val: name, Parts: []erbStrPart{{Lit: name}}, Line: line}, nil
+
+	case ch == '<' && l.peek(1) == '<' && l.valueExpected(spaceBefore) && erbHeredocRegexp.MatchString(string(l.src[l.pos:])):
+		return l.heredoc()
+
+	case ch == '/' && l.valueExpected(spaceBefore):
+		l.pos++
+		parts, err := l.stringBody('/', 0, erbStrRegexp)
+		if err != nil {
+			return erbToken{}, err
+		}
+		return erbToken{Kind: erbTokRegexp, Val: "regexp", Parts: parts, Flags: l.regexpFlags(), Line: line}, nil
+
+	case ch == '%' && l.valueExpected(spaceBefore) && l.isPercentLiteral():
+		return l.percentLiteral()
+
+	case isIdentStart(ch):
+		start := l.pos
+		l.identChars()
+
+		if l.pos < len(l.src) && (l.src[l.pos] == '?' || l.src[l.pos] == '!') && l.peek(1) != '=' {
+			l.pos++
+		} else if l.pos < len(l.src) && l.src[l.pos] == '?' && l.peek(1) == '=' && l.peek(2) == '=' {
+			l.pos++
+		}
+
+		name := string(l.src[start:l.pos])
+
+		// Labels in hashes, e.g. '{key: value}'
+		if l.pos < len(l.src) && l.src[l.pos] == ':' && l.peek(1) != ':' && !strings.HasSuffix(name, "?") {
+			if len(l.tokens) == 0 || !l.tokens[len(l.tokens)-1].Is(erbTokOp, "?") {
+				l.pos++
+				return erbToken{Kind: erbTokLabel, Val: name, Line: line}, nil
+			}
+		}
+
+		afterDot := len(l.tokens) > 0 && (l.tokens[len(l.tokens)-1].Is(erbTokOp, ".") || l.tokens[len(l.tokens)-1].Is(erbTokOp, "&."))
+
+		switch {
+		case erbKeywords[name] && !afterDot:
+			return erbToken{Kind: erbTokKeyword, Val: name, Line: line}, nil
+		case unicode.IsUpper(rune(name[0])):
+			return erbToken{Kind: erbTokConst, Val: name, Line: line}, nil
+		default:
+			return erbToken{Kind: erbTokIdent, Val: name, Line: line}, nil
+		}
+	}
+
+	for _, op := range erbOps {
+		if l.hasPrefix(op) {
+			l.pos += len([]rune(op))
+			return erbToken{Kind: erbTokOp, Val: op, Line: line}, nil
+		}
+	}
+
+	return erbToken{}, newERBError("SyntaxError", line, "unexpected character '%c'", ch)
+}
+
+var erbHeredocRegexp = regexp.MustCompile(`^<<([~-]?)(?:'([A-Za-z_]\w*)'|"?([A-Za-z_]\w*)"?)`)
+
+// heredoc reads '<<~ID' string whose body starts on the next line;
+// body is removed from source so that lexing continues after '<<~ID'
+func (l *erbLexer) heredoc() (erbToken, error) {
+	line := l.line
+	match := erbHeredocRegexp.FindStringSubmatch(string(l.src[l.pos:]))
+
+	l.pos += len([]rune(match[0]))
+
+	indented := len(match[1]) > 0
+	squiggly := match[1] == "~"
+	raw := len(match[2]) > 0
+
+	id := match[3]
+	if raw {
+		id = match[2]
+	}
+
+	lineEnd := l.pos
+	for lineEnd < len(l.src) && l.src[lineEnd] != '\n' {
+		lineEnd++
+	}
+
+	var bodyLines []string
+	terminated := false
+	bodyEnd := lineEnd + 1
+
+	for bodyEnd < len(l.src) && !terminated {
+		end := bodyEnd
+		for end < len(l.src) && l.src[end] != '\n' {
+			end++
+		}
+
+		bodyLine := string(l.src[bodyEnd:end])
+		bodyEnd = end + 1
+
+		candidate := strings.TrimRight(bodyLine, "\r")
+		if indented {
+			candidate = strings.TrimLeft(candidate, " \t")
+		}
+
+		if candidate == id {
+			terminated = true
+		} else {
+			bodyLines = append(bodyLines, bodyLine)
+		}
+	}
+
+	if !terminated {
+		return erbToken{}, newERBError("SyntaxError", line, "can't find string \"%s\" anywhere before EOF", id)
+	}
+
+	if bodyEnd > len(l.src) {
+		bodyEnd = len(l.src)
+	}
+
+	l.src = append(append([]rune{}, l.src[:lineEnd+1]...), l.src[bodyEnd:]...)
+	l.heredocLines += len(bodyLines) + 1
+
+	if squiggly {
+		bodyLines = erbDedent(bodyLines)
+	}
+
+	var body string
+	for _, bodyLine := range bodyLines {
+		body += bodyLine + "\n"
+	}
+
+	if raw {
+		return erbToken{Kind: erbTokString, Val: "string", Parts: []erbStrPart{{Lit: body, Line: line + 1}}, Line: line}, nil
+	}
+
+	sub := &erbLexer{src: append([]rune(body), 0), line: line + 1}
+
+	parts, err := sub.stringBody(0, 0, erbStrDouble)
+	if err != nil {
+		return erbToken{}, err
+	}
+
+	return erbToken{Kind: erbTokString, Val: "string", Parts: parts, Line: line}, nil
+}
+
+func erbDedent(lines []string) []string {
+	indent := -1
+
+	for _, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	var dedented []string
+	for _, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		} else if indent > 0 {
+			line = strings.TrimLeft(line, " \t")
+		}
+		dedented = append(dedented, line)
+	}
+
+	return dedented
+}
+
+// erbOperatorSymbols are operator method names usable as symbols, e.g. 'inject(:+)'
+var erbOperatorSymbols = []string{
+	"[]=", "[]", "<=>", "===", "==", "=~", "!=", "!~", "<<", ">>", "<=", ">=", "**",
+	"+@", "-@", "+", "-", "*", "/", "%", "<", ">", "!", "&", "|", "^", "~",
+}
+
+func (l *erbLexer) operatorSymbol() string {
+	for _, op := range erbOperatorSymbols {
+		if strings.HasPrefix(string(l.src[l.pos+1:]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (l *erbLexer) hasPrefix(s string) bool {
+	runes := []rune(s)
+	if l.pos+len(runes) > len(l.src) {
+		return false
+	}
+	for i, r := range runes {
+		if l.src[l.pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentStart(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
+}
+
+func (l *erbLexer) identChars() {
+	for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(l.src[l.pos]) || unicode.IsDigit(l.src[l.pos])) {
+		l.pos++
+	}
+}
+
+func (l *erbLexer) number() erbToken {
+	start := l.pos
+	kind := erbTokInt
+
+	if l.src[l.pos] == '0' && (l.peek(1) == 'x' || l.peek(1) == 'X') {
+		l.pos += 2
+		for l.pos < len(l.src) && (unicode.Is(unicode.ASCII_Hex_Digit, l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+		return erbToken{Kind: kind, Val: string(l.src[start:l.pos]), Line: l.line}
+	}
+
+	digits := func() {
+		for l.pos < len(l.src) && (unicode.IsDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+	}
+
+	digits()
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' && unicode.IsDigit(l.peek(1)) {
+		kind = erbTokFloat
+		l.pos++
+		digits()
+	}
+
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		next := l.peek(1)
+		if unicode.IsDigit(next) || ((next == '-' || next == '+') && unicode.IsDigit(l.peek(2))) {
+			kind = erbTokFloat
+			l.pos += 2
+			digits()
+		}
+	}
+
+	return erbToken{Kind: kind, Val: strings.Replace(string(l.src[start:l.pos]), "_", "", -1), Line: l.line}
+}
+
+type erbStrMode int
+
+const (
+	erbStrSingle erbStrMode = iota // no interpolation and escapes
+	erbStrDouble
+	erbStrRegexp // interpolation but escapes are kept for regexp engine
+)
+
+// stringBody reads string contents up to the closing delimiter;
+// open is non-zero for delimiters that nest such as '(' in %w()
+func (l *erbLexer) stringBody(closing, open rune, mode erbStrMode) ([]erbStrPart, error) {
+	var parts []erbStrPart
+	var lit []rune
+
+	startLine := l.line
+	depth := 0
+	interpolate := mode != erbStrSingle
+
+	flush := func() {
+		if len(lit) > 0 {
+			parts = append(parts, erbStrPart{Lit: string(lit), Line: l.line})
+			lit = nil
+		}
+	}
+
+	for {
+		if l.pos >= len(l.src) {
+			return nil, newERBError("SyntaxError", startLine, "unterminated string meets end of file")
+		}
+
+		ch := l.src[l.pos]
+
+		switch {
+		case ch == closing && depth == 0:
+			l.pos++
+			flush()
+			if len(parts) == 0 {
+				parts = append(parts, erbStrPart{Line: l.line})
+			}
+			return parts, nil
+
+		case open != 0 && ch == open:
+			depth++
+			lit = append(lit, ch)
+			l.pos++
+
+		case open != 0 && ch == closing:
+			depth--
+			lit = append(lit, ch)
+			l.pos++
+
+		case ch == '\\':
+			l.pos++
+			if l.pos >= len(l.src) {
+				continue
+			}
+			esc := l.src[l.pos]
+			l.pos++
+
+			switch {
+			case mode == erbStrRegexp:
+				// Regexps keep escapes as is except for the delimiter
+				if esc != closing {
+					lit = append(lit, '\\')
+				}
+				lit = append(lit, esc)
+			case mode == erbStrSingle:
+				if esc != closing && esc != '\\' && (open == 0 || esc != open) {
+					lit = append(lit, '\\')
+				}
+				lit = append(lit, esc)
+			default:
+				lit = append(lit, unescapeRubyChar(esc)...)
+			}
+
+			if esc == '\n' {
+				l.line++
+			}
+
+		case ch == '#' && interpolate && l.peek(1) == '{':
+			flush()
+			l.pos += 2
+
+			start := l.pos
+			codeLine := l.line
+			braces := 0
+
+			for l.pos < len(l.src) {
+				c := l.src[l.pos]
+				if c == '{' {
+					braces++
+				} else if c == '}' {
+					if braces == 0 {
+						break
+					}
+					braces--
+				} else if c == '\n' {
+					l.line++
+				} else if c == '"' || c == '\'' {
+					// Skip over nested strings so that braces inside them are ignored
+					l.pos++
+					for l.pos < len(l.src) && l.src[l.pos] != c {
+						if l.src[l.pos] == '\\' {
+							l.pos++
+						}
+						l.pos++
+					}
+				}
+				l.pos++
+			}
+
+			if l.pos >= len(l.src) {
+				return nil, newERBError("SyntaxError", codeLine, "unterminated string interpolation")
+			}
+
+			parts = append(parts, erbStrPart{Code: string(l.src[start:l.pos]), IsCode: true, Line: codeLine})
+			l.pos++
+
+		default:
+			if ch == '\n' {
+				l.line++
+			}
+			lit = append(lit, ch)
+			l.pos++
+		}
+	}
+}
+
+func unescapeRubyChar(esc rune) []rune {
+	switch esc {
+	case 'n':
+		return []rune{'\n'}
+	case 't':
+		return []rune{'\t'}
+	case 'r':
+		return []rune{'\r'}
+	case 's':
+		return []rune{' '}
+	case '0':
+		return []rune{0}
+	case 'e':
+		return []rune{27}
+	case '\n':
+		return nil
+	default:
+		return []rune{esc}
+	}
+}
+
+func (l *erbLexer) regexpFlags() string {
+	start := l.pos
+	for l.pos < len(l.src) && strings.ContainsRune("imxo", l.src[l.pos]) {
+		l.pos++
+	}
+	return string(l.src[start:l.pos])
+}
+
+func (l *erbLexer) isPercentLiteral() bool {
+	next := l.peek(1)
+	if strings.ContainsRune("wWiIqQr", next) {
+		return strings.ContainsRune("([{<|!/", l.peek(2))
+	}
+	return strings.ContainsRune("([{<|!", next)
+}
+
+func (l *erbLexer) percentLiteral() (erbToken, error) {
+	line := l.line
+	l.pos++
+
+	kind := 'Q'
+	if unicode.IsLetter(l.src[l.pos]) {
+		kind = l.src[l.pos]
+		l.pos++
+	}
+
+	open := l.src[l.pos]
+	l.pos++
+
+	closing := open
+	nestOpen := rune(0)
+
+	switch open {
+	case '(':
+		closing, nestOpen = ')', '('
+	case '[':
+		closing, nestOpen = ']', '['
+	case '{':
+		closing, nestOpen = '}', '{'
+	case '<':
+		closing, nestOpen = '>', '<'
+	}
+
+	switch kind {
+	case 'w', 'W', 'i', 'I':
+		parts, err := l.stringBody(closing, nestOpen, erbStrSingle)
+		if err != nil {
+			return erbToken{}, err
+		}
+
+		var contents string
+		for _, part := range parts {
+			contents += part.Lit
+		}
+
+		tok := erbToken{Kind: erbTokWords, Val: "%" + string(kind), Words: strings.Fields(contents), Line: line}
+		if kind == 'i' || kind == 'I' {
+			tok.Flags = "i"
+		}
+		return tok, nil
+
+	case 'r':
+		parts, err := l.stringBody(closing, nestOpen, erbStrRegexp)
+		if err != nil {
+			return erbToken{}, err
+		}
+		return erbToken{Kind: erbTokRegexp, Val: "regexp", Parts: parts, Flags: l.regexpFlags(), Line: line}, nil
+
+	default:
+		mode := erbStrDouble
+		if kind == 'q' {
+			mode = erbStrSingle
+		}
+		parts, err := l.stringBody(closing, nestOpen, mode)
+		return erbToken{Kind: erbTokString, Val: "string", Parts: parts, Line: line}, err
+	}
+}
@@ -0,0 +1,3806 @@
+package erbrenderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func erbUndefinedMethodRecv(recv interface{}) string {
+	switch typedRecv := recv.(type) {
+	case nil, bool:
+		return erbInspect(recv)
+	case *erbClass:
+		return "class " + typedRecv.Name
+	default:
+		return "an instance of " + erbClassName(recv)
+	}
+}
+
+func erbUndefinedMethodMsg(name string, recv interface{}) string {
+	return fmt.Sprintf("undefined method `%s' for %s", name, erbUndefinedMethodRecv(recv))
+}
+
+func erbArgc(args []interface{}, min, max int) error {
+	if len(args) >= min && (max < 0 || len(args) <= max) {
+		return nil
+	}
+
+	expected := strconv.Itoa(min)
+	switch {
+	case max < 0:
+		expected += "+"
+	case max != min:
+		expected += ".." + strconv.Itoa(max)
+	}
+
+	return newERBError("ArgumentError", 0, "wrong number of arguments (given %d, expected %s)", len(args), expected)
+}
+
+func erbArgInt(args []interface{}, idx int) (int, error) {
+	if idx >= len(args) {
+		return 0, erbArgc(args, idx+1, idx+1)
+	}
+
+	switch typedArg := args[idx].(type) {
+	case int:
+		return typedArg, nil
+	case float64:
+		return int(typedArg), nil
+	}
+
+	return 0, newERBError("TypeError", 0, "no implicit conversion of %s into Integer", erbConvName(args[idx]))
+}
+
+func erbArgStr(args []interface{}, idx int) (string, error) {
+	if idx >= len(args) {
+		return "", erbArgc(args, idx+1, idx+1)
+	}
+
+	if str, ok := args[idx].(string); ok {
+		return str, nil
+	}
+
+	return "", newERBError("TypeError", 0, "no implicit conversion of %s into String", erbConvName(args[idx]))
+}
+
+func erbConvName(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return erbInspect(val)
+	}
+	return erbClassName(val)
+}
+
+func (i *erbInterpreter) callMethod(recv interface{}, name string, args []interface{}, blk *erbProc) (interface{}, error) {
+	if str, ok := recv.(*erbString); ok {
+		result, found, err := i.strObjMethod(str, name, args, blk)
+		if found || err != nil {
+			return result, err
+		}
+		recv = str.Val
+	}
+
+	if !erbKeepsArgs(recv, name) {
+		args = erbUnwrapAll(args)
+	}
+
+	var result interface{}
+	var found bool
+	var err error
+
+	switch typedRecv := recv.(type) {
+	case nil, bool:
+		result, found, err = i.nilOrBoolMethod(recv, name, args)
+	case int, float64:
+		result, found, err = i.numMethod(recv, name, args, blk)
+	case string:
+		result, found, err = i.strMethod(typedRecv, name, args, blk)
+	case erbSymbol:
+		result, found, err = i.symMethod(typedRecv, name, args, blk)
+	case *erbArray:
+		result, found, err = i.arrMethod(typedRecv, name, args, blk)
+	case *erbHash:
+		result, found, err = i.hashMethod(typedRecv, name, args, blk)
+	case *erbRange:
+		result, found, err = i.rangeMethod(typedRecv, name, args, blk)
+	case *erbRegexp:
+		result, found, err = i.regexpMethod(typedRecv, name, args, blk)
+	case *erbMatchData:
+		result, found, err = i.matchDataMethod(typedRecv, name, args)
+	case *erbOpenStruct:
+		result, found, err = i.openStructMethod(typedRecv, name, args, blk)
+	case *erbProc:
+		result, found, err = i.procMethod(typedRecv, name, args, blk)
+	case *erbClass:
+		result, found, err = i.classMethod(typedRecv, name, args, blk)
+	case *erbEnumerator:
+		result, found, err = i.enumeratorMethod(typedRecv, name, args, blk)
+	case *erbError:
+		result, found, err = i.errorMethod(typedRecv, name)
+	case erbObject:
+		result, found, err = typedRecv.CallMethod(i, name, args, blk)
+	}
+
+	if found || err != nil {
+		return result, err
+	}
+
+	result, found, err = i.objectMethod(recv, name, args, blk)
+	if found || err != nil {
+		return result, err
+	}
+
+	return nil, newERBError("NoMethodError", 0, "%s", erbUndefinedMethodMsg(name, recv))
+}
+
+// erbKeepsArgs tells whether method stores references to its arguments,
+// e.g. 'arr << str', hence String objects must not be replaced with copies
+func erbKeepsArgs(recv interface{}, name string) bool {
+	switch typedRecv := recv.(type) {
+	case *erbArray, *erbHash:
+		switch name {
+		case "<<", "push", "append", "unshift", "prepend", "insert", "[]=", "store", "default=":
+			return true
+		}
+	case *erbClass:
+		// Default values are shared, e.g. 'Hash.new("")'
+		return name == "new" && (typedRecv.Name == "Hash" || typedRecv.Name == "Array")
+	case *erbOpenStruct:
+		return name == "[]=" || (strings.HasSuffix(name, "=") && isIdentName(name))
+	case *erbProc:
+		return true
+	}
+	return false
+}
+
+// strObjMethod implements methods that depend on identity of String object
+// or modify it in place; other methods are called on its plain value
+func (i *erbInterpreter) strObjMethod(str *erbString, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "to_s", "to_str", "itself", "freeze", "taint", "untaint", "+@", "force_encoding":
+		return str, true, nil
+	case "dup", "clone":
+		return &erbString{Val: str.Val}, true, nil
+	case "equal?":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return str == args[0], true, nil
+	case "tap", "then", "yield_self":
+		return i.objectMethod(str, name, args, blk)
+	}
+
+	method, found := erbStringMutators[name]
+	if !found {
+		return nil, false, nil
+	}
+
+	result, err := i.callMethod(str.Val, method, args, blk)
+	if err != nil {
+		return nil, true, err
+	}
+
+	newVal, ok := result.(string)
+	if !ok {
+		return result, true, nil
+	}
+
+	changed := newVal != str.Val
+	str.Val = newVal
+
+	if strings.HasSuffix(name, "!") && !changed {
+		return nil, true, nil
+	}
+
+	return str, true, nil
+}
+
+// objectMethod implements methods available on all objects
+func (i *erbInterpreter) objectMethod(recv interface{}, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "nil?":
+		return recv == nil, true, nil
+	case "to_s":
+		str, err := i.toS(recv)
+		return str, true, err
+	case "inspect":
+		return erbInspect(recv), true, nil
+	case "==", "eql?", "equal?", "===":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return erbEqual(recv, args[0]), true, nil
+	case "!=":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return !erbEqual(recv, args[0]), true, nil
+	case "!":
+		return !erbTruthy(recv), true, nil
+	case "<=>":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		cmp, err := erbCompare(recv, args[0])
+		if err != nil {
+			return nil, true, nil
+		}
+		return cmp, true, nil
+	case "=~":
+		return nil, true, nil
+	case "is_a?", "kind_of?", "instance_of?":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		class, ok := args[0].(*erbClass)
+		if !ok {
+			return nil, true, newERBError("TypeError", 0, "class or module required")
+		}
+		if name == "instance_of?" {
+			return erbClassName(recv) == class.Name, true, nil
+		}
+		return erbIsA(recv, class.Name), true, nil
+	case "class":
+		return &erbClass{Name: erbClassName(recv)}, true, nil
+	case "respond_to?":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		return i.respondTo(recv, erbToS(args[0])), true, nil
+	case "to_json":
+		str, err := erbToJSON(recv, false)
+		return str, true, err
+	case "to_yaml":
+		str, err := erbToYAML(recv)
+		return str, true, err
+	case "freeze", "itself", "dup", "clone", "taint", "untaint":
+		return recv, true, nil
+	case "frozen?":
+		return false, true, nil
+	case "tap":
+		_, err := i.callProc(blk, recv)
+		return recv, true, err
+	case "then", "yield_self":
+		result, err := i.callProc(blk, recv)
+		return result, true, err
+	case "send", "public_send", "__send__":
+		if err := erbArgc(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		result, err := i.callMethod(recv, erbToS(args[0]), args[1:], blk)
+		return result, true, err
+	case "instance_variable_get":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		if recv == i.self {
+			return i.ivars[erbToS(args[0])], true, nil
+		}
+		return nil, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) respondTo(recv interface{}, name string) bool {
+	switch typedRecv := recv.(type) {
+	case *erbOpenStruct:
+		if _, found := typedRecv.Fields.Get(name); found {
+			return true
+		}
+	case erbObject:
+		if recv == i.self {
+			if _, found := erbContextMethods[name]; found {
+				return true
+			}
+		}
+	}
+
+	if _, mutating := erbMutatingMethods[name]; mutating {
+		return true
+	}
+
+	// Methods without side effects are probed
+	_, err := i.callMethod(recv, name, nil, nil)
+	if typedErr, ok := err.(*erbError); ok && typedErr.Class == "NoMethodError" {
+		return false
+	}
+
+	return true
+}
+
+var erbMutatingMethods = map[string]struct{}{
+	"<<": {}, "push": {}, "append": {}, "pop": {}, "shift": {}, "unshift": {}, "prepend": {},
+	"insert": {}, "concat": {}, "delete": {}, "delete_at": {}, "delete_if": {}, "keep_if": {},
+	"clear": {}, "replace": {}, "[]=": {}, "store": {}, "merge!": {}, "update": {},
+}
+
+func (i *erbInterpreter) nilOrBoolMethod(recv interface{}, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "&", "|", "^":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		switch name {
+		case "&":
+			return erbTruthy(recv) && erbTruthy(args[0]), true, nil
+		case "|":
+			return erbTruthy(recv) || erbTruthy(args[0]), true, nil
+		}
+		return erbTruthy(recv) != erbTruthy(args[0]), true, nil
+	}
+
+	if recv != nil {
+		return nil, false, nil
+	}
+
+	switch name {
+	case "to_a":
+		return newERBArray(), true, nil
+	case "to_h":
+		return newERBHash(), true, nil
+	case "to_i":
+		return 0, true, nil
+	case "to_f":
+		return 0.0, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) errorMethod(err *erbError, name string) (interface{}, bool, error) {
+	switch name {
+	case "message", "to_s":
+		return err.Msg, true, nil
+	case "class":
+		return &erbClass{Name: err.Class}, true, nil
+	}
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) callKernel(name string, args []interface{}, blk *erbProc, env *erbEnv) (interface{}, bool, error) {
+	switch name {
+	case "raise", "fail":
+		if len(args) == 0 {
+			return nil, true, newERBError("RuntimeError", 0, "unhandled exception")
+		}
+		switch typedArg := args[0].(type) {
+		case *erbError:
+			return nil, true, typedArg
+		case *erbClass:
+			msg := typedArg.Name
+			if len(args) > 1 {
+				msg = erbToS(args[1])
+			}
+			return nil, true, newERBError(typedArg.Name, 0, "%s", msg)
+		}
+		return nil, true, newERBError("RuntimeError", 0, "%s", erbToS(args[0]))
+
+	case "format", "sprintf":
+		if err := erbArgc(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		format, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		str, err := i.format(format, args[1:])
+		return str, true, err
+
+	case "Integer":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		result, err := erbStrictInteger(args)
+		return result, true, err
+
+	case "Float":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		result, err := erbStrictFloat(args[0])
+		return result, true, err
+
+	case "String":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		str, err := i.toS(args[0])
+		return str, true, err
+
+	case "Array":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		items, err := i.toA(args[0])
+		return newERBArray(items...), true, err
+
+	case "Hash":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		switch typedArg := args[0].(type) {
+		case nil:
+			return newERBHash(), true, nil
+		case *erbHash:
+			return typedArg, true, nil
+		case *erbArray:
+			if len(typedArg.Items) == 0 {
+				return newERBHash(), true, nil
+			}
+		}
+		return nil, true, newERBError("TypeError", 0, "can't convert %s into Hash", erbClassName(args[0]))
+
+	case "`", "system", "exec", "spawn":
+		return nil, true, newERBError("NotImplementedError", 0, "running commands is not supported by built-in renderer")
+
+	case "require", "require_relative", "load":
+		return true, true, nil
+
+	case "puts", "print", "warn":
+		// Output of these goes to stdout/stderr of ruby process, not template
+		return nil, true, nil
+
+	case "lambda", "proc":
+		if blk == nil {
+			return nil, true, newERBError("ArgumentError", 0, "tried to create Proc object without a block")
+		}
+		proc := *blk
+		proc.Lambda = name == "lambda"
+		return &proc, true, nil
+
+	case "loop":
+		for {
+			_, err := i.callProc(blk)
+			if err != nil {
+				if typedErr, ok := err.(*erbError); ok && typedErr.Class == "StopIteration" {
+					return nil, true, nil
+				}
+				return nil, true, err
+			}
+		}
+
+	case "binding":
+		return i.self, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func erbStrictInteger(args []interface{}) (interface{}, error) {
+	switch typedArg := args[0].(type) {
+	case int:
+		return typedArg, nil
+	case float64:
+		return erbFloatToInt(typedArg)
+	case string:
+		base := 0
+		if len(args) > 1 {
+			b, err := erbArgInt(args, 1)
+			if err != nil {
+				return nil, err
+			}
+			base = b
+		}
+
+		str := strings.Replace(strings.TrimSpace(typedArg), "_", "", -1)
+		if base == 0 && strings.HasPrefix(strings.TrimLeft(str, "+-"), "0") && len(strings.TrimLeft(str, "+-")) > 1 {
+			// Ruby treats leading zero as octal; Go expects 0o for octal when base is 0
+			trimmed := strings.TrimLeft(str, "+-")
+			if unicode.IsDigit(rune(trimmed[1])) {
+				str = str[:len(str)-len(trimmed)] + "0o" + trimmed[1:]
+			}
+		}
+
+		val, err := strconv.ParseInt(str, base, 64)
+		if err != nil {
+			return nil, newERBError("ArgumentError", 0, "invalid value for Integer(): %s", erbInspect(typedArg))
+		}
+		return int(val), nil
+	case nil:
+		return nil, newERBError("TypeError", 0, "can't convert nil into Integer")
+	}
+
+	return nil, newERBError("TypeError", 0, "can't convert %s into Integer", erbClassName(args[0]))
+}
+
+func erbStrictFloat(arg interface{}) (interface{}, error) {
+	switch typedArg := arg.(type) {
+	case int:
+		return float64(typedArg), nil
+	case float64:
+		return typedArg, nil
+	case string:
+		val, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(typedArg), "_", "", -1), 64)
+		if err != nil {
+			return nil, newERBError("ArgumentError", 0, "invalid value for Float(): %s", erbInspect(typedArg))
+		}
+		return val, nil
+	case nil:
+		return nil, newERBError("TypeError", 0, "can't convert nil into Float")
+	}
+
+	return nil, newERBError("TypeError", 0, "can't convert %s into Float", erbClassName(arg))
+}
+
+// erbLenientInt mimics String#to_i which ignores trailing garbage
+func erbLenientInt(str string, base int) int {
+	str = strings.TrimLeftFunc(str, unicode.IsSpace)
+
+	if base == 0 {
+		sign, digits := "", str
+		if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+			sign, digits = digits[:1], digits[1:]
+		}
+
+		lower := strings.ToLower(digits)
+		switch {
+		case strings.HasPrefix(lower, "0x"):
+			base, digits = 16, digits[2:]
+		case strings.HasPrefix(lower, "0b"):
+			base, digits = 2, digits[2:]
+		case strings.HasPrefix(lower, "0o"):
+			base, digits = 8, digits[2:]
+		case strings.HasPrefix(lower, "0"):
+			base = 8
+		default:
+			base = 10
+		}
+
+		str = sign + digits
+	}
+
+	end := 0
+	for end < len(str) {
+		c := str[end]
+		if (c == '-' || c == '+') && end == 0 {
+			end++
+			continue
+		}
+		if c == '_' && end > 0 {
+			end++
+			continue
+		}
+		if digit, err := strconv.ParseInt(string(c), base, 8); err != nil || digit >= int64(base) {
+			break
+		}
+		end++
+	}
+
+	val, _ := strconv.ParseInt(strings.Replace(str[:end], "_", "", -1), base, 64)
+
+	return int(val)
+}
+
+var erbLenientFloatRegexp = regexp.MustCompile(`^[+-]?(\d[\d_]*)?(\.\d+)?([eE][+-]?\d+)?`)
+
+func erbLenientFloat(str string) float64 {
+	match := erbLenientFloatRegexp.FindString(strings.TrimLeftFunc(str, unicode.IsSpace))
+	val, _ := strconv.ParseFloat(strings.Replace(match, "_", "", -1), 64)
+	return val
+}
+
+func (i *erbInterpreter) format(format string, args []interface{}) (string, error) {
+	var buf bytes.Buffer
+
+	argIdx := 0
+
+	nextArg := func() (interface{}, error) {
+		if argIdx >= len(args) {
+			return nil, newERBError("ArgumentError", 0, "too few arguments")
+		}
+		argIdx++
+		return args[argIdx-1], nil
+	}
+
+	for idx := 0; idx < len(format); idx++ {
+		c := format[idx]
+		if c != '%' {
+			buf.WriteByte(c)
+			continue
+		}
+
+		idx++
+		if idx >= len(format) {
+			return "", newERBError("ArgumentError", 0, "incomplete format specifier; use %%%% (double %%) instead")
+		}
+
+		if format[idx] == '%' {
+			buf.WriteByte('%')
+			continue
+		}
+
+		start := idx
+		for idx < len(format) && strings.IndexByte("-+ 0#", format[idx]) >= 0 {
+			idx++
+		}
+
+		var named interface{}
+		hasNamed := false
+
+		if idx < len(format) && (format[idx] == '<' || format[idx] == '{') {
+			closing := byte('>')
+			if format[idx] == '{' {
+				closing = '}'
+			}
+
+			end := strings.IndexByte(format[idx:], closing)
+			if end < 0 {
+				return "", newERBError("ArgumentError", 0, "malformed name - unmatched parenthesis")
+			}
+
+			key := format[idx+1 : idx+end]
+
+			if len(args) == 0 {
+				return "", newERBError("ArgumentError", 0, "one hash required")
+			}
+
+			hash, ok := args[0].(*erbHash)
+			if !ok {
+				return "", newERBError("ArgumentError", 0, "one hash required")
+			}
+
+			val, found := hash.Get(erbSymbol(key))
+			if !found {
+				return "", newERBError("KeyError", 0, "key<%s> not found", key)
+			}
+
+			named, hasNamed = val, true
+
+			if closing == '}' {
+				str, err := i.toS(val)
+				if err != nil {
+					return "", err
+				}
+				buf.WriteString(str)
+				idx += end
+				continue
+			}
+
+			idx += end + 1
+		}
+
+		flags := format[start:idx]
+		if hasNamed {
+			flags = format[start : strings.IndexAny(format[start:], "<{")+start]
+		}
+
+		for idx < len(format) && (unicode.IsDigit(rune(format[idx])) || format[idx] == '.') {
+			flags += string(format[idx])
+			idx++
+		}
+
+		if idx >= len(format) {
+			return "", newERBError("ArgumentError", 0, "malformed format string - %%%s", flags)
+		}
+
+		verb := format[idx]
+
+		arg := named
+		if !hasNamed {
+			val, err := nextArg()
+			if err != nil {
+				return "", err
+			}
+			arg = val
+		}
+
+		arg = erbUnwrap(arg)
+
+		switch verb {
+		case 's':
+			str, err := i.toS(arg)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(fmt.Sprintf("%"+flags+"s", str))
+
+		case 'p':
+			buf.WriteString(fmt.Sprintf("%"+flags+"s", erbInspect(arg)))
+
+		case 'd', 'i', 'u', 'x', 'X', 'o', 'b', 'B', 'c':
+			if f, ok := arg.(float64); ok {
+				arg = int(math.Floor(f))
+			}
+
+			val, err := erbStrictInteger([]interface{}{arg})
+			if err != nil {
+				return "", err
+			}
+
+			switch verb {
+			case 'i', 'u':
+				verb = 'd'
+			case 'B':
+				verb = 'b'
+			}
+
+			if verb == 'c' {
+				buf.WriteString(fmt.Sprintf("%"+flags+"c", rune(val.(int))))
+			} else {
+				buf.WriteString(fmt.Sprintf("%"+flags+string(verb), val.(int)))
+			}
+
+		case 'f', 'e', 'E', 'g', 'G':
+			val, err := erbStrictFloat(arg)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(fmt.Sprintf("%"+flags+string(verb), val.(float64)))
+
+		default:
+			return "", newERBError("ArgumentError", 0, "malformed format string - %%%c", verb)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func (i *erbInterpreter) numMethod(recv interface{}, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	intRecv, isInt := recv.(int)
+	floatRecv, _ := recv.(float64)
+	if isInt {
+		floatRecv = float64(intRecv)
+	}
+
+	switch name {
+	case "+", "-", "*", "/", "%", "**", "modulo", "div", "fdiv", "<", ">", "<=", ">=":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+
+		var argFloat float64
+		argInt, argIsInt := args[0].(int)
+
+		switch typedArg := args[0].(type) {
+		case int:
+			argFloat = float64(typedArg)
+		case float64:
+			argFloat = typedArg
+		default:
+			switch name {
+			case "<", ">", "<=", ">=":
+				return nil, true, newERBError("ArgumentError", 0, "comparison of %s with %s failed", erbClassName(recv), erbInspect(args[0]))
+			}
+			return nil, true, newERBError("TypeError", 0, "%s can't be coerced into %s", erbConvName(args[0]), erbClassName(recv))
+		}
+
+		bothInts := isInt && argIsInt
+
+		switch name {
+		case "<":
+			return floatRecv < argFloat, true, nil
+		case ">":
+			return floatRecv > argFloat, true, nil
+		case "<=":
+			return floatRecv <= argFloat, true, nil
+		case ">=":
+			return floatRecv >= argFloat, true, nil
+		case "+":
+			if bothInts {
+				result, err := erbIntOp(name, intRecv, argInt)
+				return result, true, err
+			}
+			return floatRecv + argFloat, true, nil
+		case "-":
+			if bothInts {
+				result, err := erbIntOp(name, intRecv, argInt)
+				return result, true, err
+			}
+			return floatRecv - argFloat, true, nil
+		case "*":
+			if bothInts {
+				result, err := erbIntOp(name, intRecv, argInt)
+				return result, true, err
+			}
+			return floatRecv * argFloat, true, nil
+		case "/", "div":
+			if bothInts {
+				if argInt == 0 {
+					return nil, true, newERBError("ZeroDivisionError", 0, "divided by 0")
+				}
+				return erbFloorDiv(intRecv, argInt), true, nil
+			}
+			if name == "div" {
+				result, err := erbFloatToInt(math.Floor(floatRecv / argFloat))
+				return result, true, err
+			}
+			return floatRecv / argFloat, true, nil
+		case "fdiv":
+			return floatRecv / argFloat, true, nil
+		case "%", "modulo":
+			if bothInts {
+				if argInt == 0 {
+					return nil, true, newERBError("ZeroDivisionError", 0, "divided by 0")
+				}
+				return intRecv - argInt*erbFloorDiv(intRecv, argInt), true, nil
+			}
+			return floatRecv - argFloat*math.Floor(floatRecv/argFloat), true, nil
+		case "**":
+			if bothInts && argInt >= 0 {
+				result, err := erbIntOp(name, intRecv, argInt)
+				return result, true, err
+			}
+			return math.Pow(floatRecv, argFloat), true, nil
+		}
+
+	case "-@":
+		if isInt {
+			return -intRecv, true, nil
+		}
+		return -floatRecv, true, nil
+	case "+@":
+		return recv, true, nil
+	case "abs", "magnitude":
+		if isInt {
+			if intRecv < 0 {
+				return -intRecv, true, nil
+			}
+			return intRecv, true, nil
+		}
+		return math.Abs(floatRecv), true, nil
+	case "to_i", "to_int", "truncate":
+		if isInt {
+			return intRecv, true, nil
+		}
+		result, err := erbFloatToInt(floatRecv)
+		return result, true, err
+	case "to_f":
+		return floatRecv, true, nil
+	case "to_s", "inspect":
+		if isInt && len(args) > 0 {
+			base, err := erbArgRadix(args, 0)
+			if err != nil {
+				return nil, true, err
+			}
+			return strconv.FormatInt(int64(intRecv), base), true, nil
+		}
+		return erbInspect(recv), true, nil
+	case "round", "ceil", "floor":
+		digits := 0
+		if len(args) > 0 {
+			d, err := erbArgInt(args, 0)
+			if err != nil {
+				return nil, true, err
+			}
+			digits = d
+		}
+
+		if isInt && digits >= 0 {
+			return intRecv, true, nil
+		}
+
+		scale := math.Pow(10, float64(digits))
+		val := floatRecv * scale
+
+		switch name {
+		case "round":
+			// Ruby rounds half away from zero
+			if val < 0 {
+				val = -math.Floor(-val + 0.5)
+			} else {
+				val = math.Floor(val + 0.5)
+			}
+		case "ceil":
+			val = math.Ceil(val)
+		default:
+			val = math.Floor(val)
+		}
+
+		if digits > 0 {
+			return val / scale, true, nil
+		}
+		result, err := erbFloatToInt(val / scale)
+		return result, true, err
+	case "times":
+		if !isInt {
+			return nil, false, nil
+		}
+		var items []interface{}
+		for n := 0; n < intRecv; n++ {
+			items = append(items, n)
+		}
+		if blk == nil {
+			return &erbEnumerator{Items: items, Method: "each", Recv: recv}, true, nil
+		}
+		for _, item := range items {
+			if _, err := i.callProc(blk, item); err != nil {
+				return nil, true, err
+			}
+		}
+		return recv, true, nil
+	case "upto", "downto":
+		if err := erbArgc(args, 1, 1); err != nil || !isInt {
+			return nil, err != nil, err
+		}
+		limit, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		var items []interface{}
+		if name == "upto" {
+			for n := intRecv; n <= limit; n++ {
+				items = append(items, n)
+			}
+		} else {
+			for n := intRecv; n >= limit; n-- {
+				items = append(items, n)
+			}
+		}
+		if blk == nil {
+			return &erbEnumerator{Items: items, Method: "each", Recv: recv}, true, nil
+		}
+		for _, item := range items {
+			if _, err := i.callProc(blk, item); err != nil {
+				return nil, true, err
+			}
+		}
+		return recv, true, nil
+	case "succ", "next":
+		if isInt {
+			return intRecv + 1, true, nil
+		}
+	case "pred":
+		if isInt {
+			return intRecv - 1, true, nil
+		}
+	case "even?":
+		if isInt {
+			return intRecv%2 == 0, true, nil
+		}
+	case "odd?":
+		if isInt {
+			return intRecv%2 != 0, true, nil
+		}
+	case "zero?":
+		return floatRecv == 0, true, nil
+	case "positive?":
+		return floatRecv > 0, true, nil
+	case "negative?":
+		return floatRecv < 0, true, nil
+	case "integer?":
+		return isInt, true, nil
+	case "nan?":
+		return math.IsNaN(floatRecv), true, nil
+	case "finite?":
+		return !math.IsInf(floatRecv, 0) && !math.IsNaN(floatRecv), true, nil
+	case "infinite?":
+		if math.IsInf(floatRecv, 1) {
+			return 1, true, nil
+		} else if math.IsInf(floatRecv, -1) {
+			return -1, true, nil
+		}
+		return nil, true, nil
+	case "between?":
+		if err := erbArgc(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		min, err := erbCompare(recv, args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		max, err := erbCompare(recv, args[1])
+		if err != nil {
+			return nil, true, err
+		}
+		return min >= 0 && max <= 0, true, nil
+	case "clamp":
+		if err := erbArgc(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		if cmp, err := erbCompare(recv, args[0]); err != nil || cmp < 0 {
+			return args[0], true, err
+		}
+		if cmp, err := erbCompare(recv, args[1]); err != nil || cmp > 0 {
+			return args[1], true, err
+		}
+		return recv, true, nil
+	case "chr":
+		if isInt {
+			return string(rune(intRecv)), true, nil
+		}
+	case "ord":
+		if isInt {
+			return intRecv, true, nil
+		}
+	case "hash":
+		return intRecv, true, nil
+	case "coerce":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return newERBArray(args[0], recv), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// erbIntOp performs integer arithmetic which in Ruby would
+// switch to arbitrary precision instead of overflowing
+func erbIntOp(op string, a, b int) (int, error) {
+	x, y := big.NewInt(int64(a)), big.NewInt(int64(b))
+
+	switch op {
+	case "+":
+		x.Add(x, y)
+	case "-":
+		x.Sub(x, y)
+	case "*":
+		x.Mul(x, y)
+	case "**":
+		// Avoid computing huge powers only to find out they do not fit
+		if x.CmpAbs(big.NewInt(1)) > 0 && b >= 64 {
+			return 0, newERBError("RangeError", 0, "%d ** %d does not fit into 64-bit Integer", a, b)
+		}
+		x.Exp(x, y, nil)
+	}
+
+	if !x.IsInt64() {
+		return 0, newERBError("RangeError", 0, "%d %s %d does not fit into 64-bit Integer", a, op, b)
+	}
+
+	return int(x.Int64()), nil
+}
+
+// erbFloatToInt converts Float to Integer failing for values that do not fit
+func erbFloatToInt(f float64) (int, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, newERBError("FloatDomainError", 0, "%s", erbFloatToS(f))
+	}
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, newERBError("RangeError", 0, "%s does not fit into 64-bit Integer", erbFloatToS(f))
+	}
+	return int(f), nil
+}
+
+// erbArgRadix returns base for Integer#to_s and String#to_i
+func erbArgRadix(args []interface{}, idx int) (int, error) {
+	base, err := erbArgInt(args, idx)
+	if err != nil {
+		return 0, err
+	}
+	if base < 2 || base > 36 {
+		return 0, newERBError("ArgumentError", 0, "invalid radix %d", base)
+	}
+	return base, nil
+}
+
+func erbFloorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// erbRuneIndex converts possibly negative index into rune slice index
+func erbRuneIndex(idx, length int) (int, bool) {
+	if idx < 0 {
+		idx += length
+	}
+	return idx, idx >= 0 && idx <= length
+}
+
+func (i *erbInterpreter) strPattern(arg interface{}) (*regexp.Regexp, error) {
+	switch typedArg := arg.(type) {
+	case string:
+		return regexp.MustCompile(regexp.QuoteMeta(typedArg)), nil
+	case *erbRegexp:
+		return typedArg.Re, nil
+	}
+	return nil, newERBError("TypeError", 0, "wrong argument type %s (expected Regexp)", erbClassName(arg))
+}
+
+// erbExpandReplacement substitutes \1, \k<name>, \0 and \& in replacement strings
+func erbExpandReplacement(re *regexp.Regexp, repl, src string, match []int) string {
+	var buf bytes.Buffer
+
+	group := func(idx int) {
+		if idx*2+1 < len(match) && match[idx*2] >= 0 {
+			buf.WriteString(src[match[idx*2]:match[idx*2+1]])
+		}
+	}
+
+	for idx := 0; idx < len(repl); idx++ {
+		c := repl[idx]
+		if c != '\\' || idx+1 >= len(repl) {
+			buf.WriteByte(c)
+			continue
+		}
+
+		next := repl[idx+1]
+		idx++
+
+		switch {
+		case next >= '0' && next <= '9':
+			group(int(next - '0'))
+		case next == '&':
+			group(0)
+		case next == '\\':
+			buf.WriteByte('\\')
+		case next == 'k' && idx+1 < len(repl) && repl[idx+1] == '<':
+			end := strings.IndexByte(repl[idx:], '>')
+			if end < 0 {
+				buf.WriteString(`\k`)
+				continue
+			}
+			groupIdx := re.SubexpIndex(repl[idx+2 : idx+end])
+			if groupIdx >= 0 {
+				group(groupIdx)
+			}
+			idx += end
+		default:
+			buf.WriteByte('\\')
+			buf.WriteByte(next)
+		}
+	}
+
+	return buf.String()
+}
+
+func (i *erbInterpreter) substitute(str string, args []interface{}, blk *erbProc, global bool) (interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, erbArgc(args, 1, 2)
+	}
+
+	re, err := i.strPattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	last := 0
+
+	for _, match := range re.FindAllStringSubmatchIndex(str, -1) {
+		buf.WriteString(str[last:match[0]])
+
+		matched := str[match[0]:match[1]]
+
+		switch {
+		case len(args) == 2:
+			switch typedRepl := args[1].(type) {
+			case string:
+				buf.WriteString(erbExpandReplacement(re, typedRepl, str, match))
+			case *erbHash:
+				val, _ := typedRepl.Get(matched)
+				buf.WriteString(erbToS(val))
+			default:
+				return nil, newERBError("TypeError", 0, "no implicit conversion of %s into String", erbConvName(args[1]))
+			}
+
+		case blk != nil:
+			i.lastMatch = newERBMatchData(re, str, match)
+
+			val, err := i.callProc(blk, matched)
+			if err != nil {
+				return nil, err
+			}
+
+			replStr, err := i.toS(val)
+			if err != nil {
+				return nil, err
+			}
+
+			buf.WriteString(replStr)
+
+		default:
+			return nil, erbArgc(args, 2, 2)
+		}
+
+		last = match[1]
+
+		if !global {
+			break
+		}
+	}
+
+	buf.WriteString(str[last:])
+
+	return buf.String(), nil
+}
+
+func erbSplitTrailing(parts []string, limit int) *erbArray {
+	if limit == 0 {
+		for len(parts) > 0 && parts[len(parts)-1] == "" {
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	arr := newERBArray()
+	for _, part := range parts {
+		arr.Items = append(arr.Items, part)
+	}
+
+	return arr
+}
+
+func (i *erbInterpreter) strMethod(str string, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	runes := []rune(str)
+
+	switch name {
+	case "+":
+		other, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		return str + other, true, nil
+	case "*":
+		count, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if count < 0 {
+			return nil, true, newERBError("ArgumentError", 0, "negative argument")
+		}
+		return strings.Repeat(str, count), true, nil
+	case "%":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		fmtArgs := []interface{}{args[0]}
+		if arr, ok := args[0].(*erbArray); ok {
+			fmtArgs = arr.Items
+		}
+		result, err := i.format(str, fmtArgs)
+		return result, true, err
+	case "replace":
+		other, err := erbArgStr(args, 0)
+		return other, true, err
+	case "prepend":
+		other, err := erbArgStr(args, 0)
+		return other + str, true, err
+	case "insert":
+		idx, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		other, err := erbArgStr(args, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(runes) + 1
+		}
+		if idx < 0 || idx > len(runes) {
+			return nil, true, newERBError("IndexError", 0, "index %d out of string", idx)
+		}
+		return string(runes[:idx]) + other + string(runes[idx:]), true, nil
+	case "==", "eql?", "===":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return str == args[0], true, nil
+	case "<", ">", "<=", ">=":
+		other, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, newERBError("ArgumentError", 0, "comparison of String with %s failed", erbInspect(args[0]))
+		}
+		cmp := strings.Compare(str, other)
+		switch name {
+		case "<":
+			return cmp < 0, true, nil
+		case ">":
+			return cmp > 0, true, nil
+		case "<=":
+			return cmp <= 0, true, nil
+		}
+		return cmp >= 0, true, nil
+	case "=~":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		re, ok := args[0].(*erbRegexp)
+		if !ok {
+			return nil, true, newERBError("TypeError", 0, "wrong argument type %s (expected Regexp)", erbClassName(args[0]))
+		}
+		result, _, err := i.regexpMethod(re, "=~", []interface{}{str}, nil)
+		return result, true, err
+	case "match", "match?":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		var re *erbRegexp
+		switch typedArg := args[0].(type) {
+		case *erbRegexp:
+			re = typedArg
+		case string:
+			compiled, err := newERBRegexp(typedArg, "")
+			if err != nil {
+				return nil, true, err
+			}
+			re = compiled
+		default:
+			return nil, true, newERBError("TypeError", 0, "wrong argument type %s (expected Regexp)", erbClassName(args[0]))
+		}
+		result, _, err := i.regexpMethod(re, name, []interface{}{str}, nil)
+		return result, true, err
+	case "[]", "slice":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		switch typedArg := args[0].(type) {
+		case int:
+			idx, ok := erbRuneIndex(typedArg, len(runes))
+			if len(args) == 2 {
+				length, err := erbArgInt(args, 1)
+				if err != nil {
+					return nil, true, err
+				}
+				if !ok || length < 0 {
+					return nil, true, nil
+				}
+				end := idx + length
+				if end > len(runes) {
+					end = len(runes)
+				}
+				return string(runes[idx:end]), true, nil
+			}
+			if !ok || idx >= len(runes) {
+				return nil, true, nil
+			}
+			return string(runes[idx]), true, nil
+		case *erbRange:
+			items, err := i.sliceRange(len(runes), typedArg)
+			if err != nil || items == nil {
+				return nil, true, err
+			}
+			return string(runes[items[0]:items[1]]), true, nil
+		case string:
+			if strings.Contains(str, typedArg) {
+				return typedArg, true, nil
+			}
+			return nil, true, nil
+		case *erbRegexp:
+			match := typedArg.Re.FindStringSubmatchIndex(str)
+			if match == nil {
+				return nil, true, nil
+			}
+			md := newERBMatchData(typedArg.Re, str, match)
+			if len(args) == 2 {
+				result, _, err := i.matchDataMethod(md, "[]", args[1:])
+				return result, true, err
+			}
+			return md.Captures[0], true, nil
+		}
+		return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Integer", erbConvName(args[0]))
+	case "length", "size":
+		return len(runes), true, nil
+	case "bytesize":
+		return len(str), true, nil
+	case "empty?":
+		return len(str) == 0, true, nil
+	case "upcase":
+		return strings.ToUpper(str), true, nil
+	case "downcase":
+		return strings.ToLower(str), true, nil
+	case "capitalize":
+		if len(runes) == 0 {
+			return str, true, nil
+		}
+		return strings.ToUpper(string(runes[0])) + strings.ToLower(string(runes[1:])), true, nil
+	case "swapcase":
+		return strings.Map(func(r rune) rune {
+			if unicode.IsUpper(r) {
+				return unicode.ToLower(r)
+			}
+			return unicode.ToUpper(r)
+		}, str), true, nil
+	case "strip":
+		return strings.TrimFunc(str, erbIsSpace), true, nil
+	case "lstrip":
+		return strings.TrimLeftFunc(str, erbIsSpace), true, nil
+	case "rstrip":
+		return strings.TrimRightFunc(str, erbIsSpace), true, nil
+	case "chomp":
+		if len(args) > 0 {
+			suffix, err := erbArgStr(args, 0)
+			return strings.TrimSuffix(str, suffix), true, err
+		}
+		if strings.HasSuffix(str, "\r\n") {
+			return str[:len(str)-2], true, nil
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(str, "\n"), "\r"), true, nil
+	case "chop":
+		if strings.HasSuffix(str, "\r\n") {
+			return str[:len(str)-2], true, nil
+		}
+		if len(runes) == 0 {
+			return str, true, nil
+		}
+		return string(runes[:len(runes)-1]), true, nil
+	case "chars", "each_char":
+		arr := newERBArray()
+		for _, r := range runes {
+			arr.Items = append(arr.Items, string(r))
+		}
+		if name == "each_char" {
+			return i.eachOrEnum(str, arr.Items, blk)
+		}
+		return arr, true, nil
+	case "bytes":
+		arr := newERBArray()
+		for _, b := range []byte(str) {
+			arr.Items = append(arr.Items, int(b))
+		}
+		return arr, true, nil
+	case "lines", "each_line":
+		arr := newERBArray()
+		for len(str) > 0 {
+			idx := strings.IndexByte(str, '\n')
+			if idx < 0 {
+				arr.Items = append(arr.Items, str)
+				break
+			}
+			arr.Items = append(arr.Items, str[:idx+1])
+			str = str[idx+1:]
+		}
+		if name == "each_line" {
+			return i.eachOrEnum(str, arr.Items, blk)
+		}
+		return arr, true, nil
+	case "split":
+		if err := erbArgc(args, 0, 2); err != nil {
+			return nil, true, err
+		}
+		limit := 0
+		if len(args) == 2 {
+			l, err := erbArgInt(args, 1)
+			if err != nil {
+				return nil, true, err
+			}
+			limit = l
+		}
+		if len(str) == 0 {
+			return newERBArray(), true, nil
+		}
+		n := -1
+		if limit > 0 {
+			n = limit
+		}
+		if len(args) == 0 || args[0] == nil || args[0] == " " {
+			if n > 0 {
+				fields := regexp.MustCompile(`\s+`).Split(strings.TrimLeftFunc(str, unicode.IsSpace), n)
+				return erbSplitTrailing(fields, limit), true, nil
+			}
+			return erbSplitTrailing(strings.Fields(str), limit), true, nil
+		}
+		switch typedSep := args[0].(type) {
+		case string:
+			if len(typedSep) == 0 {
+				var parts []string
+				for _, r := range runes {
+					parts = append(parts, string(r))
+				}
+				return erbSplitTrailing(parts, limit), true, nil
+			}
+			return erbSplitTrailing(strings.SplitN(str, typedSep, n), limit), true, nil
+		case *erbRegexp:
+			return erbSplitTrailing(typedSep.Re.Split(str, n), limit), true, nil
+		}
+		return nil, true, newERBError("TypeError", 0, "wrong argument type %s (expected Regexp)", erbClassName(args[0]))
+	case "include?":
+		other, err := erbArgStr(args, 0)
+		return strings.Contains(str, other), true, err
+	case "start_with?", "end_with?":
+		for _, arg := range args {
+			switch typedArg := arg.(type) {
+			case string:
+				if name == "start_with?" && strings.HasPrefix(str, typedArg) {
+					return true, true, nil
+				}
+				if name == "end_with?" && strings.HasSuffix(str, typedArg) {
+					return true, true, nil
+				}
+			case *erbRegexp:
+				if loc := typedArg.Re.FindStringIndex(str); loc != nil && loc[0] == 0 && name == "start_with?" {
+					return true, true, nil
+				}
+			default:
+				return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into String", erbConvName(arg))
+			}
+		}
+		return false, true, nil
+	case "index", "rindex":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		// Index search starts from, rindex search ends at given position
+		pos := 0
+		if name == "rindex" {
+			pos = len(runes)
+		}
+		if len(args) == 2 {
+			p, err := erbArgInt(args, 1)
+			if err != nil {
+				return nil, true, err
+			}
+			if p < 0 {
+				p += len(runes)
+			}
+			if p < 0 || (name == "index" && p > len(runes)) {
+				return nil, true, nil
+			}
+			if p < pos || name == "index" {
+				pos = p
+			}
+		}
+		bytePos := len(string(runes[:pos]))
+		var matches [][]int
+		switch typedArg := args[0].(type) {
+		case string:
+			for start := 0; start <= len(str); {
+				idx := strings.Index(str[start:], typedArg)
+				if idx < 0 {
+					break
+				}
+				matches = append(matches, []int{start + idx})
+				_, size := utf8.DecodeRuneInString(str[start+idx:])
+				start += idx + size
+				if size == 0 {
+					break
+				}
+			}
+		case *erbRegexp:
+			matches = typedArg.Re.FindAllStringIndex(str, -1)
+		default:
+			return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into String", erbConvName(args[0]))
+		}
+		byteIdx := -1
+		for _, match := range matches {
+			if name == "index" && match[0] >= bytePos {
+				byteIdx = match[0]
+				break
+			}
+			if name == "rindex" && match[0] <= bytePos {
+				byteIdx = match[0]
+			}
+		}
+		if byteIdx < 0 {
+			return nil, true, nil
+		}
+		return utf8.RuneCountInString(str[:byteIdx]), true, nil
+	case "sub", "gsub":
+		result, err := i.substitute(str, args, blk, name == "gsub")
+		return result, true, err
+	case "tr", "delete", "squeeze", "count":
+		return i.strCharSetMethod(str, name, args)
+	case "to_s", "to_str":
+		return str, true, nil
+	case "inspect", "dump":
+		return erbInspectString(str), true, nil
+	case "to_sym", "intern":
+		return erbSymbol(str), true, nil
+	case "to_i":
+		base := 10
+		if len(args) > 0 {
+			b, err := erbArgInt(args, 0)
+			if err != nil {
+				return nil, true, err
+			}
+			// Base 0 means prefix of the string determines it
+			if b != 0 {
+				if b, err = erbArgRadix(args, 0); err != nil {
+					return nil, true, err
+				}
+			}
+			base = b
+		}
+		return erbLenientInt(str, base), true, nil
+	case "hex":
+		return erbLenientInt(strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X"), 16), true, nil
+	case "oct":
+		return erbLenientInt(str, 8), true, nil
+	case "to_f":
+		return erbLenientFloat(str), true, nil
+	case "ord":
+		if len(runes) == 0 {
+			return nil, true, newERBError("ArgumentError", 0, "empty string")
+		}
+		return int(runes[0]), true, nil
+	case "center", "ljust", "rjust":
+		width, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		pad := " "
+		if len(args) > 1 {
+			pad, err = erbArgStr(args, 1)
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		total := width - len(runes)
+		if total <= 0 || len(pad) == 0 {
+			return str, true, nil
+		}
+		padding := func(n int) string {
+			padRunes := []rune(strings.Repeat(pad, n))
+			return string(padRunes[:n])
+		}
+		switch name {
+		case "ljust":
+			return str + padding(total), true, nil
+		case "rjust":
+			return padding(total) + str, true, nil
+		}
+		left := total / 2
+		return padding(left) + str + padding(total-left), true, nil
+	case "reverse":
+		reversed := make([]rune, len(runes))
+		for idx, r := range runes {
+			reversed[len(runes)-1-idx] = r
+		}
+		return string(reversed), true, nil
+	case "scan":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		re, err := i.strPattern(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		arr := newERBArray()
+		for _, match := range re.FindAllStringSubmatchIndex(str, -1) {
+			md := newERBMatchData(re, str, match)
+			var item interface{} = md.Captures[0]
+			if len(md.Captures) > 1 {
+				item = newERBArray(md.Captures[1:]...)
+			}
+			if blk != nil {
+				i.lastMatch = md
+				if _, err := i.callProc(blk, item); err != nil {
+					return nil, true, err
+				}
+			}
+			arr.Items = append(arr.Items, item)
+		}
+		if blk != nil {
+			return str, true, nil
+		}
+		return arr, true, nil
+	case "partition", "rpartition":
+		sep, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		idx := strings.Index(str, sep)
+		if name == "rpartition" {
+			idx = strings.LastIndex(str, sep)
+		}
+		if idx < 0 {
+			if name == "rpartition" {
+				return newERBArray("", "", str), true, nil
+			}
+			return newERBArray(str, "", ""), true, nil
+		}
+		return newERBArray(str[:idx], sep, str[idx+len(sep):]), true, nil
+	case "casecmp", "casecmp?":
+		other, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if name == "casecmp?" {
+			return strings.EqualFold(str, other), true, nil
+		}
+		return strings.Compare(strings.ToLower(str), strings.ToLower(other)), true, nil
+	case "force_encoding", "encode", "unicode_normalize", "b", "scrub", "-@", "+@":
+		return str, true, nil
+	case "valid_encoding?":
+		return utf8.ValidString(str), true, nil
+	case "ascii_only?":
+		for _, r := range runes {
+			if r > 127 {
+				return false, true, nil
+			}
+		}
+		return true, true, nil
+	case "hash":
+		return erbHashString(str), true, nil
+	case "unpack1", "unpack":
+		if format, err := erbArgStr(args, 0); err == nil && format == "m" || format == "m0" {
+			decoded, err := base64.StdEncoding.DecodeString(strings.Replace(str, "\n", "", -1))
+			if err != nil {
+				return nil, true, newERBError("ArgumentError", 0, "invalid base64")
+			}
+			if name == "unpack" {
+				return newERBArray(string(decoded)), true, nil
+			}
+			return string(decoded), true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func erbIsSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\v' || r == 0
+}
+
+func erbHashString(str string) int {
+	h := 0
+	for _, b := range []byte(str) {
+		h = h*31 + int(b)
+	}
+	return h
+}
+
+// erbCharSet expands Ruby character set notation such as 'a-z' and '^0-9'
+func erbCharSet(spec string) (func(rune) bool, []rune) {
+	runes := []rune(spec)
+
+	negate := len(runes) > 1 && runes[0] == '^'
+	if negate {
+		runes = runes[1:]
+	}
+
+	var expanded []rune
+	for idx := 0; idx < len(runes); idx++ {
+		if idx+2 < len(runes) && runes[idx+1] == '-' {
+			for r := runes[idx]; r <= runes[idx+2]; r++ {
+				expanded = append(expanded, r)
+			}
+			idx += 2
+			continue
+		}
+		if runes[idx] == '\\' && idx+1 < len(runes) {
+			idx++
+		}
+		expanded = append(expanded, runes[idx])
+	}
+
+	contains := func(r rune) bool {
+		for _, e := range expanded {
+			if e == r {
+				return !negate
+			}
+		}
+		return negate
+	}
+
+	return contains, expanded
+}
+
+func (i *erbInterpreter) strCharSetMethod(str string, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "tr":
+		if err := erbArgc(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		from, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		to, err := erbArgStr(args, 1)
+		if err != nil {
+			return nil, true, err
+		}
+
+		contains, fromRunes := erbCharSet(from)
+		_, toRunes := erbCharSet(to)
+		negate := strings.HasPrefix(from, "^") && len(from) > 1
+
+		var buf bytes.Buffer
+		for _, r := range str {
+			if !contains(r) {
+				buf.WriteRune(r)
+				continue
+			}
+			if len(toRunes) == 0 {
+				continue
+			}
+			replacement := toRunes[len(toRunes)-1]
+			if !negate {
+				for idx, fr := range fromRunes {
+					if fr == r {
+						if idx < len(toRunes) {
+							replacement = toRunes[idx]
+						}
+						break
+					}
+				}
+			}
+			buf.WriteRune(replacement)
+		}
+		return buf.String(), true, nil
+
+	case "delete", "count", "squeeze":
+		var sets []func(rune) bool
+		for idx := range args {
+			spec, err := erbArgStr(args, idx)
+			if err != nil {
+				return nil, true, err
+			}
+			contains, _ := erbCharSet(spec)
+			sets = append(sets, contains)
+		}
+
+		inAll := func(r rune) bool {
+			for _, contains := range sets {
+				if !contains(r) {
+					return false
+				}
+			}
+			return true
+		}
+
+		switch name {
+		case "delete":
+			if len(sets) == 0 {
+				return nil, true, erbArgc(args, 1, -1)
+			}
+			return strings.Map(func(r rune) rune {
+				if inAll(r) {
+					return -1
+				}
+				return r
+			}, str), true, nil
+
+		case "count":
+			if len(sets) == 0 {
+				return nil, true, erbArgc(args, 1, -1)
+			}
+			count := 0
+			for _, r := range str {
+				if inAll(r) {
+					count++
+				}
+			}
+			return count, true, nil
+
+		default:
+			var buf bytes.Buffer
+			var prev rune = -1
+			for _, r := range str {
+				if r == prev && inAll(r) {
+					continue
+				}
+				buf.WriteRune(r)
+				prev = r
+			}
+			return buf.String(), true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) symMethod(sym erbSymbol, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "to_s", "id2name", "name":
+		return string(sym), true, nil
+	case "to_sym":
+		return sym, true, nil
+	case "to_proc":
+		return &erbProc{Sym: string(sym)}, true, nil
+	case "<=>":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		cmp, err := erbCompare(sym, args[0])
+		if err != nil {
+			return nil, true, nil
+		}
+		return cmp, true, nil
+	case "upcase", "downcase", "capitalize", "swapcase", "succ":
+		result, found, err := i.strMethod(string(sym), name, args, blk)
+		if str, ok := result.(string); ok {
+			return erbSymbol(str), found, err
+		}
+		return result, found, err
+	case "length", "size", "empty?", "start_with?", "end_with?", "[]":
+		return i.strMethod(string(sym), name, args, blk)
+	}
+
+	return nil, false, nil
+}
+
+// sliceRange resolves range into start and end indices for sequence of given length
+func (i *erbInterpreter) sliceRange(length int, rng *erbRange) ([]int, error) {
+	from, ok := rng.From.(int)
+	if !ok {
+		return nil, newERBError("TypeError", 0, "no implicit conversion of %s into Integer", erbConvName(rng.From))
+	}
+
+	to := -1
+	if rng.To != nil {
+		t, ok := rng.To.(int)
+		if !ok {
+			return nil, newERBError("TypeError", 0, "no implicit conversion of %s into Integer", erbConvName(rng.To))
+		}
+		to = t
+	}
+
+	if from < 0 {
+		from += length
+	}
+	if to < 0 {
+		to += length
+	}
+	if !rng.Exclusive || rng.To == nil {
+		to++
+	}
+
+	if from < 0 || from > length {
+		return nil, nil
+	}
+	if to > length {
+		to = length
+	}
+	if to < from {
+		to = from
+	}
+
+	return []int{from, to}, nil
+}
+
+func (i *erbInterpreter) eachOrEnum(recv interface{}, items []interface{}, blk *erbProc) (interface{}, bool, error) {
+	if blk == nil {
+		return &erbEnumerator{Items: items, Method: "each", Recv: recv}, true, nil
+	}
+
+	for _, item := range items {
+		if _, err := i.callProc(blk, item); err != nil {
+			return nil, true, err
+		}
+	}
+
+	return recv, true, nil
+}
+
+func (i *erbInterpreter) arrMethod(arr *erbArray, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	items := arr.Items
+
+	switch name {
+	case "[]", "slice":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		switch typedArg := args[0].(type) {
+		case int, float64:
+			idx, _ := erbArgInt(args, 0)
+			if idx < 0 {
+				idx += len(items)
+			}
+			if len(args) == 2 {
+				length, err := erbArgInt(args, 1)
+				if err != nil {
+					return nil, true, err
+				}
+				if idx < 0 || idx > len(items) || length < 0 {
+					return nil, true, nil
+				}
+				end := idx + length
+				if end > len(items) {
+					end = len(items)
+				}
+				return newERBArray(append([]interface{}{}, items[idx:end]...)...), true, nil
+			}
+			if idx < 0 || idx >= len(items) {
+				return nil, true, nil
+			}
+			return items[idx], true, nil
+		case *erbRange:
+			bounds, err := i.sliceRange(len(items), typedArg)
+			if err != nil || bounds == nil {
+				return nil, true, err
+			}
+			return newERBArray(append([]interface{}{}, items[bounds[0]:bounds[1]]...)...), true, nil
+		}
+		return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Integer", erbConvName(args[0]))
+	case "[]=":
+		if err := erbArgc(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		idx, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items)
+			if idx < 0 {
+				return nil, true, newERBError("IndexError", 0, "index %d too small for array", idx-len(items))
+			}
+		}
+		for len(arr.Items) <= idx {
+			arr.Items = append(arr.Items, nil)
+		}
+		arr.Items[idx] = args[1]
+		return args[1], true, nil
+	case "at":
+		return i.arrMethod(arr, "[]", args, blk)
+	case "dig":
+		return i.dig(arr, args)
+	case "fetch":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		idx, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		origIdx := idx
+		if idx < 0 {
+			idx += len(items)
+		}
+		if idx >= 0 && idx < len(items) {
+			return items[idx], true, nil
+		}
+		if blk != nil {
+			result, err := i.callProc(blk, args[0])
+			return result, true, err
+		}
+		if len(args) == 2 {
+			return args[1], true, nil
+		}
+		return nil, true, newERBError("IndexError", 0, "index %d outside of array bounds: %d...%d", origIdx, -len(items), len(items))
+	case "length", "size":
+		return len(items), true, nil
+	case "empty?":
+		return len(items) == 0, true, nil
+	case "push", "append", "<<":
+		if name == "<<" {
+			if err := erbArgc(args, 1, 1); err != nil {
+				return nil, true, err
+			}
+		}
+		arr.Items = append(arr.Items, args...)
+		return arr, true, nil
+	case "concat":
+		for _, arg := range args {
+			other, ok := arg.(*erbArray)
+			if !ok {
+				return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Array", erbConvName(arg))
+			}
+			arr.Items = append(arr.Items, other.Items...)
+		}
+		return arr, true, nil
+	case "pop", "shift":
+		if len(items) == 0 {
+			return nil, true, nil
+		}
+		if name == "pop" {
+			arr.Items = items[:len(items)-1]
+			return items[len(items)-1], true, nil
+		}
+		arr.Items = items[1:]
+		return items[0], true, nil
+	case "unshift", "prepend":
+		arr.Items = append(append([]interface{}{}, args...), items...)
+		return arr, true, nil
+	case "insert":
+		if err := erbArgc(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		idx, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items) + 1
+			if idx < 0 {
+				return nil, true, newERBError("IndexError", 0, "index %d too small for array; minimum: -%d", idx-len(items)-1, len(items)+1)
+			}
+		}
+		for len(arr.Items) < idx {
+			arr.Items = append(arr.Items, nil)
+		}
+		rest := append([]interface{}{}, arr.Items[idx:]...)
+		arr.Items = append(append(arr.Items[:idx], args[1:]...), rest...)
+		return arr, true, nil
+	case "delete":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		var kept []interface{}
+		var deleted interface{}
+		for _, item := range items {
+			if erbEqual(item, args[0]) {
+				deleted = item
+			} else {
+				kept = append(kept, item)
+			}
+		}
+		arr.Items = kept
+		return deleted, true, nil
+	case "delete_at":
+		idx, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items)
+		}
+		if idx < 0 || idx >= len(items) {
+			return nil, true, nil
+		}
+		deleted := items[idx]
+		arr.Items = append(append([]interface{}{}, items[:idx]...), items[idx+1:]...)
+		return deleted, true, nil
+	case "delete_if", "reject!", "keep_if", "select!", "filter!", "map!", "collect!", "compact!", "uniq!", "flatten!", "sort!", "sort_by!", "reverse!", "shuffle!":
+		method := strings.TrimSuffix(name, "!")
+		switch name {
+		case "delete_if":
+			method = "reject"
+		case "keep_if":
+			method = "select"
+		}
+		result, found, err := i.arrMethod(arr, method, args, blk)
+		if err != nil || !found {
+			return result, found, err
+		}
+		if resultArr, ok := result.(*erbArray); ok {
+			arr.Items = resultArr.Items
+		}
+		return arr, true, nil
+	case "clear":
+		arr.Items = nil
+		return arr, true, nil
+	case "replace":
+		other, ok := args[0].(*erbArray)
+		if !ok {
+			return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Array", erbConvName(args[0]))
+		}
+		arr.Items = append([]interface{}{}, other.Items...)
+		return arr, true, nil
+	case "+":
+		other, ok := args[0].(*erbArray)
+		if !ok {
+			return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Array", erbConvName(args[0]))
+		}
+		return newERBArray(append(append([]interface{}{}, items...), other.Items...)...), true, nil
+	case "-", "&", "|":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		other, ok := args[0].(*erbArray)
+		if !ok {
+			return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Array", erbConvName(args[0]))
+		}
+		contains := func(list []interface{}, val interface{}) bool {
+			for _, item := range list {
+				if erbEqual(item, val) {
+					return true
+				}
+			}
+			return false
+		}
+		result := newERBArray()
+		switch name {
+		case "-":
+			for _, item := range items {
+				if !contains(other.Items, item) {
+					result.Items = append(result.Items, item)
+				}
+			}
+			return result, true, nil
+		case "&":
+			for _, item := range items {
+				if contains(other.Items, item) && !contains(result.Items, item) {
+					result.Items = append(result.Items, item)
+				}
+			}
+			return result, true, nil
+		}
+		for _, item := range append(append([]interface{}{}, items...), other.Items...) {
+			if !contains(result.Items, item) {
+				result.Items = append(result.Items, item)
+			}
+		}
+		return result, true, nil
+	case "*":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		if sep, ok := args[0].(string); ok {
+			return i.arrMethod(arr, "join", []interface{}{sep}, nil)
+		}
+		count, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if count < 0 {
+			return nil, true, newERBError("ArgumentError", 0, "negative argument")
+		}
+		result := newERBArray()
+		for n := 0; n < count; n++ {
+			result.Items = append(result.Items, items...)
+		}
+		return result, true, nil
+	case "join":
+		sep := ""
+		if len(args) > 0 && args[0] != nil {
+			s, err := erbArgStr(args, 0)
+			if err != nil {
+				return nil, true, err
+			}
+			sep = s
+		}
+		strs, err := i.joinStrs(items)
+		if err != nil {
+			return nil, true, err
+		}
+		return strings.Join(strs, sep), true, nil
+	case "to_a", "entries", "to_ary":
+		return arr, true, nil
+	case "values_at":
+		result := newERBArray()
+		for idx := range args {
+			val, _, err := i.arrMethod(arr, "[]", args[idx:idx+1], nil)
+			if err != nil {
+				return nil, true, err
+			}
+			result.Items = append(result.Items, val)
+		}
+		return result, true, nil
+	case "each_index":
+		var idxs []interface{}
+		for idx := range items {
+			idxs = append(idxs, idx)
+		}
+		_, found, err := i.eachOrEnum(arr, idxs, blk)
+		return arr, found, err
+	case "each":
+		return i.eachOrEnum(arr, items, blk)
+	case "hash":
+		return erbHashString(erbInspect(arr)), true, nil
+	case "pack":
+		if format, err := erbArgStr(args, 0); err == nil && (format == "m" || format == "m0") && len(items) == 1 {
+			str, ok := items[0].(string)
+			if ok {
+				if format == "m0" {
+					return base64.StdEncoding.EncodeToString([]byte(str)), true, nil
+				}
+				return erbBase64Lines(base64.StdEncoding.EncodeToString([]byte(str))), true, nil
+			}
+		}
+	}
+
+	return i.enumMethod(arr, items, name, args, blk)
+}
+
+func (i *erbInterpreter) joinStrs(items []interface{}) ([]string, error) {
+	var strs []string
+
+	for _, item := range items {
+		if nested, ok := item.(*erbArray); ok {
+			nestedStrs, err := i.joinStrs(nested.Items)
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, nestedStrs...)
+			continue
+		}
+
+		str, err := i.toS(item)
+		if err != nil {
+			return nil, err
+		}
+
+		strs = append(strs, str)
+	}
+
+	return strs, nil
+}
+
+func (i *erbInterpreter) dig(recv interface{}, keys []interface{}) (interface{}, bool, error) {
+	if err := erbArgc(keys, 1, -1); err != nil {
+		return nil, true, err
+	}
+
+	current := recv
+
+	for _, key := range keys {
+		if current == nil {
+			return nil, true, nil
+		}
+
+		switch current.(type) {
+		case *erbArray, *erbHash, *erbOpenStruct:
+		default:
+			return nil, true, newERBError("TypeError", 0, "%s does not have #dig method", erbClassName(current))
+		}
+
+		val, err := i.callMethod(current, "[]", []interface{}{key}, nil)
+		if err != nil {
+			return nil, true, err
+		}
+
+		current = val
+	}
+
+	return current, true, nil
+}
+
+// enumMethod implements Enumerable methods shared by arrays, hashes and ranges;
+// for hashes items are [key, value] pairs
+func (i *erbInterpreter) enumMethod(recv interface{}, items []interface{}, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	yield := func(item interface{}) (interface{}, error) {
+		return i.callProc(blk, item)
+	}
+
+	switch name {
+	case "each":
+		return i.eachOrEnum(recv, items, blk)
+
+	case "each_with_index", "each_entry":
+		var pairs []interface{}
+		for idx, item := range items {
+			pairs = append(pairs, newERBArray(item, idx))
+		}
+		if blk == nil {
+			return &erbEnumerator{Items: pairs, Method: "each", Recv: recv}, true, nil
+		}
+		for idx, item := range items {
+			if _, err := i.callProc(blk, item, idx); err != nil {
+				return nil, true, err
+			}
+		}
+		return recv, true, nil
+
+	case "each_with_object", "with_object":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, item := range items {
+			if _, err := i.callProc(blk, item, args[0]); err != nil {
+				return nil, true, err
+			}
+		}
+		return args[0], true, nil
+
+	case "each_slice", "each_cons":
+		size, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if size <= 0 {
+			return nil, true, newERBError("ArgumentError", 0, "invalid size")
+		}
+		var groups []interface{}
+		if name == "each_slice" {
+			for start := 0; start < len(items); start += size {
+				end := start + size
+				if end > len(items) {
+					end = len(items)
+				}
+				groups = append(groups, newERBArray(append([]interface{}{}, items[start:end]...)...))
+			}
+		} else {
+			for start := 0; start+size <= len(items); start++ {
+				groups = append(groups, newERBArray(append([]interface{}{}, items[start:start+size]...)...))
+			}
+		}
+		if blk == nil {
+			return &erbEnumerator{Items: groups, Method: "each", Recv: recv}, true, nil
+		}
+		for _, group := range groups {
+			if _, err := yield(group); err != nil {
+				return nil, true, err
+			}
+		}
+		return recv, true, nil
+
+	case "map", "collect", "flat_map", "collect_concat", "select", "filter", "reject", "filter_map", "sort_by", "min_by", "max_by", "group_by", "partition", "find_index", "sum", "uniq", "count", "find", "detect", "find_all":
+		if blk == nil {
+			switch name {
+			case "map", "collect", "flat_map", "collect_concat", "select", "filter", "reject", "filter_map", "sort_by", "min_by", "max_by", "group_by", "partition", "find_all":
+				return &erbEnumerator{Items: items, Method: name, Recv: recv}, true, nil
+			}
+		}
+	}
+
+	switch name {
+	case "map", "collect", "flat_map", "collect_concat", "filter_map":
+		result := newERBArray()
+		for _, item := range items {
+			val, err := yield(item)
+			if err != nil {
+				return nil, true, err
+			}
+			switch {
+			case name == "filter_map":
+				if erbTruthy(val) {
+					result.Items = append(result.Items, val)
+				}
+			case name == "flat_map" || name == "collect_concat":
+				if nested, ok := val.(*erbArray); ok {
+					result.Items = append(result.Items, nested.Items...)
+				} else {
+					result.Items = append(result.Items, val)
+				}
+			default:
+				result.Items = append(result.Items, val)
+			}
+		}
+		return result, true, nil
+
+	case "select", "filter", "reject", "find_all":
+		result := newERBArray()
+		for _, item := range items {
+			val, err := yield(item)
+			if err != nil {
+				return nil, true, err
+			}
+			if erbTruthy(val) == (name != "reject") {
+				result.Items = append(result.Items, item)
+			}
+		}
+		return result, true, nil
+
+	case "partition":
+		trues, falses := newERBArray(), newERBArray()
+		for _, item := range items {
+			val, err := yield(item)
+			if err != nil {
+				return nil, true, err
+			}
+			if erbTruthy(val) {
+				trues.Items = append(trues.Items, item)
+			} else {
+				falses.Items = append(falses.Items, item)
+			}
+		}
+		return newERBArray(trues, falses), true, nil
+
+	case "group_by":
+		result := newERBHash()
+		for _, item := range items {
+			key, err := yield(item)
+			if err != nil {
+				return nil, true, err
+			}
+			group, found := result.Get(key)
+			if !found {
+				group = newERBArray()
+				result.Set(key, group)
+			}
+			group.(*erbArray).Items = append(group.(*erbArray).Items, item)
+		}
+		return result, true, nil
+
+	case "tally":
+		result := newERBHash()
+		for _, item := range items {
+			count, _ := result.Get(item)
+			n, _ := count.(int)
+			result.Set(item, n+1)
+		}
+		return result, true, nil
+
+	case "find", "detect":
+		for _, item := range items {
+			val, err := yield(item)
+			if err != nil {
+				return nil, true, err
+			}
+			if erbTruthy(val) {
+				return item, true, nil
+			}
+		}
+		return nil, true, nil
+
+	case "find_index", "index":
+		for idx, item := range items {
+			var matched bool
+			if len(args) > 0 {
+				matched = erbEqual(item, args[0])
+			} else {
+				val, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				matched = erbTruthy(val)
+			}
+			if matched {
+				return idx, true, nil
+			}
+		}
+		return nil, true, nil
+
+	case "rindex":
+		for idx := len(items) - 1; idx >= 0; idx-- {
+			var matched bool
+			if len(args) > 0 {
+				matched = erbEqual(items[idx], args[0])
+			} else {
+				val, err := yield(items[idx])
+				if err != nil {
+					return nil, true, err
+				}
+				matched = erbTruthy(val)
+			}
+			if matched {
+				return idx, true, nil
+			}
+		}
+		return nil, true, nil
+
+	case "include?", "member?":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, item := range items {
+			if erbEqual(item, args[0]) {
+				return true, true, nil
+			}
+		}
+		return false, true, nil
+
+	case "count":
+		if len(args) == 0 && blk == nil {
+			return len(items), true, nil
+		}
+		count := 0
+		for _, item := range items {
+			var matched bool
+			if len(args) > 0 {
+				matched = erbEqual(item, args[0])
+			} else {
+				val, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				matched = erbTruthy(val)
+			}
+			if matched {
+				count++
+			}
+		}
+		return count, true, nil
+
+	case "any?", "all?", "none?", "one?":
+		count := 0
+		for _, item := range items {
+			var matched bool
+			switch {
+			case len(args) > 0:
+				val, err := i.callMethod(args[0], "===", []interface{}{item}, nil)
+				if err != nil {
+					return nil, true, err
+				}
+				matched = erbTruthy(val)
+			case blk != nil:
+				val, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				matched = erbTruthy(val)
+			default:
+				matched = erbTruthy(item)
+			}
+			if matched {
+				count++
+			} else if name == "all?" {
+				return false, true, nil
+			}
+			if matched && name == "any?" {
+				return true, true, nil
+			}
+		}
+		switch name {
+		case "any?":
+			return false, true, nil
+		case "all?":
+			return true, true, nil
+		case "none?":
+			return count == 0, true, nil
+		}
+		return count == 1, true, nil
+
+	case "first", "take":
+		if len(args) == 0 && name == "first" {
+			if len(items) == 0 {
+				return nil, true, nil
+			}
+			return items[0], true, nil
+		}
+		n, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if n < 0 {
+			return nil, true, newERBError("ArgumentError", 0, "negative array size")
+		}
+		if n > len(items) {
+			n = len(items)
+		}
+		return newERBArray(append([]interface{}{}, items[:n]...)...), true, nil
+
+	case "last":
+		if len(args) == 0 {
+			if len(items) == 0 {
+				return nil, true, nil
+			}
+			return items[len(items)-1], true, nil
+		}
+		n, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if n < 0 {
+			return nil, true, newERBError("ArgumentError", 0, "negative array size")
+		}
+		if n > len(items) {
+			n = len(items)
+		}
+		return newERBArray(append([]interface{}{}, items[len(items)-n:]...)...), true, nil
+
+	case "drop":
+		n, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if n < 0 {
+			return nil, true, newERBError("ArgumentError", 0, "attempt to drop negative size")
+		}
+		if n > len(items) {
+			n = len(items)
+		}
+		return newERBArray(append([]interface{}{}, items[n:]...)...), true, nil
+
+	case "take_while", "drop_while":
+		idx := 0
+		for ; idx < len(items); idx++ {
+			val, err := yield(items[idx])
+			if err != nil {
+				return nil, true, err
+			}
+			if !erbTruthy(val) {
+				break
+			}
+		}
+		if name == "take_while" {
+			return newERBArray(append([]interface{}{}, items[:idx]...)...), true, nil
+		}
+		return newERBArray(append([]interface{}{}, items[idx:]...)...), true, nil
+
+	case "sort", "sort_by":
+		sorted := append([]interface{}{}, items...)
+		keys := sorted
+
+		if name == "sort_by" {
+			keys = nil
+			for _, item := range sorted {
+				key, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				keys = append(keys, key)
+			}
+		}
+
+		var sortErr error
+
+		idxs := make([]int, len(sorted))
+		for idx := range idxs {
+			idxs[idx] = idx
+		}
+
+		sort.SliceStable(idxs, func(a, b int) bool {
+			if sortErr != nil {
+				return false
+			}
+			cmp, err := i.compareWith(blk, name == "sort", keys[idxs[a]], keys[idxs[b]])
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			return cmp < 0
+		})
+
+		if sortErr != nil {
+			return nil, true, sortErr
+		}
+
+		result := newERBArray()
+		for _, idx := range idxs {
+			result.Items = append(result.Items, sorted[idx])
+		}
+		return result, true, nil
+
+	case "min", "max", "min_by", "max_by":
+		if len(items) == 0 {
+			return nil, true, nil
+		}
+		best, bestKey := items[0], items[0]
+		if name == "min_by" || name == "max_by" {
+			key, err := yield(items[0])
+			if err != nil {
+				return nil, true, err
+			}
+			bestKey = key
+		}
+		for _, item := range items[1:] {
+			key := item
+			if name == "min_by" || name == "max_by" {
+				k, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				key = k
+			}
+			cmp, err := i.compareWith(blk, name == "min" || name == "max", key, bestKey)
+			if err != nil {
+				return nil, true, err
+			}
+			if (strings.HasPrefix(name, "min") && cmp < 0) || (strings.HasPrefix(name, "max") && cmp > 0) {
+				best, bestKey = item, key
+			}
+		}
+		return best, true, nil
+
+	case "sum":
+		var total interface{} = 0
+		if len(args) > 0 {
+			total = args[0]
+		}
+		for _, item := range items {
+			if blk != nil {
+				val, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				item = val
+			}
+			val, err := i.callMethod(total, "+", []interface{}{item}, nil)
+			if err != nil {
+				return nil, true, err
+			}
+			total = val
+		}
+		return total, true, nil
+
+	case "inject", "reduce":
+		var acc interface{}
+		var op string
+		rest := items
+
+		switch len(args) {
+		case 0:
+		case 1:
+			if sym, ok := args[0].(erbSymbol); ok && blk == nil {
+				op = string(sym)
+			} else {
+				acc = args[0]
+				rest = append([]interface{}{nil}, items...)
+			}
+		case 2:
+			acc = args[0]
+			op = erbToS(args[1])
+			rest = append([]interface{}{nil}, items...)
+		default:
+			return nil, true, erbArgc(args, 0, 2)
+		}
+
+		if len(rest) == 0 {
+			return nil, true, nil
+		}
+
+		if len(args) == 0 || (len(args) == 1 && len(op) > 0) {
+			acc = rest[0]
+		}
+
+		for _, item := range rest[1:] {
+			var err error
+			if len(op) > 0 {
+				acc, err = i.callMethod(acc, op, []interface{}{item}, nil)
+			} else {
+				acc, err = i.callProc(blk, acc, item)
+			}
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		return acc, true, nil
+
+	case "uniq":
+		result := newERBArray()
+		seen := map[interface{}]bool{}
+		for _, item := range items {
+			key := item
+			if blk != nil {
+				val, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				key = val
+			}
+			hashKey := erbHashKey(key)
+			if !seen[hashKey] {
+				seen[hashKey] = true
+				result.Items = append(result.Items, item)
+			}
+		}
+		return result, true, nil
+
+	case "compact":
+		result := newERBArray()
+		for _, item := range items {
+			if item != nil {
+				result.Items = append(result.Items, item)
+			}
+		}
+		return result, true, nil
+
+	case "flatten":
+		depth := -1
+		if len(args) > 0 && args[0] != nil {
+			d, err := erbArgInt(args, 0)
+			if err != nil {
+				return nil, true, err
+			}
+			depth = d
+		}
+		return newERBArray(erbFlatten(items, depth)...), true, nil
+
+	case "reverse", "reverse_each":
+		result := newERBArray()
+		for idx := len(items) - 1; idx >= 0; idx-- {
+			result.Items = append(result.Items, items[idx])
+		}
+		if name == "reverse_each" {
+			return i.eachOrEnum(recv, result.Items, blk)
+		}
+		return result, true, nil
+
+	case "zip":
+		result := newERBArray()
+		for idx, item := range items {
+			tuple := newERBArray(item)
+			for _, arg := range args {
+				other, err := i.toA(arg)
+				if err != nil {
+					return nil, true, err
+				}
+				if idx < len(other) {
+					tuple.Items = append(tuple.Items, other[idx])
+				} else {
+					tuple.Items = append(tuple.Items, nil)
+				}
+			}
+			result.Items = append(result.Items, tuple)
+		}
+		return result, true, nil
+
+	case "to_h":
+		result := newERBHash()
+		for _, item := range items {
+			if blk != nil {
+				val, err := yield(item)
+				if err != nil {
+					return nil, true, err
+				}
+				item = val
+			}
+			pair, ok := item.(*erbArray)
+			if !ok || len(pair.Items) != 2 {
+				return nil, true, newERBError("TypeError", 0, "wrong element type %s (expected array)", erbClassName(item))
+			}
+			result.Set(pair.Items[0], pair.Items[1])
+		}
+		return result, true, nil
+
+	case "to_a", "entries":
+		return newERBArray(append([]interface{}{}, items...)...), true, nil
+
+	case "length", "size":
+		return len(items), true, nil
+
+	case "empty?":
+		return len(items) == 0, true, nil
+
+	case "join":
+		return i.arrMethod(newERBArray(items...), name, args, blk)
+
+	case "<=>":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		cmp, err := erbCompare(newERBArray(items...), args[0])
+		if err != nil {
+			return nil, true, nil
+		}
+		return cmp, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) compareWith(blk *erbProc, useBlock bool, a, b interface{}) (int, error) {
+	if useBlock && blk != nil {
+		val, err := i.callProc(blk, a, b)
+		if err != nil {
+			return 0, err
+		}
+		cmp, ok := val.(int)
+		if !ok {
+			return 0, newERBError("ArgumentError", 0, "comparison of %s with %s failed", erbClassName(a), erbClassName(b))
+		}
+		return cmp, nil
+	}
+
+	return erbCompare(a, b)
+}
+
+func erbFlatten(items []interface{}, depth int) []interface{} {
+	var result []interface{}
+
+	for _, item := range items {
+		if nested, ok := item.(*erbArray); ok && depth != 0 {
+			result = append(result, erbFlatten(nested.Items, depth-1)...)
+		} else {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func (i *erbInterpreter) hashMethod(hash *erbHash, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "[]":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, found := hash.Get(args[0])
+		if !found {
+			if hash.defProc != nil {
+				result, err := i.callProc(hash.defProc, hash, args[0])
+				return result, true, err
+			}
+			return hash.Default(), true, nil
+		}
+		return val, true, nil
+	case "[]=", "store":
+		if err := erbArgc(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		hash.Set(args[0], args[1])
+		return args[1], true, nil
+	case "fetch":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		if val, found := hash.Get(args[0]); found {
+			return val, true, nil
+		}
+		if blk != nil {
+			result, err := i.callProc(blk, args[0])
+			return result, true, err
+		}
+		if len(args) == 2 {
+			return args[1], true, nil
+		}
+		return nil, true, newERBError("KeyError", 0, "key not found: %s", erbInspect(args[0]))
+	case "dig":
+		return i.dig(hash, args)
+	case "key?", "has_key?", "include?", "member?":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		_, found := hash.Get(args[0])
+		return found, true, nil
+	case "value?", "has_value?":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, key := range hash.keys {
+			if erbEqual(hash.val(key), args[0]) {
+				return true, true, nil
+			}
+		}
+		return false, true, nil
+	case "key":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, key := range hash.keys {
+			if erbEqual(hash.val(key), args[0]) {
+				return key, true, nil
+			}
+		}
+		return nil, true, nil
+	case "keys":
+		return newERBArray(hash.Keys()...), true, nil
+	case "values":
+		arr := newERBArray()
+		for _, key := range hash.keys {
+			arr.Items = append(arr.Items, hash.val(key))
+		}
+		return arr, true, nil
+	case "values_at", "fetch_values":
+		arr := newERBArray()
+		for _, arg := range args {
+			val, _, err := i.hashMethod(hash, strings.TrimSuffix(strings.Replace(name, "values_at", "[]", 1), "_values"), []interface{}{arg}, blk)
+			if err != nil {
+				return nil, true, err
+			}
+			arr.Items = append(arr.Items, val)
+		}
+		return arr, true, nil
+	case "length", "size":
+		return hash.Len(), true, nil
+	case "empty?":
+		return hash.Len() == 0, true, nil
+	case "delete":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, _ := hash.Delete(args[0])
+		return val, true, nil
+	case "merge", "merge!", "update":
+		target := hash
+		if name == "merge" {
+			target = hash.Dup()
+		}
+		for _, arg := range args {
+			other, ok := arg.(*erbHash)
+			if !ok {
+				return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Hash", erbConvName(arg))
+			}
+			for _, key := range other.keys {
+				val := other.val(key)
+				if existing, found := target.Get(key); found && blk != nil {
+					merged, err := i.callProc(blk, key, existing, val)
+					if err != nil {
+						return nil, true, err
+					}
+					val = merged
+				}
+				target.Set(key, val)
+			}
+		}
+		return target, true, nil
+	case "each", "each_pair":
+		if blk == nil {
+			return &erbEnumerator{Items: hash.Pairs(), Method: "each", Recv: hash}, true, nil
+		}
+		for _, pair := range hash.Pairs() {
+			if _, err := i.callProc(blk, pair); err != nil {
+				return nil, true, err
+			}
+		}
+		return hash, true, nil
+	case "each_key", "each_value":
+		var items []interface{}
+		for _, key := range hash.keys {
+			if name == "each_key" {
+				items = append(items, key)
+			} else {
+				items = append(items, hash.val(key))
+			}
+		}
+		return i.eachOrEnum(hash, items, blk)
+	case "select", "filter", "reject", "delete_if", "keep_if", "select!", "reject!", "filter!":
+		if blk == nil {
+			return &erbEnumerator{Items: hash.Pairs(), Method: name, Recv: hash}, true, nil
+		}
+		result := newERBHash()
+		for _, key := range hash.keys {
+			val, err := i.callProc(blk, key, hash.val(key))
+			if err != nil {
+				return nil, true, err
+			}
+			keep := erbTruthy(val)
+			if name == "reject" || name == "delete_if" || name == "reject!" {
+				keep = !keep
+			}
+			if keep {
+				result.Set(key, hash.val(key))
+			}
+		}
+		if strings.HasSuffix(name, "!") || name == "delete_if" || name == "keep_if" {
+			hash.keys, hash.vals = result.keys, result.vals
+			return hash, true, nil
+		}
+		return result, true, nil
+	case "find_all":
+		return i.enumMethod(hash, hash.Pairs(), name, args, blk)
+	case "transform_values", "transform_keys":
+		result := newERBHash()
+		for _, key := range hash.keys {
+			val := hash.val(key)
+			target := val
+			if name == "transform_keys" {
+				target = key
+			}
+			transformed, err := i.callProc(blk, target)
+			if err != nil {
+				return nil, true, err
+			}
+			if name == "transform_keys" {
+				result.Set(transformed, val)
+			} else {
+				result.Set(key, transformed)
+			}
+		}
+		return result, true, nil
+	case "invert":
+		result := newERBHash()
+		for _, key := range hash.keys {
+			result.Set(hash.val(key), key)
+		}
+		return result, true, nil
+	case "slice", "except":
+		result := newERBHash()
+		for _, key := range hash.keys {
+			listed := false
+			for _, arg := range args {
+				if erbEqual(erbHashKey(arg), key) {
+					listed = true
+				}
+			}
+			if listed == (name == "slice") {
+				result.Set(key, hash.val(key))
+			}
+		}
+		return result, true, nil
+	case "compact":
+		result := newERBHash()
+		for _, key := range hash.keys {
+			if hash.val(key) != nil {
+				result.Set(key, hash.val(key))
+			}
+		}
+		return result, true, nil
+	case "clear":
+		hash.keys, hash.vals = nil, map[interface{}]interface{}{}
+		return hash, true, nil
+	case "to_h":
+		if blk == nil {
+			return hash, true, nil
+		}
+	case "to_hash":
+		return hash, true, nil
+	case "default":
+		return hash.Default(), true, nil
+	case "default=":
+		hash.SetDefault(args[0])
+		return args[0], true, nil
+	case "any?":
+		if blk != nil {
+			for _, key := range hash.keys {
+				val, err := i.callProc(blk, key, hash.val(key))
+				if err != nil || erbTruthy(val) {
+					return erbTruthy(val), true, err
+				}
+			}
+			return false, true, nil
+		}
+	case "sort":
+		if blk == nil {
+			return i.enumMethod(hash, hash.Pairs(), name, args, blk)
+		}
+	case "hash":
+		return erbHashString(erbInspect(hash)), true, nil
+	}
+
+	return i.enumMethod(hash, hash.Pairs(), name, args, blk)
+}
+
+func (i *erbInterpreter) rangeMethod(rng *erbRange, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "include?", "member?", "cover?", "===":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return rng.Includes(args[0]), true, nil
+	case "begin", "first":
+		if len(args) == 0 {
+			return rng.From, true, nil
+		}
+	case "end":
+		return rng.To, true, nil
+	case "last":
+		if len(args) == 0 {
+			return rng.To, true, nil
+		}
+	case "min":
+		if blk == nil && len(args) == 0 {
+			if !rng.Includes(rng.From) {
+				return nil, true, nil
+			}
+			return rng.From, true, nil
+		}
+	case "exclude_end?":
+		return rng.Exclusive, true, nil
+	case "step":
+		step, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if step <= 0 {
+			return nil, true, newERBError("ArgumentError", 0, "step can't be negative")
+		}
+		items, err := rng.Items()
+		if err != nil {
+			return nil, true, err
+		}
+		var stepped []interface{}
+		for idx := 0; idx < len(items); idx += step {
+			stepped = append(stepped, items[idx])
+		}
+		return i.eachOrEnum(rng, stepped, blk)
+	case "to_s", "inspect":
+		return erbInspect(rng), true, nil
+	case "==", "eql?":
+		return erbEqual(rng, args[0]), true, nil
+	case "hash":
+		return erbHashString(erbInspect(rng)), true, nil
+	}
+
+	items, err := rng.Items()
+	if err != nil {
+		return nil, true, err
+	}
+
+	if name == "each" {
+		return i.eachOrEnum(rng, items, blk)
+	}
+
+	return i.enumMethod(rng, items, name, args, blk)
+}
+
+func (i *erbInterpreter) regexpMethod(re *erbRegexp, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "=~", "match", "match?", "===":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		if args[0] == nil {
+			if name == "match?" || name == "===" {
+				return false, true, nil
+			}
+			return nil, true, nil
+		}
+		var str string
+		switch typedArg := args[0].(type) {
+		case string:
+			str = typedArg
+		case erbSymbol:
+			str = string(typedArg)
+		default:
+			if name == "===" {
+				return false, true, nil
+			}
+			return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into String", erbConvName(args[0]))
+		}
+		match := re.Re.FindStringSubmatchIndex(str)
+		if name != "match?" {
+			i.lastMatch = nil
+			if match != nil {
+				i.lastMatch = newERBMatchData(re.Re, str, match)
+			}
+		}
+		switch name {
+		case "match?", "===":
+			return match != nil, true, nil
+		case "=~":
+			if match == nil {
+				return nil, true, nil
+			}
+			return utf8.RuneCountInString(str[:match[0]]), true, nil
+		}
+		if match == nil {
+			return nil, true, nil
+		}
+		return i.lastMatch, true, nil
+	case "source":
+		return re.Source, true, nil
+	case "to_s":
+		return "(?-mix:" + re.Source + ")", true, nil
+	case "inspect":
+		return erbInspect(re), true, nil
+	case "names":
+		arr := newERBArray()
+		for _, name := range re.Re.SubexpNames() {
+			if len(name) > 0 {
+				arr.Items = append(arr.Items, name)
+			}
+		}
+		return arr, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) matchDataMethod(md *erbMatchData, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "[]":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		switch typedArg := args[0].(type) {
+		case int:
+			if typedArg < 0 {
+				typedArg += len(md.Captures)
+			}
+			if typedArg < 0 || typedArg >= len(md.Captures) {
+				return nil, true, nil
+			}
+			return md.Captures[typedArg], true, nil
+		case string, erbSymbol:
+			for idx, groupName := range md.Names {
+				if len(groupName) > 0 && groupName == erbToS(typedArg) {
+					return md.Captures[idx], true, nil
+				}
+			}
+			return nil, true, newERBError("IndexError", 0, "undefined group name reference: %s", erbToS(typedArg))
+		}
+		return nil, true, newERBError("TypeError", 0, "no implicit conversion of %s into Integer", erbConvName(args[0]))
+	case "captures":
+		return newERBArray(md.Captures[1:]...), true, nil
+	case "to_a":
+		return newERBArray(md.Captures...), true, nil
+	case "named_captures":
+		hash := newERBHash()
+		for idx, groupName := range md.Names {
+			if len(groupName) > 0 {
+				hash.Set(groupName, md.Captures[idx])
+			}
+		}
+		return hash, true, nil
+	case "names":
+		arr := newERBArray()
+		for _, groupName := range md.Names {
+			if len(groupName) > 0 {
+				arr.Items = append(arr.Items, groupName)
+			}
+		}
+		return arr, true, nil
+	case "pre_match":
+		return md.Str[:md.Indices[0]], true, nil
+	case "post_match":
+		return md.Str[md.Indices[1]:], true, nil
+	case "begin", "end":
+		group, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if group*2+1 >= len(md.Indices) || md.Indices[group*2] < 0 {
+			return nil, true, nil
+		}
+		idx := md.Indices[group*2]
+		if name == "end" {
+			idx = md.Indices[group*2+1]
+		}
+		return utf8.RuneCountInString(md.Str[:idx]), true, nil
+	case "to_s":
+		return md.Captures[0], true, nil
+	case "string":
+		return md.Str, true, nil
+	case "size", "length":
+		return len(md.Captures), true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) openStructMethod(os *erbOpenStruct, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "[]":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, _ := os.Fields.Get(erbToS(args[0]))
+		return val, true, nil
+	case "[]=":
+		if err := erbArgc(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		os.Fields.Set(erbToS(args[0]), args[1])
+		return args[1], true, nil
+	case "to_h":
+		result := newERBHash()
+		for _, key := range os.Fields.keys {
+			result.Set(erbSymbol(erbToS(key)), os.Fields.val(key))
+		}
+		return result, true, nil
+	case "each_pair":
+		result, _, err := i.openStructMethod(os, "to_h", nil, nil)
+		if err != nil {
+			return nil, true, err
+		}
+		return i.hashMethod(result.(*erbHash), "each_pair", args, blk)
+	case "dig":
+		return i.dig(os, args)
+	case "respond_to?":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		_, found := os.Fields.Get(erbToS(args[0]))
+		return found, true, nil
+	case "to_s", "inspect":
+		return erbInspect(os), true, nil
+	case "==":
+		return erbEqual(os, args[0]), true, nil
+	case "delete_field":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, _ := os.Fields.Delete(erbToS(args[0]))
+		return val, true, nil
+	}
+
+	if strings.HasSuffix(name, "=") && len(args) == 1 && isIdentName(name) {
+		os.Fields.Set(strings.TrimSuffix(name, "="), args[0])
+		return args[0], true, nil
+	}
+
+	if len(args) == 0 && isIdentName(name) {
+		if val, found := os.Fields.Get(name); found {
+			return val, true, nil
+		}
+
+		// Common methods take precedence over missing fields
+		if result, found, err := i.objectMethod(os, name, args, blk); found {
+			return result, found, err
+		}
+
+		return nil, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *erbInterpreter) procMethod(proc *erbProc, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "call", "()", "yield", "[]", "===":
+		result, err := i.callProc(proc, args...)
+		if next, ok := err.(erbNext); ok {
+			return next.Val, true, nil
+		}
+		if ret, ok := err.(erbReturn); ok && proc.Lambda {
+			return ret.Val, true, nil
+		}
+		return result, true, err
+	case "to_proc":
+		return proc, true, nil
+	case "arity":
+		if len(proc.Sym) > 0 {
+			return -2, true, nil
+		}
+		return len(proc.Params), true, nil
+	case "lambda?":
+		return proc.Lambda, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func erbBase64Lines(encoded string) string {
+	var buf bytes.Buffer
+
+	for len(encoded) > 60 {
+		buf.WriteString(encoded[:60] + "\n")
+		encoded = encoded[60:]
+	}
+
+	if len(encoded) > 0 {
+		buf.WriteString(encoded + "\n")
+	}
+
+	return buf.String()
+}
+
+func (i *erbInterpreter) classMethod(class *erbClass, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "name", "to_s", "inspect":
+		return class.Name, true, nil
+	case "===":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return erbIsA(args[0], class.Name), true, nil
+	case "==":
+		return erbEqual(class, args[0]), true, nil
+	}
+
+	switch class.Name + "." + name {
+	case "Hash.new":
+		hash := newERBHash()
+		if blk != nil {
+			if err := erbArgc(args, 0, 0); err != nil {
+				return nil, true, err
+			}
+			hash.defProc = blk
+		}
+		if len(args) > 0 {
+			hash.SetDefault(args[0])
+		}
+		return hash, true, nil
+
+	case "Array.new":
+		if len(args) == 0 {
+			return newERBArray(), true, nil
+		}
+		if other, ok := args[0].(*erbArray); ok {
+			return newERBArray(append([]interface{}{}, other.Items...)...), true, nil
+		}
+		size, err := erbArgInt(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		if size < 0 {
+			return nil, true, newERBError("ArgumentError", 0, "negative array size")
+		}
+		arr := newERBArray()
+		for idx := 0; idx < size; idx++ {
+			var item interface{}
+			if len(args) > 1 {
+				item = args[1]
+			}
+			if blk != nil {
+				item, err = i.callProc(blk, idx)
+				if err != nil {
+					return nil, true, err
+				}
+			}
+			arr.Items = append(arr.Items, item)
+		}
+		return arr, true, nil
+
+	case "String.new":
+		if len(args) == 0 {
+			return "", true, nil
+		}
+		str, err := erbArgStr(args, 0)
+		return str, true, err
+
+	case "OpenStruct.new":
+		fields := newERBHash()
+		if len(args) > 0 {
+			hash, ok := args[0].(*erbHash)
+			if !ok {
+				return nil, true, newERBError("NoMethodError", 0, "undefined method `each_pair' for %s", erbUndefinedMethodRecv(args[0]))
+			}
+			for _, key := range hash.keys {
+				fields.Set(erbToS(key), hash.val(key))
+			}
+		}
+		return &erbOpenStruct{Fields: fields}, true, nil
+
+	case "Regexp.new", "Regexp.compile":
+		source, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		re, err := newERBRegexp(source, "")
+		return re, true, err
+
+	case "Regexp.escape", "Regexp.quote":
+		str, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		return regexp.QuoteMeta(str), true, nil
+
+	case "Regexp.union":
+		var sources []string
+		for _, arg := range erbFlatten(args, -1) {
+			switch typedArg := arg.(type) {
+			case string:
+				sources = append(sources, regexp.QuoteMeta(typedArg))
+			case *erbRegexp:
+				sources = append(sources, typedArg.Source)
+			}
+		}
+		re, err := newERBRegexp(strings.Join(sources, "|"), "")
+		return re, true, err
+
+	case "JSON.dump", "JSON.generate", "JSON.pretty_generate":
+		if err := erbArgc(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		// Mirrors JSON.dump override in template_evaluation_context_rb.go
+		if name == "dump" {
+			switch args[0].(type) {
+			case string, int, float64:
+				return erbInspect(args[0]), true, nil
+			}
+		}
+		str, err := erbToJSON(args[0], name == "pretty_generate")
+		return str, true, err
+
+	case "JSON.parse", "JSON.load":
+		str, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		val, err := erbFromJSON([]byte(str))
+		if err != nil {
+			return nil, true, newERBError("JSON::ParserError", 0, "%s", err.Error())
+		}
+		return val, true, nil
+
+	case "YAML.dump", "Psych.dump":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		str, err := erbToYAML(args[0])
+		return str, true, err
+
+	case "YAML.load", "YAML.safe_load", "Psych.load", "Psych.safe_load":
+		str, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		val, err := erbFromYAML(str)
+		return val, true, err
+
+	case "Base64.encode64", "Base64.strict_encode64", "Base64.urlsafe_encode64":
+		str, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		switch name {
+		case "encode64":
+			return erbBase64Lines(base64.StdEncoding.EncodeToString([]byte(str))), true, nil
+		case "urlsafe_encode64":
+			return base64.URLEncoding.EncodeToString([]byte(str)), true, nil
+		}
+		return base64.StdEncoding.EncodeToString([]byte(str)), true, nil
+
+	case "Base64.decode64", "Base64.strict_decode64", "Base64.urlsafe_decode64":
+		str, err := erbArgStr(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		encoding := base64.StdEncoding
+		if name == "urlsafe_decode64" {
+			encoding = base64.URLEncoding
+		}
+		if name == "decode64" {
+			str = strings.Map(func(r rune) rune {
+				if unicode.IsSpace(r) {
+					return -1
+				}
+				return r
+			}, str)
+		}
+		decoded, err := encoding.DecodeString(str)
+		if err != nil {
+			return nil, true, newERBError("ArgumentError", 0, "invalid base64")
+		}
+		return string(decoded), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// erbEnumerator is returned by iteration methods called without a block
+// so that calls such as 'map.with_index' and 'each_with_index.map' work
+type erbEnumerator struct {
+	Items  []interface{}
+	Method string
+	Recv   interface{}
+}
+
+func (e *erbEnumerator) ClassName() string { return "Enumerator" }
+
+func (e *erbEnumerator) CallMethod(i *erbInterpreter, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	return i.enumeratorMethod(e, name, args, blk)
+}
+
+func (i *erbInterpreter) enumeratorMethod(enum *erbEnumerator, name string, args []interface{}, blk *erbProc) (interface{}, bool, error) {
+	switch name {
+	case "with_index", "each_with_index":
+		offset := 0
+		if len(args) > 0 && name == "with_index" {
+			o, err := erbArgInt(args, 0)
+			if err != nil {
+				return nil, true, err
+			}
+			offset = o
+		}
+
+		if blk == nil {
+			var pairs []interface{}
+			for idx, item := range enum.Items {
+				pairs = append(pairs, newERBArray(item, idx+offset))
+			}
+			return &erbEnumerator{Items: pairs, Method: "each", Recv: enum.Recv}, true, nil
+		}
+
+		idx := offset
+		indexed := &erbProc{Body: blk.Body, Params: blk.Params, Env: blk.Env, Sym: blk.Sym, Lambda: blk.Lambda}
+
+		result, found, err := i.runEnumerator(enum, func(item interface{}) (interface{}, error) {
+			val, err := i.callProc(indexed, item, idx)
+			idx++
+			return val, err
+		})
+
+		return result, found, err
+
+	case "with_object":
+		if err := erbArgc(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, item := range enum.Items {
+			if _, err := i.callProc(blk, item, args[0]); err != nil {
+				return nil, true, err
+			}
+		}
+		return args[0], true, nil
+
+	case "each":
+		if blk == nil {
+			return enum, true, nil
+		}
+		return i.runEnumerator(enum, func(item interface{}) (interface{}, error) {
+			return i.callProc(blk, item)
+		})
+
+	case "next":
+		if len(enum.Items) == 0 {
+			return nil, true, newERBError("StopIteration", 0, "iteration reached an end")
+		}
+		item := enum.Items[0]
+		enum.Items = enum.Items[1:]
+		return item, true, nil
+
+	case "size":
+		return len(enum.Items), true, nil
+
+	case "to_s", "inspect":
+		return "#<Enumerator: ...>", true, nil
+	}
+
+	return i.arrMethod(newERBArray(enum.Items...), name, args, blk)
+}
+
+// runEnumerator applies semantics of the method that created enumerator
+func (i *erbInterpreter) runEnumerator(enum *erbEnumerator, call func(interface{}) (interface{}, error)) (interface{}, bool, error) {
+	result := newERBArray()
+
+	for _, item := range enum.Items {
+		val, err := call(item)
+		if err != nil {
+			return nil, true, err
+		}
+
+		switch enum.Method {
+		case "map", "collect":
+			result.Items = append(result.Items, val)
+		case "flat_map", "collect_concat":
+			if nested, ok := val.(*erbArray); ok {
+				result.Items = append(result.Items, nested.Items...)
+			} else {
+				result.Items = append(result.Items, val)
+			}
+		case "filter_map":
+			if erbTruthy(val) {
+				result.Items = append(result.Items, val)
+			}
+		case "select", "filter", "find_all":
+			if erbTruthy(val) {
+				result.Items = append(result.Items, item)
+			}
+		case "reject":
+			if !erbTruthy(val) {
+				result.Items = append(result.Items, item)
+			}
+		}
+	}
+
+	switch enum.Method {
+	case "each":
+		return enum.Recv, true, nil
+	case "map", "collect", "flat_map", "collect_concat", "filter_map", "select", "filter", "find_all", "reject":
+		return result, true, nil
+	}
+
+	return nil, true, newERBError("NotImplementedError", 0, "enumerator for '%s' is not supported", enum.Method)
+}
@@ -0,0 +1,348 @@
+package erbrenderer_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+)
+
+// Templates are rendered with both Ruby and native renderers
+// and results (or failures) are expected to be the same
+var _ = Describe("ERB renderers parity", func() {
+	var (
+		fs             boshsys.FileSystem
+		tmpDir         string
+		rubyRenderer   ERBRenderer
+		nativeRenderer ERBRenderer
+	)
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("ruby"); err != nil {
+			Skip("ruby is not available")
+		}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+		tmpDir, err = fs.TempDir("erb-parity-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		rubyRenderer = NewERBRenderer(fs, boshsys.NewExecCmdRunner(logger), logger)
+		nativeRenderer = NewNativeERBRenderer(fs, logger)
+	})
+
+	AfterEach(func() {
+		if len(tmpDir) > 0 {
+			Expect(fs.RemoveAll(tmpDir)).To(Succeed())
+		}
+	})
+
+	expectSameResult := func(name, template string, context jsonContext) {
+		srcPath := filepath.Join(tmpDir, "src")
+		rubyPath := filepath.Join(tmpDir, "ruby")
+		nativePath := filepath.Join(tmpDir, "native")
+
+		Expect(fs.WriteFileString(srcPath, template)).To(Succeed())
+
+		rubyErr := rubyRenderer.Render(srcPath, rubyPath, context)
+		nativeErr := nativeRenderer.Render(srcPath, nativePath, context)
+
+		if rubyErr != nil {
+			Expect(nativeErr).To(HaveOccurred(), fmt.Sprintf("Expected '%s' to fail like Ruby: %s", name, rubyErr))
+			return
+		}
+
+		Expect(nativeErr).ToNot(HaveOccurred(), fmt.Sprintf("Expected '%s' to render", name))
+
+		rubyResult, err := fs.ReadFileString(rubyPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		nativeResult, err := fs.ReadFileString(nativePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(nativeResult).To(Equal(rubyResult), fmt.Sprintf("Expected '%s' to render like Ruby", name))
+	}
+
+	Describe("fixture release jobs", func() {
+		releasePaths := []string{
+			"../../acceptance/assets/dummy-release.tgz",
+			"../../acceptance/assets/dummy-too-release.tgz",
+			"../../acceptance/assets/sample-release-compiled.tgz",
+			"../../integration/assets/small-sha256-release.tgz",
+		}
+
+		It("renders all job templates the same", func() {
+			var rendered int
+
+			for _, releasePath := range releasePaths {
+				for _, job := range readParityJobs(releasePath) {
+					for _, templateName := range job.TemplateNames() {
+						name := fmt.Sprintf("%s/%s/%s", filepath.Base(releasePath), job.Name, templateName)
+						expectSameResult(name, job.Templates[templateName], job.Context())
+						rendered++
+					}
+				}
+			}
+
+			Expect(rendered).To(BeNumerically(">", 0))
+		})
+	})
+
+	Describe("common job template idioms", func() {
+		context := jsonContext(`{
+			"job": {"name": "web"},
+			"index": 1,
+			"id": "abc-123",
+			"az": "z2",
+			"bootstrap": false,
+			"deployment": "dep",
+			"address": "web.dep.bosh",
+			"networks": {"default": {"ip": "10.0.0.5", "netmask": "255.255.255.0", "gateway": "10.0.0.1"}},
+			"global_properties": {},
+			"cluster_properties": {},
+			"job_properties": {
+				"web": {
+					"port": 8443,
+					"workers": 4,
+					"tls": {"cert": "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"},
+					"users": [{"name": "admin", "roles": ["read", "write"]}, {"name": "viewer", "roles": ["read"]}],
+					"env": {"LANG": "C", "GOMAXPROCS": 2},
+					"timeout": 1.5
+				}
+			},
+			"default_properties": {
+				"web.port": 80,
+				"web.workers": 1,
+				"web.tls.cert": null,
+				"web.tls.key": null,
+				"web.users": [],
+				"web.env": {},
+				"web.timeout": 30,
+				"web.log_level": "info",
+				"web.hosts": ["a.example.com", "b.example.com"]
+			}
+		}`)
+
+		templates := map[string]string{
+			"config yaml": `---
+listen: 0.0.0.0:<%= p('web.port') %>
+workers: <%= p('web.workers') %>
+timeout: <%= p('web.timeout') %>
+log_level: <%= p('web.log_level').upcase %>
+<% if_p('web.tls.cert', 'web.tls.key') do |cert, key| -%>
+tls: {cert: <%= cert.inspect %>, key: <%= key.inspect %>}
+<% end.else do -%>
+tls: ~
+<% end -%>
+hosts:
+<% p('web.hosts').each do |host| -%>
+- <%= host %>
+<% end -%>
+`,
+			"json config": `<%=
+  config = {
+    'port' => p('web.port'),
+    'users' => p('web.users').map { |u| { 'name' => u['name'], 'admin' => u['roles'].include?('write') } },
+    'env' => p('web.env').sort.to_h,
+  }
+  JSON.pretty_generate(config)
+%>
+`,
+			"ctl script": `#!/bin/bash
+set -e
+<% p('web.env').keys.sort.each do |k| -%>
+export <%= k %>="<%= p('web.env')[k] %>"
+<% end -%>
+INDEX=<%= spec.index %>
+ID=<%= spec.id %>
+AZ=<%= spec.az %>
+IP=<%= spec.networks.default.ip %>
+ADDRESS=<%= spec.address %>
+<% if spec.bootstrap -%>
+echo bootstrap
+<% end -%>
+exec /var/vcap/packages/web/bin/web --workers <%= [p('web.workers'), 1].max %>
+`,
+			"statement sequences": `<%= a = p('web.port'); a + 1 %> <%= x = 1
+x += 2
+x * 2 %>`,
+			"string helpers": `<%= p('web.tls.cert').lines.map(&:strip).reject(&:empty?).join('|') %>
+<%= p('web.users').map { |u| "#{u['name']}=#{u['roles'].join(',')}" }.join(';') %>
+<%= "%-8s|%5.2f" % [p('web.log_level'), p('web.timeout')] %>
+<%= p('web.hosts').first.split('.').reverse.join('.') %>
+`,
+			"unknown property": `<%= p('web.nope') %>`,
+		}
+
+		var names []string
+
+		for name := range templates {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			name := name
+
+			It(fmt.Sprintf("renders %s the same", name), func() {
+				expectSameResult(name, templates[name], context)
+			})
+		}
+	})
+})
+
+type parityJob struct {
+	Name      string
+	Spec      parityJobSpec
+	Templates map[string]string
+}
+
+type parityJobSpec struct {
+	Templates  map[string]string `yaml:"templates"`
+	Properties map[string]struct {
+		Default interface{} `yaml:"default"`
+	} `yaml:"properties"`
+}
+
+func (j parityJob) TemplateNames() []string {
+	var names []string
+
+	for name := range j.Templates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Context provides values for properties without defaults
+// so that templates can be fully rendered
+func (j parityJob) Context() jsonContext {
+	jobProps := map[interface{}]interface{}{}
+	defaultProps := map[interface{}]interface{}{}
+
+	for name, prop := range j.Spec.Properties {
+		defaultProps[name] = prop.Default
+
+		if prop.Default == nil {
+			current := jobProps
+			pieces := strings.Split(name, ".")
+
+			for _, piece := range pieces[:len(pieces)-1] {
+				if _, found := current[piece]; !found {
+					current[piece] = map[interface{}]interface{}{}
+				}
+				current = current[piece].(map[interface{}]interface{})
+			}
+
+			current[pieces[len(pieces)-1]] = "value-of-" + name
+		}
+	}
+
+	builtJobProps, err := biproperty.BuildMap(jobProps)
+	Expect(err).ToNot(HaveOccurred())
+
+	builtDefaultProps, err := biproperty.BuildMap(defaultProps)
+	Expect(err).ToNot(HaveOccurred())
+
+	contextBytes, err := json.Marshal(map[string]interface{}{
+		"job":                map[string]string{"name": j.Name},
+		"index":              0,
+		"id":                 "fake-id",
+		"az":                 "z1",
+		"deployment":         "fake-deployment",
+		"networks":           map[string]interface{}{"default": map[string]string{"ip": "10.0.0.5"}},
+		"global_properties":  map[string]interface{}{},
+		"cluster_properties": map[string]interface{}{},
+		"job_properties":     builtJobProps,
+		"default_properties": builtDefaultProps,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	return jsonContext(contextBytes)
+}
+
+func readParityJobs(releasePath string) []parityJob {
+	var jobs []parityJob
+
+	file, err := os.Open(releasePath)
+	Expect(err).ToNot(HaveOccurred())
+
+	defer file.Close()
+
+	for path, contents := range readParityTarball(file) {
+		if !strings.HasPrefix(path, "jobs/") || !strings.HasSuffix(path, ".tgz") {
+			continue
+		}
+
+		job := parityJob{
+			Name:      strings.TrimSuffix(strings.TrimPrefix(path, "jobs/"), ".tgz"),
+			Templates: map[string]string{},
+		}
+
+		for jobPath, jobContents := range readParityTarball(bytes.NewBufferString(contents)) {
+			switch {
+			case jobPath == "job.MF":
+				Expect(yaml.Unmarshal([]byte(jobContents), &job.Spec)).To(Succeed())
+
+			case strings.HasPrefix(jobPath, "templates/"):
+				job.Templates[strings.TrimPrefix(jobPath, "templates/")] = jobContents
+			}
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	return jobs
+}
+
+func readParityTarball(reader io.Reader) map[string]string {
+	gzipReader, err := gzip.NewReader(reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string]string{}
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		contents, err := ioutil.ReadAll(tarReader)
+		Expect(err).ToNot(HaveOccurred())
+
+		files[strings.TrimPrefix(header.Name, "./")] = string(contents)
+	}
+
+	return files
+}
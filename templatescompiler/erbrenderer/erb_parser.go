@@ -0,0 +1,1620 @@
+package erbrenderer
+
+import (
+	"strconv"
+)
+
+// erbParser parses subset of Ruby used by job templates
+type erbParser struct {
+	tokens []erbToken
+	pos    int
+
+	// Local variables are known at parse time, just like in Ruby,
+	// which allows to tell apart 'foo [1]' (call) from 'foo [1]' (index)
+	scopes []map[string]bool
+
+	// Greater than zero when 'do' belongs to an enclosing construct
+	// (e.g. 'while cond do' or a command call without parens)
+	noDo int
+}
+
+func newERBParser(tokens []erbToken) *erbParser {
+	return &erbParser{tokens: tokens, scopes: []map[string]bool{{}}}
+}
+
+func (p *erbParser) parseProgram() (erbNode, error) {
+	body, err := p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.Kind != erbTokEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	return body, nil
+}
+
+func (p *erbParser) peek() erbToken { return p.peekAt(0) }
+
+func (p *erbParser) peekAt(offset int) erbToken {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+
+	line := 0
+	if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].Line
+	}
+
+	return erbToken{Kind: erbTokEOF, Line: line}
+}
+
+func (p *erbParser) advance() erbToken {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *erbParser) accept(kind erbTokenKind, val string) bool {
+	if p.peek().Is(kind, val) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *erbParser) expect(kind erbTokenKind, val string) error {
+	if !p.accept(kind, val) {
+		return p.unexpected(p.peek())
+	}
+	return nil
+}
+
+func (p *erbParser) skipNewlines() {
+	for p.peek().Kind == erbTokNewline {
+		p.advance()
+	}
+}
+
+func (p *erbParser) unexpected(tok erbToken) error {
+	return newERBError("SyntaxError", tok.Line, "syntax error, unexpected %s", tok)
+}
+
+func (p *erbParser) declare(name string) {
+	p.scopes[len(p.scopes)-1][name] = true
+}
+
+func (p *erbParser) isLocal(name string) bool {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if p.scopes[i][name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *erbParser) isStatementsEnd(tok erbToken) bool {
+	switch tok.Kind {
+	case erbTokEOF:
+		return true
+	case erbTokKeyword:
+		switch tok.Val {
+		case "end", "else", "elsif", "when", "rescue", "ensure":
+			return true
+		}
+	case erbTokOp:
+		return tok.Val == "}" || tok.Val == ")"
+	}
+	return false
+}
+
+func (p *erbParser) parseStatements() (erbNode, error) {
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	seq := &erbSeqNode{}
+
+	for {
+		p.skipNewlines()
+
+		if p.isStatementsEnd(p.peek()) {
+			return seq, nil
+		}
+
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+
+		seq.Stmts = append(seq.Stmts, stmt)
+
+		if tok := p.peek(); tok.Kind != erbTokNewline && !p.isStatementsEnd(tok) {
+			return nil, p.unexpected(tok)
+		}
+	}
+}
+
+func (p *erbParser) parseStatement() (erbNode, error) {
+	tok := p.peek()
+
+	switch tok.Kind {
+	case erbTokText:
+		p.advance()
+		return erbTextNode{Text: tok.Val}, nil
+
+	case erbTokOutput:
+		p.advance()
+
+		expr, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+
+		return erbOutputNode{Expr: expr, Line: tok.Line}, nil
+	}
+
+	stmt, err := p.parseAndOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.Kind != erbTokKeyword {
+			return stmt, nil
+		}
+
+		switch tok.Val {
+		case "if", "unless", "while", "until":
+			p.advance()
+
+			cond, err := p.parseAndOr()
+			if err != nil {
+				return nil, err
+			}
+
+			switch tok.Val {
+			case "if":
+				stmt = erbIfNode{Cond: cond, Then: stmt}
+			case "unless":
+				stmt = erbIfNode{Cond: erbNotNode{Expr: cond}, Then: stmt}
+			default:
+				stmt = erbWhileNode{Cond: cond, Body: stmt, Until: tok.Val == "until"}
+			}
+
+		case "rescue":
+			p.advance()
+
+			rescue, err := p.parseAndOr()
+			if err != nil {
+				return nil, err
+			}
+
+			stmt = erbBeginNode{Body: stmt, Rescue: rescue}
+
+		default:
+			return stmt, nil
+		}
+	}
+}
+
+func (p *erbParser) parseAndOr() (erbNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if !tok.Is(erbTokKeyword, "and") && !tok.Is(erbTokKeyword, "or") {
+			return left, nil
+		}
+
+		p.advance()
+		p.skipNewlines()
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.Val == "and" {
+			left = erbAndNode{Left: left, Right: right}
+		} else {
+			left = erbOrNode{Left: left, Right: right}
+		}
+	}
+}
+
+func (p *erbParser) parseNot() (erbNode, error) {
+	if p.accept(erbTokKeyword, "not") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return erbNotNode{Expr: expr}, nil
+	}
+
+	return p.parseExpr()
+}
+
+func (p *erbParser) parseExpr() (erbNode, error) {
+	if p.isMultiAssign() {
+		return p.parseMultiAssign()
+	}
+
+	left, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if tok.Kind != erbTokOp {
+		return left, nil
+	}
+
+	switch tok.Val {
+	case "=":
+		target, ok := p.assignTarget(left)
+		if !ok {
+			return left, nil
+		}
+
+		p.advance()
+		p.skipNewlines()
+
+		value, err := p.parseExprWithRescue()
+		if err != nil {
+			return nil, err
+		}
+
+		return erbAssignNode{Target: target, Value: value}, nil
+
+	case "+=", "-=", "*=", "/=", "||=", "&&=", "<<=", "**=":
+		target, ok := p.assignTarget(left)
+		if !ok {
+			return nil, p.unexpected(tok)
+		}
+
+		p.advance()
+		p.skipNewlines()
+
+		value, err := p.parseExprWithRescue()
+		if err != nil {
+			return nil, err
+		}
+
+		return erbOpAssignNode{Target: target, Op: tok.Val[:len(tok.Val)-1], Value: value}, nil
+	}
+
+	return left, nil
+}
+
+// parseExprWithRescue allows 'a = b rescue c' to assign c when b fails
+func (p *erbParser) parseExprWithRescue() (erbNode, error) {
+	value, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept(erbTokKeyword, "rescue") {
+		rescue, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		value = erbBeginNode{Body: value, Rescue: rescue}
+	}
+
+	return value, nil
+}
+
+func (p *erbParser) assignTarget(node erbNode) (erbNode, bool) {
+	switch typedNode := node.(type) {
+	case erbVarNode:
+		p.declare(typedNode.Name)
+		return typedNode, true
+	case erbIVarNode:
+		return typedNode, true
+	case *erbCallNode:
+		if typedNode.Block != nil || typedNode.BlockArg != nil {
+			return nil, false
+		}
+		if typedNode.Name == "[]" {
+			return typedNode, true
+		}
+		if typedNode.Recv != nil && len(typedNode.Args) == 0 && isIdentName(typedNode.Name) {
+			return typedNode, true
+		}
+		if typedNode.Recv == nil && len(typedNode.Args) == 0 {
+			p.declare(typedNode.Name)
+			return erbVarNode{Name: typedNode.Name, Line: typedNode.Line}, true
+		}
+	}
+
+	return nil, false
+}
+
+func isIdentName(name string) bool {
+	return len(name) > 0 && isIdentStart(rune(name[0]))
+}
+
+func (p *erbParser) isMultiAssign() bool {
+	for i := 0; ; i += 2 {
+		tok := p.peekAt(i)
+		if tok.Is(erbTokOp, "*") {
+			i++
+			tok = p.peekAt(i)
+		}
+		if tok.Kind != erbTokIdent && tok.Kind != erbTokIVar {
+			return false
+		}
+
+		sep := p.peekAt(i + 1)
+		if sep.Is(erbTokOp, "=") {
+			return i > 0
+		}
+		if !sep.Is(erbTokOp, ",") {
+			return false
+		}
+	}
+}
+
+func (p *erbParser) parseMultiAssign() (erbNode, error) {
+	node := erbMultiAssignNode{}
+
+	for !p.accept(erbTokOp, "=") {
+		splat := p.accept(erbTokOp, "*")
+
+		tok := p.advance()
+
+		var target erbNode
+		if tok.Kind == erbTokIVar {
+			target = erbIVarNode{Name: tok.Val}
+		} else {
+			p.declare(tok.Val)
+			target = erbVarNode{Name: tok.Val, Line: tok.Line}
+		}
+
+		if splat {
+			target = erbSplatNode{Expr: target}
+		}
+
+		node.Targets = append(node.Targets, target)
+		p.accept(erbTokOp, ",")
+	}
+
+	p.skipNewlines()
+
+	value, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().Is(erbTokOp, ",") {
+		values := erbArrayNode{Elems: []erbNode{value}}
+
+		for p.accept(erbTokOp, ",") {
+			p.skipNewlines()
+
+			value, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+
+			values.Elems = append(values.Elems, value)
+		}
+
+		value = values
+	}
+
+	node.Value = value
+
+	return node, nil
+}
+
+func (p *erbParser) parseTernary() (erbNode, error) {
+	cond, err := p.parseRange()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.accept(erbTokOp, "?") {
+		return cond, nil
+	}
+
+	p.skipNewlines()
+
+	thenNode, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipNewlines()
+
+	// 'a ? b :c' is lexed with a symbol
+	if tok := p.peek(); tok.Kind == erbTokSymbol && tok.Val != "symbol" {
+		p.advance()
+		return erbIfNode{Cond: cond, Then: thenNode, Else: erbVarNode{Name: tok.Val, Line: tok.Line}}, nil
+	}
+
+	err = p.expect(erbTokOp, ":")
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipNewlines()
+
+	elseNode, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+
+	return erbIfNode{Cond: cond, Then: thenNode, Else: elseNode}, nil
+}
+
+func (p *erbParser) parseRange() (erbNode, error) {
+	from, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if !tok.Is(erbTokOp, "..") && !tok.Is(erbTokOp, "...") {
+		return from, nil
+	}
+
+	p.advance()
+
+	to, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return erbRangeNode{From: from, To: to, Exclusive: tok.Val == "..."}, nil
+}
+
+var erbBinaryPrecs = map[string]int{
+	"||":  1,
+	"&&":  2,
+	"<=>": 3, "==": 3, "===": 3, "!=": 3, "=~": 3, "!~": 3,
+	"<": 4, ">": 4, "<=": 4, ">=": 4,
+	"|": 5, "^": 5,
+	"&":  6,
+	"<<": 7, ">>": 7,
+	"+": 8, "-": 8,
+	"*": 9, "/": 9, "%": 9,
+	"**": 11,
+}
+
+const erbUnaryMinusPrec = 10
+
+func (p *erbParser) parseBinary(minPrec int) (erbNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.Kind != erbTokOp {
+			return left, nil
+		}
+
+		prec, found := erbBinaryPrecs[tok.Val]
+		if !found || prec < minPrec {
+			return left, nil
+		}
+
+		p.advance()
+		p.skipNewlines()
+
+		nextMinPrec := prec + 1
+		if tok.Val == "**" {
+			nextMinPrec = prec
+		}
+
+		right, err := p.parseBinary(nextMinPrec)
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok.Val {
+		case "&&":
+			left = erbAndNode{Left: left, Right: right}
+		case "||":
+			left = erbOrNode{Left: left, Right: right}
+		case "!=":
+			left = erbNotNode{Expr: &erbCallNode{Recv: left, Name: "==", Args: []erbNode{right}, Line: tok.Line}}
+		case "!~":
+			left = erbNotNode{Expr: &erbCallNode{Recv: left, Name: "=~", Args: []erbNode{right}, Line: tok.Line}}
+		default:
+			left = &erbCallNode{Recv: left, Name: tok.Val, Args: []erbNode{right}, Line: tok.Line}
+		}
+	}
+}
+
+func (p *erbParser) parseUnary() (erbNode, error) {
+	tok := p.peek()
+
+	switch {
+	case tok.Is(erbTokOp, "!"):
+		p.advance()
+
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return erbNotNode{Expr: expr}, nil
+
+	case tok.Is(erbTokOp, "-"):
+		p.advance()
+
+		// Negative numeric literals
+		if next := p.peek(); (next.Kind == erbTokInt || next.Kind == erbTokFloat) && !next.SpaceBefore {
+			p.advance()
+
+			lit, err := p.numberLit(next, "-")
+			if err != nil {
+				return nil, err
+			}
+
+			return p.parsePostfix(lit)
+		}
+
+		expr, err := p.parseBinary(erbUnaryMinusPrec + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &erbCallNode{Recv: expr, Name: "-@", Line: tok.Line}, nil
+
+	case tok.Is(erbTokOp, "+"):
+		p.advance()
+		return p.parseUnary()
+	}
+
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parsePostfix(primary)
+}
+
+func (p *erbParser) parsePostfix(node erbNode) (erbNode, error) {
+	for {
+		tok := p.peek()
+
+		switch {
+		case tok.Is(erbTokOp, ".") || tok.Is(erbTokOp, "&."):
+			p.advance()
+			p.skipNewlines()
+
+			// 'proc.(args)' is a shorthand for 'proc.call(args)'
+			if p.peek().Is(erbTokOp, "(") {
+				call := &erbCallNode{Recv: node, Name: "call", SafeNav: tok.Val == "&.", Line: tok.Line}
+				if err := p.parseCallArgs(call); err != nil {
+					return nil, err
+				}
+				node = call
+				continue
+			}
+
+			nameTok := p.advance()
+
+			if nameTok.Kind != erbTokIdent && nameTok.Kind != erbTokConst && nameTok.Kind != erbTokKeyword {
+				return nil, p.unexpected(nameTok)
+			}
+
+			call := &erbCallNode{Recv: node, Name: nameTok.Val, SafeNav: tok.Val == "&.", Line: nameTok.Line}
+
+			err := p.parseCallArgs(call)
+			if err != nil {
+				return nil, err
+			}
+
+			node = call
+
+		case tok.Is(erbTokOp, "::"):
+			p.advance()
+
+			nameTok := p.advance()
+			if nameTok.Kind == erbTokConst && !p.peek().Is(erbTokOp, "(") {
+				node = erbConstNode{Scope: node, Name: nameTok.Val}
+				continue
+			}
+
+			call := &erbCallNode{Recv: node, Name: nameTok.Val, Line: nameTok.Line}
+
+			err := p.parseCallArgs(call)
+			if err != nil {
+				return nil, err
+			}
+
+			node = call
+
+		case tok.Is(erbTokOp, "[") && (!tok.SpaceBefore || p.isLocalVarNode(node)):
+			p.advance()
+
+			args, _, err := p.parseArgList("]")
+			if err != nil {
+				return nil, err
+			}
+
+			node = &erbCallNode{Recv: node, Name: "[]", Args: args, Line: tok.Line}
+
+		case tok.Is(erbTokOp, "{") || (tok.Is(erbTokKeyword, "do") && p.noDo == 0):
+			call, ok := p.blockableCall(node)
+			if !ok {
+				return node, nil
+			}
+
+			err := p.parseBlock(call)
+			if err != nil {
+				return nil, err
+			}
+
+			node = call
+
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *erbParser) isLocalVarNode(node erbNode) bool {
+	varNode, ok := node.(erbVarNode)
+	return ok && p.isLocal(varNode.Name)
+}
+
+func (p *erbParser) blockableCall(node erbNode) (*erbCallNode, bool) {
+	switch typedNode := node.(type) {
+	case *erbCallNode:
+		if typedNode.Block == nil && typedNode.BlockArg == nil && isIdentName(typedNode.Name) {
+			return typedNode, true
+		}
+	case erbVarNode:
+		if !p.isLocal(typedNode.Name) {
+			return &erbCallNode{Name: typedNode.Name, Line: typedNode.Line}, true
+		}
+	}
+	return nil, false
+}
+
+func (p *erbParser) parseCallArgs(call *erbCallNode) error {
+	tok := p.peek()
+
+	if tok.Is(erbTokOp, "(") && !tok.SpaceBefore {
+		p.advance()
+
+		args, blockArg, err := p.parseArgList(")")
+		if err != nil {
+			return err
+		}
+
+		call.Args = args
+		call.BlockArg = blockArg
+
+		return nil
+	}
+
+	if p.canStartCommandArg(tok) {
+		p.noDo++
+		args, blockArg, err := p.parseCommandArgs()
+		p.noDo--
+
+		if err != nil {
+			return err
+		}
+
+		call.Args = args
+		call.BlockArg = blockArg
+	}
+
+	return nil
+}
+
+func (p *erbParser) canStartCommandArg(tok erbToken) bool {
+	if !tok.SpaceBefore {
+		return false
+	}
+
+	switch tok.Kind {
+	case erbTokInt, erbTokFloat, erbTokString, erbTokSymbol, erbTokRegexp, erbTokWords,
+		erbTokIdent, erbTokConst, erbTokIVar, erbTokLabel:
+		return true
+
+	case erbTokKeyword:
+		switch tok.Val {
+		case "nil", "true", "false", "self", "not", "defined?":
+			return true
+		}
+
+	case erbTokOp:
+		switch tok.Val {
+		case "[", "(", "->", "::":
+			return true
+		case "-", "*", "&", "!", ":":
+			return !p.peekAt(1).SpaceBefore
+		}
+	}
+
+	return false
+}
+
+func (p *erbParser) parseCommandArgs() ([]erbNode, erbNode, error) {
+	return p.parseArgs(func() bool { return false })
+}
+
+func (p *erbParser) parseArgList(closing string) ([]erbNode, erbNode, error) {
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	p.skipNewlines()
+
+	if p.accept(erbTokOp, closing) {
+		return nil, nil, nil
+	}
+
+	args, blockArg, err := p.parseArgs(func() bool { return p.peek().Is(erbTokOp, closing) })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.skipNewlines()
+
+	err = p.expect(erbTokOp, closing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return args, blockArg, nil
+}
+
+// parseArgs parses comma separated arguments; trailing 'key: value'
+// and 'key => value' pairs are collected into a hash
+func (p *erbParser) parseArgs(isClosing func() bool) ([]erbNode, erbNode, error) {
+	var args []erbNode
+	var blockArg erbNode
+	var hash *erbHashNode
+
+	for {
+		tok := p.peek()
+
+		switch {
+		case tok.Is(erbTokOp, "*"):
+			p.advance()
+
+			expr, err := p.parseTernary()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			args = append(args, erbSplatNode{Expr: expr})
+
+		case tok.Is(erbTokOp, "&"):
+			p.advance()
+
+			expr, err := p.parseTernary()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			blockArg = expr
+
+		case tok.Kind == erbTokLabel:
+			p.advance()
+			p.skipNewlines()
+
+			value, err := p.parseNotWithoutAssignment()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if hash == nil {
+				hash = &erbHashNode{}
+				args = append(args, hash)
+			}
+
+			hash.Keys = append(hash.Keys, erbLitNode{Val: erbSymbol(tok.Val)})
+			hash.Vals = append(hash.Vals, value)
+
+		default:
+			expr, err := p.parseNot()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if p.accept(erbTokOp, "=>") {
+				p.skipNewlines()
+
+				value, err := p.parseNotWithoutAssignment()
+				if err != nil {
+					return nil, nil, err
+				}
+
+				if hash == nil {
+					hash = &erbHashNode{}
+					args = append(args, hash)
+				}
+
+				hash.Keys = append(hash.Keys, expr)
+				hash.Vals = append(hash.Vals, value)
+			} else {
+				args = append(args, expr)
+			}
+		}
+
+		if !p.accept(erbTokOp, ",") {
+			return args, blockArg, nil
+		}
+
+		p.skipNewlines()
+
+		// trailing comma
+		if isClosing() {
+			return args, blockArg, nil
+		}
+	}
+}
+
+func (p *erbParser) parseNotWithoutAssignment() (erbNode, error) {
+	if p.accept(erbTokKeyword, "not") {
+		expr, err := p.parseNotWithoutAssignment()
+		if err != nil {
+			return nil, err
+		}
+		return erbNotNode{Expr: expr}, nil
+	}
+	return p.parseTernary()
+}
+
+func (p *erbParser) parseBlock(call *erbCallNode) error {
+	opening := p.advance()
+
+	closing := erbToken{Kind: erbTokKeyword, Val: "end"}
+	if opening.Val == "{" {
+		closing = erbToken{Kind: erbTokOp, Val: "}"}
+	}
+
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	p.scopes = append(p.scopes, map[string]bool{})
+	defer func() { p.scopes = p.scopes[:len(p.scopes)-1] }()
+
+	block := &erbBlockNode{}
+
+	p.skipNewlines()
+
+	if p.accept(erbTokOp, "||") {
+		// no params
+	} else if p.accept(erbTokOp, "|") {
+		params, err := p.parseBlockParams("|")
+		if err != nil {
+			return err
+		}
+
+		block.Params = params
+	}
+
+	body, err := p.parseStatements()
+	if err != nil {
+		return err
+	}
+
+	err = p.expect(closing.Kind, closing.Val)
+	if err != nil {
+		return err
+	}
+
+	block.Body = body
+	call.Block = block
+
+	return nil
+}
+
+func (p *erbParser) parseBlockParams(closing string) ([]erbBlockParam, error) {
+	var params []erbBlockParam
+
+	for !p.accept(erbTokOp, closing) {
+		var param erbBlockParam
+
+		switch tok := p.advance(); {
+		case tok.Is(erbTokOp, "("):
+			nested, err := p.parseBlockParams(")")
+			if err != nil {
+				return nil, err
+			}
+			param.Nested = nested
+
+		case tok.Is(erbTokOp, "*") || tok.Is(erbTokOp, "&"):
+			nameTok := p.advance()
+			if nameTok.Kind != erbTokIdent {
+				return nil, p.unexpected(nameTok)
+			}
+			param.Name = nameTok.Val
+			param.Splat = tok.Val == "*"
+
+		case tok.Kind == erbTokIdent:
+			param.Name = tok.Val
+
+		default:
+			return nil, p.unexpected(tok)
+		}
+
+		if len(param.Name) > 0 {
+			p.declare(param.Name)
+		}
+
+		params = append(params, param)
+
+		if !p.accept(erbTokOp, ",") && !p.peek().Is(erbTokOp, closing) {
+			return nil, p.unexpected(p.peek())
+		}
+	}
+
+	return params, nil
+}
+
+func (p *erbParser) parsePrimary() (erbNode, error) {
+	tok := p.advance()
+
+	switch tok.Kind {
+	case erbTokInt, erbTokFloat:
+		return p.numberLit(tok, "")
+
+	case erbTokString:
+		node, err := p.strNode(tok.Parts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Commands in backticks are run by Kernel#`
+		if tok.Val == "xstring" {
+			return &erbCallNode{Name: "`", Args: []erbNode{node}, Line: tok.Line}, nil
+		}
+
+		// Adjacent string literals are concatenated
+		for next := p.peek(); next.Kind == erbTokString && next.SpaceBefore; next = p.peek() {
+			p.advance()
+
+			nextNode, err := p.strNode(next.Parts)
+			if err != nil {
+				return nil, err
+			}
+
+			node = erbStrNode{Parts: []erbNode{node, nextNode}}
+		}
+
+		return node, nil
+
+	case erbTokSymbol:
+		node, err := p.strNode(tok.Parts)
+		if err != nil {
+			return nil, err
+		}
+
+		if lit, ok := node.(erbLitNode); ok {
+			return erbLitNode{Val: erbSymbol(lit.Val.(string))}, nil
+		}
+
+		return erbSymbolNode{Parts: node.(erbStrNode).Parts}, nil
+
+	case erbTokRegexp:
+		node, err := p.strNode(tok.Parts)
+		if err != nil {
+			return nil, err
+		}
+
+		if lit, ok := node.(erbLitNode); ok {
+			return erbRegexpNode{Parts: []erbNode{lit}, Flags: tok.Flags}, nil
+		}
+
+		return erbRegexpNode{Parts: node.(erbStrNode).Parts, Flags: tok.Flags}, nil
+
+	case erbTokWords:
+		arr := erbArrayNode{}
+
+		for _, word := range tok.Words {
+			if tok.Flags == "i" {
+				arr.Elems = append(arr.Elems, erbLitNode{Val: erbSymbol(word)})
+			} else {
+				arr.Elems = append(arr.Elems, erbLitNode{Val: word})
+			}
+		}
+
+		return arr, nil
+
+	case erbTokIVar:
+		return erbIVarNode{Name: tok.Val}, nil
+
+	case erbTokGVar:
+		return erbGVarNode{Name: tok.Val}, nil
+
+	case erbTokConst:
+		if next := p.peek(); next.Is(erbTokOp, "(") && !next.SpaceBefore {
+			call := &erbCallNode{Name: tok.Val, Line: tok.Line}
+			return call, p.parseCallArgs(call)
+		}
+
+		return erbConstNode{Name: tok.Val}, nil
+
+	case erbTokIdent:
+		next := p.peek()
+
+		if next.Is(erbTokOp, "(") && !next.SpaceBefore {
+			call := &erbCallNode{Name: tok.Val, Line: tok.Line}
+			return call, p.parseCallArgs(call)
+		}
+
+		if p.isLocal(tok.Val) {
+			return erbVarNode{Name: tok.Val, Line: tok.Line}, nil
+		}
+
+		if p.canStartCommandArg(next) {
+			call := &erbCallNode{Name: tok.Val, Line: tok.Line}
+			return call, p.parseCallArgs(call)
+		}
+
+		return erbVarNode{Name: tok.Val, Line: tok.Line}, nil
+
+	case erbTokKeyword:
+		return p.parseKeyword(tok)
+
+	case erbTokOp:
+		switch tok.Val {
+		case "(":
+			savedNoDo := p.noDo
+			p.noDo = 0
+			defer func() { p.noDo = savedNoDo }()
+
+			body, err := p.parseStatements()
+			if err != nil {
+				return nil, err
+			}
+
+			return body, p.expect(erbTokOp, ")")
+
+		case "[":
+			elems, _, err := p.parseArgList("]")
+			if err != nil {
+				return nil, err
+			}
+
+			return erbArrayNode{Elems: elems}, nil
+
+		case "{":
+			return p.parseHash()
+
+		case "::":
+			nameTok := p.advance()
+			if nameTok.Kind != erbTokConst {
+				return nil, p.unexpected(nameTok)
+			}
+
+			return erbConstNode{Name: nameTok.Val}, nil
+
+		case "->":
+			return p.parseLambda(tok)
+		}
+	}
+
+	return nil, p.unexpected(tok)
+}
+
+// parseLambda parses '->(x) { ... }' into call to 'lambda' with a block
+func (p *erbParser) parseLambda(tok erbToken) (erbNode, error) {
+	p.scopes = append(p.scopes, map[string]bool{})
+	defer func() { p.scopes = p.scopes[:len(p.scopes)-1] }()
+
+	var params []erbBlockParam
+
+	if p.accept(erbTokOp, "(") {
+		var err error
+		params, err = p.parseBlockParams(")")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if next := p.peek(); !next.Is(erbTokOp, "{") && !next.Is(erbTokKeyword, "do") {
+		return nil, p.unexpected(next)
+	}
+
+	call := &erbCallNode{Name: "lambda", Line: tok.Line}
+
+	err := p.parseBlock(call)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(params) > 0 {
+		call.Block.Params = params
+	}
+
+	return call, nil
+}
+
+func (p *erbParser) numberLit(tok erbToken, sign string) (erbNode, error) {
+	if tok.Kind == erbTokFloat {
+		val, err := strconv.ParseFloat(sign+tok.Val, 64)
+		if err != nil {
+			return nil, newERBError("SyntaxError", tok.Line, "invalid float '%s'", tok.Val)
+		}
+		return erbLitNode{Val: val}, nil
+	}
+
+	val, err := strconv.ParseInt(sign+tok.Val, 0, 64)
+	if err != nil {
+		return nil, newERBError("SyntaxError", tok.Line, "invalid integer '%s'", tok.Val)
+	}
+
+	return erbLitNode{Val: int(val)}, nil
+}
+
+func (p *erbParser) strNode(parts []erbStrPart) (erbNode, error) {
+	if len(parts) == 1 && !parts[0].IsCode {
+		return erbLitNode{Val: parts[0].Lit}, nil
+	}
+
+	node := erbStrNode{}
+
+	for _, part := range parts {
+		if !part.IsCode {
+			node.Parts = append(node.Parts, erbLitNode{Val: part.Lit})
+			continue
+		}
+
+		tokens, err := lexRuby(part.Code, part.Line)
+		if err != nil {
+			return nil, err
+		}
+
+		sub := &erbParser{tokens: tokens, scopes: p.scopes}
+
+		body, err := sub.parseProgram()
+		if err != nil {
+			return nil, err
+		}
+
+		node.Parts = append(node.Parts, body)
+	}
+
+	return node, nil
+}
+
+func (p *erbParser) parseHash() (erbNode, error) {
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	hash := erbHashNode{}
+
+	for {
+		p.skipNewlines()
+
+		if p.accept(erbTokOp, "}") {
+			return hash, nil
+		}
+
+		var key erbNode
+
+		if tok := p.peek(); tok.Kind == erbTokLabel {
+			p.advance()
+			key = erbLitNode{Val: erbSymbol(tok.Val)}
+		} else {
+			expr, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+
+			// "key": value
+			if lit, ok := expr.(erbLitNode); ok && p.peek().Is(erbTokOp, ":") {
+				if str, ok := lit.Val.(string); ok {
+					p.advance()
+					expr = erbLitNode{Val: erbSymbol(str)}
+				}
+			} else {
+				p.skipNewlines()
+
+				err = p.expect(erbTokOp, "=>")
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			key = expr
+		}
+
+		p.skipNewlines()
+
+		value, err := p.parseNotWithoutAssignment()
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Vals = append(hash.Vals, value)
+
+		p.skipNewlines()
+
+		if !p.accept(erbTokOp, ",") {
+			p.skipNewlines()
+			return hash, p.expect(erbTokOp, "}")
+		}
+	}
+}
+
+func (p *erbParser) parseKeyword(tok erbToken) (erbNode, error) {
+	switch tok.Val {
+	case "nil":
+		return erbLitNode{Val: nil}, nil
+	case "true":
+		return erbLitNode{Val: true}, nil
+	case "false":
+		return erbLitNode{Val: false}, nil
+	case "self":
+		return erbSelfNode{}, nil
+
+	case "if", "unless":
+		return p.parseIf(tok.Val == "unless")
+
+	case "case":
+		return p.parseCase()
+
+	case "while", "until":
+		return p.parseWhile(tok.Val == "until")
+
+	case "for":
+		return p.parseFor()
+
+	case "begin":
+		return p.parseBegin()
+
+	case "not":
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return erbNotNode{Expr: expr}, nil
+
+	case "defined?":
+		parens := p.accept(erbTokOp, "(")
+
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if parens {
+			err = p.expect(erbTokOp, ")")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return erbDefinedNode{Expr: expr}, nil
+
+	case "def":
+		return p.parseDef()
+
+	case "break", "next", "return":
+		var value erbNode
+
+		if next := p.peek(); next.Kind != erbTokNewline && !p.isStatementsEnd(next) && next.Kind != erbTokKeyword {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			value = expr
+		}
+
+		switch tok.Val {
+		case "break":
+			return erbBreakNode{Value: value}, nil
+		case "return":
+			return erbReturnNode{Value: value}, nil
+		}
+
+		return erbNextNode{Value: value}, nil
+	}
+
+	return nil, p.unexpected(tok)
+}
+
+func (p *erbParser) parseCond() (erbNode, error) {
+	p.noDo++
+	defer func() { p.noDo-- }()
+
+	return p.parseAndOr()
+}
+
+func (p *erbParser) parseIf(unless bool) (erbNode, error) {
+	cond, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+
+	if unless {
+		cond = erbNotNode{Expr: cond}
+	}
+
+	p.accept(erbTokKeyword, "then")
+
+	thenNode, err := p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	node := erbIfNode{Cond: cond, Then: thenNode}
+
+	switch tok := p.advance(); {
+	case tok.Is(erbTokKeyword, "elsif") && !unless:
+		elseNode, err := p.parseIf(false)
+		if err != nil {
+			return nil, err
+		}
+		node.Else = elseNode
+		return node, nil
+
+	case tok.Is(erbTokKeyword, "else"):
+		elseNode, err := p.parseStatements()
+		if err != nil {
+			return nil, err
+		}
+		node.Else = elseNode
+		return node, p.expect(erbTokKeyword, "end")
+
+	case tok.Is(erbTokKeyword, "end"):
+		return node, nil
+
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
+
+func (p *erbParser) parseCase() (erbNode, error) {
+	node := erbCaseNode{}
+
+	if p.peek().Kind != erbTokNewline {
+		subject, err := p.parseCond()
+		if err != nil {
+			return nil, err
+		}
+		node.Subject = subject
+	}
+
+	p.skipNewlines()
+
+	for p.accept(erbTokKeyword, "when") {
+		clause := erbWhenClause{}
+
+		for {
+			splat := p.accept(erbTokOp, "*")
+
+			cond, err := p.parseCond()
+			if err != nil {
+				return nil, err
+			}
+
+			if splat {
+				cond = erbSplatNode{Expr: cond}
+			}
+
+			clause.Conds = append(clause.Conds, cond)
+
+			if !p.accept(erbTokOp, ",") {
+				break
+			}
+
+			p.skipNewlines()
+		}
+
+		p.accept(erbTokKeyword, "then")
+
+		body, err := p.parseStatements()
+		if err != nil {
+			return nil, err
+		}
+
+		clause.Body = body
+		node.Whens = append(node.Whens, clause)
+	}
+
+	if p.accept(erbTokKeyword, "else") {
+		elseNode, err := p.parseStatements()
+		if err != nil {
+			return nil, err
+		}
+		node.Else = elseNode
+	}
+
+	return node, p.expect(erbTokKeyword, "end")
+}
+
+func (p *erbParser) parseWhile(until bool) (erbNode, error) {
+	cond, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+
+	p.accept(erbTokKeyword, "do")
+
+	body, err := p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	return erbWhileNode{Cond: cond, Body: body, Until: until}, p.expect(erbTokKeyword, "end")
+}
+
+func (p *erbParser) parseFor() (erbNode, error) {
+	node := erbForNode{}
+
+	for {
+		tok := p.advance()
+		if tok.Kind != erbTokIdent {
+			return nil, p.unexpected(tok)
+		}
+
+		p.declare(tok.Val)
+		node.Params = append(node.Params, erbBlockParam{Name: tok.Val})
+
+		if !p.accept(erbTokOp, ",") {
+			break
+		}
+	}
+
+	err := p.expect(erbTokKeyword, "in")
+	if err != nil {
+		return nil, err
+	}
+
+	node.Iter, err = p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+
+	p.accept(erbTokKeyword, "do")
+
+	node.Body, err = p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	return node, p.expect(erbTokKeyword, "end")
+}
+
+func (p *erbParser) parseDef() (erbNode, error) {
+	nameTok := p.advance()
+
+	if nameTok.Is(erbTokKeyword, "self") && p.accept(erbTokOp, ".") {
+		nameTok = p.advance()
+	}
+
+	if nameTok.Kind != erbTokIdent && nameTok.Kind != erbTokConst {
+		return nil, p.unexpected(nameTok)
+	}
+
+	node := erbDefNode{Name: nameTok.Val}
+
+	// Methods do not see local variables of the template
+	savedScopes := p.scopes
+	p.scopes = []map[string]bool{{}}
+	defer func() { p.scopes = savedScopes }()
+
+	var err error
+
+	if p.accept(erbTokOp, "(") {
+		node.Params, err = p.parseDefParams(func() bool { return p.peek().Is(erbTokOp, ")") })
+		if err == nil {
+			err = p.expect(erbTokOp, ")")
+		}
+	} else if p.peek().Kind != erbTokNewline {
+		node.Params, err = p.parseDefParams(func() bool { return p.peek().Kind == erbTokNewline })
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	node.Body, err = p.parseBegin()
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func (p *erbParser) parseDefParams(isClosing func() bool) ([]erbDefParam, error) {
+	var params []erbDefParam
+
+	for !isClosing() {
+		var param erbDefParam
+
+		tok := p.advance()
+		switch {
+		case tok.Is(erbTokOp, "*"), tok.Is(erbTokOp, "&"):
+			param.Splat = tok.Val == "*"
+			param.Block = tok.Val == "&"
+			tok = p.advance()
+		}
+
+		if tok.Kind != erbTokIdent {
+			return nil, p.unexpected(tok)
+		}
+
+		param.Name = tok.Val
+
+		if !param.Splat && !param.Block && p.accept(erbTokOp, "=") {
+			def, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			param.Default = def
+		}
+
+		p.declare(param.Name)
+		params = append(params, param)
+
+		if !p.accept(erbTokOp, ",") && !isClosing() {
+			return nil, p.unexpected(p.peek())
+		}
+	}
+
+	return params, nil
+}
+
+func (p *erbParser) parseBegin() (erbNode, error) {
+	body, err := p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	node := erbBeginNode{Body: body}
+
+	if p.accept(erbTokKeyword, "rescue") {
+		// Exception classes are ignored; any error is rescued
+		for p.peek().Kind == erbTokConst || p.peek().Is(erbTokOp, ",") || p.peek().Is(erbTokOp, "::") {
+			p.advance()
+		}
+
+		if p.accept(erbTokOp, "=>") {
+			tok := p.advance()
+			if tok.Kind != erbTokIdent {
+				return nil, p.unexpected(tok)
+			}
+
+			p.declare(tok.Val)
+			node.RescueVar = tok.Val
+		}
+
+		p.accept(erbTokKeyword, "then")
+
+		rescue, err := p.parseStatements()
+		if err != nil {
+			return nil, err
+		}
+
+		node.Rescue = rescue
+	}
+
+	if p.accept(erbTokKeyword, "ensure") {
+		ensure, err := p.parseStatements()
+		if err != nil {
+			return nil, err
+		}
+
+		node.Ensure = ensure
+	}
+
+	return node, p.expect(erbTokKeyword, "end")
+}
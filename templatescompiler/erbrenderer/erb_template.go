@@ -0,0 +1,138 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// erbTemplate is a compiled ERB template; text and Ruby code between
+// tags are parsed together so that blocks may span multiple tags
+type erbTemplate struct {
+	body erbNode
+}
+
+func compileERBTemplate(src string) (erbTemplate, error) {
+	tokens, err := scanERB(src)
+	if err != nil {
+		return erbTemplate{}, err
+	}
+
+	body, err := newERBParser(tokens).parseProgram()
+	if err != nil {
+		return erbTemplate{}, err
+	}
+
+	return erbTemplate{body: body}, nil
+}
+
+func scanERB(src string) ([]erbToken, error) {
+	var tokens []erbToken
+	var text []string
+
+	line := 1
+
+	flushText := func() {
+		joined := strings.Join(text, "")
+		if len(joined) > 0 {
+			tokens = append(tokens,
+				erbToken{Kind: erbTokText, Val: joined, Line: line},
+				erbToken{Kind: erbTokNewline, Line: line},
+			)
+			line += strings.Count(joined, "\n")
+		}
+		text = nil
+	}
+
+	for len(src) > 0 {
+		start := strings.Index(src, "<%")
+		if start < 0 {
+			text = append(text, src)
+			break
+		}
+
+		// '<%%' produces literal '<%'
+		if strings.HasPrefix(src[start:], "<%%") {
+			text = append(text, src[:start]+"<%")
+			src = src[start+3:]
+			continue
+		}
+
+		text = append(text, src[:start])
+		src = src[start+2:]
+
+		kind := byte(0)
+		if len(src) > 0 && (src[0] == '=' || src[0] == '#' || src[0] == '-') {
+			kind = src[0]
+			src = src[1:]
+			if kind == '=' && strings.HasPrefix(src, "=") {
+				src = src[1:]
+			}
+		}
+
+		if kind == '-' {
+			// '<%-' removes indentation preceding the tag
+			last := len(text) - 1
+			idx := strings.LastIndex(text[last], "\n")
+			if strings.TrimLeft(text[last][idx+1:], " \t") == "" {
+				text[last] = text[last][:idx+1]
+			}
+		}
+
+		flushText()
+
+		end := strings.Index(src, "%>")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated ERB tag on line %d", line)
+		}
+
+		code := src[:end]
+		src = src[end+2:]
+
+		trimNewline := strings.HasSuffix(code, "-")
+		if trimNewline {
+			code = code[:len(code)-1]
+		}
+
+		switch kind {
+		case '#':
+			// comment
+		case '=':
+			codeTokens, err := lexRuby(code, line)
+			if err != nil {
+				return nil, err
+			}
+			// Output code is parenthesized like Ruby's ERB does,
+			// so that '<%= a; b %>' outputs value of the last statement
+			tokens = append(tokens, erbToken{Kind: erbTokOutput, Line: line})
+			tokens = append(tokens, erbToken{Kind: erbTokOp, Val: "(", Line: line, SpaceBefore: true})
+			tokens = append(tokens, codeTokens...)
+			tokens = append(tokens, erbToken{Kind: erbTokOp, Val: ")", Line: line + strings.Count(code, "\n")})
+			tokens = append(tokens, erbToken{Kind: erbTokNewline, Line: line})
+		default:
+			codeTokens, err := lexRuby(code, line)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, codeTokens...)
+			tokens = append(tokens, erbToken{Kind: erbTokNewline, Line: line})
+		}
+
+		line += strings.Count(code, "\n")
+
+		if trimNewline {
+			if strings.HasPrefix(src, "\r\n") {
+				src = src[2:]
+				line++
+			} else if strings.HasPrefix(src, "\n") {
+				src = src[1:]
+				line++
+			}
+		}
+	}
+
+	flushText()
+
+	tokens = append(tokens, erbToken{Kind: erbTokEOF, Line: line})
+
+	return tokens, nil
+}
@@ -0,0 +1,1004 @@
+package erbrenderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Ruby values are represented with following Go types:
+//   nil, bool, int, float64, string, *erbString, erbSymbol,
+//   *erbArray, *erbHash, *erbRange, *erbRegexp, *erbMatchData,
+//   *erbOpenStruct, *erbProc, *erbClass and erbObject implementations.
+
+type erbSymbol string
+
+// erbString is a String object that may be referenced from several places,
+// e.g. by variables and array items, so that in-place modifications
+// such as '<<' are visible through all of them. Plain Go strings are used
+// for intermediate values that nothing refers to yet.
+type erbString struct {
+	Val string
+}
+
+// erbStringObj wraps plain string into String object; other values are returned as is
+func erbStringObj(val interface{}) interface{} {
+	if str, ok := val.(string); ok {
+		return &erbString{Val: str}
+	}
+	return val
+}
+
+// erbUnwrap returns plain Go string for String objects
+func erbUnwrap(val interface{}) interface{} {
+	if str, ok := val.(*erbString); ok {
+		return str.Val
+	}
+	return val
+}
+
+func erbUnwrapAll(vals []interface{}) []interface{} {
+	var unwrapped []interface{}
+	for idx, val := range vals {
+		if str, ok := val.(*erbString); ok {
+			if unwrapped == nil {
+				unwrapped = append([]interface{}{}, vals...)
+			}
+			unwrapped[idx] = str.Val
+		}
+	}
+	if unwrapped == nil {
+		return vals
+	}
+	return unwrapped
+}
+
+type erbArray struct {
+	Items []interface{}
+}
+
+func newERBArray(items ...interface{}) *erbArray {
+	return &erbArray{Items: items}
+}
+
+// erbHash keeps insertion order just like Ruby's Hash
+type erbHash struct {
+	keys    []interface{}               // original keys in insertion order
+	vals    map[interface{}]interface{} // values by erbHashKey
+	def     interface{}
+	defProc *erbProc // block given to Hash.new
+}
+
+func newERBHash() *erbHash {
+	return &erbHash{vals: map[interface{}]interface{}{}}
+}
+
+func (h *erbHash) Get(key interface{}) (interface{}, bool) {
+	val, found := h.vals[erbHashKey(key)]
+	return val, found
+}
+
+func (h *erbHash) val(key interface{}) interface{} {
+	return h.vals[erbHashKey(key)]
+}
+
+func (h *erbHash) Set(key, val interface{}) {
+	// Like Ruby, keep a copy of String keys so that modifying them has no effect
+	key = erbUnwrap(key)
+	hashKey := erbHashKey(key)
+	if _, found := h.vals[hashKey]; !found {
+		h.keys = append(h.keys, key)
+	}
+	h.vals[hashKey] = val
+}
+
+func (h *erbHash) Delete(key interface{}) (interface{}, bool) {
+	hashKey := erbHashKey(key)
+
+	val, found := h.vals[hashKey]
+	if !found {
+		return nil, false
+	}
+
+	delete(h.vals, hashKey)
+
+	for i, k := range h.keys {
+		if erbHashKey(k) == hashKey {
+			h.keys = append(h.keys[:i:i], h.keys[i+1:]...)
+			break
+		}
+	}
+
+	return val, true
+}
+
+func (h *erbHash) Len() int { return len(h.keys) }
+
+func (h *erbHash) Keys() []interface{} {
+	return append([]interface{}{}, h.keys...)
+}
+
+func (h *erbHash) Pairs() []interface{} {
+	var pairs []interface{}
+	for _, key := range h.keys {
+		pairs = append(pairs, newERBArray(key, h.val(key)))
+	}
+	return pairs
+}
+
+func (h *erbHash) Dup() *erbHash {
+	dup := newERBHash()
+	for _, key := range h.keys {
+		dup.Set(key, h.val(key))
+	}
+	dup.def = h.def
+	dup.defProc = h.defProc
+	return dup
+}
+
+// Default returns value for missing keys, e.g. 0 for Hash.new(0)
+func (h *erbHash) Default() interface{} { return h.def }
+
+func (h *erbHash) SetDefault(val interface{}) { h.def = val }
+
+// erbHashKey makes sure keys are comparable Go values;
+// arrays are converted to their inspected representation
+func erbHashKey(key interface{}) interface{} {
+	key = erbUnwrap(key)
+
+	switch key.(type) {
+	case nil, bool, int, float64, string, erbSymbol:
+		return key
+	default:
+		return erbHashKeyOf{erbInspect(key)}
+	}
+}
+
+type erbHashKeyOf struct{ inspected string }
+
+type erbRange struct {
+	From, To  interface{}
+	Exclusive bool
+}
+
+func (r *erbRange) ints() (int, int, bool) {
+	from, ok1 := r.From.(int)
+	to, ok2 := r.To.(int)
+	if r.To == nil {
+		to, ok2 = math.MaxInt32, true
+	}
+	if ok2 && r.Exclusive {
+		to--
+	}
+	return from, to, ok1 && ok2
+}
+
+func (r *erbRange) Items() ([]interface{}, error) {
+	from, to, ok := r.ints()
+	if !ok {
+		if fromStr, ok := r.From.(string); ok {
+			if toStr, ok := r.To.(string); ok && len(fromStr) == 1 && len(toStr) == 1 {
+				var items []interface{}
+				last := toStr[0]
+				if r.Exclusive {
+					last--
+				}
+				for c := fromStr[0]; c <= last && c >= fromStr[0]; c++ {
+					items = append(items, string(c))
+				}
+				return items, nil
+			}
+		}
+		return nil, newERBError("TypeError", 0, "can't iterate from %s", erbClassName(r.From))
+	}
+
+	var items []interface{}
+	for i := from; i <= to; i++ {
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+func (r *erbRange) Includes(val interface{}) bool {
+	if cmp, err := erbCompare(r.From, val); err != nil || cmp > 0 {
+		return false
+	}
+	if r.To == nil {
+		return true
+	}
+	cmp, err := erbCompare(val, r.To)
+	if err != nil {
+		return false
+	}
+	return cmp < 0 || (cmp == 0 && !r.Exclusive)
+}
+
+type erbRegexp struct {
+	Re     *regexp.Regexp
+	Source string
+	Flags  string
+}
+
+func newERBRegexp(source, flags string) (*erbRegexp, error) {
+	re, err := regexp.Compile(erbGoRegexp(source, flags))
+	if err != nil {
+		return nil, newERBError("RegexpError", 0, "%s: /%s/", err.Error(), source)
+	}
+	return &erbRegexp{Re: re, Source: source, Flags: flags}, nil
+}
+
+// erbGoRegexp translates commonly used Ruby regexp syntax to RE2
+func erbGoRegexp(source, flags string) string {
+	var buf bytes.Buffer
+
+	goFlags := "m" // ^ and $ always match at line boundaries in Ruby
+	if strings.Contains(flags, "i") {
+		goFlags += "i"
+	}
+	if strings.Contains(flags, "m") {
+		goFlags += "s"
+	}
+
+	buf.WriteString("(?" + goFlags + ")")
+
+	extended := strings.Contains(flags, "x")
+	inClass := false
+
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+
+		switch {
+		case c == '\\' && i+1 < len(source):
+			next := source[i+1]
+			i++
+			switch next {
+			case 'h':
+				buf.WriteString(`[0-9a-fA-F]`)
+			case 'H':
+				buf.WriteString(`[^0-9a-fA-F]`)
+			case 'Z':
+				buf.WriteString(`\n?\z`)
+			case '/':
+				buf.WriteByte('/')
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(next)
+			}
+
+		case c == '[':
+			inClass = true
+			buf.WriteByte(c)
+
+		case c == ']':
+			inClass = false
+			buf.WriteByte(c)
+
+		case c == '(' && strings.HasPrefix(source[i:], "(?<") && !strings.HasPrefix(source[i:], "(?<=") && !strings.HasPrefix(source[i:], "(?<!"):
+			buf.WriteString("(?P<")
+			i += 2
+
+		case extended && !inClass && (c == ' ' || c == '\t' || c == '\n'):
+			// ignored in extended mode
+
+		case extended && !inClass && c == '#':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.String()
+}
+
+type erbMatchData struct {
+	Str      string
+	Indices  []int
+	Names    []string
+	Captures []interface{} // nil for unmatched groups
+}
+
+func newERBMatchData(re *regexp.Regexp, str string, indices []int) *erbMatchData {
+	md := &erbMatchData{Str: str, Indices: indices, Names: re.SubexpNames()}
+
+	for i := 0; i*2 < len(indices); i++ {
+		if indices[i*2] < 0 {
+			md.Captures = append(md.Captures, nil)
+		} else {
+			md.Captures = append(md.Captures, str[indices[i*2]:indices[i*2+1]])
+		}
+	}
+
+	return md
+}
+
+type erbOpenStruct struct {
+	Fields *erbHash // string keys
+}
+
+type erbProc struct {
+	Params []erbBlockParam
+	Body   erbNode
+	Env    *erbEnv
+	Sym    string // from &:sym
+	Lambda bool
+}
+
+type erbClass struct {
+	Name string
+}
+
+// erbObject is implemented by objects with their own methods
+// such as template context and links
+type erbObject interface {
+	ClassName() string
+	CallMethod(i *erbInterpreter, name string, args []interface{}, blk *erbProc) (interface{}, bool, error)
+}
+
+func erbTruthy(val interface{}) bool {
+	return val != nil && val != false
+}
+
+var erbClassParents = map[string][]string{
+	"Integer":    {"Numeric", "Comparable"},
+	"Float":      {"Numeric", "Comparable"},
+	"String":     {"Comparable"},
+	"Array":      {"Enumerable"},
+	"Hash":       {"Enumerable"},
+	"Range":      {"Enumerable"},
+	"OpenStruct": {},
+}
+
+func erbClassName(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return "NilClass"
+	case bool:
+		if typedVal {
+			return "TrueClass"
+		}
+		return "FalseClass"
+	case int:
+		return "Integer"
+	case float64:
+		return "Float"
+	case string, *erbString:
+		return "String"
+	case erbSymbol:
+		return "Symbol"
+	case *erbArray:
+		return "Array"
+	case *erbHash:
+		return "Hash"
+	case *erbRange:
+		return "Range"
+	case *erbRegexp:
+		return "Regexp"
+	case *erbMatchData:
+		return "MatchData"
+	case *erbOpenStruct:
+		return "OpenStruct"
+	case *erbProc:
+		return "Proc"
+	case *erbClass:
+		return "Class"
+	case erbObject:
+		return typedVal.ClassName()
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
+
+func erbIsA(val interface{}, class string) bool {
+	name := erbClassName(val)
+
+	switch class {
+	case "Object", "BasicObject", "Kernel":
+		return true
+	case "Fixnum", "Bignum":
+		class = "Integer"
+	case "Exception", "StandardError":
+		_, isErr := val.(*erbError)
+		return isErr
+	}
+
+	if name == class {
+		return true
+	}
+
+	for _, parent := range erbClassParents[name] {
+		if parent == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+func erbEqual(a, b interface{}) bool {
+	a, b = erbUnwrap(a), erbUnwrap(b)
+
+	switch typedA := a.(type) {
+	case int:
+		switch typedB := b.(type) {
+		case int:
+			return typedA == typedB
+		case float64:
+			return float64(typedA) == typedB
+		}
+		return false
+	case float64:
+		switch typedB := b.(type) {
+		case int:
+			return typedA == float64(typedB)
+		case float64:
+			return typedA == typedB
+		}
+		return false
+	case *erbArray:
+		typedB, ok := b.(*erbArray)
+		if !ok || len(typedA.Items) != len(typedB.Items) {
+			return false
+		}
+		for i := range typedA.Items {
+			if !erbEqual(typedA.Items[i], typedB.Items[i]) {
+				return false
+			}
+		}
+		return true
+	case *erbHash:
+		typedB, ok := b.(*erbHash)
+		if !ok || typedA.Len() != typedB.Len() {
+			return false
+		}
+		for _, key := range typedA.keys {
+			valB, found := typedB.Get(key)
+			if !found || !erbEqual(typedA.val(key), valB) {
+				return false
+			}
+		}
+		return true
+	case *erbRange:
+		typedB, ok := b.(*erbRange)
+		return ok && erbEqual(typedA.From, typedB.From) && erbEqual(typedA.To, typedB.To) && typedA.Exclusive == typedB.Exclusive
+	case *erbOpenStruct:
+		typedB, ok := b.(*erbOpenStruct)
+		return ok && erbEqual(typedA.Fields, typedB.Fields)
+	case *erbClass:
+		typedB, ok := b.(*erbClass)
+		return ok && typedA.Name == typedB.Name
+	case *erbRegexp:
+		typedB, ok := b.(*erbRegexp)
+		return ok && typedA.Source == typedB.Source && typedA.Flags == typedB.Flags
+	case nil, bool, string, erbSymbol:
+		return a == b
+	default:
+		return a == b
+	}
+}
+
+func erbCompare(a, b interface{}) (int, error) {
+	a, b = erbUnwrap(a), erbUnwrap(b)
+
+	switch typedA := a.(type) {
+	case int:
+		switch typedB := b.(type) {
+		case int:
+			return erbCmpInts(typedA, typedB), nil
+		case float64:
+			return erbCmpFloats(float64(typedA), typedB), nil
+		}
+	case float64:
+		switch typedB := b.(type) {
+		case int:
+			return erbCmpFloats(typedA, float64(typedB)), nil
+		case float64:
+			return erbCmpFloats(typedA, typedB), nil
+		}
+	case string:
+		if typedB, ok := b.(string); ok {
+			return strings.Compare(typedA, typedB), nil
+		}
+	case erbSymbol:
+		if typedB, ok := b.(erbSymbol); ok {
+			return strings.Compare(string(typedA), string(typedB)), nil
+		}
+	case *erbArray:
+		if typedB, ok := b.(*erbArray); ok {
+			for i := 0; i < len(typedA.Items) && i < len(typedB.Items); i++ {
+				cmp, err := erbCompare(typedA.Items[i], typedB.Items[i])
+				if err != nil || cmp != 0 {
+					return cmp, err
+				}
+			}
+			return erbCmpInts(len(typedA.Items), len(typedB.Items)), nil
+		}
+	}
+
+	return 0, newERBError("ArgumentError", 0, "comparison of %s with %s failed", erbClassName(a), erbInspect(b))
+}
+
+func erbCmpInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func erbCmpFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func erbToS(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return typedVal
+	case *erbString:
+		return typedVal.Val
+	case erbSymbol:
+		return string(typedVal)
+	case *erbRange:
+		return erbInspect(typedVal)
+	case *erbMatchData:
+		return typedVal.Captures[0].(string)
+	case *erbClass:
+		return typedVal.Name
+	case *erbError:
+		return typedVal.Msg
+	default:
+		return erbInspect(val)
+	}
+}
+
+func erbInspect(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(typedVal)
+	case int:
+		return strconv.Itoa(typedVal)
+	case float64:
+		return erbFloatToS(typedVal)
+	case string:
+		return erbInspectString(typedVal)
+	case *erbString:
+		return erbInspectString(typedVal.Val)
+	case erbSymbol:
+		if isIdentName(string(typedVal)) {
+			return ":" + string(typedVal)
+		}
+		return ":" + erbInspectString(string(typedVal))
+	case *erbArray:
+		var items []string
+		for _, item := range typedVal.Items {
+			items = append(items, erbInspect(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *erbHash:
+		var pairs []string
+		for _, key := range typedVal.keys {
+			pairs = append(pairs, erbInspect(key)+"=>"+erbInspect(typedVal.val(key)))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case erbHashKeyOf:
+		return typedVal.inspected
+	case *erbRange:
+		op := ".."
+		if typedVal.Exclusive {
+			op = "..."
+		}
+		to := ""
+		if typedVal.To != nil {
+			to = erbInspect(typedVal.To)
+		}
+		return erbInspect(typedVal.From) + op + to
+	case *erbRegexp:
+		return "/" + typedVal.Source + "/" + typedVal.Flags
+	case *erbMatchData:
+		return "#<MatchData " + erbInspectString(erbToS(typedVal)) + ">"
+	case *erbOpenStruct:
+		var fields []string
+		for _, key := range typedVal.Fields.keys {
+			fields = append(fields, " "+erbToS(key)+"="+erbInspect(typedVal.Fields.val(key)))
+		}
+		return "#<OpenStruct" + strings.Join(fields, ",") + ">"
+	case *erbProc:
+		return "#<Proc>"
+	case *erbClass:
+		return typedVal.Name
+	case *erbError:
+		return typedVal.Error()
+	default:
+		return "#<" + erbClassName(val) + ">"
+	}
+}
+
+func erbInspectString(str string) string {
+	var buf bytes.Buffer
+
+	buf.WriteByte('"')
+
+	for i, r := range str {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case 27:
+			buf.WriteString(`\e`)
+		case '#':
+			if i+1 < len(str) && (str[i+1] == '{' || str[i+1] == '$' || str[i+1] == '@') {
+				buf.WriteString(`\#`)
+			} else {
+				buf.WriteRune(r)
+			}
+		default:
+			if r == utf8.RuneError || r < 32 {
+				fmt.Fprintf(&buf, `\x%02X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+func erbFloatToS(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	exp := 0
+	if f != 0 {
+		exp = int(math.Floor(math.Log10(math.Abs(f))))
+	}
+
+	if exp < -4 || exp >= 16 {
+		str := strconv.FormatFloat(f, 'e', -1, 64)
+		parts := strings.SplitN(str, "e", 2)
+		if !strings.Contains(parts[0], ".") {
+			parts[0] += ".0"
+		}
+		return parts[0] + "e" + parts[1]
+	}
+
+	str := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(str, ".") {
+		str += ".0"
+	}
+
+	return str
+}
+
+// erbFromJSON decodes JSON preserving order of object keys
+func erbFromJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	val, err := erbDecodeJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+
+	return val, nil
+}
+
+func erbDecodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typedTok := tok.(type) {
+	case json.Delim:
+		switch typedTok {
+		case '{':
+			hash := newERBHash()
+
+			for decoder.More() {
+				keyTok, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				val, err := erbDecodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				hash.Set(keyTok.(string), val)
+			}
+
+			_, err := decoder.Token()
+			return hash, err
+
+		case '[':
+			arr := newERBArray()
+
+			for decoder.More() {
+				val, err := erbDecodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				arr.Items = append(arr.Items, val)
+			}
+
+			_, err := decoder.Token()
+			return arr, err
+		}
+
+	case json.Number:
+		if i, err := strconv.Atoi(typedTok.String()); err == nil {
+			return i, nil
+		}
+		return typedTok.Float64()
+
+	case string, bool, nil:
+		return typedTok, nil
+	}
+
+	return nil, fmt.Errorf("unexpected JSON token '%v'", tok)
+}
+
+func erbToJSON(val interface{}, pretty bool) (string, error) {
+	var buf bytes.Buffer
+
+	err := erbWriteJSON(&buf, val, pretty, "")
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func erbWriteJSON(buf *bytes.Buffer, val interface{}, pretty bool, indent string) error {
+	nextIndent := indent + "  "
+
+	switch typedVal := erbUnwrap(val).(type) {
+	case nil:
+		buf.WriteString("null")
+
+	case bool, int:
+		buf.WriteString(erbInspect(typedVal))
+
+	case float64:
+		if math.IsNaN(typedVal) || math.IsInf(typedVal, 0) {
+			return newERBError("JSON::GeneratorError", 0, "%s not allowed in JSON", erbFloatToS(typedVal))
+		}
+		buf.WriteString(erbFloatToS(typedVal))
+
+	case *erbArray:
+		if len(typedVal.Items) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+
+		buf.WriteByte('[')
+
+		for i, item := range typedVal.Items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if pretty {
+				buf.WriteString("\n" + nextIndent)
+			}
+
+			err := erbWriteJSON(buf, item, pretty, nextIndent)
+			if err != nil {
+				return err
+			}
+		}
+
+		if pretty {
+			buf.WriteString("\n" + indent)
+		}
+
+		buf.WriteByte(']')
+
+	case *erbHash:
+		if typedVal.Len() == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+
+		buf.WriteByte('{')
+
+		for i, key := range typedVal.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if pretty {
+				buf.WriteString("\n" + nextIndent)
+			}
+
+			erbWriteJSONString(buf, erbToS(key))
+
+			if pretty {
+				buf.WriteString(": ")
+			} else {
+				buf.WriteByte(':')
+			}
+
+			err := erbWriteJSON(buf, typedVal.val(key), pretty, nextIndent)
+			if err != nil {
+				return err
+			}
+		}
+
+		if pretty {
+			buf.WriteString("\n" + indent)
+		}
+
+		buf.WriteByte('}')
+
+	case *erbOpenStruct:
+		return erbWriteJSON(buf, typedVal.Fields, pretty, indent)
+
+	default:
+		erbWriteJSONString(buf, erbToS(val))
+	}
+
+	return nil
+}
+
+func erbWriteJSONString(buf *bytes.Buffer, str string) {
+	buf.WriteByte('"')
+
+	for _, r := range str {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			if r < 32 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteByte('"')
+}
+
+var erbYAMLNullRegexp = regexp.MustCompile(`(?m)(:|^\s*-) null$`)
+
+func erbToYAML(val interface{}) (string, error) {
+	bytes, err := yaml.Marshal(erbToYAMLValue(val))
+	if err != nil {
+		return "", newERBError("RuntimeError", 0, "dumping YAML: %s", err.Error())
+	}
+
+	// Psych emits nil values as empty scalars
+	str := erbYAMLNullRegexp.ReplaceAllString(string(bytes), "$1 ")
+
+	switch val.(type) {
+	case *erbArray, *erbHash, *erbOpenStruct:
+		if !strings.HasPrefix(str, "[") && !strings.HasPrefix(str, "{") {
+			return "---\n" + str, nil
+		}
+	}
+
+	return "--- " + str, nil
+}
+
+func erbToYAMLValue(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case erbSymbol:
+		return ":" + string(typedVal)
+	case *erbArray:
+		items := []interface{}{}
+		for _, item := range typedVal.Items {
+			items = append(items, erbToYAMLValue(item))
+		}
+		return items
+	case *erbHash:
+		slice := yaml.MapSlice{}
+		for _, key := range typedVal.keys {
+			slice = append(slice, yaml.MapItem{Key: erbToYAMLValue(key), Value: erbToYAMLValue(typedVal.val(key))})
+		}
+		return slice
+	case *erbOpenStruct:
+		return erbToYAMLValue(typedVal.Fields)
+	case nil, bool, int, float64, string:
+		return typedVal
+	default:
+		return erbToS(val)
+	}
+}
+
+func erbFromYAML(str string) (interface{}, error) {
+	var val interface{}
+
+	err := yaml.Unmarshal([]byte(str), &val)
+	if err != nil {
+		return nil, newERBError("Psych::SyntaxError", 0, "%s", err.Error())
+	}
+
+	return erbFromGo(val), nil
+}
+
+// erbFromGo converts values produced by YAML unmarshalling
+func erbFromGo(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case map[interface{}]interface{}:
+		var keys []interface{}
+		for key := range typedVal {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j]) })
+
+		hash := newERBHash()
+		for _, key := range keys {
+			hash.Set(erbFromGo(key), erbFromGo(typedVal[key]))
+		}
+		return hash
+	case []interface{}:
+		arr := newERBArray()
+		for _, item := range typedVal {
+			arr.Items = append(arr.Items, erbFromGo(item))
+		}
+		return arr
+	case int64:
+		return int(typedVal)
+	case uint64:
+		return int(typedVal)
+	case float32:
+		return float64(typedVal)
+	default:
+		return typedVal
+	}
+}
@@ -0,0 +1,99 @@
+package erbrenderer
+
+import (
+	"encoding/json"
+	"runtime/debug"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// nativeERBRenderer evaluates ERB templates without shelling out to ruby.
+// It supports the subset of Ruby commonly used in job templates and
+// mirrors semantics of template_evaluation_context_rb.go. It is experimental
+// and only used when requested with --native-erb.
+type nativeERBRenderer struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewNativeERBRenderer(
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) ERBRenderer {
+	return nativeERBRenderer{
+		fs:     fs,
+		logger: logger,
+		logTag: "nativeERBRenderer",
+	}
+}
+
+func (r nativeERBRenderer) Render(srcPath, dstPath string, context TemplateEvaluationContext) error {
+	r.logger.Debug(r.logTag, "Rendering template %s", dstPath)
+
+	contextBytes, err := json.Marshal(context)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling context")
+	}
+
+	spec, err := erbFromJSON(contextBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling context")
+	}
+
+	evalContext, err := newERBContext(spec)
+	if err != nil {
+		return bosherr.WrapError(err, "Building template evaluation context")
+	}
+
+	src, err := r.fs.ReadFileString(srcPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading template '%s'", srcPath)
+	}
+
+	result, err := r.evaluate(src, evalContext)
+	if err != nil {
+		line := "unknown"
+		if typedErr, ok := err.(*erbError); ok && typedErr.Line > 0 {
+			line = strconv.Itoa(typedErr.Line)
+		}
+
+		return bosherr.Errorf("Error filling in template '%s' for %s/%s (line %s: %s)",
+			srcPath, erbToS(evalContext.name), erbToS(evalContext.index), line, err.Error())
+	}
+
+	err = r.fs.WriteFileString(dstPath, result)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing rendered template '%s'", dstPath)
+	}
+
+	return nil
+}
+
+func (r nativeERBRenderer) evaluate(src string, evalContext *erbContext) (result string, err error) {
+	var interpreter *erbInterpreter
+
+	// Report failures of the renderer itself as template errors instead of crashing
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			line := 0
+			if interpreter != nil {
+				line = interpreter.line
+			}
+			r.logger.Error(r.logTag, "Recovered from panic while rendering template: %v\n%s", recovered, debug.Stack())
+			result, err = "", newERBError("RuntimeError", line, "unexpected failure of built-in renderer: %v", recovered)
+		}
+	}()
+
+	tpl, err := compileERBTemplate(src)
+	if err != nil {
+		return "", err
+	}
+
+	interpreter = newERBInterpreter(evalContext, evalContext.ivars())
+
+	return interpreter.Run(tpl)
+}
@@ -0,0 +1,248 @@
+package erbrenderer_test
+
+import (
+	"errors"
+
+	. "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type jsonContext string
+
+func (c jsonContext) MarshalJSON() ([]byte, error) { return []byte(c), nil }
+
+var _ = Describe("NativeERBRenderer", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		erbRenderer ERBRenderer
+		context     jsonContext
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+
+		erbRenderer = NewNativeERBRenderer(fs, logger)
+
+		context = jsonContext(`{
+			"job": {"name": "fake-job"},
+			"index": 0,
+			"id": "fake-id",
+			"az": "z1",
+			"networks": {"default": {"ip": "10.0.0.5", "default": ["dns", "gateway"]}},
+			"global_properties": {},
+			"cluster_properties": {},
+			"job_properties": {
+				"port": 8080,
+				"name": "web",
+				"tls": {"enabled": true, "cert": "CERT"},
+				"users": [{"name": "admin", "groups": ["a", "b"]}, {"name": "ops", "groups": []}],
+				"env": {"B_KEY": "b", "A_KEY": "a"},
+				"ratio": 0.5,
+				"empty": ""
+			},
+			"default_properties": {
+				"port": 80,
+				"name": null,
+				"tls.enabled": false,
+				"tls.cert": null,
+				"tls.key": null,
+				"users": [],
+				"env": {},
+				"ratio": 1,
+				"empty": "x",
+				"missing": "default-value"
+			}
+		}`)
+	})
+
+	render := func(template string) (string, error) {
+		err := fs.WriteFileString("/src", template)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = erbRenderer.Render("/src", "/dst", context)
+		if err != nil {
+			return "", err
+		}
+
+		return fs.ReadFileString("/dst")
+	}
+
+	expectRendered := func(template, expected string) {
+		result, err := render(template)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(expected))
+	}
+
+	expectError := func(template, expected string) {
+		_, err := render(template)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(expected))
+	}
+
+	It("renders text and expressions", func() {
+		expectRendered("port=<%= p('port') %>\n", "port=8080\n")
+		expectRendered("<%# comment %>a<%% b %>", "a<% b %>")
+		expectRendered("<%= 1 + 2 * 3 %> <%= 7 / 2 %> <%= -7 / 2 %> <%= 7 % 3 %> <%= 2 ** 10 %>", "7 3 -4 1 1024")
+		expectRendered("<%= 1.0 / 4 %> <%= 10.0 %> <%= 1e20 %>", "0.25 10.0 1.0e+20")
+	})
+
+	It("outputs value of the last statement in output tags", func() {
+		expectRendered("<%= 1; 2 %>", "2")
+		expectRendered("<%= a = p('port'); a + 1 %>", "8081")
+		expectRendered("<%= x = 1\nx += 2\nx * 2 %>|<%= x %>", "6|3")
+		expectRendered("<%= 'a' if false %>|<%= nil %>|", "||")
+	})
+
+	It("trims lines using '-' markers", func() {
+		expectRendered("a\n  <%- if true -%>\nb\n  <%- end -%>\nc\n", "a\nb\nc\n")
+		expectRendered("<% if false %>\nx\n<% end %>\ny", "\ny")
+	})
+
+	It("looks up properties with defaults", func() {
+		expectRendered("<%= p('tls.enabled') %>", "true")
+		expectRendered("<%= p('missing') %>", "default-value")
+		expectRendered("<%= p('empty') %>|", "|")
+		expectRendered("<%= p('name', 'x') %>", "web")
+		expectRendered("<%= p('tls.key', 'fallback') %>", "fallback")
+		expectRendered("<%= p(['tls.key', 'tls.cert']) %>", "CERT")
+		expectRendered("<%= p('ratio') %>", "0.5")
+	})
+
+	It("returns an error for unknown properties", func() {
+		expectError("\n<%= p('tls.key') %>",
+			"Error filling in template '/src' for fake-job/0 (line 2: #<TemplateEvaluationContext::UnknownProperty: Can't find property 'tls.key'>)")
+		expectError("<%= p(['a', 'b']) %>", "Can't find property 'a', or 'b'")
+	})
+
+	It("supports if_p with else blocks", func() {
+		expectRendered("<% if_p('tls.cert', 'port') do |cert, port| %><%= cert %>:<%= port %><% end %>", "CERT:8080")
+		expectRendered("<% if_p('tls.key') do |key| %>key<% end.else do %>no-key<% end %>", "no-key")
+		expectRendered("<% if_p('tls.key') do |key| %>key<% end.else_if_p('port') do |port| %><%= port %><% end %>", "8080")
+		expectRendered("<% if_p('port') do |port| %>a<% end.else do %>b<% end %>", "a")
+	})
+
+	It("exposes properties and spec as open structs", func() {
+		expectRendered("<%= properties.tls.cert %> <%= properties.users.first.name %>", "CERT admin")
+		expectRendered("<%= spec.networks.default.ip %> <%= spec.az %> <%= spec.id %> <%= spec.index %>", "10.0.0.5 z1 fake-id 0")
+		expectRendered("<%= spec.bootstrap.inspect %> <%= spec['az'] %>", "nil z1")
+		expectRendered("<%= name %>/<%= index %> <%= @name %>", "fake-job/0 fake-job")
+		expectRendered("<%= raw_properties['tls']['cert'] %>", "CERT")
+	})
+
+	It("supports common iteration idioms", func() {
+		expectRendered(`<% p('users').each do |user| %><%= user['name'] %>:<%= user['groups'].join(',') %>;<% end %>`, "admin:a,b;ops:;")
+		expectRendered(`<%= p('users').map { |u| u['name'] }.join(' ') %>`, "admin ops")
+		expectRendered(`<%= p('users').map.with_index(1) { |u, i| "#{i}=#{u['name']}" }.join(' ') %>`, "1=admin 2=ops")
+		expectRendered(`<% p('users').each_with_index do |u, i| %><%= i %><% end %>`, "01")
+		expectRendered(`<%= p('env').sort.map { |k, v| "#{k}=#{v}" }.join(' ') %>`, "A_KEY=a B_KEY=b")
+		expectRendered(`<%= p('env').keys.sort.inspect %>`, `["A_KEY", "B_KEY"]`)
+		expectRendered(`<%= p('users').select { |u| u['groups'].empty? }.map { |u| u['name'] }.inspect %>`, `["ops"]`)
+		expectRendered(`<%= p('env').map { |k, v| k.downcase }.sort.join %>`, "a_keyb_key")
+		expectRendered(`<%= [3, 1, 2].sort_by { |x| -x }.inspect %> <%= [1, 2, 3].inject(:+) %> <%= (1..3).to_a.inspect %>`, "[3, 2, 1] 6 [1, 2, 3]")
+		expectRendered(`<%= %w(a b c).each_slice(2).map { |s| s.join }.inspect %>`, `["ab", "c"]`)
+		expectRendered(`<% x = 0; 3.times { |i| x += i } %><%= x %>`, "3")
+	})
+
+	It("supports strings", func() {
+		expectRendered(`<%= "a-b-c".split('-').inspect %> <%= 'x'.upcase %> <%= "  s ".strip %>|`, `["a", "b", "c"] X s|`)
+		expectRendered(`<%= "host:8080".gsub(/:\d+/, '') %> <%= "abc".sub('b') { |m| m.upcase } %>`, "host aBc")
+		expectRendered(`<%= "%05.1f|%-4s|%d" % [3.14159, 'ab', 7] %>`, "003.1|ab  |7")
+		expectRendered(`<%= format('%s-%s', 'a', 1) %> <%= "x" * 3 %> <%= 'a,b'.include?(',') %>`, "a-1 xxx true")
+		expectRendered(`<%= "10.0.0.1" =~ /^10\./ ? 'private' : 'public' %>`, "private")
+		expectRendered(`<% if "v1.2" =~ /v(\d+)\.(\d+)/ %><%= $1 %><% end %>`, "1")
+	})
+
+	It("supports mutable strings", func() {
+		expectRendered(`<% s = "a".dup; s << "b" %><%= s %>`, "ab")
+		expectRendered(`<% a = "x"; b = a; a << "y" %><%= b %>`, "xy")
+		expectRendered(`<% parts = "a b".split; parts.each { |x| x << "!" } %><%= parts.join %>`, "a!b!")
+		expectRendered(`<% s = "abc"; s.upcase! %><%= s %> <%= "ABC".upcase!.inspect %>`, "ABC nil")
+		expectRendered(`<%= "abc".index("c", 10).inspect %> <%= "abcabc".index("a", 1) %> <%= "abcabc".rindex("a", 2) %>`, "nil 3 0")
+	})
+
+	It("supports hashes with default blocks", func() {
+		expectRendered(`<% h = Hash.new { |hash, k| hash[k] = [] }; h['a'] << 1 %><%= h.inspect %>`, `{"a"=>[1]}`)
+	})
+
+	It("supports JSON and YAML", func() {
+		expectRendered(`<%= JSON.dump(p('tls')) %>`, `{"enabled":true,"cert":"CERT","key":null}`)
+		expectRendered(`<%= JSON.dump('str') %> <%= p('users').first.to_json %>`, `"str" {"name":"admin","groups":["a","b"]}`)
+		expectRendered(`<%= JSON.pretty_generate({'a' => [1]}) %>`, "{\n  \"a\": [\n    1\n  ]\n}")
+		expectRendered(`<%= p('tls').to_yaml %>`, "---\nenabled: true\ncert: CERT\nkey: \n")
+		expectRendered(`<%= {a: 1, 'b' => nil}.inspect %>`, `{:a=>1, "b"=>nil}`)
+	})
+
+	It("supports control flow", func() {
+		expectRendered(`<% case p('port') when 80 then %>http<% when 8080, 8443 %>alt<% else %>other<% end %>`, "alt")
+		expectRendered(`<%= p('port') > 1024 ? 'high' : 'low' %> <%= 'yes' unless p('empty').empty? %>|`, "high |")
+		expectRendered(`<% begin; raise 'boom'; rescue => e %><%= e.message %><% end %>`, "boom")
+		expectRendered(`<% [1, 2, 3].each do |i| next if i == 2 %><%= i %><% end %>`, "13")
+		expectRendered(`<% x = p('tls.key', nil) || 'none' %><%= x %> <%= defined?(x) %>`, "none local-variable")
+	})
+
+	It("supports links", func() {
+		context = jsonContext(`{
+			"job": {"name": "fake-job"}, "index": 1,
+			"job_properties": {}, "default_properties": {},
+			"links": {"db": {"address": "db.bosh", "properties": {"port": 5432},
+				"instances": [{"name": "db", "index": 0, "address": "10.0.0.9", "bootstrap": true}]}}
+		}`)
+
+		expectRendered(`<%= link('db').p('port') %> <%= link('db').address %> <%= link('db').instances.map(&:address).join %>`, "5432 db.bosh 10.0.0.9")
+		expectRendered(`<% if_link('db') do |db| %><%= db.instances.first.bootstrap %><% end %>`, "true")
+		expectRendered(`<% if_link('cache') do |c| %>c<% end.else do %>no-cache<% end %>`, "no-cache")
+		expectError(`<%= link('cache') %>`, "Can't find link 'cache'")
+	})
+
+	It("returns helpful errors", func() {
+		expectError("\n\n<%= nope %>", "(line 3: #<NameError: undefined local variable or method `nope' for an instance of TemplateEvaluationContext>)")
+		expectError("<%= nil.upcase %>", "#<NoMethodError: undefined method `upcase' for nil>")
+		expectError("<%= 1 / 0 %>", "#<ZeroDivisionError: divided by 0>")
+		expectError("<% if true %>", "SyntaxError")
+		expectError("<%= 'a' + 1 %>", "#<TypeError: no implicit conversion of Integer into String>")
+	})
+
+	It("returns errors for invalid arguments", func() {
+		expectRendered(`<%= 0.times.to_a.inspect %> <%= -1.times.to_a.inspect %>`, "[] []")
+		expectError("<%= [1].last(-1) %>", "#<ArgumentError: negative array size>")
+		expectError("<%= [1].drop(-1) %>", "#<ArgumentError: attempt to drop negative size>")
+		expectError("<%= [1].insert(-10, 0) %>", "#<IndexError: index -10 too small for array; minimum: -2>")
+		expectError("<%= 10.to_s(1) %>", "#<ArgumentError: invalid radix 1>")
+		expectError("<%= 10.to_s(37) %>", "#<ArgumentError: invalid radix 37>")
+		expectError("<%= 2 ** 64 %>", "RangeError")
+		expectError("<%= (0.0 / 0).to_i %>", "#<FloatDomainError: NaN>")
+	})
+
+	It("does not run commands", func() {
+		expectError("<%= `id` %>", "#<NotImplementedError: running commands is not supported by built-in renderer>")
+		expectError("<%= system('id') %>", "NotImplementedError")
+	})
+
+	Context("when reading template fails", func() {
+		It("returns an error", func() {
+			err := fs.WriteFileString("/src", "text")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.ReadFileError = errors.New("fake-read-error")
+			err = erbRenderer.Render("/src", "/dst", context)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+		})
+	})
+
+	Context("when writing rendered template fails", func() {
+		It("returns an error", func() {
+			err := fs.WriteFileString("/src", "text")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileError = errors.New("fake-write-error")
+			err = erbRenderer.Render("/src", "/dst", context)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+})
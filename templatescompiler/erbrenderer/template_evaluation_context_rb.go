@@ -44,6 +44,7 @@ class TemplateEvaluationContext
     @properties = openstruct(properties)
     @raw_properties = properties
     @spec = openstruct(spec)
+    @links = spec['links']
   end
 
   def get_binding
@@ -73,8 +74,23 @@ class TemplateEvaluationContext
     InactiveElseBlock.new
   end
   
+  def link(name)
+    link_spec = lookup_link(name)
+    raise UnknownLink.new(name) if link_spec.nil?
+
+    EvaluationLink.new(link_spec)
+  end
+
+  # Links are only available when they were explicitly provided
+  # (e.g. stubbed when rendering templates locally)
   def if_link(name)
-    false
+    return false if @links.nil?
+
+    link_spec = lookup_link(name)
+    return ActiveElseBlock.new(self) if link_spec.nil?
+
+    yield EvaluationLink.new(link_spec)
+    InactiveElseBlock.new
   end
 
   private
@@ -122,6 +138,11 @@ class TemplateEvaluationContext
     ref
   end
 
+  def lookup_link(name)
+    return nil if @links.nil?
+    @links[name]
+  end
+
   class UnknownProperty < StandardError
     attr_reader :name
 
@@ -131,6 +152,84 @@ class TemplateEvaluationContext
     end
   end
 
+  class UnknownLink < StandardError
+    attr_reader :name
+
+    def initialize(name)
+      @name = name
+      super("Can't find link '#{name}'")
+    end
+  end
+
+  class EvaluationLink
+    attr_reader :instances, :properties
+
+    def initialize(link_spec)
+      @properties = link_spec['properties'] || {}
+      @address = link_spec['address']
+      @instances = (link_spec['instances'] || []).map do |instance|
+        EvaluationLinkInstance.new(
+          instance['name'], instance['index'], instance['id'],
+          instance['az'], instance['address'], instance['bootstrap']
+        )
+      end
+    end
+
+    def address(*_args)
+      @address
+    end
+
+    def p(*args)
+      names = Array(args[0])
+
+      names.each do |name|
+        result = lookup_property(@properties, name)
+        return result unless result.nil?
+      end
+
+      return args[1] if args.length == 2
+      raise UnknownProperty.new(names)
+    end
+
+    def if_p(*names)
+      values = names.map do |name|
+        value = lookup_property(@properties, name)
+        return ActiveElseBlock.new(self) if value.nil?
+        value
+      end
+
+      yield *values
+      InactiveElseBlock.new
+    end
+
+    private
+
+    def lookup_property(collection, name)
+      keys = name.split(".")
+      ref = collection
+
+      keys.each do |key|
+        ref = ref[key]
+        return nil if ref.nil?
+      end
+
+      ref
+    end
+  end
+
+  class EvaluationLinkInstance
+    attr_reader :name, :index, :id, :az, :address, :bootstrap
+
+    def initialize(name, index, id, az, address, bootstrap)
+      @name = name
+      @index = index
+      @id = id
+      @az = az
+      @address = address
+      @bootstrap = bootstrap
+    end
+  end
+
   class ActiveElseBlock
     def initialize(template)
       @context = template
@@ -0,0 +1,127 @@
+package templatescompiler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+)
+
+// InstanceSpec provides instance specific values that
+// are otherwise only known by the Director during a deploy.
+type InstanceSpec struct {
+	Name      string // instance group name
+	Index     int
+	ID        string
+	AZ        string
+	Bootstrap bool
+	Address   string
+
+	// Links are exposed via link() and if_link() in templates;
+	// each link is a hash with optional 'properties', 'instances' and 'address' keys
+	Links map[string]interface{}
+
+	// Spec is deep merged over the generated spec (e.g. to provide networks)
+	Spec map[string]interface{}
+}
+
+type instanceEvaluationContext struct {
+	context bierbrenderer.TemplateEvaluationContext
+	spec    InstanceSpec
+}
+
+func NewInstanceEvaluationContext(
+	context bierbrenderer.TemplateEvaluationContext,
+	spec InstanceSpec,
+) bierbrenderer.TemplateEvaluationContext {
+	return instanceEvaluationContext{context: context, spec: spec}
+}
+
+func (ec instanceEvaluationContext) MarshalJSON() ([]byte, error) {
+	baseBytes, err := ec.context.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]interface{}
+
+	err = json.Unmarshal(baseBytes, &root)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling job eval context")
+	}
+
+	root["name"] = ec.spec.Name
+	root["index"] = ec.spec.Index
+	root["az"] = ec.spec.AZ
+	root["bootstrap"] = ec.spec.Bootstrap
+
+	if len(ec.spec.ID) > 0 {
+		root["id"] = ec.spec.ID
+	}
+
+	if len(ec.spec.Address) > 0 {
+		root["address"] = ec.spec.Address
+	}
+
+	links := map[string]interface{}{}
+
+	for name, link := range ec.spec.Links {
+		links[name] = ec.stringKeys(link)
+	}
+
+	root["links"] = links
+
+	if overrides, ok := ec.stringKeys(ec.spec.Spec).(map[string]interface{}); ok {
+		root = ec.merge(root, overrides)
+	}
+
+	jsonBytes, err := json.Marshal(root)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Marshalling instance eval context")
+	}
+
+	return jsonBytes, nil
+}
+
+func (ec instanceEvaluationContext) merge(base, override map[string]interface{}) map[string]interface{} {
+	for key, val := range override {
+		baseVal, baseIsHash := base[key].(map[string]interface{})
+		overrideVal, overrideIsHash := val.(map[string]interface{})
+
+		if baseIsHash && overrideIsHash {
+			base[key] = ec.merge(baseVal, overrideVal)
+		} else {
+			base[key] = val
+		}
+	}
+
+	return base
+}
+
+// stringKeys converts hashes produced by YAML unmarshalling so that they can be marshalled to JSON
+func (ec instanceEvaluationContext) stringKeys(obj interface{}) interface{} {
+	switch typedObj := obj.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, val := range typedObj {
+			result[fmt.Sprintf("%v", key)] = ec.stringKeys(val)
+		}
+		return result
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, val := range typedObj {
+			result[key] = ec.stringKeys(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typedObj))
+		for i, val := range typedObj {
+			result[i] = ec.stringKeys(val)
+		}
+		return result
+	default:
+		return obj
+	}
+}
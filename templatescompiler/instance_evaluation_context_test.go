@@ -0,0 +1,188 @@
+package templatescompiler_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshreljob "github.com/cloudfoundry/bosh-cli/release/job"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	. "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+)
+
+var _ = Describe("InstanceEvaluationContext", func() {
+	var (
+		releaseJob *boshreljob.Job
+		uuidGen    *fakeuuid.FakeGenerator
+		spec       InstanceSpec
+	)
+
+	BeforeEach(func() {
+		releaseJob = boshreljob.NewJob(NewResource("fake-job-name", "", nil))
+		releaseJob.Properties = map[string]boshreljob.PropertyDefinition{
+			"prop": boshreljob.PropertyDefinition{Default: "spec-default"},
+		}
+
+		uuidGen = fakeuuid.NewFakeGenerator()
+		uuidGen.GeneratedUUID = "fake-uuid"
+
+		spec = InstanceSpec{
+			Name:      "fake-ig",
+			Index:     2,
+			AZ:        "z2",
+			Bootstrap: false,
+		}
+	})
+
+	buildContext := func() bierbrenderer.TemplateEvaluationContext {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		jobContext := NewJobEvaluationContext(
+			*releaseJob, nil, biproperty.Map{}, biproperty.Map{}, "fake-deployment-name", "", uuidGen, logger)
+
+		return NewInstanceEvaluationContext(jobContext, spec)
+	}
+
+	act := func() map[string]interface{} {
+		generatedJSON, err := buildContext().MarshalJSON()
+		Expect(err).ToNot(HaveOccurred())
+
+		var generatedContext map[string]interface{}
+
+		err = json.Unmarshal(generatedJSON, &generatedContext)
+		Expect(err).ToNot(HaveOccurred())
+
+		return generatedContext
+	}
+
+	It("includes instance specific values", func() {
+		generatedContext := act()
+		Expect(generatedContext["name"]).To(Equal("fake-ig"))
+		Expect(generatedContext["index"]).To(Equal(float64(2)))
+		Expect(generatedContext["az"]).To(Equal("z2"))
+		Expect(generatedContext["bootstrap"]).To(Equal(false))
+		Expect(generatedContext["id"]).To(Equal("fake-uuid"))
+		Expect(generatedContext["deployment"]).To(Equal("fake-deployment-name"))
+		Expect(generatedContext["default_properties"]).To(Equal(map[string]interface{}{"prop": "spec-default"}))
+	})
+
+	It("uses given id and address", func() {
+		spec.ID = "given-id"
+		spec.Address = "given-address"
+
+		generatedContext := act()
+		Expect(generatedContext["id"]).To(Equal("given-id"))
+		Expect(generatedContext["address"]).To(Equal("given-address"))
+	})
+
+	It("includes empty links if links are not given", func() {
+		generatedContext := act()
+		Expect(generatedContext["links"]).To(Equal(map[string]interface{}{}))
+	})
+
+	It("includes links with YAML hashes converted for JSON", func() {
+		spec.Links = map[string]interface{}{
+			"db": map[interface{}]interface{}{
+				"properties": map[interface{}]interface{}{"port": 5432},
+				"instances":  []interface{}{map[interface{}]interface{}{"address": "10.0.0.1"}},
+			},
+		}
+
+		generatedContext := act()
+		Expect(generatedContext["links"]).To(Equal(map[string]interface{}{
+			"db": map[string]interface{}{
+				"properties": map[string]interface{}{"port": float64(5432)},
+				"instances":  []interface{}{map[string]interface{}{"address": "10.0.0.1"}},
+			},
+		}))
+	})
+
+	It("deep merges given spec over generated spec", func() {
+		spec.Spec = map[string]interface{}{
+			"networks": map[interface{}]interface{}{
+				"default": map[interface{}]interface{}{"ip": "10.0.0.5"},
+				"private": map[interface{}]interface{}{"ip": "192.168.0.5"},
+			},
+			"index": 5,
+		}
+
+		generatedContext := act()
+		Expect(generatedContext["index"]).To(Equal(float64(5)))
+		Expect(generatedContext["networks"]).To(Equal(map[string]interface{}{
+			"default": map[string]interface{}{"ip": "10.0.0.5", "netmask": "", "gateway": ""},
+			"private": map[string]interface{}{"ip": "192.168.0.5"},
+		}))
+	})
+
+	Context("when rendering templates", func() {
+		render := func(erbContents string) (string, error) {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			fs := boshsys.NewOsFileSystem(logger)
+			erbRenderer := bierbrenderer.NewERBRenderer(fs, boshsys.NewExecCmdRunner(logger), logger)
+
+			srcFile, err := ioutil.TempFile("", "source.txt.erb")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(srcFile.Name())
+
+			_, err = srcFile.WriteString(erbContents)
+			Expect(err).ToNot(HaveOccurred())
+
+			destFile, err := fs.TempFile("dest.txt")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(destFile.Close()).ToNot(HaveOccurred())
+			defer os.Remove(destFile.Name())
+
+			err = erbRenderer.Render(srcFile.Name(), destFile.Name(), buildContext())
+			if err != nil {
+				return "", err
+			}
+
+			contents, err := ioutil.ReadFile(destFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+
+			return string(contents), nil
+		}
+
+		BeforeEach(func() {
+			spec.Links = map[string]interface{}{
+				"db": map[interface{}]interface{}{
+					"properties": map[interface{}]interface{}{"port": 5432},
+					"instances":  []interface{}{map[interface{}]interface{}{"address": "10.0.0.1", "index": 0}},
+					"address":    "db.internal",
+				},
+			}
+		})
+
+		It("exposes stubbed links via link()", func() {
+			contents, err := render(`<%= link('db').p('port') %> <%= link('db').instances.map(&:address).join(',') %> <%= link('db').address %>`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("5432 10.0.0.1 db.internal"))
+		})
+
+		It("exposes stubbed links via if_link()", func() {
+			contents, err := render(`<% if_link('db') do |db| %>found-<%= db.p('port') %><% end.else do %>missing<% end %>` +
+				`<% if_link('other') do |other| %>found<% end.else do %>-missing<% end %>`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("found-5432-missing"))
+		})
+
+		It("exposes instance values via spec", func() {
+			contents, err := render(`<%= spec.name %>/<%= spec.index %> <%= spec.az %> <%= spec.bootstrap %>`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-ig/2 z2 false"))
+		})
+
+		It("returns error if link is not found", func() {
+			_, err := render(`<%= link('other').p('port') %>`)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -15,6 +15,7 @@ import (
 
 type JobRenderer interface {
 	Render(releaseJob bireljob.Job, releaseJobProperties *biproperty.Map, jobProperties biproperty.Map, globalProperties biproperty.Map, deploymentName string, address string) (RenderedJob, error)
+	RenderWithContext(releaseJob bireljob.Job, context bierbrenderer.TemplateEvaluationContext) (RenderedJob, error)
 }
 
 type jobRenderer struct {
@@ -43,6 +44,10 @@ func NewJobRenderer(
 func (r *jobRenderer) Render(releaseJob bireljob.Job, releaseJobProperties *biproperty.Map, jobProperties biproperty.Map, globalProperties biproperty.Map, deploymentName string, address string) (RenderedJob, error) {
 	context := NewJobEvaluationContext(releaseJob, releaseJobProperties, jobProperties, globalProperties, deploymentName, address, r.uuidGen, r.logger)
 
+	return r.RenderWithContext(releaseJob, context)
+}
+
+func (r *jobRenderer) RenderWithContext(releaseJob bireljob.Job, context bierbrenderer.TemplateEvaluationContext) (RenderedJob, error) {
 	sourcePath := releaseJob.ExtractedPath()
 
 	destinationPath, err := r.fs.TempDir("rendered-jobs")
@@ -121,4 +121,40 @@ var _ = Describe("JobRenderer", func() {
 			})
 		})
 	})
+
+	Describe("RenderWithContext", func() {
+		It("renders job templates with given context", func() {
+			instanceContext := NewInstanceEvaluationContext(context, InstanceSpec{Name: "fake-ig", Index: 1})
+
+			fakeERBRenderer.SetRenderBehavior(
+				filepath.Join(srcPath, "templates/director.yml.erb"),
+				filepath.Join(dstPath, "config/director.yml"),
+				instanceContext,
+				nil,
+			)
+
+			fakeERBRenderer.SetRenderBehavior(
+				filepath.Join(srcPath, "monit"),
+				filepath.Join(dstPath, "monit"),
+				instanceContext,
+				nil,
+			)
+
+			renderedjob, err := jobRenderer.RenderWithContext(*job, instanceContext)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeERBRenderer.RenderInputs).To(Equal([]fakebirender.RenderInput{
+				{
+					SrcPath: filepath.Join(srcPath, "templates/director.yml.erb"),
+					DstPath: filepath.Join(renderedjob.Path(), "config/director.yml"),
+					Context: instanceContext,
+				},
+				{
+					SrcPath: filepath.Join(srcPath, "monit"),
+					DstPath: filepath.Join(renderedjob.Path(), "monit"),
+					Context: instanceContext,
+				},
+			}))
+		})
+	})
 })
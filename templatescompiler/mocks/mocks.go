@@ -6,6 +6,7 @@ package mocks
 import (
 	job "github.com/cloudfoundry/bosh-cli/release/job"
 	templatescompiler "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	erbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
 	property "github.com/cloudfoundry/bosh-utils/property"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -42,6 +43,17 @@ func (_mr *_MockJobRendererRecorder) Render(arg0, arg1, arg2, arg3, arg4, arg5 i
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Render", arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+func (_m *MockJobRenderer) RenderWithContext(_param0 job.Job, _param1 erbrenderer.TemplateEvaluationContext) (templatescompiler.RenderedJob, error) {
+	ret := _m.ctrl.Call(_m, "RenderWithContext", _param0, _param1)
+	ret0, _ := ret[0].(templatescompiler.RenderedJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockJobRendererRecorder) RenderWithContext(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RenderWithContext", arg0, arg1)
+}
+
 // Mock of JobListRenderer interface
 type MockJobListRenderer struct {
 	ctrl     *gomock.Controller
@@ -48,6 +48,10 @@ func (ui *ConfUI) EnableJSON() {
 	ui.parent = NewJSONUI(ui.parent, ui.logger)
 }
 
+func (ui *ConfUI) EnableJSONStream() {
+	ui.parent = NewJSONStreamUI(ui.parent)
+}
+
 func (ui *ConfUI) ShowColumns(columns []Header) {
 	ui.showColumns = columns
 }
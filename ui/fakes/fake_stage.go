@@ -1,12 +1,16 @@
 package fakes
 
 import (
+	"sync"
+
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
 type FakeStage struct {
 	PerformCalls []*PerformCall
 	SubStages    []*FakeStage
+
+	performLock sync.Mutex
 }
 
 type PerformCall struct {
@@ -24,14 +28,19 @@ func (s *FakeStage) Perform(name string, closure func() error) error {
 
 	call := &PerformCall{Name: name}
 
+	s.performLock.Lock()
 	// lazily instantiate to make matching sub-stages easier
 	if s.PerformCalls == nil {
 		s.PerformCalls = []*PerformCall{}
 	}
 	s.PerformCalls = append(s.PerformCalls, call) //We want to record the calls in the same order as the real implementation would print them
+	s.performLock.Unlock()
 
 	err := closure()
 
+	s.performLock.Lock()
+	defer s.performLock.Unlock()
+
 	call.Error = err
 	if err != nil {
 		if skipErr, isSkipError := err.(biui.SkipStageError); isSkipError {
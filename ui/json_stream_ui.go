@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// JSONStreamUI keeps stdout reserved for blocks (e.g. newline-delimited JSON
+// task events) and moves all human oriented output to stderr.
+type JSONStreamUI struct {
+	parent UI
+
+	partialLine string
+}
+
+func NewJSONStreamUI(parent UI) *JSONStreamUI {
+	return &JSONStreamUI{parent: parent}
+}
+
+func (ui *JSONStreamUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.parent.ErrorLinef(pattern, args...)
+}
+
+func (ui *JSONStreamUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.parent.ErrorLinef(pattern, args...)
+}
+
+func (ui *JSONStreamUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.partialLine += fmt.Sprintf(pattern, args...)
+}
+
+func (ui *JSONStreamUI) EndLinef(pattern string, args ...interface{}) {
+	line := ui.partialLine + fmt.Sprintf(pattern, args...)
+	ui.partialLine = ""
+	ui.parent.ErrorLinef("%s", line)
+}
+
+func (ui *JSONStreamUI) PrintBlock(block []byte) { ui.parent.PrintBlock(block) }
+
+func (ui *JSONStreamUI) PrintErrorBlock(block string) {
+	ui.parent.ErrorLinef("%s", strings.TrimSuffix(block, "\n"))
+}
+
+func (ui *JSONStreamUI) PrintTable(table Table) {
+	buf := bytes.NewBufferString("")
+
+	err := table.Print(buf)
+	if err != nil {
+		ui.parent.ErrorLinef("Failed to print table: %s", err)
+		return
+	}
+
+	ui.parent.ErrorLinef("%s", strings.TrimSuffix(buf.String(), "\n"))
+}
+
+func (ui *JSONStreamUI) AskForText(label string) (string, error) {
+	return ui.parent.AskForText(label)
+}
+
+func (ui *JSONStreamUI) AskForChoice(label string, options []string) (int, error) {
+	return ui.parent.AskForChoice(label, options)
+}
+
+func (ui *JSONStreamUI) AskForPassword(label string) (string, error) {
+	return ui.parent.AskForPassword(label)
+}
+
+func (ui *JSONStreamUI) AskForConfirmation() error {
+	return ui.parent.AskForConfirmation()
+}
+
+func (ui *JSONStreamUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+func (ui *JSONStreamUI) Flush() {
+	if len(ui.partialLine) > 0 {
+		ui.EndLinef("")
+	}
+
+	ui.parent.Flush()
+}
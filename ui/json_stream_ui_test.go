@@ -0,0 +1,85 @@
+package ui_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("JSONStreamUI", func() {
+	var (
+		parentUI *fakeui.FakeUI
+		ui       UI
+	)
+
+	BeforeEach(func() {
+		parentUI = &fakeui.FakeUI{}
+		ui = NewJSONStreamUI(parentUI)
+	})
+
+	Describe("ErrorLinef/PrintLinef", func() {
+		It("prints lines as errors", func() {
+			ui.ErrorLinef("fake-line1")
+			ui.PrintLinef("fake-line2 %d", 2)
+			Expect(parentUI.Said).To(BeEmpty())
+			Expect(parentUI.Errors).To(Equal([]string{"fake-line1", "fake-line2 2"}))
+		})
+	})
+
+	Describe("BeginLinef/EndLinef", func() {
+		It("joins line parts and prints them as a single error line", func() {
+			ui.BeginLinef("fake-begin %d", 1)
+			ui.BeginLinef(" fake-middle")
+			Expect(parentUI.Errors).To(BeEmpty())
+
+			ui.EndLinef(" fake-end")
+			Expect(parentUI.Said).To(BeEmpty())
+			Expect(parentUI.Errors).To(Equal([]string{"fake-begin 1 fake-middle fake-end"}))
+		})
+
+		It("prints unfinished line when flushed", func() {
+			ui.BeginLinef("fake-begin")
+			ui.Flush()
+			Expect(parentUI.Errors).To(Equal([]string{"fake-begin"}))
+			Expect(parentUI.Flushed).To(BeTrue())
+		})
+	})
+
+	Describe("PrintBlock", func() {
+		It("delegates to the parent UI", func() {
+			ui.PrintBlock([]byte("{\"type\":\"event\"}\n"))
+			Expect(parentUI.Blocks).To(Equal([]string{"{\"type\":\"event\"}\n"}))
+			Expect(parentUI.Errors).To(BeEmpty())
+		})
+	})
+
+	Describe("PrintErrorBlock", func() {
+		It("prints block as an error line", func() {
+			ui.PrintErrorBlock("fake-block\n")
+			Expect(parentUI.Blocks).To(BeEmpty())
+			Expect(parentUI.Errors).To(Equal([]string{"fake-block"}))
+		})
+	})
+
+	Describe("PrintTable", func() {
+		It("prints table as an error line", func() {
+			ui.PrintTable(Table{
+				Header: []Header{NewHeader("header1")},
+				Rows:   [][]Value{{ValueString{S: "r1c1"}}},
+			})
+			Expect(parentUI.Table).To(Equal(Table{}))
+			Expect(parentUI.Errors).To(HaveLen(1))
+			Expect(parentUI.Errors[0]).To(ContainSubstring("r1c1"))
+		})
+	})
+
+	Describe("IsInteractive", func() {
+		It("delegates to the parent UI", func() {
+			parentUI.Interactive = true
+			Expect(ui.IsInteractive()).To(BeTrue())
+		})
+	})
+})
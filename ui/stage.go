@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -21,6 +23,12 @@ type stage struct {
 	logger boshlog.Logger
 
 	simpleMode bool
+
+	// lock guards output of steps performed concurrently
+	lock     *sync.Mutex
+	running  int
+	lastStep int
+	openStep int
 }
 
 func NewStage(ui UI, timeService clock.Clock, logger boshlog.Logger) Stage {
@@ -32,32 +40,71 @@ func NewStage(ui UI, timeService clock.Clock, logger boshlog.Logger) Stage {
 		logger: logger,
 
 		simpleMode: true,
+
+		lock: &sync.Mutex{},
 	}
 }
 
+// Perform may be called concurrently; while multiple steps are running
+// each of them is printed on its own line once started and once finished
 func (s *stage) Perform(name string, closure func() error) error {
-	if !s.simpleMode {
-		// enter simple mode (only line break if exiting complex mode)
-		s.ui.BeginLinef("\n")
-		s.simpleMode = true
-	}
-
-	s.ui.BeginLinef("%s...", name)
+	step := s.beginStep(name)
 	startTime := s.timeService.Now()
 	err := closure()
 	if err != nil {
 		if skipErr, ok := err.(SkipStageError); ok {
-			s.ui.EndLinef(" Skipped [%s] (%s)", skipErr.SkipMessage(), s.elapsedSince(startTime))
+			s.endStep(step, name, fmt.Sprintf("Skipped [%s] (%s)", skipErr.SkipMessage(), s.elapsedSince(startTime)))
 			s.logger.Info("Skipped stage '%s': %s", name, skipErr.Error())
 			return nil
 		}
-		s.ui.EndLinef(" Failed (%s)", s.elapsedSince(startTime))
+		s.endStep(step, name, fmt.Sprintf("Failed (%s)", s.elapsedSince(startTime)))
 		return err
 	}
-	s.ui.EndLinef(" Finished (%s)", s.elapsedSince(startTime))
+	s.endStep(step, name, fmt.Sprintf("Finished (%s)", s.elapsedSince(startTime)))
 	return nil
 }
 
+func (s *stage) beginStep(name string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.simpleMode {
+		// enter simple mode (only line break if exiting complex mode)
+		s.ui.BeginLinef("\n")
+		s.simpleMode = true
+	}
+
+	s.lastStep++
+	s.running++
+
+	if s.running > 1 {
+		if s.openStep != 0 {
+			s.ui.EndLinef(" Started")
+			s.openStep = 0
+		}
+		s.ui.BeginLinef("%s... Started\n", name)
+	} else {
+		s.ui.BeginLinef("%s...", name)
+		s.openStep = s.lastStep
+	}
+
+	return s.lastStep
+}
+
+func (s *stage) endStep(step int, name string, result string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.running--
+
+	if s.openStep == step {
+		s.ui.EndLinef(" %s", result)
+		s.openStep = 0
+	} else {
+		s.ui.BeginLinef("%s... %s\n", name, result)
+	}
+}
+
 func (s *stage) PerformComplex(name string, closure func(Stage) error) error {
 	// exit simple mode (always line break when entering a new complex stage)
 	s.ui.BeginLinef("\n")
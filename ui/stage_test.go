@@ -90,6 +90,28 @@ var _ = Describe("Stage", func() {
 			Expect(logOutBuffer.String()).To(ContainSubstring("fake-skip-message: fake-skip-error"))
 			Expect(actionsPerformed).To(Equal([]string{"1"}))
 		})
+
+		It("prints overlapping stages on separate lines", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				fakeTimeService.Increment(time.Minute)
+
+				return stage.Perform("Simple stage 2", func() error {
+					fakeTimeService.Increment(time.Minute)
+					return nil
+				})
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = stage.Perform("Simple stage 3", func() error { return nil })
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedOutput := "Simple stage 1... Started\n" +
+				"Simple stage 2... Started\n" +
+				"Simple stage 2... Finished (00:01:00)\n" +
+				"Simple stage 1... Finished (00:02:00)\n" +
+				"Simple stage 3... Finished (00:00:00)\n"
+			Expect(uiOut.String()).To(Equal(expectedOutput))
+		})
 	})
 
 	Describe("PerformComplex", func() {
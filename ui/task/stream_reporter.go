@@ -0,0 +1,155 @@
+package task
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const (
+	StreamEventTypeTaskStarted  = "task_started"
+	StreamEventTypeTaskFinished = "task_finished"
+	StreamEventTypeEvent        = "event"
+	StreamEventTypeOutput       = "output"
+)
+
+// StreamEvent is a single line of newline-delimited JSON output
+type StreamEvent struct {
+	Type   string `json:"type"`
+	TaskID int    `json:"task_id"`
+
+	Time int64 `json:"time,omitempty"`
+
+	EventType string `json:"event_type,omitempty"` // e.g. "deprecation"
+	Message   string `json:"message,omitempty"`
+
+	Stage string   `json:"stage,omitempty"`
+	Task  string   `json:"task,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+
+	// Pointers distinguish zero values (e.g. index 0) from absent ones
+	Index    *int `json:"index,omitempty"`
+	Total    *int `json:"total,omitempty"`
+	Progress *int `json:"progress,omitempty"`
+
+	State string `json:"state,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	Output string `json:"output,omitempty"`
+}
+
+// StreamReporter emits one JSON object per line for each task event
+// instead of formatting events for humans.
+type StreamReporter struct {
+	ui          boshui.UI
+	isForEvents bool
+
+	outputRest map[int]string
+	sync.Mutex
+}
+
+func NewStreamReporter(ui boshui.UI, isForEvents bool) *StreamReporter {
+	return &StreamReporter{ui: ui, isForEvents: isForEvents, outputRest: map[int]string{}}
+}
+
+func (r *StreamReporter) TaskStarted(id int) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.emit(StreamEvent{Type: StreamEventTypeTaskStarted, TaskID: id})
+}
+
+func (r *StreamReporter) TaskFinished(id int, state string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if rest := r.outputRest[id]; len(rest) > 0 {
+		r.showEvent(id, rest)
+		delete(r.outputRest, id)
+	}
+
+	r.emit(StreamEvent{Type: StreamEventTypeTaskFinished, TaskID: id, State: state})
+}
+
+func (r *StreamReporter) TaskOutputChunk(id int, chunk []byte) {
+	r.Lock()
+	defer r.Unlock()
+
+	if !r.isForEvents {
+		r.emit(StreamEvent{Type: StreamEventTypeOutput, TaskID: id, Output: string(chunk)})
+		return
+	}
+
+	rest := r.outputRest[id] + string(chunk)
+
+	for {
+		idx := strings.Index(rest, "\n")
+		if idx == -1 {
+			break
+		}
+		if len(rest[0:idx]) > 0 {
+			r.showEvent(id, rest[0:idx])
+		}
+		rest = rest[idx+1:]
+	}
+
+	r.outputRest[id] = rest
+}
+
+func (r *StreamReporter) showEvent(id int, str string) {
+	event := Event{TaskID: id}
+
+	err := json.Unmarshal([]byte(str), &event)
+	if err != nil {
+		// Pass through lines that are not events so that nothing is lost
+		r.emit(StreamEvent{Type: StreamEventTypeOutput, TaskID: id, Output: str})
+		return
+	}
+
+	// Event uses plain ints hence presence is checked separately
+	var counts struct {
+		Index    *int `json:"index"`
+		Total    *int `json:"total"`
+		Progress *int `json:"progress"`
+	}
+
+	_ = json.Unmarshal([]byte(str), &counts)
+
+	streamEvent := StreamEvent{
+		Type:   StreamEventTypeEvent,
+		TaskID: id,
+		Time:   event.UnixTime,
+
+		EventType: event.Type,
+		Message:   event.Message,
+
+		Stage: event.Stage,
+		Task:  event.Task,
+		Tags:  event.Tags,
+
+		Index:    counts.Index,
+		Total:    counts.Total,
+		Progress: counts.Progress,
+
+		State: event.State,
+		Error: event.Data.Error,
+	}
+
+	if event.Error != nil {
+		streamEvent.Error = event.Error.Message
+	}
+
+	r.emit(streamEvent)
+}
+
+func (r *StreamReporter) emit(event StreamEvent) {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		r.ui.ErrorLinef("Serializing task %d event: %s", event.TaskID, err)
+		return
+	}
+
+	r.ui.PrintBlock(append(bytes, '\n'))
+}
@@ -0,0 +1,94 @@
+package task_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
+)
+
+var _ = Describe("StreamReporter (not for events)", func() {
+	var (
+		fakeUI   *fakeui.FakeUI
+		reporter boshuit.Reporter
+	)
+
+	BeforeEach(func() {
+		fakeUI = &fakeui.FakeUI{}
+		reporter = boshuit.NewStreamReporter(fakeUI, false)
+	})
+
+	It("prints task start, output and finish as JSON lines", func() {
+		reporter.TaskStarted(123)
+		reporter.TaskOutputChunk(123, []byte("chunk\n"))
+		reporter.TaskFinished(123, "done")
+		Expect(fakeUI.Blocks).To(Equal([]string{
+			`{"type":"task_started","task_id":123}` + "\n",
+			`{"type":"output","task_id":123,"output":"chunk\n"}` + "\n",
+			`{"type":"task_finished","task_id":123,"state":"done"}` + "\n",
+		}))
+	})
+})
+
+var _ = Describe("StreamReporter (for events)", func() {
+	var (
+		fakeUI   *fakeui.FakeUI
+		reporter boshuit.Reporter
+	)
+
+	BeforeEach(func() {
+		fakeUI = &fakeui.FakeUI{}
+		reporter = boshuit.NewStreamReporter(fakeUI, true)
+	})
+
+	It("prints each event as a JSON line", func() {
+		reporter.TaskStarted(123)
+		reporter.TaskOutputChunk(123, []byte(`{"time":1451020321,"stage":"Updating instance","tags":["api"],"total":2,"task":"api/0","index":1,"state":"started","progress":0}
+{"time":1451020322,"stage":"Updating instance","tags":["api"],"total":2,"task":"api/0","index":1,"state":"failed","progress":100,"data":{"error":"'api/0' is not running"}}
+`))
+		reporter.TaskFinished(123, "error")
+
+		Expect(fakeUI.Blocks).To(Equal([]string{
+			`{"type":"task_started","task_id":123}` + "\n",
+			`{"type":"event","task_id":123,"time":1451020321,"stage":"Updating instance","task":"api/0","tags":["api"],"index":1,"total":2,"progress":0,"state":"started"}` + "\n",
+			`{"type":"event","task_id":123,"time":1451020322,"stage":"Updating instance","task":"api/0","tags":["api"],"index":1,"total":2,"progress":100,"state":"failed","error":"'api/0' is not running"}` + "\n",
+			`{"type":"task_finished","task_id":123,"state":"error"}` + "\n",
+		}))
+	})
+
+	It("keeps zero index, total and progress", func() {
+		reporter.TaskOutputChunk(123, []byte(`{"time":1,"stage":"fake-stage","index":0,"total":0,"progress":0}`+"\n"))
+		Expect(fakeUI.Blocks).To(Equal([]string{
+			`{"type":"event","task_id":123,"time":1,"stage":"fake-stage","index":0,"total":0,"progress":0}` + "\n",
+		}))
+	})
+
+	It("includes deprecations, warnings and task errors", func() {
+		reporter.TaskOutputChunk(123, []byte(`{"time":1,"type":"deprecation","message":"fake-deprecation"}
+{"time":2,"error":{"code":100,"message":"fake-error"}}
+`))
+
+		Expect(fakeUI.Blocks).To(Equal([]string{
+			`{"type":"event","task_id":123,"time":1,"event_type":"deprecation","message":"fake-deprecation"}` + "\n",
+			`{"type":"event","task_id":123,"time":2,"error":"fake-error"}` + "\n",
+		}))
+	})
+
+	It("waits for complete lines before printing events", func() {
+		reporter.TaskOutputChunk(123, []byte(`{"time":1,"stage":"fake-`))
+		Expect(fakeUI.Blocks).To(BeEmpty())
+
+		reporter.TaskOutputChunk(123, []byte(`stage"}`+"\n"))
+		Expect(fakeUI.Blocks).To(Equal([]string{
+			`{"type":"event","task_id":123,"time":1,"stage":"fake-stage"}` + "\n",
+		}))
+	})
+
+	It("prints lines that are not events as output", func() {
+		reporter.TaskOutputChunk(123, []byte("not-json\n"))
+		Expect(fakeUI.Blocks).To(Equal([]string{
+			`{"type":"output","task_id":123,"output":"not-json"}` + "\n",
+		}))
+	})
+})
@@ -0,0 +1,330 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshuifmt "github.com/cloudfoundry/bosh-cli/ui/fmt"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+const summarySlowestCount = 5
+
+// SummaryReporter wraps another reporter and prints timing tables
+// for instances found in the event log once the task finishes.
+type SummaryReporter struct {
+	reporter Reporter
+	ui       boshui.UI
+
+	outputRest map[int]string
+	timings    map[int][]*instanceTiming
+	sync.Mutex
+}
+
+type instanceTiming struct {
+	Stage         string
+	InstanceGroup string
+	Instance      string
+	Canary        bool
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+	State      string
+
+	event Event
+}
+
+type instanceGroupTiming struct {
+	Stage         string
+	InstanceGroup string
+
+	Instances int
+	Canaries  int
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Total      time.Duration
+}
+
+func NewSummaryReporter(reporter Reporter, ui boshui.UI) *SummaryReporter {
+	return &SummaryReporter{
+		reporter: reporter,
+		ui:       ui,
+
+		outputRest: map[int]string{},
+		timings:    map[int][]*instanceTiming{},
+	}
+}
+
+func (r *SummaryReporter) TaskStarted(id int) {
+	r.reporter.TaskStarted(id)
+}
+
+func (r *SummaryReporter) TaskOutputChunk(id int, chunk []byte) {
+	r.reporter.TaskOutputChunk(id, chunk)
+
+	r.Lock()
+	defer r.Unlock()
+
+	rest := r.outputRest[id] + string(chunk)
+
+	for {
+		idx := strings.Index(rest, "\n")
+		if idx == -1 {
+			break
+		}
+		if len(rest[0:idx]) > 0 {
+			r.recordEvent(id, rest[0:idx])
+		}
+		rest = rest[idx+1:]
+	}
+
+	r.outputRest[id] = rest
+}
+
+func (r *SummaryReporter) TaskFinished(id int, state string) {
+	r.reporter.TaskFinished(id, state)
+
+	r.Lock()
+	defer r.Unlock()
+
+	timings := r.timings[id]
+	if len(timings) == 0 {
+		return
+	}
+
+	r.ui.PrintTable(r.instancesTable(id, timings))
+	r.ui.PrintTable(r.instanceGroupsTable(id, timings))
+	r.ui.PrintTable(r.slowestTable(id, timings))
+
+	delete(r.timings, id)
+	delete(r.outputRest, id)
+}
+
+func (r *SummaryReporter) recordEvent(id int, str string) {
+	event := Event{TaskID: id}
+
+	err := json.Unmarshal([]byte(str), &event)
+	if err != nil || !event.IsWorthKeeping() || len(event.State) == 0 {
+		return
+	}
+
+	instanceGroup, instance, canary := r.parseInstance(event)
+	if len(instanceGroup) == 0 {
+		return
+	}
+
+	for _, timing := range r.timings[id] {
+		if timing.event.IsSame(event) {
+			timing.State = event.State
+			if event.State != EventStateStarted {
+				timing.FinishedAt = event.Time()
+			}
+			return
+		}
+	}
+
+	timing := &instanceTiming{
+		Stage:         event.Stage,
+		InstanceGroup: instanceGroup,
+		Instance:      instance,
+		Canary:        canary,
+
+		StartedAt: event.Time(),
+		State:     event.State,
+
+		event: event,
+	}
+
+	if event.State != EventStateStarted {
+		timing.FinishedAt = event.Time()
+	}
+
+	r.timings[id] = append(r.timings[id], timing)
+}
+
+// parseInstance extracts instance details from tasks such as 'api/5f8c... (0) (canary)'
+func (r *SummaryReporter) parseInstance(event Event) (string, string, bool) {
+	instance := event.Task
+	canary := strings.HasSuffix(instance, " (canary)")
+	instance = strings.TrimSuffix(instance, " (canary)")
+
+	pieces := strings.SplitN(instance, "/", 2)
+	if len(pieces) != 2 || len(pieces[0]) == 0 || strings.Contains(pieces[0], " ") {
+		return "", "", false
+	}
+
+	if len(event.Tags) > 0 {
+		return strings.Join(event.Tags, ", "), instance, canary
+	}
+
+	return pieces[0], instance, canary
+}
+
+func (r *SummaryReporter) instancesTable(id int, timings []*instanceTiming) boshtbl.Table {
+	table := boshtbl.Table{
+		Title:   fmt.Sprintf("Task %d instance timing", id),
+		Content: "instances",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Stage"),
+			boshtbl.NewHeader("Instance Group"),
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("Canary"),
+			boshtbl.NewHeader("Started At"),
+			boshtbl.NewHeader("Finished At"),
+			boshtbl.NewHeader("Duration"),
+			boshtbl.NewHeader("State"),
+		},
+	}
+
+	for _, timing := range timings {
+		table.Rows = append(table.Rows, r.instanceRow(timing))
+	}
+
+	return table
+}
+
+func (r *SummaryReporter) instanceRow(timing *instanceTiming) []boshtbl.Value {
+	return []boshtbl.Value{
+		boshtbl.NewValueString(timing.Stage),
+		boshtbl.NewValueString(timing.InstanceGroup),
+		boshtbl.NewValueString(timing.Instance),
+		boshtbl.NewValueBool(timing.Canary),
+		boshtbl.NewValueTime(timing.StartedAt),
+		boshtbl.NewValueTime(timing.FinishedAt),
+		boshtbl.NewValueString(r.durationStr(timing)),
+		boshtbl.ValueFmt{
+			V:     boshtbl.NewValueString(timing.State),
+			Error: timing.State == EventStateFailed,
+		},
+	}
+}
+
+func (r *SummaryReporter) instanceGroupsTable(id int, timings []*instanceTiming) boshtbl.Table {
+	table := boshtbl.Table{
+		Title:   fmt.Sprintf("Task %d instance group timing", id),
+		Content: "instance groups",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Stage"),
+			boshtbl.NewHeader("Instance Group"),
+			boshtbl.NewHeader("Instances"),
+			boshtbl.NewHeader("Canaries"),
+			boshtbl.NewHeader("Started At"),
+			boshtbl.NewHeader("Finished At"),
+			boshtbl.NewHeader("Duration"),
+			boshtbl.NewHeader("Average"),
+		},
+	}
+
+	var groups []*instanceGroupTiming
+
+	for _, timing := range timings {
+		var group *instanceGroupTiming
+
+		for _, g := range groups {
+			if g.Stage == timing.Stage && g.InstanceGroup == timing.InstanceGroup {
+				group = g
+				break
+			}
+		}
+
+		if group == nil {
+			group = &instanceGroupTiming{
+				Stage:         timing.Stage,
+				InstanceGroup: timing.InstanceGroup,
+				StartedAt:     timing.StartedAt,
+			}
+			groups = append(groups, group)
+		}
+
+		group.Instances++
+
+		if timing.Canary {
+			group.Canaries++
+		}
+
+		if timing.StartedAt.Before(group.StartedAt) {
+			group.StartedAt = timing.StartedAt
+		}
+
+		if timing.FinishedAt.After(group.FinishedAt) {
+			group.FinishedAt = timing.FinishedAt
+		}
+
+		group.Total += timing.Duration()
+	}
+
+	for _, group := range groups {
+		var duration, average string
+
+		if !group.FinishedAt.IsZero() {
+			duration = boshuifmt.Duration(group.FinishedAt.Sub(group.StartedAt))
+			average = boshuifmt.Duration(group.Total / time.Duration(group.Instances))
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(group.Stage),
+			boshtbl.NewValueString(group.InstanceGroup),
+			boshtbl.NewValueInt(group.Instances),
+			boshtbl.NewValueInt(group.Canaries),
+			boshtbl.NewValueTime(group.StartedAt),
+			boshtbl.NewValueTime(group.FinishedAt),
+			boshtbl.NewValueString(duration),
+			boshtbl.NewValueString(average),
+		})
+	}
+
+	return table
+}
+
+func (r *SummaryReporter) slowestTable(id int, timings []*instanceTiming) boshtbl.Table {
+	table := r.instancesTable(id, nil)
+	table.Title = fmt.Sprintf("Task %d slowest instances", id)
+
+	var finished []*instanceTiming
+
+	for _, timing := range timings {
+		if !timing.FinishedAt.IsZero() {
+			finished = append(finished, timing)
+		}
+	}
+
+	sort.Stable(instanceTimingsByDuration(finished))
+
+	if len(finished) > summarySlowestCount {
+		finished = finished[:summarySlowestCount]
+	}
+
+	for _, timing := range finished {
+		table.Rows = append(table.Rows, r.instanceRow(timing))
+	}
+
+	return table
+}
+
+func (r *SummaryReporter) durationStr(timing *instanceTiming) string {
+	if timing.FinishedAt.IsZero() {
+		return ""
+	}
+	return boshuifmt.Duration(timing.Duration())
+}
+
+func (t instanceTiming) Duration() time.Duration {
+	if t.FinishedAt.IsZero() {
+		return 0
+	}
+	return t.FinishedAt.Sub(t.StartedAt)
+}
+
+type instanceTimingsByDuration []*instanceTiming
+
+func (s instanceTimingsByDuration) Len() int           { return len(s) }
+func (s instanceTimingsByDuration) Less(i, j int) bool { return s[i].Duration() > s[j].Duration() }
+func (s instanceTimingsByDuration) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
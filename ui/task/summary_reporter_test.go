@@ -0,0 +1,134 @@
+package task_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
+	fakeuit "github.com/cloudfoundry/bosh-cli/ui/task/taskfakes"
+)
+
+var _ = Describe("SummaryReporter", func() {
+	var (
+		fakeUI       *fakeui.FakeUI
+		parentReport *fakeuit.FakeReporter
+		reporter     boshuit.Reporter
+	)
+
+	BeforeEach(func() {
+		fakeUI = &fakeui.FakeUI{}
+		parentReport = &fakeuit.FakeReporter{}
+		reporter = boshuit.NewSummaryReporter(parentReport, fakeUI)
+	})
+
+	t := func(sec int64) boshtbl.ValueTime {
+		return boshtbl.NewValueTime(time.Unix(1451020300+sec, 0).UTC())
+	}
+
+	events := `{"time":1451020300,"stage":"Preparing deployment","tags":[],"total":1,"task":"Binding deployment","index":1,"state":"started","progress":0}
+{"time":1451020301,"stage":"Preparing deployment","tags":[],"total":1,"task":"Binding deployment","index":1,"state":"finished","progress":100}
+{"time":1451020302,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-0 (0) (canary)","index":1,"state":"started","progress":0}
+{"time":1451020310,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-0 (0) (canary)","index":1,"state":"finished","progress":100}
+{"time":1451020310,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-1 (1)","index":2,"state":"started","progress":0}
+{"time":1451020310,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-2 (2)","index":3,"state":"started","progress":0}
+{"time":1451020312,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-1 (1)","index":2,"state":"in_progress","progress":50}
+{"time":1451020330,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-1 (1)","index":2,"state":"finished","progress":100}
+{"time":1451020314,"stage":"Updating instance","tags":["api"],"total":3,"task":"api/uuid-2 (2)","index":3,"state":"failed","progress":100,"data":{"error":"fake-err"}}
+`
+
+	It("delegates to the wrapped reporter", func() {
+		reporter.TaskStarted(123)
+		reporter.TaskOutputChunk(123, []byte("chunk"))
+		reporter.TaskFinished(123, "done")
+
+		Expect(parentReport.TaskStartedCallCount()).To(Equal(1))
+		Expect(parentReport.TaskOutputChunkCallCount()).To(Equal(1))
+		Expect(parentReport.TaskFinishedCallCount()).To(Equal(1))
+	})
+
+	It("does not print tables when there are no instance events", func() {
+		reporter.TaskStarted(123)
+		reporter.TaskOutputChunk(123, []byte(`{"time":1451020300,"stage":"Preparing deployment","task":"Binding deployment","state":"started"}`+"\n"))
+		reporter.TaskFinished(123, "done")
+
+		Expect(fakeUI.Tables).To(BeEmpty())
+	})
+
+	It("prints instance, instance group and slowest instance tables", func() {
+		reporter.TaskStarted(123)
+		reporter.TaskOutputChunk(123, []byte(events[0:200]))
+		reporter.TaskOutputChunk(123, []byte(events[200:]))
+		reporter.TaskFinished(123, "error")
+
+		Expect(fakeUI.Tables).To(HaveLen(3))
+
+		Expect(fakeUI.Tables[0].Title).To(Equal("Task 123 instance timing"))
+		Expect(fakeUI.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("Updating instance"),
+				boshtbl.NewValueString("api"),
+				boshtbl.NewValueString("api/uuid-0 (0)"),
+				boshtbl.NewValueBool(true),
+				t(2),
+				t(10),
+				boshtbl.NewValueString("00:00:08"),
+				boshtbl.ValueFmt{V: boshtbl.NewValueString("finished"), Error: false},
+			},
+			{
+				boshtbl.NewValueString("Updating instance"),
+				boshtbl.NewValueString("api"),
+				boshtbl.NewValueString("api/uuid-1 (1)"),
+				boshtbl.NewValueBool(false),
+				t(10),
+				t(30),
+				boshtbl.NewValueString("00:00:20"),
+				boshtbl.ValueFmt{V: boshtbl.NewValueString("finished"), Error: false},
+			},
+			{
+				boshtbl.NewValueString("Updating instance"),
+				boshtbl.NewValueString("api"),
+				boshtbl.NewValueString("api/uuid-2 (2)"),
+				boshtbl.NewValueBool(false),
+				t(10),
+				t(14),
+				boshtbl.NewValueString("00:00:04"),
+				boshtbl.ValueFmt{V: boshtbl.NewValueString("failed"), Error: true},
+			},
+		}))
+
+		Expect(fakeUI.Tables[1].Title).To(Equal("Task 123 instance group timing"))
+		Expect(fakeUI.Tables[1].Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("Updating instance"),
+				boshtbl.NewValueString("api"),
+				boshtbl.NewValueInt(3),
+				boshtbl.NewValueInt(1),
+				t(2),
+				t(30),
+				boshtbl.NewValueString("00:00:28"),
+				boshtbl.NewValueString("00:00:10"),
+			},
+		}))
+
+		Expect(fakeUI.Tables[2].Title).To(Equal("Task 123 slowest instances"))
+		Expect(fakeUI.Tables[2].Rows).To(HaveLen(3))
+		Expect(fakeUI.Tables[2].Rows[0][2]).To(Equal(boshtbl.NewValueString("api/uuid-1 (1)")))
+		Expect(fakeUI.Tables[2].Rows[1][2]).To(Equal(boshtbl.NewValueString("api/uuid-0 (0)")))
+		Expect(fakeUI.Tables[2].Rows[2][2]).To(Equal(boshtbl.NewValueString("api/uuid-2 (2)")))
+	})
+
+	It("leaves finish time empty for instances that did not finish", func() {
+		reporter.TaskOutputChunk(123, []byte(`{"time":1451020302,"stage":"Creating missing vms","task":"db/uuid-0 (0)","state":"started"}`+"\n"))
+		reporter.TaskFinished(123, "cancelled")
+
+		Expect(fakeUI.Tables).To(HaveLen(3))
+		Expect(fakeUI.Tables[0].Rows[0][1]).To(Equal(boshtbl.NewValueString("db")))
+		Expect(fakeUI.Tables[0].Rows[0][5]).To(Equal(boshtbl.ValueTime{}))
+		Expect(fakeUI.Tables[0].Rows[0][6]).To(Equal(boshtbl.NewValueString("")))
+		Expect(fakeUI.Tables[2].Rows).To(BeEmpty())
+	})
+})